@@ -73,6 +73,9 @@ var (
 	generateDelete      = flag.Bool("generate_delete", false, "If set to true, delete methods are generated for YANG lists (Go maps) within the Go code.")
 	generateLeafGetters = flag.Bool("generate_leaf_getters", false, "If set to true, getters for YANG leaves are generated within the Go code. Caution should be exercised when using leaf getters, since values that are explicitly set to the Go default/zero value are not distinguishable from those that are unset when retrieved via the GetXXX method.")
 	includeModelData    = flag.Bool("include_model_data", false, "If set to true, a slice of gNMI ModelData messages are included in the generated Go code containing the details of the input schemas from which the code was generated.")
+	exactDecimal64      = flag.Bool("exact_decimal64", false, "If set to true, YANG decimal64 leaves are generated as the ygot.Decimal64 type, which retains the exact value of the leaf, rather than float64.")
+	generateOrderedMaps = flag.Bool("generate_ordered_maps", false, "If set to true, keyed YANG lists that are ordered-by user are generated as ordered map types, which retain the order of the list's members and provide methods to insert and move members, rather than Go maps.")
+	generateDefaults    = flag.Bool("generate_defaults", false, "If set to true, PopulateDefaults and TrimDefaults methods, which populate and trim the default values of leaves according to the YANG schema, are generated for each struct. They are only generated when the schema is included in the generated code.")
 )
 
 // writeGoCodeSingleFile takes a ygen.GeneratedGoCode struct and writes the Go code
@@ -96,6 +99,11 @@ func writeGoCodeSingleFile(w io.Writer, goCode *ygen.GeneratedGoCode) error {
 	// Write the generated enumeration map out.
 	fmt.Fprintln(w, goCode.EnumMap)
 
+	// Write the generated bits map out if there are bits types.
+	if len(goCode.BitsMap) > 0 {
+		fmt.Fprintln(w, goCode.BitsMap)
+	}
+
 	// Write the schema out if it was received.
 	if len(goCode.JSONSchemaCode) > 0 {
 		fmt.Fprintln(w, goCode.JSONSchemaCode)
@@ -150,6 +158,10 @@ func makeOutputSpec(goCode *ygen.GeneratedGoCode) map[string]codeOut {
 	if emap.Len() != 0 {
 		emap.WriteString("\n")
 	}
+	writeIfNotEmpty(emap, goCode.BitsMap)
+	if len(goCode.BitsMap) != 0 {
+		emap.WriteString("\n")
+	}
 	writeIfNotEmpty(emap, goCode.EnumTypeMap)
 
 	out := map[string]codeOut{
@@ -248,17 +260,20 @@ func main() {
 		PackageName:        *packageName,
 		GenerateJSONSchema: *generateSchema,
 		GoOptions: ygen.GoOpts{
-			YgotImportPath:       *ygotImportPath,
-			YtypesImportPath:     *ytypesImportPath,
-			GoyangImportPath:     *goyangImportPath,
-			GenerateRenameMethod: *generateRename,
-			AddAnnotationFields:  *addAnnotations,
-			AnnotationPrefix:     *annotationPrefix,
-			GenerateGetters:      *generateGetters,
-			GenerateDeleteMethod: *generateDelete,
-			GenerateAppendMethod: *generateAppend,
-			GenerateLeafGetters:  *generateLeafGetters,
-			IncludeModelData:     *includeModelData,
+			YgotImportPath:          *ygotImportPath,
+			YtypesImportPath:        *ytypesImportPath,
+			GoyangImportPath:        *goyangImportPath,
+			GenerateRenameMethod:    *generateRename,
+			AddAnnotationFields:     *addAnnotations,
+			AnnotationPrefix:        *annotationPrefix,
+			GenerateGetters:         *generateGetters,
+			GenerateDeleteMethod:    *generateDelete,
+			GenerateAppendMethod:    *generateAppend,
+			GenerateLeafGetters:     *generateLeafGetters,
+			IncludeModelData:        *includeModelData,
+			ExactDecimal64:          *exactDecimal64,
+			GenerateOrderedMaps:     *generateOrderedMaps,
+			GenerateDefaultsMethods: *generateDefaults,
 		},
 	})
 
@@ -0,0 +1,350 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmitarget
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot/datastore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// defaultSampleInterval is the interval at which a SAMPLE subscription that
+// does not specify a sample interval is sampled.
+const defaultSampleInterval = time.Second
+
+// sample stores the state of a SAMPLE subscription within a STREAM
+// Subscribe RPC.
+type sample struct {
+	// path is the absolute path that is subscribed to.
+	path *gnmipb.Path
+	// interval is the interval at which the path is sampled.
+	interval time.Duration
+	// suppress specifies whether leaves whose value has not changed since
+	// they were last sent are omitted from a sample.
+	suppress bool
+	// heartbeat is the interval after which all leaves are sent, even if
+	// suppress is set. Zero indicates that no heartbeat is sent.
+	heartbeat time.Duration
+
+	// last stores the value of each leaf that was last sent, keyed by the
+	// string form of its path.
+	last map[string]*gnmipb.TypedValue
+	// lastAll is the time at which all leaves were last sent.
+	lastAll time.Time
+}
+
+// newSample returns the sample for the SAMPLE subscription s, whose
+// absolute path is p.
+func newSample(s *gnmipb.Subscription, p *gnmipb.Path) *sample {
+	i := time.Duration(s.GetSampleInterval())
+	if i == 0 {
+		i = defaultSampleInterval
+	}
+	return &sample{
+		path:      p,
+		interval:  i,
+		suppress:  s.GetSuppressRedundant(),
+		heartbeat: time.Duration(s.GetHeartbeatInterval()),
+		last:      map[string]*gnmipb.TypedValue{},
+	}
+}
+
+// run sends s to ticks each time that the interval of s elapses, until done
+// is closed.
+func (s *sample) run(ticks chan<- *sample, done <-chan struct{}) {
+	tk := time.NewTicker(s.interval)
+	defer tk.Stop()
+	for {
+		select {
+		case <-tk.C:
+			select {
+			case ticks <- s:
+			case <-done:
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// filter returns the updates within us that should be sent for s at the
+// time now, and records them as sent. If s suppresses redundant updates,
+// the updates whose value has not changed since it was last sent are
+// omitted, unless a heartbeat is due.
+func (s *sample) filter(us []*gnmipb.Update, now time.Time) []*gnmipb.Update {
+	all := !s.suppress || (s.heartbeat != 0 && now.Sub(s.lastAll) >= s.heartbeat)
+	if all {
+		s.lastAll = now
+	}
+	var out []*gnmipb.Update
+	for _, u := range us {
+		k := pathString(u.GetPath())
+		if last, ok := s.last[k]; all || !ok || !proto.Equal(last, u.GetVal()) {
+			out = append(out, u)
+		}
+		s.last[k] = u.GetVal()
+	}
+	return out
+}
+
+// Subscribe implements the Subscribe RPC of the gNMI service. The ONCE,
+// POLL and STREAM modes are supported. Within a STREAM subscription, the
+// ON_CHANGE and TARGET_DEFINED modes stream the changes to the data tree as
+// they are committed, and the SAMPLE mode periodically sends the leaves
+// within the subscribed path. Leaves are always sent as scalar values, and
+// the paths within notifications are absolute.
+func (t *Target) Subscribe(stream gnmipb.GNMI_SubscribeServer) error {
+	req, err := stream.Recv()
+	switch {
+	case err == io.EOF:
+		return nil
+	case err != nil:
+		return err
+	}
+	sl := req.GetSubscribe()
+	switch {
+	case sl == nil:
+		return status.Error(codes.InvalidArgument, "first SubscribeRequest must contain a SubscriptionList")
+	case sl.GetUseAliases():
+		return status.Error(codes.Unimplemented, "aliases are not supported")
+	case len(sl.GetSubscription()) == 0:
+		return status.Error(codes.InvalidArgument, "SubscriptionList does not contain any subscriptions")
+	}
+	if err := checkEncoding(sl.GetEncoding()); err != nil {
+		return err
+	}
+
+	var paths []*gnmipb.Path
+	for _, s := range sl.GetSubscription() {
+		p, err := joinPaths(sl.GetPrefix(), s.GetPath())
+		if err != nil {
+			return err
+		}
+		paths = append(paths, p)
+	}
+
+	switch sl.GetMode() {
+	case gnmipb.SubscriptionList_ONCE:
+		return t.sendState(stream, sl, paths)
+	case gnmipb.SubscriptionList_POLL:
+		if err := t.sendState(stream, sl, paths); err != nil {
+			return err
+		}
+		for {
+			req, err := stream.Recv()
+			switch {
+			case err == io.EOF:
+				return nil
+			case err != nil:
+				return err
+			case req.GetPoll() == nil:
+				return status.Error(codes.InvalidArgument, "SubscribeRequest within a POLL subscription must contain a Poll")
+			}
+			if err := t.sendState(stream, sl, paths); err != nil {
+				return err
+			}
+		}
+	case gnmipb.SubscriptionList_STREAM:
+		return t.stream(stream, sl, paths)
+	}
+	return status.Errorf(codes.InvalidArgument, "invalid subscription mode %v", sl.GetMode())
+}
+
+// sendState sends the leaves within the subscribed paths, unless only
+// updates are requested, followed by a sync response.
+func (t *Target) sendState(stream gnmipb.GNMI_SubscribeServer, sl *gnmipb.SubscriptionList, paths []*gnmipb.Path) error {
+	if !sl.GetUpdatesOnly() {
+		us, err := leafUpdates(t.ds.Snapshot(), paths)
+		if err != nil {
+			return err
+		}
+		if err := sendUpdates(stream, sl, us); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&gnmipb.SubscribeResponse{Response: &gnmipb.SubscribeResponse_SyncResponse{true}})
+}
+
+// stream handles a STREAM subscription, until the RPC is cancelled or an
+// error occurs.
+func (t *Target) stream(stream gnmipb.GNMI_SubscribeServer, sl *gnmipb.SubscriptionList, paths []*gnmipb.Path) error {
+	var (
+		onChange []*gnmipb.Path
+		samples  []*sample
+	)
+	for i, s := range sl.GetSubscription() {
+		switch s.GetMode() {
+		case gnmipb.SubscriptionMode_TARGET_DEFINED, gnmipb.SubscriptionMode_ON_CHANGE:
+			onChange = append(onChange, paths[i])
+		case gnmipb.SubscriptionMode_SAMPLE:
+			samples = append(samples, newSample(s, paths[i]))
+		default:
+			return status.Errorf(codes.InvalidArgument, "invalid subscription mode %v", s.GetMode())
+		}
+	}
+
+	// The initial state is read from the snapshot at which the datastore
+	// subscription started, such that no change is either missed or sent
+	// twice. The subscription is to the entire data tree, so that each
+	// commit results in at most one notification, which is filtered by the
+	// ON_CHANGE paths.
+	snap := t.ds.Snapshot()
+	changes := make(chan *gnmipb.Notification)
+	errc := make(chan error, 2)
+	done := make(chan struct{})
+	defer close(done)
+	if len(onChange) != 0 {
+		sub, err := t.ds.Subscribe(nil)
+		if err != nil {
+			return status.Errorf(codes.Internal, "cannot subscribe to datastore: %v", err)
+		}
+		defer sub.Close()
+		snap = sub.Snapshot()
+		go forwardChanges(stream.Context(), sub, onChange, changes, errc, done)
+	}
+	us, err := leafUpdates(snap, paths)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, s := range samples {
+		s.filter(filterUpdates(us, s.path), now)
+	}
+	if !sl.GetUpdatesOnly() {
+		if err := sendUpdates(stream, sl, us); err != nil {
+			return err
+		}
+	}
+	if err := stream.Send(&gnmipb.SubscribeResponse{Response: &gnmipb.SubscribeResponse_SyncResponse{true}}); err != nil {
+		return err
+	}
+
+	// No further requests are expected from the client within a STREAM
+	// subscription.
+	go func() {
+		_, err := stream.Recv()
+		switch {
+		case err == io.EOF:
+			return
+		case err != nil:
+			errc <- err
+		default:
+			errc <- status.Error(codes.InvalidArgument, "unexpected SubscribeRequest within a STREAM subscription")
+		}
+	}()
+
+	ticks := make(chan *sample)
+	for _, s := range samples {
+		go s.run(ticks, done)
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case err := <-errc:
+			return err
+		case n := <-changes:
+			n.Prefix = targetPrefix(sl.GetPrefix())
+			if err := stream.Send(&gnmipb.SubscribeResponse{Response: &gnmipb.SubscribeResponse_Update{n}}); err != nil {
+				return err
+			}
+		case s := <-ticks:
+			us, err := leafUpdates(t.ds.Snapshot(), []*gnmipb.Path{s.path})
+			if err != nil {
+				return err
+			}
+			if err := sendUpdates(stream, sl, s.filter(us, time.Now())); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// forwardChanges sends the changes received by sub whose paths are within
+// the subtree rooted at one of the paths within ps to changes, until done is
+// closed. An error that is returned by sub is sent to errc. The changes are
+// received in a separate goroutine such that the queue of sub is drained
+// while the stream is sending other responses.
+func forwardChanges(ctx context.Context, sub *datastore.Subscription, ps []*gnmipb.Path, changes chan<- *gnmipb.Notification, errc chan<- error, done <-chan struct{}) {
+	for {
+		n, err := sub.Next(ctx)
+		switch {
+		case err == datastore.ErrClosed:
+			return
+		case err == datastore.ErrOverflow:
+			errc <- status.Errorf(codes.ResourceExhausted, "subscriber cannot keep up with changes: %v", err)
+			return
+		case err != nil:
+			errc <- err
+			return
+		}
+		f := &gnmipb.Notification{Timestamp: n.GetTimestamp()}
+		for _, u := range n.GetUpdate() {
+			if matchesAny(ps, u.GetPath()) {
+				f.Update = append(f.Update, u)
+			}
+		}
+		for _, d := range n.GetDelete() {
+			if matchesAny(ps, d) {
+				f.Delete = append(f.Delete, d)
+			}
+		}
+		if len(f.Update) == 0 && len(f.Delete) == 0 {
+			continue
+		}
+		select {
+		case changes <- f:
+		case <-done:
+			return
+		}
+	}
+}
+
+// sendUpdates sends the updates us within a single notification, if there
+// are any updates to send.
+func sendUpdates(stream gnmipb.GNMI_SubscribeServer, sl *gnmipb.SubscriptionList, us []*gnmipb.Update) error {
+	if len(us) == 0 {
+		return nil
+	}
+	n := &gnmipb.Notification{
+		Timestamp: time.Now().UnixNano(),
+		Prefix:    targetPrefix(sl.GetPrefix()),
+		Update:    us,
+	}
+	return stream.Send(&gnmipb.SubscribeResponse{Response: &gnmipb.SubscribeResponse_Update{n}})
+}
+
+// filterUpdates returns the updates within us whose path is within the
+// subtree rooted at p.
+func filterUpdates(us []*gnmipb.Update, p *gnmipb.Path) []*gnmipb.Update {
+	var out []*gnmipb.Update
+	for _, u := range us {
+		if util.PathMatchesQuery(u.GetPath(), p) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
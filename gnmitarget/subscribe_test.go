@@ -0,0 +1,278 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmitarget
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/ygot/internal/devicetest"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// subscribe starts a Subscribe RPC to the target that c is connected to
+// with the supplied subscription list. The RPC is cancelled after a timeout
+// such that a test that is waiting for a response does not hang.
+func subscribe(t *testing.T, c gnmipb.GNMIClient, sl *gnmipb.SubscriptionList) (gnmipb.GNMI_SubscribeClient, func()) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	sc, err := c.Subscribe(ctx)
+	if err != nil {
+		cancel()
+		t.Fatalf("Subscribe: got unexpected error: %v", err)
+	}
+	if err := sc.Send(&gnmipb.SubscribeRequest{Request: &gnmipb.SubscribeRequest_Subscribe{sl}}); err != nil {
+		cancel()
+		t.Fatalf("Send(%v): got unexpected error: %v", sl, err)
+	}
+	return sc, cancel
+}
+
+// recvUpdate receives the next response from sc, which must be an update,
+// and returns its normalized notification.
+func recvUpdate(t *testing.T, sc gnmipb.GNMI_SubscribeClient) *gnmipb.Notification {
+	t.Helper()
+	resp, err := sc.Recv()
+	if err != nil {
+		t.Fatalf("Recv: got unexpected error: %v", err)
+	}
+	n := resp.GetUpdate()
+	if n == nil {
+		t.Fatalf("Recv: got %s, want update", proto.MarshalTextString(resp))
+	}
+	return devicetest.Normalize(n)
+}
+
+// recvSync receives the next response from sc, which must be a sync
+// response.
+func recvSync(t *testing.T, sc gnmipb.GNMI_SubscribeClient) {
+	t.Helper()
+	resp, err := sc.Recv()
+	if err != nil {
+		t.Fatalf("Recv: got unexpected error: %v", err)
+	}
+	if !resp.GetSyncResponse() {
+		t.Fatalf("Recv: got %s, want sync response", proto.MarshalTextString(resp))
+	}
+}
+
+// checkNotification checks that the notification got is equal to want.
+func checkNotification(t *testing.T, got, want *gnmipb.Notification) {
+	t.Helper()
+	if !proto.Equal(got, want) {
+		t.Errorf("did not get expected notification, got: %s, want: %s", proto.MarshalTextString(got), proto.MarshalTextString(want))
+	}
+}
+
+func TestSubscribeOnce(t *testing.T) {
+	_, c, stop := startTarget(t)
+	defer stop()
+
+	sc, cancel := subscribe(t, c, &gnmipb.SubscriptionList{
+		Prefix:       &gnmipb.Path{Target: "dut"},
+		Subscription: []*gnmipb.Subscription{{Path: devicetest.MustPath("/system")}},
+		Mode:         gnmipb.SubscriptionList_ONCE,
+	})
+	defer cancel()
+
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Prefix: &gnmipb.Path{Target: "dut"},
+		Update: []*gnmipb.Update{
+			{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr1")},
+			{Path: devicetest.MustPath("/system/location"), Val: devicetest.StringVal("lab")},
+			{Path: devicetest.MustPath("/system/uplink"), Val: devicetest.StringVal("eth0")},
+		},
+	})
+	recvSync(t, sc)
+	if _, err := sc.Recv(); err != io.EOF {
+		t.Errorf("Recv: got %v, want EOF at end of ONCE subscription", err)
+	}
+}
+
+func TestSubscribePoll(t *testing.T) {
+	tg, c, stop := startTarget(t)
+	defer stop()
+
+	sc, cancel := subscribe(t, c, &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{Path: devicetest.MustPath("/system/hostname")}},
+		Mode:         gnmipb.SubscriptionList_POLL,
+	})
+	defer cancel()
+
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr1")}},
+	})
+	recvSync(t, sc)
+
+	if _, err := tg.Datastore().Update(func(r ygot.ValidatedGoStruct) error {
+		r.(*devicetest.Device).System.Hostname = ygot.String("rtr2")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	if err := sc.Send(&gnmipb.SubscribeRequest{Request: &gnmipb.SubscribeRequest_Poll{&gnmipb.Poll{}}}); err != nil {
+		t.Fatalf("Send(poll): got unexpected error: %v", err)
+	}
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr2")}},
+	})
+	recvSync(t, sc)
+}
+
+func TestSubscribeStreamOnChange(t *testing.T) {
+	tg, c, stop := startTarget(t)
+	defer stop()
+
+	sc, cancel := subscribe(t, c, &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{Path: devicetest.MustPath("/system"), Mode: gnmipb.SubscriptionMode_ON_CHANGE}},
+		Mode:         gnmipb.SubscriptionList_STREAM,
+		UpdatesOnly:  true,
+	})
+	defer cancel()
+	recvSync(t, sc)
+
+	// Changes outside of the subscribed path are not streamed, hence the
+	// first notification is for the change to the hostname.
+	for _, name := range []string{"eth1", "eth2"} {
+		if _, err := c.Set(context.Background(), &gnmipb.SetRequest{
+			Update: []*gnmipb.Update{{Path: devicetest.MustPath("/interfaces/interface[name=" + name + "]/mtu"), Val: devicetest.UintVal(9000)}},
+		}); err != nil {
+			t.Fatalf("Set: got unexpected error: %v", err)
+		}
+	}
+	if _, err := tg.Datastore().Update(func(r ygot.ValidatedGoStruct) error {
+		r.(*devicetest.Device).System.Hostname = ygot.String("rtr2")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr2")}},
+	})
+
+	if _, err := c.Set(context.Background(), &gnmipb.SetRequest{
+		Delete: []*gnmipb.Path{devicetest.MustPath("/system/uplink")},
+	}); err != nil {
+		t.Fatalf("Set: got unexpected error: %v", err)
+	}
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Delete: []*gnmipb.Path{devicetest.MustPath("/system/uplink")},
+	})
+}
+
+func TestSubscribeStreamSample(t *testing.T) {
+	tg, c, stop := startTarget(t)
+	defer stop()
+
+	sc, cancel := subscribe(t, c, &gnmipb.SubscriptionList{
+		Subscription: []*gnmipb.Subscription{{
+			Path:              devicetest.MustPath("/interfaces/interface[name=eth0]"),
+			Mode:              gnmipb.SubscriptionMode_SAMPLE,
+			SampleInterval:    uint64(10 * time.Millisecond),
+			SuppressRedundant: true,
+		}},
+		Mode: gnmipb.SubscriptionList_STREAM,
+	})
+	defer cancel()
+
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Update: []*gnmipb.Update{
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/counter"), Val: devicetest.UintVal(0)},
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), Val: devicetest.UintVal(1500)},
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/name"), Val: devicetest.StringVal("eth0")},
+		},
+	})
+	recvSync(t, sc)
+
+	// Since redundant updates are suppressed, the next sample contains only
+	// the counter.
+	if _, err := tg.Datastore().Update(func(r ygot.ValidatedGoStruct) error {
+		r.(*devicetest.Device).Interfaces.Interface["eth0"].Counter = ygot.Uint64(42)
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	checkNotification(t, recvUpdate(t, sc), &gnmipb.Notification{
+		Update: []*gnmipb.Update{{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/counter"), Val: devicetest.UintVal(42)}},
+	})
+}
+
+func TestSubscribeErrors(t *testing.T) {
+	tests := []struct {
+		desc     string
+		in       []*gnmipb.SubscribeRequest
+		wantCode codes.Code
+	}{{
+		desc:     "poll without subscription list",
+		in:       []*gnmipb.SubscribeRequest{{Request: &gnmipb.SubscribeRequest_Poll{&gnmipb.Poll{}}}},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc: "unsupported encoding",
+		in: []*gnmipb.SubscribeRequest{{Request: &gnmipb.SubscribeRequest_Subscribe{&gnmipb.SubscriptionList{
+			Subscription: []*gnmipb.Subscription{{Path: devicetest.MustPath("/system")}},
+			Encoding:     gnmipb.Encoding_ASCII,
+		}}}},
+		wantCode: codes.Unimplemented,
+	}, {
+		desc: "no subscriptions",
+		in: []*gnmipb.SubscribeRequest{{Request: &gnmipb.SubscribeRequest_Subscribe{&gnmipb.SubscriptionList{
+			Mode: gnmipb.SubscriptionList_ONCE,
+		}}}},
+		wantCode: codes.InvalidArgument,
+	}, {
+		desc: "poll within stream subscription",
+		in: []*gnmipb.SubscribeRequest{{Request: &gnmipb.SubscribeRequest_Subscribe{&gnmipb.SubscriptionList{
+			Subscription: []*gnmipb.Subscription{{Path: devicetest.MustPath("/system")}},
+			Mode:         gnmipb.SubscriptionList_STREAM,
+			UpdatesOnly:  true,
+		}}}, {Request: &gnmipb.SubscribeRequest_Poll{&gnmipb.Poll{}}}},
+		wantCode: codes.InvalidArgument,
+	}}
+
+	_, c, stop := startTarget(t)
+	defer stop()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			sc, err := c.Subscribe(ctx)
+			if err != nil {
+				t.Fatalf("Subscribe: got unexpected error: %v", err)
+			}
+			for _, r := range tt.in {
+				if err := sc.Send(r); err != nil {
+					t.Fatalf("Send(%v): got unexpected error: %v", r, err)
+				}
+			}
+			for {
+				_, err := sc.Recv()
+				if err == nil {
+					continue
+				}
+				if status.Code(err) != tt.wantCode {
+					t.Errorf("Recv: got error %v, want code %v", err, tt.wantCode)
+				}
+				return
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gnmitarget provides an in-process gNMI target whose data tree is a
+// GoStruct generated by ygen. It implements the Capabilities, Get, Set and
+// Subscribe RPCs of the gNMI service, such that it can be used as a test
+// double for a device when testing gNMI clients.
+package gnmitarget
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygot/datastore"
+	"github.com/openconfig/ygot/ytypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// gNMIVersion is the version of the gNMI specification that is implemented
+// by the target, as specified by the gnmi_service option of gnmi.proto.
+const gNMIVersion = "0.7.0"
+
+// supportedEncodings is the set of encodings that the target supports within
+// Get and Subscribe requests.
+var supportedEncodings = []gnmipb.Encoding{
+	gnmipb.Encoding_JSON,
+	gnmipb.Encoding_JSON_IETF,
+	gnmipb.Encoding_PROTO,
+}
+
+// Target is a gNMI target that serves the data tree of a Datastore. Changes
+// that are made by the Set RPC are committed to the Datastore, and the
+// changes made by each commit are streamed to the subscribers of the paths
+// that they affect. A Target is safe for concurrent use.
+type Target struct {
+	// ds is the datastore whose data tree is served.
+	ds *datastore.Datastore
+	// models is the set of models that are returned in the Capabilities
+	// RPC.
+	models []*gnmipb.ModelData
+
+	// srvMu protects srv.
+	srvMu sync.Mutex
+	srv   *grpc.Server
+}
+
+// New returns a Target that serves a new Datastore whose data tree is
+// initially the Root of the supplied schema, as per datastore.New, and whose
+// Capabilities response lists the supplied models, which are typically the
+// ΓModelData generated alongside the GoStructs.
+func New(schema *ytypes.Schema, models []*gnmipb.ModelData) (*Target, error) {
+	ds, err := datastore.New(schema)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithDatastore(ds, models), nil
+}
+
+// NewWithDatastore returns a Target that serves the data tree of ds, and
+// whose Capabilities response lists the supplied models. The datastore may
+// be shared with other users, whose commits are streamed to subscribers.
+func NewWithDatastore(ds *datastore.Datastore, models []*gnmipb.ModelData) *Target {
+	return &Target{ds: ds, models: models}
+}
+
+// Serve serves the gNMI service of t on lis, using a gRPC server that is
+// created with the supplied options, until Stop is called. The listener may
+// be a TCP listener on a local address, or an in-memory listener such as
+// that provided by the grpc/test/bufconn package. Serve returns the error
+// returned by the gRPC server.
+func (t *Target) Serve(lis net.Listener, opts ...grpc.ServerOption) error {
+	t.srvMu.Lock()
+	if t.srv != nil {
+		t.srvMu.Unlock()
+		return fmt.Errorf("target is already serving")
+	}
+	t.srv = grpc.NewServer(opts...)
+	gnmipb.RegisterGNMIServer(t.srv, t)
+	srv := t.srv
+	t.srvMu.Unlock()
+	return srv.Serve(lis)
+}
+
+// Stop stops the gRPC server that was started by Serve, closing all of its
+// listeners and terminating any active RPCs.
+func (t *Target) Stop() {
+	t.srvMu.Lock()
+	defer t.srvMu.Unlock()
+	if t.srv != nil {
+		t.srv.Stop()
+		t.srv = nil
+	}
+}
+
+// Datastore returns the datastore whose data tree is served by t. It allows
+// the state of the simulated device to be changed by the test that uses it,
+// such as to update counters or the operational status of an interface.
+func (t *Target) Datastore() *datastore.Datastore {
+	return t.ds
+}
+
+// Capabilities implements the Capabilities RPC of the gNMI service.
+func (t *Target) Capabilities(ctx context.Context, req *gnmipb.CapabilityRequest) (*gnmipb.CapabilityResponse, error) {
+	return &gnmipb.CapabilityResponse{
+		SupportedModels:    t.models,
+		SupportedEncodings: supportedEncodings,
+		GNMIVersion:        gNMIVersion,
+	}, nil
+}
+
+// Get implements the Get RPC of the gNMI service. A Notification is returned
+// for each path within the request, whose updates have absolute paths. With
+// the JSON and JSON_IETF encodings, a container or list member is returned
+// as a single update whose value is its encoded subtree. With the PROTO
+// encoding, an update is returned for each leaf within the subtree. Leaves
+// are returned as scalar values with all encodings. A path for which there
+// is no data results in a NotFound error.
+func (t *Target) Get(ctx context.Context, req *gnmipb.GetRequest) (*gnmipb.GetResponse, error) {
+	if err := checkEncoding(req.GetEncoding()); err != nil {
+		return nil, err
+	}
+	if req.GetType() != gnmipb.GetRequest_ALL {
+		return nil, status.Errorf(codes.Unimplemented, "unsupported data type %v", req.GetType())
+	}
+
+	snap := t.ds.Snapshot()
+	ts := time.Now().UnixNano()
+	resp := &gnmipb.GetResponse{}
+	for _, p := range req.GetPath() {
+		fp, err := joinPaths(req.GetPrefix(), p)
+		if err != nil {
+			return nil, err
+		}
+		var us []*gnmipb.Update
+		if req.GetEncoding() == gnmipb.Encoding_PROTO {
+			us, err = leafUpdates(snap, []*gnmipb.Path{fp})
+		} else {
+			us, err = treeUpdates(snap, fp, req.GetEncoding())
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(us) == 0 {
+			return nil, status.Errorf(codes.NotFound, "no data at path %s", pathString(fp))
+		}
+		resp.Notification = append(resp.Notification, &gnmipb.Notification{
+			Timestamp: ts,
+			Prefix:    targetPrefix(req.GetPrefix()),
+			Update:    us,
+		})
+	}
+	return resp, nil
+}
+
+// treeUpdates returns an update for each node of the data tree of snap that
+// matches the path p, whose value is the subtree rooted at the node encoded
+// with enc.
+func treeUpdates(snap *datastore.Snapshot, p *gnmipb.Path, enc gnmipb.Encoding) ([]*gnmipb.Update, error) {
+	if len(p.GetElem()) == 0 {
+		tv, err := ygot.EncodeTypedValue(snap.Tree(), enc)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot encode data tree: %v", err)
+		}
+		return []*gnmipb.Update{{Path: &gnmipb.Path{}, Val: tv}}, nil
+	}
+
+	nodes, err := snap.Get(p)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		return nil, nil
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid path %s: %s", pathString(p), status.Convert(err).Message())
+	}
+
+	var us []*gnmipb.Update
+	for _, n := range nodes {
+		if util.IsValueNil(n.Data) {
+			continue
+		}
+		tv, err := ygot.EncodeTypedValue(n.Data, enc)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "cannot encode value at path %s: %v", pathString(n.Path), err)
+		}
+		if tv == nil {
+			continue
+		}
+		us = append(us, &gnmipb.Update{Path: n.Path, Val: tv})
+	}
+	return us, nil
+}
+
+// leafUpdates returns an update for each leaf and leaf-list within the data
+// tree of snap that is within the subtree rooted at one of the paths within
+// ps, which may contain wildcards.
+func leafUpdates(snap *datastore.Snapshot, ps []*gnmipb.Path) ([]*gnmipb.Update, error) {
+	ns, err := ygot.TogNMINotifications(snap.Tree(), 0, ygot.GNMINotificationsConfig{UsePathElem: true})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "cannot render data tree: %v", err)
+	}
+	var us []*gnmipb.Update
+	for _, n := range ns {
+		for _, u := range n.GetUpdate() {
+			p := &gnmipb.Path{Elem: append(append([]*gnmipb.PathElem{}, n.GetPrefix().GetElem()...), u.GetPath().GetElem()...)}
+			if matchesAny(ps, p) {
+				us = append(us, &gnmipb.Update{Path: p, Val: u.GetVal()})
+			}
+		}
+	}
+	return us, nil
+}
+
+// Set implements the Set RPC of the gNMI service. The request is applied as
+// per ytypes.ApplySetRequest within a single transaction of the datastore,
+// such that the data tree is changed only if the entire request succeeds and
+// results in a valid data tree.
+func (t *Target) Set(ctx context.Context, req *gnmipb.SetRequest) (*gnmipb.SetResponse, error) {
+	if _, err := t.ds.Update(func(root ygot.ValidatedGoStruct) error {
+		return ytypes.ApplySetRequest(t.ds.Schema(), root, req)
+	}); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.InvalidArgument, "cannot commit SetRequest: %v", err)
+	}
+
+	resp := &gnmipb.SetResponse{
+		Prefix:    req.GetPrefix(),
+		Timestamp: time.Now().UnixNano(),
+	}
+	for _, p := range req.GetDelete() {
+		resp.Response = append(resp.Response, &gnmipb.UpdateResult{Path: p, Op: gnmipb.UpdateResult_DELETE})
+	}
+	for _, u := range req.GetReplace() {
+		resp.Response = append(resp.Response, &gnmipb.UpdateResult{Path: u.GetPath(), Op: gnmipb.UpdateResult_REPLACE})
+	}
+	for _, u := range req.GetUpdate() {
+		resp.Response = append(resp.Response, &gnmipb.UpdateResult{Path: u.GetPath(), Op: gnmipb.UpdateResult_UPDATE})
+	}
+	return resp, nil
+}
+
+// checkEncoding returns an Unimplemented error if the target does not
+// support the encoding enc.
+func checkEncoding(enc gnmipb.Encoding) error {
+	for _, e := range supportedEncodings {
+		if e == enc {
+			return nil
+		}
+	}
+	return status.Errorf(codes.Unimplemented, "unsupported encoding %v", enc)
+}
+
+// joinPaths returns the path p prefixed with the elements of prefix. Paths
+// that use the deprecated element field are not supported.
+func joinPaths(prefix, p *gnmipb.Path) (*gnmipb.Path, error) {
+	if len(prefix.GetElement()) != 0 || len(p.GetElement()) != 0 {
+		return nil, status.Error(codes.Unimplemented, "paths using the deprecated element field are not supported")
+	}
+	fp := &gnmipb.Path{}
+	for _, e := range append(append([]*gnmipb.PathElem{}, prefix.GetElem()...), p.GetElem()...) {
+		fp.Elem = append(fp.Elem, proto.Clone(e).(*gnmipb.PathElem))
+	}
+	return fp, nil
+}
+
+// targetPrefix returns the prefix of a Notification that is returned in
+// response to a request with the supplied prefix. Since the paths within the
+// Notification are absolute, only the target and origin of the prefix are
+// retained.
+func targetPrefix(prefix *gnmipb.Path) *gnmipb.Path {
+	if prefix.GetTarget() == "" && prefix.GetOrigin() == "" {
+		return nil
+	}
+	return &gnmipb.Path{Target: prefix.GetTarget(), Origin: prefix.GetOrigin()}
+}
+
+// matchesAny returns true if p is within the subtree rooted at one of the
+// paths within ps, which may contain wildcards.
+func matchesAny(ps []*gnmipb.Path, p *gnmipb.Path) bool {
+	for _, s := range ps {
+		if util.PathMatchesQuery(p, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathString returns the string form of the path p, for use in errors.
+func pathString(p *gnmipb.Path) string {
+	s, err := ygot.PathToString(p)
+	if err != nil {
+		return proto.CompactTextString(p)
+	}
+	return s
+}
@@ -0,0 +1,294 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmitarget
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/devicetest"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygot/datastore"
+	"github.com/openconfig/ygot/ytypes"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// testRoutes is the routes container of the data tree of the test schema.
+var testRoutes = devicetest.NewSchema().Root.(*devicetest.Device).Routes
+
+// testModels is the set of models that are supported by the test target.
+var testModels = []*gnmipb.ModelData{{Name: "dev", Organization: "example", Version: "1.0.0"}}
+
+// startTarget starts a Target serving the test schema on a local listener,
+// and returns it along with a client that is connected to it, and a
+// function that stops the target.
+func startTarget(t *testing.T) (*Target, gnmipb.GNMIClient, func()) {
+	t.Helper()
+	tg, err := New(devicetest.NewSchema(), testModels)
+	if err != nil {
+		t.Fatalf("New: got unexpected error: %v", err)
+	}
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %v", err)
+	}
+	go tg.Serve(lis)
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("cannot dial target: %v", err)
+	}
+	return tg, gnmipb.NewGNMIClient(conn), func() {
+		conn.Close()
+		tg.Stop()
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&ytypes.Schema{}, nil); err == nil {
+		t.Errorf("New(empty schema): did not get expected error")
+	}
+	s := devicetest.NewSchema()
+	s.SchemaTree = map[string]*yang.Entry{}
+	if _, err := New(s, nil); err == nil {
+		t.Errorf("New(schema without root): did not get expected error")
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	_, c, stop := startTarget(t)
+	defer stop()
+
+	got, err := c.Capabilities(context.Background(), &gnmipb.CapabilityRequest{})
+	if err != nil {
+		t.Fatalf("Capabilities: got unexpected error: %v", err)
+	}
+	want := &gnmipb.CapabilityResponse{
+		SupportedModels:    testModels,
+		SupportedEncodings: []gnmipb.Encoding{gnmipb.Encoding_JSON, gnmipb.Encoding_JSON_IETF, gnmipb.Encoding_PROTO},
+		GNMIVersion:        "0.7.0",
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("Capabilities: got %s, want %s", proto.MarshalTextString(got), proto.MarshalTextString(want))
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		desc     string
+		in       *gnmipb.GetRequest
+		want     []*gnmipb.Update
+		wantJSON string
+		wantCode codes.Code
+	}{{
+		desc: "leaf",
+		in: &gnmipb.GetRequest{
+			Prefix:   devicetest.MustPath("/interfaces"),
+			Path:     []*gnmipb.Path{devicetest.MustPath("/interface[name=eth0]/mtu")},
+			Encoding: gnmipb.Encoding_JSON_IETF,
+		},
+		want: []*gnmipb.Update{{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), Val: devicetest.UintVal(1500)}},
+	}, {
+		desc: "container as JSON_IETF",
+		in: &gnmipb.GetRequest{
+			Path:     []*gnmipb.Path{devicetest.MustPath("/system")},
+			Encoding: gnmipb.Encoding_JSON_IETF,
+		},
+		wantJSON: `{"dev:hostname": "rtr1", "dev:uplink": "eth0", "ext:location": "lab"}`,
+	}, {
+		desc: "leaves with wildcard as PROTO",
+		in: &gnmipb.GetRequest{
+			Path:     []*gnmipb.Path{devicetest.MustPath("/interfaces/interface[name=*]")},
+			Encoding: gnmipb.Encoding_PROTO,
+		},
+		want: []*gnmipb.Update{
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/counter"), Val: devicetest.UintVal(0)},
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), Val: devicetest.UintVal(1500)},
+			{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/name"), Val: devicetest.StringVal("eth0")},
+		},
+	}, {
+		desc: "missing data",
+		in: &gnmipb.GetRequest{
+			Path:     []*gnmipb.Path{devicetest.MustPath("/interfaces/interface[name=eth1]/mtu")},
+			Encoding: gnmipb.Encoding_JSON_IETF,
+		},
+		wantCode: codes.NotFound,
+	}, {
+		desc: "unsupported encoding",
+		in: &gnmipb.GetRequest{
+			Path:     []*gnmipb.Path{devicetest.MustPath("/system")},
+			Encoding: gnmipb.Encoding_ASCII,
+		},
+		wantCode: codes.Unimplemented,
+	}, {
+		desc: "unsupported data type",
+		in: &gnmipb.GetRequest{
+			Path: []*gnmipb.Path{devicetest.MustPath("/system")},
+			Type: gnmipb.GetRequest_CONFIG,
+		},
+		wantCode: codes.Unimplemented,
+	}}
+
+	_, c, stop := startTarget(t)
+	defer stop()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := c.Get(context.Background(), tt.in)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Get(%v): got error %v, want code %v", tt.in, err, tt.wantCode)
+			}
+			if err != nil {
+				return
+			}
+			if len(got.GetNotification()) != 1 {
+				t.Fatalf("Get(%v): got %d notifications, want 1", tt.in, len(got.GetNotification()))
+			}
+			n := devicetest.Normalize(got.GetNotification()[0])
+			if tt.wantJSON != "" {
+				if len(n.GetUpdate()) != 1 {
+					t.Fatalf("Get(%v): got %d updates, want 1", tt.in, len(n.GetUpdate()))
+				}
+				var gotJSON, wantJSON interface{}
+				if err := json.Unmarshal(n.GetUpdate()[0].GetVal().GetJsonIetfVal(), &gotJSON); err != nil {
+					t.Fatalf("Get(%v): cannot unmarshal returned JSON: %v", tt.in, err)
+				}
+				if err := json.Unmarshal([]byte(tt.wantJSON), &wantJSON); err != nil {
+					t.Fatalf("Get(%v): cannot unmarshal expected JSON: %v", tt.in, err)
+				}
+				if diff := cmp.Diff(wantJSON, gotJSON); diff != "" {
+					t.Errorf("Get(%v): did not get expected JSON, diff(-want, +got):\n%s", tt.in, diff)
+				}
+				return
+			}
+			if want := (&gnmipb.Notification{Update: tt.want}); !proto.Equal(n, want) {
+				t.Errorf("Get(%v): got %s, want %s", tt.in, proto.MarshalTextString(n), proto.MarshalTextString(want))
+			}
+		})
+	}
+}
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               *gnmipb.SetRequest
+		want             *devicetest.Device
+		wantCode         codes.Code
+		wantErrSubstring string
+	}{{
+		desc: "update and replace",
+		in: &gnmipb.SetRequest{
+			Replace: []*gnmipb.Update{{
+				Path: devicetest.MustPath("/interfaces/interface[name=eth1]"),
+				Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte(`{"dev:name": "eth1", "dev:mtu": 9000}`)}},
+			}},
+			Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/uplink"), Val: devicetest.StringVal("eth1")}},
+		},
+		want: &devicetest.Device{
+			Interfaces: &devicetest.Interfaces{Interface: map[string]*devicetest.Interface{
+				"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500), Counter: ygot.Uint64(0)},
+				"eth1": {Name: ygot.String("eth1"), Mtu: ygot.Uint16(9000)},
+			}},
+			Routes: testRoutes,
+			System: &devicetest.System{Hostname: ygot.String("rtr1"), Uplink: ygot.String("eth1"), Location: ygot.String("lab")},
+		},
+	}, {
+		desc: "delete",
+		in: &gnmipb.SetRequest{
+			Delete: []*gnmipb.Path{devicetest.MustPath("/system/uplink")},
+		},
+		want: &devicetest.Device{
+			Interfaces: &devicetest.Interfaces{Interface: map[string]*devicetest.Interface{
+				"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500), Counter: ygot.Uint64(0)},
+			}},
+			Routes: testRoutes,
+			System: &devicetest.System{Hostname: ygot.String("rtr1"), Location: ygot.String("lab")},
+		},
+	}, {
+		desc: "invalid data tree",
+		in: &gnmipb.SetRequest{
+			Delete: []*gnmipb.Path{devicetest.MustPath("/interfaces/interface[name=eth0]")},
+		},
+		wantCode:         codes.InvalidArgument,
+		wantErrSubstring: "cannot commit SetRequest: invalid data tree",
+	}, {
+		desc: "invalid path",
+		in: &gnmipb.SetRequest{
+			Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/contact"), Val: devicetest.StringVal("noc")}},
+		},
+		wantCode:         codes.InvalidArgument,
+		wantErrSubstring: "update /system/contact",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			tg, c, stop := startTarget(t)
+			defer stop()
+			_, err := c.Set(context.Background(), tt.in)
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Set(%v): got error %v, want code %v", tt.in, err, tt.wantCode)
+			}
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("Set(%v): did not get expected error, %s", tt.in, diff)
+			}
+			want := tt.want
+			if err != nil {
+				// The data tree must not be changed by a failed Set.
+				want = devicetest.NewSchema().Root.(*devicetest.Device)
+			}
+			got := tg.Datastore().Snapshot().Tree()
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Set(%v): did not get expected data tree, diff(-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestNewWithDatastore(t *testing.T) {
+	ds, err := datastore.New(devicetest.NewSchema())
+	if err != nil {
+		t.Fatalf("datastore.New: got unexpected error: %v", err)
+	}
+	tg := NewWithDatastore(ds, nil)
+	if tg.Datastore() != ds {
+		t.Errorf("Datastore: did not return the supplied datastore")
+	}
+	if _, err := ds.Update(func(r ygot.ValidatedGoStruct) error {
+		r.(*devicetest.Device).System.Hostname = ygot.String("rtr2")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+
+	got, err := tg.Get(context.Background(), &gnmipb.GetRequest{
+		Path:     []*gnmipb.Path{devicetest.MustPath("/system/hostname")},
+		Encoding: gnmipb.Encoding_JSON_IETF,
+	})
+	if err != nil {
+		t.Fatalf("Get: got unexpected error: %v", err)
+	}
+	want := &gnmipb.Notification{Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr2")}}}
+	if n := devicetest.Normalize(got.GetNotification()[0]); !proto.Equal(n, want) {
+		t.Errorf("Get after commit to datastore: got %s, want %s", proto.MarshalTextString(n), proto.MarshalTextString(want))
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package devicetest provides a small hand-written schema, and the GoStructs
+// that correspond to it, for use in the tests of the packages that serve a
+// data tree, such as the datastore, gNMI target and RESTCONF server. The
+// GoStructs are of the form generated by ygen with path compression.
+package devicetest
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// schemaTree returns the schema tree of the Device struct, in the form of
+// the SchemaTree generated by ygen. The location leaf of the system
+// container is defined in the ext module, whose prefix is e, and all other
+// nodes in the dev module, whose prefix is d. The modules are found from the
+// annotations of the root, as per a generated schema whose entries have no
+// AST node. The counter leaf of an interface is state data, and the dns
+// leaf-list of the system container is ordered-by user.
+func schemaTree() map[string]*yang.Entry {
+	leaf := func(name string, t *yang.YangType) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: t}
+	}
+	str := &yang.YangType{Kind: yang.Ystring}
+	iface := &yang.Entry{
+		Name:     "interface",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{},
+		Key:      "name",
+		Dir: map[string]*yang.Entry{
+			"name":    leaf("name", str),
+			"mtu":     leaf("mtu", &yang.YangType{Kind: yang.Yuint16}),
+			"counter": leaf("counter", &yang.YangType{Kind: yang.Yuint64}),
+		},
+	}
+	iface.Dir["counter"].Config = yang.TSFalse
+	route := &yang.Entry{
+		Name:     "route",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{},
+		Key:      "prefix vrf",
+		Dir: map[string]*yang.Entry{
+			"prefix":   leaf("prefix", str),
+			"vrf":      leaf("vrf", str),
+			"next-hop": leaf("next-hop", str),
+		},
+	}
+	system := &yang.Entry{
+		Name: "system",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"hostname": leaf("hostname", str),
+			"uplink":   leaf("uplink", &yang.YangType{Kind: yang.Yleafref, Path: "/interfaces/interface/name"}),
+			"location": leaf("location", str),
+			"dns":      leaf("dns", str),
+		},
+	}
+	system.Dir["location"].Prefix = &yang.Value{Name: "e"}
+	system.Dir["dns"].ListAttr = &yang.ListAttr{OrderedBy: &yang.Value{Name: "user"}}
+	interfaces := &yang.Entry{Name: "interfaces", Kind: yang.DirectoryEntry, Dir: map[string]*yang.Entry{"interface": iface}}
+	routes := &yang.Entry{Name: "routes", Kind: yang.DirectoryEntry, Dir: map[string]*yang.Entry{"route": route}}
+	root := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Annotation: map[string]interface{}{
+			"isFakeRoot":                    true,
+			util.ModuleNamespacesAnnotation: map[string]string{"dev": "urn:dev", "ext": "urn:ext"},
+			util.ModulePrefixesAnnotation:   map[string]string{"d": "dev", "e": "ext"},
+		},
+		Dir: map[string]*yang.Entry{"interfaces": interfaces, "routes": routes, "system": system},
+	}
+	for _, e := range root.Dir {
+		e.Parent = root
+		e.Prefix = &yang.Value{Name: "d"}
+	}
+	iface.Parent = interfaces
+	route.Parent = routes
+	for _, e := range []*yang.Entry{iface, route, system} {
+		for _, c := range e.Dir {
+			c.Parent = e
+		}
+	}
+	return map[string]*yang.Entry{
+		"Device":     root,
+		"Interfaces": interfaces,
+		"Interface":  iface,
+		"Routes":     routes,
+		"Route":      route,
+		"System":     system,
+	}
+}
+
+// SchemaTree is the schema tree of the Device struct, keyed by the name of
+// each struct type, as per the SchemaTree generated by ygen.
+var SchemaTree = schemaTree()
+
+// Device is the fake root of the data tree.
+type Device struct {
+	Interfaces *Interfaces `path:"interfaces" module:"dev"`
+	Routes     *Routes     `path:"routes" module:"dev"`
+	System     *System     `path:"system" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*Device) IsYANGGoStruct() {}
+
+// ΛEnumTypeMap implements the ygot.ValidatedGoStruct interface.
+func (*Device) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+// Validate implements the ygot.ValidatedGoStruct interface.
+func (d *Device) Validate(opts ...ygot.ValidationOption) error {
+	if err := ytypes.Validate(SchemaTree["Device"], d, opts...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Interfaces is the /interfaces container.
+type Interfaces struct {
+	Interface map[string]*Interface `path:"interface" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*Interfaces) IsYANGGoStruct() {}
+
+// Interface is an entry of the /interfaces/interface list.
+type Interface struct {
+	Name    *string `path:"name" module:"dev"`
+	Mtu     *uint16 `path:"mtu" module:"dev"`
+	Counter *uint64 `path:"counter" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*Interface) IsYANGGoStruct() {}
+
+// ΛListKeyMap implements the ygot.KeyHelperGoStruct interface.
+func (i *Interface) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+// Routes is the /routes container.
+type Routes struct {
+	Route map[RouteKey]*Route `path:"route" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*Routes) IsYANGGoStruct() {}
+
+// RouteKey is the key of the /routes/route list.
+type RouteKey struct {
+	Prefix string `path:"prefix"`
+	Vrf    string `path:"vrf"`
+}
+
+// Route is an entry of the /routes/route list.
+type Route struct {
+	Prefix  *string `path:"prefix" module:"dev"`
+	Vrf     *string `path:"vrf" module:"dev"`
+	NextHop *string `path:"next-hop" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*Route) IsYANGGoStruct() {}
+
+// ΛListKeyMap implements the ygot.KeyHelperGoStruct interface.
+func (r *Route) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"prefix": *r.Prefix, "vrf": *r.Vrf}, nil
+}
+
+// System is the /system container.
+type System struct {
+	Hostname *string  `path:"hostname" module:"dev"`
+	Uplink   *string  `path:"uplink" module:"dev"`
+	Location *string  `path:"location" module:"ext"`
+	DNS      []string `path:"dns" module:"dev"`
+}
+
+// IsYANGGoStruct implements the ygot.GoStruct interface.
+func (*System) IsYANGGoStruct() {}
+
+// NewSchema returns a schema whose root is a Device containing the
+// interface eth0, which is the uplink of the system, and a route within the
+// red VRF.
+func NewSchema() *ytypes.Schema {
+	return &ytypes.Schema{
+		Root: &Device{
+			Interfaces: &Interfaces{Interface: map[string]*Interface{
+				"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500), Counter: ygot.Uint64(0)},
+			}},
+			Routes: &Routes{Route: map[RouteKey]*Route{
+				{"10.0.0.0/8", "red"}: {Prefix: ygot.String("10.0.0.0/8"), Vrf: ygot.String("red"), NextHop: ygot.String("192.0.2.1")},
+			}},
+			System: &System{Hostname: ygot.String("rtr1"), Uplink: ygot.String("eth0"), Location: ygot.String("lab")},
+		},
+		SchemaTree: SchemaTree,
+		Unmarshal: func(b []byte, s ygot.GoStruct, opts ...ytypes.UnmarshalOpt) error {
+			var jv interface{}
+			if err := json.Unmarshal(b, &jv); err != nil {
+				return err
+			}
+			return ytypes.Unmarshal(SchemaTree["Device"], s, jv, opts...)
+		},
+	}
+}
+
+// MustPath returns the gNMI path for the string s, and panics if s is not a
+// valid path.
+func MustPath(s string) *gnmipb.Path {
+	p, err := ygot.StringToStructuredPath(s)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// UintVal returns a TypedValue containing the uint64 u.
+func UintVal(u uint64) *gnmipb.TypedValue {
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{u}}
+}
+
+// StringVal returns a TypedValue containing the string s.
+func StringVal(s string) *gnmipb.TypedValue {
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{s}}
+}
+
+// Normalize returns a copy of the notification n whose timestamp is
+// cleared, and whose updates and deletes are sorted by path, such that it
+// can be compared.
+func Normalize(n *gnmipb.Notification) *gnmipb.Notification {
+	n = proto.Clone(n).(*gnmipb.Notification)
+	n.Timestamp = 0
+	sort.Slice(n.Update, func(i, j int) bool { return pathString(n.Update[i].Path) < pathString(n.Update[j].Path) })
+	sort.Slice(n.Delete, func(i, j int) bool { return pathString(n.Delete[i]) < pathString(n.Delete[j]) })
+	return n
+}
+
+// pathString returns the string form of the path p.
+func pathString(p *gnmipb.Path) string {
+	s, err := ygot.PathToString(p)
+	if err != nil {
+		return proto.CompactTextString(p)
+	}
+	return s
+}
@@ -0,0 +1,146 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package encodingtest provides a small hand-written schema for use in the
+// tests of the XML and CBOR encodings of GoStructs, in the ygot package, and
+// of their unmarshalling and patching, in the ytypes package. It does not
+// depend on either package, such that it can be used by the tests within
+// them.
+package encodingtest
+
+import (
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+)
+
+// Schema returns the schema of the device root, in the compressed form of a
+// schema generated by ygen. The entries of the schema are defined by the dev
+// module, whose prefix is d, other than ext-leaf, which is defined by the ext
+// module, whose prefix is x. The modules are found from the annotations of
+// the root, which also record the namespace of the foo module, which defines
+// the identities that are referenced by the ident leaf. A new schema is
+// returned for each call, such that tests can modify it. The dns leaf-list
+// and the rule list are ordered-by user.
+func Schema() *yang.Entry {
+	leaf := func(name string, k yang.TypeKind) *yang.Entry {
+		return &yang.Entry{
+			Name:   name,
+			Kind:   yang.LeafEntry,
+			Prefix: &yang.Value{Name: "d"},
+			Type:   &yang.YangType{Kind: k},
+		}
+	}
+	dir := func(name string, children ...*yang.Entry) *yang.Entry {
+		e := &yang.Entry{
+			Name:   name,
+			Kind:   yang.DirectoryEntry,
+			Prefix: &yang.Value{Name: "d"},
+			Dir:    map[string]*yang.Entry{},
+		}
+		for _, c := range children {
+			e.Dir[c.Name] = c
+			c.Parent = e
+		}
+		return e
+	}
+
+	dns := leaf("dns", yang.Ystring)
+	dns.ListAttr = &yang.ListAttr{OrderedBy: &yang.Value{Name: "user"}}
+	extLeaf := leaf("ext-leaf", yang.Ystring)
+	extLeaf.Prefix = &yang.Value{Name: "x"}
+	logging := dir("logging", leaf("level", yang.Ystring))
+	logging.Annotation = map[string]interface{}{util.PresenceAnnotation: true}
+
+	iface := dir("interface", leaf("name", yang.Ystring), dir("config", leaf("name", yang.Ystring), leaf("mtu", yang.Yuint16)))
+	iface.ListAttr = &yang.ListAttr{}
+	iface.Key = "name"
+	route := dir("route", leaf("prefix", yang.Ystring), leaf("vrf", yang.Yuint32), leaf("next-hop", yang.Ystring))
+	route.ListAttr = &yang.ListAttr{}
+	route.Key = "prefix vrf"
+	rule := dir("rule", leaf("key", yang.Ystring), leaf("leaf-field", yang.Yint32))
+	rule.ListAttr = &yang.ListAttr{OrderedBy: &yang.Value{Name: "user"}}
+	rule.Key = "key"
+	server := dir("server", leaf("address", yang.Ystring), leaf("port", yang.Yuint16))
+	server.ListAttr = &yang.ListAttr{}
+
+	root := dir("device",
+		dir("system",
+			dir("config",
+				leaf("hostname", yang.Ystring),
+				dns,
+				extLeaf,
+				leaf("mtu", yang.Yuint16),
+				leaf("counter", yang.Yuint64),
+				leaf("enabled", yang.Ybool),
+				leaf("flag", yang.Yempty),
+				leaf("key-data", yang.Ybinary),
+				leaf("ident", yang.Yidentityref),
+			),
+			logging,
+		),
+		dir("interfaces", iface),
+		dir("routes", route),
+		dir("rules", rule),
+		dir("servers", server),
+	)
+	root.Prefix = nil
+	root.Annotation = map[string]interface{}{
+		util.ModuleNamespacesAnnotation: map[string]string{"dev": "urn:dev", "ext": "urn:ext", "foo": "urn:foo"},
+		util.ModulePrefixesAnnotation:   map[string]string{"d": "dev", "x": "ext"},
+	}
+	return root
+}
+
+// SIDFiles are the JSON encoded SID files of the dev, ext and foo modules,
+// which assign the SIDs used in the tests of the CBOR encoding. The mtu leaf
+// of the system config container is left without a SID, such that tests can
+// encode a data node that has none. The ext module augments the interface
+// list with a state container, and the foo module defines the identities
+// VAL_ONE and E_VALUE_FORTY_TWO.
+var SIDFiles = []string{`{
+  "ietf-sid-file:sid-file": {
+    "module-name": "dev",
+    "item": [
+      {"namespace": "data", "identifier": "/dev:system", "sid": "1000"},
+      {"namespace": "data", "identifier": "/dev:system/config", "sid": "1001"},
+      {"namespace": "data", "identifier": "/dev:system/config/hostname", "sid": "1002"},
+      {"namespace": "data", "identifier": "/dev:system/config/ident", "sid": "999"},
+      {"namespace": "data", "identifier": "/dev:system/config/value", "sid": "1003"},
+      {"namespace": "data", "identifier": "/dev:system/logging", "sid": "1004"},
+      {"namespace": "data", "identifier": "/dev:system/config/dns", "sid": "1005"},
+      {"namespace": "data", "identifier": "/dev:interfaces", "sid": "1010"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface", "sid": "1011"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/name", "sid": "1012"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/config", "sid": "1013"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/config/name", "sid": "1014"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/config/mtu", "sid": "1015"}
+    ]
+  }
+}`, `{
+  "ietf-sid-file:sid-file": {
+    "module-name": "ext",
+    "item": [
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/ext:state", "sid": "2000"},
+      {"namespace": "data", "identifier": "/dev:interfaces/interface/ext:state/counter", "sid": "2001"}
+    ]
+  }
+}`, `{
+  "ietf-sid-file:sid-file": {
+    "module-name": "foo",
+    "item": [
+      {"namespace": "identity", "identifier": "VAL_ONE", "sid": "3000"},
+      {"namespace": "identity", "identifier": "E_VALUE_FORTY_TWO", "sid": "3001"}
+    ]
+  }
+}`}
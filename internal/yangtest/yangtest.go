@@ -0,0 +1,128 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yangtest provides schemas parsed from YANG modules for use in the
+// tests of the packages that evaluate XPath expressions against a data tree,
+// such as util and ytypes.
+package yangtest
+
+import (
+	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// ParseModule parses the YANG module named name from src, and returns its
+// schema entry. The test is failed if the module cannot be parsed.
+func ParseModule(t testing.TB, name, src string) *yang.Entry {
+	t.Helper()
+	ms := yang.NewModules()
+	if err := ms.Parse(src, name+".yang"); err != nil {
+		t.Fatalf("cannot parse module %s: %v", name, err)
+	}
+	e, errs := ms.GetModule(name)
+	if errs != nil {
+		t.Fatalf("cannot process module %s: %v", name, errs)
+	}
+	return e
+}
+
+// xpathModule is the module from which XPathSchema is parsed. It is of the
+// form of an OpenConfig module, whose interface list is keyed by a leafref
+// to the name leaf of its config container.
+const xpathModule = `
+module xpath-test {
+  prefix "xt";
+  namespace "urn:xt";
+
+  identity BASE;
+
+  identity PARENT {
+    base BASE;
+  }
+
+  identity E_VALUE_FORTY_TWO {
+    base PARENT;
+  }
+
+  container interfaces {
+    list interface {
+      key "name";
+
+      leaf name {
+        type leafref {
+          path "../config/name";
+        }
+      }
+
+      container config {
+        leaf name {
+          type string;
+        }
+
+        leaf mtu {
+          type uint32;
+        }
+
+        leaf type {
+          type identityref {
+            base BASE;
+          }
+        }
+
+        leaf enabled {
+          type boolean;
+        }
+      }
+    }
+  }
+
+  container system {
+    leaf hostname {
+      type string;
+    }
+
+    leaf-list server {
+      type string;
+    }
+
+    leaf ref {
+      type leafref {
+        path "/interfaces/interface/name";
+      }
+    }
+  }
+}
+`
+
+// XPathSchema returns the schema of the xpath-test module, which is used to
+// test the evaluation of XPath expressions such as must and when statements.
+// The AST nodes of its entries are removed, as per a schema that is
+// unmarshalled from the JSON generated by ygen, hence the statements of each
+// entry, including leaves, are read from its Extra field. A new schema is
+// parsed for each call, such that tests can add statements to its entries.
+func XPathSchema(t testing.TB) *yang.Entry {
+	t.Helper()
+	e := ParseModule(t, "xpath-test", xpathModule)
+	removeNodes(e)
+	return e
+}
+
+// removeNodes removes the AST node of e and each of its descendants.
+func removeNodes(e *yang.Entry) {
+	e.Node = nil
+	for _, c := range e.Dir {
+		removeNodes(c)
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"fmt"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// rcError is an error that is returned to a client as an entry of the
+// "errors" container of the ietf-restconf module (RFC 8040 section 7.1),
+// along with an HTTP status code.
+type rcError struct {
+	// code is the HTTP status code of the response.
+	code int
+	// Type is the protocol layer at which the error occurred, either
+	// "protocol" or "application".
+	Type string `json:"error-type"`
+	// Tag is the error-tag of the error, such as "invalid-value".
+	Tag string `json:"error-tag"`
+	// Message is a description of the error.
+	Message string `json:"error-message,omitempty"`
+}
+
+// newError returns an error with the supplied HTTP status code, error-type
+// and error-tag, whose message is formed from format and args.
+func newError(code int, errType, tag, format string, args ...interface{}) *rcError {
+	return &rcError{code: code, Type: errType, Tag: tag, Message: fmt.Sprintf(format, args...)}
+}
+
+// Error implements the error interface.
+func (e *rcError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Tag, e.Message)
+}
+
+// toRCError returns err as an rcError. Errors of other types are reported
+// as an operation-failed error.
+func toRCError(err error) *rcError {
+	if e, ok := err.(*rcError); ok {
+		return e
+	}
+	return newError(http.StatusInternalServerError, "application", "operation-failed", "%v", err)
+}
+
+// statusError returns the rcError that corresponds to the gRPC status error
+// err, which is returned by the functions of the ytypes package.
+func statusError(err error) *rcError {
+	s := status.Convert(err)
+	switch s.Code() {
+	case codes.InvalidArgument, codes.NotFound:
+		return newError(http.StatusBadRequest, "application", "invalid-value", "%s", s.Message())
+	case codes.Unimplemented:
+		return newError(http.StatusNotImplemented, "application", "operation-not-supported", "%s", s.Message())
+	}
+	return newError(http.StatusInternalServerError, "application", "operation-failed", "%s", s.Message())
+}
+
+// missingError returns err if it is not nil, and otherwise returns the
+// data-missing error for the data resource at path p.
+func missingError(p *gnmipb.Path, err error) error {
+	if err != nil {
+		return err
+	}
+	return newError(http.StatusNotFound, "application", "data-missing", "data resource %s does not exist", pathString(p))
+}
+
+// writeError writes err to w as an ietf-restconf:errors message body.
+func writeError(w http.ResponseWriter, err error) {
+	e := toRCError(err)
+	writeJSON(w, e.code, map[string]interface{}{
+		"ietf-restconf:errors": map[string]interface{}{
+			"error": []*rcError{e},
+		},
+	})
+}
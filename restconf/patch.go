@@ -0,0 +1,144 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+)
+
+// yangPatch is the yang-patch container of the ietf-yang-patch module
+// (RFC 8072 section 2.2).
+type yangPatch struct {
+	ID      string       `json:"patch-id"`
+	Comment string       `json:"comment,omitempty"`
+	Edits   []*patchEdit `json:"edit"`
+}
+
+// patchEdit is an entry of the edit list of a YANG Patch.
+type patchEdit struct {
+	ID        string `json:"edit-id"`
+	Operation string `json:"operation"`
+	// Target is the data resource identifier of the target of the edit,
+	// relative to the target resource of the request.
+	Target string `json:"target"`
+	// Point is the data resource identifier of the list entry relative to
+	// which an insert or move edit positions the target, relative to the
+	// target resource of the request.
+	Point string `json:"point,omitempty"`
+	Where string `json:"where,omitempty"`
+	// Value is the RFC 7951 encoded value of the target of the edit.
+	Value interface{} `json:"value,omitempty"`
+}
+
+// yangPatch serves a PATCH request whose body is a YANG Patch. The edits
+// within the patch are applied in order by ytypes.ApplyYANGPatch, and the
+// result is committed only if all of them succeed and the result is valid.
+// The response body is the yang-patch-status for the patch, in which the
+// edit that failed, if any, is reported.
+func (s *Server) yangPatch(w http.ResponseWriter, r *http.Request, id string) error {
+	var body struct {
+		Patch *yangPatch `json:"ietf-yang-patch:yang-patch"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return newError(http.StatusBadRequest, "protocol", "malformed-message", "cannot decode YANG Patch: %v", err)
+	}
+	yp := body.Patch
+	if yp == nil {
+		return newError(http.StatusBadRequest, "protocol", "malformed-message", "message body does not contain an ietf-yang-patch:yang-patch member")
+	}
+
+	// The targets and points of the edits are made relative to the
+	// datastore resource, which is the root that the patch is applied to.
+	base := strings.TrimSuffix(id, "/")
+	for _, e := range yp.Edits {
+		e.Target = base + e.Target
+		if e.Point != "" {
+			e.Point = base + e.Point
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return newError(http.StatusInternalServerError, "application", "operation-failed", "cannot encode YANG Patch: %v", err)
+	}
+
+	var failed string
+	err = s.write(func(root ygot.ValidatedGoStruct) error {
+		failed = ""
+		if err := ytypes.ApplyYANGPatch(s.schema, root, b); err != nil {
+			e, ok := err.(*ytypes.YANGPatchError)
+			if !ok {
+				return toRCError(err)
+			}
+			failed = e.EditID
+			return patchError(e)
+		}
+		return nil
+	})
+
+	status := map[string]interface{}{"patch-id": yp.ID}
+	code := http.StatusOK
+	switch {
+	case err == nil:
+		status["ok"] = []interface{}{nil}
+	case failed != "":
+		e := toRCError(err)
+		code = e.code
+		status["edit-status"] = map[string]interface{}{
+			"edit": []interface{}{map[string]interface{}{
+				"edit-id": failed,
+				"errors":  map[string]interface{}{"error": []*rcError{e}},
+			}},
+		}
+	default:
+		e := toRCError(err)
+		code = e.code
+		status["errors"] = map[string]interface{}{"error": []*rcError{e}}
+	}
+	writeJSON(w, code, map[string]interface{}{"ietf-yang-patch:yang-patch-status": status})
+	return nil
+}
+
+// patchErrorCodes maps the error-tag of an error that is returned by
+// ytypes.ApplyYANGPatch to the HTTP status code of the response, as per
+// RFC 8040 section 7.
+var patchErrorCodes = map[string]int{
+	ytypes.ErrorTagDataExists:            http.StatusConflict,
+	ytypes.ErrorTagDataMissing:           http.StatusConflict,
+	ytypes.ErrorTagOperationNotSupported: http.StatusNotImplemented,
+	ytypes.ErrorTagOperationFailed:       http.StatusInternalServerError,
+}
+
+// patchError returns the rcError that corresponds to the error e, which is
+// returned by ytypes.ApplyYANGPatch. Errors whose error-tag is not within
+// patchErrorCodes are reported with the 400 status code.
+func patchError(e *ytypes.YANGPatchError) *rcError {
+	code, ok := patchErrorCodes[e.Tag]
+	if !ok {
+		code = http.StatusBadRequest
+	}
+	errType := "application"
+	if e.Tag == ytypes.ErrorTagMalformedMessage || e.Tag == ytypes.ErrorTagUnknownNamespace {
+		errType = "protocol"
+	}
+	if e.Path == "" {
+		return newError(code, errType, e.Tag, "%s", e.Message)
+	}
+	return newError(code, errType, e.Tag, "%s: %s", e.Path, e.Message)
+}
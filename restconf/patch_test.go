@@ -0,0 +1,177 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestYANGPatch(t *testing.T) {
+	tests := []struct {
+		desc       string
+		path       string
+		body       string
+		wantCode   int
+		wantStatus string
+		// checkPath is retrieved after the request, and must return
+		// wantJSON.
+		checkPath string
+		wantJSON  string
+	}{{
+		desc: "multiple edits",
+		path: "/restconf/data/dev:interfaces",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "e1", "operation": "create", "target": "/interface=eth1", "value": {"dev:interface": [{"name": "eth1", "mtu": 9000}]}},
+			{"edit-id": "e2", "operation": "merge", "target": "/interface=eth0", "value": {"dev:interface": [{"name": "eth0", "mtu": 1400}]}},
+			{"edit-id": "e3", "operation": "replace", "target": "/interface=eth2", "value": {"dev:interface": [{"name": "eth2", "mtu": 1500}]}},
+			{"edit-id": "e4", "operation": "remove", "target": "/interface=eth3"}
+		]}}`,
+		wantCode:   http.StatusOK,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p1", "ok": [null]}}`,
+		checkPath:  "/restconf/data/dev:interfaces?content=config",
+		wantJSON: `{"dev:interfaces": {"interface": [
+			{"name": "eth0", "mtu": 1400},
+			{"name": "eth1", "mtu": 9000},
+			{"name": "eth2", "mtu": 1500}
+		]}}`,
+	}, {
+		desc: "edits of datastore",
+		path: "/restconf/data",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p2", "edit": [
+			{"edit-id": "e1", "operation": "delete", "target": "/dev:routes/route=10.0.0.0%2F8,red"},
+			{"edit-id": "e2", "operation": "replace", "target": "/dev:system", "value": {"dev:system": {"hostname": "rtr2"}}}
+		]}}`,
+		wantCode:   http.StatusOK,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p2", "ok": [null]}}`,
+		checkPath:  "/restconf/data?depth=2",
+		wantJSON: `{"ietf-restconf:data": {
+			"dev:interfaces": {"interface": [{"name": "eth0"}]},
+			"dev:system": {"hostname": "rtr2"}
+		}}`,
+	}, {
+		desc: "failed edit",
+		path: "/restconf/data/dev:interfaces",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p3", "edit": [
+			{"edit-id": "e1", "operation": "merge", "target": "/interface=eth0/mtu", "value": {"dev:mtu": 9000}},
+			{"edit-id": "e2", "operation": "create", "target": "/interface=eth0", "value": {"dev:interface": [{"name": "eth0"}]}}
+		]}}`,
+		wantCode: http.StatusConflict,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p3", "edit-status": {"edit": [{"edit-id": "e2", "errors": {"error": [{
+			"error-type": "application",
+			"error-tag": "data-exists",
+			"error-message": "/dev:interfaces/interface=eth0: data already exists"
+		}]}}]}}}`,
+		checkPath: "/restconf/data/dev:interfaces/interface=eth0/mtu",
+		wantJSON:  `{"dev:mtu": 1500}`,
+	}, {
+		desc: "invalid result",
+		path: "/restconf/data/dev:system",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p4", "edit": [
+			{"edit-id": "e1", "operation": "merge", "target": "/uplink", "value": {"dev:uplink": "eth1"}}
+		]}}`,
+		wantCode: http.StatusBadRequest,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p4", "errors": {"error": [{
+			"error-type": "application",
+			"error-tag": "invalid-value",
+			"error-message": "invalid data tree: field name Uplink value eth1 (string ptr) schema path /device/system/uplink has leafref path /interfaces/interface/name not equal to any target nodes"
+		}]}}}`,
+	}, {
+		desc: "insert and move entries of ordered leaf-list",
+		path: "/restconf/data/dev:system",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p5", "edit": [
+			{"edit-id": "e1", "operation": "insert", "target": "/dns=10.0.0.1", "where": "last", "value": {"dev:dns": ["10.0.0.1"]}},
+			{"edit-id": "e2", "operation": "insert", "target": "/dns=10.0.0.2", "where": "before", "point": "/dns=10.0.0.1", "value": {"dev:dns": ["10.0.0.2"]}},
+			{"edit-id": "e3", "operation": "insert", "target": "/dns=10.0.0.3", "where": "first", "value": {"dev:dns": ["10.0.0.3"]}},
+			{"edit-id": "e4", "operation": "move", "target": "/dns=10.0.0.3", "where": "after", "point": "/dns=10.0.0.1"}
+		]}}`,
+		wantCode:   http.StatusOK,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p5", "ok": [null]}}`,
+		checkPath:  "/restconf/data/dev:system/dns",
+		wantJSON:   `{"dev:dns": ["10.0.0.2", "10.0.0.1", "10.0.0.3"]}`,
+	}, {
+		desc: "edit of node in other module",
+		path: "/restconf/data/dev:system",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p7", "edit": [
+			{"edit-id": "e1", "operation": "replace", "target": "/ext:location", "value": {"ext:location": "dc1"}}
+		]}}`,
+		wantCode:   http.StatusOK,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p7", "ok": [null]}}`,
+		checkPath:  "/restconf/data/dev:system/ext:location",
+		wantJSON:   `{"ext:location": "dc1"}`,
+	}, {
+		desc: "target qualified by wrong module",
+		path: "/restconf/data",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p8", "edit": [
+			{"edit-id": "e1", "operation": "replace", "target": "/ext:system/hostname", "value": {"dev:hostname": "rtr2"}}
+		]}}`,
+		wantCode: http.StatusBadRequest,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p8", "edit-status": {"edit": [{"edit-id": "e1", "errors": {"error": [{
+			"error-type": "protocol",
+			"error-tag": "unknown-namespace",
+			"error-message": "/ext:system/hostname: data node system is not defined in module ext"
+		}]}}]}}}`,
+		checkPath: "/restconf/data/dev:system/hostname",
+		wantJSON:  `{"dev:hostname": "rtr1"}`,
+	}, {
+		desc: "insert into unordered list",
+		path: "/restconf/data/dev:interfaces",
+		body: `{"ietf-yang-patch:yang-patch": {"patch-id": "p6", "edit": [
+			{"edit-id": "e1", "operation": "insert", "target": "/interface=eth1", "point": "/interface=eth0", "where": "after", "value": {"dev:interface": [{"name": "eth1"}]}}
+		]}}`,
+		wantCode: http.StatusNotImplemented,
+		wantStatus: `{"ietf-yang-patch:yang-patch-status": {"patch-id": "p6", "edit-status": {"edit": [{"edit-id": "e1", "errors": {"error": [{
+			"error-type": "application",
+			"error-tag": "operation-not-supported",
+			"error-message": "/dev:interfaces/interface=eth1: operation insert is only supported for entries of ordered-by user lists"
+		}]}}]}}}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, ts := startServer(t)
+			defer ts.Close()
+
+			resp, body := do(t, ts, http.MethodPatch, tt.path, mediaYANGPatch, tt.body)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("PATCH %s: got status %d, want %d, body: %s", tt.path, resp.StatusCode, tt.wantCode, body)
+			}
+			checkJSON(t, body, tt.wantStatus)
+			if tt.checkPath == "" {
+				return
+			}
+			resp, body = do(t, ts, http.MethodGet, tt.checkPath, "", "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET %s: got status %d, body: %s", tt.checkPath, resp.StatusCode, body)
+			}
+			checkJSON(t, body, tt.wantJSON)
+		})
+	}
+}
+
+func TestYANGPatchMalformed(t *testing.T) {
+	_, ts := startServer(t)
+	defer ts.Close()
+	for _, body := range []string{`{"ietf-yang-patch:yang-patch": [}`, `{"patch-id": "p1"}`} {
+		resp, got := do(t, ts, http.MethodPatch, "/restconf/data", mediaYANGPatch, body)
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("PATCH with body %s: got status %d, want %d", body, resp.StatusCode, http.StatusBadRequest)
+			continue
+		}
+		if tag := errorTag(t, got); tag != "malformed-message" {
+			t.Errorf("PATCH with body %s: got error-tag %s, want malformed-message", body, tag)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// parsePath returns the gNMI path and the schema of the data resource that
+// is identified by id, which is a data resource identifier with its
+// percent-encoding intact (RFC 8040 section 3.5.3), such as
+// "/mod:interfaces/interface=eth0%2F1". The key values of a list are
+// separated by commas, and are listed in the order of the list's key
+// statement. An empty identifier identifies the datastore resource, for which
+// a path without elements and the root schema are returned.
+func (s *Server) parsePath(id string) (*gnmipb.Path, *yang.Entry, error) {
+	segs, err := util.ParseResourceID(id)
+	if err != nil {
+		return nil, nil, pathError("%v", err)
+	}
+	p := &gnmipb.Path{}
+	e := s.schema
+	for _, seg := range segs {
+		name, hasKeys := seg.Name, seg.Keys != nil
+		c := childSchema(e, util.StripModulePrefix(name))
+		if c == nil {
+			return nil, nil, pathError("unknown data node %s in %q", name, id)
+		}
+		if i := strings.IndexByte(name, ':'); i >= 0 {
+			mod, err := util.EntryModule(c)
+			if err != nil {
+				return nil, nil, toRCError(err)
+			}
+			if mod != name[:i] {
+				return nil, nil, newError(http.StatusBadRequest, "protocol", "unknown-namespace", "data node %s is not defined in module %s", c.Name, name[:i])
+			}
+		}
+
+		pe := &gnmipb.PathElem{Name: c.Name}
+		switch {
+		case util.IsKeyedList(c):
+			if !hasKeys {
+				return nil, nil, pathError("list %s in %q must be identified by its keys", c.Name, id)
+			}
+			keys := strings.Fields(c.Key)
+			if len(seg.Keys) != len(keys) {
+				return nil, nil, pathError("list %s in %q has keys %v, got %d key values", c.Name, id, keys, len(seg.Keys))
+			}
+			pe.Key = map[string]string{}
+			for i, k := range keys {
+				pe.Key[k] = keyValue(c.Dir[k], seg.Keys[i])
+			}
+		case c.IsList():
+			return nil, nil, pathError("entries of list %s in %q cannot be identified, since it has no keys", c.Name, id)
+		case hasKeys:
+			return nil, nil, pathError("data node %s in %q cannot be identified by a value", c.Name, id)
+		}
+		p.Elem = append(p.Elem, pe)
+		e = c
+	}
+	return p, e, nil
+}
+
+// resourceID returns the data resource identifier of the data node at path
+// p, which is the inverse of parsePath. The first element, and each element
+// that is defined in a different module than its parent, is qualified with
+// its module name. An error is returned if the module of an element cannot
+// be determined.
+func (s *Server) resourceID(p *gnmipb.Path) (string, error) {
+	var elems []*util.ResourceIDElem
+	e, pmod := s.schema, ""
+	for _, pe := range p.GetElem() {
+		if e = childSchema(e, pe.GetName()); e == nil {
+			// The path was created by the server, hence this is not
+			// expected; the remainder of the path is not qualified.
+			elems = append(elems, &util.ResourceIDElem{Name: pe.GetName()})
+			continue
+		}
+		re := &util.ResourceIDElem{Name: e.Name}
+		mod, err := util.EntryModule(e)
+		if err != nil {
+			return "", err
+		}
+		if mod != pmod {
+			re.Name = mod + ":" + e.Name
+			pmod = mod
+		}
+		if e.IsList() {
+			re.Keys = []string{}
+			for _, k := range strings.Fields(e.Key) {
+				re.Keys = append(re.Keys, pe.GetKey()[k])
+			}
+		}
+		elems = append(elems, re)
+	}
+	return util.ResourceID(elems), nil
+}
+
+// keyValue returns the value of the key whose schema is e, and whose RFC
+// 7951 encoded value is v, as used within a gNMI path. The module name of
+// an identityref value is removed, since it is not included in the paths
+// that ygot generates.
+func keyValue(e *yang.Entry, v string) string {
+	if e == nil {
+		return v
+	}
+	if t, err := util.ResolveIfLeafRef(e); err == nil && t.Type != nil && t.Type.Kind == yang.Yidentityref {
+		return util.StripModulePrefix(v)
+	}
+	return v
+}
+
+// entryKeys returns the keys of the entry of the list whose schema is e,
+// which are found within the RFC 7951 encoded entry v.
+func entryKeys(e *yang.Entry, v interface{}) (map[string]string, error) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, newError(http.StatusBadRequest, "application", "invalid-value", "entry of list %s must be an object, got %T", e.Name, v)
+	}
+	keys := map[string]string{}
+	for _, k := range strings.Fields(e.Key) {
+		kv, ok := member(m, k)
+		if !ok {
+			return nil, newError(http.StatusBadRequest, "application", "missing-element", "entry of list %s does not contain key %s", e.Name, k)
+		}
+		var s string
+		switch kv := kv.(type) {
+		case string:
+			s = kv
+		case float64:
+			s = strconv.FormatFloat(kv, 'f', -1, 64)
+		case bool:
+			s = strconv.FormatBool(kv)
+		default:
+			return nil, newError(http.StatusBadRequest, "application", "invalid-value", "invalid value %v for key %s of list %s", kv, k, e.Name)
+		}
+		keys[k] = keyValue(e.Dir[k], s)
+	}
+	return keys, nil
+}
+
+// childSchema returns the schema of the data node named name that is a
+// child of the data node whose schema is e, looking through any choice and
+// case statements, or nil if there is no such child.
+func childSchema(e *yang.Entry, name string) *yang.Entry {
+	if c := e.Dir[name]; c != nil && !util.IsChoiceOrCase(c) {
+		return c
+	}
+	for _, c := range util.FindFirstNonChoiceOrCase(e) {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// schemaAt returns the schema of the data node at path p relative to the
+// node whose schema is e, or nil if there is no such node.
+func schemaAt(e *yang.Entry, p *gnmipb.Path) *yang.Entry {
+	for _, pe := range p.GetElem() {
+		if e = childSchema(e, util.StripModulePrefix(pe.GetName())); e == nil {
+			return nil
+		}
+	}
+	return e
+}
+
+// pathString returns the string form of the path p, for use in errors.
+func pathString(p *gnmipb.Path) string {
+	if len(p.GetElem()) == 0 {
+		return "/"
+	}
+	s, err := ygot.PathToString(p)
+	if err != nil {
+		return proto.CompactTextString(p)
+	}
+	return s
+}
+
+// pathError returns an error for an invalid data resource identifier.
+func pathError(format string, args ...interface{}) error {
+	return newError(http.StatusBadRequest, "protocol", "invalid-value", format, args...)
+}
@@ -0,0 +1,150 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/devicetest"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		desc           string
+		in             string
+		want           *gnmipb.Path
+		wantSchema     string
+		wantErrSubstr  string
+		wantResourceID string
+	}{{
+		desc:       "datastore",
+		in:         "",
+		want:       &gnmipb.Path{},
+		wantSchema: "device",
+	}, {
+		desc:           "container",
+		in:             "/dev:system",
+		want:           &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "system"}}},
+		wantSchema:     "system",
+		wantResourceID: "/dev:system",
+	}, {
+		desc: "leaf in other module",
+		in:   "/dev:system/ext:location",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "system"},
+			{Name: "location"},
+		}},
+		wantSchema:     "location",
+		wantResourceID: "/dev:system/ext:location",
+	}, {
+		desc: "unqualified identifiers",
+		in:   "/system/hostname",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "system"},
+			{Name: "hostname"},
+		}},
+		wantSchema:     "hostname",
+		wantResourceID: "/dev:system/hostname",
+	}, {
+		desc: "list entry",
+		in:   "/dev:interfaces/interface=eth0/mtu",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "interfaces"},
+			{Name: "interface", Key: map[string]string{"name": "eth0"}},
+			{Name: "mtu"},
+		}},
+		wantSchema:     "mtu",
+		wantResourceID: "/dev:interfaces/interface=eth0/mtu",
+	}, {
+		desc: "multiple keys with reserved characters",
+		in:   "/dev:routes/route=10.0.0.0%2F8,a%2Cb%3D%20c",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "routes"},
+			{Name: "route", Key: map[string]string{"prefix": "10.0.0.0/8", "vrf": "a,b= c"}},
+		}},
+		wantSchema:     "route",
+		wantResourceID: "/dev:routes/route=10.0.0.0%2F8,a%2Cb%3D%20c",
+	}, {
+		desc: "empty key value",
+		in:   "/dev:routes/route=,red",
+		want: &gnmipb.Path{Elem: []*gnmipb.PathElem{
+			{Name: "routes"},
+			{Name: "route", Key: map[string]string{"prefix": "", "vrf": "red"}},
+		}},
+		wantSchema:     "route",
+		wantResourceID: "/dev:routes/route=,red",
+	}, {
+		desc:          "unknown data node",
+		in:            "/dev:system/domain",
+		wantErrSubstr: "unknown data node domain",
+	}, {
+		desc:          "wrong module",
+		in:            "/ext:system",
+		wantErrSubstr: "not defined in module ext",
+	}, {
+		desc:          "list without keys",
+		in:            "/dev:interfaces/interface",
+		wantErrSubstr: "must be identified by its keys",
+	}, {
+		desc:          "wrong number of keys",
+		in:            "/dev:routes/route=10.0.0.0%2F8",
+		wantErrSubstr: "got 1 key values",
+	}, {
+		desc:          "value for container",
+		in:            "/dev:system=rtr1",
+		wantErrSubstr: "cannot be identified by a value",
+	}, {
+		desc:          "child of leaf",
+		in:            "/dev:system/hostname/value",
+		wantErrSubstr: "unknown data node value",
+	}, {
+		desc:          "relative identifier",
+		in:            "dev:system",
+		wantErrSubstr: "must start with /",
+	}}
+
+	s, err := New(devicetest.NewSchema())
+	if err != nil {
+		t.Fatalf("New: got unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, gotSchema, err := s.parsePath(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("parsePath(%q): %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("parsePath(%q): got path %s, want %s", tt.in, proto.CompactTextString(got), proto.CompactTextString(tt.want))
+			}
+			if gotSchema.Name != tt.wantSchema {
+				t.Errorf("parsePath(%q): got schema %s, want %s", tt.in, gotSchema.Name, tt.wantSchema)
+			}
+			gotID, err := s.resourceID(got)
+			if err != nil {
+				t.Fatalf("resourceID(%q): got unexpected error: %v", tt.in, err)
+			}
+			if gotID != tt.wantResourceID {
+				t.Errorf("resourceID(%q): got %q, want %q", tt.in, gotID, tt.wantResourceID)
+			}
+		})
+	}
+}
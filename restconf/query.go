@@ -0,0 +1,299 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+)
+
+// maxDepth is the maximum value of the depth query parameter.
+const maxDepth = 65535
+
+// query is the set of query parameters of a GET request, which determine the
+// descendants of the target resource that are returned (RFC 8040 section
+// 4.8).
+type query struct {
+	// depth is the maximum depth of the returned data nodes, where the
+	// target resource has depth 1. Zero indicates that the depth is
+	// unbounded.
+	depth int
+	// fields is the set of selected descendants of the target resource, or
+	// nil if all descendants are selected.
+	fields fieldSet
+	// content is the class of data nodes that are returned, which is one
+	// of "all", "config" or "nonconfig".
+	content string
+}
+
+// fieldSet is a set of selected data nodes, keyed by name. The value of each
+// entry is the set of selected descendants of the node, or nil if all of
+// its descendants are selected.
+type fieldSet map[string]fieldSet
+
+// parseQuery returns the query that is specified by the raw query string of
+// a request URI. The query string is parsed directly, rather than by using
+// url.ParseQuery, since the value of the fields parameter may contain
+// semicolons.
+func parseQuery(raw string) (*query, error) {
+	q := &query{content: "all"}
+	seen := map[string]bool{}
+	for _, kv := range strings.Split(raw, "&") {
+		if kv == "" {
+			continue
+		}
+		var rv string
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			kv, rv = kv[:i], kv[i+1:]
+		}
+		k, err := url.QueryUnescape(kv)
+		if err != nil {
+			return nil, queryError("invalid query parameter %q", kv)
+		}
+		v, err := url.QueryUnescape(rv)
+		if err != nil {
+			return nil, queryError("invalid value %q for query parameter %s", rv, k)
+		}
+		if seen[k] {
+			return nil, queryError("query parameter %s must be specified at most once", k)
+		}
+		seen[k] = true
+		switch k {
+		case "depth":
+			if v == "unbounded" {
+				continue
+			}
+			d, err := strconv.Atoi(v)
+			if err != nil || d < 1 || d > maxDepth {
+				return nil, queryError("invalid depth %q, must be unbounded or between 1 and %d", v, maxDepth)
+			}
+			q.depth = d
+		case "fields":
+			fs, err := parseFields(v)
+			if err != nil {
+				return nil, err
+			}
+			q.fields = fs
+		case "content":
+			switch v {
+			case "all", "config", "nonconfig":
+				q.content = v
+			default:
+				return nil, queryError("invalid content %q, must be all, config or nonconfig", v)
+			}
+		default:
+			return nil, queryError("unsupported query parameter %s", k)
+		}
+	}
+	return q, nil
+}
+
+// filter returns the RFC 7951 encoded value v of the target resource, whose
+// schema is e, with the descendants that are not selected by q removed, and
+// whether the target resource itself is selected.
+func (q *query) filter(e *yang.Entry, v interface{}) (interface{}, bool) {
+	return q.node(e, v, q.fields, 1)
+}
+
+// filterData returns the RFC 7951 encoded contents m of the datastore
+// resource, whose schema is e, with the data nodes that are not selected by
+// q removed. The top-level data nodes have depth 1.
+func (q *query) filterData(e *yang.Entry, m map[string]interface{}) map[string]interface{} {
+	out, _ := q.object(e, m, q.fields, 0)
+	return out
+}
+
+// node returns the RFC 7951 encoded value v of the data node whose schema
+// is e, and whose depth is level, with the descendants that are not selected
+// by q removed, and whether the node is retained. sel is the set of selected
+// descendants of the node, or nil if all are selected.
+func (q *query) node(e *yang.Entry, v interface{}, sel fieldSet, level int) (interface{}, bool) {
+	switch {
+	case q.content == "config" && !util.IsConfig(e):
+		return nil, false
+	case q.content == "nonconfig" && util.IsConfig(e) && !e.IsDir():
+		// A configuration container or list may have non-configuration
+		// descendants, hence only configuration leaves are removed.
+		return nil, false
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return q.object(e, v, sel, level)
+	case []interface{}:
+		if !e.IsList() {
+			return v, true
+		}
+		var out []interface{}
+		for _, le := range v {
+			m, ok := le.(map[string]interface{})
+			if !ok {
+				out = append(out, le)
+				continue
+			}
+			if fm, ok := q.object(e, m, sel, level); ok {
+				out = append(out, fm)
+			}
+		}
+		return out, len(out) != 0
+	}
+	return v, true
+}
+
+// object returns the object m, which encodes the container or list entry
+// whose schema is e, and whose depth is level, with the members that are
+// not selected by q removed, and whether it is retained. A container or list
+// entry is not retained if all of its members are removed by the fields or
+// content parameters, whereas it is retained if they are removed only due to
+// the depth parameter. The keys of a list entry are always retained. As per
+// RFC 8040, the nodes that are selected by the fields parameter, and their
+// ancestors, have depth 1.
+func (q *query) object(e *yang.Entry, m map[string]interface{}, sel fieldSet, level int) (map[string]interface{}, bool) {
+	keys := map[string]bool{}
+	if e.IsList() {
+		keys = util.ListKeyFieldsMap(e)
+	}
+	out := map[string]interface{}{}
+	var kept int
+	var removed bool
+	for k, v := range m {
+		name := util.StripModulePrefix(k)
+		if keys[name] {
+			out[k] = v
+			continue
+		}
+		cl, csel := level+1, fieldSet(nil)
+		if sel != nil {
+			s, ok := sel[name]
+			if !ok {
+				removed = true
+				continue
+			}
+			cl, csel = 1, s
+		}
+		if q.depth != 0 && cl > q.depth {
+			continue
+		}
+		c := childSchema(e, name)
+		if c == nil {
+			// Members that are not data nodes, such as metadata
+			// annotations, are retained.
+			out[k] = v
+			continue
+		}
+		cv, ok := q.node(c, v, csel, cl)
+		if !ok {
+			removed = true
+			continue
+		}
+		out[k] = cv
+		kept++
+	}
+	return out, kept != 0 || !removed
+}
+
+// parseFields parses the value s of the fields query parameter, whose
+// syntax is specified by RFC 8040 section 4.8.3, for example
+// "mod:a/b(c;d/e);f". Module names are ignored.
+func parseFields(s string) (fieldSet, error) {
+	p := &fieldsParser{s: s}
+	fs, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(s) {
+		return nil, queryError("invalid fields %q, unexpected %q at offset %d", s, s[p.pos], p.pos)
+	}
+	return fs, nil
+}
+
+// fieldsParser is a recursive descent parser for the value of the fields
+// query parameter.
+type fieldsParser struct {
+	s   string
+	pos int
+}
+
+// expr parses a fields-expr, which is a list of paths that are separated by
+// semicolons.
+func (p *fieldsParser) expr() (fieldSet, error) {
+	fs := fieldSet{}
+	for {
+		if err := p.path(fs); err != nil {
+			return nil, err
+		}
+		if p.pos == len(p.s) || p.s[p.pos] != ';' {
+			return fs, nil
+		}
+		p.pos++
+	}
+}
+
+// path parses a path, which may be followed by a parenthesised fields-expr
+// that selects descendants of its last node, and adds the nodes that it
+// selects to fs.
+func (p *fieldsParser) path(fs fieldSet) error {
+	start := p.pos
+	for p.pos < len(p.s) && !strings.ContainsRune("/();", rune(p.s[p.pos])) {
+		p.pos++
+	}
+	if p.pos == start {
+		return queryError("invalid fields %q, expected identifier at offset %d", p.s, start)
+	}
+	name := util.StripModulePrefix(p.s[start:p.pos])
+
+	if p.pos == len(p.s) || (p.s[p.pos] != '/' && p.s[p.pos] != '(') {
+		fs[name] = nil
+		return nil
+	}
+	sub, ok := fs[name]
+	switch {
+	case ok && sub == nil:
+		// All descendants of the node are already selected, hence the
+		// remainder of the path is parsed, but has no effect.
+		sub = fieldSet{}
+	case !ok:
+		sub = fieldSet{}
+		fs[name] = sub
+	}
+
+	if p.s[p.pos] == '/' {
+		p.pos++
+		return p.path(sub)
+	}
+	p.pos++
+	e, err := p.expr()
+	if err != nil {
+		return err
+	}
+	if p.pos == len(p.s) || p.s[p.pos] != ')' {
+		return queryError("invalid fields %q, expected ) at offset %d", p.s, p.pos)
+	}
+	p.pos++
+	for k, v := range e {
+		sub[k] = v
+	}
+	return nil
+}
+
+// queryError returns an error for an invalid query parameter.
+func queryError(format string, args ...interface{}) error {
+	return newError(http.StatusBadRequest, "protocol", "invalid-value", format, args...)
+}
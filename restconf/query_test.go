@@ -0,0 +1,201 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/devicetest"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		desc          string
+		in            string
+		want          *query
+		wantErrSubstr string
+	}{{
+		desc: "empty",
+		want: &query{content: "all"},
+	}, {
+		desc: "all parameters",
+		in:   "depth=3&content=nonconfig&fields=a;b/c",
+		want: &query{
+			depth:   3,
+			content: "nonconfig",
+			fields:  fieldSet{"a": nil, "b": fieldSet{"c": nil}},
+		},
+	}, {
+		desc: "percent-encoded fields",
+		in:   "fields=a%3Bb",
+		want: &query{content: "all", fields: fieldSet{"a": nil, "b": nil}},
+	}, {
+		desc: "unbounded depth",
+		in:   "depth=unbounded",
+		want: &query{content: "all"},
+	}, {
+		desc:          "depth out of range",
+		in:            "depth=65536",
+		wantErrSubstr: "invalid depth",
+	}, {
+		desc:          "invalid content",
+		in:            "content=state",
+		wantErrSubstr: "invalid content",
+	}, {
+		desc:          "repeated parameter",
+		in:            "depth=1&depth=2",
+		wantErrSubstr: "at most once",
+	}, {
+		desc:          "unsupported parameter",
+		in:            "insert=first",
+		wantErrSubstr: "unsupported query parameter insert",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseQuery(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("parseQuery(%q): %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(query{})); diff != "" {
+				t.Errorf("parseQuery(%q): did not get expected query, diff(-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		desc          string
+		in            string
+		want          fieldSet
+		wantErrSubstr string
+	}{{
+		desc: "single node",
+		in:   "mod:a",
+		want: fieldSet{"a": nil},
+	}, {
+		desc: "paths",
+		in:   "a/b;a/c/d;e",
+		want: fieldSet{"a": fieldSet{"b": nil, "c": fieldSet{"d": nil}}, "e": nil},
+	}, {
+		desc: "nested expression",
+		in:   "a(b;c/d);e",
+		want: fieldSet{"a": fieldSet{"b": nil, "c": fieldSet{"d": nil}}, "e": nil},
+	}, {
+		desc: "node and its descendant",
+		in:   "a;a/b",
+		want: fieldSet{"a": nil},
+	}, {
+		desc:          "empty",
+		in:            "",
+		wantErrSubstr: "expected identifier",
+	}, {
+		desc:          "unbalanced parentheses",
+		in:            "a(b",
+		wantErrSubstr: "expected )",
+	}, {
+		desc:          "trailing characters",
+		in:            "a)",
+		wantErrSubstr: "unexpected ')'",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := parseFields(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("parseFields(%q): %s", tt.in, diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseFields(%q): did not get expected fields, diff(-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestFilter(t *testing.T) {
+	interfaces := map[string]interface{}{
+		"interface": []interface{}{
+			map[string]interface{}{"name": "eth0", "mtu": 1500, "counter": "10"},
+			map[string]interface{}{"name": "eth1", "mtu": 9000},
+		},
+	}
+	tests := []struct {
+		desc     string
+		inQuery  string
+		want     interface{}
+		wantKeep bool
+	}{{
+		desc:     "no parameters",
+		want:     interfaces,
+		wantKeep: true,
+	}, {
+		desc:    "depth",
+		inQuery: "depth=2",
+		want: map[string]interface{}{
+			"interface": []interface{}{
+				map[string]interface{}{"name": "eth0"},
+				map[string]interface{}{"name": "eth1"},
+			},
+		},
+		wantKeep: true,
+	}, {
+		desc:    "nonconfig content removes entries without state",
+		inQuery: "content=nonconfig",
+		want: map[string]interface{}{
+			"interface": []interface{}{
+				map[string]interface{}{"name": "eth0", "counter": "10"},
+			},
+		},
+		wantKeep: true,
+	}, {
+		desc:    "fields with depth",
+		inQuery: "fields=interface(mtu)&depth=1",
+		want: map[string]interface{}{
+			"interface": []interface{}{
+				map[string]interface{}{"name": "eth0", "mtu": 1500},
+				map[string]interface{}{"name": "eth1", "mtu": 9000},
+			},
+		},
+		wantKeep: true,
+	}, {
+		desc:    "fields selecting no data",
+		inQuery: "fields=interface(counter)&content=config",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			q, err := parseQuery(tt.inQuery)
+			if err != nil {
+				t.Fatalf("parseQuery(%q): got unexpected error: %v", tt.inQuery, err)
+			}
+			got, keep := q.filter(devicetest.SchemaTree["Interfaces"], interfaces)
+			if keep != tt.wantKeep {
+				t.Errorf("filter with %q: got keep %v, want %v", tt.inQuery, keep, tt.wantKeep)
+			}
+			if !keep {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("filter with %q: did not get expected value, diff(-want, +got):\n%s", tt.inQuery, diff)
+			}
+		})
+	}
+}
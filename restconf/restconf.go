@@ -0,0 +1,569 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package restconf provides an http.Handler that implements the RESTCONF
+// protocol, as specified by RFC 8040, over a data tree that is a GoStruct
+// generated by ygen. Data resources are encoded as JSON as per RFC 7951, and
+// can be retrieved, created, replaced, merged and deleted, including by
+// using YANG Patch (RFC 8072), such that the handler can be used as a test
+// double for a device when testing RESTCONF clients.
+package restconf
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ygot/datastore"
+	"github.com/openconfig/ygot/ytypes"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	// rootPath is the path of the RESTCONF API resource, which is
+	// advertised by the /.well-known/host-meta resource.
+	rootPath = "/restconf"
+	// dataPath is the path of the datastore resource.
+	dataPath = rootPath + "/data"
+	// yangLibraryVersion is the revision of the ietf-yang-library module
+	// that is reported by the server.
+	yangLibraryVersion = "2016-06-21"
+
+	// mediaYANGData is the media type of RFC 7951 encoded data resources.
+	mediaYANGData = "application/yang-data+json"
+	// mediaYANGPatch is the media type of YANG Patch requests.
+	mediaYANGPatch = "application/yang-patch+json"
+)
+
+// Server is a RESTCONF server whose datastore is the data tree of a
+// Datastore. The changes made by each PUT, POST, PATCH and DELETE request
+// are committed to the Datastore within a single transaction, such that each
+// request is applied atomically. A Server is safe for concurrent use.
+type Server struct {
+	// ds is the datastore whose data tree is served.
+	ds *datastore.Datastore
+	// schema is the schema of the root of the data tree of ds.
+	schema *yang.Entry
+}
+
+// New returns a Server that serves a new Datastore whose data tree is
+// initially the Root of the supplied schema, as per datastore.New.
+func New(schema *ytypes.Schema) (*Server, error) {
+	ds, err := datastore.New(schema)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithDatastore(ds), nil
+}
+
+// NewWithDatastore returns a Server that serves the data tree of ds, which
+// may be shared with other users, such as a gNMI target.
+func NewWithDatastore(ds *datastore.Datastore) *Server {
+	return &Server{ds: ds, schema: ds.Schema()}
+}
+
+// Datastore returns the datastore whose data tree is served by s. It allows
+// the state of the simulated device to be changed by the test that uses it.
+func (s *Server) Datastore() *datastore.Datastore {
+	return s.ds
+}
+
+// write calls fn with the data tree of a transaction of the datastore, and
+// commits the transaction if fn returns nil. Errors returned by fn are
+// returned unchanged, and an error committing the transaction is returned
+// as an invalid-value error.
+func (s *Server) write(fn func(root ygot.ValidatedGoStruct) error) error {
+	if _, err := s.ds.Update(fn); err != nil {
+		if e, ok := err.(*rcError); ok {
+			return e
+		}
+		return newError(http.StatusBadRequest, "application", "invalid-value", "%v", err)
+	}
+	return nil
+}
+
+// ServeHTTP implements the http.Handler interface. It serves the
+// /.well-known/host-meta resource, the RESTCONF API resource at /restconf,
+// and the datastore and data resources beneath /restconf/data. No
+// operations are supported.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.EscapedPath()
+	switch {
+	case p == dataPath || strings.HasPrefix(p, dataPath+"/"):
+		s.serveData(w, r, strings.TrimPrefix(p, dataPath))
+	case p == "/.well-known/host-meta":
+		if !allowMethod(w, r, http.MethodGet, http.MethodHead) {
+			return
+		}
+		w.Header().Set("Content-Type", "application/xrd+xml")
+		io.WriteString(w, "<XRD xmlns='http://docs.oasis-open.org/ns/xri/xrd-1.0'>\n  <Link rel='restconf' href='"+rootPath+"'/>\n</XRD>\n")
+	case p == rootPath || p == rootPath+"/":
+		if allowMethod(w, r, http.MethodGet, http.MethodHead) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{
+				"ietf-restconf:restconf": map[string]interface{}{
+					"data":                 map[string]interface{}{},
+					"operations":           map[string]interface{}{},
+					"yang-library-version": yangLibraryVersion,
+				},
+			})
+		}
+	case p == rootPath+"/operations":
+		if allowMethod(w, r, http.MethodGet, http.MethodHead) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ietf-restconf:operations": map[string]interface{}{}})
+		}
+	case p == rootPath+"/yang-library-version":
+		if allowMethod(w, r, http.MethodGet, http.MethodHead) {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"ietf-restconf:yang-library-version": yangLibraryVersion})
+		}
+	default:
+		writeError(w, newError(http.StatusNotFound, "protocol", "invalid-value", "unknown resource %s", p))
+	}
+}
+
+// dataMethods is the set of methods that are supported for the datastore
+// and data resources.
+var dataMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+// serveData serves a request for the datastore or data resource identified
+// by id, which is the part of the request path following /restconf/data.
+func (s *Server) serveData(w http.ResponseWriter, r *http.Request, id string) {
+	if !allowMethod(w, r, dataMethods...) {
+		return
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", strings.Join(dataMethods, ", "))
+		w.Header().Set("Accept-Patch", mediaYANGData+", "+mediaYANGPatch)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	p, ps, err := s.parsePath(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.URL.RawQuery != "" {
+		writeError(w, newError(http.StatusBadRequest, "protocol", "invalid-value", "query parameters are not supported for method %s", r.Method))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		err = s.get(w, r, p, ps)
+	case http.MethodPut:
+		err = s.put(w, r, p, ps)
+	case http.MethodPost:
+		err = s.post(w, r, p, ps)
+	case http.MethodPatch:
+		if mediaType(r) == mediaYANGPatch {
+			err = s.yangPatch(w, r, id)
+		} else {
+			err = s.patch(w, r, p, ps)
+		}
+	case http.MethodDelete:
+		err = s.delete(w, p)
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+// get serves a GET or HEAD request for the resource at path p, whose schema
+// is ps. The depth, fields and content query parameters of the request are
+// applied to the returned data.
+func (s *Server) get(w http.ResponseWriter, r *http.Request, p *gnmipb.Path, ps *yang.Entry) error {
+	q, err := parseQuery(r.URL.RawQuery)
+	if err != nil {
+		return err
+	}
+
+	v, ok, err := s.lookup(s.ds.Snapshot().Tree(), p)
+	switch {
+	case err != nil:
+		return err
+	case !ok:
+		return newError(http.StatusNotFound, "application", "data-missing", "data resource %s does not exist", pathString(p))
+	}
+
+	if len(p.GetElem()) == 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"ietf-restconf:data": q.filterData(ps, v.(map[string]interface{}))})
+		return nil
+	}
+	if ps.IsList() {
+		v = []interface{}{v}
+	}
+	if v, ok = q.filter(ps, v); !ok {
+		return newError(http.StatusNotFound, "application", "data-missing", "data resource %s does not contain any %s data", pathString(p), q.content)
+	}
+	name, err := qualifiedName(ps)
+	if err != nil {
+		return toRCError(err)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{name: v})
+	return nil
+}
+
+// put serves a PUT request, which creates or replaces the resource at path
+// p, whose schema is ps, with the value within the request body.
+func (s *Server) put(w http.ResponseWriter, r *http.Request, p *gnmipb.Path, ps *yang.Entry) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	v, err := memberValue(body, ps)
+	if err != nil {
+		return err
+	}
+	var existed bool
+	if err := s.write(func(root ygot.ValidatedGoStruct) error {
+		if _, existed, err = s.lookup(root, p); err != nil {
+			return err
+		}
+		return s.apply(root, "replace", p, v)
+	}); err != nil {
+		return err
+	}
+	if existed {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// post serves a POST request, which creates the child of the resource at
+// path p, whose schema is ps, that is contained within the request body.
+// The location of the created resource is returned in the Location header.
+func (s *Server) post(w http.ResponseWriter, r *http.Request, p *gnmipb.Path, ps *yang.Entry) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	cs, cv, err := childValue(body, ps)
+	if err != nil {
+		return err
+	}
+	pe := &gnmipb.PathElem{Name: cs.Name}
+	if cs.IsList() {
+		if pe.Key, err = entryKeys(cs, cv); err != nil {
+			return err
+		}
+	}
+	cp := &gnmipb.Path{Elem: append(append([]*gnmipb.PathElem{}, p.GetElem()...), pe)}
+
+	if err := s.write(func(root ygot.ValidatedGoStruct) error {
+		if _, ok, err := s.lookup(root, p); err != nil || !ok {
+			return missingError(p, err)
+		}
+		return s.apply(root, "create", cp, cv)
+	}); err != nil {
+		return err
+	}
+	id, err := s.resourceID(cp)
+	if err != nil {
+		return toRCError(err)
+	}
+	w.Header().Set("Location", dataPath+id)
+	w.WriteHeader(http.StatusCreated)
+	return nil
+}
+
+// patch serves a plain PATCH request, which merges the value within the
+// request body into the existing resource at path p, whose schema is ps.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, p *gnmipb.Path, ps *yang.Entry) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+	v, err := memberValue(body, ps)
+	if err != nil {
+		return err
+	}
+	if err := s.write(func(root ygot.ValidatedGoStruct) error {
+		if _, ok, err := s.lookup(root, p); err != nil || !ok {
+			return missingError(p, err)
+		}
+		return s.apply(root, "merge", p, v)
+	}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// delete serves a DELETE request, which deletes the existing resource at
+// path p. The datastore resource itself cannot be deleted.
+func (s *Server) delete(w http.ResponseWriter, p *gnmipb.Path) error {
+	if len(p.GetElem()) == 0 {
+		return newError(http.StatusMethodNotAllowed, "protocol", "operation-not-supported", "the datastore resource cannot be deleted")
+	}
+	if err := s.write(func(root ygot.ValidatedGoStruct) error {
+		return s.apply(root, "delete", p, nil)
+	}); err != nil {
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// apply performs the YANG Patch edit operation op on the data node at path
+// p within root, whose value is set to the RFC 7951 encoded value v by the
+// create, merge and replace operations. The create operation fails if the
+// node exists, and the delete operation fails if it does not.
+func (s *Server) apply(root ygot.ValidatedGoStruct, op string, p *gnmipb.Path, v interface{}) error {
+	_, exists, err := s.lookup(root, p)
+	switch {
+	case err != nil:
+		return err
+	case op == "create" && exists:
+		return newError(http.StatusConflict, "application", "data-exists", "data resource %s already exists", pathString(p))
+	case op == "delete" && !exists:
+		return missingError(p, nil)
+	}
+
+	req := &gnmipb.SetRequest{}
+	switch op {
+	case "delete", "remove":
+		req.Delete = []*gnmipb.Path{p}
+	case "create", "merge", "replace":
+		j, err := json.Marshal(v)
+		if err != nil {
+			return newError(http.StatusBadRequest, "application", "invalid-value", "cannot encode value for %s: %v", pathString(p), err)
+		}
+		u := &gnmipb.Update{Path: p, Val: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{j}}}
+		if op == "replace" {
+			req.Replace = []*gnmipb.Update{u}
+		} else {
+			req.Update = []*gnmipb.Update{u}
+		}
+	default:
+		return newError(http.StatusNotImplemented, "protocol", "operation-not-supported", "unsupported edit operation %q", op)
+	}
+	if err := ytypes.ApplySetRequest(s.schema, root, req); err != nil {
+		return statusError(err)
+	}
+	return nil
+}
+
+// lookup returns the RFC 7951 encoded value of the data node at path p
+// within root, and whether the node exists. The node is encoded by
+// rendering the closest GoStruct that contains it, such that nodes that do
+// not correspond to a GoStruct, such as the containers that are removed by
+// path compression, can be retrieved. The members of a returned object are
+// namespace-qualified relative to the node.
+func (s *Server) lookup(root ygot.GoStruct, p *gnmipb.Path) (interface{}, bool, error) {
+	elems := p.GetElem()
+	for i := len(elems); i >= 0; i-- {
+		gs := root
+		if i > 0 {
+			nodes, err := ytypes.GetNode(s.schema, root, &gnmipb.Path{Elem: elems[:i]})
+			if err != nil || len(nodes) != 1 {
+				continue
+			}
+			var ok bool
+			if gs, ok = nodes[0].Data.(ygot.GoStruct); !ok || util.IsValueNil(gs) {
+				continue
+			}
+		}
+		j, err := ygot.ConstructIETFJSON(gs, &ygot.RFC7951JSONConfig{AppendModuleName: true})
+		if err != nil {
+			return nil, false, newError(http.StatusInternalServerError, "application", "operation-failed", "cannot encode data tree: %v", err)
+		}
+		if i == len(elems) {
+			if e := schemaAt(s.schema, p); e != nil && i != 0 {
+				if err := unqualify(j, e); err != nil {
+					return nil, false, toRCError(err)
+				}
+			}
+			return j, true, nil
+		}
+		v, ok := descend(j, elems[i:])
+		return v, ok, nil
+	}
+	return nil, false, nil
+}
+
+// unqualify removes the module name from the members of the object j,
+// which is the top-level encoding of the data node whose schema is e, that
+// are defined in the same module as the node. ConstructIETFJSON qualifies
+// all top-level members, since it does not know the module of the node that
+// contains them. An error is returned if the module of the node cannot be
+// determined.
+func unqualify(j map[string]interface{}, e *yang.Entry) error {
+	mod, err := util.EntryModule(e)
+	if err != nil {
+		return err
+	}
+	for k, v := range j {
+		if strings.HasPrefix(k, mod+":") {
+			delete(j, k)
+			j[strings.TrimPrefix(k, mod+":")] = v
+		}
+	}
+	return nil
+}
+
+// descend returns the member of the RFC 7951 encoded value v that is found
+// by following the elements es, which must not contain keys, and whether
+// such a member exists.
+func descend(v interface{}, es []*gnmipb.PathElem) (interface{}, bool) {
+	for _, e := range es {
+		m, ok := v.(map[string]interface{})
+		if !ok || len(e.GetKey()) != 0 {
+			return nil, false
+		}
+		if v, ok = member(m, e.GetName()); !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// member returns the value of the member of the object m whose name, with
+// any module name removed, is name, and whether such a member exists.
+func member(m map[string]interface{}, name string) (interface{}, bool) {
+	for k, v := range m {
+		if util.StripModulePrefix(k) == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// qualifiedName returns the namespace-qualified name of the data node whose
+// schema is e, as used for the top-level member of a message body. An error
+// is returned if the module of the node cannot be determined.
+func qualifiedName(e *yang.Entry) (string, error) {
+	mod, err := util.EntryModule(e)
+	if err != nil {
+		return "", err
+	}
+	return mod + ":" + e.Name, nil
+}
+
+// mediaType returns the media type of the body of the request r, or an empty
+// string if it does not specify a valid media type.
+func mediaType(r *http.Request) string {
+	mt, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+	return mt
+}
+
+// readBody returns the decoded JSON body of the request r, which must be
+// encoded as application/yang-data+json or application/json. A request
+// that does not specify a media type is assumed to be JSON.
+func readBody(r *http.Request) (interface{}, error) {
+	switch mt := mediaType(r); {
+	case r.Header.Get("Content-Type") == "", mt == mediaYANGData, mt == "application/json":
+	default:
+		return nil, newError(http.StatusUnsupportedMediaType, "protocol", "invalid-value", "unsupported media type %q", r.Header.Get("Content-Type"))
+	}
+	var v interface{}
+	if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+		return nil, newError(http.StatusBadRequest, "protocol", "malformed-message", "cannot decode request body: %v", err)
+	}
+	return v, nil
+}
+
+// memberValue returns the value of the single member of the RFC 7951
+// encoded message body, which must be the member for the data node whose
+// schema is e (RFC 8040 section 4.5). The value of an entry of a list is
+// the entry itself, rather than an array containing it. If e is the root
+// schema, the body may either be the contents of the datastore, or an
+// ietf-restconf:data member that contains them.
+func memberValue(body interface{}, e *yang.Entry) (interface{}, error) {
+	m, ok := body.(map[string]interface{})
+	if !ok {
+		return nil, newError(http.StatusBadRequest, "application", "invalid-value", "message body must be an object, got %T", body)
+	}
+	if util.IsRoot(e) {
+		if v, ok := m["ietf-restconf:data"]; ok && len(m) == 1 {
+			return v, nil
+		}
+		return m, nil
+	}
+	v, ok := member(m, e.Name)
+	if !ok || len(m) != 1 {
+		return nil, newError(http.StatusBadRequest, "application", "invalid-value", "message body must contain only a member for %s", e.Name)
+	}
+	if a, ok := v.([]interface{}); ok && e.IsList() {
+		if len(a) != 1 {
+			return nil, newError(http.StatusBadRequest, "application", "invalid-value", "message body must contain a single entry of list %s, got %d", e.Name, len(a))
+		}
+		v = a[0]
+	}
+	return v, nil
+}
+
+// childValue returns the schema and value of the child of the data node
+// whose schema is e that is the single member of the RFC 7951 encoded
+// message body of a POST request. If the child is a list, the value is its
+// single entry within the body.
+func childValue(body interface{}, e *yang.Entry) (*yang.Entry, interface{}, error) {
+	m, ok := body.(map[string]interface{})
+	if !ok || len(m) != 1 {
+		return nil, nil, newError(http.StatusBadRequest, "application", "invalid-value", "message body must be an object containing a single member")
+	}
+	for k := range m {
+		c := childSchema(e, util.StripModulePrefix(k))
+		if c == nil {
+			return nil, nil, newError(http.StatusBadRequest, "application", "unknown-element", "%s is not a child of %s", k, e.Name)
+		}
+		v, err := memberValue(m, c)
+		return c, v, err
+	}
+	return nil, nil, nil
+}
+
+// writeJSON writes the JSON encoding of v as the body of a response with
+// the supplied status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		writeError(w, newError(http.StatusInternalServerError, "application", "operation-failed", "cannot encode response: %v", err))
+		return
+	}
+	w.Header().Set("Content-Type", mediaYANGData)
+	w.WriteHeader(code)
+	w.Write(append(b, '\n'))
+}
+
+// allowMethod returns true if the method of the request r is one of
+// methods. Otherwise, it writes an error to w and returns false.
+func allowMethod(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, m := range methods {
+		if r.Method == m {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, newError(http.StatusMethodNotAllowed, "protocol", "operation-not-supported", "method %s is not supported for resource %s", r.Method, r.URL.Path))
+	return false
+}
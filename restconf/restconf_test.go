@@ -0,0 +1,524 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package restconf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/devicetest"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+)
+
+// startServer starts a Server for the test schema on a local HTTP server,
+// and returns it along with the HTTP server, which must be closed by the
+// caller.
+func startServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s, err := New(devicetest.NewSchema())
+	if err != nil {
+		t.Fatalf("New: got unexpected error: %v", err)
+	}
+	return s, httptest.NewServer(s)
+}
+
+// do sends a request with the supplied method, path and body to the HTTP
+// server ts, and returns the response along with its body. The body is sent
+// with the supplied content type, if it is not empty.
+func do(t *testing.T, ts *httptest.Server, method, path, contentType, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest(%s, %s): got unexpected error: %v", method, path, err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: got unexpected error: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s %s: cannot read body: %v", method, path, err)
+	}
+	return resp, string(b)
+}
+
+// checkJSON checks that the JSON documents got and want are equal.
+func checkJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("cannot decode JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("cannot decode JSON %s: %v", want, err)
+	}
+	if diff := cmp.Diff(w, g); diff != "" {
+		t.Errorf("did not get expected JSON, diff(-want, +got):\n%s", diff)
+	}
+}
+
+// errorTag returns the error-tag of the single error within the
+// ietf-restconf:errors message body.
+func errorTag(t *testing.T, body string) string {
+	t.Helper()
+	var errs struct {
+		Errors struct {
+			Error []*rcError `json:"error"`
+		} `json:"ietf-restconf:errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &errs); err != nil || len(errs.Errors.Error) != 1 {
+		t.Fatalf("body %s is not a single error, decoding error: %v", body, err)
+	}
+	return errs.Errors.Error[0].Tag
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&ytypes.Schema{}); err == nil {
+		t.Errorf("New(empty schema): did not get expected error")
+	}
+	s := devicetest.NewSchema()
+	s.SchemaTree = map[string]*yang.Entry{}
+	if _, err := New(s); err == nil {
+		t.Errorf("New(schema without root): did not get expected error")
+	}
+}
+
+func TestAPIResources(t *testing.T) {
+	tests := []struct {
+		desc      string
+		method    string
+		path      string
+		wantCode  int
+		wantBody  string
+		wantJSON  string
+		wantAllow string
+	}{{
+		desc:     "host-meta",
+		method:   http.MethodGet,
+		path:     "/.well-known/host-meta",
+		wantCode: http.StatusOK,
+		wantBody: "<XRD xmlns='http://docs.oasis-open.org/ns/xri/xrd-1.0'>\n  <Link rel='restconf' href='/restconf'/>\n</XRD>\n",
+	}, {
+		desc:     "API resource",
+		method:   http.MethodGet,
+		path:     "/restconf",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:restconf": {"data": {}, "operations": {}, "yang-library-version": "2016-06-21"}}`,
+	}, {
+		desc:     "operations",
+		method:   http.MethodGet,
+		path:     "/restconf/operations",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:operations": {}}`,
+	}, {
+		desc:     "yang library version",
+		method:   http.MethodGet,
+		path:     "/restconf/yang-library-version",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:yang-library-version": "2016-06-21"}`,
+	}, {
+		desc:      "options for data resource",
+		method:    http.MethodOptions,
+		path:      "/restconf/data/dev:system",
+		wantCode:  http.StatusOK,
+		wantAllow: "GET, HEAD, PUT, POST, PATCH, DELETE, OPTIONS",
+	}, {
+		desc:      "unsupported method",
+		method:    http.MethodPost,
+		path:      "/restconf",
+		wantCode:  http.StatusMethodNotAllowed,
+		wantAllow: "GET, HEAD",
+	}, {
+		desc:     "unknown resource",
+		method:   http.MethodGet,
+		path:     "/restconf/streams",
+		wantCode: http.StatusNotFound,
+	}}
+
+	_, ts := startServer(t)
+	defer ts.Close()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resp, body := do(t, ts, tt.method, tt.path, "", "")
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("%s %s: got status %d, want %d, body: %s", tt.method, tt.path, resp.StatusCode, tt.wantCode, body)
+			}
+			if tt.wantBody != "" && body != tt.wantBody {
+				t.Errorf("%s %s: got body %q, want %q", tt.method, tt.path, body, tt.wantBody)
+			}
+			if tt.wantJSON != "" {
+				checkJSON(t, body, tt.wantJSON)
+			}
+			if got := resp.Header.Get("Allow"); got != tt.wantAllow {
+				t.Errorf("%s %s: got Allow header %q, want %q", tt.method, tt.path, got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	tests := []struct {
+		desc     string
+		path     string
+		wantCode int
+		wantJSON string
+		wantTag  string
+	}{{
+		desc:     "datastore",
+		path:     "/restconf/data",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:data": {
+			"dev:interfaces": {"interface": [{"name": "eth0", "mtu": 1500, "counter": "0"}]},
+			"dev:routes": {"route": [{"prefix": "10.0.0.0/8", "vrf": "red", "next-hop": "192.0.2.1"}]},
+			"dev:system": {"hostname": "rtr1", "uplink": "eth0", "ext:location": "lab"}
+		}}`,
+	}, {
+		desc:     "container",
+		path:     "/restconf/data/dev:system",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:system": {"hostname": "rtr1", "uplink": "eth0", "ext:location": "lab"}}`,
+	}, {
+		desc:     "list entry",
+		path:     "/restconf/data/dev:interfaces/interface=eth0",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:interface": [{"name": "eth0", "mtu": 1500, "counter": "0"}]}`,
+	}, {
+		desc:     "list entry with multiple percent-encoded keys",
+		path:     "/restconf/data/dev:routes/route=10.0.0.0%2F8,red",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:route": [{"prefix": "10.0.0.0/8", "vrf": "red", "next-hop": "192.0.2.1"}]}`,
+	}, {
+		desc:     "leaf",
+		path:     "/restconf/data/dev:interfaces/interface=eth0/mtu",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:mtu": 1500}`,
+	}, {
+		desc:     "leaf in other module",
+		path:     "/restconf/data/dev:system/ext:location",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ext:location": "lab"}`,
+	}, {
+		desc:     "depth 1",
+		path:     "/restconf/data/dev:system?depth=1",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:system": {}}`,
+	}, {
+		desc:     "depth 2 retains list keys",
+		path:     "/restconf/data/dev:interfaces?depth=2",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:interfaces": {"interface": [{"name": "eth0"}]}}`,
+	}, {
+		desc:     "fields",
+		path:     "/restconf/data/dev:system?fields=hostname;ext:location",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:system": {"hostname": "rtr1", "ext:location": "lab"}}`,
+	}, {
+		desc:     "fields with nested selection",
+		path:     "/restconf/data?fields=dev:interfaces/interface(mtu)",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:data": {"dev:interfaces": {"interface": [{"name": "eth0", "mtu": 1500}]}}}`,
+	}, {
+		desc:     "config content",
+		path:     "/restconf/data/dev:interfaces?content=config",
+		wantCode: http.StatusOK,
+		wantJSON: `{"dev:interfaces": {"interface": [{"name": "eth0", "mtu": 1500}]}}`,
+	}, {
+		desc:     "nonconfig content",
+		path:     "/restconf/data?content=nonconfig",
+		wantCode: http.StatusOK,
+		wantJSON: `{"ietf-restconf:data": {"dev:interfaces": {"interface": [{"name": "eth0", "counter": "0"}]}}}`,
+	}, {
+		desc:     "nonconfig content of container without state",
+		path:     "/restconf/data/dev:system?content=nonconfig",
+		wantCode: http.StatusNotFound,
+		wantTag:  "data-missing",
+	}, {
+		desc:     "missing list entry",
+		path:     "/restconf/data/dev:interfaces/interface=eth1",
+		wantCode: http.StatusNotFound,
+		wantTag:  "data-missing",
+	}, {
+		desc:     "unknown data node",
+		path:     "/restconf/data/dev:system/domain",
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}, {
+		desc:     "invalid depth",
+		path:     "/restconf/data/dev:system?depth=0",
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}, {
+		desc:     "unsupported query parameter",
+		path:     "/restconf/data/dev:system?with-defaults=report-all",
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}}
+
+	_, ts := startServer(t)
+	defer ts.Close()
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			resp, body := do(t, ts, http.MethodGet, tt.path, "", "")
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("GET %s: got status %d, want %d, body: %s", tt.path, resp.StatusCode, tt.wantCode, body)
+			}
+			if got := resp.Header.Get("Content-Type"); got != mediaYANGData {
+				t.Errorf("GET %s: got Content-Type %q, want %q", tt.path, got, mediaYANGData)
+			}
+			if tt.wantTag != "" {
+				if got := errorTag(t, body); got != tt.wantTag {
+					t.Errorf("GET %s: got error-tag %s, want %s", tt.path, got, tt.wantTag)
+				}
+				return
+			}
+			checkJSON(t, body, tt.wantJSON)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		desc         string
+		method       string
+		path         string
+		contentType  string
+		body         string
+		wantCode     int
+		wantTag      string
+		wantLocation string
+		// checkPath is retrieved after the request, and must return
+		// wantJSON.
+		checkPath string
+		wantJSON  string
+	}{{
+		desc:      "PUT replaces leaf",
+		method:    http.MethodPut,
+		path:      "/restconf/data/dev:system/hostname",
+		body:      `{"dev:hostname": "rtr2"}`,
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data/dev:system/hostname",
+		wantJSON:  `{"dev:hostname": "rtr2"}`,
+	}, {
+		desc:      "PUT replaces container",
+		method:    http.MethodPut,
+		path:      "/restconf/data/dev:system",
+		body:      `{"dev:system": {"hostname": "rtr2"}}`,
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data/dev:system",
+		wantJSON:  `{"dev:system": {"hostname": "rtr2"}}`,
+	}, {
+		desc:        "PUT creates list entry",
+		method:      http.MethodPut,
+		path:        "/restconf/data/dev:interfaces/interface=eth1",
+		contentType: mediaYANGData,
+		body:        `{"dev:interface": [{"name": "eth1", "mtu": 9000}]}`,
+		wantCode:    http.StatusCreated,
+		checkPath:   "/restconf/data/dev:interfaces/interface=eth1",
+		wantJSON:    `{"dev:interface": [{"name": "eth1", "mtu": 9000}]}`,
+	}, {
+		desc:      "PUT replaces datastore",
+		method:    http.MethodPut,
+		path:      "/restconf/data",
+		body:      `{"ietf-restconf:data": {"dev:system": {"hostname": "rtr2"}}}`,
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data",
+		wantJSON:  `{"ietf-restconf:data": {"dev:system": {"hostname": "rtr2"}}}`,
+	}, {
+		desc:     "PUT with mismatched key",
+		method:   http.MethodPut,
+		path:     "/restconf/data/dev:interfaces/interface=eth1",
+		body:     `{"dev:interface": [{"name": "eth2"}]}`,
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}, {
+		desc:      "PUT resulting in invalid data tree",
+		method:    http.MethodPut,
+		path:      "/restconf/data/dev:system/uplink",
+		body:      `{"dev:uplink": "eth1"}`,
+		wantCode:  http.StatusBadRequest,
+		wantTag:   "invalid-value",
+		checkPath: "/restconf/data/dev:system/uplink",
+		wantJSON:  `{"dev:uplink": "eth0"}`,
+	}, {
+		desc:     "PUT with wrong member",
+		method:   http.MethodPut,
+		path:     "/restconf/data/dev:system/hostname",
+		body:     `{"dev:uplink": "eth0"}`,
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}, {
+		desc:        "PUT with unsupported media type",
+		method:      http.MethodPut,
+		path:        "/restconf/data/dev:system/hostname",
+		contentType: "application/yang-data+xml",
+		body:        `<hostname>rtr2</hostname>`,
+		wantCode:    http.StatusUnsupportedMediaType,
+		wantTag:     "invalid-value",
+	}, {
+		desc:     "PUT with malformed body",
+		method:   http.MethodPut,
+		path:     "/restconf/data/dev:system/hostname",
+		body:     `{"dev:hostname": `,
+		wantCode: http.StatusBadRequest,
+		wantTag:  "malformed-message",
+	}, {
+		desc:         "POST creates list entry",
+		method:       http.MethodPost,
+		path:         "/restconf/data/dev:interfaces",
+		body:         `{"dev:interface": [{"name": "eth1"}]}`,
+		wantCode:     http.StatusCreated,
+		wantLocation: "/restconf/data/dev:interfaces/interface=eth1",
+		checkPath:    "/restconf/data/dev:interfaces/interface=eth1",
+		wantJSON:     `{"dev:interface": [{"name": "eth1"}]}`,
+	}, {
+		desc:         "POST creates list entry with multiple keys",
+		method:       http.MethodPost,
+		path:         "/restconf/data/dev:routes",
+		body:         `{"dev:route": [{"prefix": "192.0.2.0/24", "vrf": "blue green", "next-hop": "192.0.2.254"}]}`,
+		wantCode:     http.StatusCreated,
+		wantLocation: "/restconf/data/dev:routes/route=192.0.2.0%2F24,blue%20green",
+		checkPath:    "/restconf/data/dev:routes/route=192.0.2.0%2F24,blue%20green/next-hop",
+		wantJSON:     `{"dev:next-hop": "192.0.2.254"}`,
+	}, {
+		desc:     "POST existing container to datastore",
+		method:   http.MethodPost,
+		path:     "/restconf/data",
+		body:     `{"dev:system": {"hostname": "rtr2"}}`,
+		wantCode: http.StatusConflict,
+		wantTag:  "data-exists",
+	}, {
+		desc:     "POST existing list entry",
+		method:   http.MethodPost,
+		path:     "/restconf/data/dev:interfaces",
+		body:     `{"dev:interface": [{"name": "eth0"}]}`,
+		wantCode: http.StatusConflict,
+		wantTag:  "data-exists",
+	}, {
+		desc:     "POST unknown child",
+		method:   http.MethodPost,
+		path:     "/restconf/data/dev:interfaces",
+		body:     `{"dev:subinterface": [{"index": 0}]}`,
+		wantCode: http.StatusBadRequest,
+		wantTag:  "unknown-element",
+	}, {
+		desc:     "POST list entry without keys",
+		method:   http.MethodPost,
+		path:     "/restconf/data/dev:routes",
+		body:     `{"dev:route": [{"prefix": "192.0.2.0/24"}]}`,
+		wantCode: http.StatusBadRequest,
+		wantTag:  "missing-element",
+	}, {
+		desc:      "PATCH merges container",
+		method:    http.MethodPatch,
+		path:      "/restconf/data/dev:system",
+		body:      `{"dev:system": {"hostname": "rtr2"}}`,
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data/dev:system",
+		wantJSON:  `{"dev:system": {"hostname": "rtr2", "uplink": "eth0", "ext:location": "lab"}}`,
+	}, {
+		desc:      "PATCH merges datastore",
+		method:    http.MethodPatch,
+		path:      "/restconf/data",
+		body:      `{"dev:interfaces": {"interface": [{"name": "eth1"}]}}`,
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data/dev:interfaces?depth=2",
+		wantJSON:  `{"dev:interfaces": {"interface": [{"name": "eth0"}, {"name": "eth1"}]}}`,
+	}, {
+		desc:     "PATCH missing resource",
+		method:   http.MethodPatch,
+		path:     "/restconf/data/dev:interfaces/interface=eth1",
+		body:     `{"dev:interface": [{"name": "eth1"}]}`,
+		wantCode: http.StatusNotFound,
+		wantTag:  "data-missing",
+	}, {
+		desc:      "DELETE list entry",
+		method:    http.MethodDelete,
+		path:      "/restconf/data/dev:routes/route=10.0.0.0%2F8,red",
+		wantCode:  http.StatusNoContent,
+		checkPath: "/restconf/data/dev:routes",
+		wantJSON:  `{"dev:routes": {}}`,
+	}, {
+		desc:     "DELETE missing resource",
+		method:   http.MethodDelete,
+		path:     "/restconf/data/dev:interfaces/interface=eth1",
+		wantCode: http.StatusNotFound,
+		wantTag:  "data-missing",
+	}, {
+		desc:     "DELETE datastore",
+		method:   http.MethodDelete,
+		path:     "/restconf/data",
+		wantCode: http.StatusMethodNotAllowed,
+		wantTag:  "operation-not-supported",
+	}, {
+		desc:     "query parameter for DELETE",
+		method:   http.MethodDelete,
+		path:     "/restconf/data/dev:system?depth=1",
+		wantCode: http.StatusBadRequest,
+		wantTag:  "invalid-value",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			_, ts := startServer(t)
+			defer ts.Close()
+
+			resp, body := do(t, ts, tt.method, tt.path, tt.contentType, tt.body)
+			if resp.StatusCode != tt.wantCode {
+				t.Fatalf("%s %s: got status %d, want %d, body: %s", tt.method, tt.path, resp.StatusCode, tt.wantCode, body)
+			}
+			if tt.wantTag != "" {
+				if got := errorTag(t, body); got != tt.wantTag {
+					t.Errorf("%s %s: got error-tag %s, want %s", tt.method, tt.path, got, tt.wantTag)
+				}
+			}
+			if got := resp.Header.Get("Location"); got != tt.wantLocation {
+				t.Errorf("%s %s: got Location %q, want %q", tt.method, tt.path, got, tt.wantLocation)
+			}
+			if tt.checkPath == "" {
+				return
+			}
+			resp, body = do(t, ts, http.MethodGet, tt.checkPath, "", "")
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("GET %s: got status %d, body: %s", tt.checkPath, resp.StatusCode, body)
+			}
+			checkJSON(t, body, tt.wantJSON)
+		})
+	}
+}
+
+func TestDatastore(t *testing.T) {
+	s, ts := startServer(t)
+	defer ts.Close()
+
+	if _, err := s.Datastore().Update(func(r ygot.ValidatedGoStruct) error {
+		r.(*devicetest.Device).Interfaces.Interface["eth0"].Counter = ygot.Uint64(42)
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	_, body := do(t, ts, http.MethodGet, "/restconf/data/dev:interfaces/interface=eth0/counter", "", "")
+	checkJSON(t, body, `{"dev:counter": "42"}`)
+}
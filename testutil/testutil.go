@@ -177,6 +177,11 @@ func JSONIETFComparer(a, b *gnmipb.TypedValue_JsonIetfVal) bool {
 	return reflect.DeepEqual(aj, bj)
 }
 
+// JSONIETFVal returns a TypedValue containing the JSON IETF encoded value j.
+func JSONIETFVal(j string) *gnmipb.TypedValue {
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte(j)}}
+}
+
 // notificationMatch tracks whether a gNMI notification pair has matched.
 type notificationMatch struct {
 	timestamp bool
@@ -0,0 +1,416 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc8949 for the CBOR data model and
+// encoding.
+
+// CBOR major types.
+const (
+	cborUnsigned byte = iota << 5
+	cborNegative
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+const (
+	// cborIndefinite is the additional information value that indicates
+	// an indefinite length item.
+	cborIndefinite byte = 31
+	// cborBreak is the stop code that ends an indefinite length item.
+	cborBreak byte = 0xff
+	// cborMaxDepth is the maximum nesting depth of the data items that
+	// are decoded by UnmarshalCBOR.
+	cborMaxDepth = 512
+)
+
+// CBORTag is a CBOR tagged data item, as per RFC8949 Section 3.4.
+type CBORTag struct {
+	// Number is the tag number.
+	Number uint64
+	// Content is the data item that is tagged.
+	Content interface{}
+}
+
+// CBORPair is a key-value pair of a CBOR map.
+type CBORPair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// CBORMap is a CBOR map. Since the keys of a CBOR map may be of any type,
+// it is represented as a slice of key-value pairs.
+type CBORMap []CBORPair
+
+// MarshalCBOR returns the CBOR encoding of v, which must be nil, a bool, an
+// integer, a float64, a string, a []byte, a []interface{}, a CBORMap or a
+// CBORTag, or a composition of these. The pairs of each map are encoded in
+// the order of their encoded keys, as per the core deterministic encoding
+// requirements of RFC8949 Section 4.2.1.
+func MarshalCBOR(v interface{}) ([]byte, error) {
+	var b bytes.Buffer
+	if err := marshalCBOR(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// cborHead writes the initial byte of a data item with the major type mt,
+// followed by its argument n, to b.
+func cborHead(b *bytes.Buffer, mt byte, n uint64) {
+	switch {
+	case n < 24:
+		b.WriteByte(mt | byte(n))
+	case n <= math.MaxUint8:
+		b.WriteByte(mt | 24)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(mt | 25)
+		binary.Write(b, binary.BigEndian, uint16(n))
+	case n <= math.MaxUint32:
+		b.WriteByte(mt | 26)
+		binary.Write(b, binary.BigEndian, uint32(n))
+	default:
+		b.WriteByte(mt | 27)
+		binary.Write(b, binary.BigEndian, n)
+	}
+}
+
+// marshalCBOR writes the CBOR encoding of v to b.
+func marshalCBOR(b *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte(cborSimple | 22)
+	case bool:
+		if v {
+			b.WriteByte(cborSimple | 21)
+		} else {
+			b.WriteByte(cborSimple | 20)
+		}
+	case uint8:
+		cborHead(b, cborUnsigned, uint64(v))
+	case uint16:
+		cborHead(b, cborUnsigned, uint64(v))
+	case uint32:
+		cborHead(b, cborUnsigned, uint64(v))
+	case uint64:
+		cborHead(b, cborUnsigned, v)
+	case uint:
+		cborHead(b, cborUnsigned, uint64(v))
+	case int8:
+		marshalCBORInt(b, int64(v))
+	case int16:
+		marshalCBORInt(b, int64(v))
+	case int32:
+		marshalCBORInt(b, int64(v))
+	case int64:
+		marshalCBORInt(b, v)
+	case int:
+		marshalCBORInt(b, int64(v))
+	case float64:
+		b.WriteByte(cborSimple | 27)
+		binary.Write(b, binary.BigEndian, math.Float64bits(v))
+	case string:
+		cborHead(b, cborText, uint64(len(v)))
+		b.WriteString(v)
+	case []byte:
+		cborHead(b, cborBytes, uint64(len(v)))
+		b.Write(v)
+	case []interface{}:
+		cborHead(b, cborArray, uint64(len(v)))
+		for _, e := range v {
+			if err := marshalCBOR(b, e); err != nil {
+				return err
+			}
+		}
+	case CBORMap:
+		type encodedPair struct{ k, v []byte }
+		pairs := make([]encodedPair, 0, len(v))
+		for _, p := range v {
+			k, err := MarshalCBOR(p.Key)
+			if err != nil {
+				return err
+			}
+			val, err := MarshalCBOR(p.Value)
+			if err != nil {
+				return err
+			}
+			pairs = append(pairs, encodedPair{k, val})
+		}
+		sort.SliceStable(pairs, func(i, j int) bool { return bytes.Compare(pairs[i].k, pairs[j].k) < 0 })
+		for i := 1; i < len(pairs); i++ {
+			if bytes.Equal(pairs[i-1].k, pairs[i].k) {
+				return fmt.Errorf("duplicate CBOR map key %x", pairs[i].k)
+			}
+		}
+		cborHead(b, cborMap, uint64(len(pairs)))
+		for _, p := range pairs {
+			b.Write(p.k)
+			b.Write(p.v)
+		}
+	case CBORTag:
+		cborHead(b, cborTag, v.Number)
+		return marshalCBOR(b, v.Content)
+	default:
+		return fmt.Errorf("cannot encode value %v of type %T as CBOR", v, v)
+	}
+	return nil
+}
+
+// marshalCBORInt writes the CBOR encoding of the integer i to b.
+func marshalCBORInt(b *bytes.Buffer, i int64) {
+	if i < 0 {
+		cborHead(b, cborNegative, uint64(-1-i))
+		return
+	}
+	cborHead(b, cborUnsigned, uint64(i))
+}
+
+// UnmarshalCBOR decodes the single CBOR data item in data. Unsigned integers
+// are returned as uint64, negative integers as int64, floating point values
+// as float64, byte strings as []byte, text strings as string, arrays as
+// []interface{}, maps as CBORMap, and tagged items as CBORTag. The simple
+// values false, true and null are returned as false, true and nil. An error
+// is returned if data is not well-formed, contains trailing bytes, or
+// contains a negative integer that cannot be represented as an int64.
+func UnmarshalCBOR(data []byte) (interface{}, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(d.data) {
+		return nil, fmt.Errorf("trailing bytes after CBOR data item at offset %d", d.off)
+	}
+	return v, nil
+}
+
+// cborDecoder decodes CBOR data items from data, starting at offset off.
+type cborDecoder struct {
+	data []byte
+	off  int
+}
+
+// errBreak is returned by decode when a break stop code is read.
+var errBreak = fmt.Errorf("unexpected CBOR break")
+
+// head reads the initial byte and argument of a data item, returning its
+// major type, additional information and argument.
+func (d *cborDecoder) head() (byte, byte, uint64, error) {
+	if d.off >= len(d.data) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	ib := d.data[d.off]
+	d.off++
+	mt, ai := ib&0xe0, ib&0x1f
+
+	var n int
+	switch {
+	case ai < 24:
+		return mt, ai, uint64(ai), nil
+	case ai == 24:
+		n = 1
+	case ai == 25:
+		n = 2
+	case ai == 26:
+		n = 4
+	case ai == 27:
+		n = 8
+	case ai == cborIndefinite:
+		return mt, ai, 0, nil
+	default:
+		return 0, 0, 0, fmt.Errorf("invalid CBOR additional information %d at offset %d", ai, d.off-1)
+	}
+	if d.off+n > len(d.data) {
+		return 0, 0, 0, fmt.Errorf("unexpected end of CBOR data")
+	}
+	var arg uint64
+	for _, c := range d.data[d.off : d.off+n] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.off += n
+	return mt, ai, arg, nil
+}
+
+// decode decodes the next data item, which is nested at the supplied depth.
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("CBOR data is nested too deeply")
+	}
+	start := d.off
+	mt, ai, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+	indefinite := ai == cborIndefinite
+
+	switch mt {
+	case cborUnsigned, cborNegative:
+		if indefinite {
+			return nil, fmt.Errorf("invalid indefinite length integer at offset %d", start)
+		}
+		if mt == cborUnsigned {
+			return arg, nil
+		}
+		if arg > math.MaxInt64 {
+			return nil, fmt.Errorf("negative integer at offset %d is out of range", start)
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		var s []byte
+		if indefinite {
+			// An indefinite length string is a sequence of definite
+			// length chunks of the same major type.
+			for {
+				if d.off < len(d.data) && d.data[d.off] == cborBreak {
+					d.off++
+					break
+				}
+				cmt, cai, n, err := d.head()
+				if err != nil {
+					return nil, err
+				}
+				if cmt != mt || cai == cborIndefinite {
+					return nil, fmt.Errorf("invalid chunk of indefinite length string at offset %d", start)
+				}
+				c, err := d.bytes(n)
+				if err != nil {
+					return nil, err
+				}
+				s = append(s, c...)
+			}
+		} else if s, err = d.bytes(arg); err != nil {
+			return nil, err
+		}
+		if mt == cborText {
+			return string(s), nil
+		}
+		return append([]byte{}, s...), nil
+	case cborArray:
+		// Each item is encoded in at least one byte, such that the length
+		// of the data bounds the number of items.
+		if !indefinite && arg > uint64(len(d.data)-d.off) {
+			return nil, fmt.Errorf("unexpected end of CBOR data")
+		}
+		out := []interface{}{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			v, err := d.decode(depth + 1)
+			if err == errBreak && indefinite {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	case cborMap:
+		// Each item is encoded in at least one byte, such that the length
+		// of the data bounds the number of items.
+		if !indefinite && arg > uint64(len(d.data)-d.off) {
+			return nil, fmt.Errorf("unexpected end of CBOR data")
+		}
+		out := CBORMap{}
+		for i := uint64(0); indefinite || i < arg; i++ {
+			k, err := d.decode(depth + 1)
+			if err == errBreak && indefinite {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, CBORPair{Key: k, Value: v})
+		}
+		return out, nil
+	case cborTag:
+		if indefinite {
+			return nil, fmt.Errorf("invalid indefinite length tag at offset %d", start)
+		}
+		c, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return CBORTag{Number: arg, Content: c}, nil
+	}
+
+	// Major type 7 contains simple values and floating point numbers.
+	switch ai {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		// Both null and undefined are represented by nil.
+		return nil, nil
+	case 25:
+		return float16ToFloat64(uint16(arg)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), nil
+	case 27:
+		return math.Float64frombits(arg), nil
+	case cborIndefinite:
+		return nil, errBreak
+	}
+	return nil, fmt.Errorf("unsupported CBOR simple value %d at offset %d", arg, start)
+}
+
+// bytes returns the next n bytes of the data.
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.off) {
+		return nil, fmt.Errorf("unexpected end of CBOR data")
+	}
+	b := d.data[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// float16ToFloat64 returns the value of the IEEE 754 half-precision floating
+// point number h.
+func float16ToFloat64(h uint16) float64 {
+	exp, mant := int(h>>10)&0x1f, float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
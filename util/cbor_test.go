@@ -0,0 +1,190 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/hex"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestCBOR(t *testing.T) {
+	// The encoded values are from RFC8949 Appendix A.
+	tests := []struct {
+		name string
+		in   interface{}
+		// want is the hex encoding of in.
+		want string
+		// wantDecoded is the value that want is decoded to, if it
+		// differs from in.
+		wantDecoded interface{}
+	}{
+		{name: "zero", in: uint64(0), want: "00"},
+		{name: "23", in: uint64(23), want: "17"},
+		{name: "24", in: uint64(24), want: "1818"},
+		{name: "1000", in: uint64(1000), want: "1903e8"},
+		{name: "1000000", in: uint64(1000000), want: "1a000f4240"},
+		{name: "max uint64", in: uint64(math.MaxUint64), want: "1bffffffffffffffff"},
+		{name: "int", in: int64(10), want: "0a", wantDecoded: uint64(10)},
+		{name: "uint16", in: uint16(500), want: "1901f4", wantDecoded: uint64(500)},
+		{name: "negative", in: int64(-1000), want: "3903e7"},
+		{name: "min int64", in: int64(math.MinInt64), want: "3b7fffffffffffffff"},
+		{name: "float", in: float64(1.1), want: "fb3ff199999999999a"},
+		{name: "false", in: false, want: "f4"},
+		{name: "true", in: true, want: "f5"},
+		{name: "null", in: nil, want: "f6"},
+		{name: "byte string", in: []byte{1, 2, 3, 4}, want: "4401020304"},
+		{name: "text string", in: "IETF", want: "6449455446"},
+		{name: "unicode text string", in: "ü", want: "62c3bc"},
+		{name: "array", in: []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}}, want: "8201820203"},
+		{
+			name: "map with sorted keys",
+			in:   CBORMap{{Key: "b", Value: uint64(2)}, {Key: "a", Value: uint64(1)}, {Key: uint64(10), Value: "x"}},
+			want: "a30a6178616101616202",
+		},
+		{name: "tag", in: CBORTag{Number: 4, Content: []interface{}{int64(-2), uint64(27315)}}, want: "c48221196ab3"},
+	}
+
+	for _, tt := range tests {
+		got, err := MarshalCBOR(tt.in)
+		if err != nil {
+			t.Errorf("%s: MarshalCBOR(%v): got unexpected error: %v", tt.name, tt.in, err)
+			continue
+		}
+		if gotHex := hex.EncodeToString(got); gotHex != tt.want {
+			t.Errorf("%s: MarshalCBOR(%v): got: %s, want: %s", tt.name, tt.in, gotHex, tt.want)
+			continue
+		}
+
+		want := tt.in
+		if tt.wantDecoded != nil {
+			want = tt.wantDecoded
+		}
+		gotDecoded, err := UnmarshalCBOR(got)
+		if err != nil {
+			t.Errorf("%s: UnmarshalCBOR(%s): got unexpected error: %v", tt.name, tt.want, err)
+			continue
+		}
+		if m, ok := want.(CBORMap); ok {
+			// Maps are decoded in the order in which they are encoded.
+			want = CBORMap{m[2], m[1], m[0]}
+		}
+		if !reflect.DeepEqual(gotDecoded, want) {
+			t.Errorf("%s: UnmarshalCBOR(%s): got: %#v, want: %#v", tt.name, tt.want, gotDecoded, want)
+		}
+	}
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               string
+		want             interface{}
+		wantErrSubstring string
+	}{{
+		name: "half precision float",
+		in:   "f93c00",
+		want: float64(1),
+	}, {
+		name: "single precision float",
+		in:   "fa47c35000",
+		want: float64(100000),
+	}, {
+		name: "indefinite length byte string",
+		in:   "5f42010243030405ff",
+		want: []byte{1, 2, 3, 4, 5},
+	}, {
+		name: "indefinite length text string",
+		in:   "7f657374726561646d696e67ff",
+		want: "streaming",
+	}, {
+		name: "indefinite length array",
+		in:   "9f018202039f0405ffff",
+		want: []interface{}{uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)}},
+	}, {
+		name: "indefinite length map",
+		in:   "bf61610161629f0203ffff",
+		want: CBORMap{{Key: "a", Value: uint64(1)}, {Key: "b", Value: []interface{}{uint64(2), uint64(3)}}},
+	}, {
+		name:             "truncated",
+		in:               "1903",
+		wantErrSubstring: "unexpected end of CBOR data",
+	}, {
+		name:             "truncated array",
+		in:               "830102",
+		wantErrSubstring: "unexpected end of CBOR data",
+	}, {
+		name:             "array longer than data",
+		in:               "9bffffffffffffffff00",
+		wantErrSubstring: "unexpected end of CBOR data",
+	}, {
+		name:             "trailing bytes",
+		in:               "0001",
+		wantErrSubstring: "trailing bytes",
+	}, {
+		name:             "negative integer out of range",
+		in:               "3b8000000000000000",
+		wantErrSubstring: "out of range",
+	}, {
+		name:             "unexpected break",
+		in:               "ff",
+		wantErrSubstring: "unexpected CBOR break",
+	}, {
+		name:             "invalid additional information",
+		in:               "1c",
+		wantErrSubstring: "invalid CBOR additional information",
+	}}
+
+	for _, tt := range tests {
+		in, err := hex.DecodeString(tt.in)
+		if err != nil {
+			t.Fatalf("%s: invalid test input %s: %v", tt.name, tt.in, err)
+		}
+		got, err := UnmarshalCBOR(in)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: UnmarshalCBOR(%s): did not get expected error, %s", tt.name, tt.in, diff)
+			continue
+		}
+		if err == nil && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: UnmarshalCBOR(%s): got: %#v, want: %#v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalCBORErrors(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               interface{}
+		wantErrSubstring string
+	}{{
+		name:             "unsupported type",
+		in:               struct{}{},
+		wantErrSubstring: "cannot encode value",
+	}, {
+		name:             "duplicate map key",
+		in:               CBORMap{{Key: "a", Value: uint64(1)}, {Key: "a", Value: uint64(2)}},
+		wantErrSubstring: "duplicate CBOR map key",
+	}}
+
+	for _, tt := range tests {
+		_, err := MarshalCBOR(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: MarshalCBOR(%v): did not get expected error, %s", tt.name, tt.in, diff)
+		}
+	}
+}
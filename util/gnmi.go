@@ -81,6 +81,42 @@ func PathMatchesPathElemPrefix(path, prefix *gpb.Path) bool {
 	return true
 }
 
+// PathMatchesQuery reports whether path is within the subtree rooted at the
+// query path, which may contain wildcards, as per the gNMI path conventions.
+// An element of query named "*" matches any single element of path, and an
+// element named "..." matches zero or more elements. A key of query whose
+// value is "*", or a key that is not specified by query, matches any value of
+// the key. The module prefixes of element names are ignored.
+func PathMatchesQuery(path, query *gpb.Path) bool {
+	return elemsMatchQuery(path.GetElem(), query.GetElem())
+}
+
+// elemsMatchQuery reports whether the path elements of the query q match a
+// prefix of the path elements p.
+func elemsMatchQuery(p, q []*gpb.PathElem) bool {
+	switch {
+	case len(q) == 0:
+		return true
+	case q[0].GetName() == "...":
+		for i := 0; i <= len(p); i++ {
+			if elemsMatchQuery(p[i:], q[1:]) {
+				return true
+			}
+		}
+		return false
+	case len(p) == 0:
+		return false
+	case q[0].GetName() != "*" && StripModulePrefix(q[0].GetName()) != StripModulePrefix(p[0].GetName()):
+		return false
+	}
+	for k, v := range q[0].GetKey() {
+		if pv, ok := p[0].GetKey()[k]; v != "*" && (!ok || pv != v) {
+			return false
+		}
+	}
+	return elemsMatchQuery(p[1:], q[1:])
+}
+
 // TrimGNMIPathPrefix returns path with the prefix trimmed. It returns the
 // original path if the prefix does not fully match.
 func TrimGNMIPathPrefix(path *gpb.Path, prefix []string) *gpb.Path {
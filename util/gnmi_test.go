@@ -330,6 +330,109 @@ func TestPathElemsEqual(t *testing.T) {
 	}
 }
 
+func TestPathMatchesQuery(t *testing.T) {
+	// path returns a gNMI path whose elements have the supplied names. The
+	// keys of the elements are specified by keys, which is keyed by index.
+	path := func(keys map[int]map[string]string, names ...string) *gpb.Path {
+		p := &gpb.Path{}
+		for i, n := range names {
+			p.Elem = append(p.Elem, &gpb.PathElem{Name: n, Key: keys[i]})
+		}
+		return p
+	}
+	eth0 := map[int]map[string]string{1: {"name": "eth0"}}
+	mtu := path(eth0, "interfaces", "interface", "config", "mtu")
+
+	tests := []struct {
+		desc    string
+		inPath  *gpb.Path
+		inQuery *gpb.Path
+		want    bool
+	}{{
+		desc:    "exact match",
+		inPath:  mtu,
+		inQuery: path(eth0, "interfaces", "interface", "config", "mtu"),
+		want:    true,
+	}, {
+		desc:    "prefix",
+		inPath:  mtu,
+		inQuery: path(nil, "interfaces"),
+		want:    true,
+	}, {
+		desc:    "empty query",
+		inPath:  mtu,
+		inQuery: &gpb.Path{},
+		want:    true,
+	}, {
+		desc:    "different name",
+		inPath:  mtu,
+		inQuery: path(nil, "system"),
+	}, {
+		desc:    "query longer than path",
+		inPath:  path(nil, "interfaces"),
+		inQuery: path(nil, "interfaces", "interface"),
+	}, {
+		desc:    "different key",
+		inPath:  mtu,
+		inQuery: path(map[int]map[string]string{1: {"name": "eth1"}}, "interfaces", "interface"),
+	}, {
+		desc:    "wildcard key",
+		inPath:  mtu,
+		inQuery: path(map[int]map[string]string{1: {"name": "*"}}, "interfaces", "interface", "config"),
+		want:    true,
+	}, {
+		desc:    "unspecified key",
+		inPath:  mtu,
+		inQuery: path(nil, "interfaces", "interface", "config"),
+		want:    true,
+	}, {
+		desc:    "key not in path",
+		inPath:  mtu,
+		inQuery: path(map[int]map[string]string{1: {"type": "eth"}}, "interfaces", "interface"),
+	}, {
+		desc:    "wildcard name",
+		inPath:  mtu,
+		inQuery: path(nil, "*", "interface", "*", "mtu"),
+		want:    true,
+	}, {
+		desc:    "wildcard name does not match multiple elements",
+		inPath:  mtu,
+		inQuery: path(nil, "*", "config"),
+	}, {
+		desc:    "multi-level wildcard",
+		inPath:  mtu,
+		inQuery: path(nil, "...", "mtu"),
+		want:    true,
+	}, {
+		desc:    "multi-level wildcard matching no elements",
+		inPath:  mtu,
+		inQuery: path(nil, "interfaces", "...", "interface"),
+		want:    true,
+	}, {
+		desc:    "multi-level wildcard followed by elements that do not match",
+		inPath:  mtu,
+		inQuery: path(nil, "interfaces", "...", "description"),
+	}, {
+		desc:    "multi-level wildcards around key",
+		inPath:  mtu,
+		inQuery: path(map[int]map[string]string{1: {"name": "eth0"}}, "...", "interface", "..."),
+		want:    true,
+	}, {
+		desc:    "module prefixes",
+		inPath:  path(eth0, "dev:interfaces", "interface", "config", "mtu"),
+		inQuery: path(nil, "interfaces", "dev:interface"),
+		want:    true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if got := PathMatchesQuery(tt.inPath, tt.inQuery); got != tt.want {
+				t.Errorf("PathMatchesQuery(%v, %v): got %v, want %v", tt.inPath, tt.inQuery, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPathMatchesPathElemPrefix(t *testing.T) {
 	tests := []struct {
 		desc     string
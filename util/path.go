@@ -17,6 +17,7 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 
@@ -141,81 +142,103 @@ func firstMatching(schema *yang.Entry, path []string) *yang.Entry {
 	return s
 }
 
-// removeXPATHPredicates removes predicates from an XPath string. e.g.,
-// removeXPATHPredicates(/foo/bar[name="foo"]/config/baz -> /foo/bar/config/baz.
-func removeXPATHPredicates(s string) (string, error) {
-	var b bytes.Buffer
-	for i := 0; i < len(s); {
-		ss := s[i:]
-		si, ei := strings.Index(ss, "["), strings.Index(ss, "]")
-		switch {
-		case si == -1 && ei == -1:
-			// This substring didn't contain a [] pair, therefore write it
-			// to the buffer.
-			b.WriteString(ss)
-			// Move to the last character of the substring.
-			i += len(ss)
-		case si == -1 || ei == -1:
-			// This substring contained a mismatched pair of []s.
-			return "", fmt.Errorf("Mismatched brackets within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
-		case si > ei:
-			// This substring contained a ] before a [.
-			return "", fmt.Errorf("Incorrect ordering of [] within substring %s of %s, [ pos: %d, ] pos: %d", ss, s, si, ei)
-		default:
-			// This substring contained a matched set of []s.
-			b.WriteString(ss[0:si])
-			i += ei + 1
-		}
-	}
-
-	return b.String(), nil
-}
-
 // FindLeafRefSchema returns a schema Entry at the path pathStr relative to
-// schema if it exists, or an error otherwise.
-// pathStr has either:
-//  - the relative form "../a/b/../b/c", where ".." indicates the parent of the
-//    node, or
-//  - the absolute form "/a/b/c", which indicates the absolute path from the
-//    root of the schema tree.
+// schema if it exists, or an error otherwise. pathStr is a YANG leafref path,
+// which may be:
+//  - a relative path such as "../a/b/../b/c", where ".." indicates the parent
+//    of the node,
+//  - an absolute path such as "/a/b[k = current()/../k]/c", which indicates
+//    the absolute path from the root of the schema tree, or
+//  - a path that begins with the deref() function, such as
+//    "deref(../a)/../b", which is resolved relative to the target of the
+//    leafref a.
+// Predicates do not change the schema node that is selected by a path, and
+// hence are ignored. Choice and case nodes are not data nodes, and hence are
+// not named in pathStr.
 func FindLeafRefSchema(schema *yang.Entry, pathStr string) (*yang.Entry, error) {
 	if pathStr == "" {
 		return nil, fmt.Errorf("leafref schema %s has empty path", schema.Name)
 	}
 
-	refSchema := schema
-	pathStr, err := removeXPATHPredicates(pathStr)
+	e, err := ParseXPath(pathStr)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("leafref schema %s path %s: %v", schema.Name, pathStr, err)
 	}
-	path := strings.Split(pathStr, "/")
-
-	// For absolute path, reset to root of the schema tree.
-	if pathStr[0] == '/' {
-		refSchema = SchemaTreeRoot(schema)
-		path = path[1:]
+	refSchema, err := xpathTargetSchema(e, schema)
+	if err != nil {
+		return nil, fmt.Errorf("%v for leafref schema %s with path %s", err, schema.Name, pathStr)
 	}
+	return refSchema, nil
+}
 
-	for i := 0; i < len(path); i++ {
-		pe, err := stripModulePrefixWithCheck(path[i])
+// xpathTargetSchema returns the schema of the nodes that are selected by the
+// XPath expression e when it is evaluated with a context node described by
+// the context schema. Only the subset of XPath that is allowed in a leafref
+// path is supported.
+func xpathTargetSchema(e XPathExpr, context *yang.Entry) (*yang.Entry, error) {
+	switch e := e.(type) {
+	case *XPathLocationPath:
+		s := context
+		if e.Absolute {
+			s = SchemaTreeRoot(context)
+		}
+		return xpathStepsSchema(s, e.Steps)
+	case *XPathPathExpr:
+		s, err := xpathTargetSchema(e.Filter, context)
 		if err != nil {
-			return nil, fmt.Errorf("leafref schema %s path %s: %v", schema.Name, pathStr, err)
+			return nil, err
 		}
-
-		if pe == ".." {
-			if refSchema.Parent == nil {
-				return nil, fmt.Errorf("parent of %s is nil for leafref schema %s with path %s", refSchema.Name, schema.Name, pathStr)
-			}
-			refSchema = refSchema.Parent
-			continue
+		if e.Path == nil {
+			return s, nil
 		}
-		if refSchema.Dir[pe] == nil {
-			return nil, fmt.Errorf("schema node %s is nil for leafref schema %s with path %s", pe, schema.Name, pathStr)
+		return xpathStepsSchema(s, e.Path.Steps)
+	case *XPathFilterExpr:
+		return xpathTargetSchema(e.Primary, context)
+	case *XPathFunctionCall:
+		switch {
+		case e.Name == "current" && len(e.Args) == 0:
+			return context, nil
+		case e.Name == "deref" && len(e.Args) == 1:
+			s, err := xpathTargetSchema(e.Args[0], context)
+			if err != nil {
+				return nil, err
+			}
+			if !IsLeafRef(s) {
+				return nil, fmt.Errorf("argument %s of deref() is not a leafref", s.Name)
+			}
+			return FindLeafRefSchema(s, s.Type.Path)
 		}
-		refSchema = refSchema.Dir[pe]
 	}
+	return nil, fmt.Errorf("expression %s does not select a schema node", e)
+}
 
-	return refSchema, nil
+// xpathStepsSchema returns the schema that is reached by following steps
+// from the schema s. The parent of a node is its nearest ancestor that is not
+// a choice or case.
+func xpathStepsSchema(s *yang.Entry, steps []*XPathStep) (*yang.Entry, error) {
+	for _, st := range steps {
+		switch {
+		case st.Axis == "self" && st.NodeType == "node":
+		case st.Axis == "parent" && st.NodeType == "node":
+			p := s.Parent
+			for p != nil && IsChoiceOrCase(p) {
+				p = p.Parent
+			}
+			if p == nil {
+				return nil, fmt.Errorf("parent of %s is nil", s.Name)
+			}
+			s = p
+		case st.Axis == "child" && st.NodeType == "" && st.Name != "*":
+			c := xpathChildSchema(s, st.Name)
+			if c == nil {
+				return nil, fmt.Errorf("schema node %s is nil", st.Name)
+			}
+			s = c
+		default:
+			return nil, fmt.Errorf("step %s does not select a single schema node", st)
+		}
+	}
+	return s, nil
 }
 
 // StripModulePrefixesStr returns "in" with each element with the format "A:B"
@@ -237,14 +260,16 @@ func stripModulePrefixes(in []string) []string {
 // stripModulePrefixWithCheck removes the prefix from a YANG path element, and
 // returns an error for unexpected formats. For example, removing foo from
 // "foo:bar".  Such qualified paths are used in YANG modules where remote paths
-// are referenced.
+// are referenced. The @ that RFC7952 prepends to the name of the member that
+// carries the metadata of a data node is retained, such that "@foo:bar"
+// becomes "@bar".
 func stripModulePrefixWithCheck(name string) (string, error) {
 	ps := strings.Split(name, ":")
 	switch len(ps) {
 	case 1:
 		return name, nil
 	case 2:
-		return ps[1], nil
+		return metadataMarker(ps[0]) + ps[1], nil
 	}
 	return "", fmt.Errorf("path element did not form a valid name (name, prefix:name): %v", name)
 }
@@ -252,19 +277,30 @@ func stripModulePrefixWithCheck(name string) (string, error) {
 // StripModulePrefix removes the prefix from a YANG path element, and
 // the string format is invalid, simply returns the argument. For example,
 // removing foo from "foo:bar". Such qualified paths are used in YANG modules
-// where remote paths are referenced.
+// where remote paths are referenced. As with stripModulePrefixWithCheck, the
+// @ of an RFC7952 metadata member name is retained.
 func StripModulePrefix(name string) string {
 	ps := strings.Split(name, ":")
 	switch len(ps) {
 	case 1:
 		return name
 	case 2:
-		return ps[1]
+		return metadataMarker(ps[0]) + ps[1]
 	default:
 		return name
 	}
 }
 
+// metadataMarker returns "@" if prefix, the module prefix of a path element,
+// begins with the @ of an RFC7952 metadata member name, and the empty string
+// otherwise.
+func metadataMarker(prefix string) string {
+	if strings.HasPrefix(prefix, "@") {
+		return "@"
+	}
+	return ""
+}
+
 // PathStringToElements splits the string s, which represents a gNMI string
 // path into its constituent elements. It does not parse keys, which are left
 // unchanged within the path - but removes escape characters from element
@@ -332,3 +368,89 @@ func SlicePathToString(parts []string) string {
 	}
 	return buf.String()
 }
+
+// ResourceIDElem is a segment of an RFC8040 data resource identifier, which
+// identifies a single data node.
+type ResourceIDElem struct {
+	// Name is the name of the data node, which may be qualified with the
+	// name of its module, as "module:name".
+	Name string
+	// Keys is the values of the keys of a list entry, in the order of the
+	// YANG key statement, or the value of a leaf-list entry. It is nil if
+	// the segment does not specify any values.
+	Keys []string
+}
+
+// ParseResourceID splits the RFC8040 data resource identifier id, which must
+// either be empty or start with a /, into its segments, and removes the
+// percent-encoding of their names and key values. The key values of a
+// segment are separated by commas, as per RFC8040 section 3.5.3. An empty
+// identifier, or one that is only a /, has no segments.
+func ParseResourceID(id string) ([]*ResourceIDElem, error) {
+	if id == "" || id == "/" {
+		return nil, nil
+	}
+	if id[0] != '/' {
+		return nil, fmt.Errorf("data resource identifier %q must start with /", id)
+	}
+	var elems []*ResourceIDElem
+	for _, seg := range strings.Split(id[1:], "/") {
+		name, vals, hasKeys := seg, "", false
+		if i := strings.IndexByte(seg, '='); i >= 0 {
+			name, vals, hasKeys = seg[:i], seg[i+1:], true
+		}
+		n, err := url.PathUnescape(name)
+		if err != nil || n == "" {
+			return nil, fmt.Errorf("invalid identifier %q in %q", seg, id)
+		}
+		e := &ResourceIDElem{Name: n}
+		if hasKeys {
+			e.Keys = []string{}
+			for _, v := range strings.Split(vals, ",") {
+				k, err := url.PathUnescape(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q for %s in %q", v, n, id)
+				}
+				e.Keys = append(e.Keys, k)
+			}
+		}
+		elems = append(elems, e)
+	}
+	return elems, nil
+}
+
+// ResourceID returns the RFC8040 data resource identifier whose segments are
+// elems, which is the inverse of ParseResourceID. Key values are
+// percent-encoded, other than the unreserved characters of RFC3986.
+func ResourceID(elems []*ResourceIDElem) string {
+	var b strings.Builder
+	for _, e := range elems {
+		b.WriteByte('/')
+		b.WriteString(e.Name)
+		if e.Keys == nil {
+			continue
+		}
+		b.WriteByte('=')
+		for i, k := range e.Keys {
+			if i != 0 {
+				b.WriteByte(',')
+			}
+			escapeResourceIDValue(&b, k)
+		}
+	}
+	return b.String()
+}
+
+// escapeResourceIDValue writes the key value v to b, percent-encoding all of
+// its characters other than those that are unreserved, as per RFC3986.
+func escapeResourceIDValue(b *strings.Builder, v string) {
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(b, "%%%02X", c)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/gnmi/errdiff"
 	"github.com/openconfig/goyang/pkg/yang"
 )
 
@@ -356,72 +357,6 @@ func TestSchemaPathNoChoiceCase(t *testing.T) {
 	}
 }
 
-func TestRemoveXPATHPredicates(t *testing.T) {
-	tests := []struct {
-		desc    string
-		in      string
-		want    string
-		wantErr bool
-	}{{
-		desc: "simple predicate",
-		in:   `/foo/bar[name="eth0"]`,
-		want: "/foo/bar",
-	}, {
-		desc: "predicate with path",
-		in:   `/foo/bar[name="/foo/bar/baz"]/config/hat`,
-		want: "/foo/bar/config/hat",
-	}, {
-		desc: "predicate with function",
-		in:   `/foo/bar[name="current()/../interface"]/config/baz`,
-		want: "/foo/bar/config/baz",
-	}, {
-		desc: "multiple predicates",
-		in:   `/foo/bar[name="current()/../interface"]/container/list[key="42"]/config/foo`,
-		want: "/foo/bar/container/list/config/foo",
-	}, {
-		desc: "a real example",
-		in:   `/oc-if:interfaces/oc-if:interface[oc-if:name=current()/../interface]/oc-if:subinterfaces/oc-if:subinterface/oc-if:index`,
-		want: "/oc-if:interfaces/oc-if:interface/oc-if:subinterfaces/oc-if:subinterface/oc-if:index",
-	}, {
-		desc:    "] without [",
-		in:      `/foo/bar]`,
-		wantErr: true,
-	}, {
-		desc:    "[ without closure",
-		in:      `/foo/bar[`,
-		wantErr: true,
-	}, {
-		desc: "multiple predicates, end of string",
-		in:   `/foo/bar/name[e="1"]/bar[j="2"]`,
-		want: "/foo/bar/name/bar",
-	}, {
-		desc:    "][ in incorrect order",
-		in:      `/foo/bar][`,
-		wantErr: true,
-	}, {
-		desc: "empty string",
-		in:   ``,
-		want: ``,
-	}, {
-		desc: "predicate directly",
-		in:   `foo[bar="test"]`,
-		want: `foo`,
-	}}
-
-	for _, tt := range tests {
-		t.Run(tt.desc, func(t *testing.T) {
-			got, err := removeXPATHPredicates(tt.in)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("removeXPATHPredicates(%s): got unexpected error, got: %v", tt.in, err)
-			}
-
-			if got != tt.want {
-				t.Errorf("removePredicate(%v): did not get expected value, got: %v, want: %v", tt.in, got, tt.want)
-			}
-		})
-	}
-}
-
 func TestFindLeafRefSchema(t *testing.T) {
 	tests := []struct {
 		desc      string
@@ -472,7 +407,7 @@ func TestFindLeafRefSchema(t *testing.T) {
 			},
 		},
 		inPathStr: "/interfaces/interface[name=foo/bar",
-		wantErr:   "Mismatched brackets within substring /interfaces/interface[name=foo/bar of /interfaces/interface[name=foo/bar, [ pos: 21, ] pos: -1",
+		wantErr:   `leafref schema referencing path /interfaces/interface[name=foo/bar: cannot parse XPath expression "/interfaces/interface[name=foo/bar": expected ] at position 34, got ""`,
 	}, {
 		desc: "with xpath predicate",
 		inSchema: &yang.Entry{
@@ -534,7 +469,7 @@ func TestFindLeafRefSchema(t *testing.T) {
 			},
 		},
 		inPathStr: "/interface:foo:bar/baz",
-		wantErr:   "leafref schema referencing path /interface:foo:bar/baz: path element did not form a valid name (name, prefix:name): interface:foo:bar",
+		wantErr:   `leafref schema referencing path /interface:foo:bar/baz: cannot parse XPath expression "/interface:foo:bar/baz": unexpected character ':' at position 14`,
 	}, {
 		desc: "nil reference",
 		inSchema: &yang.Entry{
@@ -566,6 +501,165 @@ func TestFindLeafRefSchema(t *testing.T) {
 	}
 }
 
+func TestFindLeafRefSchemaXPath(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"interfaces": {
+				Name: "interfaces",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"interface": {
+						Name:     "interface",
+						Kind:     yang.DirectoryEntry,
+						ListAttr: &yang.ListAttr{},
+						Key:      "name",
+						Dir: map[string]*yang.Entry{
+							"name": {
+								Name: "name",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Yleafref, Path: "../config/name"},
+							},
+							"config": {
+								Name: "config",
+								Kind: yang.DirectoryEntry,
+								Dir: map[string]*yang.Entry{
+									"name": {
+										Name: "name",
+										Kind: yang.LeafEntry,
+										Type: &yang.YangType{Kind: yang.Ystring},
+									},
+									"mtu": {
+										Name: "mtu",
+										Kind: yang.LeafEntry,
+										Type: &yang.YangType{Kind: yang.Yuint32},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"system": {
+				Name: "system",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"hostname": {
+						Name: "hostname",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Ystring},
+					},
+					"ref": {
+						Name: "ref",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Yleafref, Path: "/interfaces/interface/name"},
+					},
+					"transport": {
+						Name: "transport",
+						Kind: yang.ChoiceEntry,
+						Dir: map[string]*yang.Entry{
+							"tcp": {
+								Name: "tcp",
+								Kind: yang.CaseEntry,
+								Dir: map[string]*yang.Entry{
+									"port": {
+										Name: "port",
+										Kind: yang.LeafEntry,
+										Type: &yang.YangType{Kind: yang.Yuint16},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	addParents(schema)
+	system := schema.Dir["system"]
+	port := system.Dir["transport"].Dir["tcp"].Dir["port"]
+	intf := schema.Dir["interfaces"].Dir["interface"]
+
+	tests := []struct {
+		desc      string
+		inSchema  *yang.Entry
+		inPathStr string
+		want      *yang.Entry
+		wantErr   string
+	}{{
+		desc:      "multiple predicates",
+		inSchema:  system.Dir["hostname"],
+		inPathStr: "/interfaces/interface[name = current()/../ref][config/mtu > 1500]/config/mtu",
+		want:      intf.Dir["config"].Dir["mtu"],
+	}, {
+		desc:      "nested predicates",
+		inSchema:  system.Dir["hostname"],
+		inPathStr: "/interfaces/interface[name = /interfaces/interface[config/mtu = 1500]/name]/config/name",
+		want:      intf.Dir["config"].Dir["name"],
+	}, {
+		desc:      "deref of current node",
+		inSchema:  system.Dir["ref"],
+		inPathStr: "deref(.)/../config/mtu",
+		want:      intf.Dir["config"].Dir["mtu"],
+	}, {
+		desc:      "deref of sibling",
+		inSchema:  system.Dir["hostname"],
+		inPathStr: "deref(current()/../ref)/../config/mtu",
+		want:      intf.Dir["config"].Dir["mtu"],
+	}, {
+		desc:      "nested deref",
+		inSchema:  system.Dir["hostname"],
+		inPathStr: "deref(deref(../ref))",
+		want:      intf.Dir["config"].Dir["name"],
+	}, {
+		desc:      "child within choice and case",
+		inSchema:  system.Dir["hostname"],
+		inPathStr: "../port",
+		want:      port,
+	}, {
+		desc:      "parent of node within case",
+		inSchema:  port,
+		inPathStr: "../hostname",
+		want:      system.Dir["hostname"],
+	}, {
+		desc:      "deref of non-leafref",
+		inSchema:  system.Dir["ref"],
+		inPathStr: "deref(../hostname)/../config/mtu",
+		wantErr:   "argument hostname of deref() is not a leafref for leafref schema ref with path deref(../hostname)/../config/mtu",
+	}, {
+		desc:      "wildcard",
+		inSchema:  system.Dir["ref"],
+		inPathStr: "/interfaces/*",
+		wantErr:   "step * does not select a single schema node for leafref schema ref with path /interfaces/*",
+	}, {
+		desc:      "parent of root",
+		inSchema:  system.Dir["ref"],
+		inPathStr: "../../../name",
+		wantErr:   "parent of device is nil for leafref schema ref with path ../../../name",
+	}, {
+		desc:      "not a path",
+		inSchema:  system.Dir["ref"],
+		inPathStr: "count(/interfaces/interface)",
+		wantErr:   "expression count(/interfaces/interface) does not select a schema node for leafref schema ref with path count(/interfaces/interface)",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := FindLeafRefSchema(tt.inSchema, tt.inPathStr)
+			if diff := errdiff.Substring(err, tt.wantErr); diff != "" {
+				t.Fatalf("FindLeafRefSchema(%s, %s): did not get expected error, %s", tt.inSchema.Name, tt.inPathStr, diff)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FindLeafRefSchema(%s, %s): did not get expected schema, got: %s, want: %s", tt.inSchema.Name, tt.inPathStr, SchemaTreePathNoModule(got), SchemaTreePathNoModule(tt.want))
+			}
+		})
+	}
+}
+
 func TestStripModulePrefix(t *testing.T) {
 	tests := []struct {
 		desc     string
@@ -580,6 +674,10 @@ func TestStripModulePrefix(t *testing.T) {
 		desc:     "valid without prefix",
 		inName:   "two",
 		wantName: "two",
+	}, {
+		desc:     "metadata member with prefix",
+		inName:   "@one:two",
+		wantName: "@two",
 	}, {
 		desc:    "invalid input",
 		inName:  "foo:bar:foo",
@@ -782,3 +880,75 @@ func TestSlicePathToString(t *testing.T) {
 		}
 	}
 }
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               string
+		want             []*ResourceIDElem
+		wantID           string
+		wantErrSubstring string
+	}{{
+		desc: "empty",
+		in:   "",
+	}, {
+		desc:   "datastore",
+		in:     "/",
+		wantID: "",
+	}, {
+		desc:   "containers with module names",
+		in:     "/mod:a/b/other:c",
+		want:   []*ResourceIDElem{{Name: "mod:a"}, {Name: "b"}, {Name: "other:c"}},
+		wantID: "/mod:a/b/other:c",
+	}, {
+		desc:   "list entry with multiple keys",
+		in:     "/routes/route=10.0.0.0%2F8,red/next-hop",
+		want:   []*ResourceIDElem{{Name: "routes"}, {Name: "route", Keys: []string{"10.0.0.0/8", "red"}}, {Name: "next-hop"}},
+		wantID: "/routes/route=10.0.0.0%2F8,red/next-hop",
+	}, {
+		desc:   "escaped comma and empty key",
+		in:     "/list=a%2Cb,",
+		want:   []*ResourceIDElem{{Name: "list", Keys: []string{"a,b", ""}}},
+		wantID: "/list=a%2Cb,",
+	}, {
+		desc:   "leaf-list entry",
+		in:     "/dns=10.0.0.1",
+		want:   []*ResourceIDElem{{Name: "dns", Keys: []string{"10.0.0.1"}}},
+		wantID: "/dns=10.0.0.1",
+	}, {
+		desc:   "reserved characters are escaped",
+		in:     "/list=a%20b%3Dc",
+		want:   []*ResourceIDElem{{Name: "list", Keys: []string{"a b=c"}}},
+		wantID: "/list=a%20b%3Dc",
+	}, {
+		desc:             "relative identifier",
+		in:               "a/b",
+		wantErrSubstring: "must start with /",
+	}, {
+		desc:             "empty name",
+		in:               "/a//b",
+		wantErrSubstring: `invalid identifier "" in "/a//b"`,
+	}, {
+		desc:             "invalid escape in key",
+		in:               "/list=a%2",
+		wantErrSubstring: `invalid value "a%2" for list`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseResourceID(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("ParseResourceID(%q): did not get expected error, %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseResourceID(%q): did not get expected elements, diff(-want, +got):\n%s", tt.in, diff)
+			}
+			if got := ResourceID(got); got != tt.wantID {
+				t.Errorf("ResourceID(ParseResourceID(%q)): got %q, want %q", tt.in, got, tt.wantID)
+			}
+		})
+	}
+}
@@ -77,6 +77,23 @@ func IsTypeSliceOfInterface(t reflect.Type) bool {
 	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Interface
 }
 
+// orderedMapType is the type of the interface that is implemented by the
+// ordered map types that are generated to represent keyed YANG lists that are
+// "ordered-by user". It corresponds to the ygot.GoOrderedMap interface, which
+// cannot be referenced from this package.
+var orderedMapType = reflect.TypeOf((*interface {
+	IsYANGOrderedList()
+})(nil)).Elem()
+
+// IsTypeOrderedMap reports whether t is an ordered map ptr type, which is used
+// to represent a keyed YANG list that is "ordered-by user" in generated code.
+func IsTypeOrderedMap(t reflect.Type) bool {
+	if t == reflect.TypeOf(nil) {
+		return false
+	}
+	return t.Kind() == reflect.Ptr && t.Implements(orderedMapType)
+}
+
 // IsNilOrInvalidValue reports whether v is nil or reflect.Zero.
 func IsNilOrInvalidValue(v reflect.Value) bool {
 	return !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) || IsValueNil(v.Interface())
@@ -133,6 +150,11 @@ func IsValueMap(v reflect.Value) bool {
 	return v.Kind() == reflect.Map
 }
 
+// IsValueOrderedMap reports whether v is an ordered map ptr type.
+func IsValueOrderedMap(v reflect.Value) bool {
+	return v.IsValid() && IsTypeOrderedMap(v.Type())
+}
+
 // IsValueSlice reports whether v is a slice type.
 func IsValueSlice(v reflect.Value) bool {
 	return v.Kind() == reflect.Slice
@@ -207,6 +229,94 @@ func InsertIntoMap(parentMap interface{}, key interface{}, value interface{}) er
 	return nil
 }
 
+// OrderedMapKeyType returns the type of the keys of the ordered map type t.
+func OrderedMapKeyType(t reflect.Type) reflect.Type {
+	m, _ := t.MethodByName("Get")
+	// The first input of the method type is the receiver.
+	return m.Type.In(1)
+}
+
+// OrderedMapElemType returns the type of the values of the ordered map type t,
+// which is a ptr to the struct that represents a member of the list.
+func OrderedMapElemType(t reflect.Type) reflect.Type {
+	m, _ := t.MethodByName("Get")
+	return m.Type.Out(0)
+}
+
+// OrderedMapKeys returns the keys of the ordered map v, in the order of the
+// members of the list that it represents.
+func OrderedMapKeys(v reflect.Value) []reflect.Value {
+	return orderedMapSlice(v.MethodByName("Keys").Call(nil)[0])
+}
+
+// OrderedMapValues returns the values of the ordered map v, in the order of
+// the members of the list that it represents.
+func OrderedMapValues(v reflect.Value) []reflect.Value {
+	return orderedMapSlice(v.MethodByName("Values").Call(nil)[0])
+}
+
+// orderedMapSlice returns the elements of the slice v.
+func orderedMapSlice(v reflect.Value) []reflect.Value {
+	var vals []reflect.Value
+	for i := 0; i < v.Len(); i++ {
+		vals = append(vals, v.Index(i))
+	}
+	return vals
+}
+
+// OrderedMapGet returns the value with the supplied key from the ordered map
+// v. The zero value of the ordered map's value type is returned if the key
+// does not exist.
+func OrderedMapGet(v reflect.Value, key reflect.Value) reflect.Value {
+	return v.MethodByName("Get").Call([]reflect.Value{key})[0]
+}
+
+// KeyedListMembers returns the keys of the keyed list v, which must be a map
+// or an ordered map, along with the values with the corresponding indices.
+// The members of an ordered map are returned in order.
+func KeyedListMembers(v reflect.Value) ([]reflect.Value, []reflect.Value) {
+	if IsValueOrderedMap(v) {
+		return OrderedMapKeys(v), OrderedMapValues(v)
+	}
+	keys := v.MapKeys()
+	var vals []reflect.Value
+	for _, k := range keys {
+		vals = append(vals, v.MapIndex(k))
+	}
+	return keys, vals
+}
+
+// OrderedMapDelete deletes the value with the supplied key from the ordered
+// map v, if it exists.
+func OrderedMapDelete(v reflect.Value, key reflect.Value) {
+	v.MethodByName("Delete").Call([]reflect.Value{key})
+}
+
+// InsertIntoOrderedMap appends value to the end of parentMap, which must be an
+// ordered map. An error is returned if a member with the same key as value
+// already exists in parentMap.
+func InsertIntoOrderedMap(parentMap interface{}, value interface{}) error {
+	DbgPrint("InsertIntoOrderedMap into parent type %T with value \n%s\n (%T)",
+		parentMap, pretty.Sprint(value), value)
+
+	v := reflect.ValueOf(parentMap)
+	if !IsValueOrderedMap(v) {
+		return fmt.Errorf("InsertIntoOrderedMap parent type is %T, must be ordered map", parentMap)
+	}
+	if v.IsNil() {
+		return fmt.Errorf("InsertIntoOrderedMap parent is a nil %T", parentMap)
+	}
+	vv := reflect.ValueOf(value)
+	if et := OrderedMapElemType(v.Type()); !vv.IsValid() || vv.Type() != et {
+		return fmt.Errorf("InsertIntoOrderedMap value type is %T, must be %v", value, et)
+	}
+
+	if err := v.MethodByName("Append").Call([]reflect.Value{vv})[0].Interface(); err != nil {
+		return err.(error)
+	}
+	return nil
+}
+
 // UpdateField updates a field called fieldName (which must exist, but may be
 // nil) in parentStruct, with value fieldValue. If the field is a slice,
 // fieldValue is appended.
@@ -643,6 +753,43 @@ func forEachFieldInternal(ni *NodeInfo, in, out interface{}, iterFunction FieldI
 	t := v.Type()
 
 	switch {
+	case IsTypeOrderedMap(t):
+		// An ordered map is handled in the same way as a map, with its members
+		// being traversed in order.
+		schema := *(ni.Schema)
+		schema.ListAttr = nil
+		if IsNilOrInvalidValue(v) {
+			nn := &NodeInfo{
+				Parent:         ni,
+				PathFromParent: []string{schema.Name},
+				Schema:         &schema,
+				FieldValue:     reflect.Zero(OrderedMapElemType(t)),
+			}
+			switch in.(type) {
+			case *PathQueryNodeMemo: // Memoization of path queries requested.
+				errs = AppendErrs(errs, forEachFieldInternal(nn, newPathQueryMemo(), out, iterFunction))
+			default:
+				errs = AppendErrs(errs, forEachFieldInternal(nn, in, out, iterFunction))
+			}
+		} else {
+			keys := OrderedMapKeys(v)
+			for i, val := range OrderedMapValues(v) {
+				nn := *ni
+				nn.Schema = &schema
+				nn.Parent = ni
+				nn.PathFromParent = []string{schema.Name}
+				nn.FieldValue = val
+				nn.FieldKey = keys[i]
+				nn.FieldKeys = keys
+				switch in.(type) {
+				case *PathQueryNodeMemo: // Memoization of path queries requested.
+					errs = AppendErrs(errs, forEachFieldInternal(&nn, newPathQueryMemo(), out, iterFunction))
+				default:
+					errs = AppendErrs(errs, forEachFieldInternal(&nn, in, out, iterFunction))
+				}
+			}
+		}
+
 	case IsTypeStructPtr(t):
 		t = t.Elem()
 		if !IsNilOrInvalidValue(v) {
@@ -683,7 +830,7 @@ func forEachFieldInternal(ni *NodeInfo, in, out interface{}, iterFunction FieldI
 				// In the case of a map/slice, the path is of the form
 				// "container/element" in the compressed schema, so trim off
 				// any extra path elements in this case.
-				if IsTypeSlice(sf.Type) || IsTypeMap(sf.Type) {
+				if IsTypeSlice(sf.Type) || IsTypeMap(sf.Type) || IsTypeOrderedMap(sf.Type) {
 					nn.PathFromParent = p[0:1]
 				}
 				switch in.(type) {
@@ -808,6 +955,18 @@ func forEachDataFieldInternal(ni *NodeInfo, in, out interface{}, iterFunction Fi
 	// a leaf or leaf-list, which are not recursed into when traversing the
 	// data tree.
 	switch {
+	case IsTypeOrderedMap(t):
+		// Handle the case of an ordered map, which is a keyed YANG list that
+		// is ordered-by user, by iterating its members in order.
+		keys := OrderedMapKeys(v)
+		for i, val := range OrderedMapValues(v) {
+			nn := *ni
+			nn.Parent = ni
+			nn.FieldValue = val
+			nn.FieldKey = keys[i]
+			nn.FieldKeys = keys
+			errs = AppendErrs(errs, forEachDataFieldInternal(&nn, in, out, iterFunction))
+		}
 	case IsTypeStructPtr(t):
 		// A struct pointer in a GoStruct is a pointer to another container within
 		// the YANG, therefore we dereference the pointer and then recurse. If the
@@ -838,7 +997,7 @@ func forEachDataFieldInternal(ni *NodeInfo, in, out interface{}, iterFunction Fi
 			// fields.
 			for _, p := range ps {
 				nn.PathFromParent = p
-				if IsTypeSlice(sf.Type) || IsTypeMap(sf.Type) {
+				if IsTypeSlice(sf.Type) || IsTypeMap(sf.Type) || IsTypeOrderedMap(sf.Type) {
 					// Since lists can have path compression - where the path contains more
 					// than one element, ensure that the schema path we received is only two
 					// elements long. This protects against compression errors where there are
@@ -921,7 +1080,7 @@ func getNodesInternal(schema *yang.Entry, root interface{}, path *gpb.Path) ([]i
 	DbgPrint("GetNode next path %v, value %v", path.GetElem()[0], ValueStrDebug(root))
 
 	switch {
-	case schema.IsContainer() || (schema.IsList() && IsTypeStructPtr(reflect.TypeOf(root))):
+	case schema.IsContainer() || IsOperationContainer(schema) || (schema.IsList() && IsTypeStructPtr(reflect.TypeOf(root)) && !IsTypeOrderedMap(reflect.TypeOf(root))):
 		// Either a container or list schema with struct data node (which could
 		// be an element of a list).
 		return getNodesContainer(schema, root, path)
@@ -973,7 +1132,7 @@ func getNodesContainer(schema *yang.Entry, root interface{}, path *gpb.Path) ([]
 				// don't trim whole prefix  for keyed list since name and key
 				// are a in the same element.
 				to := len(p)
-				if IsTypeMap(ft.Type) {
+				if IsTypeMap(ft.Type) || IsTypeOrderedMap(ft.Type) {
 					to--
 				}
 				return getNodesInternal(cschema, f.Interface(), TrimGNMIPathPrefix(path, p[0:to]))
@@ -995,7 +1154,19 @@ func getNodesList(schema *yang.Entry, root interface{}, path *gpb.Path) ([]inter
 	if schema.Key == "" {
 		return nil, nil, fmt.Errorf("getNodesList: path %v cannot traverse unkeyed list type %T", path, root)
 	}
-	if !IsValueMap(rv) {
+	var keys, vals []reflect.Value
+	var listElementType, listKeyType reflect.Type
+	switch {
+	case IsValueMap(rv):
+		keys = rv.MapKeys()
+		for _, k := range keys {
+			vals = append(vals, rv.MapIndex(k))
+		}
+		listElementType, listKeyType = rv.Type().Elem().Elem(), rv.Type().Key()
+	case IsValueOrderedMap(rv):
+		keys, vals = OrderedMapKeys(rv), OrderedMapValues(rv)
+		listElementType, listKeyType = OrderedMapElemType(rv.Type()).Elem(), OrderedMapKeyType(rv.Type())
+	default:
 		// Only keyed lists can be traversed with a path.
 		return nil, nil, fmt.Errorf("getNodesList: root has type %T, expect map", root)
 	}
@@ -1005,15 +1176,12 @@ func getNodesList(schema *yang.Entry, root interface{}, path *gpb.Path) ([]inter
 		emptyKey = true
 	}
 
-	listElementType := rv.Type().Elem().Elem()
-	listKeyType := rv.Type().Key()
-
 	var matchNodes []interface{}
 	var matchSchemas []*yang.Entry
 
 	// Iterate through all the map keys to see if any match the path.
-	for _, k := range rv.MapKeys() {
-		ev := rv.MapIndex(k)
+	for i, k := range keys {
+		ev := vals[i]
 		DbgPrint("checking key %v, value %v", k.Interface(), ValueStrDebug(ev.Interface()))
 		match := true
 		if !emptyKey { // empty key matches everything.
@@ -898,6 +898,119 @@ func TestInsertIntoMap(t *testing.T) {
 	}
 }
 
+// orderedBasicStructs is an ordered map of BasicStruct pointers, keyed by
+// their StringField, of the form that is generated for an ordered-by user
+// list.
+type orderedBasicStructs struct {
+	keys     []string
+	valueMap map[string]*BasicStruct
+}
+
+func (*orderedBasicStructs) IsYANGOrderedList() {}
+
+func (o *orderedBasicStructs) Keys() []string {
+	return append([]string{}, o.keys...)
+}
+
+func (o *orderedBasicStructs) Values() []*BasicStruct {
+	var values []*BasicStruct
+	for _, k := range o.keys {
+		values = append(values, o.valueMap[k])
+	}
+	return values
+}
+
+func (o *orderedBasicStructs) Get(key string) *BasicStruct {
+	return o.valueMap[key]
+}
+
+func (o *orderedBasicStructs) Delete(key string) bool {
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			delete(o.valueMap, key)
+			return true
+		}
+	}
+	return false
+}
+
+func (o *orderedBasicStructs) Append(v *BasicStruct) error {
+	if _, ok := o.valueMap[v.StringField]; ok {
+		return fmt.Errorf("duplicate key %s", v.StringField)
+	}
+	if o.valueMap == nil {
+		o.valueMap = map[string]*BasicStruct{}
+	}
+	o.keys = append(o.keys, v.StringField)
+	o.valueMap[v.StringField] = v
+	return nil
+}
+
+func TestInsertIntoOrderedMap(t *testing.T) {
+	om := &orderedBasicStructs{}
+	for _, k := range []string{"b", "a", "c"} {
+		if err := InsertIntoOrderedMap(om, &BasicStruct{StringField: k}); err != nil {
+			t.Fatalf("InsertIntoOrderedMap(%s): got error: %v, want error: nil", k, err)
+		}
+	}
+
+	tests := []struct {
+		desc    string
+		inMap   interface{}
+		inValue interface{}
+		wantErr string
+	}{{
+		desc:    "duplicate key",
+		inMap:   om,
+		inValue: &BasicStruct{StringField: "a"},
+		wantErr: "duplicate key a",
+	}, {
+		desc:    "not an ordered map",
+		inMap:   map[string]*BasicStruct{},
+		inValue: &BasicStruct{StringField: "d"},
+		wantErr: "InsertIntoOrderedMap parent type is map[string]*util.BasicStruct, must be ordered map",
+	}, {
+		desc:    "nil ordered map",
+		inMap:   (*orderedBasicStructs)(nil),
+		inValue: &BasicStruct{StringField: "d"},
+		wantErr: "InsertIntoOrderedMap parent is a nil *util.orderedBasicStructs",
+	}, {
+		desc:    "wrong value type",
+		inMap:   om,
+		inValue: &BasicSliceStruct{},
+		wantErr: "InsertIntoOrderedMap value type is *util.BasicSliceStruct, must be *util.BasicStruct",
+	}}
+
+	for _, tt := range tests {
+		if got, want := errToString(InsertIntoOrderedMap(tt.inMap, tt.inValue)), tt.wantErr; got != want {
+			t.Errorf("%s: InsertIntoOrderedMap(%T, %v): got error: %s, want error: %s", tt.desc, tt.inMap, tt.inValue, got, want)
+		}
+	}
+
+	v := reflect.ValueOf(om)
+	var gotKeys []string
+	for _, k := range OrderedMapKeys(v) {
+		gotKeys = append(gotKeys, k.String())
+	}
+	if want := []string{"b", "a", "c"}; !reflect.DeepEqual(gotKeys, want) {
+		t.Errorf("OrderedMapKeys: got: %v, want: %v", gotKeys, want)
+	}
+	keys, vals := KeyedListMembers(v)
+	for i, k := range keys {
+		if got := vals[i].Interface().(*BasicStruct).StringField; got != k.String() {
+			t.Errorf("KeyedListMembers: got value %s for key %s", got, k.String())
+		}
+	}
+	if got := OrderedMapGet(v, reflect.ValueOf("a")).Interface().(*BasicStruct); got != om.Get("a") {
+		t.Errorf("OrderedMapGet(a): got: %v, want: %v", got, om.Get("a"))
+	}
+	OrderedMapDelete(v, reflect.ValueOf("a"))
+	if got, want := om.Keys(), []string{"b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("OrderedMapDelete(a): got keys: %v, want: %v", got, want)
+	}
+}
+
 func TestInitializeStructField(t *testing.T) {
 	type testStruct struct {
 		// Following two fields exist to exercise
@@ -1346,6 +1459,33 @@ func TestForEachDataField(t *testing.T) {
 	}
 }
 
+type StructOfOrderedMap struct {
+	OrderedField *orderedBasicStructs `path:"basic-struct"`
+}
+
+func TestForEachDataFieldOrderedMap(t *testing.T) {
+	om := &orderedBasicStructs{}
+	for _, k := range []string{"c", "a", "b"} {
+		if err := om.Append(&BasicStruct{StringField: k, Int32Field: 42}); err != nil {
+			t.Fatalf("cannot append %s: %v", k, err)
+		}
+	}
+
+	var got []string
+	errs := ForEachDataField(&StructOfOrderedMap{OrderedField: om}, nil, &got, func(ni *NodeInfo, in, out interface{}) Errors {
+		if IsValueStructPtr(ni.FieldValue) && !IsValueOrderedMap(ni.FieldValue) && ni.FieldKey.IsValid() {
+			*out.(*[]string) = append(*out.(*[]string), fmt.Sprintf("%v", ni.FieldKey.Interface()))
+		}
+		return nil
+	})
+	if errs != nil {
+		t.Fatalf("ForEachDataField: got unexpected errors: %v", errs)
+	}
+	if want := []string{"c", "a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ForEachDataField: did not visit members in order, got: %v, want: %v", got, want)
+	}
+}
+
 func TestUpdateFieldUsingForEachField(t *testing.T) {
 	type BasicStruct struct {
 		Int32Field     int32   `path:"int32"`
@@ -0,0 +1,818 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// This file contains a parser for XPath 1.0 expressions, as used within YANG
+// must, when and leafref path statements. The parser produces an abstract
+// syntax tree which can be evaluated against a data tree by the caller; the
+// grammar implemented is that of https://www.w3.org/TR/1999/REC-xpath-19991116,
+// including the lexical disambiguation rules described in section 3.7.
+
+// XPathExpr is an interface implemented by all nodes of a parsed XPath
+// expression.
+type XPathExpr interface {
+	// String returns the expression in XPath syntax.
+	String() string
+	// isXPathExpr ensures that only types within this package implement
+	// the interface.
+	isXPathExpr()
+}
+
+// XPathBinaryExpr is a binary operation within an XPath expression. Op is
+// one of "or", "and", "=", "!=", "<", "<=", ">", ">=", "+", "-", "*", "div",
+// "mod" or "|".
+type XPathBinaryExpr struct {
+	Op  string
+	LHS XPathExpr
+	RHS XPathExpr
+}
+
+// XPathNegateExpr is a unary minus applied to an XPath expression.
+type XPathNegateExpr struct {
+	Expr XPathExpr
+}
+
+// XPathLiteral is a string literal within an XPath expression.
+type XPathLiteral struct {
+	Value string
+}
+
+// XPathNumber is a numeric literal within an XPath expression.
+type XPathNumber struct {
+	Value float64
+}
+
+// XPathVariable is a variable reference ($name) within an XPath expression.
+type XPathVariable struct {
+	Name string
+}
+
+// XPathFunctionCall is a call to a function within an XPath expression. The
+// Prefix is set only when the function name was qualified.
+type XPathFunctionCall struct {
+	Prefix string
+	Name   string
+	Args   []XPathExpr
+}
+
+// XPathFilterExpr is a primary expression (e.g., a function call or
+// parenthesised expression) that is filtered by one or more predicates.
+type XPathFilterExpr struct {
+	Primary    XPathExpr
+	Predicates []XPathExpr
+}
+
+// XPathPathExpr is a relative location path that is applied to the node-set
+// resulting from a filter expression, e.g., current()/../name.
+type XPathPathExpr struct {
+	Filter XPathExpr
+	Path   *XPathLocationPath
+}
+
+// XPathLocationPath is a location path within an XPath expression. Absolute
+// is set when the path is rooted at the document root.
+type XPathLocationPath struct {
+	Absolute bool
+	Steps    []*XPathStep
+}
+
+// XPathStep is a single step of a location path. Axis is the name of the
+// axis (e.g., child, parent) that the step traverses. When NodeType is set,
+// the step's node test is a node type test (e.g., node(), text()), otherwise
+// Name is the local name to be matched, or "*" to match any name. Prefix
+// contains the module prefix of a qualified name test.
+type XPathStep struct {
+	Axis       string
+	Prefix     string
+	Name       string
+	NodeType   string
+	Predicates []XPathExpr
+}
+
+func (*XPathBinaryExpr) isXPathExpr()   {}
+func (*XPathNegateExpr) isXPathExpr()   {}
+func (*XPathLiteral) isXPathExpr()      {}
+func (*XPathNumber) isXPathExpr()       {}
+func (*XPathVariable) isXPathExpr()     {}
+func (*XPathFunctionCall) isXPathExpr() {}
+func (*XPathFilterExpr) isXPathExpr()   {}
+func (*XPathPathExpr) isXPathExpr()     {}
+func (*XPathLocationPath) isXPathExpr() {}
+
+// String returns the XPath representation of the binary expression.
+func (e *XPathBinaryExpr) String() string {
+	if e.Op == "|" {
+		return fmt.Sprintf("%s | %s", e.LHS, e.RHS)
+	}
+	return fmt.Sprintf("(%s %s %s)", e.LHS, e.Op, e.RHS)
+}
+
+// String returns the XPath representation of the negation.
+func (e *XPathNegateExpr) String() string { return fmt.Sprintf("-%s", e.Expr) }
+
+// String returns the XPath representation of the literal, using the quote
+// character that does not appear within the value.
+func (e *XPathLiteral) String() string {
+	if strings.Contains(e.Value, `"`) {
+		return fmt.Sprintf("'%s'", e.Value)
+	}
+	return fmt.Sprintf(`"%s"`, e.Value)
+}
+
+// String returns the XPath representation of the number.
+func (e *XPathNumber) String() string { return strconv.FormatFloat(e.Value, 'f', -1, 64) }
+
+// String returns the XPath representation of the variable reference.
+func (e *XPathVariable) String() string { return "$" + e.Name }
+
+// String returns the XPath representation of the function call.
+func (e *XPathFunctionCall) String() string {
+	var args []string
+	for _, a := range e.Args {
+		args = append(args, a.String())
+	}
+	name := e.Name
+	if e.Prefix != "" {
+		name = e.Prefix + ":" + name
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+}
+
+// String returns the XPath representation of the filter expression.
+func (e *XPathFilterExpr) String() string {
+	var b strings.Builder
+	if be, ok := e.Primary.(*XPathBinaryExpr); ok && be.Op == "|" {
+		fmt.Fprintf(&b, "(%s)", be)
+	} else {
+		b.WriteString(e.Primary.String())
+	}
+	for _, p := range e.Predicates {
+		fmt.Fprintf(&b, "[%s]", p)
+	}
+	return b.String()
+}
+
+// String returns the XPath representation of the path expression.
+func (e *XPathPathExpr) String() string { return fmt.Sprintf("%s/%s", e.Filter, e.Path) }
+
+// String returns the XPath representation of the location path.
+func (e *XPathLocationPath) String() string {
+	var steps []string
+	for _, s := range e.Steps {
+		steps = append(steps, s.String())
+	}
+	p := strings.Join(steps, "/")
+	if e.Absolute {
+		return "/" + p
+	}
+	return p
+}
+
+// String returns the XPath representation of the step, using the
+// abbreviated syntax where possible.
+func (s *XPathStep) String() string {
+	var b strings.Builder
+	switch {
+	case s.Axis == "self" && s.NodeType == "node" && len(s.Predicates) == 0:
+		return "."
+	case s.Axis == "parent" && s.NodeType == "node" && len(s.Predicates) == 0:
+		return ".."
+	case s.Axis != "child":
+		b.WriteString(s.Axis + "::")
+	}
+	switch {
+	case s.NodeType != "":
+		b.WriteString(s.NodeType + "()")
+	case s.Prefix != "":
+		b.WriteString(s.Prefix + ":" + s.Name)
+	default:
+		b.WriteString(s.Name)
+	}
+	for _, p := range s.Predicates {
+		fmt.Fprintf(&b, "[%s]", p)
+	}
+	return b.String()
+}
+
+// xpathTokenKind is the type of a lexical token within an XPath expression.
+type xpathTokenKind int
+
+const (
+	// xtEOF indicates the end of the expression.
+	xtEOF xpathTokenKind = iota
+	// xtPunct is a punctuation token: ( ) [ ] . .. @ , ::
+	xtPunct
+	// xtOperator is an operator token, including the operator names and,
+	// or, mod and div, and the multiply operator.
+	xtOperator
+	// xtNameTest is a name test, i.e., *, NCName:* or a QName.
+	xtNameTest
+	// xtNodeType is a node type name followed by an opening bracket.
+	xtNodeType
+	// xtFunctionName is a function name followed by an opening bracket.
+	xtFunctionName
+	// xtAxisName is an axis name followed by ::.
+	xtAxisName
+	// xtLiteral is a quoted string literal.
+	xtLiteral
+	// xtNumber is a numeric literal.
+	xtNumber
+	// xtVariable is a variable reference.
+	xtVariable
+)
+
+// xpathToken is a single lexical token within an XPath expression.
+type xpathToken struct {
+	kind xpathTokenKind
+	val  string
+	pos  int
+}
+
+var (
+	// xpathAxes is the set of valid XPath axis names.
+	xpathAxes = map[string]bool{
+		"ancestor":           true,
+		"ancestor-or-self":   true,
+		"attribute":          true,
+		"child":              true,
+		"descendant":         true,
+		"descendant-or-self": true,
+		"following":          true,
+		"following-sibling":  true,
+		"namespace":          true,
+		"parent":             true,
+		"preceding":          true,
+		"preceding-sibling":  true,
+		"self":               true,
+	}
+	// xpathNodeTypes is the set of valid XPath node type tests.
+	xpathNodeTypes = map[string]bool{
+		"comment":                true,
+		"text":                   true,
+		"processing-instruction": true,
+		"node":                   true,
+	}
+	// xpathOperatorNames is the set of operators that are expressed as names.
+	xpathOperatorNames = map[string]bool{
+		"and": true,
+		"or":  true,
+		"mod": true,
+		"div": true,
+	}
+)
+
+// isXPathNameStart returns true if r can start an NCName.
+func isXPathNameStart(r byte) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r >= 0x80
+}
+
+// isXPathNameChar returns true if r can be used within an NCName.
+func isXPathNameChar(r byte) bool {
+	return isXPathNameStart(r) || r == '-' || r == '.' || (r >= '0' && r <= '9')
+}
+
+// isXPathSpace returns true if r is XPath whitespace.
+func isXPathSpace(r byte) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// xpathLexer splits an XPath expression into its tokens.
+type xpathLexer struct {
+	in   string
+	pos  int
+	toks []xpathToken
+}
+
+// skipSpace advances the lexer past any whitespace.
+func (l *xpathLexer) skipSpace() {
+	for l.pos < len(l.in) && isXPathSpace(l.in[l.pos]) {
+		l.pos++
+	}
+}
+
+// peekNonSpace returns the remainder of the input after any whitespace at
+// the current position.
+func (l *xpathLexer) peekNonSpace() string {
+	i := l.pos
+	for i < len(l.in) && isXPathSpace(l.in[i]) {
+		i++
+	}
+	return l.in[i:]
+}
+
+// readNCName reads an NCName from the current position of the lexer.
+func (l *xpathLexer) readNCName() string {
+	start := l.pos
+	for l.pos < len(l.in) && isXPathNameChar(l.in[l.pos]) {
+		l.pos++
+	}
+	return l.in[start:l.pos]
+}
+
+// operatorContext returns true if the preceding token means that a
+// subsequent * or NCName should be treated as an operator, per the
+// disambiguation rules of XPath 1.0 section 3.7.
+func (l *xpathLexer) operatorContext() bool {
+	if len(l.toks) == 0 {
+		return false
+	}
+	p := l.toks[len(l.toks)-1]
+	switch p.kind {
+	case xtOperator, xtAxisName:
+		return false
+	case xtPunct:
+		switch p.val {
+		case "@", "::", "(", "[", ",":
+			return false
+		}
+	}
+	return true
+}
+
+// emit appends a token to the lexer's output.
+func (l *xpathLexer) emit(k xpathTokenKind, v string, pos int) {
+	l.toks = append(l.toks, xpathToken{kind: k, val: v, pos: pos})
+}
+
+// lex tokenises the input of the lexer.
+func (l *xpathLexer) lex() error {
+	for {
+		l.skipSpace()
+		if l.pos >= len(l.in) {
+			l.emit(xtEOF, "", l.pos)
+			return nil
+		}
+		start := l.pos
+		c := l.in[l.pos]
+		two := ""
+		if l.pos+1 < len(l.in) {
+			two = l.in[l.pos : l.pos+2]
+		}
+		switch {
+		case two == "::" || two == "..":
+			l.pos += 2
+			l.emit(xtPunct, two, start)
+		case two == "//" || two == "!=" || two == "<=" || two == ">=":
+			l.pos += 2
+			l.emit(xtOperator, two, start)
+		case c == '.' && l.pos+1 < len(l.in) && l.in[l.pos+1] >= '0' && l.in[l.pos+1] <= '9', c >= '0' && c <= '9':
+			for l.pos < len(l.in) && l.in[l.pos] >= '0' && l.in[l.pos] <= '9' {
+				l.pos++
+			}
+			if l.pos < len(l.in) && l.in[l.pos] == '.' {
+				l.pos++
+				for l.pos < len(l.in) && l.in[l.pos] >= '0' && l.in[l.pos] <= '9' {
+					l.pos++
+				}
+			}
+			l.emit(xtNumber, l.in[start:l.pos], start)
+		case strings.IndexByte("()[].@,", c) != -1:
+			l.pos++
+			l.emit(xtPunct, string(c), start)
+		case strings.IndexByte("/|+-=<>", c) != -1:
+			l.pos++
+			l.emit(xtOperator, string(c), start)
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(l.in[l.pos+1:], c)
+			if end == -1 {
+				return fmt.Errorf("unterminated literal at position %d", start)
+			}
+			l.emit(xtLiteral, l.in[l.pos+1:l.pos+1+end], start)
+			l.pos += end + 2
+		case c == '$':
+			l.pos++
+			name := l.readNCName()
+			if l.pos < len(l.in) && l.in[l.pos] == ':' && l.pos+1 < len(l.in) && isXPathNameStart(l.in[l.pos+1]) {
+				l.pos++
+				name = name + ":" + l.readNCName()
+			}
+			if name == "" {
+				return fmt.Errorf("invalid variable reference at position %d", start)
+			}
+			l.emit(xtVariable, name, start)
+		case c == '*':
+			l.pos++
+			if l.operatorContext() {
+				l.emit(xtOperator, "*", start)
+			} else {
+				l.emit(xtNameTest, "*", start)
+			}
+		case isXPathNameStart(c):
+			name := l.readNCName()
+			if l.operatorContext() {
+				if !xpathOperatorNames[name] {
+					return fmt.Errorf("unexpected name %s at position %d, expected operator", name, start)
+				}
+				l.emit(xtOperator, name, start)
+				continue
+			}
+			// Handle QNames and NCName:* name tests, ensuring that an
+			// axis separator is not consumed.
+			if l.pos+1 < len(l.in) && l.in[l.pos] == ':' && l.in[l.pos+1] != ':' {
+				switch {
+				case l.in[l.pos+1] == '*':
+					l.pos += 2
+					l.emit(xtNameTest, name+":*", start)
+					continue
+				case isXPathNameStart(l.in[l.pos+1]):
+					l.pos++
+					name = name + ":" + l.readNCName()
+				default:
+					return fmt.Errorf("invalid qualified name at position %d", start)
+				}
+			}
+			rest := l.peekNonSpace()
+			switch {
+			case strings.HasPrefix(rest, "::"):
+				if !xpathAxes[name] {
+					return fmt.Errorf("invalid axis name %s at position %d", name, start)
+				}
+				l.emit(xtAxisName, name, start)
+			case strings.HasPrefix(rest, "("):
+				if xpathNodeTypes[name] {
+					l.emit(xtNodeType, name, start)
+				} else {
+					l.emit(xtFunctionName, name, start)
+				}
+			default:
+				l.emit(xtNameTest, name, start)
+			}
+		default:
+			return fmt.Errorf("unexpected character %q at position %d", c, start)
+		}
+	}
+}
+
+// xpathParser is a recursive descent parser for XPath 1.0 expressions.
+type xpathParser struct {
+	toks []xpathToken
+	pos  int
+}
+
+// peek returns the next token without consuming it.
+func (p *xpathParser) peek() xpathToken { return p.toks[p.pos] }
+
+// next consumes and returns the next token.
+func (p *xpathParser) next() xpathToken {
+	t := p.toks[p.pos]
+	if t.kind != xtEOF {
+		p.pos++
+	}
+	return t
+}
+
+// is returns true if the next token is of kind k with value v.
+func (p *xpathParser) is(k xpathTokenKind, v string) bool {
+	t := p.peek()
+	return t.kind == k && t.val == v
+}
+
+// expect consumes the next token, returning an error if it is not of kind k
+// with value v.
+func (p *xpathParser) expect(k xpathTokenKind, v string) error {
+	if t := p.next(); t.kind != k || t.val != v {
+		return fmt.Errorf("expected %s at position %d, got %q", v, t.pos, t.val)
+	}
+	return nil
+}
+
+// ParseXPath parses the XPath 1.0 expression expr, returning the parsed
+// expression, or an error if the expression is not valid.
+func ParseXPath(expr string) (XPathExpr, error) {
+	l := &xpathLexer{in: expr}
+	if err := l.lex(); err != nil {
+		return nil, fmt.Errorf("cannot parse XPath expression %q: %v", expr, err)
+	}
+	p := &xpathParser{toks: l.toks}
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse XPath expression %q: %v", expr, err)
+	}
+	if t := p.peek(); t.kind != xtEOF {
+		return nil, fmt.Errorf("cannot parse XPath expression %q: unexpected token %q at position %d", expr, t.val, t.pos)
+	}
+	return e, nil
+}
+
+// parseExpr parses an Expr production.
+func (p *xpathParser) parseExpr() (XPathExpr, error) {
+	return p.parseBinary(0)
+}
+
+// xpathPrecedence lists the binary operators from lowest to highest
+// precedence. All operators are left associative.
+var xpathPrecedence = [][]string{
+	{"or"},
+	{"and"},
+	{"=", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "div", "mod"},
+}
+
+// parseBinary parses the binary expressions whose operators are at the
+// supplied level of precedence or higher.
+func (p *xpathParser) parseBinary(level int) (XPathExpr, error) {
+	if level == len(xpathPrecedence) {
+		return p.parseUnary()
+	}
+	lhs, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != xtOperator || !stringInSlice(t.val, xpathPrecedence[level]) {
+			return lhs, nil
+		}
+		p.next()
+		rhs, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		lhs = &XPathBinaryExpr{Op: t.val, LHS: lhs, RHS: rhs}
+	}
+}
+
+// stringInSlice returns true if s is within the slice ss.
+func stringInSlice(s string, ss []string) bool {
+	for _, c := range ss {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUnary parses a UnaryExpr production.
+func (p *xpathParser) parseUnary() (XPathExpr, error) {
+	if p.is(xtOperator, "-") {
+		p.next()
+		e, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &XPathNegateExpr{Expr: e}, nil
+	}
+	return p.parseUnion()
+}
+
+// parseUnion parses a UnionExpr production.
+func (p *xpathParser) parseUnion() (XPathExpr, error) {
+	lhs, err := p.parsePathExpr()
+	if err != nil {
+		return nil, err
+	}
+	for p.is(xtOperator, "|") {
+		p.next()
+		rhs, err := p.parsePathExpr()
+		if err != nil {
+			return nil, err
+		}
+		lhs = &XPathBinaryExpr{Op: "|", LHS: lhs, RHS: rhs}
+	}
+	return lhs, nil
+}
+
+// parsePathExpr parses a PathExpr production, which is either a location
+// path, or a filter expression optionally followed by a relative location
+// path.
+func (p *xpathParser) parsePathExpr() (XPathExpr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == xtLiteral, t.kind == xtNumber, t.kind == xtVariable, t.kind == xtFunctionName, t.kind == xtPunct && t.val == "(":
+	default:
+		return p.parseLocationPath()
+	}
+
+	prim, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	var filter XPathExpr = prim
+	if len(preds) != 0 {
+		filter = &XPathFilterExpr{Primary: prim, Predicates: preds}
+	}
+
+	if !p.is(xtOperator, "/") && !p.is(xtOperator, "//") {
+		return filter, nil
+	}
+	path := &XPathLocationPath{}
+	if p.next().val == "//" {
+		path.Steps = append(path.Steps, descendantOrSelfStep())
+	}
+	if err := p.parseRelativePath(path); err != nil {
+		return nil, err
+	}
+	return &XPathPathExpr{Filter: filter, Path: path}, nil
+}
+
+// descendantOrSelfStep returns the step that the abbreviated // syntax
+// expands to.
+func descendantOrSelfStep() *XPathStep {
+	return &XPathStep{Axis: "descendant-or-self", NodeType: "node"}
+}
+
+// startsStep returns true if the token t can begin a location step.
+func startsStep(t xpathToken) bool {
+	switch t.kind {
+	case xtNameTest, xtNodeType, xtAxisName:
+		return true
+	case xtPunct:
+		return t.val == "." || t.val == ".." || t.val == "@"
+	}
+	return false
+}
+
+// parseLocationPath parses a LocationPath production.
+func (p *xpathParser) parseLocationPath() (XPathExpr, error) {
+	path := &XPathLocationPath{}
+	switch {
+	case p.is(xtOperator, "/"):
+		p.next()
+		path.Absolute = true
+		if !startsStep(p.peek()) {
+			return path, nil
+		}
+	case p.is(xtOperator, "//"):
+		p.next()
+		path.Absolute = true
+		path.Steps = append(path.Steps, descendantOrSelfStep())
+	}
+	if err := p.parseRelativePath(path); err != nil {
+		return nil, err
+	}
+	return path, nil
+}
+
+// parseRelativePath parses a RelativeLocationPath production, appending the
+// steps to the supplied path.
+func (p *xpathParser) parseRelativePath(path *XPathLocationPath) error {
+	for {
+		s, err := p.parseStep()
+		if err != nil {
+			return err
+		}
+		path.Steps = append(path.Steps, s)
+		switch {
+		case p.is(xtOperator, "/"):
+			p.next()
+		case p.is(xtOperator, "//"):
+			p.next()
+			path.Steps = append(path.Steps, descendantOrSelfStep())
+		default:
+			return nil
+		}
+	}
+}
+
+// parseStep parses a Step production.
+func (p *xpathParser) parseStep() (*XPathStep, error) {
+	switch {
+	case p.is(xtPunct, "."):
+		p.next()
+		return &XPathStep{Axis: "self", NodeType: "node"}, nil
+	case p.is(xtPunct, ".."):
+		p.next()
+		return &XPathStep{Axis: "parent", NodeType: "node"}, nil
+	}
+
+	s := &XPathStep{Axis: "child"}
+	switch t := p.peek(); {
+	case t.kind == xtAxisName:
+		p.next()
+		s.Axis = t.val
+		if err := p.expect(xtPunct, "::"); err != nil {
+			return nil, err
+		}
+	case t.kind == xtPunct && t.val == "@":
+		p.next()
+		s.Axis = "attribute"
+	}
+
+	switch t := p.next(); t.kind {
+	case xtNameTest:
+		if i := strings.IndexByte(t.val, ':'); i != -1 {
+			s.Prefix, s.Name = t.val[:i], t.val[i+1:]
+		} else {
+			s.Name = t.val
+		}
+	case xtNodeType:
+		s.NodeType = t.val
+		if err := p.expect(xtPunct, "("); err != nil {
+			return nil, err
+		}
+		// processing-instruction() can take a literal argument, which
+		// has no meaning within YANG data trees and is ignored.
+		if t.val == "processing-instruction" && p.peek().kind == xtLiteral {
+			p.next()
+		}
+		if err := p.expect(xtPunct, ")"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("expected node test at position %d, got %q", t.pos, t.val)
+	}
+
+	preds, err := p.parsePredicates()
+	if err != nil {
+		return nil, err
+	}
+	s.Predicates = preds
+	return s, nil
+}
+
+// parsePredicates parses zero or more Predicate productions.
+func (p *xpathParser) parsePredicates() ([]XPathExpr, error) {
+	var preds []XPathExpr
+	for p.is(xtPunct, "[") {
+		p.next()
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(xtPunct, "]"); err != nil {
+			return nil, err
+		}
+		preds = append(preds, e)
+	}
+	return preds, nil
+}
+
+// parsePrimary parses a PrimaryExpr production.
+func (p *xpathParser) parsePrimary() (XPathExpr, error) {
+	t := p.next()
+	switch t.kind {
+	case xtLiteral:
+		return &XPathLiteral{Value: t.val}, nil
+	case xtNumber:
+		f, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d: %v", t.val, t.pos, err)
+		}
+		return &XPathNumber{Value: f}, nil
+	case xtVariable:
+		return &XPathVariable{Name: t.val}, nil
+	case xtFunctionName:
+		fn := &XPathFunctionCall{Name: t.val}
+		if i := strings.IndexByte(t.val, ':'); i != -1 {
+			fn.Prefix, fn.Name = t.val[:i], t.val[i+1:]
+		}
+		if err := p.expect(xtPunct, "("); err != nil {
+			return nil, err
+		}
+		if p.is(xtPunct, ")") {
+			p.next()
+			return fn, nil
+		}
+		for {
+			a, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			fn.Args = append(fn.Args, a)
+			if p.is(xtPunct, ",") {
+				p.next()
+				continue
+			}
+			if err := p.expect(xtPunct, ")"); err != nil {
+				return nil, err
+			}
+			return fn, nil
+		}
+	case xtPunct:
+		if t.val == "(" {
+			e, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(xtPunct, ")"); err != nil {
+				return nil, err
+			}
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected token %q at position %d", t.val, t.pos)
+}
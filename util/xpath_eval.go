@@ -0,0 +1,1524 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+)
+
+// This file contains an evaluator for XPath 1.0 expressions, as parsed by
+// ParseXPath, over a data tree of GoStructs. The GoStruct is first
+// converted to a tree of XPathNode elements which mirrors the YANG data tree
+// (i.e., without path compression, and with each list entry and leaf-list
+// element represented as a separate node), such that XPath axes can be
+// traversed in either direction.
+
+// XPathNode is a node within the data tree that an XPath expression is
+// evaluated against. A tree of XPathNodes is created from a GoStruct, or an
+// XPathData, using NewXPathTree.
+type XPathNode struct {
+	// name is the name of the node, without any module prefix. The
+	// name of the root node is the empty string.
+	name string
+	// schema is the schema entry that corresponds to the node.
+	schema *yang.Entry
+	// parent is the parent of the node, nil for the root.
+	parent *XPathNode
+	// children are the children of the node, in document order.
+	children []*XPathNode
+	// value is the Go value of the node where it is a leaf, or a leaf-list
+	// element.
+	value interface{}
+	// data is the Go value that the node was created from where it is a
+	// container or list entry, or the XPathData that it was created from.
+	data interface{}
+	// field is the struct field that contains the data of the node. It
+	// is not valid for nodes that do not directly correspond to a field,
+	// such as containers that are removed by path compression.
+	field reflect.Value
+	// fieldName is the name of the struct field that contains the data of
+	// the node, or the empty string where there is no such field. For nodes
+	// created from XPathData, it is the name of the schema node.
+	fieldName string
+	// key is the map key of the node where it is an entry of a keyed list.
+	key reflect.Value
+	// order is the position of the node in document order.
+	order int
+	// outside is set to true for nodes that are created as the ancestors
+	// of the data tree that is being evaluated, and hence do not
+	// themselves contain any data.
+	outside bool
+}
+
+// Name returns the name of the node n, without any module prefix.
+func (n *XPathNode) Name() string { return n.name }
+
+// Parent returns the parent of the node n, or nil if n is the root of the
+// tree.
+func (n *XPathNode) Parent() *XPathNode { return n.parent }
+
+// Children returns the children of the node n, in document order.
+func (n *XPathNode) Children() []*XPathNode { return n.children }
+
+// Schema returns the schema entry that describes the node n.
+func (n *XPathNode) Schema() *yang.Entry { return n.schema }
+
+// Value returns the Go value of n where it is a leaf or a leaf-list element,
+// and nil otherwise.
+func (n *XPathNode) Value() interface{} { return n.value }
+
+// FieldName returns the name of the struct field that contains the data of
+// n, or the empty string if the node does not directly correspond to a field.
+// For nodes created from XPathData, the name of the schema node is returned.
+func (n *XPathNode) FieldName() string { return n.fieldName }
+
+// Field returns the struct field that contains the data of n. The returned
+// value is not valid if the node does not directly correspond to a field.
+func (n *XPathNode) Field() reflect.Value { return n.field }
+
+// Outside returns true if the node n is an ancestor of the value that the
+// tree was created from, and hence does not contain any data.
+func (n *XPathNode) Outside() bool { return n.outside }
+
+// Walk calls fn for n and each of its descendants in document order. If fn
+// returns false, the descendants of the node it was called for are not
+// visited.
+func (n *XPathNode) Walk(fn func(*XPathNode) bool) {
+	if !fn(n) {
+		return
+	}
+	for _, c := range n.children {
+		c.Walk(fn)
+	}
+}
+
+// String returns the data tree path of the node n.
+func (n *XPathNode) String() string {
+	var p []string
+	for ; n.parent != nil; n = n.parent {
+		p = append([]string{n.name}, p...)
+	}
+	return "/" + strings.Join(p, "/")
+}
+
+// StringValue returns the XPath string-value of the node n.
+func (n *XPathNode) StringValue() string { return xpathStringValue(n) }
+
+// Remove removes the data that corresponds to the node n from the GoStruct
+// that the tree was created from. The node remains within the tree.
+func (n *XPathNode) Remove() error {
+	switch {
+	case n.outside:
+		return fmt.Errorf("cannot remove node %s, which is outside of the data tree", n)
+	case n.isXPathData():
+		return fmt.Errorf("cannot remove node %s, which is not within a GoStruct", n)
+	case !n.field.IsValid():
+		for _, c := range n.children {
+			if err := c.Remove(); err != nil {
+				return err
+			}
+		}
+	case n.key.IsValid() && IsValueOrderedMap(n.field):
+		OrderedMapDelete(n.field, n.key)
+	case n.key.IsValid():
+		n.field.SetMapIndex(n.key, reflect.Value{})
+	case n.field.Kind() == reflect.Slice && !n.schema.IsLeaf():
+		elem := n.value
+		if n.data != nil {
+			elem = n.data
+		}
+		out := reflect.MakeSlice(n.field.Type(), 0, n.field.Len())
+		removed := false
+		for i := 0; i < n.field.Len(); i++ {
+			if e := n.field.Index(i); !removed && reflect.DeepEqual(e.Interface(), elem) && (!IsValuePtr(e) || e.Interface() == elem) {
+				removed = true
+				continue
+			}
+			out = reflect.Append(out, n.field.Index(i))
+		}
+		if out.Len() == 0 {
+			out = reflect.Zero(n.field.Type())
+		}
+		n.field.Set(out)
+	default:
+		n.field.Set(reflect.Zero(n.field.Type()))
+	}
+	return nil
+}
+
+// isXPathData returns true if the node was created from an XPathData.
+func (n *XPathNode) isXPathData() bool {
+	_, ok := n.data.(XPathData)
+	return ok
+}
+
+// isLeaf returns true if the node is a leaf or a leaf-list element.
+func (n *XPathNode) isLeaf() bool {
+	return n.schema != nil && (n.schema.IsLeaf() || n.schema.IsLeafList())
+}
+
+// childDir returns the child of n with the supplied name which is a
+// container. If no such child exists, it is created with the supplied
+// schema.
+func (n *XPathNode) childDir(name string, schema *yang.Entry) *XPathNode {
+	for _, c := range n.children {
+		if c.name == name && !c.isLeaf() && (c.schema == nil || !c.schema.IsList()) {
+			return c
+		}
+	}
+	return n.addChild(&XPathNode{name: name, schema: schema})
+}
+
+// addChild appends c to the children of n.
+func (n *XPathNode) addChild(c *XPathNode) *XPathNode {
+	c.parent = n
+	n.children = append(n.children, c)
+	return c
+}
+
+// walk calls fn for n and each of its descendants in document order.
+func (n *XPathNode) walk(fn func(*XPathNode)) {
+	n.Walk(func(d *XPathNode) bool {
+		fn(d)
+		return true
+	})
+}
+
+// root returns the root of the tree that n is within.
+func (n *XPathNode) root() *XPathNode {
+	for n.parent != nil {
+		n = n.parent
+	}
+	return n
+}
+
+// NewXPathTree builds the data tree that corresponds to value, which is
+// described by schema, such that XPath expressions can be evaluated against
+// it. The value is a GoStruct, or a data node that implements XPathData. If
+// schema is not the root of its schema tree, the ancestors of the value are
+// created such that absolute paths can be evaluated, these nodes are marked
+// as being outside of the data tree. It returns the node that
+// corresponds to value, which is nil if value does not contain any data.
+func NewXPathTree(schema *yang.Entry, value interface{}) (*XPathNode, error) {
+	var ancestors []*yang.Entry
+	for s := schema.Parent; s != nil; s = s.Parent {
+		if !IsChoiceOrCase(s) {
+			ancestors = append([]*yang.Entry{s}, ancestors...)
+		}
+	}
+
+	d, isData := value.(XPathData)
+	v := reflect.ValueOf(value)
+	if len(ancestors) == 0 {
+		if isData {
+			root := newXPathDataTree(nil, schema, d)
+			setXPathOrder(root)
+			return root, nil
+		}
+		if !IsValueStructPtr(v) {
+			return nil, fmt.Errorf("root value of type %T is not a struct pointer", value)
+		}
+		root := &XPathNode{schema: schema, data: value}
+		if err := addXPathStruct(root, schema, v); err != nil {
+			return nil, err
+		}
+		setXPathOrder(root)
+		return root, nil
+	}
+
+	root := &XPathNode{schema: ancestors[0], outside: true}
+	parent := root
+	for _, a := range ancestors[1:] {
+		parent = parent.addChild(&XPathNode{name: a.Name, schema: a, outside: true})
+	}
+	if isData {
+		dn := newXPathDataTree(parent, schema, d)
+		setXPathOrder(root)
+		return dn, nil
+	}
+	n := len(parent.children)
+	if IsValuePtr(v) && (schema.IsLeafList() || schema.IsList()) && v.Elem().Kind() == reflect.Slice {
+		v = v.Elem()
+	}
+	if err := addXPathField(parent, schema.Name, schema, reflect.Value{}, v); err != nil {
+		return nil, err
+	}
+	setXPathOrder(root)
+	if len(parent.children) == n {
+		return nil, nil
+	}
+	return parent.children[n], nil
+}
+
+// XPathData is implemented by data trees that are not made up of GoStructs,
+// such that a tree of XPathNodes can be created from them by NewXPathTree.
+// Each XPathData is a single data node, with each list entry and leaf-list
+// element being a separate node.
+type XPathData interface {
+	// XPathSchema returns the schema entry that describes the data node.
+	XPathSchema() *yang.Entry
+	// XPathValue returns the Go value of the data node where it is a leaf
+	// or a leaf-list element.
+	XPathValue() interface{}
+	// XPathChildren returns the children of the data node, in document
+	// order.
+	XPathChildren() []XPathData
+}
+
+// newXPathDataTree builds the data tree that corresponds to d, which is
+// described by schema, below the node parent, which is nil if d is the root
+// of the data tree. It returns the node that corresponds to d.
+func newXPathDataTree(parent *XPathNode, schema *yang.Entry, d XPathData) *XPathNode {
+	n := &XPathNode{schema: schema, data: d, fieldName: schema.Name}
+	if parent != nil {
+		n.name = schema.Name
+		parent.addChild(n)
+	}
+	if schema.IsLeaf() || schema.IsLeafList() {
+		n.value = d.XPathValue()
+	}
+	for _, c := range d.XPathChildren() {
+		newXPathDataTree(n, c.XPathSchema(), c)
+	}
+	return n
+}
+
+// setXPathOrder assigns the document order of each node in the tree rooted
+// at root.
+func setXPathOrder(root *XPathNode) {
+	i := 0
+	root.walk(func(n *XPathNode) {
+		n.order = i
+		i++
+	})
+}
+
+// xpathChildSchema returns the child of the schema entry parent with the
+// supplied name, descending through any choice and case statements.
+func xpathChildSchema(parent *yang.Entry, name string) *yang.Entry {
+	if c, ok := parent.Dir[name]; ok && !IsChoiceOrCase(c) {
+		return c
+	}
+	for _, c := range parent.Dir {
+		if IsChoiceOrCase(c) {
+			if e := xpathChildSchema(c, name); e != nil {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// addXPathStruct adds the fields of the GoStruct v, described by schema, as
+// children of the node n.
+func addXPathStruct(n *XPathNode, schema *yang.Entry, v reflect.Value) error {
+	sv := v.Elem()
+	st := sv.Type()
+	for i := 0; i < sv.NumField(); i++ {
+		f, fv := st.Field(i), sv.Field(i)
+		// Nil fields are skipped before their schema is found, since
+		// they do not contain data regardless of their schema.
+		if IsYgotAnnotation(f) || isUnsetXPathValue(nil, fv) {
+			continue
+		}
+		paths, err := SchemaPaths(f)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			if len(p) > 0 && p[0] == "" {
+				p = p[1:]
+			}
+			if len(p) > 1 && p[0] == schema.Name && schema.Dir[p[0]] == nil {
+				p = p[1:]
+			}
+			// The schema of each element of the path is found before any
+			// nodes are added, such that no containers are created for
+			// fields that are unset.
+			schemas := make([]*yang.Entry, len(p))
+			ps := schema
+			for i, name := range p {
+				if ps = xpathChildSchema(ps, name); ps == nil {
+					return fmt.Errorf("cannot find schema for %s in path %v of field %s", name, p, f.Name)
+				}
+				schemas[i] = ps
+			}
+			name, cs := p[len(p)-1], schemas[len(p)-1]
+			if isUnsetXPathValue(cs, fv) {
+				continue
+			}
+			parent := n
+			for i, name := range p[:len(p)-1] {
+				parent = parent.childDir(name, schemas[i])
+			}
+			before := len(parent.children)
+			if err := addXPathField(parent, name, cs, fv, fv); err != nil {
+				return err
+			}
+			for _, c := range parent.children[before:] {
+				c.fieldName = f.Name
+			}
+		}
+	}
+	return nil
+}
+
+// isUnsetXPathValue returns true if the field value v, which is described by
+// schema, does not contain any data, and hence should not be represented in
+// the data tree. If schema is nil, only nil values are reported as unset.
+func isUnsetXPathValue(schema *yang.Entry, v reflect.Value) bool {
+	switch {
+	case IsValuePtr(v), IsValueInterface(v), IsValueMap(v), IsValueSlice(v):
+		return v.IsNil()
+	case v.Kind() == reflect.Int64:
+		// Enumerated values, which are not stored as pointers, use 0 to
+		// indicate that they are unset. Other int64 values are valid data
+		// when 0.
+		return v.Int() == 0 && schema != nil && schema.IsLeaf() && isEnumeratedXPathType(schema.Type)
+	case v.Kind() == reflect.Bool:
+		// Empty leaves are represented by a bool type, which is false when
+		// the leaf is not present.
+		return !v.Bool() && schema != nil && schema.IsLeaf() && schema.Type != nil && schema.Type.Kind == yang.Yempty
+	}
+	return !v.IsValid()
+}
+
+// isEnumeratedXPathType returns true if values of the type t are stored as
+// generated enumerated types, that is, t is an enumeration, an identityref
+// or a union of such types.
+func isEnumeratedXPathType(t *yang.YangType) bool {
+	if t == nil || t.Kind != yang.Yunion {
+		return IsEnumeratedType(t)
+	}
+	for _, u := range t.Type {
+		if !isEnumeratedXPathType(u) {
+			return false
+		}
+	}
+	return len(t.Type) > 0
+}
+
+// addXPathField adds the value v of the data node with the supplied name and
+// schema as a child of n. The field is the struct field that v is stored
+// within, which is invalid if v is not stored within a struct.
+func addXPathField(n *XPathNode, name string, schema *yang.Entry, field, v reflect.Value) error {
+	if isUnsetXPathValue(schema, v) {
+		return nil
+	}
+	switch {
+	case schema.IsLeaf():
+		n.addChild(&XPathNode{name: name, schema: schema, value: v.Interface(), field: field})
+	case schema.IsLeafList():
+		if v.Kind() != reflect.Slice {
+			return fmt.Errorf("value of type %T for leaf-list %s is not a slice", v.Interface(), name)
+		}
+		// Each element of a leaf-list is data, including zero values.
+		for i := 0; i < v.Len(); i++ {
+			n.addChild(&XPathNode{name: name, schema: schema, value: v.Index(i).Interface(), field: field})
+		}
+	case schema.IsList():
+		type entry struct{ key, value reflect.Value }
+		var entries []entry
+		switch {
+		case IsValueOrderedMap(v):
+			// Ordered lists are evaluated in the order of their members.
+			keys := OrderedMapKeys(v)
+			for i, val := range OrderedMapValues(v) {
+				entries = append(entries, entry{key: keys[i], value: val})
+			}
+		case v.Kind() == reflect.Ptr:
+			// A single list entry is supplied when a list member is
+			// validated directly.
+			entries = append(entries, entry{value: v})
+		case v.Kind() == reflect.Map:
+			keys := v.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+			})
+			for _, k := range keys {
+				entries = append(entries, entry{key: k, value: v.MapIndex(k)})
+			}
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				entries = append(entries, entry{value: v.Index(i)})
+			}
+		default:
+			return fmt.Errorf("value of type %T for list %s is not a map or slice", v.Interface(), name)
+		}
+		for _, e := range entries {
+			if !IsValueStructPtr(e.value) || e.value.IsNil() {
+				continue
+			}
+			c := &XPathNode{name: name, schema: schema, data: e.value.Interface()}
+			if v.Kind() != reflect.Ptr || IsValueOrderedMap(v) {
+				c.field, c.key = field, e.key
+			}
+			n.addChild(c)
+			if err := addXPathStruct(c, schema, e.value); err != nil {
+				return err
+			}
+		}
+	default:
+		if !IsValueStructPtr(v) {
+			return fmt.Errorf("value of type %T for container %s is not a struct pointer", v.Interface(), name)
+		}
+		c := n.childDir(name, schema)
+		c.data, c.field = v.Interface(), field
+		return addXPathStruct(c, schema, v)
+	}
+	return nil
+}
+
+// xpathLeafString returns the string value of the leaf value v, as per the
+// canonical representation of the value in YANG.
+func xpathLeafString(schema *yang.Entry, v interface{}) (string, error) {
+	if schema != nil && schema.Type != nil && schema.Type.Kind == yang.Yempty {
+		return "", nil
+	}
+	rv := reflect.ValueOf(v)
+	switch {
+	case IsNilOrInvalidValue(rv):
+		return "", nil
+	case rv.Type().Name() == "Binary":
+		return base64.StdEncoding.EncodeToString(rv.Bytes()), nil
+	case IsValueStructPtr(rv):
+		// Union values are wrapped in a struct with a single field.
+		if rv.Elem().NumField() != 1 {
+			return "", fmt.Errorf("cannot resolve union value of type %T", v)
+		}
+		return xpathLeafString(nil, rv.Elem().Field(0).Interface())
+	case rv.Kind() == reflect.Ptr:
+		return xpathLeafString(schema, rv.Elem().Interface())
+	}
+	if name, ok, err := enumName(rv); ok {
+		return name, err
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+// enumName returns the YANG name of the enumerated value v, and true if v is
+// a generated enumerated type. Since this package cannot depend on the ygot
+// package, the ΛMap method of the enumerated type is called by reflection
+// rather than via the ygot.GoEnum interface.
+func enumName(v reflect.Value) (string, bool, error) {
+	m := v.MethodByName("ΛMap")
+	if v.Kind() != reflect.Int64 || !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+		return "", false, nil
+	}
+	defs := m.Call(nil)[0]
+	if defs.Kind() != reflect.Map {
+		return "", true, fmt.Errorf("invalid enum map for type %s", v.Type())
+	}
+	vals := defs.MapIndex(reflect.ValueOf(v.Type().Name()))
+	if !vals.IsValid() {
+		return "", true, fmt.Errorf("cannot map enumerated value of type %s", v.Type())
+	}
+	def := vals.MapIndex(reflect.ValueOf(v.Int()))
+	if !def.IsValid() || def.Kind() != reflect.Struct || !def.FieldByName("Name").IsValid() {
+		return "", true, fmt.Errorf("cannot map enumerated value %d of type %s", v.Int(), v.Type())
+	}
+	return def.FieldByName("Name").String(), true, nil
+}
+
+// xpathEvaluator evaluates XPath expressions against a tree of xpathNodes.
+type xpathEvaluator struct {
+	// current is the node returned by the current() function.
+	current *XPathNode
+	// outside is set to true when a location path selects a node that is
+	// outside of the data tree, and hence the result of the evaluation
+	// does not reflect the data of the entire tree.
+	outside bool
+}
+
+// xpathContext is the context within which an XPath expression is
+// evaluated.
+type xpathContext struct {
+	node *XPathNode
+	pos  int
+	size int
+}
+
+// EvalXPath evaluates the expression e with the context node and current
+// node set to n. The result is one of a node-set ([]*XPathNode), a string,
+// a float64 or a bool.
+func EvalXPath(e XPathExpr, n *XPathNode) (interface{}, error) {
+	ev := &xpathEvaluator{current: n}
+	return ev.eval(e, xpathContext{node: n, pos: 1, size: 1})
+}
+
+// eval evaluates the expression e within the context ctx.
+func (ev *xpathEvaluator) eval(e XPathExpr, ctx xpathContext) (interface{}, error) {
+	switch e := e.(type) {
+	case *XPathLiteral:
+		return e.Value, nil
+	case *XPathNumber:
+		return e.Value, nil
+	case *XPathVariable:
+		return nil, fmt.Errorf("variable $%s is not defined", e.Name)
+	case *XPathNegateExpr:
+		v, err := ev.eval(e.Expr, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return -xpathNumber(v), nil
+	case *XPathBinaryExpr:
+		return ev.evalBinary(e, ctx)
+	case *XPathFunctionCall:
+		return ev.evalFunction(e, ctx)
+	case *XPathFilterExpr:
+		v, err := ev.eval(e.Primary, ctx)
+		if err != nil {
+			return nil, err
+		}
+		ns, ok := v.([]*XPathNode)
+		if !ok {
+			return nil, fmt.Errorf("predicate applied to non node-set value in %s", e)
+		}
+		return ev.filter(ns, e.Predicates)
+	case *XPathPathExpr:
+		v, err := ev.eval(e.Filter, ctx)
+		if err != nil {
+			return nil, err
+		}
+		ns, ok := v.([]*XPathNode)
+		if !ok {
+			return nil, fmt.Errorf("path applied to non node-set value in %s", e)
+		}
+		return ev.evalSteps(ns, e.Path.Steps)
+	case *XPathLocationPath:
+		start := []*XPathNode{ctx.node}
+		if e.Absolute {
+			start = []*XPathNode{ctx.node.root()}
+		}
+		return ev.evalSteps(start, e.Steps)
+	}
+	return nil, fmt.Errorf("unsupported XPath expression %v (%T)", e, e)
+}
+
+// evalBinary evaluates the binary expression e within the context ctx.
+func (ev *xpathEvaluator) evalBinary(e *XPathBinaryExpr, ctx xpathContext) (interface{}, error) {
+	l, err := ev.eval(e.LHS, ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Op {
+	case "or", "and":
+		lb := xpathBool(l)
+		if (e.Op == "or" && lb) || (e.Op == "and" && !lb) {
+			return lb, nil
+		}
+		r, err := ev.eval(e.RHS, ctx)
+		if err != nil {
+			return nil, err
+		}
+		return xpathBool(r), nil
+	}
+
+	r, err := ev.eval(e.RHS, ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch e.Op {
+	case "|":
+		lns, lok := l.([]*XPathNode)
+		rns, rok := r.([]*XPathNode)
+		if !lok || !rok {
+			return nil, fmt.Errorf("union of non node-set values in %s", e)
+		}
+		return mergeNodeSets(lns, rns), nil
+	case "=", "!=", "<", "<=", ">", ">=":
+		return compareXPath(e.Op, l, r), nil
+	}
+
+	a, b := xpathNumber(l), xpathNumber(r)
+	switch e.Op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "div":
+		return a / b, nil
+	case "mod":
+		return math.Mod(a, b), nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", e.Op)
+}
+
+// evalSteps evaluates the location steps against each of the nodes in
+// start, returning the resulting node-set.
+func (ev *xpathEvaluator) evalSteps(start []*XPathNode, steps []*XPathStep) ([]*XPathNode, error) {
+	ns := start
+	ev.checkOutside(ns)
+	for _, s := range steps {
+		var out []*XPathNode
+		for _, n := range ns {
+			r, err := ev.evalStep(n, s)
+			if err != nil {
+				return nil, err
+			}
+			out = mergeNodeSets(out, r)
+		}
+		ns = out
+		ev.checkOutside(ns)
+	}
+	return ns, nil
+}
+
+// checkOutside records whether any of the nodes in ns is outside of the
+// data tree.
+func (ev *xpathEvaluator) checkOutside(ns []*XPathNode) {
+	for _, n := range ns {
+		if n.outside {
+			ev.outside = true
+			return
+		}
+	}
+}
+
+// evalStep evaluates the step s with the context node n.
+func (ev *xpathEvaluator) evalStep(n *XPathNode, s *XPathStep) ([]*XPathNode, error) {
+	var ns []*XPathNode
+	for _, c := range xpathAxis(n, s.Axis) {
+		if xpathNodeTest(c, s) {
+			ns = append(ns, c)
+		}
+	}
+	return ev.filter(ns, s.Predicates)
+}
+
+// filter returns the nodes of ns for which each of the predicates in preds
+// is true, evaluated in turn. The position of each node is given by its
+// index within ns.
+func (ev *xpathEvaluator) filter(ns []*XPathNode, preds []XPathExpr) ([]*XPathNode, error) {
+	for _, p := range preds {
+		var out []*XPathNode
+		for i, n := range ns {
+			v, err := ev.eval(p, xpathContext{node: n, pos: i + 1, size: len(ns)})
+			if err != nil {
+				return nil, err
+			}
+			if f, ok := v.(float64); ok {
+				if f == float64(i+1) {
+					out = append(out, n)
+				}
+				continue
+			}
+			if xpathBool(v) {
+				out = append(out, n)
+			}
+		}
+		ns = out
+	}
+	return ns, nil
+}
+
+// xpathAxis returns the nodes on the named axis of n, in the order of the
+// axis; reverse axes return nodes in reverse document order.
+func xpathAxis(n *XPathNode, axis string) []*XPathNode {
+	var out []*XPathNode
+	switch axis {
+	case "child":
+		out = append(out, n.children...)
+	case "self":
+		out = append(out, n)
+	case "parent":
+		if n.parent != nil {
+			out = append(out, n.parent)
+		}
+	case "descendant", "descendant-or-self":
+		n.walk(func(d *XPathNode) {
+			if d != n || axis == "descendant-or-self" {
+				out = append(out, d)
+			}
+		})
+	case "ancestor", "ancestor-or-self":
+		a := n.parent
+		if axis == "ancestor-or-self" {
+			a = n
+		}
+		for ; a != nil; a = a.parent {
+			out = append(out, a)
+		}
+	case "following-sibling", "preceding-sibling":
+		if n.parent == nil {
+			return nil
+		}
+		sib := n.parent.children
+		for i, s := range sib {
+			if s != n {
+				continue
+			}
+			if axis == "following-sibling" {
+				out = append(out, sib[i+1:]...)
+			} else {
+				for j := i - 1; j >= 0; j-- {
+					out = append(out, sib[j])
+				}
+			}
+		}
+	case "following", "preceding":
+		// following contains all nodes after n in document order that
+		// are not its descendants; preceding contains all nodes before n
+		// that are not its ancestors.
+		anc := map[*XPathNode]bool{}
+		for a := n.parent; a != nil; a = a.parent {
+			anc[a] = true
+		}
+		var last int
+		n.walk(func(d *XPathNode) { last = d.order })
+		n.root().walk(func(d *XPathNode) {
+			switch {
+			case axis == "following" && d.order > last:
+				out = append(out, d)
+			case axis == "preceding" && d.order < n.order && !anc[d]:
+				out = append([]*XPathNode{d}, out...)
+			}
+		})
+	}
+	// The attribute and namespace axes are always empty since YANG data
+	// trees do not contain such nodes.
+	return out
+}
+
+// xpathNodeTest returns true if the node n matches the node test of the
+// step s.
+func xpathNodeTest(n *XPathNode, s *XPathStep) bool {
+	switch {
+	case s.NodeType == "node":
+		return true
+	case s.NodeType != "":
+		return false
+	case n.parent == nil:
+		// The root node has no name.
+		return false
+	case s.Name == "*":
+		return true
+	}
+	return n.name == s.Name
+}
+
+// mergeNodeSets returns the union of the node-sets a and b, in document
+// order.
+func mergeNodeSets(a, b []*XPathNode) []*XPathNode {
+	if len(a) == 0 {
+		return sortNodeSet(b)
+	}
+	seen := map[*XPathNode]bool{}
+	var out []*XPathNode
+	for _, ns := range [][]*XPathNode{a, b} {
+		for _, n := range ns {
+			if !seen[n] {
+				seen[n] = true
+				out = append(out, n)
+			}
+		}
+	}
+	return sortNodeSet(out)
+}
+
+// sortNodeSet sorts the node-set ns into document order.
+func sortNodeSet(ns []*XPathNode) []*XPathNode {
+	sort.SliceStable(ns, func(i, j int) bool { return ns[i].order < ns[j].order })
+	return ns
+}
+
+// xpathStringValue returns the string-value of the node n.
+func xpathStringValue(n *XPathNode) string {
+	if n.isLeaf() {
+		s, err := xpathLeafString(n.schema, n.value)
+		if err != nil {
+			return ""
+		}
+		return s
+	}
+	var b strings.Builder
+	for _, c := range n.children {
+		b.WriteString(xpathStringValue(c))
+	}
+	return b.String()
+}
+
+// isIdentityrefNode returns true if the node n is an identityref leaf.
+func isIdentityrefNode(n *XPathNode) bool {
+	return n.isLeaf() && n.schema.Type != nil && n.schema.Type.Kind == yang.Yidentityref
+}
+
+// xpathString converts the XPath value v to a string.
+func xpathString(v interface{}) string {
+	switch v := v.(type) {
+	case []*XPathNode:
+		if len(v) == 0 {
+			return ""
+		}
+		return xpathStringValue(v[0])
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NaN"
+		case math.IsInf(v, 1):
+			return "Infinity"
+		case math.IsInf(v, -1):
+			return "-Infinity"
+		case v == 0:
+			return "0"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// xpathNumberRE matches the strings that can be converted to XPath numbers.
+var xpathNumberRE = regexp.MustCompile(`^-?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+// xpathNumber converts the XPath value v to a number.
+func xpathNumber(v interface{}) float64 {
+	switch v := v.(type) {
+	case float64:
+		return v
+	case bool:
+		if v {
+			return 1
+		}
+		return 0
+	case []*XPathNode:
+		return xpathNumber(xpathString(v))
+	case string:
+		s := strings.TrimSpace(v)
+		if !xpathNumberRE.MatchString(s) {
+			return math.NaN()
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return math.NaN()
+		}
+		return f
+	}
+	return math.NaN()
+}
+
+// xpathBool converts the XPath value v to a boolean.
+func xpathBool(v interface{}) bool {
+	switch v := v.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0 && !math.IsNaN(v)
+	case string:
+		return len(v) != 0
+	case []*XPathNode:
+		return len(v) != 0
+	}
+	return false
+}
+
+// compareXPath compares the XPath values l and r using the operator op,
+// according to the rules of XPath 1.0 section 3.4. Comparisons involving
+// node-sets are true if the comparison is true for any node in the set.
+func compareXPath(op string, l, r interface{}) bool {
+	lns, lok := l.([]*XPathNode)
+	rns, rok := r.([]*XPathNode)
+	switch {
+	case lok && rok:
+		for _, a := range lns {
+			for _, b := range rns {
+				as, bs := xpathStringValue(a), xpathStringValue(b)
+				if isIdentityrefNode(a) || isIdentityrefNode(b) {
+					as, bs = StripModulePrefix(as), StripModulePrefix(bs)
+				}
+				if compareXPathAtoms(op, as, bs) {
+					return true
+				}
+			}
+		}
+		return false
+	case lok:
+		return compareNodeSetAtom(op, lns, r, false)
+	case rok:
+		return compareNodeSetAtom(op, rns, l, true)
+	}
+	return compareXPathAtoms(op, l, r)
+}
+
+// compareNodeSetAtom compares each node in ns with the non node-set value v
+// using op. If swap is true, v is the left hand operand of the comparison.
+func compareNodeSetAtom(op string, ns []*XPathNode, v interface{}, swap bool) bool {
+	if b, ok := v.(bool); ok {
+		if swap {
+			return compareXPathAtoms(op, b, xpathBool(ns))
+		}
+		return compareXPathAtoms(op, xpathBool(ns), b)
+	}
+	for _, n := range ns {
+		var a, b interface{} = xpathStringValue(n), v
+		switch v := v.(type) {
+		case float64:
+			a = xpathNumber(a)
+		case string:
+			// Identityref values may be compared to literals that
+			// include a module prefix, which is not retained within
+			// the GoStruct.
+			if isIdentityrefNode(n) {
+				a, b = StripModulePrefix(a.(string)), StripModulePrefix(v)
+			}
+		}
+		l, r := a, b
+		if swap {
+			l, r = b, a
+		}
+		if compareXPathAtoms(op, l, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareXPathAtoms compares the non node-set XPath values l and r using
+// the operator op.
+func compareXPathAtoms(op string, l, r interface{}) bool {
+	switch op {
+	case "=", "!=":
+		var eq bool
+		_, lb := l.(bool)
+		_, rb := r.(bool)
+		_, lf := l.(float64)
+		_, rf := r.(float64)
+		switch {
+		case lb || rb:
+			eq = xpathBool(l) == xpathBool(r)
+		case lf || rf:
+			eq = xpathNumber(l) == xpathNumber(r)
+		default:
+			eq = xpathString(l) == xpathString(r)
+		}
+		return eq == (op == "=")
+	}
+	a, b := xpathNumber(l), xpathNumber(r)
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+// xpathRound implements the XPath round() function.
+func xpathRound(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+// evalFunction evaluates the function call fn within the context ctx.
+func (ev *xpathEvaluator) evalFunction(fn *XPathFunctionCall, ctx xpathContext) (interface{}, error) {
+	var args []interface{}
+	for _, a := range fn.Args {
+		v, err := ev.eval(a, ctx)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+
+	// nargs checks that the number of arguments supplied is between min
+	// and max, inclusive.
+	nargs := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("invalid number of arguments to %s, got: %d", fn.Name, len(args))
+		}
+		return nil
+	}
+	// nodeSetArg returns the i'th argument as a node-set, or the
+	// context node if the argument is not specified.
+	nodeSetArg := func(i int) ([]*XPathNode, error) {
+		if i >= len(args) {
+			return []*XPathNode{ctx.node}, nil
+		}
+		ns, ok := args[i].([]*XPathNode)
+		if !ok {
+			return nil, fmt.Errorf("argument %d to %s is not a node-set", i+1, fn.Name)
+		}
+		return ns, nil
+	}
+	// stringArg returns the i'th argument as a string, or the string-value
+	// of the context node if the argument is not specified.
+	stringArg := func(i int) string {
+		if i >= len(args) {
+			return xpathStringValue(ctx.node)
+		}
+		return xpathString(args[i])
+	}
+
+	switch fn.Name {
+	case "last":
+		return float64(ctx.size), nargs(0, 0)
+	case "position":
+		return float64(ctx.pos), nargs(0, 0)
+	case "current":
+		return []*XPathNode{ev.current}, nargs(0, 0)
+	case "count":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		return float64(len(ns)), err
+	case "local-name", "name":
+		if err := nargs(0, 1); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		if err != nil || len(ns) == 0 {
+			return "", err
+		}
+		return ns[0].name, nil
+	case "namespace-uri":
+		return "", nargs(0, 1)
+	case "string":
+		return stringArg(0), nargs(0, 1)
+	case "concat":
+		if err := nargs(2, math.MaxInt32); err != nil {
+			return nil, err
+		}
+		var b strings.Builder
+		for i := range args {
+			b.WriteString(stringArg(i))
+		}
+		return b.String(), nil
+	case "starts-with":
+		return strings.HasPrefix(stringArg(0), stringArg(1)), nargs(2, 2)
+	case "contains":
+		return strings.Contains(stringArg(0), stringArg(1)), nargs(2, 2)
+	case "substring-before":
+		s, sep := stringArg(0), stringArg(1)
+		if i := strings.Index(s, sep); i != -1 {
+			return s[:i], nargs(2, 2)
+		}
+		return "", nargs(2, 2)
+	case "substring-after":
+		s, sep := stringArg(0), stringArg(1)
+		if i := strings.Index(s, sep); i != -1 {
+			return s[i+len(sep):], nargs(2, 2)
+		}
+		return "", nargs(2, 2)
+	case "substring":
+		if err := nargs(2, 3); err != nil {
+			return nil, err
+		}
+		rs := []rune(stringArg(0))
+		start := xpathRound(xpathNumber(args[1]))
+		end := math.Inf(1)
+		if len(args) == 3 {
+			end = start + xpathRound(xpathNumber(args[2]))
+		}
+		var b strings.Builder
+		for i, r := range rs {
+			if p := float64(i + 1); p >= start && p < end {
+				b.WriteRune(r)
+			}
+		}
+		return b.String(), nil
+	case "string-length":
+		return float64(len([]rune(stringArg(0)))), nargs(0, 1)
+	case "normalize-space":
+		return strings.Join(strings.Fields(stringArg(0)), " "), nargs(0, 1)
+	case "translate":
+		if err := nargs(3, 3); err != nil {
+			return nil, err
+		}
+		from, to := []rune(stringArg(1)), []rune(stringArg(2))
+		return strings.Map(func(r rune) rune {
+			for i, f := range from {
+				if f != r {
+					continue
+				}
+				if i < len(to) {
+					return to[i]
+				}
+				return -1
+			}
+			return r
+		}, stringArg(0)), nil
+	case "boolean":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		return xpathBool(args[0]), nil
+	case "not":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		return !xpathBool(args[0]), nil
+	case "true":
+		return true, nargs(0, 0)
+	case "false":
+		return false, nargs(0, 0)
+	case "lang":
+		// The lang function always returns false since YANG data trees do
+		// not include xml:lang attributes.
+		return false, nargs(1, 1)
+	case "number":
+		if err := nargs(0, 1); err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return xpathNumber(xpathStringValue(ctx.node)), nil
+		}
+		return xpathNumber(args[0]), nil
+	case "sum":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		var sum float64
+		for _, n := range ns {
+			sum += xpathNumber(xpathStringValue(n))
+		}
+		return sum, err
+	case "floor", "ceiling", "round":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		f := xpathNumber(args[0])
+		switch fn.Name {
+		case "floor":
+			return math.Floor(f), nil
+		case "ceiling":
+			return math.Ceil(f), nil
+		}
+		return xpathRound(f), nil
+	case "re-match":
+		if err := nargs(2, 2); err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(FixYangRegexp(stringArg(1)))
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in re-match: %v", err)
+		}
+		return re.MatchString(stringArg(0)), nil
+	case "deref":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		if err != nil || len(ns) == 0 {
+			return []*XPathNode{}, err
+		}
+		return ev.deref(ns[0])
+	case "derived-from", "derived-from-or-self":
+		if err := nargs(2, 2); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		if err != nil {
+			return nil, err
+		}
+		base := StripModulePrefix(stringArg(1))
+		for _, n := range ns {
+			if isIdentityrefNode(n) && derivedFrom(n.schema.Type.IdentityBase, StripModulePrefix(xpathStringValue(n)), base, fn.Name == "derived-from-or-self") {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "enum-value":
+		if err := nargs(1, 1); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		if err != nil || len(ns) == 0 {
+			return math.NaN(), err
+		}
+		n := ns[0]
+		if !n.isLeaf() || n.schema.Type == nil || n.schema.Type.Enum == nil {
+			return math.NaN(), nil
+		}
+		name := xpathStringValue(n)
+		if !n.schema.Type.Enum.IsDefined(name) {
+			return math.NaN(), nil
+		}
+		return float64(n.schema.Type.Enum.Value(name)), nil
+	case "bit-is-set":
+		if err := nargs(2, 2); err != nil {
+			return nil, err
+		}
+		ns, err := nodeSetArg(0)
+		if err != nil || len(ns) == 0 {
+			return false, err
+		}
+		bit := stringArg(1)
+		for _, b := range strings.Fields(xpathStringValue(ns[0])) {
+			if b == bit {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	return nil, fmt.Errorf("unsupported XPath function %s", fn.Name)
+}
+
+// deref returns the node-set that is referenced by the leafref or
+// instance-identifier leaf n.
+func (ev *xpathEvaluator) deref(n *XPathNode) (interface{}, error) {
+	if !n.isLeaf() || n.schema.Type == nil {
+		return []*XPathNode{}, nil
+	}
+	var path string
+	switch n.schema.Type.Kind {
+	case yang.Yleafref:
+		path = n.schema.Type.Path
+	case yang.YinstanceIdentifier:
+		path = xpathStringValue(n)
+	default:
+		return []*XPathNode{}, nil
+	}
+	e, err := ParseXPath(path)
+	if err != nil {
+		return nil, err
+	}
+	// The path is evaluated with n as the current node, any nodes outside
+	// of the data tree that it selects are recorded by ev.
+	pev := &xpathEvaluator{current: n}
+	v, err := pev.eval(e, xpathContext{node: n, pos: 1, size: 1})
+	ev.outside = ev.outside || pev.outside
+	if err != nil {
+		return nil, err
+	}
+	ns, ok := v.([]*XPathNode)
+	if !ok {
+		return nil, fmt.Errorf("path %s of %s does not evaluate to a node-set", path, n.name)
+	}
+	if n.schema.Type.Kind == yang.YinstanceIdentifier {
+		return ns, nil
+	}
+	var out []*XPathNode
+	val := xpathStringValue(n)
+	for _, t := range ns {
+		if xpathStringValue(t) == val {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// derivedFrom returns true if the identity named value is derived from the
+// identity named base, where both identities are derived from root. If
+// orSelf is true, the function also returns true where value is equal to
+// base.
+func derivedFrom(root *yang.Identity, value, base string, orSelf bool) bool {
+	if value == base {
+		return orSelf
+	}
+	b := findIdentity(root, base)
+	return b != nil && findIdentity(b, value) != nil
+}
+
+// findIdentity returns the identity named name from the identity id and
+// those that are derived from it, or nil if it does not exist.
+func findIdentity(id *yang.Identity, name string) *yang.Identity {
+	if id == nil {
+		return nil
+	}
+	if id.Name == name {
+		return id
+	}
+	for _, v := range id.Values {
+		if f := findIdentity(v, name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// EvalXPathBool evaluates the expression e with the context node and current
+// node set to n, and returns the result converted to a boolean.
+func EvalXPathBool(e XPathExpr, n *XPathNode) (bool, error) {
+	v, err := EvalXPath(e, n)
+	if err != nil {
+		return false, err
+	}
+	return xpathBool(v), nil
+}
+
+// EvalXPathBoolInTree evaluates the expression e as per EvalXPathBool, and
+// additionally returns whether the evaluation remained within the data tree.
+// Where the tree was built by NewXPathTree for a value that is not the root
+// of its schema, the ancestors of the value do not contain data, hence the
+// result of an expression that selects them, such as an absolute path or a
+// relative path that leaves the subtree, does not reflect the data of the
+// entire tree and inTree is false.
+func EvalXPathBoolInTree(e XPathExpr, n *XPathNode) (ok, inTree bool, err error) {
+	ev := &xpathEvaluator{current: n}
+	v, err := ev.eval(e, xpathContext{node: n, pos: 1, size: 1})
+	if err != nil {
+		return false, !ev.outside, err
+	}
+	return xpathBool(v), !ev.outside, nil
+}
+
+// XPathStatement is a YANG statement whose argument is an XPath expression,
+// such as must or when.
+type XPathStatement struct {
+	// Expr is the XPath expression.
+	Expr string
+	// ErrorMessage is the error-message substatement, if specified.
+	ErrorMessage string
+}
+
+// SchemaXPathStatements returns the statements with the supplied keyword
+// that are specified for schema. goyang stores the must and when statements
+// of leaves and leaf-lists only in their AST node, and those of other
+// statements in the Extra field of schema. The contents of Extra are stored
+// as the goyang AST types where the schema was generated from YANG, and as
+// generic maps where the schema was unmarshalled from JSON, both forms are
+// handled.
+func SchemaXPathStatements(schema *yang.Entry, keyword string) []*XPathStatement {
+	if schema == nil {
+		return nil
+	}
+	var out []*XPathStatement
+	var add func(v interface{})
+	add = func(v interface{}) {
+		switch v := v.(type) {
+		case []interface{}:
+			for _, e := range v {
+				add(e)
+			}
+		case []*yang.Must:
+			for _, e := range v {
+				add(e)
+			}
+		case []*yang.Value:
+			for _, e := range v {
+				add(e)
+			}
+		case *yang.Must:
+			if v != nil {
+				s := &XPathStatement{Expr: v.Name}
+				if v.ErrorMessage != nil {
+					s.ErrorMessage = v.ErrorMessage.Name
+				}
+				out = append(out, s)
+			}
+		case *yang.Value:
+			if v != nil {
+				out = append(out, &XPathStatement{Expr: v.Name})
+			}
+		case map[string]interface{}:
+			name, ok := v["Name"].(string)
+			if !ok {
+				return
+			}
+			s := &XPathStatement{Expr: name}
+			if em, ok := v["ErrorMessage"].(map[string]interface{}); ok {
+				s.ErrorMessage, _ = em["Name"].(string)
+			}
+			out = append(out, s)
+		case string:
+			out = append(out, &XPathStatement{Expr: v})
+		}
+	}
+	switch n := schema.Node.(type) {
+	case *yang.Leaf:
+		add(leafXPathStatements(keyword, n.Must, n.When))
+	case *yang.LeafList:
+		add(leafXPathStatements(keyword, n.Must, n.When))
+	default:
+		add(schema.Extra[keyword])
+	}
+	return out
+}
+
+// leafXPathStatements returns the must or when statements, as selected by
+// keyword, from the supplied statements of a leaf or leaf-list AST node. nil
+// is returned for any other keyword.
+func leafXPathStatements(keyword string, must []*yang.Must, when *yang.Value) interface{} {
+	switch keyword {
+	case "must":
+		return must
+	case "when":
+		if when != nil {
+			return []*yang.Value{when}
+		}
+	}
+	return nil
+}
+
+// InactiveWhenNodes returns the nodes of the tree rooted at n that contain
+// data, but have a YANG when statement that evaluates to false. The
+// descendants of such nodes are not examined, since they are inactive
+// regardless of their own conditions. Errors are returned for when
+// statements that cannot be evaluated. When statements whose evaluation
+// leaves the data tree, which is possible only where the tree was built for a
+// value that is not the root of its schema, are not considered, since the
+// data that they depend on is not available.
+func InactiveWhenNodes(n *XPathNode) ([]*XPathNode, Errors) {
+	var inactive []*XPathNode
+	var errs Errors
+	n.Walk(func(d *XPathNode) bool {
+		if d.outside {
+			return true
+		}
+		for _, w := range SchemaXPathStatements(d.schema, "when") {
+			e, err := ParseXPath(w.Expr)
+			if err != nil {
+				errs = AppendErr(errs, fmt.Errorf("schema path %s: invalid when statement: %v", SchemaTreePathNoModule(d.schema), err))
+				continue
+			}
+			ok, inTree, err := EvalXPathBoolInTree(e, d)
+			if err != nil {
+				errs = AppendErr(errs, fmt.Errorf("schema path %s: cannot evaluate when statement %q: %v", SchemaTreePathNoModule(d.schema), w.Expr, err))
+				continue
+			}
+			if inTree && !ok {
+				inactive = append(inactive, d)
+				return false
+			}
+		}
+		return true
+	})
+	return inactive, errs
+}
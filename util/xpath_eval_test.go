@@ -0,0 +1,1063 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
+)
+
+// xpathEnum is an enumerated type that mimics those generated by ygen.
+type xpathEnum int64
+
+// xpathEnumDef mimics ygot.EnumDefinition.
+type xpathEnumDef struct {
+	Name string
+}
+
+func (xpathEnum) ΛMap() map[string]map[int64]xpathEnumDef {
+	return map[string]map[int64]xpathEnumDef{
+		"xpathEnum": {42: {Name: "E_VALUE_FORTY_TWO"}},
+	}
+}
+
+type xpathInterface struct {
+	Name    *string   `path:"config/name|name"`
+	Mtu     *uint32   `path:"config/mtu"`
+	Type    xpathEnum `path:"config/type"`
+	Enabled *bool     `path:"config/enabled"`
+}
+
+func (*xpathInterface) IsYANGGoStruct() {}
+
+type xpathSystem struct {
+	Hostname *string  `path:"hostname"`
+	Server   []string `path:"server"`
+	Ref      *string  `path:"ref"`
+}
+
+func (*xpathSystem) IsYANGGoStruct() {}
+
+type xpathDevice struct {
+	Interface map[string]*xpathInterface `path:"interfaces/interface"`
+	System    *xpathSystem               `path:"system"`
+}
+
+func (*xpathDevice) IsYANGGoStruct() {}
+
+func toUint32Ptr(i uint32) *uint32 { return &i }
+func toBoolPtr(b bool) *bool       { return &b }
+
+// findXPathNode returns the first node in the tree rooted at root with the
+// supplied path.
+func findXPathNode(root *XPathNode, path string) *XPathNode {
+	var found *XPathNode
+	root.walk(func(n *XPathNode) {
+		if found == nil && n.String() == path {
+			found = n
+		}
+	})
+	return found
+}
+
+func TestEvalXPath(t *testing.T) {
+	device := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth1": {Name: toStringPtr("eth1"), Mtu: toUint32Ptr(9000), Type: 42, Enabled: toBoolPtr(true)},
+			"eth0": {Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500)},
+		},
+		System: &xpathSystem{
+			Hostname: toStringPtr("router"),
+			Server:   []string{"a", "b", "c"},
+			Ref:      toStringPtr("eth1"),
+		},
+	}
+
+	root, err := NewXPathTree(yangtest.XPathSchema(t), device)
+	if err != nil {
+		t.Fatalf("cannot build XPath tree: %v", err)
+	}
+
+	tests := []struct {
+		desc             string
+		expr             string
+		context          string
+		want             interface{}
+		wantErrSubstring string
+	}{{
+		desc: "absolute path",
+		expr: "/interfaces/interface/config/mtu",
+		want: []string{
+			"/interfaces/interface/config/mtu",
+			"/interfaces/interface/config/mtu",
+		},
+	}, {
+		desc: "list keys are present both in the list and config container",
+		expr: "count(/interfaces/interface/name) + count(//config/name)",
+		want: float64(4),
+	}, {
+		desc: "predicate on key",
+		expr: "/interfaces/interface[name = 'eth1']/config/mtu = 9000",
+		want: true,
+	}, {
+		desc: "positional predicate",
+		expr: "string(/interfaces/interface[2]/name)",
+		want: "eth1",
+	}, {
+		desc: "last",
+		expr: "string(/system/server[last()])",
+		want: "c",
+	}, {
+		desc:    "relative path with current",
+		expr:    "../../config[mtu > 1000]/name = current()/../name",
+		context: "/interfaces/interface/config/mtu",
+		want:    true,
+	}, {
+		desc:    "parent of leaf",
+		expr:    "..",
+		context: "/system/hostname",
+		want:    []string{"/system"},
+	}, {
+		desc:    "ancestor axis",
+		expr:    "count(ancestor::*)",
+		context: "/interfaces/interface/config/mtu",
+		want:    float64(3),
+	}, {
+		desc:    "sibling axes",
+		expr:    "concat(preceding-sibling::server[1], following-sibling::server)",
+		context: "/system/server",
+		want:    "b",
+	}, {
+		desc:    "following sibling of first leaf-list element",
+		expr:    "string(following-sibling::server[1])",
+		context: "/system/server",
+		want:    "b",
+	}, {
+		desc: "union",
+		expr: "/system/hostname | /system/ref",
+		want: []string{"/system/hostname", "/system/ref"},
+	}, {
+		desc: "node-set compared to node-set",
+		expr: "/system/ref = /interfaces/interface/name",
+		want: true,
+	}, {
+		desc: "not equal with node-sets is existential",
+		expr: "/interfaces/interface/config/mtu != 1500",
+		want: true,
+	}, {
+		desc: "empty node-set comparison",
+		expr: "/system/missing = ''",
+		want: false,
+	}, {
+		desc: "boolean leaf",
+		expr: "/interfaces/interface[name = 'eth1']/config/enabled = 'true'",
+		want: true,
+	}, {
+		desc: "arithmetic",
+		expr: "(7 mod 3) * 2 - 4 div 8",
+		want: 1.5,
+	}, {
+		desc: "sum",
+		expr: "sum(/interfaces/interface/config/mtu)",
+		want: float64(10500),
+	}, {
+		desc: "NaN",
+		expr: "number('abc')",
+		want: math.NaN(),
+	}, {
+		desc: "string functions",
+		expr: "concat(substring('12345', 1.5, 2.6), '-', translate('bar', 'abc', 'AB'), '-', normalize-space('  a  b '), '-', substring-after('a:b', ':'))",
+		want: "234-BAr-a b-b",
+	}, {
+		desc:    "string-length of context",
+		expr:    "string-length()",
+		context: "/system/hostname",
+		want:    float64(6),
+	}, {
+		desc: "re-match",
+		expr: "re-match(/system/hostname, '[a-z]+') and not(re-match('r1', '[a-z]+'))",
+		want: true,
+	}, {
+		desc: "identityref with prefix",
+		expr: "/interfaces/interface/config/type = 'mod:E_VALUE_FORTY_TWO'",
+		want: true,
+	}, {
+		desc: "derived-from",
+		expr: "derived-from(/interfaces/interface/config/type, 'mod:BASE') and derived-from(/interfaces/interface/config/type, 'PARENT')",
+		want: true,
+	}, {
+		desc: "derived-from excludes self",
+		expr: "derived-from(/interfaces/interface/config/type, 'E_VALUE_FORTY_TWO')",
+		want: false,
+	}, {
+		desc: "derived-from-or-self",
+		expr: "derived-from-or-self(/interfaces/interface/config/type, 'E_VALUE_FORTY_TWO')",
+		want: true,
+	}, {
+		desc: "deref",
+		expr: "deref(/system/ref)/../config/mtu",
+		want: []string{"/interfaces/interface/config/mtu"},
+	}, {
+		desc: "deref value",
+		expr: "number(deref(/system/ref)/../config/mtu)",
+		want: float64(9000),
+	}, {
+		desc: "local-name",
+		expr: "local-name(/system/*[1])",
+		want: "hostname",
+	}, {
+		desc:             "unknown function",
+		expr:             "foo()",
+		wantErrSubstring: "unsupported XPath function foo",
+	}, {
+		desc:             "bad argument count",
+		expr:             "count()",
+		wantErrSubstring: "invalid number of arguments to count",
+	}, {
+		desc:             "variable",
+		expr:             "$foo",
+		wantErrSubstring: "variable $foo is not defined",
+	}, {
+		desc:             "union of non node-sets",
+		expr:             "1 | 2",
+		wantErrSubstring: "union of non node-set values",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			e, err := ParseXPath(tt.expr)
+			if err != nil {
+				t.Fatalf("cannot parse %s: %v", tt.expr, err)
+			}
+			ctx := root
+			if tt.context != "" {
+				if ctx = findXPathNode(root, tt.context); ctx == nil {
+					t.Fatalf("cannot find context node %s", tt.context)
+				}
+			}
+			got, err := EvalXPath(e, ctx)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("EvalXPath(%s): did not get expected error, %s", tt.expr, diff)
+			}
+			if err != nil {
+				return
+			}
+			if ns, ok := got.([]*XPathNode); ok {
+				var paths []string
+				for _, n := range ns {
+					paths = append(paths, n.String())
+				}
+				got = paths
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b float64) bool {
+				return a == b || (math.IsNaN(a) && math.IsNaN(b))
+			})); diff != "" {
+				t.Errorf("EvalXPath(%s): did not get expected result, diff(-want, +got):\n%s", tt.expr, diff)
+			}
+		})
+	}
+}
+
+func TestNewXPathTreeSubtree(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	intf := &xpathInterface{Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500)}
+
+	n, err := NewXPathTree(schema.Dir["interfaces"].Dir["interface"], intf)
+	if err != nil {
+		t.Fatalf("NewXPathTree: got unexpected error: %v", err)
+	}
+	root := n.root()
+	if got, want := n.String(), "/interfaces/interface"; got != want {
+		t.Errorf("NewXPathTree: got node with path %s, want: %s", got, want)
+	}
+	if !root.outside || !n.parent.outside || n.outside {
+		t.Errorf("NewXPathTree: got incorrect outside markers, root: %v, parent: %v, node: %v", root.outside, n.parent.outside, n.outside)
+	}
+
+	e, err := ParseXPath("/interfaces/interface/config/mtu + 1")
+	if err != nil {
+		t.Fatalf("cannot parse expression: %v", err)
+	}
+	got, err := EvalXPath(e, n)
+	if err != nil {
+		t.Fatalf("EvalXPath: got unexpected error: %v", err)
+	}
+	if got != float64(1501) {
+		t.Errorf("EvalXPath: got %v, want 1501", got)
+	}
+}
+
+func TestEvalXPathBoolInTree(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	intf := &xpathInterface{Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500)}
+	n, err := NewXPathTree(schema.Dir["interfaces"].Dir["interface"], intf)
+	if err != nil {
+		t.Fatalf("NewXPathTree: got unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		inExpr     string
+		wantOK     bool
+		wantInTree bool
+	}{{
+		inExpr:     "config/mtu = 1500",
+		wantOK:     true,
+		wantInTree: true,
+	}, {
+		inExpr:     "deref(name)/../mtu = 1500",
+		wantOK:     true,
+		wantInTree: true,
+	}, {
+		inExpr: "/interfaces/interface/config/mtu = 1500",
+		wantOK: true,
+	}, {
+		inExpr: "../../system",
+	}, {
+		inExpr: "count(../interface) = 1",
+		wantOK: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.inExpr, func(t *testing.T) {
+			e, err := ParseXPath(tt.inExpr)
+			if err != nil {
+				t.Fatalf("cannot parse expression: %v", err)
+			}
+			ok, inTree, err := EvalXPathBoolInTree(e, n)
+			if err != nil {
+				t.Fatalf("EvalXPathBoolInTree(%s): got unexpected error: %v", tt.inExpr, err)
+			}
+			if ok != tt.wantOK || inTree != tt.wantInTree {
+				t.Errorf("EvalXPathBoolInTree(%s): got (%v, %v), want: (%v, %v)", tt.inExpr, ok, inTree, tt.wantOK, tt.wantInTree)
+			}
+		})
+	}
+}
+
+// xpathData is an XPathData implementation used for testing.
+type xpathData struct {
+	schema   *yang.Entry
+	value    interface{}
+	children []*xpathData
+}
+
+func (d *xpathData) XPathSchema() *yang.Entry { return d.schema }
+func (d *xpathData) XPathValue() interface{}  { return d.value }
+func (d *xpathData) XPathChildren() []XPathData {
+	var out []XPathData
+	for _, c := range d.children {
+		out = append(out, c)
+	}
+	return out
+}
+
+func TestNewXPathTreeData(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	system := schema.Dir["system"]
+	data := &xpathData{schema: system, children: []*xpathData{
+		{schema: system.Dir["hostname"], value: "rtr1"},
+		{schema: system.Dir["server"], value: "192.0.2.1"},
+		{schema: system.Dir["server"], value: "192.0.2.2"},
+	}}
+
+	tests := []struct {
+		desc   string
+		inRoot XPathData
+		inExpr string
+		want   interface{}
+	}{{
+		desc:   "root",
+		inRoot: &xpathData{schema: schema, children: []*xpathData{data}},
+		inExpr: "count(/system/server) + string-length(/system/hostname)",
+		want:   float64(6),
+	}, {
+		desc:   "subtree",
+		inRoot: data,
+		inExpr: "/system/server[2]",
+		want:   "192.0.2.2",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n, err := NewXPathTree(tt.inRoot.XPathSchema(), tt.inRoot)
+			if err != nil {
+				t.Fatalf("NewXPathTree: got unexpected error: %v", err)
+			}
+			e, err := ParseXPath(tt.inExpr)
+			if err != nil {
+				t.Fatalf("cannot parse expression %s: %v", tt.inExpr, err)
+			}
+			got, err := EvalXPath(e, n)
+			if err != nil {
+				t.Fatalf("EvalXPath(%s): got unexpected error: %v", tt.inExpr, err)
+			}
+			if ns, ok := got.([]*XPathNode); ok && len(ns) == 1 {
+				got = ns[0].Value()
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("EvalXPath(%s): did not get expected result, diff(-want, +got):\n%s", tt.inExpr, diff)
+			}
+			if err := n.Children()[0].Remove(); err == nil {
+				t.Errorf("Remove: did not get expected error for XPathData node")
+			}
+		})
+	}
+}
+
+func TestSchemaXPathStatements(t *testing.T) {
+	must := []*yang.Must{{
+		Name:         "a = 1",
+		ErrorMessage: &yang.Value{Name: "a must be one"},
+	}, {
+		Name: "b = 2",
+	}}
+
+	// Schemas that are unmarshalled from JSON store the contents of Extra
+	// as generic types.
+	var fromJSON []interface{}
+	j, err := json.Marshal([]interface{}{must})
+	if err != nil {
+		t.Fatalf("cannot marshal must statements: %v", err)
+	}
+	if err := json.Unmarshal(j, &fromJSON); err != nil {
+		t.Fatalf("cannot unmarshal must statements: %v", err)
+	}
+
+	want := []*XPathStatement{
+		{Expr: "a = 1", ErrorMessage: "a must be one"},
+		{Expr: "b = 2"},
+	}
+
+	tests := []struct {
+		desc    string
+		in      *yang.Entry
+		keyword string
+		want    []*XPathStatement
+	}{{
+		desc:    "nil extra",
+		in:      &yang.Entry{},
+		keyword: "must",
+	}, {
+		desc:    "AST types",
+		in:      &yang.Entry{Extra: map[string][]interface{}{"must": {must}}},
+		keyword: "must",
+		want:    want,
+	}, {
+		desc:    "individual AST values",
+		in:      &yang.Entry{Extra: map[string][]interface{}{"must": {must[0], must[1]}}},
+		keyword: "must",
+		want:    want,
+	}, {
+		desc:    "unmarshalled from JSON",
+		in:      &yang.Entry{Extra: map[string][]interface{}{"must": fromJSON}},
+		keyword: "must",
+		want:    want,
+	}, {
+		desc:    "value",
+		in:      &yang.Entry{Extra: map[string][]interface{}{"when": {&yang.Value{Name: "c = 3"}}}},
+		keyword: "when",
+		want:    []*XPathStatement{{Expr: "c = 3"}},
+	}, {
+		desc:    "other keyword",
+		in:      &yang.Entry{Extra: map[string][]interface{}{"when": {&yang.Value{Name: "c = 3"}}}},
+		keyword: "must",
+	}, {
+		desc:    "leaf AST node",
+		in:      &yang.Entry{Node: &yang.Leaf{Must: must}},
+		keyword: "must",
+		want:    want,
+	}, {
+		desc:    "leaf-list AST node",
+		in:      &yang.Entry{Node: &yang.LeafList{When: &yang.Value{Name: "c = 3"}}},
+		keyword: "when",
+		want:    []*XPathStatement{{Expr: "c = 3"}},
+	}, {
+		desc:    "leaf AST node without when",
+		in:      &yang.Entry{Node: &yang.Leaf{Must: must}},
+		keyword: "when",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := SchemaXPathStatements(tt.in, tt.keyword)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SchemaXPathStatements: did not get expected statements, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchemaXPathStatementsFromYANG(t *testing.T) {
+	mod := yangtest.ParseModule(t, "xpath-test", `
+module xpath-test {
+  prefix "xt";
+  namespace "urn:xt";
+
+  container system {
+    must "hostname" {
+      error-message "hostname must be set";
+    }
+    when "true()";
+
+    leaf hostname {
+      type string;
+      must "string-length(.) > 1";
+      when "../server";
+    }
+
+    leaf-list server {
+      type string;
+      must "string-length(.) > 1" {
+        error-message "server too short";
+      }
+      when "../hostname";
+    }
+
+    leaf plain {
+      type string;
+    }
+  }
+}`)
+	system := mod.Dir["system"]
+
+	tests := []struct {
+		desc    string
+		in      *yang.Entry
+		keyword string
+		want    []*XPathStatement
+	}{{
+		desc:    "container must",
+		in:      system,
+		keyword: "must",
+		want:    []*XPathStatement{{Expr: "hostname", ErrorMessage: "hostname must be set"}},
+	}, {
+		desc:    "container when",
+		in:      system,
+		keyword: "when",
+		want:    []*XPathStatement{{Expr: "true()"}},
+	}, {
+		desc:    "leaf must",
+		in:      system.Dir["hostname"],
+		keyword: "must",
+		want:    []*XPathStatement{{Expr: "string-length(.) > 1"}},
+	}, {
+		desc:    "leaf when",
+		in:      system.Dir["hostname"],
+		keyword: "when",
+		want:    []*XPathStatement{{Expr: "../server"}},
+	}, {
+		desc:    "leaf-list must",
+		in:      system.Dir["server"],
+		keyword: "must",
+		want:    []*XPathStatement{{Expr: "string-length(.) > 1", ErrorMessage: "server too short"}},
+	}, {
+		desc:    "leaf-list when",
+		in:      system.Dir["server"],
+		keyword: "when",
+		want:    []*XPathStatement{{Expr: "../hostname"}},
+	}, {
+		desc:    "leaf without statements",
+		in:      system.Dir["plain"],
+		keyword: "must",
+	}, {
+		desc:    "leaf without when statement",
+		in:      system.Dir["plain"],
+		keyword: "when",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := SchemaXPathStatements(tt.in, tt.keyword)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("SchemaXPathStatements: did not get expected statements, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// YANGEmpty mimics the YANGEmpty type used by generated code.
+type YANGEmpty bool
+
+type xpathZeroValues struct {
+	Counters []int64     `path:"counters"`
+	Flags    []bool      `path:"flags"`
+	Enums    []xpathEnum `path:"enums"`
+	Type     xpathEnum   `path:"type"`
+	Enabled  bool        `path:"enabled"`
+	Offset   int64       `path:"offset"`
+	Empty    YANGEmpty   `path:"empty"`
+}
+
+func (*xpathZeroValues) IsYANGGoStruct() {}
+
+func TestNewXPathTreeZeroValues(t *testing.T) {
+	leaf := func(name string, kind yang.TypeKind) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: kind}}
+	}
+	leafList := func(name string, kind yang.TypeKind) *yang.Entry {
+		e := leaf(name, kind)
+		e.ListAttr = &yang.ListAttr{}
+		return e
+	}
+	schema := &yang.Entry{
+		Name: "values",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"counters": leafList("counters", yang.Yint64),
+			"flags":    leafList("flags", yang.Ybool),
+			"enums":    leafList("enums", yang.Yenum),
+			"type":     leaf("type", yang.Yenum),
+			"enabled":  leaf("enabled", yang.Ybool),
+			"offset":   leaf("offset", yang.Yint64),
+			"empty":    leaf("empty", yang.Yempty),
+		},
+	}
+	addParents(schema)
+	in := &xpathZeroValues{
+		Counters: []int64{0, 5},
+		Flags:    []bool{false},
+		Enums:    []xpathEnum{0, 42},
+	}
+
+	tests := []struct {
+		inExpr string
+		want   float64
+	}{{
+		inExpr: "count(counters)",
+		want:   2,
+	}, {
+		inExpr: "sum(counters)",
+		want:   5,
+	}, {
+		inExpr: "count(flags)",
+		want:   1,
+	}, {
+		inExpr: "count(enums)",
+		want:   2,
+	}, {
+		inExpr: "count(type)",
+		want:   0,
+	}, {
+		inExpr: "count(enabled)",
+		want:   1,
+	}, {
+		inExpr: "count(offset)",
+		want:   1,
+	}, {
+		inExpr: "count(empty)",
+		want:   0,
+	}}
+
+	root, err := NewXPathTree(schema, in)
+	if err != nil {
+		t.Fatalf("NewXPathTree: got unexpected error: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.inExpr, func(t *testing.T) {
+			e, err := ParseXPath(tt.inExpr)
+			if err != nil {
+				t.Fatalf("cannot parse expression %s: %v", tt.inExpr, err)
+			}
+			got, err := EvalXPath(e, root)
+			if err != nil {
+				t.Fatalf("EvalXPath(%s): got unexpected error: %v", tt.inExpr, err)
+			}
+			if got != tt.want {
+				t.Errorf("EvalXPath(%s): got %v, want: %v", tt.inExpr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXPathNodeRemove(t *testing.T) {
+	newDevice := func() *xpathDevice {
+		return &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500)},
+				"eth1": {Name: toStringPtr("eth1")},
+			},
+			System: &xpathSystem{
+				Hostname: toStringPtr("router"),
+				Server:   []string{"a", "b", "c"},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc             string
+		path             string
+		want             *xpathDevice
+		wantErrSubstring string
+	}{{
+		desc: "leaf",
+		path: "/system/hostname",
+		want: &xpathDevice{
+			Interface: newDevice().Interface,
+			System:    &xpathSystem{Server: []string{"a", "b", "c"}},
+		},
+	}, {
+		desc: "leaf-list element",
+		path: "/system/server[. = 'b']",
+		want: &xpathDevice{
+			Interface: newDevice().Interface,
+			System: &xpathSystem{
+				Hostname: toStringPtr("router"),
+				Server:   []string{"a", "c"},
+			},
+		},
+	}, {
+		desc: "list entry",
+		path: "/interfaces/interface[name = 'eth0']",
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth1": {Name: toStringPtr("eth1")},
+			},
+			System: newDevice().System,
+		},
+	}, {
+		desc: "leaf within compressed container",
+		path: "/interfaces/interface[name = 'eth0']/config/mtu",
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: toStringPtr("eth0")},
+				"eth1": {Name: toStringPtr("eth1")},
+			},
+			System: newDevice().System,
+		},
+	}, {
+		desc: "container",
+		path: "/system",
+		want: &xpathDevice{Interface: newDevice().Interface},
+	}, {
+		desc: "virtual container",
+		path: "/interfaces",
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{},
+			System:    newDevice().System,
+		},
+	}, {
+		desc:             "root",
+		path:             "/",
+		wantErrSubstring: "outside of the data tree",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			d := newDevice()
+			n, err := NewXPathTree(yangtest.XPathSchema(t), d)
+			if err != nil {
+				t.Fatalf("cannot build XPath tree: %v", err)
+			}
+			e, err := ParseXPath(tt.path)
+			if err != nil {
+				t.Fatalf("cannot parse %s: %v", tt.path, err)
+			}
+			got, err := EvalXPath(e, n)
+			if err != nil {
+				t.Fatalf("cannot evaluate %s: %v", tt.path, err)
+			}
+			ns, ok := got.([]*XPathNode)
+			if !ok || len(ns) != 1 {
+				t.Fatalf("%s did not select a single node, got: %v", tt.path, got)
+			}
+			// The root node is not outside of the data tree when the tree
+			// is built from the root, hence mark it as such to test that
+			// outside nodes cannot be removed.
+			if ns[0].parent == nil {
+				ns[0].outside = true
+			}
+
+			err = ns[0].Remove()
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("Remove: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, d); diff != "" {
+				t.Errorf("Remove: did not get expected device, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXPathNodeRemoveOrderedList(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"list": {
+				Name:     "list",
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{OrderedBy: &yang.Value{Name: "user"}},
+				Key:      "string",
+				Dir: map[string]*yang.Entry{
+					"string": {Name: "string", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}},
+					"int32":  {Name: "int32", Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Yint32}},
+				},
+			},
+		},
+	}
+	addParents(schema)
+
+	d := &struct {
+		List *orderedBasicStructs `path:"list"`
+	}{List: &orderedBasicStructs{}}
+	for _, k := range []string{"b", "a", "c"} {
+		if err := d.List.Append(&BasicStruct{StringField: k}); err != nil {
+			t.Fatalf("cannot append %s: %v", k, err)
+		}
+	}
+
+	n, err := NewXPathTree(schema, d)
+	if err != nil {
+		t.Fatalf("cannot build XPath tree: %v", err)
+	}
+	e, err := ParseXPath("/list[string = 'a']")
+	if err != nil {
+		t.Fatalf("cannot parse path: %v", err)
+	}
+	got, err := EvalXPath(e, n)
+	if err != nil {
+		t.Fatalf("cannot evaluate path: %v", err)
+	}
+	ns, ok := got.([]*XPathNode)
+	if !ok || len(ns) != 1 {
+		t.Fatalf("path did not select a single node, got: %v", got)
+	}
+	if err := ns[0].Remove(); err != nil {
+		t.Fatalf("Remove: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"b", "c"}, d.List.Keys()); diff != "" {
+		t.Errorf("Remove: did not get expected list keys, diff(-want, +got):\n%s", diff)
+	}
+}
+
+func TestInactiveWhenNodes(t *testing.T) {
+	whenSchema := func() *yang.Entry {
+		s := yangtest.XPathSchema(t)
+		s.Dir["system"].Extra = map[string][]interface{}{
+			"when": {&yang.Value{Name: "count(/interfaces/interface) > 0"}},
+		}
+		s.Dir["system"].Dir["hostname"].Extra = map[string][]interface{}{
+			"when": {&yang.Value{Name: "../server"}},
+		}
+		s.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["mtu"].Extra = map[string][]interface{}{
+			"when": {&yang.Value{Name: "../enabled = 'true'"}},
+		}
+		return s
+	}
+	invalidSchema := whenSchema()
+	invalidSchema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["enabled"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "invalid["}},
+	}
+
+	tests := []struct {
+		desc     string
+		schema   *yang.Entry
+		in       *xpathDevice
+		want     []string
+		wantErrs []string
+	}{{
+		desc:   "all when statements true",
+		schema: whenSchema(),
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500), Enabled: toBoolPtr(true)},
+			},
+			System: &xpathSystem{Hostname: toStringPtr("router"), Server: []string{"a"}},
+		},
+	}, {
+		desc:   "false when statement on leaf",
+		schema: whenSchema(),
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500)},
+				"eth1": {Name: toStringPtr("eth1"), Mtu: toUint32Ptr(1500), Enabled: toBoolPtr(true)},
+			},
+		},
+		want: []string{"/interfaces/interface/config/mtu"},
+	}, {
+		desc:   "descendants of inactive node are skipped",
+		schema: whenSchema(),
+		in: &xpathDevice{
+			System: &xpathSystem{Hostname: toStringPtr("router")},
+		},
+		want: []string{"/system"},
+	}, {
+		desc:   "invalid when statement",
+		schema: invalidSchema,
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: toStringPtr("eth0"), Enabled: toBoolPtr(true)},
+			},
+		},
+		wantErrs: []string{`schema path /interfaces/interface/config/enabled: invalid when statement: cannot parse XPath expression "invalid[": expected node test at position 8, got ""`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n, err := NewXPathTree(tt.schema, tt.in)
+			if err != nil {
+				t.Fatalf("cannot build XPath tree: %v", err)
+			}
+			ns, errs := InactiveWhenNodes(n)
+			var gotErrs []string
+			for _, err := range errs {
+				gotErrs = append(gotErrs, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, gotErrs); diff != "" {
+				t.Errorf("InactiveWhenNodes: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+			var got []string
+			for _, n := range ns {
+				got = append(got, n.String())
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("InactiveWhenNodes: did not get expected nodes, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEnumName(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               interface{}
+		want             string
+		wantOK           bool
+		wantErrSubstring string
+	}{{
+		desc:   "enumerated value",
+		in:     xpathEnum(42),
+		want:   "E_VALUE_FORTY_TWO",
+		wantOK: true,
+	}, {
+		desc:             "unknown enumerated value",
+		in:               xpathEnum(1),
+		wantOK:           true,
+		wantErrSubstring: "cannot map enumerated value 1",
+	}, {
+		desc: "non-enumerated int64",
+		in:   int64(42),
+	}, {
+		desc: "string",
+		in:   "foo",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, ok, err := enumName(reflect.ValueOf(tt.in))
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("enumName(%v): did not get expected error, %s", tt.in, diff)
+			}
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("enumName(%v): got (%q, %v), want: (%q, %v)", tt.in, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestXPathNodeStringValue(t *testing.T) {
+	device := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth0": {Name: toStringPtr("eth0"), Mtu: toUint32Ptr(1500), Type: 42},
+		},
+	}
+	root, err := NewXPathTree(yangtest.XPathSchema(t), device)
+	if err != nil {
+		t.Fatalf("cannot build XPath tree: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{{
+		path: "/interfaces/interface/config/mtu",
+		want: "1500",
+	}, {
+		path: "/interfaces/interface/config/type",
+		want: "E_VALUE_FORTY_TWO",
+	}, {
+		path: "/interfaces/interface/config",
+		want: "eth01500E_VALUE_FORTY_TWO",
+	}}
+
+	for _, tt := range tests {
+		n := findXPathNode(root, tt.path)
+		if n == nil {
+			t.Errorf("cannot find node %s", tt.path)
+			continue
+		}
+		if got := n.StringValue(); got != tt.want {
+			t.Errorf("%s: StringValue(): got %q, want: %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestXPathNodeAccessors(t *testing.T) {
+	mtu := toUint32Ptr(1500)
+	device := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth0": {Name: toStringPtr("eth0"), Mtu: mtu},
+		},
+	}
+	root, err := NewXPathTree(yangtest.XPathSchema(t), device)
+	if err != nil {
+		t.Fatalf("cannot build XPath tree: %v", err)
+	}
+
+	n := findXPathNode(root, "/interfaces/interface/config/mtu")
+	if n == nil {
+		t.Fatalf("cannot find mtu node")
+	}
+	if got, want := n.Name(), "mtu"; got != want {
+		t.Errorf("Name(): got %s, want: %s", got, want)
+	}
+	if got, want := n.FieldName(), "Mtu"; got != want {
+		t.Errorf("FieldName(): got %s, want: %s", got, want)
+	}
+	if got := n.Value(); got != mtu {
+		t.Errorf("Value(): got %v, want: %v", got, mtu)
+	}
+
+	config := n.Parent()
+	if got, want := config.String(), "/interfaces/interface/config"; got != want {
+		t.Errorf("Parent(): got %s, want: %s", got, want)
+	}
+	if got := config.FieldName(); got != "" {
+		t.Errorf("FieldName() of compressed container: got %s, want empty string", got)
+	}
+	if got := config.Value(); got != nil {
+		t.Errorf("Value() of container: got %v, want nil", got)
+	}
+	var names []string
+	for _, c := range config.Children() {
+		names = append(names, c.Name())
+	}
+	if diff := cmp.Diff([]string{"name", "mtu"}, names); diff != "" {
+		t.Errorf("Children(): did not get expected children, diff(-want, +got):\n%s", diff)
+	}
+	if got := root.Parent(); got != nil {
+		t.Errorf("Parent() of root: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseXPath(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               string
+		want             XPathExpr
+		wantString       string
+		wantErrSubstring string
+	}{{
+		desc:       "simple relative path",
+		in:         "../config/name",
+		wantString: "../config/name",
+		want: &XPathLocationPath{
+			Steps: []*XPathStep{
+				{Axis: "parent", NodeType: "node"},
+				{Axis: "child", Name: "config"},
+				{Axis: "child", Name: "name"},
+			},
+		},
+	}, {
+		desc:       "absolute path with prefixes",
+		in:         "/oc-if:interfaces/oc-if:interface",
+		wantString: "/oc-if:interfaces/oc-if:interface",
+		want: &XPathLocationPath{
+			Absolute: true,
+			Steps: []*XPathStep{
+				{Axis: "child", Prefix: "oc-if", Name: "interfaces"},
+				{Axis: "child", Prefix: "oc-if", Name: "interface"},
+			},
+		},
+	}, {
+		desc:       "root only",
+		in:         "/",
+		wantString: "/",
+		want:       &XPathLocationPath{Absolute: true},
+	}, {
+		desc:       "leafref path with predicate",
+		in:         "../../list[key = current()/../key]/value",
+		wantString: "../../list[(key = current()/../key)]/value",
+		want: &XPathLocationPath{
+			Steps: []*XPathStep{
+				{Axis: "parent", NodeType: "node"},
+				{Axis: "parent", NodeType: "node"},
+				{
+					Axis: "child",
+					Name: "list",
+					Predicates: []XPathExpr{
+						&XPathBinaryExpr{
+							Op:  "=",
+							LHS: &XPathLocationPath{Steps: []*XPathStep{{Axis: "child", Name: "key"}}},
+							RHS: &XPathPathExpr{
+								Filter: &XPathFunctionCall{Name: "current"},
+								Path: &XPathLocationPath{
+									Steps: []*XPathStep{
+										{Axis: "parent", NodeType: "node"},
+										{Axis: "child", Name: "key"},
+									},
+								},
+							},
+						},
+					},
+				},
+				{Axis: "child", Name: "value"},
+			},
+		},
+	}, {
+		desc:       "operator precedence",
+		in:         "a or b and c = 1 + 2 * 3",
+		wantString: "(a or (b and (c = (1 + (2 * 3)))))",
+	}, {
+		desc:       "multiply disambiguated from wildcard",
+		in:         "* * *",
+		wantString: "(* * *)",
+		want: &XPathBinaryExpr{
+			Op:  "*",
+			LHS: &XPathLocationPath{Steps: []*XPathStep{{Axis: "child", Name: "*"}}},
+			RHS: &XPathLocationPath{Steps: []*XPathStep{{Axis: "child", Name: "*"}}},
+		},
+	}, {
+		desc:       "operator name disambiguated from element name",
+		in:         "div div div",
+		wantString: "(div div div)",
+	}, {
+		desc:       "left associativity",
+		in:         "10 - 2 - 3",
+		wantString: "((10 - 2) - 3)",
+	}, {
+		desc:       "unary minus",
+		in:         "--5",
+		wantString: "--5",
+	}, {
+		desc:       "union",
+		in:         "a | b/c",
+		wantString: "a | b/c",
+	}, {
+		desc:       "explicit axes",
+		in:         "ancestor-or-self::node()/following-sibling::x:*",
+		wantString: "ancestor-or-self::node()/following-sibling::x:*",
+	}, {
+		desc:       "abbreviated descendant",
+		in:         "//interface",
+		wantString: "/descendant-or-self::node()/interface",
+	}, {
+		desc:       "attribute abbreviation",
+		in:         "@name",
+		wantString: "attribute::name",
+	}, {
+		desc:       "function call with multiple arguments",
+		in:         "concat('a', \"b\", 1.5)",
+		wantString: `concat("a", "b", 1.5)`,
+	}, {
+		desc:       "filter expression with predicates",
+		in:         "(a | b)[1][last()]",
+		wantString: "(a | b)[1][last()]",
+	}, {
+		desc:       "YANG functions",
+		in:         "derived-from-or-self(type, 'ianaift:ethernetCsmacd') and re-match(name, '[a-z]+')",
+		wantString: `(derived-from-or-self(type, "ianaift:ethernetCsmacd") and re-match(name, "[a-z]+"))`,
+	}, {
+		desc:       "deref",
+		in:         "deref(../interface)/../config/enabled = 'true'",
+		wantString: `(deref(../interface)/../config/enabled = "true")`,
+	}, {
+		desc:       "variable",
+		in:         "$x + 1",
+		wantString: "($x + 1)",
+	}, {
+		desc:       "number starting with decimal point",
+		in:         ".5 < 1.",
+		wantString: "(0.5 < 1)",
+	}, {
+		desc:       "whitespace and relational operators",
+		in:         " a<=b ",
+		wantString: "(a <= b)",
+	}, {
+		desc:             "unterminated literal",
+		in:               "a = 'foo",
+		wantErrSubstring: "unterminated literal",
+	}, {
+		desc:             "invalid operator name",
+		in:               "a foo b",
+		wantErrSubstring: "expected operator",
+	}, {
+		desc:             "invalid axis",
+		in:               "sibling::a",
+		wantErrSubstring: "invalid axis name",
+	}, {
+		desc:             "unbalanced bracket",
+		in:               "a[b = 1",
+		wantErrSubstring: "expected ]",
+	}, {
+		desc:             "trailing token",
+		in:               "a b",
+		wantErrSubstring: "expected operator",
+	}, {
+		desc:             "unclosed function call",
+		in:               "count(a",
+		wantErrSubstring: "expected )",
+	}, {
+		desc:             "empty expression",
+		in:               "",
+		wantErrSubstring: "expected node test",
+	}, {
+		desc:             "invalid character",
+		in:               "a # b",
+		wantErrSubstring: "unexpected character",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseXPath(tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("ParseXPath(%q): did not get expected error, %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if tt.want != nil {
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("ParseXPath(%q): did not get expected expression, diff(-want, +got):\n%s", tt.in, diff)
+				}
+			}
+			if s := got.String(); s != tt.wantString {
+				t.Errorf("ParseXPath(%q).String(): got %s, want %s", tt.in, s, tt.wantString)
+			}
+		})
+	}
+}
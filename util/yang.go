@@ -16,6 +16,8 @@
 package util
 
 import (
+	"bytes"
+	"fmt"
 	"reflect"
 	"strings"
 
@@ -35,19 +37,91 @@ var (
 // SchemaTree.
 const CompressedSchemaAnnotation string = "isCompressedSchema"
 
+// ModuleNamespacesAnnotation stores the name of the annotation that maps the
+// name of each module used to generate a set of structs to its XML namespace.
+// It is appended to the yang.Entry struct of the root entity of the structs
+// within the SchemaTree.
+const ModuleNamespacesAnnotation string = "moduleNamespaces"
+
+// ModulePrefixesAnnotation stores the name of the annotation that maps the
+// prefix of each module used to generate a set of structs to its name. It is
+// appended to the yang.Entry struct of the root entity of the structs within
+// the SchemaTree, such that the module that defines an entry can be found
+// from its prefix.
+const ModulePrefixesAnnotation string = "modulePrefixes"
+
+// PresenceAnnotation stores the name of the annotation indicating that a
+// container is a presence container. It is added by ygen to the yang.Entry
+// struct of each presence container within the SchemaTree, since the
+// presence statement itself is not retained in the serialised schema.
+const PresenceAnnotation string = "presence"
+
 // Children returns all child elements of a directory element e that are not
-// RPC entries.
+// RPC, action or notification entries.
 func Children(e *yang.Entry) []*yang.Entry {
 	var entries []*yang.Entry
 
 	for _, e := range e.Dir {
-		if e.RPC == nil {
+		if e.RPC == nil && e.Kind != yang.NotificationEntry {
 			entries = append(entries, e)
 		}
 	}
 	return entries
 }
 
+// OperationChildren returns the entries that describe the operations that are
+// defined directly within the directory element e. The input and output
+// entries of each RPC or action are returned, along with each notification.
+func OperationChildren(e *yang.Entry) []*yang.Entry {
+	var entries []*yang.Entry
+
+	for _, ch := range e.Dir {
+		switch {
+		case ch.RPC != nil:
+			entries = append(entries, RPCInputOutput(ch)...)
+		case ch.Kind == yang.NotificationEntry:
+			entries = append(entries, ch)
+		}
+	}
+	return entries
+}
+
+// RPCInputOutput returns the input and output entries of the RPC or action
+// entry e, where they are defined. Since goyang does not store these entries
+// within the Dir of e, their Parent is set to e if it has not been populated,
+// such that their path can be determined.
+func RPCInputOutput(e *yang.Entry) []*yang.Entry {
+	if e.RPC == nil {
+		return nil
+	}
+
+	var entries []*yang.Entry
+	for _, io := range []*yang.Entry{e.RPC.Input, e.RPC.Output} {
+		if io == nil {
+			continue
+		}
+		if io.Parent == nil {
+			io.Parent = e
+		}
+		entries = append(entries, io)
+	}
+	return entries
+}
+
+// IsOperationContainer returns true if the entry e is the input or output of
+// an RPC or action, or is a notification. Such entries are not part of the
+// data tree, but are represented in the same way as a container.
+func IsOperationContainer(e *yang.Entry) bool {
+	if e == nil {
+		return false
+	}
+	switch e.Kind {
+	case yang.InputEntry, yang.OutputEntry, yang.NotificationEntry:
+		return true
+	}
+	return false
+}
+
 // SchemaTreeRoot returns the root of the schema tree, given any node in that
 // tree. It returns nil if schema is nil.
 func SchemaTreeRoot(schema *yang.Entry) *yang.Entry {
@@ -124,6 +198,16 @@ func IsLeafRef(schema *yang.Entry) bool {
 	return schema.Type.Kind == yang.Yleafref
 }
 
+// IsOrderedByUser returns true if the supplied yang.Entry represents a list
+// or leaf-list whose order is determined by the user, as per RFC7950 Section
+// 7.7.7.
+func IsOrderedByUser(e *yang.Entry) bool {
+	if e == nil || e.ListAttr == nil || e.ListAttr.OrderedBy == nil {
+		return false
+	}
+	return e.ListAttr.OrderedBy.Name == "user"
+}
+
 // IsFakeRoot reports whether the supplied yang.Entry represents the synthesised
 // root entity in the generated code.
 func IsFakeRoot(e *yang.Entry) bool {
@@ -136,6 +220,23 @@ func IsFakeRoot(e *yang.Entry) bool {
 	return false
 }
 
+// IsPresenceContainer reports whether the supplied yang.Entry represents a
+// YANG presence container, whose existence has meaning in itself, as per
+// RFC7950 Section 7.5.1. goyang stores the presence statement of every
+// container in Extra, using a nil value where it is not specified.
+func IsPresenceContainer(e *yang.Entry) bool {
+	if e == nil || !e.IsContainer() {
+		return false
+	}
+	for _, p := range e.Extra["presence"] {
+		if v, ok := p.(*yang.Value); ok && v != nil && v.Name != "" {
+			return true
+		}
+	}
+	_, ok := e.Annotation[PresenceAnnotation]
+	return ok
+}
+
 // IsKeyedList returns true if the supplied yang.Entry represents a keyed list.
 func IsKeyedList(e *yang.Entry) bool {
 	if e == nil {
@@ -187,12 +288,91 @@ func IsCompressedSchema(s *yang.Entry) bool {
 	return ok
 }
 
+// ModuleNamespaces returns a map, keyed by module name, of the XML namespaces
+// of the modules that the schema tree that s is within was generated from, as
+// stored by ygen in the ModuleNamespacesAnnotation of the root of the tree. An
+// empty map is returned if the annotation is not present.
+func ModuleNamespaces(s *yang.Entry) map[string]string {
+	return rootStringMapAnnotation(s, ModuleNamespacesAnnotation)
+}
+
+// ModulePrefixes returns a map, keyed by module prefix, of the names of the
+// modules that the schema tree that s is within was generated from, as stored
+// by ygen in the ModulePrefixesAnnotation of the root of the tree. An empty
+// map is returned if the annotation is not present.
+func ModulePrefixes(s *yang.Entry) map[string]string {
+	return rootStringMapAnnotation(s, ModulePrefixesAnnotation)
+}
+
+// EntryModule returns the name of the module that instantiates the schema
+// entry e, such that e is qualified by it in the RFC7951 JSON and RESTCONF
+// encodings. The module is that of the closest entry to e, inclusive, that
+// has a prefix, and is resolved using the ModulePrefixesAnnotation that ygen
+// stores in the root of the schema tree. Where the annotation does not
+// contain the prefix, the module is resolved from the AST node of the entry,
+// which is retained only by schemas that are parsed from YANG at runtime. An
+// error is returned if the module cannot be determined.
+func EntryModule(e *yang.Entry) (string, error) {
+	if e == nil {
+		return "", fmt.Errorf("cannot find the module of a nil entry")
+	}
+	n := e
+	for n.Prefix == nil || n.Prefix.Name == "" {
+		if n.Parent == nil {
+			return "", fmt.Errorf("cannot find the module of entry %s, no prefix is specified", e.Name)
+		}
+		n = n.Parent
+	}
+	if mod, ok := ModulePrefixes(e)[n.Prefix.Name]; ok {
+		return mod, nil
+	}
+	if n.Node == nil {
+		return "", fmt.Errorf("cannot find the module of entry %s, prefix %s is not known", e.Name, n.Prefix.Name)
+	}
+	mod, err := n.InstantiatingModule()
+	if err != nil {
+		return "", fmt.Errorf("cannot find the module of entry %s: %v", e.Name, err)
+	}
+	return mod, nil
+}
+
+// rootStringMapAnnotation returns the annotation with the supplied name of the
+// root of the schema tree that s is within, which is a map of strings.
+func rootStringMapAnnotation(s *yang.Entry, name string) map[string]string {
+	out := map[string]string{}
+	root := SchemaTreeRoot(s)
+	if root == nil {
+		return out
+	}
+	// The annotation is a map[string]interface{} when the schema has been
+	// unmarshalled from its serialised JSON form.
+	switch m := root.Annotation[name].(type) {
+	case map[string]string:
+		for k, v := range m {
+			out[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range m {
+			if str, ok := v.(string); ok {
+				out[k] = str
+			}
+		}
+	}
+	return out
+}
+
 // IsYgotAnnotation reports whether struct field s is an annotation field.
 func IsYgotAnnotation(s reflect.StructField) bool {
 	_, ok := s.Tag.Lookup("ygotAnnotation")
 	return ok
 }
 
+// IsYangPresence reports whether struct field s is a presence container, as
+// indicated by the yangPresence tag added to such fields by ygen.
+func IsYangPresence(s reflect.StructField) bool {
+	return s.Tag.Get("yangPresence") == "true"
+}
+
 // IsSimpleEnumerationType returns true when the type supplied is a simple
 // enumeration (i.e., a leaf that is defined as type enumeration { ... },
 // and is not a typedef that contains an enumeration, or a union that
@@ -406,3 +586,61 @@ func ListKeyFieldsMap(e *yang.Entry) map[string]bool {
 	}
 	return r
 }
+
+// FixYangRegexp takes a pattern regular expression from a YANG module and
+// returns it into a format which can be used by the Go regular expression
+// library. YANG uses a W3C standard that is defined to be implicitly anchored
+// at the head or tail of the expression. See
+// https://www.w3.org/TR/2004/REC-xmlschema-2-20041028/#regexs for details.
+func FixYangRegexp(pattern string) string {
+	var buf bytes.Buffer
+	var inEscape bool
+	var prevChar rune
+	addParens := false
+
+	for i, ch := range pattern {
+		if i == 0 && ch != '^' {
+			buf.WriteRune('^')
+			// Add parens around entire expression to prevent logical
+			// subexpressions associating with leading/trailing ^ / $.
+			buf.WriteRune('(')
+			addParens = true
+		}
+
+		switch ch {
+		case '$':
+			// Dollar signs need to be escaped unless they are at
+			// the end of the pattern, or are already escaped.
+			if !inEscape && i != len(pattern)-1 {
+				buf.WriteRune('\\')
+			}
+		case '^':
+			// Carets need to be escaped unless they are already
+			// escaped, indicating set negation ([^.*]) or at the
+			// start of the string.
+			if !inEscape && prevChar != '[' && i != 0 {
+				buf.WriteRune('\\')
+			}
+		}
+
+		// If the previous character was an escape character, then we
+		// leave the escape, otherwise check whether this is an escape
+		// char and if so, then enter escape.
+		inEscape = !inEscape && ch == '\\'
+
+		buf.WriteRune(ch)
+
+		if i == len(pattern)-1 {
+			if addParens {
+				buf.WriteRune(')')
+			}
+			if ch != '$' {
+				buf.WriteRune('$')
+			}
+		}
+
+		prevChar = ch
+	}
+
+	return buf.String()
+}
@@ -19,7 +19,9 @@ import (
 	"testing"
 
 	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/gnmi/errdiff"
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
 )
 
 func TestSchemaTreeRoot(t *testing.T) {
@@ -64,6 +66,116 @@ func TestSchemaTreeRoot(t *testing.T) {
 	}
 }
 
+func TestModuleNamespaces(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *yang.Entry
+		want map[string]string
+	}{{
+		name: "nil schema",
+		want: map[string]string{},
+	}, {
+		name: "no annotation",
+		in:   &yang.Entry{Name: "root"},
+		want: map[string]string{},
+	}, {
+		name: "annotation on root of child",
+		in: &yang.Entry{
+			Name: "child",
+			Parent: &yang.Entry{
+				Name: "root",
+				Annotation: map[string]interface{}{
+					ModuleNamespacesAnnotation: map[string]string{"mod-a": "urn:mod-a"},
+				},
+			},
+		},
+		want: map[string]string{"mod-a": "urn:mod-a"},
+	}, {
+		name: "annotation unmarshalled from JSON",
+		in: &yang.Entry{
+			Name: "root",
+			Annotation: map[string]interface{}{
+				ModuleNamespacesAnnotation: map[string]interface{}{"mod-a": "urn:mod-a", "mod-b": 42},
+			},
+		},
+		want: map[string]string{"mod-a": "urn:mod-a"},
+	}}
+
+	for _, tt := range tests {
+		if got := ModuleNamespaces(tt.in); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: ModuleNamespaces(%v): got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestModulePrefixes(t *testing.T) {
+	in := &yang.Entry{
+		Name: "child",
+		Parent: &yang.Entry{
+			Name: "root",
+			Annotation: map[string]interface{}{
+				ModulePrefixesAnnotation: map[string]interface{}{"a": "mod-a"},
+			},
+		},
+	}
+	want := map[string]string{"a": "mod-a"}
+	if got := ModulePrefixes(in); !reflect.DeepEqual(got, want) {
+		t.Errorf("ModulePrefixes(%v): got: %v, want: %v", in, got, want)
+	}
+}
+
+func TestEntryModule(t *testing.T) {
+	root := &yang.Entry{
+		Name: "root",
+		Annotation: map[string]interface{}{
+			ModulePrefixesAnnotation: map[string]interface{}{"a": "mod-a", "b": "mod-b"},
+		},
+	}
+	container := &yang.Entry{Name: "container", Parent: root, Prefix: &yang.Value{Name: "a"}}
+	augmented := &yang.Entry{Name: "augmented", Parent: container, Prefix: &yang.Value{Name: "b"}}
+
+	tests := []struct {
+		name             string
+		in               *yang.Entry
+		want             string
+		wantErrSubstring string
+	}{{
+		name: "entry with prefix",
+		in:   container,
+		want: "mod-a",
+	}, {
+		name: "prefix of parent",
+		in:   &yang.Entry{Name: "leaf", Parent: container},
+		want: "mod-a",
+	}, {
+		name: "entry augmented from another module",
+		in:   &yang.Entry{Name: "leaf", Parent: augmented},
+		want: "mod-b",
+	}, {
+		name:             "unknown prefix",
+		in:               &yang.Entry{Name: "leaf", Parent: root, Prefix: &yang.Value{Name: "c"}},
+		wantErrSubstring: "prefix c is not known",
+	}, {
+		name:             "no prefix",
+		in:               &yang.Entry{Name: "leaf", Parent: root},
+		wantErrSubstring: "no prefix is specified",
+	}, {
+		name:             "nil entry",
+		wantErrSubstring: "nil entry",
+	}}
+
+	for _, tt := range tests {
+		got, err := EntryModule(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: EntryModule(%v): did not get expected error, %s", tt.name, tt.in, diff)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: EntryModule(%v): got: %s, want: %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
 // TestYangHelperChecks tests a known set of input data against the helper
 // functions that check the type of a particular element in yanghelpers.go.
 func TestYangHelperChecks(t *testing.T) {
@@ -284,6 +396,18 @@ func TestYangChildren(t *testing.T) {
 			"state":  true,
 			"rpc":    false,
 		},
+	}, {
+		name: "test container with notification entry",
+		inEntry: &yang.Entry{
+			Dir: map[string]*yang.Entry{
+				"notification": {Name: "notification", Kind: yang.NotificationEntry},
+				"config":       {Name: "config"},
+			},
+		},
+		wantChildNames: map[string]bool{
+			"config":       true,
+			"notification": false,
+		},
 	}}
 
 	for _, tt := range tests {
@@ -302,6 +426,85 @@ func TestYangChildren(t *testing.T) {
 	}
 }
 
+func TestOperationChildren(t *testing.T) {
+	input := &yang.Entry{Name: "input", Kind: yang.InputEntry}
+	output := &yang.Entry{Name: "output", Kind: yang.OutputEntry}
+	actionInput := &yang.Entry{Name: "input", Kind: yang.InputEntry}
+	notification := &yang.Entry{Name: "notification", Kind: yang.NotificationEntry}
+	rpc := &yang.Entry{
+		Name: "rpc",
+		RPC:  &yang.RPCEntry{Input: input, Output: output},
+	}
+	action := &yang.Entry{
+		Name: "action",
+		RPC:  &yang.RPCEntry{Input: actionInput},
+	}
+
+	e := &yang.Entry{
+		Name: "module",
+		Dir: map[string]*yang.Entry{
+			"rpc":          rpc,
+			"action":       action,
+			"notification": notification,
+			"container":    {Name: "container", Kind: yang.DirectoryEntry},
+		},
+	}
+	rpc.Parent, action.Parent, notification.Parent = e, e, e
+
+	got := map[string]bool{}
+	for _, op := range OperationChildren(e) {
+		got[op.Path()] = true
+	}
+	want := map[string]bool{
+		"/module/rpc/input":    true,
+		"/module/rpc/output":   true,
+		"/module/action/input": true,
+		"/module/notification": true,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("OperationChildren: did not get expected entries, got: %v, want: %v", got, want)
+	}
+
+	for _, e := range []*yang.Entry{rpc, action, notification, {Name: "container"}} {
+		for _, io := range RPCInputOutput(e) {
+			if io.Parent != e {
+				t.Errorf("RPCInputOutput(%s): did not get expected parent for %s, got: %v, want: %v", e.Name, io.Name, io.Parent, e)
+			}
+		}
+	}
+}
+
+func TestIsOperationContainer(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *yang.Entry
+		want bool
+	}{{
+		desc: "nil entry",
+	}, {
+		desc: "container",
+		in:   &yang.Entry{Kind: yang.DirectoryEntry},
+	}, {
+		desc: "input",
+		in:   &yang.Entry{Kind: yang.InputEntry},
+		want: true,
+	}, {
+		desc: "output",
+		in:   &yang.Entry{Kind: yang.OutputEntry},
+		want: true,
+	}, {
+		desc: "notification",
+		in:   &yang.Entry{Kind: yang.NotificationEntry},
+		want: true,
+	}}
+
+	for _, tt := range tests {
+		if got := IsOperationContainer(tt.in); got != tt.want {
+			t.Errorf("%s: IsOperationContainer(%v): got: %v, want: %v", tt.desc, tt.in, got, tt.want)
+		}
+	}
+}
+
 // TestIsConfig tests the isConfig function to ensure that the config parameter is correctly
 // determined.
 func TestIsConfig(t *testing.T) {
@@ -937,6 +1140,41 @@ func TestIsOrNotKeyedList(t *testing.T) {
 	}
 }
 
+func TestIsOrderedByUser(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *yang.Entry
+		want bool
+	}{{
+		desc: "nil entry",
+	}, {
+		desc: "container",
+		in:   &yang.Entry{Kind: yang.DirectoryEntry},
+	}, {
+		desc: "list with no ordered-by statement",
+		in:   &yang.Entry{Kind: yang.DirectoryEntry, ListAttr: &yang.ListAttr{}},
+	}, {
+		desc: "list ordered by system",
+		in: &yang.Entry{
+			Kind:     yang.DirectoryEntry,
+			ListAttr: &yang.ListAttr{OrderedBy: &yang.Value{Name: "system"}},
+		},
+	}, {
+		desc: "list ordered by user",
+		in: &yang.Entry{
+			Kind:     yang.DirectoryEntry,
+			ListAttr: &yang.ListAttr{OrderedBy: &yang.Value{Name: "user"}},
+		},
+		want: true,
+	}}
+
+	for _, tt := range tests {
+		if got := IsOrderedByUser(tt.in); got != tt.want {
+			t.Errorf("%s: IsOrderedByUser(%v): got: %v, want: %v", tt.desc, tt.in, got, tt.want)
+		}
+	}
+}
+
 func TestIsYgotAnnotation(t *testing.T) {
 	type testStruct struct {
 		Yes *string `ygotAnnotation:"true"`
@@ -964,6 +1202,111 @@ func TestIsYgotAnnotation(t *testing.T) {
 	}
 }
 
+func TestIsYangPresence(t *testing.T) {
+	type testStruct struct {
+		Yes *struct{} `path:"a" yangPresence:"true"`
+		No  *struct{} `path:"b"`
+	}
+
+	tests := []struct {
+		name string
+		in   reflect.StructField
+		want bool
+	}{{
+		name: "presence container field",
+		in:   reflect.TypeOf(testStruct{}).Field(0),
+		want: true,
+	}, {
+		name: "standard field",
+		in:   reflect.TypeOf(testStruct{}).Field(1),
+		want: false,
+	}}
+
+	for _, tt := range tests {
+		if got := IsYangPresence(tt.in); got != tt.want {
+			t.Errorf("%s: IsYangPresence(%#v): did not get expected result, got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsPresenceContainer(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *yang.Entry
+		want bool
+	}{{
+		desc: "nil entry",
+	}, {
+		desc: "container with presence statement",
+		in: &yang.Entry{
+			Kind:  yang.DirectoryEntry,
+			Extra: map[string][]interface{}{"presence": {&yang.Value{Name: "enabled"}}},
+		},
+		want: true,
+	}, {
+		desc: "container with presence annotation",
+		in: &yang.Entry{
+			Kind:       yang.DirectoryEntry,
+			Annotation: map[string]interface{}{PresenceAnnotation: true},
+		},
+		want: true,
+	}, {
+		desc: "non-presence container",
+		in:   &yang.Entry{Kind: yang.DirectoryEntry},
+	}, {
+		desc: "container with nil presence statement",
+		in: &yang.Entry{
+			Kind:  yang.DirectoryEntry,
+			Extra: map[string][]interface{}{"presence": {(*yang.Value)(nil)}},
+		},
+	}, {
+		desc: "container with empty presence statement",
+		in: &yang.Entry{
+			Kind:  yang.DirectoryEntry,
+			Extra: map[string][]interface{}{"presence": {&yang.Value{}}},
+		},
+	}, {
+		desc: "list with presence annotation",
+		in: &yang.Entry{
+			Kind:       yang.DirectoryEntry,
+			ListAttr:   &yang.ListAttr{},
+			Annotation: map[string]interface{}{PresenceAnnotation: true},
+		},
+	}}
+
+	for _, tt := range tests {
+		if got := IsPresenceContainer(tt.in); got != tt.want {
+			t.Errorf("%s: IsPresenceContainer(%v): got: %v, want: %v", tt.desc, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsPresenceContainerFromYANG(t *testing.T) {
+	mod := yangtest.ParseModule(t, "presence-test", `
+module presence-test {
+  prefix "pt";
+  namespace "urn:pt";
+
+  container with-presence {
+    presence "the feature is enabled";
+    leaf a { type string; }
+  }
+
+  container without-presence {
+    leaf b { type string; }
+  }
+}`)
+
+	for name, want := range map[string]bool{
+		"with-presence":    true,
+		"without-presence": false,
+	} {
+		if got := IsPresenceContainer(mod.Dir[name]); got != want {
+			t.Errorf("IsPresenceContainer(%s): got: %v, want: %v", name, got, want)
+		}
+	}
+}
+
 func TestEnumeratedUnionTypes(t *testing.T) {
 	tests := []struct {
 		desc  string
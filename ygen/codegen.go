@@ -162,6 +162,23 @@ type GoOpts struct {
 	// IncludeModelData specifies whether gNMI ModelData messages should be generated
 	// in the output code.
 	IncludeModelData bool
+	// ExactDecimal64 specifies whether YANG decimal64 leaves should be
+	// represented by the ygot.Decimal64 type, which retains the exact value
+	// and precision of the leaf, rather than being mapped to float64.
+	// Decimal64 types that are within a union are always mapped to float64.
+	ExactDecimal64 bool
+	// GenerateOrderedMaps specifies whether keyed YANG lists that are
+	// "ordered-by user" should be represented by a generated ordered map
+	// type, which retains the order of the members of the list, rather than
+	// a Go map.
+	GenerateOrderedMaps bool
+	// GenerateDefaultsMethods specifies whether PopulateDefaults and
+	// TrimDefaults methods should be generated for each struct, which set
+	// unset leaves to their default value in the YANG schema, and unset
+	// leaves that are equal to their default value respectively. The
+	// methods are only generated when the JSON schema is included in the
+	// generated code.
+	GenerateDefaultsMethods bool
 }
 
 // ProtoOpts stores Protobuf specific options for the code generation library.
@@ -238,6 +255,7 @@ type GeneratedGoCode struct {
 	CommonHeader string                // CommonHeader is the header that should be used for all output Go files.
 	OneOffHeader string                // OneOffHeader defines the header that should be included in only one output Go file - such as package init statements.
 	EnumMap      string                // EnumMap is a Go map that allows the YANG string values of enumerated types to be resolved.
+	BitsMap      string                // BitsMap is a Go map that allows the YANG names of the bits of bits types to be resolved.
 	// JSONSchemaCode contains code defining a variable storing a serialised JSON schema for the
 	// generated Go structs. When deserialised it consists of a map[string]*yang.Entry. The
 	// entries are the root level yang.Entry definitions along with their corresponding
@@ -320,6 +338,8 @@ func (cg *YANGCodeGenerator) GenerateGoCode(yangFiles, includePaths []string) (*
 
 	// Store the returned schematree within the state for this code generation.
 	gogen := newGoGenState(mdef.schematree)
+	gogen.exactDecimal64 = cg.Config.GoOptions.ExactDecimal64
+	gogen.generateOrderedMaps = cg.Config.GoOptions.GenerateOrderedMaps
 
 	directoryMap, errs := gogen.buildDirectoryDefinitions(mdef.directoryEntries, cg.Config.TransformationOptions.CompressBehaviour, cg.Config.TransformationOptions.GenerateFakeRoot)
 	if errs != nil {
@@ -373,7 +393,7 @@ func (cg *YANGCodeGenerator) GenerateGoCode(yangFiles, includePaths []string) (*
 		return nil, codegenErr
 	}
 
-	enumSnippets, enumMap, errs := generateEnumCode(goEnums)
+	enumSnippets, enumMap, bitsMap, errs := generateEnumCode(goEnums)
 	if errs != nil {
 		codegenErr = util.AppendErrs(codegenErr, errs)
 	}
@@ -410,6 +430,7 @@ func (cg *YANGCodeGenerator) GenerateGoCode(yangFiles, includePaths []string) (*
 		Structs:        structSnippets,
 		Enums:          enumSnippets,
 		EnumMap:        enumMap,
+		BitsMap:        bitsMap,
 		JSONSchemaCode: jsonSchema,
 		RawJSONSchema:  rawSchema,
 		EnumTypeMap:    enumTypeMapCode,
@@ -486,7 +507,11 @@ func (dcg *DirectoryGenConfig) GetDirectoriesAndLeafTypes(yangFiles, includePath
 	return directoryMap, leafTypeMap, nil
 }
 
-func generateEnumCode(goEnums map[string]*yangEnum) ([]string, string, util.Errors) {
+// generateEnumCode generates the code for the enumerated and bits types within
+// goEnums. It returns the code snippets defining each type, along with the
+// code defining the maps used to resolve the YANG names of enumerated values
+// and bits.
+func generateEnumCode(goEnums map[string]*yangEnum) ([]string, string, string, util.Errors) {
 	// orderedEnumNames is used to get the enumerated types that have been
 	// identified in alphabetical order, such that they are returned in a
 	// deterministic order to the calling application. This ensures that
@@ -508,8 +533,23 @@ func generateEnumCode(goEnums map[string]*yangEnum) ([]string, string, util.Erro
 	// for the enumeration. The value number is an int64 which is the value
 	// of the constant that represents the enumeration type.
 	enumValueMap := map[string]map[int64]ygot.EnumDefinition{}
+	// bitsValueMap is used to store a map of the bits types that are
+	// included in the generated code. It is keyed by the name of the
+	// generated bits type, with the values being a map, keyed by the
+	// position of each bit to its name in the YANG schema.
+	bitsValueMap := map[string]map[uint32]string{}
 	errs := util.Errors{}
 	for _, enumName := range orderedEnumNames {
+		if enumNameMap[enumName].entry.Type.Kind == yang.Ybits {
+			bitsOut, err := writeGoBits(enumNameMap[enumName])
+			if err != nil {
+				errs = util.AppendErr(errs, err)
+				continue
+			}
+			enumSnippets = append(enumSnippets, bitsOut.typeDef)
+			bitsValueMap[bitsOut.name] = bitsOut.posToString
+			continue
+		}
 		enumOut, err := writeGoEnum(enumNameMap[enumName])
 		if err != nil {
 			util.AppendErr(errs, err)
@@ -526,10 +566,14 @@ func generateEnumCode(goEnums map[string]*yangEnum) ([]string, string, util.Erro
 	if err != nil {
 		util.AppendErr(errs, err)
 	}
+	bitsMap, err := generateBitsMap(bitsValueMap)
+	if err != nil {
+		errs = util.AppendErr(errs, err)
+	}
 	if len(errs) == 0 {
 		errs = nil
 	}
-	return enumSnippets, enumMap, errs
+	return enumSnippets, enumMap, bitsMap, errs
 }
 
 // GenerateProto3 generates Protobuf 3 code for the input set of YANG files.
@@ -849,7 +893,8 @@ func mappedDefinitions(yangFiles, includePaths []string, cfg *GeneratorConfig) (
 // mappableLeaf determines whether the yang.Entry e is leaf with an
 // enumerated value, such that the referenced enumerated type (enumeration or
 // identity) should have code generated for it. If it is an enumerated type
-// the leaf is returned.
+// the leaf is returned. Leaves of a bits type are also returned, such that a
+// type can be generated for the bits type.
 func mappableLeaf(e *yang.Entry) *yang.Entry {
 	if e.Type == nil {
 		// If the type of the leaf is nil, then this is not a valid
@@ -863,6 +908,10 @@ func mappableLeaf(e *yang.Entry) *yang.Entry {
 
 	var types []*yang.YangType
 	switch {
+	case e.Type.Kind == yang.Ybits && isMappableBitsType(e.Type):
+		// Handle the case that this leaf is of a bits type, or a typedef
+		// of a bits type, for which a type is generated.
+		types = append(types, e.Type)
 	case util.IsEnumeratedType(e.Type):
 		// Handle the case that this leaf is an enumeration or identityref itself.
 		// This also handles cases where the leaf is a typedef that is an enumeration
@@ -882,8 +931,9 @@ func mappableLeaf(e *yang.Entry) *yang.Entry {
 }
 
 // findMappableEntities finds the descendants of a yang.Entry (e) that should be mapped in
-// the generated code. The descendants that represent directories are appended to the dirs
-// map (keyed by the schema path). Those that represent enumerated types (identityref, enumeration,
+// the generated code. The descendants that represent directories, including the input and
+// output of RPCs and actions, and notifications, are appended to the dirs map (keyed by the
+// schema path). Those that represent enumerated types (identityref, enumeration,
 // unions containing these types, or typedefs containing these types) are appended to the
 // enums map, which is again keyed by schema path. If any child of the entry is in a module
 // defined in excludeModules, it is skipped. If compressPaths is set to true, then names are
@@ -952,6 +1002,14 @@ func findMappableEntities(e *yang.Entry, dirs map[string]*yang.Entry, enums map[
 			errs = util.AppendErr(errs, fmt.Errorf("unknown type of entry %v in findMappableEntities for %s", e.Kind, e.Path()))
 		}
 	}
+
+	// The input and output of RPCs and actions, and notifications, are not
+	// part of the data tree, but are mapped as directories such that a struct
+	// is generated to represent each of them.
+	for _, op := range util.OperationChildren(e) {
+		dirs[op.Path()] = op
+		errs = util.AppendErrs(errs, findMappableEntities(op, dirs, enums, excludeModules, compressPaths, modules))
+	}
 	return errs
 }
 
@@ -347,6 +347,53 @@ func TestFindMappableEntities(t *testing.T) {
 		wantUncompressed: map[string][]string{
 			"structs": {"container"},
 			"enums":   {"choice-case-container-leaf", "choice-case2-leaf", "direct"}},
+	}, {
+		name: "rpc and notification",
+		in: &yang.Entry{
+			Name: "module",
+			Kind: yang.DirectoryEntry,
+			Dir: map[string]*yang.Entry{
+				"reboot": {
+					Name: "reboot",
+					Kind: yang.DirectoryEntry,
+					Dir:  map[string]*yang.Entry{},
+					RPC: &yang.RPCEntry{
+						Input: &yang.Entry{
+							Name: "input",
+							Kind: yang.InputEntry,
+							Dir: map[string]*yang.Entry{
+								"method": {
+									Name: "method",
+									Type: &yang.YangType{Kind: yang.Yenum},
+								},
+							},
+						},
+						Output: &yang.Entry{
+							Name: "output",
+							Kind: yang.OutputEntry,
+							Dir:  map[string]*yang.Entry{},
+						},
+					},
+				},
+				"link-down": {
+					Name: "link-down",
+					Kind: yang.NotificationEntry,
+					Dir: map[string]*yang.Entry{
+						"counters": {
+							Name: "counters",
+							Kind: yang.DirectoryEntry,
+							Dir:  map[string]*yang.Entry{},
+						},
+					},
+				},
+			},
+		},
+		wantCompressed: map[string][]string{
+			"structs": {"input", "output", "link-down", "counters"},
+			"enums":   {"method"}},
+		wantUncompressed: map[string][]string{
+			"structs": {"input", "output", "link-down", "counters"},
+			"enums":   {"method"}},
 	}}
 
 	for _, tt := range tests {
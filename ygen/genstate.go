@@ -298,8 +298,8 @@ func (s *enumGenState) findEnumSet(entries map[string]*yang.Entry, compressPaths
 					entry: e,
 				}
 			}
-		case e.Type.Name == "enumeration":
-			// We simply want to map this enumeration into a new name. Since we do
+		case e.Type.Name == "enumeration", e.Type.Name == "bits":
+			// We simply want to map this enumeration (or bits type) into a new name. Since we do
 			// de-duplication of re-used enumerated leaves at different points in
 			// the schema (e.g., if openconfig-bgp/container/enum-A can be instantiated
 			// in two places, then we do not want to have multiple enumerated types
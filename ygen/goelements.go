@@ -30,6 +30,13 @@ const (
 	// Go code, such that an enumeration's name is of the form
 	//   <goEnumPrefix><EnumName>
 	goEnumPrefix string = "E_"
+	// goBitsPrefix is the prefix that is used for type names in the output
+	// Go code, such that a bits type's name is of the form
+	//   <goBitsPrefix><BitsName>
+	goBitsPrefix string = "B_"
+	// goDecimal64Type is the type that is used in the output Go code for
+	// YANG decimal64 leaves when exact decimal64 values are to be generated.
+	goDecimal64Type string = "ygot.Decimal64"
 )
 
 var (
@@ -99,6 +106,10 @@ type MappedType struct {
 	// derived types with constant values, and are hence not represented
 	// as pointers in the output code.
 	IsEnumeratedValue bool
+	// IsBitsValue specifies whether the NativeType that is returned is a
+	// generated bits type. Such entities are reflected as derived uint64
+	// types with a constant value for each bit.
+	IsBitsValue bool
 	// ZeroValue stores the value that should be used for the type if
 	// it is unset. This is used only in contexts where the nil pointer
 	// cannot be used, such as leaf getters.
@@ -112,7 +123,7 @@ type MappedType struct {
 // IsYgenDefinedGoType returns true if the native type of a MappedType is a type that's
 // defined by ygen's generated code.
 func IsYgenDefinedGoType(t *MappedType) bool {
-	return t.IsEnumeratedValue || len(t.UnionTypes) >= 2 || t.NativeType == ygot.BinaryTypeName || t.NativeType == ygot.EmptyTypeName
+	return t.IsEnumeratedValue || t.IsBitsValue || len(t.UnionTypes) >= 2 || t.NativeType == ygot.BinaryTypeName || t.NativeType == ygot.EmptyTypeName
 }
 
 // goGenState contains the functionality and state for generating Go names for
@@ -136,6 +147,12 @@ type goGenState struct {
 	// where two entities re-use a union that has already been created (e.g.,
 	// a leafref to a union) then it is output only once in the generated code.
 	generatedUnions map[string]bool
+	// exactDecimal64 specifies whether decimal64 leaves that are not within
+	// a union are mapped to the ygot.Decimal64 type rather than float64.
+	exactDecimal64 bool
+	// generateOrderedMaps specifies whether keyed lists that are "ordered-by
+	// user" are mapped to a generated ordered map type rather than a map.
+	generateOrderedMaps bool
 }
 
 // newGoGenState creates a new goGenState instance, initialised with the
@@ -344,7 +361,42 @@ func (s *goGenState) yangTypeToGoType(args resolveTypeArgs, compressOCPaths bool
 			DefaultValue:      defVal,
 		}, nil
 	case yang.Ydecimal64:
-		return &MappedType{NativeType: "float64", ZeroValue: goZeroValues["float64"]}, nil
+		// Decimal64 types within a union are always mapped to float64 such
+		// that the union's types are all builtin types.
+		if !s.exactDecimal64 || args.contextEntry == nil || args.contextEntry.Type == nil || args.contextEntry.Type.Kind != yang.Ydecimal64 {
+			return &MappedType{NativeType: "float64", ZeroValue: goZeroValues["float64"]}, nil
+		}
+		if defVal != nil {
+			defVal = decimal64DefaultValue(*defVal, args.yangType.FractionDigits)
+		}
+		return &MappedType{NativeType: goDecimal64Type, ZeroValue: `""`, DefaultValue: defVal}, nil
+	case yang.Ybits:
+		// Bits types are mapped to a generated type, named according to the
+		// leaf or typedef that defines them, such that each bit can be set
+		// individually. Bits types that are within a union, or that have
+		// positions that cannot be represented by the generated type, are
+		// mapped to an empty interface.
+		if args.contextEntry == nil || args.contextEntry.Type == nil || args.contextEntry.Type.Kind != yang.Ybits || !isMappableBitsType(args.yangType) {
+			return &MappedType{NativeType: "interface{}", ZeroValue: goZeroValues["interface{}"]}, nil
+		}
+		var n string
+		if util.IsYANGBaseType(args.yangType) {
+			n = s.enumGen.resolveEnumName(args.contextEntry, compressOCPaths, false)
+		} else {
+			var err error
+			if n, err = s.enumGen.resolveTypedefEnumeratedName(args.contextEntry, false); err != nil {
+				return nil, err
+			}
+		}
+		if defVal != nil {
+			defVal = bitsDefaultValue(n, *defVal)
+		}
+		return &MappedType{
+			NativeType:   fmt.Sprintf("%s%s", goBitsPrefix, n),
+			IsBitsValue:  true,
+			ZeroValue:    "0",
+			DefaultValue: defVal,
+		}, nil
 	case yang.Yleafref:
 		// This is a leafref, so we check what the type of the leaf that it
 		// references is by looking it up in the schematree.
@@ -365,8 +417,6 @@ func (s *goGenState) yangTypeToGoType(args resolveTypeArgs, compressOCPaths bool
 	default:
 		// Return an empty interface for the types that we do not currently
 		// support. Back-end validation is required for these types.
-		// TODO(robjs): Missing types currently bits. These
-		// should be added.
 		return &MappedType{NativeType: "interface{}", ZeroValue: goZeroValues["interface{}"]}, nil
 	}
 }
@@ -45,7 +45,7 @@ func TestUnionSubTypes(t *testing.T) {
 		},
 		want: []string{"string"},
 		wantMtypes: map[int]*MappedType{
-			0: {"string", nil, false, goZeroValues["string"], nil},
+			0: {"string", nil, false, false, goZeroValues["string"], nil},
 		},
 	}, {
 		name: "union of int8, string",
@@ -58,8 +58,8 @@ func TestUnionSubTypes(t *testing.T) {
 		},
 		want: []string{"int8", "string"},
 		wantMtypes: map[int]*MappedType{
-			0: {"int8", nil, false, goZeroValues["int8"], nil},
-			1: {"string", nil, false, goZeroValues["string"], nil},
+			0: {"int8", nil, false, false, goZeroValues["int8"], nil},
+			1: {"string", nil, false, false, goZeroValues["string"], nil},
 		},
 	}, {
 		name: "union of unions",
@@ -84,10 +84,10 @@ func TestUnionSubTypes(t *testing.T) {
 		},
 		want: []string{"string", "int32", "uint64", "int16"},
 		wantMtypes: map[int]*MappedType{
-			0: {"string", nil, false, goZeroValues["string"], nil},
-			1: {"int32", nil, false, goZeroValues["int32"], nil},
-			2: {"uint64", nil, false, goZeroValues["uint64"], nil},
-			3: {"int16", nil, false, goZeroValues["int16"], nil},
+			0: {"string", nil, false, false, goZeroValues["string"], nil},
+			1: {"int32", nil, false, false, goZeroValues["int32"], nil},
+			2: {"uint64", nil, false, false, goZeroValues["uint64"], nil},
+			3: {"int16", nil, false, false, goZeroValues["int16"], nil},
 		},
 	}, {
 		name: "erroneous union without context",
@@ -142,8 +142,8 @@ func TestUnionSubTypes(t *testing.T) {
 		},
 		want: []string{"E_Basemod_Id", "E_Basemod2_Id2"},
 		wantMtypes: map[int]*MappedType{
-			0: {"E_Basemod_Id", nil, true, "0", nil},
-			1: {"E_Basemod2_Id2", nil, true, "0", nil},
+			0: {"E_Basemod_Id", nil, true, false, "0", nil},
+			1: {"E_Basemod2_Id2", nil, true, false, "0", nil},
 		},
 	}, {
 		name: "union of single identityref",
@@ -241,12 +241,17 @@ func TestUnionSubTypes(t *testing.T) {
 // TestYangTypeToGoType tests the resolution of a particular YangType to the
 // corresponding Go type.
 func TestYangTypeToGoType(t *testing.T) {
+	bits := yang.NewBitfield()
+	bits.Set("UP", 0)
+	bits.Set("DOWN", 1)
+
 	tests := []struct {
 		name         string
 		in           *yang.YangType
 		ctx          *yang.Entry
 		inEntries    []*yang.Entry
 		compressPath bool
+		inExactDec64 bool
 		want         *MappedType
 		wantErr      bool
 	}{{
@@ -261,6 +266,37 @@ func TestYangTypeToGoType(t *testing.T) {
 		name: "decimal64",
 		in:   &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64"},
 		want: &MappedType{NativeType: "float64", ZeroValue: "0.0"},
+	}, {
+		name: "exact decimal64",
+		in:   &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2},
+		ctx: &yang.Entry{
+			Name: "decimal-leaf",
+			Type: &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2},
+		},
+		inExactDec64: true,
+		want:         &MappedType{NativeType: "ygot.Decimal64", ZeroValue: `""`},
+	}, {
+		name: "exact decimal64 with default",
+		in:   &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2, Default: "1.5"},
+		ctx: &yang.Entry{
+			Name: "decimal-leaf",
+			Type: &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2},
+		},
+		inExactDec64: true,
+		want:         &MappedType{NativeType: "ygot.Decimal64", ZeroValue: `""`, DefaultValue: ygot.String(`ygot.Decimal64("1.50")`)},
+	}, {
+		name: "exact decimal64 in union",
+		in:   &yang.YangType{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2},
+		ctx: &yang.Entry{
+			Name: "union-leaf",
+			Type: &yang.YangType{
+				Kind: yang.Yunion,
+				Name: "union",
+				Type: []*yang.YangType{{Kind: yang.Ydecimal64, Name: "decimal64", FractionDigits: 2}},
+			},
+		},
+		inExactDec64: true,
+		want:         &MappedType{NativeType: "float64", ZeroValue: "0.0"},
 	}, {
 		name: "binary lookup resolution",
 		in:   &yang.YangType{Kind: yang.Ybinary, Name: "binary"},
@@ -483,6 +519,81 @@ func TestYangTypeToGoType(t *testing.T) {
 			IsEnumeratedValue: true,
 			ZeroValue:         "0",
 		},
+	}, {
+		name: "bits",
+		in:   &yang.YangType{Kind: yang.Ybits, Name: "bits", Bit: bits},
+		ctx: &yang.Entry{
+			Name: "bits-leaf",
+			Type: &yang.YangType{
+				Kind: yang.Ybits,
+				Name: "bits",
+				Bit:  bits,
+			},
+			Parent: &yang.Entry{Name: "base-module"},
+			Node: &yang.Enum{
+				Parent: &yang.Module{Name: "base-module"},
+			},
+		},
+		want: &MappedType{
+			NativeType:  "B_BaseModule_BitsLeaf",
+			IsBitsValue: true,
+			ZeroValue:   "0",
+		},
+	}, {
+		name: "bits with default",
+		in:   &yang.YangType{Kind: yang.Ybits, Name: "bits", Bit: bits, Default: "UP DOWN"},
+		ctx: &yang.Entry{
+			Name: "bits-leaf",
+			Type: &yang.YangType{
+				Kind: yang.Ybits,
+				Name: "bits",
+				Bit:  bits,
+			},
+			Parent: &yang.Entry{Name: "base-module"},
+			Node: &yang.Enum{
+				Parent: &yang.Module{Name: "base-module"},
+			},
+		},
+		want: &MappedType{
+			NativeType:   "B_BaseModule_BitsLeaf",
+			IsBitsValue:  true,
+			ZeroValue:    "0",
+			DefaultValue: ygot.String("BaseModule_BitsLeaf_UP | BaseModule_BitsLeaf_DOWN"),
+		},
+	}, {
+		name: "typedef bits",
+		in:   &yang.YangType{Kind: yang.Ybits, Name: "derived-bits", Bit: bits},
+		ctx: &yang.Entry{
+			Name: "bits-leaf",
+			Type: &yang.YangType{
+				Kind: yang.Ybits,
+				Name: "derived-bits",
+				Bit:  bits,
+			},
+			Node: &yang.Enum{
+				Parent: &yang.Module{
+					Name: "base-module",
+				},
+			},
+		},
+		want: &MappedType{
+			NativeType:  "B_BaseModule_DerivedBits",
+			IsBitsValue: true,
+			ZeroValue:   "0",
+		},
+	}, {
+		name: "bits in union",
+		in:   &yang.YangType{Kind: yang.Ybits, Name: "bits", Bit: bits},
+		ctx: &yang.Entry{
+			Name: "union-leaf",
+			Type: &yang.YangType{
+				Kind: yang.Yunion,
+				Name: "union",
+				Type: []*yang.YangType{{Kind: yang.Ybits, Name: "bits", Bit: bits}},
+			},
+			Parent: &yang.Entry{Name: "base-module"},
+		},
+		want: &MappedType{NativeType: "interface{}", ZeroValue: "nil"},
 	}, {
 		name: "typedef enumeration in union as the lone type",
 		in: &yang.YangType{
@@ -717,6 +828,7 @@ func TestYangTypeToGoType(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := newGoGenState(nil)
+			s.exactDecimal64 = tt.inExactDec64
 			if tt.inEntries != nil {
 				st, err := buildSchemaTree(tt.inEntries)
 				if err != nil {
@@ -142,9 +142,10 @@ type GoStructCodeSnippet struct {
 	// the input when code generation is performed.
 	StructDef string
 	// ListKeys stores code snippets that are associated with structs that are
-	// generated to represent the keys of multi-key lists. In the case that the
-	// Go struct for which the code is being generated does not contain a list
-	// with multiple keys, this string is empty.
+	// generated to represent the keys of multi-key lists, and the ordered map
+	// types that are generated to represent "ordered-by user" lists. In the
+	// case that the Go struct for which the code is being generated does not
+	// contain such a list, this string is empty.
 	ListKeys string
 	// Methods contains code snippsets that represent functions that have the
 	// input struct as a receiver, that help the user create new entries within
@@ -242,6 +243,12 @@ type generatedGoListMethod struct {
 	Keys      []goStructField // Keys of the list that is being generated (length = 1 if the list is single keyed).
 	KeyStruct string          // KeyStruct is the name of the struct used as a key for a multi-keyed list.
 	Receiver  string          // Receiver is the name of the parent struct of the list, which is the receiver for the generated method.
+	// IsOrderedMap specifies whether the list is represented by a generated
+	// ordered map type, rather than a Go map.
+	IsOrderedMap bool
+	// KeyType is the Go type of the key of the list, which is either the
+	// type of the single key or the KeyStruct.
+	KeyType string
 }
 
 // generatedGoKeyHelper contains the fields required for generating a method
@@ -280,6 +287,37 @@ type generatedGoEnumeration struct {
 	Values map[int64]string
 }
 
+// generatedGoBits is used to represent a Go bits type to be handed to a
+// template for output.
+type generatedGoBits struct {
+	// BitsPrefix is the prefix that is used for each bit of the generated
+	// output. For example, if BitsPrefix is set to Interface_Flags, then the
+	// bit "up" is named Interface_Flags_UP. The generated type that is
+	// referred to is the BitsPrefix with a further prefix of B_.
+	BitsPrefix string
+	// Bits is a map of bit position to the name of the bit within the
+	// generated code.
+	Bits map[uint32]string
+	// Names is a map of bit position to the name of the bit within the
+	// YANG schema.
+	Names map[uint32]string
+}
+
+// goBitsCodeSnippet is used to store the generated code snippets associated
+// with a particular Go bits type (generated from a leaf of type bits, or a
+// typedef referencing a bits type).
+type goBitsCodeSnippet struct {
+	// typeDef stores the code snippet for the definition of the derived
+	// uint64 type, its methods, and the set of constants corresponding to
+	// each bit of the target YANG node.
+	typeDef string
+	// posToString is a map of the position of each bit to its name in the
+	// YANG schema.
+	posToString map[uint32]string
+	// name is the name of the bits type, used for mapping purposes.
+	name string
+}
+
 // generatedLeafGetter is used to represent the parameters required to generate a
 // getter for a leaf within the generated Go code.
 type generatedLeafGetter struct {
@@ -466,6 +504,23 @@ func (t *{{ .StructName }}) Validate(opts ...ygot.ValidationOption) error {
 	}
 	return nil
 }
+`
+
+	// goStructDefaultsTemplate takes an input generatedGoStruct, which contains
+	// a definition of a YANG schema node, and generates the methods to populate
+	// and trim the default values of the struct's leaves from it.
+	goStructDefaultsTemplate = `
+// PopulateDefaults sets each unset leaf within t that has a default value in
+// the YANG schema to its default value, including within its descendants.
+func (t *{{ .StructName }}) PopulateDefaults() error {
+	return ytypes.PopulateDefaults(SchemaTree["{{ .StructName }}"], t)
+}
+
+// TrimDefaults unsets each leaf within t whose value is equal to its default
+// value in the YANG schema, including within its descendants.
+func (t *{{ .StructName }}) TrimDefaults() error {
+	return ytypes.TrimDefaults(SchemaTree["{{ .StructName }}"], t)
+}
 `
 
 	// goContainerGetterTemplate defines a template that generates a getter function
@@ -554,6 +609,43 @@ const (
 	{{- end }}
 )
 `
+	// goBitsDefinitionTemplate takes an input generatedGoBits struct and
+	// outputs the Go code that is associated with the bits type to be
+	// generated.
+	goBitsDefinitionTemplate = `
+// B_{{ .BitsPrefix }} is a derived uint64 type which is used to represent
+// the bits node {{ .BitsPrefix }}. Each bit of the value corresponds to the
+// bit with the same position in the YANG schema, and the constants named
+// {{ .BitsPrefix }}_<bit> can be combined to specify multiple bits.
+type B_{{ .BitsPrefix }} uint64
+
+// IsYANGGoBits ensures that {{ .BitsPrefix }} implements the ygot.GoBits
+// interface. This ensures that {{ .BitsPrefix }} can be identified as a
+// mapped type for a YANG bits type.
+func (B_{{ .BitsPrefix }}) IsYANGGoBits() {}
+
+// ΛBits returns the bit lookup map associated with {{ .BitsPrefix }}.
+func (B_{{ .BitsPrefix }}) ΛBits() map[string]map[uint32]string { return ΛBits; }
+
+// Set sets each of the bits that are set in v.
+func (b *B_{{ .BitsPrefix }}) Set(v B_{{ .BitsPrefix }}) { *b |= v }
+
+// Clear clears each of the bits that are set in v.
+func (b *B_{{ .BitsPrefix }}) Clear(v B_{{ .BitsPrefix }}) { *b &^= v }
+
+// Test returns true if all of the bits that are set in v are set.
+func (b B_{{ .BitsPrefix }}) Test(v B_{{ .BitsPrefix }}) bool { return b&v == v }
+
+{{ $bitsName := .BitsPrefix -}}
+{{ $names := .Names -}}
+const (
+	{{- range $pos, $bit := .Bits }}
+	// {{ $bitsName }}_{{ $bit }} corresponds to the bit {{ index $names $pos }}, at position {{ $pos }}, of {{ $bitsName }}
+	{{ $bitsName }}_{{ $bit }} B_{{ $bitsName }} = 1 << {{ $pos }}
+	{{- end }}
+)
+`
+
 	// goNewListMemberTemplate takes an input generatedGoListMethod struct and
 	// outputs a method, using the specified receiver, that creates a new instance
 	// of a struct within a keyed YANG list, and populates the map key, and the
@@ -853,6 +945,408 @@ func (t *{{ .Receiver }}) Rename{{ .ListName }}(
 	delete(t.{{ .ListName }}, oldK)
 	return nil
 }
+`
+
+	// goOrderedMapTemplate takes an input generatedGoListMethod struct and
+	// outputs the ordered map type that is used to represent a keyed list
+	// that is "ordered-by user", along with its methods. The ordered map
+	// stores the keys of the list in order, along with a map from each key to
+	// the corresponding list member.
+	goOrderedMapTemplate = `
+// {{ .ListType }}_OrderedMap is an ordered map that represents the "ordered-by user"
+// list {{ .ListName }} of the {{ .Receiver }} struct. The order of the members
+// of the list is retained.
+type {{ .ListType }}_OrderedMap struct {
+	keys     []{{ .KeyType }}
+	valueMap map[{{ .KeyType }}]*{{ .ListType }}
+}
+
+// IsYANGOrderedList ensures that {{ .ListType }}_OrderedMap implements the
+// ygot.GoOrderedMap interface.
+func (*{{ .ListType }}_OrderedMap) IsYANGOrderedList() {}
+
+// Keys returns a copy of the keys of the list {{ .ListName }}, in order.
+func (o *{{ .ListType }}_OrderedMap) Keys() []{{ .KeyType }} {
+	if o == nil {
+		return nil
+	}
+	return append([]{{ .KeyType }}{}, o.keys...)
+}
+
+// Values returns the members of the list {{ .ListName }}, in order.
+func (o *{{ .ListType }}_OrderedMap) Values() []*{{ .ListType }} {
+	if o == nil {
+		return nil
+	}
+	var values []*{{ .ListType }}
+	for _, key := range o.keys {
+		values = append(values, o.valueMap[key])
+	}
+	return values
+}
+
+// Len returns the number of members of the list {{ .ListName }}.
+func (o *{{ .ListType }}_OrderedMap) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.keys)
+}
+
+// Get returns the member of the list {{ .ListName }} with the specified key,
+// or nil if there is no such member.
+func (o *{{ .ListType }}_OrderedMap) Get(key {{ .KeyType }}) *{{ .ListType }} {
+	if o == nil {
+		return nil
+	}
+	return o.valueMap[key]
+}
+
+// Delete deletes the member of the list {{ .ListName }} with the specified
+// key. It returns true if the member was present in the list.
+func (o *{{ .ListType }}_OrderedMap) Delete(key {{ .KeyType }}) bool {
+	i := o.index(key)
+	if i == -1 {
+		return false
+	}
+	o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	delete(o.valueMap, key)
+	return true
+}
+
+// Append appends the supplied {{ .ListType }} struct to the end of the list
+// {{ .ListName }}. If the key value(s) specified in the supplied struct
+// already exist in the list, an error is returned.
+func (o *{{ .ListType }}_OrderedMap) Append(v *{{ .ListType }}) error {
+	return o.insert(o.Len(), v)
+}
+
+// AppendNew creates a new member of the list {{ .ListName }}, populating its
+// keys from the input arguments, and appends it to the end of the list.
+func (o *{{ .ListType }}_OrderedMap) AppendNew(
+  {{- $length := len .Keys -}}
+  {{- range $i, $key := .Keys -}}
+	{{ $key.Name }} {{ $key.Type -}}
+	{{- if ne (inc $i) $length -}}, {{ end -}}
+  {{- end -}}
+  ) (*{{ .ListType }}, error) {
+	v := &{{ .ListType }}{
+		{{- range $key := .Keys }}
+		{{- if $key.IsScalarField }}
+		{{ $key.Name }}: &{{ $key.Name }},
+		{{- else }}
+		{{ $key.Name }}: {{ $key.Name }},
+		{{- end -}}
+		{{- end }}
+	}
+	if err := o.Append(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// InsertBefore inserts the supplied {{ .ListType }} struct into the list
+// {{ .ListName }} immediately before the member with the key before. An error
+// is returned if there is no such member, or if the key value(s) specified in
+// the supplied struct already exist in the list.
+func (o *{{ .ListType }}_OrderedMap) InsertBefore(before {{ .KeyType }}, v *{{ .ListType }}) error {
+	i := o.index(before)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list {{ .ListName }}", before)
+	}
+	return o.insert(i, v)
+}
+
+// InsertAfter inserts the supplied {{ .ListType }} struct into the list
+// {{ .ListName }} immediately after the member with the key after. An error
+// is returned if there is no such member, or if the key value(s) specified in
+// the supplied struct already exist in the list.
+func (o *{{ .ListType }}_OrderedMap) InsertAfter(after {{ .KeyType }}, v *{{ .ListType }}) error {
+	i := o.index(after)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list {{ .ListName }}", after)
+	}
+	return o.insert(i+1, v)
+}
+
+// Move moves the member of the list {{ .ListName }} with the specified key
+// to the position pos, where 0 is the start of the list. An error is returned
+// if there is no such member, or if pos is not a valid position in the list.
+func (o *{{ .ListType }}_OrderedMap) Move(key {{ .KeyType }}, pos int) error {
+	i := o.index(key)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list {{ .ListName }}", key)
+	}
+	if pos < 0 || pos >= len(o.keys) {
+		return fmt.Errorf("invalid position %d for list {{ .ListName }} with %d members", pos, len(o.keys))
+	}
+	o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	o.keys = append(o.keys[:pos], append([]{{ .KeyType }}{key}, o.keys[pos:]...)...)
+	return nil
+}
+
+// index returns the position of the member with the specified key in the
+// list {{ .ListName }}, or -1 if there is no such member.
+func (o *{{ .ListType }}_OrderedMap) index(key {{ .KeyType }}) int {
+	if o == nil {
+		return -1
+	}
+	for i, k := range o.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert inserts the supplied {{ .ListType }} struct at position i of the
+// list {{ .ListName }}. An error is returned if the struct is nil, if its key
+// fields are unset, or if its key value(s) already exist in the list.
+func (o *{{ .ListType }}_OrderedMap) insert(i int, v *{{ .ListType }}) error {
+	if o == nil {
+		return fmt.Errorf("cannot insert into nil list {{ .ListName }}")
+	}
+	if v == nil {
+		return fmt.Errorf("cannot insert nil member into list {{ .ListName }}")
+	}
+	{{- $listName := .ListName -}}
+	{{- range $key := .Keys -}}
+	{{- if $key.IsScalarField }}
+	if v.{{ $key.Name }} == nil {
+		return fmt.Errorf("nil value for key {{ $key.Name }} of list {{ $listName }}")
+	}
+	{{- end -}}
+	{{- end }}
+
+	{{ if ne .KeyStruct "" -}}
+	key := {{ .KeyStruct }}{
+		{{- range $key := .Keys }}
+		{{- if $key.IsScalarField -}}
+			{{ $key.Name }}: *v.{{ $key.Name }},
+		{{- else -}}
+			{{ $key.Name }}: v.{{ $key.Name }},
+		{{- end -}}
+
+		{{- end }}
+	}
+	{{- else -}}
+	{{- range $key := .Keys -}}
+		{{- if $key.IsScalarField -}}
+			key := *v.{{ $key.Name }}
+		{{- else -}}
+			key := v.{{ $key.Name }}
+		{{- end -}}
+	{{- end -}}
+	{{- end }}
+
+	if _, ok := o.valueMap[key]; ok {
+		return fmt.Errorf("duplicate key for list {{ .ListName }} %v", key)
+	}
+	if o.valueMap == nil {
+		o.valueMap = map[{{ .KeyType }}]*{{ .ListType }}{}
+	}
+	o.keys = append(o.keys[:i], append([]{{ .KeyType }}{key}, o.keys[i:]...)...)
+	o.valueMap[key] = v
+	return nil
+}
+`
+
+	// goNewOrderedListMemberTemplate takes an input generatedGoListMethod
+	// struct and outputs a method, using the specified receiver, that creates
+	// a new member of a keyed list that is represented by an ordered map, and
+	// appends it to the end of the list.
+	goNewOrderedListMemberTemplate = `
+// New{{ .ListName }} creates a new entry in the {{ .ListName }} list of the
+// {{ .Receiver}} struct, and appends it to the end of the list. The keys of
+// the list are populated from the input arguments.
+func (t *{{ .Receiver }}) New{{ .ListName }}(
+  {{- $length := len .Keys -}}
+  {{- range $i, $key := .Keys -}}
+	{{ $key.Name }} {{ $key.Type -}}
+	{{- if ne (inc $i) $length -}}, {{ end -}}
+  {{- end -}}
+  ) (*{{ .ListType }}, error){
+
+	// Initialise the list within the receiver struct if it has not already been
+	// created.
+	if t.{{ .ListName }} == nil {
+		t.{{ .ListName }} = &{{ .ListType }}_OrderedMap{}
+	}
+
+	return t.{{ .ListName }}.AppendNew(
+		{{- range $i, $key := .Keys -}}
+		{{ $key.Name }}
+		{{- if ne (inc $i) $length -}}, {{ end -}}
+		{{- end -}})
+}
+`
+
+	// goOrderedListGetterTemplate defines a template for a function that, for
+	// a particular list key, gets an existing member of a list that is
+	// represented by an ordered map.
+	goOrderedListGetterTemplate = `
+// Get{{ .ListName }} retrieves the value with the specified key from
+// the {{ .ListName }} ordered map field of {{ .Receiver }}. If the receiver is
+// nil, or the specified key is not present in the list, nil is returned such
+// that Get* methods may be safely chained.
+func (t *{{ .Receiver }}) Get{{ .ListName }}(
+  {{- $length := len .Keys -}}
+  {{- range $i, $key := .Keys -}}
+	{{ $key.Name }} {{ $key.Type -}}
+	{{- if ne (inc $i) $length -}}, {{ end -}}
+  {{- end -}}
+  ) (*{{ .ListType }}){
+
+	if t == nil {
+		return nil
+	}
+
+	{{ if ne .KeyStruct "" -}}
+	key := {{ .KeyStruct }}{
+		{{- range $key := .Keys }}
+		{{ $key.Name }}: {{ $key.Name }},
+		{{- end }}
+	}
+	{{- else -}}
+	{{- range $key := .Keys -}}
+	key := {{ $key.Name }}
+	{{- end -}}
+	{{- end }}
+
+	return t.{{ .ListName }}.Get(key)
+}
+`
+
+	// goGetOrCreateOrderedListTemplate defines a template for a function that,
+	// for a particular list key, gets an existing member of a list that is
+	// represented by an ordered map, or appends it if it doesn't exist.
+	goGetOrCreateOrderedListTemplate = `
+// GetOrCreate{{ .ListName }} retrieves the value with the specified keys from
+// the receiver {{ .Receiver }}. If the entry does not exist, then it is created
+// at the end of the list. It returns the existing or new list member.
+func (t *{{ .Receiver }}) GetOrCreate{{ .ListName }}(
+  {{- $length := len .Keys -}}
+  {{- range $i, $key := .Keys -}}
+	{{ $key.Name }} {{ $key.Type -}}
+	{{- if ne (inc $i) $length -}}, {{ end -}}
+  {{- end -}}
+  ) (*{{ .ListType }}){
+
+	{{ if ne .KeyStruct "" -}}
+	key := {{ .KeyStruct }}{
+		{{- range $key := .Keys }}
+		{{ $key.Name }}: {{ $key.Name }},
+		{{- end }}
+	}
+	{{- else -}}
+	{{- range $key := .Keys -}}
+	key := {{ $key.Name }}
+	{{- end -}}
+	{{- end }}
+
+	if v := t.{{ .ListName }}.Get(key); v != nil {
+		return v
+	}
+	// Panic if we receive an error, since we should have retrieved an existing
+	// list member. This allows chaining of GetOrCreate methods.
+	v, err := t.New{{ .ListName }}(
+		{{- range $i, $key := .Keys -}}
+		{{ $key.Name }}
+		{{- if ne (inc $i) $length -}}, {{ end -}}
+		{{- end -}})
+	if err != nil {
+		panic(fmt.Sprintf("GetOrCreate{{ .ListName }} got unexpected error: %v", err))
+	}
+	return v
+}
+`
+
+	// goDeleteOrderedListTemplate defines a template for a function that, for
+	// a particular list key, deletes an existing member of a list that is
+	// represented by an ordered map.
+	goDeleteOrderedListTemplate = `
+// Delete{{ .ListName }} deletes the value with the specified keys from
+// the receiver {{ .Receiver }}. If there is no such element, the function
+// is a no-op.
+func (t *{{ .Receiver }}) Delete{{ .ListName }}(
+  {{- $length := len .Keys -}}
+  {{- range $i, $key := .Keys -}}
+	{{ $key.Name }} {{ $key.Type -}}
+	{{- if ne (inc $i) $length -}}, {{ end -}}
+  {{- end -}}
+  ) {
+	{{ if ne .KeyStruct "" -}}
+	key := {{ .KeyStruct }}{
+		{{- range $key := .Keys }}
+		{{ $key.Name }}: {{ $key.Name }},
+		{{- end }}
+	}
+	{{- else -}}
+	{{- range $key := .Keys -}}
+	key := {{ $key.Name }}
+	{{- end -}}
+	{{- end }}
+
+	t.{{ .ListName }}.Delete(key)
+}
+`
+
+	// goOrderedListAppendTemplate defines a template for a function that takes
+	// an input list member struct, and appends it to the end of a list that is
+	// represented by an ordered map.
+	goOrderedListAppendTemplate = `
+// Append{{ .ListName }} appends the supplied {{ .ListType }} struct to the
+// end of the list {{ .ListName }} of {{ .Receiver }}. If the key value(s)
+// specified in the supplied {{ .ListType }} already exist in the list, an
+// error is returned.
+func (t *{{ .Receiver }}) Append{{ .ListName }}(v *{{ .ListType }}) error {
+	// Initialise the list within the receiver struct if it has not already been
+	// created.
+	if t.{{ .ListName }} == nil {
+		t.{{ .ListName }} = &{{ .ListType }}_OrderedMap{}
+	}
+	return t.{{ .ListName }}.Append(v)
+}
+`
+
+	// goOrderedListMemberRenameTemplate provides a template for a function
+	// which renames an entry within a list that is represented by an ordered
+	// map, retaining the position of the entry within the list.
+	goOrderedListMemberRenameTemplate = `
+// Rename{{ .ListName }} renames an entry in the list {{ .ListName }} within
+// the {{ .Receiver }} struct. The entry with key oldK is renamed to newK updating
+// the key within the value, and retaining its position in the list.
+func (t *{{ .Receiver }}) Rename{{ .ListName }}(oldK, newK {{ .KeyType }}) error {
+	if t.{{ .ListName }}.Get(newK) != nil {
+		return fmt.Errorf("key %v already exists in {{ .ListName }}", newK)
+	}
+
+	i := t.{{ .ListName }}.index(oldK)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in {{ .ListName }}", oldK)
+	}
+	e := t.{{ .ListName }}.Get(oldK)
+
+	{{- if ne .KeyStruct "" -}}
+	{{- range $key := .Keys -}}
+	{{- if $key.IsScalarField }}
+	e.{{ $key.Name }} = &newK.{{ $key.Name }}
+	{{- else }}
+	e.{{ $key.Name }} = newK.{{ $key.Name }}
+	{{- end -}}
+	{{- end -}}
+	{{ else -}}
+	{{- $key := index .Keys 0 -}}
+	{{- if $key.IsScalarField }}
+	e.{{ $key.Name }} = &newK
+	{{- else }}
+	e.{{ $key.Name }} = newK
+	{{- end -}}
+	{{- end }}
+
+	t.{{ .ListName }}.Delete(oldK)
+	return t.{{ .ListName }}.insert(i, e)
+}
 `
 
 	// goKeyMapTemplate defines the template for a function that is generated for a YANG
@@ -899,6 +1393,25 @@ var ΛEnum = map[string]map[int64]ygot.EnumDefinition{
 	},
 	{{- end }}
 }
+`
+
+	// goBitsMapTemplate provides a template to output a constant map which
+	// can be used to resolve the names of the bits of any bits type within
+	// the schema.
+	goBitsMapTemplate = `
+// ΛBits is a map, keyed by the name of the type defined for each bits type in
+// the generated Go code, which provides a mapping between the position of each
+// bit, and the string that is used to represent it in the YANG schema. The map
+// is named ΛBits in order to avoid clash with any valid YANG identifier.
+var ΛBits = map[string]map[uint32]string{
+	{{- range $bitsName, $bits := . }}
+	"B_{{ $bitsName }}": {
+		{{- range $pos, $name := $bits }}
+		{{ $pos }}: "{{ $name }}",
+		{{- end }}
+	},
+	{{- end }}
+}
 `
 
 	// goEnumTypeMapTemplate provides a template to output a constant map which
@@ -1003,11 +1516,14 @@ func (t *{{ .ParentReceiver }}) To_{{ .Name }}(i interface{}) ({{ .Name }}, erro
 		"oneoffHeader":        makeTemplate("oneoffHeader", goOneOffHeaderTemplate),
 		"struct":              makeTemplate("struct", goStructTemplate),
 		"structValidator":     makeTemplate("structValidator", goStructValidatorTemplate),
+		"structDefaults":      makeTemplate("structDefaults", goStructDefaultsTemplate),
 		"listkey":             makeTemplate("listkey", goListKeyTemplate),
 		"newListEntry":        makeTemplate("newListEntry", goNewListMemberTemplate),
 		"renameListEntry":     makeTemplate("renameListEntry", goListMemberRenameTemplate),
 		"enumDefinition":      makeTemplate("enumDefinition", goEnumDefinitionTemplate),
 		"enumMap":             makeTemplate("enumMap", goEnumMapTemplate),
+		"bitsDefinition":      makeTemplate("bitsDefinition", goBitsDefinitionTemplate),
+		"bitsMap":             makeTemplate("bitsMap", goBitsMapTemplate),
 		"schemaVar":           makeTemplate("schemaVar", schemaVarTemplate),
 		"unionHelper":         makeTemplate("unionHelper", unionHelperTemplate),
 		"unionType":           makeTemplate("unionType", unionTypeTemplate),
@@ -1021,6 +1537,13 @@ func (t *{{ .ParentReceiver }}) To_{{ .Name }}(i interface{}) ({{ .Name }}, erro
 		"getList":             makeTemplate("getList", goListGetterTemplate),
 		"getContainer":        makeTemplate("getContainer", goContainerGetterTemplate),
 		"getLeaf":             makeTemplate("getLeaf", goLeafGetterTemplate),
+		"orderedMap":          makeTemplate("orderedMap", goOrderedMapTemplate),
+		"newOrderedListEntry": makeTemplate("newOrderedListEntry", goNewOrderedListMemberTemplate),
+		"renameOrderedList":   makeTemplate("renameOrderedList", goOrderedListMemberRenameTemplate),
+		"getOrderedList":      makeTemplate("getOrderedList", goOrderedListGetterTemplate),
+		"getOrCreateOrdered":  makeTemplate("getOrCreateOrdered", goGetOrCreateOrderedListTemplate),
+		"deleteOrderedList":   makeTemplate("deleteOrderedList", goDeleteOrderedListTemplate),
+		"appendOrderedList":   makeTemplate("appendOrderedList", goOrderedListAppendTemplate),
 	}
 
 	// templateHelperFunctions specifies a set of functions that are supplied as
@@ -1423,6 +1946,12 @@ func writeGoStruct(targetStruct *Directory, goStructElements map[string]*Directo
 			tagBuf.WriteString(fmt.Sprintf(` module:"%s"`, im))
 		}
 
+		// Append a tag indicating that the field is a presence container, such
+		// that its existence is retained when it has no populated children.
+		if util.IsPresenceContainer(field) {
+			tagBuf.WriteString(` yangPresence:"true"`)
+		}
+
 		fieldDef.Tags = tagBuf.String()
 
 		// Append the generated field definition to the set of fields of the struct.
@@ -1459,12 +1988,18 @@ func writeGoStruct(targetStruct *Directory, goStructElements map[string]*Directo
 	// target entity's generated struct as a receiver.
 	var methodBuf bytes.Buffer
 	for _, method := range associatedListMethods {
-		if err := goTemplates["newListEntry"].Execute(&methodBuf, method); err != nil {
+		if method.IsOrderedMap {
+			if err := goTemplates["orderedMap"].Execute(&listkeyBuf, method); err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if err := generateNewListEntry(&methodBuf, method); err != nil {
 			errs = append(errs, err)
 		}
 
 		if goOpts.GenerateRenameMethod {
-			if err := goTemplates["renameListEntry"].Execute(&methodBuf, method); err != nil {
+			if err := generateListRename(&methodBuf, method); err != nil {
 				errs = append(errs, err)
 			}
 		}
@@ -1534,6 +2069,12 @@ func writeGoStruct(targetStruct *Directory, goStructElements map[string]*Directo
 		if err := generateEnumTypeMapAccessor(&methodBuf, structDef); err != nil {
 			errs = append(errs, err)
 		}
+
+		if goOpts.GenerateDefaultsMethods {
+			if err := generateDefaultsMethods(&methodBuf, structDef); err != nil {
+				errs = append(errs, err)
+			}
+		}
 	}
 
 	return GoStructCodeSnippet{
@@ -1566,6 +2107,28 @@ func generateValidator(buf *bytes.Buffer, structDef generatedGoStruct) error {
 	return goTemplates["structValidator"].Execute(buf, structDef)
 }
 
+// generateDefaultsMethods generates the PopulateDefaults and TrimDefaults
+// methods for structDef, which populate and trim the default values of the
+// struct using the YANG schema, and appends them to the supplied buffer.
+// Assuming structDef represents the following struct:
+//
+//   type MyStruct struct {
+//     field1 *string
+//   }
+//
+// the methods generated for the struct will be:
+//
+//   func (t *MyStruct) PopulateDefaults() error {
+//     return ytypes.PopulateDefaults(SchemaTree["MyStruct"], t)
+//   }
+//
+//   func (t *MyStruct) TrimDefaults() error {
+//     return ytypes.TrimDefaults(SchemaTree["MyStruct"], t)
+//   }
+func generateDefaultsMethods(buf *bytes.Buffer, structDef generatedGoStruct) error {
+	return goTemplates["structDefaults"].Execute(buf, structDef)
+}
+
 // goTmplFieldDetails stores a goStructField along with additional details
 // corresponding to it. It is used withAin templates that handle individual
 // fields.
@@ -1655,6 +2218,9 @@ func generateLeafGetters(buf *bytes.Buffer, leaves []*generatedLeafGetter) error
 // The generated function is written to the supplied buffer, using the method
 // argument to determine the list's characteristics in the template.
 func generateGetOrCreateList(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["getOrCreateOrdered"].Execute(buf, method)
+	}
 	return goTemplates["getOrCreateList"].Execute(buf, method)
 }
 
@@ -1663,6 +2229,9 @@ func generateGetOrCreateList(buf *bytes.Buffer, method *generatedGoListMethod) e
 // of the same form as those that are given to the GetOrCreate method generated
 // by generateGetOrCreateList.
 func generateListGetter(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["getOrderedList"].Execute(buf, method)
+	}
 	return goTemplates["getList"].Execute(buf, method)
 }
 
@@ -1671,6 +2240,9 @@ func generateListGetter(buf *bytes.Buffer, method *generatedGoListMethod) error
 // of the same form as those that are given to the GetOrCreate method generated
 // by generateGetOrCreateList.
 func generateListDelete(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["deleteOrderedList"].Execute(buf, method)
+	}
 	return goTemplates["deleteList"].Execute(buf, method)
 }
 
@@ -1682,9 +2254,32 @@ func generateListDelete(buf *bytes.Buffer, method *generatedGoListMethod) error
 // The generated function is written to the supplied buffer - using the supplied
 // method argument to determine the list's characteristics in the template.
 func generateListAppend(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["appendOrderedList"].Execute(buf, method)
+	}
 	return goTemplates["appendList"].Execute(buf, method)
 }
 
+// generateNewListEntry generates a function which creates a new member of a
+// YANG list within the generated code, populating its keys from the arguments
+// of the function. The generated function is written to the supplied buffer.
+func generateNewListEntry(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["newOrderedListEntry"].Execute(buf, method)
+	}
+	return goTemplates["newListEntry"].Execute(buf, method)
+}
+
+// generateListRename generates a function which renames a member of a YANG
+// list within the generated code, updating its keys. The generated function
+// is written to the supplied buffer.
+func generateListRename(buf *bytes.Buffer, method *generatedGoListMethod) error {
+	if method.IsOrderedMap {
+		return goTemplates["renameOrderedList"].Execute(buf, method)
+	}
+	return goTemplates["renameListEntry"].Execute(buf, method)
+}
+
 // generateGetListKey generates a function extracting the keys from a list
 // defined in the Directory s, and appends it to the supplier buffer. The
 // nameMap stores maps between the key YANG field identifiers and their Go
@@ -1764,6 +2359,8 @@ func generateGetListKey(buf *bytes.Buffer, s *Directory, nameMap map[string]*yan
 //	- If the list has multiple keys, a new struct is defined which represents the set of
 //	  leaves that make up the key. The type of the list is then a map, keyed by the new struct
 //	  type.
+//	- If the list is keyed and "ordered-by user", and the generation of ordered maps is
+//	  enabled, a pointer to the ordered map type that is generated for the list is returned.
 // In the case that the list has multiple keys, the type generated as the key of the list is returned.
 // If errors are encountered during the type generation for the list, the error is returned.
 func yangListFieldToGoType(listField *yang.Entry, listFieldName string, parent *Directory, goStructElements map[string]*Directory, gogen *goGenState) (string, *generatedGoMultiKeyListStruct, *generatedGoListMethod, error) {
@@ -1843,6 +2440,18 @@ func yangListFieldToGoType(listField *yang.Entry, listFieldName string, parent *
 		Receiver:  parent.Name,
 	}
 
+	if gogen.generateOrderedMaps && util.IsOrderedByUser(listField) {
+		// The order of the list's members is significant, so it is represented
+		// by an ordered map type that is generated for the list, rather than a
+		// Go map.
+		listMethodSpec.IsOrderedMap = true
+		listMethodSpec.KeyType = listKeyStructName
+		if listKeyStructName == "" {
+			listMethodSpec.KeyType = listKeys[0].Type
+		}
+		listType = fmt.Sprintf("*%s_OrderedMap", listName)
+	}
+
 	return listType, multiListKey, listMethodSpec, nil
 }
 
@@ -1913,6 +2522,39 @@ func writeGoEnum(inputEnum *yangEnum) (goEnumCodeSnippet, error) {
 	}, err
 }
 
+// writeGoBits takes an input yangEnum, which describes a leaf of type bits,
+// or a typedef that references a bits type, and outputs the derived type and
+// constants that are used to represent it in the generated Go code. Each bit
+// is represented by a constant that has only the bit at the same position as
+// the bit in the YANG schema set.
+func writeGoBits(inputBits *yangEnum) (goBitsCodeSnippet, error) {
+	if inputBits.entry.Type.Bit == nil {
+		return goBitsCodeSnippet{}, fmt.Errorf("bits type %s has no bits defined", inputBits.name)
+	}
+
+	bits := map[uint32]string{}
+	names := map[uint32]string{}
+	for p, n := range inputBits.entry.Type.Bit.ValueMap() {
+		if p < 0 || p > 63 {
+			return goBitsCodeSnippet{}, fmt.Errorf("bits type %s has bit %s at position %d which cannot be represented", inputBits.name, n, p)
+		}
+		bits[uint32(p)] = safeGoEnumeratedValueName(n)
+		names[uint32(p)] = n
+	}
+
+	var buf bytes.Buffer
+	err := goTemplates["bitsDefinition"].Execute(&buf, generatedGoBits{
+		BitsPrefix: inputBits.name,
+		Bits:       bits,
+		Names:      names,
+	})
+	return goBitsCodeSnippet{
+		typeDef:     buf.String(),
+		posToString: names,
+		name:        inputBits.name,
+	}, err
+}
+
 // findMapPaths takes an input field name for a parent Directory and calculates the set of schemapaths that it represents.
 // If absolutePaths is set, the paths are absolute otherwise they are relative to the parent. If
 // the input entry is a key to a list, and is of type leafref, then the corresponding target leaf's
@@ -1983,6 +2625,22 @@ func generateEnumMap(enumValues map[string]map[int64]ygot.EnumDefinition) (strin
 	return buf.String(), nil
 }
 
+// generateBitsMap outputs a map using the bitsMap template. It takes an input
+// of a map, keyed by the name of the generated bits type, of maps between the
+// position of each bit and its name within the YANG schema. The map generated
+// allows the names of the bits that are set within a bits value to be resolved.
+func generateBitsMap(bitsValues map[string]map[uint32]string) (string, error) {
+	if len(bitsValues) == 0 {
+		return "", nil
+	}
+
+	var buf bytes.Buffer
+	if err := goTemplates["bitsMap"].Execute(&buf, bitsValues); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
 // generateEnumTypeMap outputs a map using the enumTypeMap template. It takes an
 // input of a map, keyed by schema path, to the string names of the enumerated
 // types that can correspond to the schema path. The map generated allows a
@@ -2039,6 +2697,12 @@ func goLeafDefault(e *yang.Entry, t *MappedType) *string {
 		if t.IsEnumeratedValue {
 			return enumDefaultValue(t.NativeType, e.Default, goEnumPrefix)
 		}
+		if t.IsBitsValue {
+			return bitsDefaultValue(strings.TrimPrefix(t.NativeType, goBitsPrefix), e.Default)
+		}
+		if t.NativeType == goDecimal64Type && e.Type != nil {
+			return decimal64DefaultValue(e.Default, e.Type.FractionDigits)
+		}
 		return quoteDefault(&e.Default, t.NativeType)
 	}
 
@@ -1695,6 +1695,461 @@ func (t *Container) Validate(opts ...ygot.ValidationOption) error {
 // ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
 // that are included in the generated code.
 func (t *Container) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
+`,
+		},
+		wantSame: true,
+	}, {
+		name: "struct with ordered-by user single key list - ordered maps",
+		inStructToMap: &Directory{
+			Name: "Tstruct",
+			Fields: map[string]*yang.Entry{
+				"listWithKey": {
+					Name: "listWithKey",
+					ListAttr: &yang.ListAttr{
+						OrderedBy: &yang.Value{Name: "user"},
+					},
+					Key: "keyLeaf",
+					Parent: &yang.Entry{
+						Name: "tstruct",
+						Parent: &yang.Entry{
+							Name: "root-module",
+							Node: &yang.Module{
+								Name: "exmod",
+							},
+						},
+					},
+					Kind: yang.DirectoryEntry,
+					Dir: map[string]*yang.Entry{
+						"keyLeaf": {
+							Name: "keyLeaf",
+							Type: &yang.YangType{Kind: yang.Ystring},
+						},
+					},
+					Node: &yang.Leaf{Parent: &yang.Module{Name: "exmod"}},
+				},
+			},
+			Path: []string{"", "root-module", "tstruct"},
+		},
+		inMappableEntities: map[string]*Directory{
+			"/root-module/tstruct/listWithKey": {
+				Name: "ListWithKey",
+				ListAttr: &YangListAttr{
+					Keys: map[string]*MappedType{
+						"keyLeaf": {NativeType: "string"},
+					},
+				},
+				Path: []string{"", "root-module", "tstruct", "listWithKey"},
+			},
+		},
+		inUniqueDirectoryNames: map[string]string{
+			"/root-module/tstruct/listWithKey": "ListWithKey",
+		},
+		inGoOpts: GoOpts{
+			GenerateOrderedMaps:  true,
+			GenerateRenameMethod: true,
+			GenerateAppendMethod: true,
+			GenerateGetters:      true,
+			GenerateDeleteMethod: true,
+		},
+		wantCompressed: wantGoStructOut{
+			structs: `
+// Tstruct represents the /root-module/tstruct YANG schema element.
+type Tstruct struct {
+	ListWithKey	*ListWithKey_OrderedMap	` + "`" + `path:"listWithKey"` + "`" + `
+}
+
+// IsYANGGoStruct ensures that Tstruct implements the yang.GoStruct
+// interface. This allows functions that need to handle this struct to
+// identify it as being generated by ygen.
+func (*Tstruct) IsYANGGoStruct() {}
+`,
+			keys: `
+// ListWithKey_OrderedMap is an ordered map that represents the "ordered-by user"
+// list ListWithKey of the Tstruct struct. The order of the members
+// of the list is retained.
+type ListWithKey_OrderedMap struct {
+	keys     []string
+	valueMap map[string]*ListWithKey
+}
+
+// IsYANGOrderedList ensures that ListWithKey_OrderedMap implements the
+// ygot.GoOrderedMap interface.
+func (*ListWithKey_OrderedMap) IsYANGOrderedList() {}
+
+// Keys returns a copy of the keys of the list ListWithKey, in order.
+func (o *ListWithKey_OrderedMap) Keys() []string {
+	if o == nil {
+		return nil
+	}
+	return append([]string{}, o.keys...)
+}
+
+// Values returns the members of the list ListWithKey, in order.
+func (o *ListWithKey_OrderedMap) Values() []*ListWithKey {
+	if o == nil {
+		return nil
+	}
+	var values []*ListWithKey
+	for _, key := range o.keys {
+		values = append(values, o.valueMap[key])
+	}
+	return values
+}
+
+// Len returns the number of members of the list ListWithKey.
+func (o *ListWithKey_OrderedMap) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.keys)
+}
+
+// Get returns the member of the list ListWithKey with the specified key,
+// or nil if there is no such member.
+func (o *ListWithKey_OrderedMap) Get(key string) *ListWithKey {
+	if o == nil {
+		return nil
+	}
+	return o.valueMap[key]
+}
+
+// Delete deletes the member of the list ListWithKey with the specified
+// key. It returns true if the member was present in the list.
+func (o *ListWithKey_OrderedMap) Delete(key string) bool {
+	i := o.index(key)
+	if i == -1 {
+		return false
+	}
+	o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	delete(o.valueMap, key)
+	return true
+}
+
+// Append appends the supplied ListWithKey struct to the end of the list
+// ListWithKey. If the key value(s) specified in the supplied struct
+// already exist in the list, an error is returned.
+func (o *ListWithKey_OrderedMap) Append(v *ListWithKey) error {
+	return o.insert(o.Len(), v)
+}
+
+// AppendNew creates a new member of the list ListWithKey, populating its
+// keys from the input arguments, and appends it to the end of the list.
+func (o *ListWithKey_OrderedMap) AppendNew(KeyLeaf string) (*ListWithKey, error) {
+	v := &ListWithKey{
+		KeyLeaf: &KeyLeaf,
+	}
+	if err := o.Append(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// InsertBefore inserts the supplied ListWithKey struct into the list
+// ListWithKey immediately before the member with the key before. An error
+// is returned if there is no such member, or if the key value(s) specified in
+// the supplied struct already exist in the list.
+func (o *ListWithKey_OrderedMap) InsertBefore(before string, v *ListWithKey) error {
+	i := o.index(before)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list ListWithKey", before)
+	}
+	return o.insert(i, v)
+}
+
+// InsertAfter inserts the supplied ListWithKey struct into the list
+// ListWithKey immediately after the member with the key after. An error
+// is returned if there is no such member, or if the key value(s) specified in
+// the supplied struct already exist in the list.
+func (o *ListWithKey_OrderedMap) InsertAfter(after string, v *ListWithKey) error {
+	i := o.index(after)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list ListWithKey", after)
+	}
+	return o.insert(i+1, v)
+}
+
+// Move moves the member of the list ListWithKey with the specified key
+// to the position pos, where 0 is the start of the list. An error is returned
+// if there is no such member, or if pos is not a valid position in the list.
+func (o *ListWithKey_OrderedMap) Move(key string, pos int) error {
+	i := o.index(key)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in list ListWithKey", key)
+	}
+	if pos < 0 || pos >= len(o.keys) {
+		return fmt.Errorf("invalid position %d for list ListWithKey with %d members", pos, len(o.keys))
+	}
+	o.keys = append(o.keys[:i], o.keys[i+1:]...)
+	o.keys = append(o.keys[:pos], append([]string{key}, o.keys[pos:]...)...)
+	return nil
+}
+
+// index returns the position of the member with the specified key in the
+// list ListWithKey, or -1 if there is no such member.
+func (o *ListWithKey_OrderedMap) index(key string) int {
+	if o == nil {
+		return -1
+	}
+	for i, k := range o.keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert inserts the supplied ListWithKey struct at position i of the
+// list ListWithKey. An error is returned if the struct is nil, if its key
+// fields are unset, or if its key value(s) already exist in the list.
+func (o *ListWithKey_OrderedMap) insert(i int, v *ListWithKey) error {
+	if o == nil {
+		return fmt.Errorf("cannot insert into nil list ListWithKey")
+	}
+	if v == nil {
+		return fmt.Errorf("cannot insert nil member into list ListWithKey")
+	}
+	if v.KeyLeaf == nil {
+		return fmt.Errorf("nil value for key KeyLeaf of list ListWithKey")
+	}
+
+	key := *v.KeyLeaf
+
+	if _, ok := o.valueMap[key]; ok {
+		return fmt.Errorf("duplicate key for list ListWithKey %v", key)
+	}
+	if o.valueMap == nil {
+		o.valueMap = map[string]*ListWithKey{}
+	}
+	o.keys = append(o.keys[:i], append([]string{key}, o.keys[i:]...)...)
+	o.valueMap[key] = v
+	return nil
+}
+`,
+			methods: `
+// NewListWithKey creates a new entry in the ListWithKey list of the
+// Tstruct struct, and appends it to the end of the list. The keys of
+// the list are populated from the input arguments.
+func (t *Tstruct) NewListWithKey(KeyLeaf string) (*ListWithKey, error){
+
+	// Initialise the list within the receiver struct if it has not already been
+	// created.
+	if t.ListWithKey == nil {
+		t.ListWithKey = &ListWithKey_OrderedMap{}
+	}
+
+	return t.ListWithKey.AppendNew(KeyLeaf)
+}
+
+// RenameListWithKey renames an entry in the list ListWithKey within
+// the Tstruct struct. The entry with key oldK is renamed to newK updating
+// the key within the value, and retaining its position in the list.
+func (t *Tstruct) RenameListWithKey(oldK, newK string) error {
+	if t.ListWithKey.Get(newK) != nil {
+		return fmt.Errorf("key %v already exists in ListWithKey", newK)
+	}
+
+	i := t.ListWithKey.index(oldK)
+	if i == -1 {
+		return fmt.Errorf("key %v not found in ListWithKey", oldK)
+	}
+	e := t.ListWithKey.Get(oldK)
+	e.KeyLeaf = &newK
+
+	t.ListWithKey.Delete(oldK)
+	return t.ListWithKey.insert(i, e)
+}
+
+// GetOrCreateListWithKey retrieves the value with the specified keys from
+// the receiver Tstruct. If the entry does not exist, then it is created
+// at the end of the list. It returns the existing or new list member.
+func (t *Tstruct) GetOrCreateListWithKey(KeyLeaf string) (*ListWithKey){
+
+	key := KeyLeaf
+
+	if v := t.ListWithKey.Get(key); v != nil {
+		return v
+	}
+	// Panic if we receive an error, since we should have retrieved an existing
+	// list member. This allows chaining of GetOrCreate methods.
+	v, err := t.NewListWithKey(KeyLeaf)
+	if err != nil {
+		panic(fmt.Sprintf("GetOrCreateListWithKey got unexpected error: %v", err))
+	}
+	return v
+}
+
+// GetListWithKey retrieves the value with the specified key from
+// the ListWithKey ordered map field of Tstruct. If the receiver is
+// nil, or the specified key is not present in the list, nil is returned such
+// that Get* methods may be safely chained.
+func (t *Tstruct) GetListWithKey(KeyLeaf string) (*ListWithKey){
+
+	if t == nil {
+		return nil
+	}
+
+	key := KeyLeaf
+
+	return t.ListWithKey.Get(key)
+}
+
+// DeleteListWithKey deletes the value with the specified keys from
+// the receiver Tstruct. If there is no such element, the function
+// is a no-op.
+func (t *Tstruct) DeleteListWithKey(KeyLeaf string) {
+	key := KeyLeaf
+
+	t.ListWithKey.Delete(key)
+}
+
+// AppendListWithKey appends the supplied ListWithKey struct to the
+// end of the list ListWithKey of Tstruct. If the key value(s)
+// specified in the supplied ListWithKey already exist in the list, an
+// error is returned.
+func (t *Tstruct) AppendListWithKey(v *ListWithKey) error {
+	// Initialise the list within the receiver struct if it has not already been
+	// created.
+	if t.ListWithKey == nil {
+		t.ListWithKey = &ListWithKey_OrderedMap{}
+	}
+	return t.ListWithKey.Append(v)
+}
+
+// Validate validates s against the YANG schema corresponding to its type.
+func (t *Tstruct) Validate(opts ...ygot.ValidationOption) error {
+	if err := ytypes.Validate(SchemaTree["Tstruct"], t, opts...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
+// that are included in the generated code.
+func (t *Tstruct) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
+`,
+		},
+		wantSame: true,
+	}, {
+		name: "struct with defaults methods",
+		inStructToMap: &Directory{
+			Name: "Tstruct",
+			Fields: map[string]*yang.Entry{
+				"f1": {
+					Name:    "f1",
+					Type:    &yang.YangType{Kind: yang.Yint8},
+					Default: "42",
+					Parent: &yang.Entry{
+						Name: "tstruct",
+						Parent: &yang.Entry{
+							Name: "root-module",
+							Node: &yang.Module{
+								Name: "exmod",
+							},
+						},
+					},
+					Node: &yang.Leaf{
+						Name: "f1",
+						Parent: &yang.Module{
+							Name: "exmod",
+						},
+					},
+				},
+			},
+			Path: []string{"", "root-module", "tstruct"},
+		},
+		inGoOpts: GoOpts{
+			GenerateDefaultsMethods: true,
+		},
+		wantCompressed: wantGoStructOut{
+			structs: `
+// Tstruct represents the /root-module/tstruct YANG schema element.
+type Tstruct struct {
+	F1	*int8	` + "`" + `path:"f1"` + "`" + `
+}
+
+// IsYANGGoStruct ensures that Tstruct implements the yang.GoStruct
+// interface. This allows functions that need to handle this struct to
+// identify it as being generated by ygen.
+func (*Tstruct) IsYANGGoStruct() {}
+`,
+			methods: `
+// Validate validates s against the YANG schema corresponding to its type.
+func (t *Tstruct) Validate(opts ...ygot.ValidationOption) error {
+	if err := ytypes.Validate(SchemaTree["Tstruct"], t, opts...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
+// that are included in the generated code.
+func (t *Tstruct) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
+
+// PopulateDefaults sets each unset leaf within t that has a default value in
+// the YANG schema to its default value, including within its descendants.
+func (t *Tstruct) PopulateDefaults() error {
+	return ytypes.PopulateDefaults(SchemaTree["Tstruct"], t)
+}
+
+// TrimDefaults unsets each leaf within t whose value is equal to its default
+// value in the YANG schema, including within its descendants.
+func (t *Tstruct) TrimDefaults() error {
+	return ytypes.TrimDefaults(SchemaTree["Tstruct"], t)
+}
+`,
+		},
+		wantSame: true,
+	}, {
+		name: "struct with child presence container",
+		inStructToMap: &Directory{
+			Name: "InputStruct",
+			Fields: map[string]*yang.Entry{
+				"c1": {
+					Name: "c1",
+					Dir:  map[string]*yang.Entry{},
+					Kind: yang.DirectoryEntry,
+					Extra: map[string][]interface{}{
+						"presence": {&yang.Value{Name: "enables c1"}},
+					},
+					Parent: &yang.Entry{
+						Name: "input-struct",
+						Parent: &yang.Entry{
+							Name: "root-module",
+							Node: &yang.Module{
+								Name: "exmod",
+							},
+						},
+					},
+					Node: &yang.Leaf{Parent: &yang.Module{Name: "exmod"}},
+				},
+			},
+			Path: []string{"", "root-module", "input-struct"},
+		},
+		inUniqueDirectoryNames: map[string]string{"/root-module/input-struct/c1": "InputStruct_C1"},
+		wantCompressed: wantGoStructOut{
+			structs: `
+// InputStruct represents the /root-module/input-struct YANG schema element.
+type InputStruct struct {
+	C1	*InputStruct_C1	` + "`" + `path:"c1" yangPresence:"true"` + "`" + `
+}
+
+// IsYANGGoStruct ensures that InputStruct implements the yang.GoStruct
+// interface. This allows functions that need to handle this struct to
+// identify it as being generated by ygen.
+func (*InputStruct) IsYANGGoStruct() {}
+`,
+			methods: `
+// Validate validates s against the YANG schema corresponding to its type.
+func (t *InputStruct) Validate(opts ...ygot.ValidationOption) error {
+	if err := ytypes.Validate(SchemaTree["InputStruct"], t, opts...); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ΛEnumTypeMap returns a map, keyed by YANG schema path, of the enumerated types
+// that are included in the generated code.
+func (t *InputStruct) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTypes }
 `,
 		},
 		wantSame: true,
@@ -1708,6 +2163,7 @@ func (t *Container) ΛEnumTypeMap() map[string][]reflect.Type { return ΛEnumTyp
 			for compressed, want := range map[bool]wantGoStructOut{true: tt.wantCompressed, false: tt.wantUncompressed} {
 				s := newGoGenState(nil)
 				s.uniqueDirectoryNames = tt.inUniqueDirectoryNames
+				s.generateOrderedMaps = tt.inGoOpts.GenerateOrderedMaps
 
 				// Always generate the JSON schema for this test.
 				got, errs := writeGoStruct(tt.inStructToMap, tt.inMappableEntities, s, compressed, true, tt.inGoOpts)
@@ -1948,6 +2404,115 @@ const (
 	}
 }
 
+// TestGoCodeBitsGeneration tests the output of writeGoBits for bits types.
+func TestGoCodeBitsGeneration(t *testing.T) {
+	bitfield := func(bits map[string]int64) *yang.EnumType {
+		b := yang.NewBitfield()
+		for n, p := range bits {
+			if err := b.Set(n, p); err != nil {
+				t.Fatalf("cannot set bit %s at position %d: %v", n, p, err)
+			}
+		}
+		return b
+	}
+
+	tests := []struct {
+		name    string
+		in      *yangEnum
+		want    goBitsCodeSnippet
+		wantErr bool
+	}{{
+		name: "bits leaf",
+		in: &yangEnum{
+			name: "Interface_Flags",
+			entry: &yang.Entry{
+				Name: "flags",
+				Type: &yang.YangType{
+					Kind: yang.Ybits,
+					Bit:  bitfield(map[string]int64{"up": 0, "SPEED-40G": 3}),
+				},
+			},
+		},
+		want: goBitsCodeSnippet{
+			typeDef: `
+// B_Interface_Flags is a derived uint64 type which is used to represent
+// the bits node Interface_Flags. Each bit of the value corresponds to the
+// bit with the same position in the YANG schema, and the constants named
+// Interface_Flags_<bit> can be combined to specify multiple bits.
+type B_Interface_Flags uint64
+
+// IsYANGGoBits ensures that Interface_Flags implements the ygot.GoBits
+// interface. This ensures that Interface_Flags can be identified as a
+// mapped type for a YANG bits type.
+func (B_Interface_Flags) IsYANGGoBits() {}
+
+// ΛBits returns the bit lookup map associated with Interface_Flags.
+func (B_Interface_Flags) ΛBits() map[string]map[uint32]string { return ΛBits; }
+
+// Set sets each of the bits that are set in v.
+func (b *B_Interface_Flags) Set(v B_Interface_Flags) { *b |= v }
+
+// Clear clears each of the bits that are set in v.
+func (b *B_Interface_Flags) Clear(v B_Interface_Flags) { *b &^= v }
+
+// Test returns true if all of the bits that are set in v are set.
+func (b B_Interface_Flags) Test(v B_Interface_Flags) bool { return b&v == v }
+
+const (
+	// Interface_Flags_up corresponds to the bit up, at position 0, of Interface_Flags
+	Interface_Flags_up B_Interface_Flags = 1 << 0
+	// Interface_Flags_SPEED_40G corresponds to the bit SPEED-40G, at position 3, of Interface_Flags
+	Interface_Flags_SPEED_40G B_Interface_Flags = 1 << 3
+)
+`,
+			posToString: map[uint32]string{0: "up", 3: "SPEED-40G"},
+			name:        "Interface_Flags",
+		},
+	}, {
+		name: "bit position too large",
+		in: &yangEnum{
+			name: "Interface_Flags",
+			entry: &yang.Entry{
+				Name: "flags",
+				Type: &yang.YangType{
+					Kind: yang.Ybits,
+					Bit:  bitfield(map[string]int64{"up": 0, "far": 64}),
+				},
+			},
+		},
+		wantErr: true,
+	}, {
+		name: "no bits",
+		in: &yangEnum{
+			name: "Interface_Flags",
+			entry: &yang.Entry{
+				Name: "flags",
+				Type: &yang.YangType{Kind: yang.Ybits},
+			},
+		},
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		got, err := writeGoBits(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: writeGoBits(%v): got unexpected error: %v, want error: %v", tt.name, tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			if diffl, err := testutil.GenerateUnifiedDiff(got.typeDef, tt.want.typeDef); err == nil {
+				diff = diffl
+			}
+			t.Errorf("%s: writeGoBits(%v): got incorrect output, diff(-got,+want):\n%s",
+				tt.name, tt.in, diff)
+		}
+	}
+}
+
 // TestFindMapPaths ensures that the schema paths that an entity should be
 // mapped to are properly extracted from a schema element.
 func TestFindMapPaths(t *testing.T) {
@@ -2214,6 +2779,54 @@ var ΛEnum = map[string]map[int64]ygot.EnumDefinition{
 	}
 }
 
+func TestGenerateBitsMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		inMap   map[string]map[uint32]string
+		wantMap string
+	}{{
+		name: "bits types",
+		inMap: map[string]map[uint32]string{
+			"BitsOne": {0: "up", 3: "down"},
+			"BitsTwo": {1: "b1"},
+		},
+		wantMap: `
+// ΛBits is a map, keyed by the name of the type defined for each bits type in
+// the generated Go code, which provides a mapping between the position of each
+// bit, and the string that is used to represent it in the YANG schema. The map
+// is named ΛBits in order to avoid clash with any valid YANG identifier.
+var ΛBits = map[string]map[uint32]string{
+	"B_BitsOne": {
+		0: "up",
+		3: "down",
+	},
+	"B_BitsTwo": {
+		1: "b1",
+	},
+}
+`,
+	}, {
+		name:  "no bits types",
+		inMap: map[string]map[uint32]string{},
+	}}
+
+	for _, tt := range tests {
+		got, err := generateBitsMap(tt.inMap)
+		if err != nil {
+			t.Errorf("%s: got unexpected error when generating map: %v", tt.name, err)
+			continue
+		}
+
+		if tt.wantMap != got {
+			diff := fmt.Sprintf("got: %s, want %s", got, tt.wantMap)
+			if diffl, err := testutil.GenerateUnifiedDiff(got, tt.wantMap); err == nil {
+				diff = "diff (-got, +want):\n" + diffl
+			}
+			t.Errorf("%s: did not get expected generated bits map, %s", tt.name, diff)
+		}
+	}
+}
+
 func TestGoLeafDefault(t *testing.T) {
 	tests := []struct {
 		name   string
@@ -2251,6 +2864,27 @@ func TestGoLeafDefault(t *testing.T) {
 			IsEnumeratedValue: true,
 		},
 		want: ygot.String("EnumType_FORTY_TWO"),
+	}, {
+		name:   "bits default in leaf",
+		inLeaf: &yang.Entry{Default: "UP SPEED-40G"},
+		inType: &MappedType{
+			NativeType:  fmt.Sprintf("%sBitsType", goBitsPrefix),
+			IsBitsValue: true,
+		},
+		want: ygot.String("BitsType_UP | BitsType_SPEED_40G"),
+	}, {
+		name: "exact decimal64 default in leaf",
+		inLeaf: &yang.Entry{
+			Default: "-2.5",
+			Type:    &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 3},
+		},
+		inType: &MappedType{NativeType: goDecimal64Type},
+		want:   ygot.String(`ygot.Decimal64("-2.500")`),
+	}, {
+		name:   "exact decimal64 default in type",
+		inLeaf: &yang.Entry{},
+		inType: &MappedType{NativeType: goDecimal64Type, DefaultValue: ygot.String(`ygot.Decimal64("1.0")`)},
+		want:   ygot.String(`ygot.Decimal64("1.0")`),
 	}}
 
 	// Define a helper to print string pointers in a more useful way during test output.
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/ygot"
 )
 
@@ -81,6 +82,50 @@ func enumDefaultValue(baseName, defVal, prefix string) *string {
 	return ygot.String(fmt.Sprintf("%s_%s", baseName, defVal))
 }
 
+// bitsDefaultValue returns the default value of a bits type, whose generated
+// name, without its type prefix, is baseName, and whose default value within
+// the YANG schema is the space-separated set of bit names within defVal. The
+// default is returned as an expression that sets each named bit, in the form
+// <baseName>_<bit> | <baseName>_<bit>, or 0 if no bits are named.
+func bitsDefaultValue(baseName, defVal string) *string {
+	var bits []string
+	for _, b := range strings.Fields(defVal) {
+		bits = append(bits, fmt.Sprintf("%s_%s", baseName, safeGoEnumeratedValueName(b)))
+	}
+	if len(bits) == 0 {
+		return ygot.String("0")
+	}
+	return ygot.String(strings.Join(bits, " | "))
+}
+
+// decimal64DefaultValue returns the default value of a decimal64 type that is
+// mapped to ygot.Decimal64, whose default value in the YANG schema is defVal,
+// and which has the specified number of fraction digits. The default is
+// returned as a ygot.Decimal64 literal with exactly fractionDigits digits after
+// the decimal point, or nil if defVal cannot be represented exactly.
+func decimal64DefaultValue(defVal string, fractionDigits int) *string {
+	d, err := ygot.ParseDecimal64(defVal, uint8(fractionDigits))
+	if err != nil {
+		return nil
+	}
+	return ygot.String(fmt.Sprintf("%s(%q)", goDecimal64Type, d))
+}
+
+// isMappableBitsType returns true if the bits type t can be represented by
+// the type that is generated for it, i.e., if all of the bit positions within
+// t are less than 64.
+func isMappableBitsType(t *yang.YangType) bool {
+	if t == nil || t.Bit == nil {
+		return false
+	}
+	for p := range t.Bit.ValueMap() {
+		if p < 0 || p > 63 {
+			return false
+		}
+	}
+	return true
+}
+
 // resolveRootName resolves the name of the fakeroot by taking configuration
 // and the default values, along with a boolean indicating whether the fake
 // root is to be generated. It returns an empty string if the root is not
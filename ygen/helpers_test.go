@@ -15,7 +15,11 @@
 package ygen
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // TestSafeGoEnumeratedValueName tests the safeGoEnumeratedValue function to ensure
@@ -75,3 +79,103 @@ func TestResolveRootName(t *testing.T) {
 		}
 	}
 }
+
+func TestBitsDefaultValue(t *testing.T) {
+	tests := []struct {
+		name       string
+		inBaseName string
+		inDefault  string
+		want       string
+	}{{
+		name:       "single bit",
+		inBaseName: "Module_Flags",
+		inDefault:  "up",
+		want:       "Module_Flags_up",
+	}, {
+		name:       "multiple bits",
+		inBaseName: "Module_Flags",
+		inDefault:  "UP  SPEED-40G",
+		want:       "Module_Flags_UP | Module_Flags_SPEED_40G",
+	}, {
+		name:       "no bits",
+		inBaseName: "Module_Flags",
+		inDefault:  "",
+		want:       "0",
+	}}
+
+	for _, tt := range tests {
+		if got := bitsDefaultValue(tt.inBaseName, tt.inDefault); *got != tt.want {
+			t.Errorf("%s: bitsDefaultValue(%s, %s): got: %s, want: %s", tt.name, tt.inBaseName, tt.inDefault, *got, tt.want)
+		}
+	}
+}
+
+func TestDecimal64DefaultValue(t *testing.T) {
+	tests := []struct {
+		name             string
+		inDefault        string
+		inFractionDigits int
+		want             *string
+	}{{
+		name:             "default with fewer fraction digits",
+		inDefault:        "1.5",
+		inFractionDigits: 3,
+		want:             ygot.String(`ygot.Decimal64("1.500")`),
+	}, {
+		name:             "default with precision that cannot be represented as float64",
+		inDefault:        "0.300000000000000001",
+		inFractionDigits: 18,
+		want:             ygot.String(`ygot.Decimal64("0.300000000000000001")`),
+	}, {
+		name:             "default with too many fraction digits",
+		inDefault:        "1.55",
+		inFractionDigits: 1,
+	}, {
+		name:             "invalid default",
+		inDefault:        "one",
+		inFractionDigits: 1,
+	}}
+
+	for _, tt := range tests {
+		got := decimal64DefaultValue(tt.inDefault, tt.inFractionDigits)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: decimal64DefaultValue(%s, %d): got: %v, want: %v", tt.name, tt.inDefault, tt.inFractionDigits, got, tt.want)
+		}
+	}
+}
+
+func TestIsMappableBitsType(t *testing.T) {
+	bitfield := func(bits map[string]int64) *yang.EnumType {
+		b := yang.NewBitfield()
+		for n, p := range bits {
+			if err := b.Set(n, p); err != nil {
+				t.Fatalf("cannot set bit %s at position %d: %v", n, p, err)
+			}
+		}
+		return b
+	}
+
+	tests := []struct {
+		name string
+		in   *yang.YangType
+		want bool
+	}{{
+		name: "valid bits",
+		in:   &yang.YangType{Kind: yang.Ybits, Bit: bitfield(map[string]int64{"ZERO": 0, "SIXTY_THREE": 63})},
+		want: true,
+	}, {
+		name: "bit position too large",
+		in:   &yang.YangType{Kind: yang.Ybits, Bit: bitfield(map[string]int64{"ZERO": 0, "SIXTY_FOUR": 64})},
+	}, {
+		name: "no bits",
+		in:   &yang.YangType{Kind: yang.Ybits},
+	}, {
+		name: "nil type",
+	}}
+
+	for _, tt := range tests {
+		if got := isMappableBitsType(tt.in); got != tt.want {
+			t.Errorf("%s: isMappableBitsType(%v): got: %v, want: %v", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -133,6 +133,13 @@ func (s *protoGenState) yangTypeToProtoType(args resolveTypeArgs, pargs resolveP
 		return &MappedType{NativeType: "ywrapper.StringValue"}, nil
 	case yang.Ydecimal64:
 		return &MappedType{NativeType: "ywrapper.Decimal64Value"}, nil
+	case yang.Ybits:
+		// Bits are represented as a bitmask, where each bit of the value
+		// corresponds to the bit with the same position in the YANG schema.
+		if !isMappableBitsType(args.yangType) {
+			return nil, fmt.Errorf("cannot map bits type %s, positions must be between 0 and 63", args.yangType.Name)
+		}
+		return &MappedType{NativeType: "ywrapper.UintValue"}, nil
 	case yang.Yleafref:
 		// We look up the leafref in the schema tree to be able to
 		// determine what type to map to.
@@ -169,7 +176,6 @@ func (s *protoGenState) yangTypeToProtoType(args resolveTypeArgs, pargs resolveP
 		// TODO(robjs): Implement types that are missing within this function.
 		// Missing types are:
 		//  - binary
-		//  - bits
 		// We cannot return an interface{} in protobuf, so therefore
 		// we just throw an error with types that we cannot map.
 		return nil, fmt.Errorf("unimplemented type: %v", args.yangType.Kind)
@@ -205,6 +211,11 @@ func (s *protoGenState) yangTypeToProtoScalarType(args resolveTypeArgs, pargs re
 		// Decimal64 continues to be a message even when we are mapping scalars
 		// as there is not an equivalent Protobuf type.
 		return &MappedType{NativeType: "ywrapper.Decimal64Value"}, nil
+	case yang.Ybits:
+		if !isMappableBitsType(args.yangType) {
+			return nil, fmt.Errorf("cannot map bits type %s, positions must be between 0 and 63", args.yangType.Name)
+		}
+		return &MappedType{NativeType: "uint64"}, nil
 	case yang.Yleafref:
 		target, err := s.schematree.resolveLeafrefTarget(args.yangType.Path, args.contextEntry)
 		if err != nil {
@@ -236,7 +247,6 @@ func (s *protoGenState) yangTypeToProtoScalarType(args resolveTypeArgs, pargs re
 	default:
 		// TODO(robjs): implement missing types.
 		//	- binary
-		//	- bits
 		return nil, fmt.Errorf("unimplemented type in scalar generation: %s", args.yangType.Kind)
 	}
 }
@@ -22,6 +22,10 @@ import (
 )
 
 func TestYangTypeToProtoType(t *testing.T) {
+	bits := yang.NewBitfield()
+	bits.Set("UP", 0)
+	bits.Set("FAR", 64)
+
 	tests := []struct {
 		name                   string
 		in                     []resolveTypeArgs
@@ -95,6 +99,15 @@ func TestYangTypeToProtoType(t *testing.T) {
 		in:          []resolveTypeArgs{{yangType: &yang.YangType{Kind: yang.Ydecimal64}}},
 		wantWrapper: &MappedType{NativeType: "ywrapper.Decimal64Value"},
 		wantSame:    true,
+	}, {
+		name:        "bits",
+		in:          []resolveTypeArgs{{yangType: &yang.YangType{Kind: yang.Ybits, Bit: yang.NewBitfield()}}},
+		wantWrapper: &MappedType{NativeType: "ywrapper.UintValue"},
+		wantScalar:  &MappedType{NativeType: "uint64"},
+	}, {
+		name:    "bits with position that cannot be mapped",
+		in:      []resolveTypeArgs{{yangType: &yang.YangType{Kind: yang.Ybits, Bit: bits}}},
+		wantErr: true,
 	}, {
 		name: "unmapped types",
 		in: []resolveTypeArgs{
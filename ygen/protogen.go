@@ -355,7 +355,8 @@ func writeProto3Msg(msg *Directory, msgs map[string]*Directory, protogen *protoG
 // created. The compressPaths argument specifies whether path compression is enabled.
 // Valid messages are those that are direct children of a module, or become a direct
 // child when path compression is enabled (i.e., lists that have their parent
-// surrounding container removed).
+// surrounding container removed). The input and output of RPCs and actions are
+// not the child of any other message, and hence are also valid messages.
 func outputNestedMessage(msg *Directory, compressPaths bool) bool {
 	// If path compression is enabled, and this entry is a list, then its top-level
 	// parent will have been removed, therefore this is a valid message. The path
@@ -365,9 +366,19 @@ func outputNestedMessage(msg *Directory, compressPaths bool) bool {
 		return true
 	}
 
+	if isRPCInputOutput(msg.Entry) {
+		return true
+	}
+
 	return msg.isChildOfModule()
 }
 
+// isRPCInputOutput determines whether the supplied yang.Entry is the input or
+// output of an RPC or action.
+func isRPCInputOutput(e *yang.Entry) bool {
+	return e.Kind == yang.InputEntry || e.Kind == yang.OutputEntry
+}
+
 // writeProto3MsgNested returns a nested set of protobuf messages for the message
 // supplied, which is expected to be a top-level message that code generation is
 // being performed for. It takes:
@@ -462,8 +473,11 @@ func protobufPackageForMsg(msg *Directory, protogen *protoGenState, compressPath
 	e := msg.Entry
 	// If we have nested messages enabled, the protobuf package name is defined
 	// based on the top-level message within the schema tree that is created -
-	// we therefore need to derive the name of this message.
-	if nestedMessages {
+	// we therefore need to derive the name of this message. The input and
+	// output of an RPC or action are top-level messages that are always within
+	// the package of the RPC or action, such that their names do not collide
+	// with those of other RPCs.
+	if nestedMessages && !isRPCInputOutput(e) {
 		if compressPaths {
 			if e.Parent.Parent == nil {
 				// In the special case that the grandparent of this entry is nil, and
@@ -822,8 +836,9 @@ func writeProtoEnums(enums map[string]*yangEnum, annotateEnumNames bool) ([]stri
 		// global enumerations. Additional logic is required to determine the provenance
 		// of such an enum, since we do not store that it was extracted from a union
 		// within the type (or entry) currently.
-		if util.IsSimpleEnumerationType(enum.entry.Type) || enum.entry.Type.Kind == yang.Yunion {
-			// Skip simple enumerations and those within unions.
+		if util.IsSimpleEnumerationType(enum.entry.Type) || enum.entry.Type.Kind == yang.Yunion || enum.entry.Type.Kind == yang.Ybits {
+			// Skip simple enumerations, those within unions, and bits types
+			// which are represented as a bitmask.
 			continue
 		}
 
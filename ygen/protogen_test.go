@@ -1983,3 +1983,107 @@ func TestStripPackagePrefix(t *testing.T) {
 	}
 
 }
+
+func TestNestedRPCMessages(t *testing.T) {
+	module := &yang.Entry{
+		Name: "module",
+		Kind: yang.DirectoryEntry,
+		Dir:  map[string]*yang.Entry{},
+	}
+	container := &yang.Entry{
+		Name:   "container",
+		Kind:   yang.DirectoryEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: module,
+	}
+	rpc := &yang.Entry{
+		Name:   "reboot",
+		Kind:   yang.DirectoryEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: module,
+	}
+	action := &yang.Entry{
+		Name:   "reset",
+		Kind:   yang.DirectoryEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: container,
+	}
+	rpcInput := &yang.Entry{
+		Name:   "input",
+		Kind:   yang.InputEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: rpc,
+	}
+	actionOutput := &yang.Entry{
+		Name:   "output",
+		Kind:   yang.OutputEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: action,
+	}
+	childContainer := &yang.Entry{
+		Name:   "child",
+		Kind:   yang.DirectoryEntry,
+		Dir:    map[string]*yang.Entry{},
+		Parent: container,
+	}
+
+	tests := []struct {
+		name        string
+		inMsg       *Directory
+		inCompress  bool
+		wantOutput  bool
+		wantPackage string
+	}{{
+		name: "rpc input",
+		inMsg: &Directory{
+			Name:  "Input",
+			Entry: rpcInput,
+			Path:  []string{"", "module", "reboot", "input"},
+		},
+		wantOutput:  true,
+		wantPackage: "module.reboot",
+	}, {
+		name: "rpc input with compression",
+		inMsg: &Directory{
+			Name:  "Input",
+			Entry: rpcInput,
+			Path:  []string{"", "module", "reboot", "input"},
+		},
+		inCompress:  true,
+		wantOutput:  true,
+		wantPackage: "reboot",
+	}, {
+		name: "action output",
+		inMsg: &Directory{
+			Name:  "Output",
+			Entry: actionOutput,
+			Path:  []string{"", "module", "container", "reset", "output"},
+		},
+		wantOutput:  true,
+		wantPackage: "module.container.reset",
+	}, {
+		name: "nested container",
+		inMsg: &Directory{
+			Name:  "Child",
+			Entry: childContainer,
+			Path:  []string{"", "module", "container", "child"},
+		},
+		wantOutput:  false,
+		wantPackage: "module",
+	}}
+
+	for _, tt := range tests {
+		if got := outputNestedMessage(tt.inMsg, tt.inCompress); got != tt.wantOutput {
+			t.Errorf("%s: outputNestedMessage(%v, %v): got: %v, want: %v", tt.name, tt.inMsg, tt.inCompress, got, tt.wantOutput)
+		}
+
+		got, err := protobufPackageForMsg(tt.inMsg, newProtoGenState(nil), tt.inCompress, true)
+		if err != nil {
+			t.Errorf("%s: protobufPackageForMsg(%v, %v): got unexpected error: %v", tt.name, tt.inMsg, tt.inCompress, err)
+			continue
+		}
+		if got != tt.wantPackage {
+			t.Errorf("%s: protobufPackageForMsg(%v, %v): did not get expected package, got: %s, want: %s", tt.name, tt.inMsg, tt.inCompress, got, tt.wantPackage)
+		}
+	}
+}
@@ -34,16 +34,28 @@ import (
 // root-level enties (and their subtrees) within the input module set. All
 // YANG directories are annotated in the output JSON with the name of the type
 // they correspond to in the generated code, and the absolute schema path that
-// the entry corresponds to. In the case that the fake root struct that is provided
-// is nil, a synthetic root entry is used to store the schema tree.
+// the entry corresponds to. The root entry is annotated with the XML namespace
+// and prefix of each module. In the case that the fake root struct that is
+// provided is nil, a synthetic root entry is used to store the schema tree.
 func buildJSONTree(ms []*yang.Entry, dn map[string]string, fakeroot *yang.Entry, compressed bool) ([]byte, error) {
 	rootEntry := &yang.Entry{
 		Dir:        map[string]*yang.Entry{},
 		Annotation: map[string]interface{}{},
 	}
+	// Record the XML namespace and prefix of each module, such that the
+	// schema can be used to serialise and deserialise XML.
+	namespaces, prefixes := map[string]string{}, map[string]string{}
 	for _, m := range ms {
+		if ns := m.Namespace(); ns != nil && ns.Name != "" {
+			namespaces[m.Name] = ns.Name
+		}
+		if mod, ok := m.Node.(*yang.Module); ok && mod.GetPrefix() != "" {
+			prefixes[mod.GetPrefix()] = m.Name
+		}
 		annotateChildren(m, dn)
-		for _, ch := range util.Children(m) {
+		// RPCs and notifications are included such that the structs that are
+		// generated for them have a corresponding schema entry.
+		for _, ch := range m.Dir {
 			if _, ex := rootEntry.Dir[ch.Name]; ex {
 				return nil, fmt.Errorf("overlapping root children for key %s", ch.Name)
 			}
@@ -66,6 +78,13 @@ func buildJSONTree(ms []*yang.Entry, dn map[string]string, fakeroot *yang.Entry,
 		rootEntry.Annotation[util.CompressedSchemaAnnotation] = compressed
 	}
 
+	if len(namespaces) != 0 {
+		rootEntry.Annotation[util.ModuleNamespacesAnnotation] = namespaces
+	}
+	if len(prefixes) != 0 {
+		rootEntry.Annotation[util.ModulePrefixesAnnotation] = prefixes
+	}
+
 	j, err := json.MarshalIndent(rootEntry, "", strings.Repeat(" ", 4))
 	if err != nil {
 		return nil, fmt.Errorf("JSON marshalling error: %v", err)
@@ -90,6 +109,9 @@ func annotateChildren(e *yang.Entry, dn map[string]string) {
 			annotateChildren(ch, dn)
 		}
 	}
+	for _, op := range util.OperationChildren(e) {
+		annotateChildren(op, dn)
+	}
 }
 
 // annotateEntry modifies the yang.Entry e to:
@@ -99,6 +121,11 @@ func annotateChildren(e *yang.Entry, dn map[string]string) {
 //    in the supplied dn map to the annotations.
 //  - add the YANG schema path to the annotations, where e
 //    corresponds to a YANG directory.
+//  - add the presence annotation, where e corresponds to a YANG
+//    presence container.
+//  - copy the must and when statements of leaves and leaf-lists, which
+//    goyang stores only in the AST node, to the Extra field such that
+//    they are retained in the serialised schema.
 func annotateEntry(e *yang.Entry, dn map[string]string) {
 	e.Description = ""
 	if e.Annotation == nil {
@@ -110,6 +137,35 @@ func annotateEntry(e *yang.Entry, dn map[string]string) {
 	if e.IsDir() {
 		e.Annotation["schemapath"] = e.Path()
 	}
+	if util.IsPresenceContainer(e) {
+		e.Annotation[util.PresenceAnnotation] = true
+	}
+	switch n := e.Node.(type) {
+	case *yang.Leaf:
+		copyLeafXPathStatements(e, n.Must, n.When)
+	case *yang.LeafList:
+		copyLeafXPathStatements(e, n.Must, n.When)
+	}
+}
+
+// copyLeafXPathStatements stores the supplied must and when statements of
+// the leaf or leaf-list e in its Extra field.
+func copyLeafXPathStatements(e *yang.Entry, must []*yang.Must, when *yang.Value) {
+	if len(must) == 0 && when == nil {
+		return
+	}
+	if e.Extra == nil {
+		e.Extra = map[string][]interface{}{}
+	}
+	if len(must) != 0 {
+		e.Extra["must"] = nil
+		for _, m := range must {
+			e.Extra["must"] = append(e.Extra["must"], m)
+		}
+	}
+	if when != nil {
+		e.Extra["when"] = []interface{}{when}
+	}
 }
 
 // WriteGzippedByteSlice takes an input slice of bytes, gzips it
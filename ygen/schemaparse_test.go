@@ -87,6 +87,79 @@ func TestBuildJSONTree(t *testing.T) {
 		"container-two": moduleTwoContainerTwo,
 	}
 
+	// YANG hierarchy containing an RPC and a notification.
+	rpcModule := &yang.Entry{
+		Name: "rpc-module",
+		Kind: yang.DirectoryEntry,
+	}
+	rpcEntry := &yang.Entry{
+		Name:   "reboot",
+		Kind:   yang.DirectoryEntry,
+		Parent: rpcModule,
+	}
+	rpcInput := &yang.Entry{
+		Name:   "input",
+		Kind:   yang.InputEntry,
+		Parent: rpcEntry,
+	}
+	rpcInput.Dir = map[string]*yang.Entry{
+		"delay": {
+			Name:   "delay",
+			Kind:   yang.LeafEntry,
+			Parent: rpcInput,
+		},
+	}
+	rpcEntry.RPC = &yang.RPCEntry{Input: rpcInput}
+	notification := &yang.Entry{
+		Name:   "link-down",
+		Kind:   yang.NotificationEntry,
+		Parent: rpcModule,
+	}
+	notification.Dir = map[string]*yang.Entry{
+		"ifname": {
+			Name:   "ifname",
+			Kind:   yang.LeafEntry,
+			Parent: notification,
+		},
+	}
+	rpcModule.Dir = map[string]*yang.Entry{
+		"reboot":    rpcEntry,
+		"link-down": notification,
+	}
+
+	// YANG hierarchy containing a presence container.
+	presenceModule := &yang.Entry{
+		Name: "presence-module",
+		Kind: yang.DirectoryEntry,
+	}
+	presenceContainer := &yang.Entry{
+		Name:   "presence-container",
+		Kind:   yang.DirectoryEntry,
+		Parent: presenceModule,
+		Extra: map[string][]interface{}{
+			"presence": {&yang.Value{Name: "enables the feature"}},
+		},
+	}
+	presenceModule.Dir = map[string]*yang.Entry{"presence-container": presenceContainer}
+
+	// YANG module with an XML namespace.
+	namespaceModule := &yang.Entry{
+		Name: "namespace-module",
+		Kind: yang.DirectoryEntry,
+		Node: &yang.Module{
+			Name:      "namespace-module",
+			Namespace: &yang.Value{Name: "urn:namespace-module"},
+			Prefix:    &yang.Value{Name: "nsm"},
+		},
+	}
+	namespaceModule.Dir = map[string]*yang.Entry{
+		"ns-leaf": {
+			Name:   "ns-leaf",
+			Kind:   yang.LeafEntry,
+			Parent: namespaceModule,
+		},
+	}
+
 	tests := []struct {
 		name             string
 		inEntries        []*yang.Entry
@@ -254,6 +327,120 @@ func TestBuildJSONTree(t *testing.T) {
         "schemapath": "/",
         "structname": "TheFakeRoot"
     }
+}`,
+	}, {
+		name:      "module with rpc and notification",
+		inEntries: []*yang.Entry{rpcModule},
+		inDirectoryNames: map[string]string{
+			"/rpc-module/reboot/input": "Reboot_Input",
+			"/rpc-module/link-down":    "LinkDown",
+		},
+		want: `{
+    "Name": "",
+    "Kind": 0,
+    "Config": 0,
+    "Dir": {
+        "link-down": {
+            "Name": "link-down",
+            "Kind": 7,
+            "Config": 0,
+            "Dir": {
+                "ifname": {
+                    "Name": "ifname",
+                    "Kind": 0,
+                    "Config": 0
+                }
+            },
+            "Annotation": {
+                "schemapath": "/rpc-module/link-down",
+                "structname": "LinkDown"
+            }
+        },
+        "reboot": {
+            "Name": "reboot",
+            "Kind": 1,
+            "Config": 0,
+            "RPC": {
+                "Input": {
+                    "Name": "input",
+                    "Kind": 6,
+                    "Config": 0,
+                    "Dir": {
+                        "delay": {
+                            "Name": "delay",
+                            "Kind": 0,
+                            "Config": 0
+                        }
+                    },
+                    "Annotation": {
+                        "schemapath": "/rpc-module/reboot/input",
+                        "structname": "Reboot_Input"
+                    }
+                },
+                "Output": null
+            }
+        }
+    },
+    "Annotation": {
+        "isFakeRoot": true
+    }
+}`,
+	}, {
+		name:      "module with presence container",
+		inEntries: []*yang.Entry{presenceModule},
+		inDirectoryNames: map[string]string{
+			"/presence-module/presence-container": "PresenceContainer",
+		},
+		want: `{
+    "Name": "",
+    "Kind": 0,
+    "Config": 0,
+    "Dir": {
+        "presence-container": {
+            "Name": "presence-container",
+            "Kind": 1,
+            "Config": 0,
+            "extra-unstable": {
+                "presence": [
+                    {
+                        "Name": "enables the feature",
+                        "Source": null
+                    }
+                ]
+            },
+            "Annotation": {
+                "presence": true,
+                "structname": "PresenceContainer"
+            }
+        }
+    },
+    "Annotation": {
+        "isFakeRoot": true
+    }
+}`,
+	}, {
+		name:      "module with namespace",
+		inEntries: []*yang.Entry{namespaceModule},
+		want: `{
+    "Name": "",
+    "Kind": 0,
+    "Config": 0,
+    "Dir": {
+        "ns-leaf": {
+            "Name": "ns-leaf",
+            "Kind": 0,
+            "Config": 0
+        }
+    },
+    "Annotation": {
+        "isFakeRoot": true,
+        "moduleNamespaces": {
+            "namespace-module": "urn:namespace-module"
+        },
+        "modulePrefixes": {
+            "nsm": "namespace-module"
+        }
+    }
 }`,
 	}}
 
@@ -48,6 +48,17 @@ func buildSchemaTree(entries []*yang.Entry) (*schemaTree, error) {
 			continue
 		}
 
+		if e.RPC != nil {
+			// The input and output of an RPC are not stored within its
+			// Dir, and hence are added individually.
+			for _, io := range util.RPCInputOutput(e) {
+				if err := schemaTreeChildrenAdd(t, io); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
 		if !e.IsDir() {
 			if err := t.Add([]string{pp[2]}, e); err != nil {
 				return nil, err
@@ -109,6 +120,13 @@ func schemaTreeChildrenAdd(t *schemaTree, e *yang.Entry) error {
 			return err
 		}
 	}
+	// Add the contents of RPCs, actions and notifications such that leafrefs
+	// within them can be resolved.
+	for _, op := range util.OperationChildren(e) {
+		if err := schemaTreeChildrenAdd(t, op); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
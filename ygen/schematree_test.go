@@ -30,6 +30,49 @@ type wantTreeEntry struct {
 }
 
 func TestBuildSchemaTree(t *testing.T) {
+	rpc := &yang.Entry{
+		Name: "reboot",
+		Parent: &yang.Entry{
+			Name: "module",
+		},
+	}
+	rpcInput := &yang.Entry{
+		Name: "input",
+		Kind: yang.InputEntry,
+	}
+	rpcInputLeaf := &yang.Entry{
+		Name: "delay",
+		Parent: &yang.Entry{
+			Name: "input",
+			Parent: &yang.Entry{
+				Name: "reboot",
+				Parent: &yang.Entry{
+					Name: "module",
+				},
+			},
+		},
+	}
+	rpcInput.Dir = map[string]*yang.Entry{"delay": rpcInputLeaf}
+	rpc.RPC = &yang.RPCEntry{Input: rpcInput}
+
+	notification := &yang.Entry{
+		Name: "link-down",
+		Kind: yang.NotificationEntry,
+		Parent: &yang.Entry{
+			Name: "module",
+		},
+	}
+	notificationLeaf := &yang.Entry{
+		Name: "ifname",
+		Parent: &yang.Entry{
+			Name: "link-down",
+			Parent: &yang.Entry{
+				Name: "module",
+			},
+		},
+	}
+	notification.Dir = map[string]*yang.Entry{"ifname": notificationLeaf}
+
 	tests := []struct {
 		name         string
 		inEntries    []*yang.Entry
@@ -115,6 +158,16 @@ func TestBuildSchemaTree(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name:      "rpc and notification",
+		inEntries: []*yang.Entry{rpc, notification},
+		wantElements: []wantTreeEntry{{
+			path:  []string{"reboot", "input", "delay"},
+			value: rpcInputLeaf,
+		}, {
+			path:  []string{"link-down", "ifname"},
+			value: notificationLeaf,
+		}},
 	}}
 
 	for _, tt := range tests {
@@ -0,0 +1,145 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/genutil"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// GenerateSIDFile generates a SID file, as per RFC9595, for the YANG module
+// with the supplied name and revision, from the Directory tree dirs, as
+// returned by GetDirectoriesAndLeafTypes. SIDs are assigned sequentially from
+// entryPoint, first to the module, then to the identities of the module that
+// are referenced by identityref leaves, and then to the data nodes of the
+// module, with identities and data nodes in the order of their identifiers.
+// An error is returned if more than size SIDs are required.
+func GenerateSIDFile(dirs map[string]*Directory, module, revision string, entryPoint, size uint64) (*ygot.SIDFile, error) {
+	identities := map[string]bool{}
+	paths := map[string]bool{}
+	for _, d := range dirs {
+		if d.Entry != nil && !d.IsFakeRoot {
+			if err := addSIDPaths(paths, d.Entry, module); err != nil {
+				return nil, err
+			}
+			// The key leaves of a list are not fields of its struct where
+			// path compression maps them to the leaves of the config
+			// container, but are data nodes nonetheless.
+			for _, k := range strings.Fields(d.Entry.Key) {
+				if ke, ok := d.Entry.Dir[k]; ok {
+					if err := addSIDPaths(paths, ke, module); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		for _, f := range d.Fields {
+			if err := addSIDPaths(paths, f, module); err != nil {
+				return nil, err
+			}
+			if f.Type != nil {
+				addSIDIdentities(identities, f.Type, module)
+			}
+		}
+	}
+
+	items := []ygot.SIDItem{{Namespace: ygot.SIDNamespaceModule, Identifier: module}}
+	for _, i := range sortedSIDKeys(identities) {
+		items = append(items, ygot.SIDItem{Namespace: ygot.SIDNamespaceIdentity, Identifier: i})
+	}
+	for _, p := range sortedSIDKeys(paths) {
+		items = append(items, ygot.SIDItem{Namespace: ygot.SIDNamespaceData, Identifier: p})
+	}
+	if uint64(len(items)) > size {
+		return nil, fmt.Errorf("module %s requires %d SIDs, which exceeds the size %d of the assignment range", module, len(items), size)
+	}
+	for i := range items {
+		items[i].SID = entryPoint + uint64(i)
+	}
+
+	return &ygot.SIDFile{
+		ModuleName:       module,
+		ModuleRevision:   revision,
+		AssignmentRanges: []ygot.SIDRange{{EntryPoint: entryPoint, Size: size}},
+		Items:            items,
+	}, nil
+}
+
+// addSIDPaths adds the schema node path of the entry e, and those of its
+// ancestors, to paths, where they are data nodes that are defined by the
+// module with the supplied name. Each node of a path is qualified with the
+// name of its module where it differs from that of its parent, and choice
+// and case nodes are omitted, as per RFC7951 Section 6.11.
+func addSIDPaths(paths map[string]bool, e *yang.Entry, module string) error {
+	var chain []*yang.Entry
+	for n := e; n != nil && n.Parent != nil; n = n.Parent {
+		if n.IsChoice() || n.IsCase() {
+			continue
+		}
+		chain = append([]*yang.Entry{n}, chain...)
+	}
+
+	var elems []string
+	var parentMod string
+	for _, n := range chain {
+		// The module that defines a node, such as the module of an augment
+		// statement, is that which qualifies its name.
+		if n.Node == nil {
+			return fmt.Errorf("cannot determine the module of %s, nil Node", n.Path())
+		}
+		mod := genutil.ParentModuleName(n.Node)
+		name := n.Name
+		if mod != parentMod {
+			name = fmt.Sprintf("%s:%s", mod, n.Name)
+		}
+		elems = append(elems, name)
+		parentMod = mod
+		if mod == module {
+			paths["/"+strings.Join(elems, "/")] = true
+		}
+	}
+	return nil
+}
+
+// addSIDIdentities adds the names of the identities that are defined by the
+// module with the supplied name, and which are valid values of the type t, to
+// identities.
+func addSIDIdentities(identities map[string]bool, t *yang.YangType, module string) {
+	if t.IdentityBase != nil {
+		for _, i := range append([]*yang.Identity{t.IdentityBase}, t.IdentityBase.Values...) {
+			if m := yang.RootNode(i); m != nil && m.Name == module {
+				identities[i.Name] = true
+			}
+		}
+	}
+	for _, st := range t.Type {
+		addSIDIdentities(identities, st, module)
+	}
+}
+
+// sortedSIDKeys returns the keys of m in sorted order.
+func sortedSIDKeys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
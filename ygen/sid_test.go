@@ -0,0 +1,101 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygen
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/genutil"
+	"github.com/openconfig/ygot/ygot"
+)
+
+func TestGenerateSIDFile(t *testing.T) {
+	// The sid-dev module is augmented by the sid-ext module, which also
+	// defines an identity that is derived from an identity of sid-dev.
+	dcg := &DirectoryGenConfig{
+		TransformationOptions: TransformationOpts{CompressBehaviour: genutil.PreferIntendedConfig},
+	}
+	dirs, _, errs := dcg.GetDirectoriesAndLeafTypes([]string{
+		filepath.Join(datapath, "sid-dev.yang"),
+		filepath.Join(datapath, "sid-ext.yang"),
+	}, nil)
+	if errs != nil {
+		t.Fatalf("GetDirectoriesAndLeafTypes: got unexpected errors: %v", errs)
+	}
+
+	tests := []struct {
+		desc             string
+		inModule         string
+		inSize           uint64
+		want             *ygot.SIDFile
+		wantErrSubstring string
+	}{{
+		desc:     "dev module",
+		inModule: "sid-dev",
+		inSize:   100,
+		want: &ygot.SIDFile{
+			ModuleName:       "sid-dev",
+			ModuleRevision:   "2024-01-01",
+			AssignmentRanges: []ygot.SIDRange{{EntryPoint: 60000, Size: 100}},
+			Items: []ygot.SIDItem{
+				{Namespace: ygot.SIDNamespaceModule, Identifier: "sid-dev", SID: 60000},
+				{Namespace: ygot.SIDNamespaceIdentity, Identifier: "ETHERNET", SID: 60001},
+				{Namespace: ygot.SIDNamespaceIdentity, Identifier: "IF_TYPE", SID: 60002},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces", SID: 60003},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface", SID: 60004},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/config", SID: 60005},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/config/name", SID: 60006},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/config/type", SID: 60007},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/config/vlan-id", SID: 60008},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/name", SID: 60009},
+			},
+		},
+	}, {
+		desc:     "augmenting module",
+		inModule: "sid-ext",
+		inSize:   10,
+		want: &ygot.SIDFile{
+			ModuleName:       "sid-ext",
+			ModuleRevision:   "2024-01-01",
+			AssignmentRanges: []ygot.SIDRange{{EntryPoint: 60000, Size: 10}},
+			Items: []ygot.SIDItem{
+				{Namespace: ygot.SIDNamespaceModule, Identifier: "sid-ext", SID: 60000},
+				{Namespace: ygot.SIDNamespaceIdentity, Identifier: "TUNNEL", SID: 60001},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/sid-ext:state", SID: 60002},
+				{Namespace: ygot.SIDNamespaceData, Identifier: "/sid-dev:interfaces/interface/sid-ext:state/counter", SID: 60003},
+			},
+		},
+	}, {
+		desc:             "assignment range too small",
+		inModule:         "sid-dev",
+		inSize:           5,
+		wantErrSubstring: "module sid-dev requires 10 SIDs",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := GenerateSIDFile(dirs, tt.inModule, "2024-01-01", 60000, tt.inSize)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("GenerateSIDFile: did not get expected error, %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("GenerateSIDFile: did not get expected file, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,406 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc9254 for the CBOR encoding of
+// YANG data.
+
+// CBOR tags that are used in the encoding of YANG values, as per RFC9254
+// Section 9.3.
+const (
+	// CBORTagDecimalFraction is the tag of a decimal64 value, which is
+	// encoded as an array of its exponent and mantissa.
+	CBORTagDecimalFraction = 4
+	// CBORTagBits is the tag of a bits value that is encoded as a string.
+	CBORTagBits = 43
+	// CBORTagEnumeration is the tag of an enumeration value that is
+	// encoded as a string.
+	CBORTagEnumeration = 44
+	// CBORTagIdentityRef is the tag of an identityref value that is encoded
+	// as a SID within a union.
+	CBORTagIdentityRef = 45
+)
+
+// CBORConfig is used to control the behaviour of ConstructCBOR.
+type CBORConfig struct {
+	// SIDs specifies the SIDs of the schema nodes and identities of the
+	// GoStruct. When it is set, the keys of the CBOR maps, and identityref
+	// values, are encoded as SIDs rather than as names.
+	SIDs *SIDMap
+}
+
+// ConstructCBOR marshals the supplied GoStruct to CBOR as per RFC9254. By
+// default, the names of the data nodes are used as the keys of the CBOR maps,
+// qualified with the name of their module where it differs from that of their
+// parent, as per RFC7951 JSON. When opts specifies a SIDMap, the keys are
+// instead the SIDs of the data nodes, encoded as the delta from the SID of
+// their parent.
+//
+// Since the generated Go structures do not retain the values or positions
+// that are assigned to enumerations and bits in the schema, enumeration and
+// bits values are always encoded as strings, using the tags that RFC9254
+// specifies for such values within unions.
+func ConstructCBOR(s GoStruct, opts *CBORConfig) ([]byte, error) {
+	var sids *SIDMap
+	if opts != nil {
+		sids = opts.SIDs
+	}
+	c := &cborEncoder{sids: sids}
+	tree, err := c.structTree(reflect.ValueOf(s), "")
+	if err != nil {
+		return nil, err
+	}
+	m, err := c.cborMap(tree, "", 0)
+	if err != nil {
+		return nil, err
+	}
+	return util.MarshalCBOR(m)
+}
+
+// EncodeTypedValueCBOR encodes the supplied GoStruct into a gNMI TypedValue
+// message whose bytes value is the RFC9254 CBOR encoding of the struct, as
+// produced by ConstructCBOR with the supplied opts. Since gNMI does not define
+// an encoding for CBOR, it must be requested explicitly, and is not used by
+// EncodeTypedValue for any gNMI encoding.
+func EncodeTypedValueCBOR(s GoStruct, opts *CBORConfig) (*gnmipb.TypedValue, error) {
+	if util.IsValueNil(s) {
+		return nil, nil
+	}
+	b, err := ConstructCBOR(s, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_BytesVal{b}}, nil
+}
+
+// cborEncoder encodes GoStructs to CBOR.
+type cborEncoder struct {
+	// sids are the SIDs of the schema nodes and identities, or nil if names
+	// are to be used.
+	sids *SIDMap
+}
+
+// structTree returns the tree of the data nodes within the struct pointer s,
+// whose module is parentMod. The tree is a map keyed by the RFC7951 JSON name
+// of each data node, whose values are maps for containers, slices of maps for
+// lists, slices of values for leaf-lists, and CBOR values for leaves.
+func (c *cborEncoder) structTree(s reflect.Value, parentMod string) (map[string]interface{}, error) {
+	if !util.IsValueStructPtr(s) {
+		return nil, fmt.Errorf("cannot marshal %v to CBOR, not a struct pointer", s.Type())
+	}
+
+	var errs errlist.List
+	tree := map[string]interface{}{}
+	sv := s.Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		f, ft := sv.Field(i), sv.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+
+		mod := parentMod
+		if chMod, ok := ft.Tag.Lookup("module"); ok && chMod != "" {
+			mod = chMod
+		}
+
+		value, err := c.fieldValue(f, mod)
+		if err != nil {
+			errs.Add(fmt.Errorf("%s: %v", ft.Name, err))
+			continue
+		}
+		if value == nil {
+			continue
+		}
+		// Empty containers are omitted, other than presence containers whose
+		// existence is meaningful.
+		if m, ok := value.(map[string]interface{}); ok && len(m) == 0 && !util.IsYangPresence(ft) {
+			continue
+		}
+
+		paths, err := util.SchemaPaths(ft)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		for _, p := range paths {
+			if len(p) > 0 && p[0] == "" {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				errs.Add(fmt.Errorf("invalid schema path for field %s", ft.Name))
+				continue
+			}
+			if mod != parentMod {
+				p = append([]string{fmt.Sprintf("%s:%s", mod, p[0])}, p[1:]...)
+			}
+
+			// The intermediate containers of compressed structs are
+			// created where they do not exist.
+			parent := tree
+			for _, e := range p[:len(p)-1] {
+				if _, ok := parent[e].(map[string]interface{}); !ok {
+					parent[e] = map[string]interface{}{}
+				}
+				parent = parent[e].(map[string]interface{})
+			}
+			parent[p[len(p)-1]] = value
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// fieldValue returns the value of the struct field f, within the module mod,
+// for inclusion within the tree returned by structTree. It returns nil if the
+// field is unset.
+func (c *cborEncoder) fieldValue(f reflect.Value, mod string) (interface{}, error) {
+	if util.IsNilOrInvalidValue(f) {
+		return nil, nil
+	}
+
+	switch {
+	case util.IsValueStructPtr(f) && !util.IsValueOrderedMap(f):
+		if _, ok := f.Interface().(GoStruct); ok {
+			return c.structTree(f, mod)
+		}
+	case util.IsValueMap(f), util.IsValueOrderedMap(f):
+		keys, vals := util.KeyedListMembers(f)
+		idx := make([]int, len(keys))
+		for i := range idx {
+			idx[i] = i
+		}
+		if !util.IsValueOrderedMap(f) {
+			sort.SliceStable(idx, func(i, j int) bool {
+				return fmt.Sprintf("%v", keys[idx[i]].Interface()) < fmt.Sprintf("%v", keys[idx[j]].Interface())
+			})
+		}
+		var members []interface{}
+		for _, i := range idx {
+			m, err := c.structTree(vals[i], mod)
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, m)
+		}
+		return members, nil
+	case f.Kind() == reflect.Slice && f.Type().Name() != BinaryTypeName:
+		var vals []interface{}
+		for i := 0; i < f.Len(); i++ {
+			e := f.Index(i)
+			if util.IsTypeStructPtr(e.Type()) && !util.IsValueInterfaceToStructPtr(e) {
+				if _, ok := e.Interface().(GoStruct); ok {
+					// Members of keyless lists.
+					m, err := c.structTree(e, mod)
+					if err != nil {
+						return nil, err
+					}
+					vals = append(vals, m)
+					continue
+				}
+			}
+			v, set, err := c.leafValue(e, false)
+			if err != nil {
+				return nil, err
+			}
+			if set {
+				vals = append(vals, v)
+			}
+		}
+		if len(vals) == 0 {
+			return nil, nil
+		}
+		return vals, nil
+	}
+
+	v, set, err := c.leafValue(f, false)
+	if err != nil || !set {
+		return nil, err
+	}
+	return cborLeaf{v}, nil
+}
+
+// cborLeaf wraps the CBOR value of a leaf within the tree returned by
+// structTree, such that it is distinguished from the maps and slices of
+// containers and lists.
+type cborLeaf struct {
+	v interface{}
+}
+
+// leafValue returns the CBOR value of the leaf or leaf-list value v, and
+// whether it is set. If inUnion is true, the value is a member of a union,
+// and is encoded such that its type can be determined as per RFC9254.
+func (c *cborEncoder) leafValue(v reflect.Value, inUnion bool) (interface{}, bool, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil, false, nil
+		}
+		if util.IsValueInterfaceToStructPtr(v) || util.IsValueStructPtr(v) {
+			// Union values are stored as a pointer to a struct with a single
+			// field, which contains the value.
+			s := v.Elem()
+			if s.Kind() == reflect.Ptr {
+				s = s.Elem()
+			}
+			if !util.IsStructValueWithNFields(s, 1) {
+				return nil, false, fmt.Errorf("invalid union type %v", s.Type())
+			}
+			return c.leafValue(s.Field(0), true)
+		}
+		return c.leafValue(v.Elem(), inUnion)
+	case reflect.Bool:
+		if v.Type().Name() == EmptyTypeName {
+			// An empty leaf is encoded as null.
+			return nil, v.Bool(), nil
+		}
+		return v.Bool(), true, nil
+	case reflect.Slice:
+		if v.Type().Name() != BinaryTypeName {
+			return nil, false, fmt.Errorf("unknown slice type %v", v.Type())
+		}
+		return v.Bytes(), true, nil
+	}
+
+	switch i := v.Interface().(type) {
+	case GoEnum:
+		name, set, err := enumFieldToString(v, false)
+		if err != nil || !set {
+			return nil, false, err
+		}
+		qname, _, err := enumFieldToString(v, true)
+		if err != nil {
+			return nil, false, err
+		}
+		if qname == name {
+			return util.CBORTag{Number: CBORTagEnumeration, Content: name}, true, nil
+		}
+		// Only identities are qualified with the module that defines them.
+		if c.sids == nil {
+			return qname, true, nil
+		}
+		sid, ok := c.sids.IdentitySID(qname)
+		if !ok {
+			return nil, false, fmt.Errorf("no SID for identity %s", qname)
+		}
+		if inUnion {
+			return util.CBORTag{Number: CBORTagIdentityRef, Content: sid}, true, nil
+		}
+		return sid, true, nil
+	case GoBits:
+		name, err := BitsName(i)
+		if err != nil {
+			return nil, false, err
+		}
+		return util.CBORTag{Number: CBORTagBits, Content: name}, true, nil
+	case Decimal64:
+		return cborDecimal(i)
+	case float64:
+		return cborDecimal(Decimal64(strconv.FormatFloat(i, 'f', -1, 64)))
+	case string:
+		return i, true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), true, nil
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint(), true, nil
+	}
+	return nil, false, fmt.Errorf("unknown value type %v", v.Type())
+}
+
+// cborDecimal returns the CBOR decimal fraction that corresponds to d, which
+// is encoded as an array of its exponent and mantissa.
+func cborDecimal(d Decimal64) (interface{}, bool, error) {
+	digits, err := d.Digits()
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid decimal value %s: %v", d, err)
+	}
+	return util.CBORTag{Number: CBORTagDecimalFraction, Content: []interface{}{-int64(d.Precision()), digits}}, true, nil
+}
+
+// cborMap returns the CBOR map that corresponds to tree, which is the tree of
+// the data node with the schema node path p and the SID sid, as returned by
+// structTree. The keys of the map are SIDs if the encoder has a SIDMap.
+func (c *cborEncoder) cborMap(tree map[string]interface{}, p string, sid uint64) (util.CBORMap, error) {
+	var errs errlist.List
+	m := util.CBORMap{}
+	for k, v := range tree {
+		cp := fmt.Sprintf("%s/%s", p, k)
+		var key interface{} = k
+		var csid uint64
+		if c.sids != nil {
+			var ok bool
+			if csid, ok = c.sids.DataSID(cp); !ok {
+				errs.Add(fmt.Errorf("no SID for data node %s", cp))
+				continue
+			}
+			key = csid
+			if p != "" {
+				// The keys of nested maps are the delta from the SID of
+				// their parent.
+				key = int64(csid - sid)
+			}
+		}
+
+		cv, err := c.cborValue(v, cp, csid)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+		m = append(m, util.CBORPair{Key: key, Value: cv})
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// cborValue returns the CBOR value of v, which is a value of the tree returned
+// by structTree for the data node with the schema node path p and the SID sid.
+func (c *cborEncoder) cborValue(v interface{}, p string, sid uint64) (interface{}, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return c.cborMap(v, p, sid)
+	case []interface{}:
+		var vals []interface{}
+		for _, e := range v {
+			ce, err := c.cborValue(e, p, sid)
+			if err != nil {
+				return nil, err
+			}
+			vals = append(vals, ce)
+		}
+		return vals, nil
+	case cborLeaf:
+		return v.v, nil
+	}
+	// Values of leaf-lists are not wrapped.
+	return v, nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// cborTestEnum is an enumeration that is not an identity.
+type cborTestEnum int64
+
+func (cborTestEnum) IsYANGGoEnum() {}
+
+func (cborTestEnum) ΛMap() map[string]map[int64]EnumDefinition {
+	return map[string]map[int64]EnumDefinition{
+		"cborTestEnum": {1: {Name: "AUTO"}},
+	}
+}
+
+// cborTestDevice is a compressed GoStruct used to test ConstructCBOR.
+type cborTestDevice struct {
+	Hostname  *string                       `path:"system/config/hostname" module:"dev"`
+	DNS       []string                      `path:"system/config/dns" module:"dev"`
+	Mtu       *uint16                       `path:"system/config/mtu" module:"dev"`
+	Offset    *int32                        `path:"system/config/offset" module:"dev"`
+	Flag      YANGEmpty                     `path:"system/config/flag" module:"dev"`
+	KeyData   Binary                        `path:"system/config/key-data" module:"dev"`
+	Ident     EnumTest                      `path:"system/config/ident" module:"dev"`
+	Mode      cborTestEnum                  `path:"system/config/mode" module:"dev"`
+	Features  *BitsTest                     `path:"system/config/features" module:"dev"`
+	Ratio     *Decimal64                    `path:"system/config/ratio" module:"dev"`
+	Value     renderExampleUnion            `path:"system/config/value" module:"dev"`
+	Logging   *cborTestLogging              `path:"system/logging" module:"dev" yangPresence:"true"`
+	Interface map[string]*cborTestInterface `path:"interfaces/interface" module:"dev"`
+}
+
+func (*cborTestDevice) IsYANGGoStruct() {}
+
+type cborTestLogging struct {
+	Level *string `path:"level" module:"dev"`
+}
+
+func (*cborTestLogging) IsYANGGoStruct() {}
+
+type cborTestInterface struct {
+	Mtu     *uint16 `path:"config/mtu" module:"dev"`
+	Name    *string `path:"config/name|name" module:"dev"`
+	Counter *uint64 `path:"state/counter" module:"ext"`
+}
+
+func (*cborTestInterface) IsYANGGoStruct() {}
+
+// cborTestSIDs returns the SIDs of the schema nodes of cborTestDevice, which
+// are assigned by encodingtest.SIDFiles.
+func cborTestSIDs(t *testing.T) *SIDMap {
+	var files []*SIDFile
+	for _, d := range encodingtest.SIDFiles {
+		f, err := ParseSIDFile([]byte(d))
+		if err != nil {
+			t.Fatalf("ParseSIDFile: got unexpected error: %v", err)
+		}
+		files = append(files, f)
+	}
+	m, err := NewSIDMap(files...)
+	if err != nil {
+		t.Fatalf("NewSIDMap: got unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestConstructCBOR(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               GoStruct
+		inSIDs           bool
+		want             interface{}
+		wantErrSubstring string
+	}{{
+		desc: "leaves with names",
+		in: &cborTestDevice{
+			Hostname: String("rtr1"),
+			DNS:      []string{"192.0.2.1", "192.0.2.2"},
+			Mtu:      Uint16(9000),
+			Offset:   Int32(-5),
+			Flag:     true,
+			KeyData:  Binary("abc"),
+			Ident:    EnumTestVALONE,
+			Mode:     1,
+			Features: func() *BitsTest { b := BitsTestBITZERO | BitsTestBITTHREE; return &b }(),
+			Ratio:    func() *Decimal64 { d := NewDecimal64(-150, 2); return &d }(),
+		},
+		want: util.CBORMap{{Key: "dev:system", Value: util.CBORMap{{Key: "config", Value: util.CBORMap{
+			{Key: "hostname", Value: "rtr1"},
+			{Key: "dns", Value: []interface{}{"192.0.2.1", "192.0.2.2"}},
+			{Key: "mtu", Value: uint64(9000)},
+			{Key: "offset", Value: int64(-5)},
+			{Key: "flag", Value: nil},
+			{Key: "key-data", Value: []byte("abc")},
+			{Key: "ident", Value: "foo:VAL_ONE"},
+			{Key: "mode", Value: util.CBORTag{Number: CBORTagEnumeration, Content: "AUTO"}},
+			{Key: "features", Value: util.CBORTag{Number: CBORTagBits, Content: "BIT_ZERO BIT_THREE"}},
+			{Key: "ratio", Value: util.CBORTag{Number: CBORTagDecimalFraction, Content: []interface{}{int64(-2), int64(-150)}}},
+		}}}}},
+	}, {
+		desc: "union, presence container and list with names",
+		in: &cborTestDevice{
+			Value:   &renderExampleUnionInt8{Int8: 42},
+			Logging: &cborTestLogging{},
+			Interface: map[string]*cborTestInterface{
+				"eth1": {Name: String("eth1"), Counter: Uint64(42)},
+				"eth0": {Name: String("eth0"), Mtu: Uint16(1500)},
+			},
+		},
+		want: util.CBORMap{
+			{Key: "dev:system", Value: util.CBORMap{
+				{Key: "config", Value: util.CBORMap{{Key: "value", Value: int64(42)}}},
+				{Key: "logging", Value: util.CBORMap{}},
+			}},
+			{Key: "dev:interfaces", Value: util.CBORMap{{Key: "interface", Value: []interface{}{
+				util.CBORMap{
+					{Key: "name", Value: "eth0"},
+					{Key: "config", Value: util.CBORMap{{Key: "name", Value: "eth0"}, {Key: "mtu", Value: uint64(1500)}}},
+				},
+				util.CBORMap{
+					{Key: "name", Value: "eth1"},
+					{Key: "config", Value: util.CBORMap{{Key: "name", Value: "eth1"}}},
+					{Key: "ext:state", Value: util.CBORMap{{Key: "counter", Value: uint64(42)}}},
+				},
+			}}}},
+		},
+	}, {
+		desc: "SIDs",
+		in: &cborTestDevice{
+			Hostname: String("rtr1"),
+			Ident:    EnumTestVALONE,
+			Value:    &renderExampleUnionEnum{Enum: EnumTestVALONE},
+			Interface: map[string]*cborTestInterface{
+				"eth0": {Name: String("eth0"), Counter: Uint64(42)},
+			},
+		},
+		inSIDs: true,
+		want: util.CBORMap{
+			{Key: uint64(1000), Value: util.CBORMap{{Key: int64(1), Value: util.CBORMap{
+				{Key: int64(1), Value: "rtr1"},
+				{Key: int64(-2), Value: uint64(3000)},
+				{Key: int64(2), Value: util.CBORTag{Number: CBORTagIdentityRef, Content: uint64(3000)}},
+			}}}},
+			{Key: uint64(1010), Value: util.CBORMap{{Key: int64(1), Value: []interface{}{
+				util.CBORMap{
+					{Key: int64(1), Value: "eth0"},
+					{Key: int64(2), Value: util.CBORMap{{Key: int64(1), Value: "eth0"}}},
+					{Key: int64(989), Value: util.CBORMap{{Key: int64(1), Value: uint64(42)}}},
+				},
+			}}}},
+		},
+	}, {
+		desc:             "missing data node SID",
+		in:               &cborTestDevice{Mtu: Uint16(1500)},
+		inSIDs:           true,
+		wantErrSubstring: "no SID for data node /dev:system/config/mtu",
+	}, {
+		desc:             "missing identity SID",
+		in:               &cborTestDevice{Ident: EnumTestVALTWO},
+		inSIDs:           true,
+		wantErrSubstring: "no SID for identity bar:VAL_TWO",
+	}, {
+		desc:             "invalid union",
+		in:               &cborTestDevice{Value: &renderExampleUnionInvalid{String: "hello"}},
+		wantErrSubstring: "invalid union type",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var opts *CBORConfig
+			if tt.inSIDs {
+				opts = &CBORConfig{SIDs: cborTestSIDs(t)}
+			}
+			got, err := ConstructCBOR(tt.in, opts)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("ConstructCBOR: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+
+			want, err := util.MarshalCBOR(tt.want)
+			if err != nil {
+				t.Fatalf("MarshalCBOR(%v): got unexpected error: %v", tt.want, err)
+			}
+			if !bytes.Equal(got, want) {
+				gotV, _ := util.UnmarshalCBOR(got)
+				wantV, _ := util.UnmarshalCBOR(want)
+				t.Errorf("ConstructCBOR: did not get expected CBOR, diff(-want, +got):\n%s", cmp.Diff(wantV, gotV))
+			}
+		})
+	}
+}
+
+func TestEncodeTypedValueCBOR(t *testing.T) {
+	tests := []struct {
+		desc   string
+		in     GoStruct
+		inOpts *CBORConfig
+		want   *gnmipb.TypedValue
+	}{{
+		desc: "names",
+		in:   &ietfRenderExample{F1: String("hello")},
+		// {"f1mod:f1": "hello"}
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_BytesVal{[]byte("\xa1\x68f1mod:f1\x65hello")}},
+	}, {
+		desc:   "SIDs",
+		in:     &cborTestDevice{Hostname: String("rtr1")},
+		inOpts: &CBORConfig{SIDs: cborTestSIDs(t)},
+		// {1000: {1: {1: "rtr1"}}}
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_BytesVal{[]byte("\xa1\x19\x03\xe8\xa1\x01\xa1\x01\x64rtr1")}},
+	}, {
+		desc: "nil struct",
+		in:   (*ietfRenderExample)(nil),
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := EncodeTypedValueCBOR(tt.in, tt.inOpts)
+			if err != nil {
+				t.Fatalf("EncodeTypedValueCBOR: got unexpected error: %v", err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("EncodeTypedValueCBOR: got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
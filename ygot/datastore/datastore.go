@@ -0,0 +1,336 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package datastore provides a versioned datastore whose data tree is a
+// GoStruct generated by ygen, and which can be shared between goroutines.
+// Readers use immutable snapshots of the data tree, such that they never
+// block, or are blocked by, writers. Writers modify the data tree using
+// transactions, which are validated against the schema when they are
+// committed, and each commit results in a new snapshot. The changes made by
+// each commit are computed using ygot.Diff, and are delivered as gNMI
+// Notifications to the subscriptions whose paths they affect.
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ErrDone is returned by the methods of a Txn that has already been
+// committed or discarded.
+var ErrDone = errors.New("transaction has already been committed or discarded")
+
+// Datastore is a versioned data tree whose root is a GoStruct. It is safe
+// for concurrent use.
+type Datastore struct {
+	// schema is the schema of the root of the data tree.
+	schema *yang.Entry
+
+	// snap stores the *Snapshot of the current version of the data tree. It
+	// is replaced, rather than modified, by each commit, such that it can be
+	// read without holding a lock.
+	snap atomic.Value
+
+	// commitMu serialises commits, and the registration of subscriptions,
+	// such that each subscription receives the changes of every commit
+	// after the snapshot at which it started.
+	commitMu sync.Mutex
+
+	// subsMu protects subs.
+	subsMu sync.Mutex
+	subs   map[*Subscription]bool
+}
+
+// New returns a Datastore whose data tree is initially the Root of the
+// supplied schema, with version 0. The Datastore takes ownership of the root,
+// which must not be modified subsequently.
+func New(schema *ytypes.Schema) (*Datastore, error) {
+	if schema == nil || !schema.IsValid() {
+		return nil, fmt.Errorf("invalid schema %v, must specify a root, schema tree and unmarshal function", schema)
+	}
+	rs := schema.RootSchema()
+	if rs == nil {
+		return nil, fmt.Errorf("cannot find schema for root type %T", schema.Root)
+	}
+	d := &Datastore{schema: rs, subs: map[*Subscription]bool{}}
+	d.snap.Store(&Snapshot{schema: rs, root: schema.Root})
+	return d, nil
+}
+
+// Schema returns the schema of the root of the data tree of d.
+func (d *Datastore) Schema() *yang.Entry {
+	return d.schema
+}
+
+// Snapshot returns the snapshot of the current version of the data tree.
+func (d *Datastore) Snapshot() *Snapshot {
+	return d.snap.Load().(*Snapshot)
+}
+
+// Update calls fn with a copy of the current data tree within a
+// transaction, and commits the transaction if fn returns nil. It returns the
+// snapshot that results from the commit.
+func (d *Datastore) Update(fn func(root ygot.ValidatedGoStruct) error) (*Snapshot, error) {
+	t := d.Begin()
+	if err := t.Update(fn); err != nil {
+		t.Discard()
+		return nil, err
+	}
+	return t.Commit()
+}
+
+// Begin starts a transaction that modifies the current version of the data
+// tree.
+func (d *Datastore) Begin() *Txn {
+	return &Txn{d: d, base: d.Snapshot()}
+}
+
+// Snapshot is an immutable version of the data tree of a Datastore. It is
+// safe for concurrent use.
+type Snapshot struct {
+	// version is the version of the data tree, which is incremented by
+	// each commit.
+	version uint64
+	// schema is the schema of root.
+	schema *yang.Entry
+	// root is the data tree, which must not be modified.
+	root ygot.ValidatedGoStruct
+}
+
+// Version returns the version of the data tree of s. The initial data tree
+// of a Datastore has version 0, and each commit increments the version.
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// Root returns a copy of the data tree of s, which may be modified by the
+// caller.
+func (s *Snapshot) Root() (ygot.ValidatedGoStruct, error) {
+	return copyRoot(s.root)
+}
+
+// Tree returns the data tree of s, which is shared with the snapshot, and
+// hence must not be modified. It avoids the copy made by Root for callers
+// that only read the data tree, such as to render it.
+func (s *Snapshot) Tree() ygot.ValidatedGoStruct {
+	return s.root
+}
+
+// Get returns the nodes of the data tree of s that match the path p, which
+// may contain wildcards and partially specified keys, as per ytypes.GetNode.
+// The data of the returned nodes is shared with the snapshot, and hence must
+// not be modified.
+func (s *Snapshot) Get(p *gnmipb.Path) ([]*ytypes.TreeNode, error) {
+	return ytypes.GetNode(s.schema, s.root, p, &ytypes.GetHandleWildcards{}, &ytypes.GetPartialKeyMatch{})
+}
+
+// Txn is a transaction that modifies the data tree of a Datastore. The
+// changes made within a transaction are applied to a private copy of the
+// data tree, and are made visible to other goroutines only when the
+// transaction is committed. A Txn is not safe for concurrent use.
+type Txn struct {
+	d *Datastore
+	// base is the snapshot that the transaction started from.
+	base *Snapshot
+	// root is the copy of the data tree of base that is modified in place
+	// by the transaction. It is nil until the first change is made, and
+	// after a change fails.
+	root ygot.ValidatedGoStruct
+	// ops is the set of changes made by the transaction, in order, which
+	// are replayed if another transaction is committed first.
+	ops []func(root ygot.ValidatedGoStruct) error
+	// done indicates that the transaction has been committed or discarded.
+	done bool
+}
+
+// Set sets the leaf or leaf-list at path p to the value val, creating any
+// missing ancestors of the leaf. The path must not contain wildcards.
+func (t *Txn) Set(p *gnmipb.Path, val *gnmipb.TypedValue) error {
+	return t.apply(func(root ygot.ValidatedGoStruct) error {
+		if err := ytypes.SetNode(t.d.schema, root, p, val, &ytypes.InitMissingElements{}); err != nil {
+			return fmt.Errorf("cannot set %s: %v", pathString(p), err)
+		}
+		return nil
+	})
+}
+
+// Delete deletes the node at path p, along with its descendants. Deleting a
+// node that does not exist is not an error.
+func (t *Txn) Delete(p *gnmipb.Path) error {
+	return t.apply(func(root ygot.ValidatedGoStruct) error {
+		if err := ytypes.DeleteNode(t.d.schema, root, p); err != nil {
+			return fmt.Errorf("cannot delete %s: %v", pathString(p), err)
+		}
+		return nil
+	})
+}
+
+// Update calls fn with the data tree of t, which fn may modify in any way.
+// Since fn is called again if the transaction is replayed when it is
+// committed, it must make the same changes each time that it is called, and
+// must not retain the data tree.
+func (t *Txn) Update(fn func(root ygot.ValidatedGoStruct) error) error {
+	return t.apply(fn)
+}
+
+// Get returns the nodes of the data tree of t, including the changes made
+// within t, that match the path p, as per Snapshot.Get. The data of the
+// returned nodes must not be modified.
+func (t *Txn) Get(p *gnmipb.Path) ([]*ytypes.TreeNode, error) {
+	if t.done {
+		return nil, ErrDone
+	}
+	if len(t.ops) == 0 {
+		return t.base.Get(p)
+	}
+	root, err := t.tree()
+	if err != nil {
+		return nil, err
+	}
+	return ytypes.GetNode(t.d.schema, root, p, &ytypes.GetHandleWildcards{}, &ytypes.GetPartialKeyMatch{})
+}
+
+// apply applies the change op to the data tree of t, and records it such
+// that it can be replayed. The data tree of base is copied by the first
+// change, and subsequent changes modify the copy in place. If op fails, the
+// copy is discarded, such that it is rebuilt from the changes that succeeded
+// when it is next used.
+func (t *Txn) apply(op func(root ygot.ValidatedGoStruct) error) error {
+	if t.done {
+		return ErrDone
+	}
+	root, err := t.tree()
+	if err != nil {
+		return err
+	}
+	if err := op(root); err != nil {
+		t.root = nil
+		return err
+	}
+	t.ops = append(t.ops, op)
+	return nil
+}
+
+// tree returns the private copy of the data tree of t, copying the data
+// tree of base and replaying the changes of t on it if there is no copy.
+func (t *Txn) tree() (ygot.ValidatedGoStruct, error) {
+	if t.root != nil {
+		return t.root, nil
+	}
+	c, err := copyRoot(t.base.root)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range t.ops {
+		if err := op(c); err != nil {
+			return nil, err
+		}
+	}
+	t.root = c
+	return c, nil
+}
+
+// Discard abandons the transaction t, such that none of its changes are
+// committed.
+func (t *Txn) Discard() {
+	t.done = true
+	t.root = nil
+	t.ops = nil
+}
+
+// Commit validates the data tree of t, and if it is valid, makes it the
+// current version of the data tree of the Datastore. If another transaction
+// has been committed since t began, the changes made by t are replayed on
+// the current version, and the commit fails if any of them fails. The
+// changes between the previous and new versions are delivered to the
+// subscriptions of the Datastore before Commit returns. It returns the
+// snapshot of the new version, or the current snapshot if t did not make
+// any changes.
+func (t *Txn) Commit() (*Snapshot, error) {
+	if t.done {
+		return nil, ErrDone
+	}
+	defer t.Discard()
+
+	d := t.d
+	d.commitMu.Lock()
+	defer d.commitMu.Unlock()
+	cur := d.Snapshot()
+	if len(t.ops) == 0 {
+		return cur, nil
+	}
+
+	root, err := t.tree()
+	if err != nil {
+		return nil, err
+	}
+	if cur != t.base {
+		c, err := copyRoot(cur.root)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range t.ops {
+			if err := op(c); err != nil {
+				return nil, fmt.Errorf("cannot replay transaction on version %d: %v", cur.version, err)
+			}
+		}
+		root = c
+	}
+	if err := root.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid data tree: %v", err)
+	}
+	n, err := ygot.Diff(cur.root, root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot compute changes to data tree: %v", err)
+	}
+
+	ns := &Snapshot{version: cur.version + 1, schema: d.schema, root: root}
+	d.snap.Store(ns)
+	if len(n.GetUpdate()) != 0 || len(n.GetDelete()) != 0 {
+		n.Timestamp = time.Now().UnixNano()
+		d.subsMu.Lock()
+		for s := range d.subs {
+			s.publish(n)
+		}
+		d.subsMu.Unlock()
+	}
+	return ns, nil
+}
+
+// copyRoot returns a deep copy of the data tree root.
+func copyRoot(root ygot.ValidatedGoStruct) (ygot.ValidatedGoStruct, error) {
+	c, err := ygot.DeepCopy(root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot copy data tree: %v", err)
+	}
+	return c.(ygot.ValidatedGoStruct), nil
+}
+
+// pathString returns the string form of the path p, for use in errors.
+func pathString(p *gnmipb.Path) string {
+	s, err := ygot.PathToString(p)
+	if err != nil {
+		return p.String()
+	}
+	return s
+}
@@ -0,0 +1,353 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/devicetest"
+	"github.com/openconfig/ygot/ygot"
+	"github.com/openconfig/ygot/ytypes"
+)
+
+// newTestDatastore returns a Datastore whose data tree is the root of the
+// test schema.
+func newTestDatastore(t *testing.T) *Datastore {
+	t.Helper()
+	d, err := New(devicetest.NewSchema())
+	if err != nil {
+		t.Fatalf("New: got unexpected error: %v", err)
+	}
+	return d
+}
+
+// mtu returns the MTU of the interface name within the snapshot s, or 0 if
+// the interface does not exist.
+func mtu(t *testing.T, s *Snapshot, name string) uint16 {
+	t.Helper()
+	nodes, err := s.Get(devicetest.MustPath(fmt.Sprintf("/interfaces/interface[name=%s]/mtu", name)))
+	if err != nil || len(nodes) != 1 {
+		return 0
+	}
+	return *nodes[0].Data.(*uint16)
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(&ytypes.Schema{}); err == nil {
+		t.Errorf("New(empty schema): did not get expected error")
+	}
+	s := devicetest.NewSchema()
+	s.SchemaTree = map[string]*yang.Entry{}
+	if _, err := New(s); err == nil {
+		t.Errorf("New(schema without root): did not get expected error")
+	}
+	d, err := New(devicetest.NewSchema())
+	if err != nil {
+		t.Fatalf("New: got unexpected error: %v", err)
+	}
+	if got := d.Snapshot().Version(); got != 0 {
+		t.Errorf("New: got initial version %d, want 0", got)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	d := newTestDatastore(t)
+	s0 := d.Snapshot()
+
+	root, err := s0.Root()
+	if err != nil {
+		t.Fatalf("Root: got unexpected error: %v", err)
+	}
+	root.(*devicetest.Device).Interfaces.Interface["eth0"].Mtu = ygot.Uint16(9000)
+	if got := mtu(t, s0, "eth0"); got != 1500 {
+		t.Errorf("after modifying copy of root: got MTU %d, want 1500", got)
+	}
+
+	t1 := d.Begin()
+	if err := t1.Set(devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), devicetest.UintVal(9000)); err != nil {
+		t.Fatalf("Set: got unexpected error: %v", err)
+	}
+	if got := mtu(t, d.Snapshot(), "eth0"); got != 1500 {
+		t.Errorf("before commit: got MTU %d, want 1500", got)
+	}
+	s1, err := t1.Commit()
+	if err != nil {
+		t.Fatalf("Commit: got unexpected error: %v", err)
+	}
+	if got := s1.Version(); got != 1 {
+		t.Errorf("Commit: got version %d, want 1", got)
+	}
+	if d.Snapshot() != s1 {
+		t.Errorf("Commit: returned snapshot is not current snapshot")
+	}
+	if got := mtu(t, s1, "eth0"); got != 9000 {
+		t.Errorf("after commit: got MTU %d, want 9000", got)
+	}
+	if got := mtu(t, s0, "eth0"); got != 1500 {
+		t.Errorf("after commit: got MTU %d in previous snapshot, want 1500", got)
+	}
+
+	nodes, err := s1.Get(devicetest.MustPath("/interfaces/interface[name=*]/name"))
+	if err != nil {
+		t.Fatalf("Get with wildcard: got unexpected error: %v", err)
+	}
+	if len(nodes) != 1 || *nodes[0].Data.(*string) != "eth0" {
+		t.Errorf("Get with wildcard: got %v, want eth0", nodes)
+	}
+}
+
+func TestTxn(t *testing.T) {
+	tests := []struct {
+		desc string
+		// inOps are applied to the transaction in order.
+		inOps         func(*Txn) error
+		wantErrSubstr string
+		// wantCommitErrSubstr is the expected error from Commit.
+		wantCommitErrSubstr string
+		wantVersion         uint64
+		wantMTU             map[string]uint16
+	}{{
+		desc: "set leaves creating list entry",
+		inOps: func(t *Txn) error {
+			if err := t.Set(devicetest.MustPath("/interfaces/interface[name=eth1]/name"), devicetest.StringVal("eth1")); err != nil {
+				return err
+			}
+			return t.Set(devicetest.MustPath("/interfaces/interface[name=eth1]/mtu"), devicetest.UintVal(9000))
+		},
+		wantVersion: 1,
+		wantMTU:     map[string]uint16{"eth0": 1500, "eth1": 9000},
+	}, {
+		desc: "delete",
+		inOps: func(t *Txn) error {
+			if err := t.Delete(devicetest.MustPath("/system/uplink")); err != nil {
+				return err
+			}
+			return t.Delete(devicetest.MustPath("/interfaces/interface[name=eth0]"))
+		},
+		wantVersion: 1,
+		wantMTU:     map[string]uint16{"eth0": 0},
+	}, {
+		desc: "update",
+		inOps: func(t *Txn) error {
+			return t.Update(func(root ygot.ValidatedGoStruct) error {
+				root.(*devicetest.Device).Interfaces.Interface["eth0"].Mtu = ygot.Uint16(1400)
+				return nil
+			})
+		},
+		wantVersion: 1,
+		wantMTU:     map[string]uint16{"eth0": 1400},
+	}, {
+		desc:        "no changes",
+		inOps:       func(*Txn) error { return nil },
+		wantVersion: 0,
+		wantMTU:     map[string]uint16{"eth0": 1500},
+	}, {
+		desc: "failed change",
+		inOps: func(t *Txn) error {
+			return t.Set(devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), devicetest.StringVal("large"))
+		},
+		wantErrSubstr: "cannot set /interfaces/interface[name=eth0]/mtu",
+		wantVersion:   0,
+		wantMTU:       map[string]uint16{"eth0": 1500},
+	}, {
+		desc: "partially applied change after successful change",
+		inOps: func(t *Txn) error {
+			if err := t.Set(devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), devicetest.UintVal(9000)); err != nil {
+				return err
+			}
+			if err := t.Update(func(root ygot.ValidatedGoStruct) error {
+				root.(*devicetest.Device).Interfaces.Interface["eth0"].Mtu = ygot.Uint16(1400)
+				return errors.New("update failed")
+			}); err == nil {
+				return errors.New("Update: got nil error, want error")
+			}
+			return nil
+		},
+		wantVersion: 1,
+		wantMTU:     map[string]uint16{"eth0": 9000},
+	}, {
+		desc: "invalid data tree",
+		inOps: func(t *Txn) error {
+			return t.Set(devicetest.MustPath("/system/uplink"), devicetest.StringVal("eth1"))
+		},
+		wantCommitErrSubstr: "invalid data tree",
+		wantVersion:         0,
+		wantMTU:             map[string]uint16{"eth0": 1500},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			d := newTestDatastore(t)
+			txn := d.Begin()
+			err := tt.inOps(txn)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("did not get expected error, %s", diff)
+			}
+			_, err = txn.Commit()
+			if diff := errdiff.Substring(err, tt.wantCommitErrSubstr); diff != "" {
+				t.Fatalf("Commit: did not get expected error, %s", diff)
+			}
+			s := d.Snapshot()
+			if got := s.Version(); got != tt.wantVersion {
+				t.Errorf("got version %d, want %d", got, tt.wantVersion)
+			}
+			for name, want := range tt.wantMTU {
+				if got := mtu(t, s, name); got != want {
+					t.Errorf("got MTU %d for %s, want %d", got, name, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTxnGet(t *testing.T) {
+	d := newTestDatastore(t)
+	txn := d.Begin()
+	if err := txn.Set(devicetest.MustPath("/system/hostname"), devicetest.StringVal("rtr2")); err != nil {
+		t.Fatalf("Set: got unexpected error: %v", err)
+	}
+	nodes, err := txn.Get(devicetest.MustPath("/system/hostname"))
+	if err != nil {
+		t.Fatalf("Get: got unexpected error: %v", err)
+	}
+	if got := *nodes[0].Data.(*string); got != "rtr2" {
+		t.Errorf("Get: got hostname %s, want rtr2", got)
+	}
+	txn.Discard()
+	if _, err := txn.Get(devicetest.MustPath("/system/hostname")); err != ErrDone {
+		t.Errorf("Get after Discard: got error %v, want %v", err, ErrDone)
+	}
+	if _, err := txn.Commit(); err != ErrDone {
+		t.Errorf("Commit after Discard: got error %v, want %v", err, ErrDone)
+	}
+	if got := d.Snapshot().Version(); got != 0 {
+		t.Errorf("after Discard: got version %d, want 0", got)
+	}
+}
+
+func TestCommitReplay(t *testing.T) {
+	d := newTestDatastore(t)
+	t1 := d.Begin()
+	t2 := d.Begin()
+	t3 := d.Begin()
+	if err := t1.Set(devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), devicetest.UintVal(9000)); err != nil {
+		t.Fatalf("t1.Set: got unexpected error: %v", err)
+	}
+	if err := t2.Set(devicetest.MustPath("/system/hostname"), devicetest.StringVal("rtr2")); err != nil {
+		t.Fatalf("t2.Set: got unexpected error: %v", err)
+	}
+	// t3 is valid when applied to its base, but not once the interface
+	// that its uplink refers to has been deleted by a later commit.
+	if err := t3.Set(devicetest.MustPath("/system/uplink"), devicetest.StringVal("eth0")); err != nil {
+		t.Fatalf("t3.Set: got unexpected error: %v", err)
+	}
+
+	if _, err := t1.Commit(); err != nil {
+		t.Fatalf("t1.Commit: got unexpected error: %v", err)
+	}
+	s, err := t2.Commit()
+	if err != nil {
+		t.Fatalf("t2.Commit: got unexpected error: %v", err)
+	}
+	if got := s.Version(); got != 2 {
+		t.Errorf("t2.Commit: got version %d, want 2", got)
+	}
+	if got := mtu(t, s, "eth0"); got != 9000 {
+		t.Errorf("t2.Commit: got MTU %d, want change of t1 to be retained", got)
+	}
+	nodes, err := s.Get(devicetest.MustPath("/system/hostname"))
+	if err != nil {
+		t.Fatalf("Get: got unexpected error: %v", err)
+	}
+	if got := *nodes[0].Data.(*string); got != "rtr2" {
+		t.Errorf("t2.Commit: got hostname %s, want rtr2", got)
+	}
+
+	if _, err := d.Update(func(root ygot.ValidatedGoStruct) error {
+		root.(*devicetest.Device).Interfaces.Interface = nil
+		root.(*devicetest.Device).System.Uplink = nil
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	_, err = t3.Commit()
+	if diff := errdiff.Substring(err, "invalid data tree"); diff != "" {
+		t.Errorf("t3.Commit: did not get expected error, %s", diff)
+	}
+	if got := d.Snapshot().Version(); got != 3 {
+		t.Errorf("after t3.Commit: got version %d, want 3", got)
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	d := newTestDatastore(t)
+	const writers, commits = 8, 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("eth%d", i+1)
+			for j := 0; j < commits; j++ {
+				txn := d.Begin()
+				if err := txn.Set(devicetest.MustPath(fmt.Sprintf("/interfaces/interface[name=%s]/name", name)), devicetest.StringVal(name)); err != nil {
+					t.Errorf("Set: got unexpected error: %v", err)
+					return
+				}
+				if err := txn.Set(devicetest.MustPath(fmt.Sprintf("/interfaces/interface[name=%s]/mtu", name)), devicetest.UintVal(uint64(j+1))); err != nil {
+					t.Errorf("Set: got unexpected error: %v", err)
+					return
+				}
+				if _, err := txn.Commit(); err != nil {
+					t.Errorf("Commit: got unexpected error: %v", err)
+					return
+				}
+			}
+		}(i)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < commits; j++ {
+				s := d.Snapshot()
+				if _, err := s.Get(devicetest.MustPath("/interfaces/interface[name=*]/mtu")); err != nil {
+					t.Errorf("Get: got unexpected error: %v", err)
+					return
+				}
+				if _, err := s.Root(); err != nil {
+					t.Errorf("Root: got unexpected error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	s := d.Snapshot()
+	if got, want := s.Version(), uint64(writers*commits); got != want {
+		t.Errorf("got version %d, want %d", got, want)
+	}
+	for i := 0; i < writers; i++ {
+		name := fmt.Sprintf("eth%d", i+1)
+		if got := mtu(t, s, name); got != commits {
+			t.Errorf("got MTU %d for %s, want %d", got, name, commits)
+		}
+	}
+}
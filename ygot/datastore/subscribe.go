@@ -0,0 +1,232 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ErrClosed is returned by Subscription.Next once the subscription has been
+// closed, and all of the changes that it received beforehand have been
+// returned.
+var ErrClosed = errors.New("subscription is closed")
+
+// ErrOverflow is returned by Subscription.Next once the subscription has
+// been closed because more changes were queued for it than its queue limit,
+// since they were not consumed quickly enough. The queued changes are
+// discarded, and the consumer must subscribe again, and retrieve the
+// contents of the subtree from the snapshot of the new subscription, to
+// resynchronise.
+var ErrOverflow = errors.New("subscription queue limit exceeded")
+
+// DefaultQueueLimit is the maximum number of changes that are queued for a
+// subscription, unless a QueueLimit option is supplied to Subscribe.
+const DefaultQueueLimit = 1024
+
+// SubscribeOpt is an interface used for any option to be supplied to the
+// Subscribe method of a Datastore.
+type SubscribeOpt interface {
+	IsSubscribeOpt()
+}
+
+// QueueLimit is a SubscribeOpt that specifies the maximum number of changes
+// that are queued for a subscription before they are returned by Next. A
+// subscription whose queue exceeds the limit is closed, and ErrOverflow is
+// returned by Next.
+type QueueLimit struct {
+	// N is the maximum number of changes, which must be positive.
+	N int
+}
+
+// IsSubscribeOpt marks QueueLimit as a valid SubscribeOpt.
+func (*QueueLimit) IsSubscribeOpt() {}
+
+// Subscription receives the changes made to a subtree of the data tree of a
+// Datastore by each commit. It is safe for concurrent use.
+type Subscription struct {
+	d *Datastore
+	// path is the path of the subtree, which may contain wildcards, and
+	// whose element names have no module prefixes.
+	path *gnmipb.Path
+	// start is the snapshot at which the subscription started.
+	start *Snapshot
+
+	// limit is the maximum length of queue.
+	limit int
+
+	// mu protects queue, closed and overflowed.
+	mu     sync.Mutex
+	queue  []*gnmipb.Notification
+	closed bool
+	// overflowed indicates that s was closed since its queue exceeded
+	// limit.
+	overflowed bool
+	// ready is signalled when a notification is queued, or the subscription
+	// is closed.
+	ready chan struct{}
+}
+
+// Subscribe returns a subscription that receives the changes made by each
+// subsequent commit to the subtree of the data tree at path p. Within p, an
+// element named "*" matches any single element, an element named "..."
+// matches any number of elements, and a key whose value is "*", or that is
+// not specified, matches any value of that key, as per util.PathMatchesQuery. A
+// nil path subscribes to the entire data tree. The snapshot from which the
+// changes are computed is returned by the Snapshot method of the
+// subscription, and can be used to retrieve the initial contents of the
+// subtree. At most DefaultQueueLimit changes, or the limit specified by a
+// QueueLimit option, are queued for the subscription, after which it is
+// closed, and ErrOverflow is returned by Next. The subscription must be
+// closed when it is no longer used.
+func (d *Datastore) Subscribe(p *gnmipb.Path, opts ...SubscribeOpt) (*Subscription, error) {
+	limit := DefaultQueueLimit
+	for _, o := range opts {
+		if ql, ok := o.(*QueueLimit); ok {
+			if ql.N <= 0 {
+				return nil, fmt.Errorf("invalid queue limit %d, must be positive", ql.N)
+			}
+			limit = ql.N
+		}
+	}
+	var elems []*gnmipb.PathElem
+	for _, e := range p.GetElem() {
+		if e.GetName() == "" {
+			return nil, errors.New("subscription path contains an element with no name")
+		}
+		elems = append(elems, &gnmipb.PathElem{Name: util.StripModulePrefix(e.GetName()), Key: e.GetKey()})
+	}
+	s := &Subscription{
+		d:     d,
+		path:  &gnmipb.Path{Elem: elems},
+		limit: limit,
+		ready: make(chan struct{}, 1),
+	}
+
+	// Holding commitMu ensures that no commit is made between the start
+	// snapshot being retrieved and the subscription being registered.
+	d.commitMu.Lock()
+	defer d.commitMu.Unlock()
+	s.start = d.Snapshot()
+	d.subsMu.Lock()
+	d.subs[s] = true
+	d.subsMu.Unlock()
+	return s, nil
+}
+
+// Snapshot returns the snapshot of the data tree at which s started. The
+// first change returned by Next is relative to this snapshot.
+func (s *Subscription) Snapshot() *Snapshot {
+	return s.start
+}
+
+// Next returns the changes made to the subtree of s by the next commit that
+// modified it, blocking until such a commit is made, ctx is done, or s is
+// closed. The Update and Delete fields of the returned notification contain
+// only the paths within the subtree of s, and its Timestamp is the time of
+// the commit. The returned notification must not be modified. ErrOverflow is
+// returned if the queue limit of s was exceeded.
+func (s *Subscription) Next(ctx context.Context) (*gnmipb.Notification, error) {
+	for {
+		s.mu.Lock()
+		if len(s.queue) != 0 {
+			n := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			return n, nil
+		}
+		closed, overflowed := s.closed, s.overflowed
+		s.mu.Unlock()
+		switch {
+		case overflowed:
+			return nil, ErrOverflow
+		case closed:
+			return nil, ErrClosed
+		}
+
+		select {
+		case <-s.ready:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close closes the subscription s, such that it no longer receives changes.
+// Changes that s has already received continue to be returned by Next.
+func (s *Subscription) Close() {
+	s.d.subsMu.Lock()
+	delete(s.d.subs, s)
+	s.d.subsMu.Unlock()
+
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.signal()
+}
+
+// publish queues the changes within the notification n that are within the
+// subtree of s. It does not block. If the queue limit of s is exceeded, the
+// queued changes are discarded, and s is closed and removed from the
+// subscriptions of its Datastore. It must be called with the subsMu mutex of
+// the Datastore held.
+func (s *Subscription) publish(n *gnmipb.Notification) {
+	f := &gnmipb.Notification{Timestamp: n.GetTimestamp(), Prefix: n.GetPrefix()}
+	for _, u := range n.GetUpdate() {
+		if util.PathMatchesQuery(u.GetPath(), s.path) {
+			f.Update = append(f.Update, u)
+		}
+	}
+	for _, p := range n.GetDelete() {
+		if util.PathMatchesQuery(p, s.path) {
+			f.Delete = append(f.Delete, p)
+		}
+	}
+	if len(f.Update) == 0 && len(f.Delete) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	if len(s.queue) >= s.limit {
+		s.queue = nil
+		s.closed, s.overflowed = true, true
+		s.mu.Unlock()
+		delete(s.d.subs, s)
+		s.signal()
+		return
+	}
+	s.queue = append(s.queue, f)
+	s.mu.Unlock()
+	s.signal()
+}
+
+// signal wakes a call to Next that is waiting for s, if there is one.
+func (s *Subscription) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
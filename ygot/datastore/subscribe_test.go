@@ -0,0 +1,296 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package datastore
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/ygot/internal/devicetest"
+	"github.com/openconfig/ygot/ygot"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// commitAll makes a commit that sets the MTU of eth0 to 9000, one that adds
+// the interface eth1, and one that deletes the uplink.
+func commitAll(t *testing.T, d *Datastore) {
+	t.Helper()
+	commits := []func(*Txn) error{
+		func(txn *Txn) error {
+			return txn.Set(devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), devicetest.UintVal(9000))
+		},
+		func(txn *Txn) error {
+			return txn.Set(devicetest.MustPath("/interfaces/interface[name=eth1]/name"), devicetest.StringVal("eth1"))
+		},
+		func(txn *Txn) error {
+			return txn.Delete(devicetest.MustPath("/system/uplink"))
+		},
+	}
+	for i, c := range commits {
+		txn := d.Begin()
+		if err := c(txn); err != nil {
+			t.Fatalf("commit %d: got unexpected error: %v", i, err)
+		}
+		if _, err := txn.Commit(); err != nil {
+			t.Fatalf("commit %d: got unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	mtuUpdate := &gnmipb.Update{Path: devicetest.MustPath("/interfaces/interface[name=eth0]/mtu"), Val: devicetest.UintVal(9000)}
+	eth1Update := &gnmipb.Update{Path: devicetest.MustPath("/interfaces/interface[name=eth1]/name"), Val: devicetest.StringVal("eth1")}
+	uplinkDelete := devicetest.MustPath("/system/uplink")
+
+	tests := []struct {
+		desc   string
+		inPath *gnmipb.Path
+		want   []*gnmipb.Notification
+	}{{
+		desc: "entire data tree",
+		want: []*gnmipb.Notification{
+			{Update: []*gnmipb.Update{mtuUpdate}},
+			{Update: []*gnmipb.Update{eth1Update}},
+			{Delete: []*gnmipb.Path{uplinkDelete}},
+		},
+	}, {
+		desc:   "container",
+		inPath: devicetest.MustPath("/system"),
+		want:   []*gnmipb.Notification{{Delete: []*gnmipb.Path{uplinkDelete}}},
+	}, {
+		desc:   "list entry",
+		inPath: devicetest.MustPath("/interfaces/interface[name=eth1]"),
+		want:   []*gnmipb.Notification{{Update: []*gnmipb.Update{eth1Update}}},
+	}, {
+		desc:   "wildcard key",
+		inPath: devicetest.MustPath("/interfaces/interface[name=*]/name"),
+		want:   []*gnmipb.Notification{{Update: []*gnmipb.Update{eth1Update}}},
+	}, {
+		desc:   "wildcard elements",
+		inPath: devicetest.MustPath("/.../mtu"),
+		want:   []*gnmipb.Notification{{Update: []*gnmipb.Update{mtuUpdate}}},
+	}, {
+		desc:   "wildcard element with module prefix",
+		inPath: devicetest.MustPath("/*/dev:uplink"),
+		want:   []*gnmipb.Notification{{Delete: []*gnmipb.Path{uplinkDelete}}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			d := newTestDatastore(t)
+			s, err := d.Subscribe(tt.inPath)
+			if err != nil {
+				t.Fatalf("Subscribe: got unexpected error: %v", err)
+			}
+			if got := s.Snapshot(); got != d.Snapshot() {
+				t.Errorf("Subscribe: got start snapshot version %d, want current snapshot", got.Version())
+			}
+			commitAll(t, d)
+			s.Close()
+
+			var got []*gnmipb.Notification
+			for {
+				n, err := s.Next(context.Background())
+				if err == ErrClosed {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Next: got unexpected error: %v", err)
+				}
+				if n.GetTimestamp() == 0 {
+					t.Errorf("Next: got notification without timestamp: %s", proto.CompactTextString(n))
+				}
+				got = append(got, devicetest.Normalize(n))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d notifications, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				if !proto.Equal(got[i], tt.want[i]) {
+					t.Errorf("notification %d: got %s, want %s", i, proto.CompactTextString(got[i]), proto.CompactTextString(tt.want[i]))
+				}
+			}
+		})
+	}
+}
+
+func TestSubscriptionNext(t *testing.T) {
+	d := newTestDatastore(t)
+	s, err := d.Subscribe(devicetest.MustPath("/system"))
+	if err != nil {
+		t.Fatalf("Subscribe: got unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Next(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Next without changes: got error %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	done := make(chan *gnmipb.Notification)
+	go func() {
+		n, err := s.Next(context.Background())
+		if err != nil {
+			t.Errorf("Next: got unexpected error: %v", err)
+		}
+		done <- n
+	}()
+	if _, err := d.Update(func(root ygot.ValidatedGoStruct) error {
+		root.(*devicetest.Device).System.Hostname = ygot.String("rtr2")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	want := &gnmipb.Notification{Update: []*gnmipb.Update{{Path: devicetest.MustPath("/system/hostname"), Val: devicetest.StringVal("rtr2")}}}
+	if got := <-done; !proto.Equal(devicetest.Normalize(got), want) {
+		t.Errorf("Next: got %s, want %s", proto.CompactTextString(got), proto.CompactTextString(want))
+	}
+
+	s.Close()
+	if _, err := d.Update(func(root ygot.ValidatedGoStruct) error {
+		root.(*devicetest.Device).System.Hostname = ygot.String("rtr3")
+		return nil
+	}); err != nil {
+		t.Fatalf("Update: got unexpected error: %v", err)
+	}
+	if _, err := s.Next(context.Background()); err != ErrClosed {
+		t.Errorf("Next after Close: got error %v, want %v", err, ErrClosed)
+	}
+}
+
+func TestSubscriptionOverflow(t *testing.T) {
+	d := newTestDatastore(t)
+	slow, err := d.Subscribe(devicetest.MustPath("/system"), &QueueLimit{N: 2})
+	if err != nil {
+		t.Fatalf("Subscribe: got unexpected error: %v", err)
+	}
+	defer slow.Close()
+	fast, err := d.Subscribe(devicetest.MustPath("/system"), &QueueLimit{N: 2})
+	if err != nil {
+		t.Fatalf("Subscribe: got unexpected error: %v", err)
+	}
+	defer fast.Close()
+
+	// The fast subscription consumes each change as it is committed, whereas
+	// the slow subscription consumes none of them.
+	for i, h := range []string{"rtr2", "rtr3", "rtr4"} {
+		if _, err := d.Update(func(root ygot.ValidatedGoStruct) error {
+			root.(*devicetest.Device).System.Hostname = ygot.String(h)
+			return nil
+		}); err != nil {
+			t.Fatalf("Update %d: got unexpected error: %v", i, err)
+		}
+		if _, err := fast.Next(context.Background()); err != nil {
+			t.Fatalf("Next %d of fast subscription: got unexpected error: %v", i, err)
+		}
+	}
+
+	if _, err := slow.Next(context.Background()); err != ErrOverflow {
+		t.Errorf("Next of slow subscription: got error %v, want %v", err, ErrOverflow)
+	}
+	d.subsMu.Lock()
+	registered := d.subs[slow]
+	d.subsMu.Unlock()
+	if registered {
+		t.Errorf("slow subscription is still registered after overflow")
+	}
+
+	if _, err := d.Subscribe(nil, &QueueLimit{}); err == nil {
+		t.Errorf("Subscribe with zero queue limit: did not get expected error")
+	}
+}
+
+func TestSubscribeInvalidPath(t *testing.T) {
+	d := newTestDatastore(t)
+	if _, err := d.Subscribe(&gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "system"}, {}}}); err == nil {
+		t.Errorf("Subscribe with empty element name: did not get expected error")
+	}
+}
+
+// TestConcurrentSubscriptions checks that each subscription receives the
+// changes of every commit made after its start snapshot, such that applying
+// them to that snapshot results in the final data tree, whilst commits and
+// subscriptions are made concurrently.
+func TestConcurrentSubscriptions(t *testing.T) {
+	d := newTestDatastore(t)
+	const writers, commits, subscribers = 4, 10, 4
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < commits; j++ {
+				if _, err := d.Update(func(root ygot.ValidatedGoStruct) error {
+					name := fmt.Sprintf("eth%d", i+1)
+					ifs := root.(*devicetest.Device).Interfaces.Interface
+					ifs[name] = &devicetest.Interface{Name: ygot.String(name), Mtu: ygot.Uint16(uint16(j + 1))}
+					return nil
+				}); err != nil {
+					t.Errorf("Update: got unexpected error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+
+	var subs []*Subscription
+	for i := 0; i < subscribers; i++ {
+		s, err := d.Subscribe(devicetest.MustPath("/interfaces/interface[name=*]/mtu"))
+		if err != nil {
+			t.Fatalf("Subscribe: got unexpected error: %v", err)
+		}
+		subs = append(subs, s)
+	}
+	wg.Wait()
+
+	final := map[string]uint64{"eth0": 1500}
+	for i := 0; i < writers; i++ {
+		final[fmt.Sprintf("eth%d", i+1)] = commits
+	}
+	for i, s := range subs {
+		s.Close()
+		got := map[string]uint64{}
+		nodes, err := s.Snapshot().Get(devicetest.MustPath("/interfaces/interface[name=*]/mtu"))
+		if err != nil {
+			t.Fatalf("subscription %d: Get: got unexpected error: %v", i, err)
+		}
+		for _, n := range nodes {
+			got[n.Path.GetElem()[1].GetKey()["name"]] = uint64(*n.Data.(*uint16))
+		}
+		for {
+			n, err := s.Next(context.Background())
+			if err == ErrClosed {
+				break
+			}
+			if err != nil {
+				t.Fatalf("subscription %d: Next: got unexpected error: %v", i, err)
+			}
+			for _, u := range n.GetUpdate() {
+				got[u.GetPath().GetElem()[1].GetKey()["name"]] = u.GetVal().GetUintVal()
+			}
+		}
+		for name, want := range final {
+			if got[name] != want {
+				t.Errorf("subscription %d: got MTU %d for %s, want %d", i, got[name], name, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxDecimal64Precision is the maximum number of fraction digits that a YANG
+// decimal64 value can have, as per RFC7950 Section 9.3.4.
+const maxDecimal64Precision = 18
+
+// Decimal64 is a type that is used for fields that have a YANG type of
+// decimal64 where the exact value of the field must be retained. It is
+// generated in place of float64 when the ExactDecimal64 code generation
+// option is set. The value is stored in its decimal string form, with exactly
+// as many digits after the decimal point as its precision, such that the
+// integer digits and precision of the value can be recovered without loss.
+// A Decimal64 is a string, rather than a struct, such that it is handled as a
+// leaf value when reflecting over a GoStruct.
+type Decimal64 string
+
+// NewDecimal64 returns the Decimal64 value that corresponds to digits
+// multiplied by 10^-precision. For example, NewDecimal64(150, 2) returns the
+// value 1.50.
+func NewDecimal64(digits int64, precision uint8) Decimal64 {
+	s := strconv.FormatInt(digits, 10)
+	var sign string
+	if digits < 0 {
+		sign, s = "-", s[1:]
+	}
+	if precision == 0 {
+		return Decimal64(sign + s)
+	}
+	if len(s) <= int(precision) {
+		s = strings.Repeat("0", int(precision)-len(s)+1) + s
+	}
+	i := len(s) - int(precision)
+	return Decimal64(fmt.Sprintf("%s%s.%s", sign, s[:i], s[i:]))
+}
+
+// ParseDecimal64 parses the decimal string s, and returns it as a Decimal64
+// with the specified precision. An error is returned if s is not a valid
+// decimal value, if it cannot be represented with the specified precision
+// without loss, or if it is outside the range of a decimal64.
+func ParseDecimal64(s string, precision uint8) (Decimal64, error) {
+	if precision > maxDecimal64Precision {
+		return "", fmt.Errorf("invalid precision %d for decimal64 value %s, must be at most %d", precision, s, maxDecimal64Precision)
+	}
+
+	str := s
+	var sign string
+	if strings.HasPrefix(str, "-") {
+		sign, str = "-", str[1:]
+	}
+
+	ip, fp := str, ""
+	if i := strings.IndexByte(str, '.'); i != -1 {
+		ip, fp = str[:i], str[i+1:]
+		if fp == "" {
+			return "", fmt.Errorf("invalid decimal64 value %s, no digits after decimal point", s)
+		}
+	}
+	if ip == "" || !isDecimalDigits(ip) || !isDecimalDigits(fp) {
+		return "", fmt.Errorf("invalid decimal64 value %s", s)
+	}
+
+	fp = strings.TrimRight(fp, "0")
+	if len(fp) > int(precision) {
+		return "", fmt.Errorf("decimal64 value %s cannot be represented with precision %d", s, precision)
+	}
+	fp += strings.Repeat("0", int(precision)-len(fp))
+
+	d, err := strconv.ParseInt(sign+ip+fp, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("decimal64 value %s is out of range for precision %d", s, precision)
+	}
+	return NewDecimal64(d, precision), nil
+}
+
+// isDecimalDigits returns true if s consists only of the digits 0-9.
+func isDecimalDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Precision returns the number of digits after the decimal point of d.
+func (d Decimal64) Precision() uint8 {
+	if i := strings.IndexByte(string(d), '.'); i != -1 {
+		return uint8(len(d) - i - 1)
+	}
+	return 0
+}
+
+// Digits returns the value of d multiplied by 10^Precision, such that the
+// value of d is exactly Digits * 10^-Precision. An error is returned if d
+// is not a valid decimal64 value.
+func (d Decimal64) Digits() (int64, error) {
+	v, err := ParseDecimal64(string(d), d.Precision())
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.Replace(string(v), ".", "", 1), 10, 64)
+}
+
+// Float64 returns the closest float64 to the value of d. An error is returned
+// if d is not a valid decimal value.
+func (d Decimal64) Float64() (float64, error) {
+	if _, err := d.Digits(); err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(d), 64)
+}
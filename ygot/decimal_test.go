@@ -0,0 +1,217 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"math"
+	"testing"
+
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestNewDecimal64(t *testing.T) {
+	tests := []struct {
+		name        string
+		inDigits    int64
+		inPrecision uint8
+		want        Decimal64
+	}{{
+		name:        "simple value",
+		inDigits:    150,
+		inPrecision: 2,
+		want:        "1.50",
+	}, {
+		name:        "value less than one",
+		inDigits:    5,
+		inPrecision: 3,
+		want:        "0.005",
+	}, {
+		name:        "negative value",
+		inDigits:    -42,
+		inPrecision: 1,
+		want:        "-4.2",
+	}, {
+		name:        "negative value less than one",
+		inDigits:    -42,
+		inPrecision: 4,
+		want:        "-0.0042",
+	}, {
+		name:        "zero",
+		inDigits:    0,
+		inPrecision: 2,
+		want:        "0.00",
+	}, {
+		name:        "zero precision",
+		inDigits:    42,
+		inPrecision: 0,
+		want:        "42",
+	}, {
+		name:        "maximum precision",
+		inDigits:    300000000000000001,
+		inPrecision: 18,
+		want:        "0.300000000000000001",
+	}, {
+		name:        "minimum value",
+		inDigits:    math.MinInt64,
+		inPrecision: 18,
+		want:        "-9.223372036854775808",
+	}}
+
+	for _, tt := range tests {
+		if got := NewDecimal64(tt.inDigits, tt.inPrecision); got != tt.want {
+			t.Errorf("%s: NewDecimal64(%d, %d): got: %s, want: %s", tt.name, tt.inDigits, tt.inPrecision, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimal64(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               string
+		inPrecision      uint8
+		want             Decimal64
+		wantErrSubstring string
+	}{{
+		name:        "exact precision",
+		in:          "1.50",
+		inPrecision: 2,
+		want:        "1.50",
+	}, {
+		name:        "fewer fraction digits",
+		in:          "-1.5",
+		inPrecision: 3,
+		want:        "-1.500",
+	}, {
+		name:        "integer",
+		in:          "42",
+		inPrecision: 1,
+		want:        "42.0",
+	}, {
+		name:        "leading zeros",
+		in:          "007.1",
+		inPrecision: 1,
+		want:        "7.1",
+	}, {
+		name:        "trailing zeros beyond precision",
+		in:          "1.5000",
+		inPrecision: 1,
+		want:        "1.5",
+	}, {
+		name:        "value that cannot be represented exactly as float64",
+		in:          "0.300000000000000001",
+		inPrecision: 18,
+		want:        "0.300000000000000001",
+	}, {
+		name:             "too many fraction digits",
+		in:               "1.55",
+		inPrecision:      1,
+		wantErrSubstring: "decimal64 value 1.55 cannot be represented with precision 1",
+	}, {
+		name:             "out of range",
+		in:               "10",
+		inPrecision:      18,
+		wantErrSubstring: "decimal64 value 10 is out of range for precision 18",
+	}, {
+		name:             "invalid precision",
+		in:               "1",
+		inPrecision:      19,
+		wantErrSubstring: "invalid precision 19",
+	}, {
+		name:             "no digits after decimal point",
+		in:               "1.",
+		inPrecision:      1,
+		wantErrSubstring: "no digits after decimal point",
+	}, {
+		name:             "no integer digits",
+		in:               ".5",
+		inPrecision:      1,
+		wantErrSubstring: "invalid decimal64 value .5",
+	}, {
+		name:             "not a number",
+		in:               "1.5e3",
+		inPrecision:      1,
+		wantErrSubstring: "invalid decimal64 value 1.5e3",
+	}, {
+		name:             "empty string",
+		inPrecision:      1,
+		wantErrSubstring: "invalid decimal64 value",
+	}}
+
+	for _, tt := range tests {
+		got, err := ParseDecimal64(tt.in, tt.inPrecision)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: ParseDecimal64(%q, %d): did not get expected error, %s", tt.name, tt.in, tt.inPrecision, diff)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: ParseDecimal64(%q, %d): got: %s, want: %s", tt.name, tt.in, tt.inPrecision, got, tt.want)
+		}
+	}
+}
+
+func TestDecimal64Values(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               Decimal64
+		wantDigits       int64
+		wantPrecision    uint8
+		wantFloat        float64
+		wantErrSubstring string
+	}{{
+		name:          "simple value",
+		in:            "1.50",
+		wantDigits:    150,
+		wantPrecision: 2,
+		wantFloat:     1.5,
+	}, {
+		name:          "negative value",
+		in:            "-0.042",
+		wantDigits:    -42,
+		wantPrecision: 3,
+		wantFloat:     -0.042,
+	}, {
+		name:          "integer",
+		in:            "42",
+		wantDigits:    42,
+		wantPrecision: 0,
+		wantFloat:     42,
+	}, {
+		name:             "invalid value",
+		in:               "forty-two",
+		wantErrSubstring: "invalid decimal64 value forty-two",
+	}}
+
+	for _, tt := range tests {
+		if got := tt.in.Precision(); got != tt.wantPrecision {
+			t.Errorf("%s: (%s).Precision(): got: %d, want: %d", tt.name, tt.in, got, tt.wantPrecision)
+		}
+
+		gotDigits, err := tt.in.Digits()
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: (%s).Digits(): did not get expected error, %s", tt.name, tt.in, diff)
+		}
+		if gotDigits != tt.wantDigits {
+			t.Errorf("%s: (%s).Digits(): got: %d, want: %d", tt.name, tt.in, gotDigits, tt.wantDigits)
+		}
+
+		gotFloat, err := tt.in.Float64()
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: (%s).Float64(): did not get expected error, %s", tt.name, tt.in, diff)
+		}
+		if gotFloat != tt.wantFloat {
+			t.Errorf("%s: (%s).Float64(): got: %v, want: %v", tt.name, tt.in, gotFloat, tt.wantFloat)
+		}
+	}
+}
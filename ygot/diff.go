@@ -205,13 +205,43 @@ func getPathSpec(ni *util.NodeInfo) (*pathSpec, error) {
 // that the leaf is set to. YANG lists (Go maps), and containers (Go structs) are
 // not included within the returned map, such that only leaf or leaf-list values
 // that are set are returned.
+func findSetLeaves(s GoStruct, opts ...DiffOpt) (map[*pathSpec]interface{}, error) {
+	nodes, err := findSetNodes(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return nodes.leaves, nil
+}
+
+// setNodes stores the data nodes that are set within a GoStruct.
+type setNodes struct {
+	// leaves is a map, keyed by the path of each leaf or leaf-list that
+	// is set, of the value that the leaf is set to. Presence containers
+	// that are set are included, with an empty GoStruct as their value.
+	leaves map[*pathSpec]interface{}
+	// containers is a map, keyed by the path of each container or list
+	// member that is set, of the GoStruct that represents it. The
+	// GoStruct that the walk starts from is not included.
+	containers map[*pathSpec]GoStruct
+	// leafOrder stores the paths of the leaves in the order in which they
+	// were found, such that the leaves of members of ordered lists are in
+	// the order of the members.
+	leafOrder []*pathSpec
+	// orderedLists is a map, keyed by the path of each ordered list that
+	// is set, of the paths of its members in order.
+	orderedLists map[*pathSpec][]*pathSpec
+}
+
+// findSetNodes iteratively walks the fields of the supplied GoStruct, s, and
+// returns the leaves, containers and list members that are set within it.
 //
 // The ForEachDataField helper of the util library is used to perform the iterative
 // walk of the struct - using the out argument to store the set of changed leaves.
 // A specific Annotation is used to store the absolute path of the entity during
 // the walk.
-func findSetLeaves(s GoStruct, opts ...DiffOpt) (map[*pathSpec]interface{}, error) {
+func findSetNodes(s GoStruct, opts ...DiffOpt) (*setNodes, error) {
 	pathOpt := hasDiffPathOpt(opts)
+	filter := hasDiffFilterOpt(opts)
 	processedPaths := map[string]bool{}
 
 	findSetIterFunc := func(ni *util.NodeInfo, in, out interface{}) (errs util.Errors) {
@@ -264,7 +294,45 @@ func findSetLeaves(s GoStruct, opts ...DiffOpt) (map[*pathSpec]interface{}, erro
 
 		ni.Annotation = []interface{}{vp}
 
-		if util.IsNilOrInvalidValue(ni.FieldValue) || util.IsValueStructPtr(ni.FieldValue) || util.IsValueMap(ni.FieldValue) {
+		if util.IsNilOrInvalidValue(ni.FieldValue) || util.IsValueMap(ni.FieldValue) {
+			return
+		}
+
+		if util.IsValueOrderedMap(ni.FieldValue) {
+			var members []*pathSpec
+			for _, v := range util.OrderedMapValues(ni.FieldValue) {
+				kh, ok := v.Interface().(KeyHelperGoStruct)
+				if !ok {
+					return util.NewErrs(fmt.Errorf("member of ordered list %v is not a KeyHelperGoStruct", vp))
+				}
+				mp, err := nodeMapPath(kh, vp)
+				if err != nil {
+					return util.NewErrs(err)
+				}
+				members = append(members, mp)
+			}
+			out.(*setNodes).orderedLists[vp] = members
+			return
+		}
+
+		if util.IsValueStructPtr(ni.FieldValue) {
+			if gs, ok := ni.FieldValue.Interface().(GoStruct); ok {
+				out.(*setNodes).containers[vp] = gs
+			}
+			if !util.IsYangPresence(ni.StructField) {
+				return
+			}
+			// The existence of a presence container is data in itself, and
+			// hence it is handled as a leaf whose value is an empty instance
+			// of the container, such that its creation and deletion are
+			// reported.
+			if filter != nil {
+				if vp = filter.filterPathSpec(vp); vp == nil {
+					return
+				}
+			}
+			out.(*setNodes).leaves[vp] = reflect.New(ni.FieldValue.Type().Elem()).Interface()
+			out.(*setNodes).leafOrder = append(out.(*setNodes).leafOrder, vp)
 			return
 		}
 
@@ -278,13 +346,23 @@ func findSetLeaves(s GoStruct, opts ...DiffOpt) (map[*pathSpec]interface{}, erro
 			}
 		}
 
-		outs := out.(map[*pathSpec]interface{})
-		outs[vp] = ival
+		if filter != nil {
+			if vp = filter.filterPathSpec(vp); vp == nil {
+				return
+			}
+		}
+
+		out.(*setNodes).leaves[vp] = ival
+		out.(*setNodes).leafOrder = append(out.(*setNodes).leafOrder, vp)
 
 		return
 	}
 
-	out := map[*pathSpec]interface{}{}
+	out := &setNodes{
+		leaves:       map[*pathSpec]interface{}{},
+		containers:   map[*pathSpec]GoStruct{},
+		orderedLists: map[*pathSpec][]*pathSpec{},
+	}
 	if errs := util.ForEachDataField(s, nil, out, findSetIterFunc); errs != nil {
 		return nil, fmt.Errorf("error from ForEachDataField iteration: %v", errs)
 	}
@@ -305,6 +383,18 @@ func hasDiffPathOpt(opts []DiffOpt) *DiffPathOpt {
 	return nil
 }
 
+// hasDiffFilterOpt extracts a DiffFilterOpt from the opts slice provided. In
+// the case that there are multiple DiffFilterOpt structs within the opts
+// slice, the first is returned.
+func hasDiffFilterOpt(opts []DiffOpt) *DiffFilterOpt {
+	for _, o := range opts {
+		if f, ok := o.(*DiffFilterOpt); ok {
+			return f
+		}
+	}
+	return nil
+}
+
 // leastSpecificPath returns the path with the shortest length from the supplied
 // paths slice. If the slice contains two paths that are equal in length, the
 // first one encountered in the slice is returned.
@@ -326,7 +416,13 @@ func leastSpecificPath(paths [][]string) []string {
 // appendUpdate adds an update to the supplied gNMI Notification message corresponding
 // to the path and value supplied.
 func appendUpdate(n *gnmipb.Notification, path *pathSpec, val interface{}) error {
-	v, err := EncodeTypedValue(val, gnmipb.Encoding_PROTO)
+	// Presence containers are represented by an empty GoStruct, which cannot
+	// be encoded as a scalar value, and is hence encoded as RFC7951 JSON.
+	enc := gnmipb.Encoding_PROTO
+	if _, ok := val.(GoStruct); ok {
+		enc = gnmipb.Encoding_JSON_IETF
+	}
+	v, err := EncodeTypedValue(val, enc)
 	if err != nil {
 		return fmt.Errorf("cannot represent field value %v as TypedValue for path %v: %v", val, path, err)
 	}
@@ -364,6 +460,60 @@ type DiffPathOpt struct {
 // IsDiffOpt marks DiffPathOpt as a diff option.
 func (*DiffPathOpt) IsDiffOpt() {}
 
+// DiffFilterOpt is a DiffOpt that restricts the Diff function to the leaves
+// that are within a set of subtrees.
+type DiffFilterOpt struct {
+	// Paths is the set of paths of the subtrees that are compared. A leaf
+	// is compared if one of the paths is a prefix of its path. Within each
+	// path, an element named "*" matches any single element, and an element
+	// named "..." matches any number of elements. A key whose value is "*",
+	// or that is not specified, matches any value of that key, as per
+	// util.PathMatchesQuery.
+	Paths []*gnmipb.Path
+}
+
+// IsDiffOpt marks DiffFilterOpt as a diff option.
+func (*DiffFilterOpt) IsDiffOpt() {}
+
+// matches returns true if the path p is within one of the subtrees of the
+// filter f. All paths are within a nil filter.
+func (f *DiffFilterOpt) matches(p *gnmipb.Path) bool {
+	if f == nil {
+		return true
+	}
+	for _, fp := range f.Paths {
+		if util.PathMatchesQuery(p, fp) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterPathSpec returns a pathSpec containing the paths of p that are within
+// the filter f, or nil if there are no such paths.
+func (f *DiffFilterOpt) filterPathSpec(p *pathSpec) *pathSpec {
+	var paths []*gnmipb.Path
+	for _, gp := range p.gNMIPaths {
+		if f.matches(gp) {
+			paths = append(paths, gp)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return &pathSpec{gNMIPaths: paths}
+}
+
+// ListRename describes a member of a keyed list whose keys differ between
+// the original and modified GoStructs supplied to DiffRenames, but whose
+// other contents are equal.
+type ListRename struct {
+	// From is the path of the list member within the original GoStruct.
+	From *gnmipb.Path
+	// To is the path of the list member within the modified GoStruct.
+	To *gnmipb.Path
+}
+
 // Diff takes an original and modified GoStruct, which must be of the same type
 // and returns a gNMI Notification that contains the diff between them. The original
 // struct is considered as the "from" data, with the modified struct the "to" such that:
@@ -377,6 +527,13 @@ func (*DiffPathOpt) IsDiffOpt() {}
 // Annotation fields that are contained within the supplied original or modified
 // GoStruct are skipped.
 //
+// Since the position of a member of an ordered-by user list cannot be expressed
+// within a gNMI Notification, the reordering of such a list is reported by
+// deleting each member that follows the first member whose position differs,
+// and then updating all of the leaves of the members that follow it, in the
+// order of the modified list. The updates for these members are at the end of
+// the Update field of the notification.
+//
 // A set of options for diff's behaviour, as specified by the supplied DiffOpts
 // can be used to modify the behaviour of the Diff function per the individual
 // option's specification.
@@ -386,24 +543,32 @@ func (*DiffPathOpt) IsDiffOpt() {}
 // to the fields specified if a GoStruct that does not represent the root of
 // a YANG schema tree is not supplied as original and modified.
 func Diff(original, modified GoStruct, opts ...DiffOpt) (*gnmipb.Notification, error) {
-
 	if reflect.TypeOf(original) != reflect.TypeOf(modified) {
 		return nil, fmt.Errorf("cannot diff structs of different types, original: %T, modified: %T", original, modified)
 	}
 
-	origLeaves, err := findSetLeaves(original, opts...)
+	origNodes, err := findSetNodes(original, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not extract set leaves from original struct: %v", err)
 	}
 
-	modLeaves, err := findSetLeaves(modified, opts...)
+	modNodes, err := findSetNodes(modified, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not extract set leaves from modified struct: %v", err)
 	}
+	origLeaves, modLeaves := origNodes.leaves, modNodes.leaves
+
+	moved, movedInOrig, err := reorderedMembers(origNodes, modNodes, hasDiffFilterOpt(opts))
+	if err != nil {
+		return nil, err
+	}
 
 	matched := map[*pathSpec]bool{}
 	n := &gnmipb.Notification{}
 	for origPath, origVal := range origLeaves {
+		if withinMembers(origPath, moved) {
+			continue
+		}
 		var origMatched bool
 		for modPath, modVal := range modLeaves {
 			if origPath.Equal(modPath) {
@@ -432,7 +597,23 @@ func Diff(original, modified GoStruct, opts ...DiffOpt) (*gnmipb.Notification, e
 	// Check that all paths that are in the modified struct have been examined, if
 	// not they are updates.
 	for modPath, modVal := range modLeaves {
-		if !matched[modPath] {
+		if !matched[modPath] && !withinMembers(modPath, moved) {
+			if err := appendUpdate(n, modPath, modVal); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Re-add the members of ordered lists that were moved, in order.
+	for _, m := range moved {
+		if movedInOrig[m] {
+			n.Delete = append(n.Delete, m.gNMIPaths...)
+		}
+		for _, modPath := range modNodes.leafOrder {
+			modVal, ok := modLeaves[modPath]
+			if !ok || !withinMembers(modPath, []*pathSpec{m}) {
+				continue
+			}
 			if err := appendUpdate(n, modPath, modVal); err != nil {
 				return nil, err
 			}
@@ -441,3 +622,308 @@ func Diff(original, modified GoStruct, opts ...DiffOpt) (*gnmipb.Notification, e
 
 	return n, nil
 }
+
+// reorderedMembers returns the members of the ordered lists within mod whose
+// position is not the same as within orig. Since a gNMI Notification cannot
+// express the position of a list member, all members that follow the first
+// member of a list that is not at the same position are returned, such that
+// they can be deleted and re-added in order. The members are returned in the
+// order of mod, along with a map indicating which of them are within orig.
+// Members whose paths are not within the supplied filter are not returned.
+func reorderedMembers(orig, mod *setNodes, filter *DiffFilterOpt) ([]*pathSpec, map[*pathSpec]bool, error) {
+	origLists := map[string][]*pathSpec{}
+	for lp, members := range orig.orderedLists {
+		k, err := pathSpecKey(lp)
+		if err != nil {
+			return nil, nil, err
+		}
+		origLists[k] = members
+	}
+
+	type modList struct {
+		key     string
+		members []*pathSpec
+	}
+	var modLists []*modList
+	for lp, members := range mod.orderedLists {
+		k, err := pathSpecKey(lp)
+		if err != nil {
+			return nil, nil, err
+		}
+		modLists = append(modLists, &modList{key: k, members: members})
+	}
+	sort.Slice(modLists, func(i, j int) bool { return modLists[i].key < modLists[j].key })
+
+	var moved []*pathSpec
+	inOrig := map[*pathSpec]bool{}
+	for _, ml := range modLists {
+		modKeys := map[string]bool{}
+		for _, m := range ml.members {
+			k, err := pathSpecKey(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			modKeys[k] = true
+		}
+
+		// retained stores the members of the original list that are also
+		// within the modified list, in their original order.
+		var retained []string
+		origKeys := map[string]bool{}
+		for _, m := range origLists[ml.key] {
+			k, err := pathSpecKey(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			origKeys[k] = true
+			if modKeys[k] {
+				retained = append(retained, k)
+			}
+		}
+
+		var diverged bool
+		for i, m := range ml.members {
+			k, err := pathSpecKey(m)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !diverged && i < len(retained) && retained[i] == k {
+				continue
+			}
+			diverged = true
+			if filter != nil && filter.filterPathSpec(m) == nil {
+				continue
+			}
+			moved = append(moved, m)
+			inOrig[m] = origKeys[k]
+		}
+	}
+
+	// Members of ordered lists that are within other moved members are
+	// re-added along with the member that they are within.
+	var out []*pathSpec
+	for _, m := range moved {
+		var nested bool
+		for _, o := range moved {
+			if o != m && withinMembers(m, []*pathSpec{o}) {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			out = append(out, m)
+		}
+	}
+	return out, inOrig, nil
+}
+
+// pathSpecKey returns a string that uniquely identifies the pathSpec p.
+func pathSpecKey(p *pathSpec) (string, error) {
+	var keys []string
+	for _, gp := range p.gNMIPaths {
+		s, err := PathToString(gp)
+		if err != nil {
+			return "", err
+		}
+		keys = append(keys, s)
+	}
+	return strings.Join(keys, "|"), nil
+}
+
+// DiffRenames returns the members of keyed lists that were renamed between
+// the original and modified GoStructs, which must be of the same type. A list
+// member is renamed if it is not present in the modified struct, and there is
+// a member of the same list that is only present in the modified struct with
+// at least one leaf other than its keys, and whose leaves other than its keys
+// are equal. The DiffFilterOpt option restricts the leaves that are compared,
+// as per Diff.
+//
+// The renames complement the Notification that is returned by Diff for the
+// same structs, which contains the deletion of the leaves of each renamed
+// member and the update of the leaves of the member that it is renamed to,
+// such that it can be applied by a target that does not support renaming list
+// members.
+func DiffRenames(original, modified GoStruct, opts ...DiffOpt) ([]*ListRename, error) {
+	if reflect.TypeOf(original) != reflect.TypeOf(modified) {
+		return nil, fmt.Errorf("cannot diff structs of different types, original: %T, modified: %T", original, modified)
+	}
+
+	origNodes, err := findSetNodes(original, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract set leaves from original struct: %v", err)
+	}
+
+	modNodes, err := findSetNodes(modified, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract set leaves from modified struct: %v", err)
+	}
+	return findRenames(origNodes, modNodes)
+}
+
+// withinMembers returns true if one of the paths of p is equal to, or a
+// descendant of, one of the paths of the supplied members.
+func withinMembers(p *pathSpec, members []*pathSpec) bool {
+	for _, m := range members {
+		for _, mp := range m.gNMIPaths {
+			for _, gp := range p.gNMIPaths {
+				if util.PathMatchesPathElemPrefix(gp, mp) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// listMember is a member of a keyed list that is set within a GoStruct.
+type listMember struct {
+	// path is the path of the member.
+	path *gnmipb.Path
+	// key is the string representation of path.
+	key string
+	// list is the string representation of path with the keys of the
+	// member removed.
+	list string
+	// contents is the set of values of the leaves of the member other than
+	// its keys, keyed by their path relative to the member.
+	contents map[string]interface{}
+}
+
+// findRenames returns the list members of the orig set nodes that are
+// renamed in the mod set nodes.
+func findRenames(orig, mod *setNodes) ([]*ListRename, error) {
+	origMembers, err := listMembers(orig)
+	if err != nil {
+		return nil, err
+	}
+	modMembers, err := listMembers(mod)
+	if err != nil {
+		return nil, err
+	}
+	inMod := map[string]bool{}
+	for _, m := range modMembers {
+		inMod[m.key] = true
+	}
+	inOrig := map[string]bool{}
+	for _, m := range origMembers {
+		inOrig[m.key] = true
+	}
+
+	var rs []*ListRename
+	used := map[string]bool{}
+	for _, o := range origMembers {
+		if inMod[o.key] || withinRenamed(o.path, rs, func(r *ListRename) *gnmipb.Path { return r.From }) {
+			continue
+		}
+		// A member that has no leaves other than its keys cannot be
+		// distinguished from any other such member.
+		if len(o.contents) == 0 {
+			continue
+		}
+		for _, m := range modMembers {
+			if inOrig[m.key] || used[m.key] || m.list != o.list || !reflect.DeepEqual(o.contents, m.contents) {
+				continue
+			}
+			used[m.key] = true
+			rs = append(rs, &ListRename{From: o.path, To: m.path})
+			break
+		}
+	}
+	return rs, nil
+}
+
+// listMembers returns the members of keyed lists that are within the set
+// nodes, sorted by their path. The contents of each member are found in a
+// single pass over the leaves, in which each leaf is added to the members
+// whose paths are prefixes of its path.
+func listMembers(nodes *setNodes) ([]*listMember, error) {
+	var members []*listMember
+	byKey := map[string]*listMember{}
+	memberKeys := map[*listMember]map[string]string{}
+	for ps, c := range nodes.containers {
+		kh, ok := c.(KeyHelperGoStruct)
+		if !ok || len(ps.gNMIPaths) == 0 {
+			continue
+		}
+		km, err := kh.ΛListKeyMap()
+		if err != nil {
+			return nil, err
+		}
+		keys, err := keyMapAsStrings(km)
+		if err != nil {
+			return nil, err
+		}
+
+		p := ps.gNMIPaths[0]
+		k, err := PathToString(p)
+		if err != nil {
+			return nil, err
+		}
+		lp := proto.Clone(p).(*gnmipb.Path)
+		lp.Elem[len(lp.Elem)-1].Key = nil
+		l, err := PathToString(lp)
+		if err != nil {
+			return nil, err
+		}
+
+		m := &listMember{path: p, key: k, list: l, contents: map[string]interface{}{}}
+		members = append(members, m)
+		byKey[k] = m
+		memberKeys[m] = keys
+	}
+
+	for lps, v := range nodes.leaves {
+		for _, leafPath := range lps.gNMIPaths {
+			elems := leafPath.GetElem()
+			for i := len(elems) - 1; i > 0; i-- {
+				if len(elems[i-1].GetKey()) == 0 {
+					continue
+				}
+				k, err := PathToString(&gnmipb.Path{Elem: elems[:i]})
+				if err != nil {
+					return nil, err
+				}
+				m, ok := byKey[k]
+				if !ok {
+					continue
+				}
+				rel := &gnmipb.Path{Elem: elems[i:]}
+				if isKeyLeaf(rel, v, memberKeys[m]) {
+					continue
+				}
+				rs, err := PathToString(rel)
+				if err != nil {
+					return nil, err
+				}
+				m.contents[rs] = v
+			}
+		}
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].key < members[j].key })
+	return members, nil
+}
+
+// isKeyLeaf returns true if the leaf at the path rel, relative to a list
+// member whose keys are keys, and whose value is v, is a key of the member.
+func isKeyLeaf(rel *gnmipb.Path, v interface{}, keys map[string]string) bool {
+	kv, ok := keys[rel.Elem[len(rel.Elem)-1].Name]
+	if !ok {
+		return false
+	}
+	if rv := reflect.ValueOf(v); util.IsValuePtr(rv) && !util.IsValueStructPtr(rv) {
+		v = rv.Elem().Interface()
+	}
+	s, err := KeyValueAsString(v)
+	return err == nil && s == kv
+}
+
+// withinRenamed returns true if the path p is equal to, or a descendant of,
+// the path returned by the path function for one of the renames rs.
+func withinRenamed(p *gnmipb.Path, rs []*ListRename, path func(*ListRename) *gnmipb.Path) bool {
+	for _, r := range rs {
+		if util.PathMatchesPathElemPrefix(p, path(r)) {
+			return true
+		}
+	}
+	return false
+}
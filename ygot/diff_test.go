@@ -17,6 +17,7 @@ package ygot
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 
@@ -1340,3 +1341,360 @@ func TestLeastSpecificPath(t *testing.T) {
 		}
 	}
 }
+
+// setReqTestDevice returns the device used as the original struct in tests
+// of the Diff filter and rename behaviour.
+func setReqTestDevice() *setReqDevice {
+	return &setReqDevice{
+		Interface: map[string]*setReqInterface{
+			"eth0": {Name: String("eth0"), Mtu: Uint16(1500)},
+			"eth1": {Name: String("eth1"), Mtu: Uint16(9000), Description: String("uplink")},
+		},
+		System: &setReqSystem{
+			Hostname: String("rtr1"),
+			Clock:    &setReqClock{TimezoneName: String("UTC")},
+		},
+	}
+}
+
+func TestDiffFilter(t *testing.T) {
+	modify := func(d *setReqDevice) {
+		d.Interface["eth0"].Mtu = Uint16(1400)
+		d.Interface["eth1"].Description = String("core")
+		d.Interface["eth2"] = &setReqInterface{Name: String("eth2")}
+		d.System.Hostname = String("rtr2")
+		d.System.Clock = nil
+	}
+
+	tests := []struct {
+		desc    string
+		inPaths []string
+		inOpts  []DiffOpt
+		want    func(t *testing.T) *gnmipb.Notification
+	}{{
+		desc:    "container subtree",
+		inPaths: []string{"/system"},
+		want: func(t *testing.T) *gnmipb.Notification {
+			return &gnmipb.Notification{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/system/config/hostname"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"rtr2"}},
+				}},
+				Delete: []*gnmipb.Path{mustPath(t, "/system/clock/config/timezone-name")},
+			}
+		},
+	}, {
+		desc:    "multiple subtrees",
+		inPaths: []string{"/system/config", "/interfaces/interface[name=eth1]"},
+		want: func(t *testing.T) *gnmipb.Notification {
+			return &gnmipb.Notification{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/system/config/hostname"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"rtr2"}},
+				}, {
+					Path: mustPath(t, "/interfaces/interface[name=eth1]/config/description"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"core"}},
+				}},
+			}
+		},
+	}, {
+		desc:    "wildcard key",
+		inPaths: []string{"/interfaces/interface[name=*]/config/mtu"},
+		want: func(t *testing.T) *gnmipb.Notification {
+			return &gnmipb.Notification{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth0]/config/mtu"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{1400}},
+				}},
+			}
+		},
+	}, {
+		desc:    "only matching paths of a field with multiple paths",
+		inPaths: []string{"/interfaces/interface[name=eth2]/config"},
+		want: func(t *testing.T) *gnmipb.Notification {
+			return &gnmipb.Notification{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth2]/config/name"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth2"}},
+				}},
+			}
+		},
+	}, {
+		desc:    "multi-level wildcard",
+		inPaths: []string{"/.../timezone-name"},
+		want: func(t *testing.T) *gnmipb.Notification {
+			return &gnmipb.Notification{
+				Delete: []*gnmipb.Path{mustPath(t, "/system/clock/config/timezone-name")},
+			}
+		},
+	}, {
+		desc:    "no matching paths",
+		inPaths: []string{"/interfaces/interface[name=eth3]"},
+		want:    func(*testing.T) *gnmipb.Notification { return &gnmipb.Notification{} },
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			f := &DiffFilterOpt{}
+			for _, p := range tt.inPaths {
+				f.Paths = append(f.Paths, mustPath(t, p))
+			}
+			mod := setReqTestDevice()
+			modify(mod)
+
+			got, err := Diff(setReqTestDevice(), mod, append(tt.inOpts, f)...)
+			if err != nil {
+				t.Fatalf("Diff: got unexpected error: %v", err)
+			}
+			if want := tt.want(t); !testutil.NotificationSetEqual([]*gnmipb.Notification{want}, []*gnmipb.Notification{got}) {
+				t.Errorf("Diff: did not get expected Notification, diff(-got,+want):\n%s", pretty.Compare(got, want))
+			}
+		})
+	}
+}
+
+func TestDiffRenames(t *testing.T) {
+	tests := []struct {
+		desc        string
+		inOrig      func(*setReqDevice)
+		inMod       func(*setReqDevice)
+		wantRenames []string
+	}{{
+		desc: "renamed list member",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth2"] = d.Interface["eth0"]
+			d.Interface["eth2"].Name = String("eth2")
+			delete(d.Interface, "eth0")
+			d.System.Hostname = String("rtr2")
+		},
+		wantRenames: []string{"/interfaces/interface[name=eth0] -> /interfaces/interface[name=eth2]"},
+	}, {
+		desc: "renamed members matched by contents",
+		inMod: func(d *setReqDevice) {
+			d.Interface = map[string]*setReqInterface{
+				"eth3": {Name: String("eth3"), Mtu: Uint16(9000), Description: String("uplink")},
+				"eth4": {Name: String("eth4"), Mtu: Uint16(1500)},
+			}
+		},
+		wantRenames: []string{
+			"/interfaces/interface[name=eth0] -> /interfaces/interface[name=eth4]",
+			"/interfaces/interface[name=eth1] -> /interfaces/interface[name=eth3]",
+		},
+	}, {
+		desc: "member with changed contents is not renamed",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth2"] = &setReqInterface{Name: String("eth2"), Mtu: Uint16(1400)}
+			delete(d.Interface, "eth0")
+		},
+	}, {
+		desc: "member with only keys is not renamed",
+		inOrig: func(d *setReqDevice) {
+			d.Interface["eth5"] = &setReqInterface{Name: String("eth5")}
+		},
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth6"] = &setReqInterface{Name: String("eth6")}
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, mod := setReqTestDevice(), setReqTestDevice()
+			if tt.inOrig != nil {
+				tt.inOrig(orig)
+			}
+			tt.inMod(mod)
+
+			got, err := DiffRenames(orig, mod)
+			if err != nil {
+				t.Fatalf("DiffRenames: got unexpected error: %v", err)
+			}
+			var gotRenames []string
+			for _, r := range got {
+				from, err := PathToString(r.From)
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", r.From, err)
+				}
+				to, err := PathToString(r.To)
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", r.To, err)
+				}
+				gotRenames = append(gotRenames, fmt.Sprintf("%s -> %s", from, to))
+			}
+			if diff := cmp.Diff(tt.wantRenames, gotRenames); diff != "" {
+				t.Errorf("DiffRenames: did not get expected renames, diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffOrderedList(t *testing.T) {
+	// memberUpdates returns the paths of the updates for the leaves of
+	// the members of the ordered list with the supplied names.
+	memberUpdates := func(names ...string) []string {
+		var paths []string
+		for _, n := range names {
+			paths = append(paths,
+				fmt.Sprintf("/lists/list[name=%s]/config/name", n),
+				fmt.Sprintf("/lists/list[name=%s]/name", n),
+				fmt.Sprintf("/lists/list[name=%s]/config/value", n))
+		}
+		return paths
+	}
+
+	tests := []struct {
+		desc        string
+		inOrig      *orderedTestRoot
+		inMod       *orderedTestRoot
+		wantUpdates []string
+		wantDeletes []string
+	}{{
+		desc:   "unchanged list",
+		inOrig: &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two")},
+		inMod:  &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two")},
+	}, {
+		desc:        "new list is updated in order",
+		inOrig:      &orderedTestRoot{},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("c", "three", "a", "one", "b", "two")},
+		wantUpdates: memberUpdates("c", "a", "b"),
+	}, {
+		desc:        "member appended",
+		inOrig:      &orderedTestRoot{List: newOrderedTestList("a", "one")},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two")},
+		wantUpdates: memberUpdates("b"),
+	}, {
+		desc:        "leaf of member changed",
+		inOrig:      &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two")},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "2")},
+		wantUpdates: []string{"/lists/list[name=b]/config/value"},
+	}, {
+		desc:        "members reordered",
+		inOrig:      &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two", "c", "three")},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("a", "one", "c", "three", "b", "two")},
+		wantUpdates: memberUpdates("c", "b"),
+		wantDeletes: []string{"/lists/list[name=b]", "/lists/list[name=c]"},
+	}, {
+		desc:        "member inserted",
+		inOrig:      &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two")},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("c", "three", "a", "one", "b", "two")},
+		wantUpdates: memberUpdates("c", "a", "b"),
+		wantDeletes: []string{"/lists/list[name=a]", "/lists/list[name=b]"},
+	}, {
+		desc:        "member deleted",
+		inOrig:      &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two", "c", "three")},
+		inMod:       &orderedTestRoot{List: newOrderedTestList("a", "one", "c", "three")},
+		wantDeletes: []string{"/lists/list[name=b]/config/name", "/lists/list[name=b]/config/value", "/lists/list[name=b]/name"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Diff(tt.inOrig, tt.inMod)
+			if err != nil {
+				t.Fatalf("Diff: got unexpected error: %v", err)
+			}
+
+			var gotUpdates, gotDeletes []string
+			for _, u := range got.GetUpdate() {
+				p, err := PathToString(u.GetPath())
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", u.GetPath(), err)
+				}
+				gotUpdates = append(gotUpdates, p)
+			}
+			for _, d := range got.GetDelete() {
+				p, err := PathToString(d)
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", d, err)
+				}
+				gotDeletes = append(gotDeletes, p)
+			}
+			sort.Strings(gotDeletes)
+
+			if diff := cmp.Diff(tt.wantUpdates, gotUpdates); diff != "" {
+				t.Errorf("Diff: did not get expected updates, diff(-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeletes, gotDeletes); diff != "" {
+				t.Errorf("Diff: did not get expected deletes, diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffPresenceContainer(t *testing.T) {
+	emptyVal := &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{[]byte("{}")}}
+
+	tests := []struct {
+		desc        string
+		inOrig      *presenceTestRoot
+		inMod       *presenceTestRoot
+		wantUpdates map[string]*gnmipb.TypedValue
+		wantDeletes []string
+	}{{
+		desc:   "unchanged presence container",
+		inOrig: &presenceTestRoot{Logging: &presenceTestLogging{}},
+		inMod:  &presenceTestRoot{Logging: &presenceTestLogging{}},
+	}, {
+		desc:        "presence container enabled",
+		inOrig:      &presenceTestRoot{},
+		inMod:       &presenceTestRoot{Logging: &presenceTestLogging{}},
+		wantUpdates: map[string]*gnmipb.TypedValue{"/system/logging": emptyVal},
+	}, {
+		desc:        "presence container disabled",
+		inOrig:      &presenceTestRoot{Logging: &presenceTestLogging{}},
+		inMod:       &presenceTestRoot{},
+		wantDeletes: []string{"/system/logging"},
+	}, {
+		desc:   "presence container enabled with child",
+		inOrig: &presenceTestRoot{},
+		inMod:  &presenceTestRoot{Logging: &presenceTestLogging{Level: String("info")}},
+		wantUpdates: map[string]*gnmipb.TypedValue{
+			"/system/logging":       emptyVal,
+			"/system/logging/level": {Value: &gnmipb.TypedValue_StringVal{"info"}},
+		},
+	}, {
+		desc:        "child of presence container removed",
+		inOrig:      &presenceTestRoot{Logging: &presenceTestLogging{Level: String("info")}},
+		inMod:       &presenceTestRoot{Logging: &presenceTestLogging{}},
+		wantDeletes: []string{"/system/logging/level"},
+	}, {
+		desc:   "empty non-presence container is not reported",
+		inOrig: &presenceTestRoot{},
+		inMod:  &presenceTestRoot{Clock: &presenceTestLogging{}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := Diff(tt.inOrig, tt.inMod)
+			if err != nil {
+				t.Fatalf("Diff: got unexpected error: %v", err)
+			}
+
+			var gotUpdates map[string]*gnmipb.TypedValue
+			for _, u := range got.GetUpdate() {
+				p, err := PathToString(u.GetPath())
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", u.GetPath(), err)
+				}
+				if gotUpdates == nil {
+					gotUpdates = map[string]*gnmipb.TypedValue{}
+				}
+				gotUpdates[p] = u.GetVal()
+			}
+			var gotDeletes []string
+			for _, d := range got.GetDelete() {
+				p, err := PathToString(d)
+				if err != nil {
+					t.Fatalf("cannot convert path %v to string: %v", d, err)
+				}
+				gotDeletes = append(gotDeletes, p)
+			}
+			sort.Strings(gotDeletes)
+
+			if diff := cmp.Diff(tt.wantUpdates, gotUpdates, cmp.Comparer(proto.Equal)); diff != "" {
+				t.Errorf("Diff: did not get expected updates, diff(-want,+got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDeletes, gotDeletes); diff != "" {
+				t.Errorf("Diff: did not get expected deletes, diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Merge3Opt is an interface that is implemented by the options to the
+// Merge3 function.
+type Merge3Opt interface {
+	// IsMerge3Opt is a marker method for each Merge3Opt.
+	IsMerge3Opt()
+}
+
+// Merge3PreferOurs is a Merge3Opt that specifies that conflicting changes
+// are resolved by using the value from the ours struct.
+type Merge3PreferOurs struct{}
+
+// IsMerge3Opt marks Merge3PreferOurs as a Merge3Opt.
+func (*Merge3PreferOurs) IsMerge3Opt() {}
+
+// Merge3PreferTheirs is a Merge3Opt that specifies that conflicting changes
+// are resolved by using the value from the theirs struct.
+type Merge3PreferTheirs struct{}
+
+// IsMerge3Opt marks Merge3PreferTheirs as a Merge3Opt.
+func (*Merge3PreferTheirs) IsMerge3Opt() {}
+
+// conflictPolicy is the policy used to resolve a conflict during a three-way
+// merge.
+type conflictPolicy int
+
+const (
+	// conflictFail specifies that conflicts are returned as an error.
+	conflictFail conflictPolicy = iota
+	// conflictPreferOurs specifies that the ours value is used.
+	conflictPreferOurs
+	// conflictPreferTheirs specifies that the theirs value is used.
+	conflictPreferTheirs
+)
+
+// merge3Policy returns the conflict policy specified by opts. If there are
+// multiple policies within opts, the last is used.
+func merge3Policy(opts []Merge3Opt) conflictPolicy {
+	p := conflictFail
+	for _, o := range opts {
+		switch o.(type) {
+		case *Merge3PreferOurs:
+			p = conflictPreferOurs
+		case *Merge3PreferTheirs:
+			p = conflictPreferTheirs
+		}
+	}
+	return p
+}
+
+// Merge3 performs a three-way merge of the changes made in ours and theirs to
+// their common ancestor, base, returning a new ValidatedGoStruct, and the
+// paths at which the changes conflict. All of the supplied structs must be of
+// the same type, other than base, which may be nil to indicate that there is
+// no common ancestor.
+//
+// A value that is changed from its value in base in only one of ours or
+// theirs takes the changed value, and a value that is changed to the same
+// value in both is taken from either. A conflict occurs when a value is
+// changed to different values in ours and theirs, or when a container or list
+// member is deleted in one, and modified in the other. By default, conflicts
+// cause an error to be returned along with the conflicting paths. The
+// Merge3PreferOurs and Merge3PreferTheirs options resolve conflicts by using
+// the value from ours or theirs respectively, in which case the resolved
+// conflicts are returned without an error.
+//
+// Members of keyed lists (Go maps) are merged individually, and are
+// identified by their keys. Leaf-lists and keyless lists (Go slices) are
+// treated as a single value, since their entries have no identity, such that
+// any change to the contents of such a list in both ours and theirs is a
+// conflict unless the resulting lists are equal. Ordered-by user keyed lists
+// are also treated as a single value, since the order of their members cannot
+// be merged.
+func Merge3(base, ours, theirs ValidatedGoStruct, opts ...Merge3Opt) (ValidatedGoStruct, []*gnmipb.Path, error) {
+	t := reflect.TypeOf(ours)
+	switch {
+	case util.IsValueNil(ours) || util.IsValueNil(theirs):
+		return nil, nil, fmt.Errorf("cannot merge nil structs, ours: %v, theirs: %v", ours, theirs)
+	case t != reflect.TypeOf(theirs):
+		return nil, nil, fmt.Errorf("cannot merge structs that are not of matching types, %T != %T", ours, theirs)
+	case !util.IsValueNil(base) && t != reflect.TypeOf(base):
+		return nil, nil, fmt.Errorf("cannot merge structs that are not of matching types, base %T != %T", base, ours)
+	case !util.IsTypeStructPtr(t):
+		return nil, nil, fmt.Errorf("cannot merge non-struct pointer type %T", ours)
+	}
+
+	var bv reflect.Value
+	if !util.IsValueNil(base) {
+		bv = reflect.ValueOf(base).Elem()
+	}
+
+	m := &merger{policy: merge3Policy(opts)}
+	n := reflect.New(t.Elem())
+	if err := m.mergeStruct(n.Elem(), bv, reflect.ValueOf(ours).Elem(), reflect.ValueOf(theirs).Elem(), &gnmipb.Path{}); err != nil {
+		return nil, nil, err
+	}
+
+	conflicts := m.sortedConflicts()
+	if len(conflicts) != 0 && m.policy == conflictFail {
+		var ps []string
+		for _, p := range conflicts {
+			ps = append(ps, conflictPathString(p))
+		}
+		return nil, conflicts, fmt.Errorf("conflicting changes at paths %s", strings.Join(ps, ", "))
+	}
+	return n.Interface().(ValidatedGoStruct), conflicts, nil
+}
+
+// merger stores the state of a three-way merge.
+type merger struct {
+	// policy is the policy used to resolve conflicts.
+	policy conflictPolicy
+	// conflicts is the set of paths at which conflicts were found.
+	conflicts []*gnmipb.Path
+}
+
+// resolve records a conflict at path p, and returns the value of ours or
+// theirs that is used based on the merger's policy.
+func (m *merger) resolve(p *gnmipb.Path, ours, theirs reflect.Value) reflect.Value {
+	m.conflicts = append(m.conflicts, p)
+	if m.policy == conflictPreferTheirs {
+		return theirs
+	}
+	return ours
+}
+
+// sortedConflicts returns the conflicts found by the merger, sorted by path.
+func (m *merger) sortedConflicts() []*gnmipb.Path {
+	sort.Slice(m.conflicts, func(i, j int) bool {
+		return conflictPathString(m.conflicts[i]) < conflictPathString(m.conflicts[j])
+	})
+	return m.conflicts
+}
+
+// mergeStruct merges the fields of the base, ours and theirs structs into dst,
+// which is a struct of the same type that is at path p. Any of base, ours or
+// theirs may be an invalid reflect.Value to indicate that the struct is not
+// present.
+func (m *merger) mergeStruct(dst, base, ours, theirs reflect.Value, p *gnmipb.Path) error {
+	field := func(v reflect.Value, i int) reflect.Value {
+		if !v.IsValid() {
+			return reflect.Zero(dst.Type().Field(i).Type)
+		}
+		return v.Field(i)
+	}
+
+	for i := 0; i < dst.NumField(); i++ {
+		ft := dst.Type().Field(i)
+		fp, err := mergeFieldPath(p, ft)
+		if err != nil {
+			return err
+		}
+		b, o, t := field(base, i), field(ours, i), field(theirs, i)
+
+		switch {
+		case util.IsTypeOrderedMap(ft.Type):
+			err = m.mergeLeaf(dst.Field(i), b, o, t, fp)
+		case util.IsTypeStructPtr(ft.Type):
+			err = m.mergeContainer(dst.Field(i), b, o, t, fp)
+		case util.IsTypeMap(ft.Type):
+			err = m.mergeList(dst.Field(i), b, o, t, fp)
+		default:
+			err = m.mergeLeaf(dst.Field(i), b, o, t, fp)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeContainer merges the base, ours and theirs struct pointers, which
+// represent a container or list member at path p, into dst.
+func (m *merger) mergeContainer(dst, base, ours, theirs reflect.Value, p *gnmipb.Path) error {
+	oNil, tNil := util.IsNilOrInvalidValue(ours), util.IsNilOrInvalidValue(theirs)
+	switch {
+	case oNil && tNil:
+		return nil
+	case !oNil && !tNil:
+		var b reflect.Value
+		if !util.IsNilOrInvalidValue(base) {
+			b = base.Elem()
+		}
+		n := reflect.New(dst.Type().Elem())
+		if err := m.mergeStruct(n.Elem(), b, ours.Elem(), theirs.Elem(), p); err != nil {
+			return err
+		}
+		dst.Set(n)
+		return nil
+	}
+
+	// The container is present in only one of ours and theirs, such that it
+	// was either added by one, or deleted by the other.
+	present := ours
+	if oNil {
+		present = theirs
+	}
+	var v reflect.Value
+	switch {
+	case util.IsNilOrInvalidValue(base):
+		v = present
+	case reflect.DeepEqual(present.Interface(), base.Interface()):
+		return nil
+	default:
+		v = m.resolve(p, ours, theirs)
+	}
+	if util.IsNilOrInvalidValue(v) {
+		return nil
+	}
+	return copyField(dst, v)
+}
+
+// mergeList merges the base, ours and theirs maps, which represent a keyed
+// list at path p, into dst. Each list member is merged as a container.
+func (m *merger) mergeList(dst, base, ours, theirs reflect.Value, p *gnmipb.Path) error {
+	var keys []reflect.Value
+	seen := map[interface{}]bool{}
+	for _, v := range []reflect.Value{base, ours, theirs} {
+		if util.IsNilOrInvalidValue(v) {
+			continue
+		}
+		for _, k := range v.MapKeys() {
+			if !seen[k.Interface()] {
+				seen[k.Interface()] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	entry := func(v, k reflect.Value) reflect.Value {
+		if util.IsNilOrInvalidValue(v) {
+			return reflect.Value{}
+		}
+		return v.MapIndex(k)
+	}
+
+	nm := reflect.MakeMap(dst.Type())
+	for _, k := range keys {
+		b, o, t := entry(base, k), entry(ours, k), entry(theirs, k)
+		mp, err := mergeMemberPath(p, b, o, t)
+		if err != nil {
+			return err
+		}
+		d := reflect.New(dst.Type().Elem()).Elem()
+		if err := m.mergeContainer(d, b, o, t, mp); err != nil {
+			return err
+		}
+		if !d.IsNil() {
+			nm.SetMapIndex(k, d)
+		}
+	}
+	if nm.Len() != 0 {
+		dst.Set(nm)
+	}
+	return nil
+}
+
+// mergeLeaf merges the base, ours and theirs values, which represent a leaf,
+// leaf-list or keyless list at path p, into dst. The values are compared as a
+// whole.
+func (m *merger) mergeLeaf(dst, base, ours, theirs reflect.Value, p *gnmipb.Path) error {
+	var v reflect.Value
+	switch o, t, b := ours.Interface(), theirs.Interface(), base.Interface(); {
+	case reflect.DeepEqual(o, t), reflect.DeepEqual(t, b):
+		v = ours
+	case reflect.DeepEqual(o, b):
+		v = theirs
+	default:
+		v = m.resolve(p, ours, theirs)
+	}
+	if err := copyField(dst, v); err != nil {
+		return fmt.Errorf("cannot copy value at %s: %v", conflictPathString(p), err)
+	}
+	return nil
+}
+
+// mergeFieldPath returns the path of the struct field ft, whose parent is at
+// path p. Where the field has multiple paths, the shortest is used.
+func mergeFieldPath(p *gnmipb.Path, ft reflect.StructField) (*gnmipb.Path, error) {
+	sp, err := util.SchemaPaths(ft)
+	if err != nil {
+		return nil, err
+	}
+	if len(sp) == 0 {
+		return nil, fmt.Errorf("invalid schema path for %s", ft.Name)
+	}
+	return joingNMIPaths(p, schemaPathTogNMIPath(leastSpecificPath(sp))), nil
+}
+
+// mergeMemberPath returns the path of a member of the list at path p, which
+// is represented by one of the base, ours or theirs values.
+func mergeMemberPath(p *gnmipb.Path, base, ours, theirs reflect.Value) (*gnmipb.Path, error) {
+	for _, v := range []reflect.Value{ours, theirs, base} {
+		if util.IsNilOrInvalidValue(v) {
+			continue
+		}
+		kh, ok := v.Interface().(KeyHelperGoStruct)
+		if !ok {
+			break
+		}
+		ps, err := nodeMapPath(kh, &pathSpec{gNMIPaths: []*gnmipb.Path{proto.Clone(p).(*gnmipb.Path)}})
+		if err != nil {
+			return nil, err
+		}
+		return ps.gNMIPaths[0], nil
+	}
+	return p, nil
+}
+
+// conflictPathString returns the string form of the path p for use in error
+// messages and sorting.
+func conflictPathString(p *gnmipb.Path) string {
+	s, err := PathToString(p)
+	if err != nil {
+		return proto.CompactTextString(p)
+	}
+	return s
+}
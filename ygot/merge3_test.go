@@ -0,0 +1,277 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+type merge3Root struct {
+	Hostname  *string                 `path:"system/config/hostname"`
+	Server    []string                `path:"system/config/server"`
+	Clock     *merge3Clock            `path:"system/clock"`
+	Interface map[string]*merge3Intf  `path:"interfaces/interface"`
+	Entry     []*merge3Entry          `path:"entries/entry"`
+	Multi     map[merge3Key]*merge3MK `path:"multis/multi"`
+}
+
+func (*merge3Root) IsYANGGoStruct()                         {}
+func (*merge3Root) Validate(...ValidationOption) error      { return nil }
+func (*merge3Root) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type merge3Clock struct {
+	TimezoneName *string `path:"config/timezone-name"`
+}
+
+func (*merge3Clock) IsYANGGoStruct() {}
+
+type merge3Intf struct {
+	Name        *string `path:"config/name|name"`
+	Mtu         *uint16 `path:"config/mtu"`
+	Description *string `path:"config/description"`
+}
+
+func (*merge3Intf) IsYANGGoStruct() {}
+
+func (i *merge3Intf) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+type merge3Entry struct {
+	Value *string `path:"value"`
+}
+
+func (*merge3Entry) IsYANGGoStruct() {}
+
+type merge3Key struct {
+	A string
+	B uint32
+}
+
+type merge3MK struct {
+	A     *string `path:"a"`
+	B     *uint32 `path:"b"`
+	Value *string `path:"value"`
+}
+
+func (*merge3MK) IsYANGGoStruct() {}
+
+func (m *merge3MK) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"a": *m.A, "b": *m.B}, nil
+}
+
+func TestMerge3(t *testing.T) {
+	base := func() *merge3Root {
+		return &merge3Root{
+			Hostname: String("rtr1"),
+			Server:   []string{"s1"},
+			Clock:    &merge3Clock{TimezoneName: String("UTC")},
+			Interface: map[string]*merge3Intf{
+				"eth0": {Name: String("eth0"), Mtu: Uint16(1500)},
+				"eth1": {Name: String("eth1"), Description: String("uplink")},
+			},
+			Entry: []*merge3Entry{{Value: String("one")}},
+		}
+	}
+
+	tests := []struct {
+		desc          string
+		inBase        ValidatedGoStruct
+		inOurs        func(*merge3Root)
+		inTheirs      func(*merge3Root)
+		inOpts        []Merge3Opt
+		want          func(*merge3Root)
+		wantConflicts []string
+		wantErrSubstr string
+	}{{
+		desc:     "no changes",
+		inOurs:   func(*merge3Root) {},
+		inTheirs: func(*merge3Root) {},
+		want:     func(*merge3Root) {},
+	}, {
+		desc:     "non-overlapping changes",
+		inOurs:   func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs: func(r *merge3Root) { r.Interface["eth2"] = &merge3Intf{Name: String("eth2")} },
+		want: func(r *merge3Root) {
+			r.Hostname = String("rtr2")
+			r.Interface["eth2"] = &merge3Intf{Name: String("eth2")}
+		},
+	}, {
+		desc:     "same change in both",
+		inOurs:   func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs: func(r *merge3Root) { r.Hostname = String("rtr2") },
+		want:     func(r *merge3Root) { r.Hostname = String("rtr2") },
+	}, {
+		desc:     "different leaves of the same list member",
+		inOurs:   func(r *merge3Root) { r.Interface["eth0"].Mtu = Uint16(9000) },
+		inTheirs: func(r *merge3Root) { r.Interface["eth0"].Description = String("core") },
+		want: func(r *merge3Root) {
+			r.Interface["eth0"].Mtu = Uint16(9000)
+			r.Interface["eth0"].Description = String("core")
+		},
+	}, {
+		desc:     "deletions",
+		inOurs:   func(r *merge3Root) { r.Clock = nil },
+		inTheirs: func(r *merge3Root) { delete(r.Interface, "eth1") },
+		want: func(r *merge3Root) {
+			r.Clock = nil
+			delete(r.Interface, "eth1")
+		},
+	}, {
+		desc: "all list members deleted",
+		inOurs: func(r *merge3Root) {
+			delete(r.Interface, "eth0")
+		},
+		inTheirs: func(r *merge3Root) { r.Interface = nil },
+		want:     func(r *merge3Root) { r.Interface = nil },
+	}, {
+		desc:          "conflicting leaf",
+		inOurs:        func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs:      func(r *merge3Root) { r.Hostname = String("rtr3") },
+		wantConflicts: []string{"/system/config/hostname"},
+		wantErrSubstr: "conflicting changes at paths /system/config/hostname",
+	}, {
+		desc:          "conflicting leaf, prefer ours",
+		inOurs:        func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs:      func(r *merge3Root) { r.Hostname = String("rtr3") },
+		inOpts:        []Merge3Opt{&Merge3PreferOurs{}},
+		want:          func(r *merge3Root) { r.Hostname = String("rtr2") },
+		wantConflicts: []string{"/system/config/hostname"},
+	}, {
+		desc:          "conflicting leaf, prefer theirs",
+		inOurs:        func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs:      func(r *merge3Root) { r.Hostname = nil },
+		inOpts:        []Merge3Opt{&Merge3PreferTheirs{}},
+		want:          func(r *merge3Root) { r.Hostname = nil },
+		wantConflicts: []string{"/system/config/hostname"},
+	}, {
+		desc:          "member deleted and modified",
+		inOurs:        func(r *merge3Root) { r.Interface["eth0"].Mtu = Uint16(9000) },
+		inTheirs:      func(r *merge3Root) { delete(r.Interface, "eth0") },
+		wantConflicts: []string{"/interfaces/interface[name=eth0]"},
+		wantErrSubstr: "conflicting changes at paths /interfaces/interface[name=eth0]",
+	}, {
+		desc:          "member deleted and modified, prefer ours",
+		inOurs:        func(r *merge3Root) { r.Interface["eth0"].Mtu = Uint16(9000) },
+		inTheirs:      func(r *merge3Root) { delete(r.Interface, "eth0") },
+		inOpts:        []Merge3Opt{&Merge3PreferOurs{}},
+		want:          func(r *merge3Root) { r.Interface["eth0"].Mtu = Uint16(9000) },
+		wantConflicts: []string{"/interfaces/interface[name=eth0]"},
+	}, {
+		desc:          "container deleted and modified, prefer theirs",
+		inOurs:        func(r *merge3Root) { r.Clock.TimezoneName = String("PST") },
+		inTheirs:      func(r *merge3Root) { r.Clock = nil },
+		inOpts:        []Merge3Opt{&Merge3PreferTheirs{}},
+		want:          func(r *merge3Root) { r.Clock = nil },
+		wantConflicts: []string{"/system/clock"},
+	}, {
+		desc:   "member added in both",
+		inOurs: func(r *merge3Root) { r.Interface["eth2"] = &merge3Intf{Name: String("eth2"), Mtu: Uint16(1500)} },
+		inTheirs: func(r *merge3Root) {
+			r.Interface["eth2"] = &merge3Intf{Name: String("eth2"), Description: String("new")}
+		},
+		want: func(r *merge3Root) {
+			r.Interface["eth2"] = &merge3Intf{Name: String("eth2"), Mtu: Uint16(1500), Description: String("new")}
+		},
+	}, {
+		desc: "multi-keyed list",
+		inOurs: func(r *merge3Root) {
+			r.Multi = map[merge3Key]*merge3MK{{"a", 1}: {A: String("a"), B: Uint32(1), Value: String("x")}}
+		},
+		inTheirs: func(r *merge3Root) {
+			r.Multi = map[merge3Key]*merge3MK{{"a", 1}: {A: String("a"), B: Uint32(1), Value: String("y")}}
+		},
+		inOpts: []Merge3Opt{&Merge3PreferTheirs{}},
+		want: func(r *merge3Root) {
+			r.Multi = map[merge3Key]*merge3MK{{"a", 1}: {A: String("a"), B: Uint32(1), Value: String("y")}}
+		},
+		wantConflicts: []string{"/multis/multi[a=a][b=1]/value"},
+	}, {
+		desc:          "leaf-lists are compared as a whole",
+		inOurs:        func(r *merge3Root) { r.Server = []string{"s1", "s2"} },
+		inTheirs:      func(r *merge3Root) { r.Server = []string{"s1", "s3"} },
+		wantConflicts: []string{"/system/config/server"},
+		wantErrSubstr: "conflicting changes at paths /system/config/server",
+	}, {
+		desc:     "keyless list changed in one struct",
+		inOurs:   func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs: func(r *merge3Root) { r.Entry = append(r.Entry, &merge3Entry{Value: String("two")}) },
+		want: func(r *merge3Root) {
+			r.Hostname = String("rtr2")
+			r.Entry = append(r.Entry, &merge3Entry{Value: String("two")})
+		},
+	}, {
+		desc:          "no base",
+		inBase:        (*merge3Root)(nil),
+		inOurs:        func(r *merge3Root) { r.Hostname = String("rtr2") },
+		inTheirs:      func(*merge3Root) {},
+		wantConflicts: []string{"/system/config/hostname"},
+		wantErrSubstr: "conflicting changes at paths /system/config/hostname",
+	}, {
+		desc:          "mismatched base",
+		inBase:        &validatedMergeTest{},
+		inOurs:        func(*merge3Root) {},
+		inTheirs:      func(*merge3Root) {},
+		wantErrSubstr: "cannot merge structs that are not of matching types",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var b ValidatedGoStruct = base()
+			if tt.inBase != nil {
+				b = tt.inBase
+			}
+			ours, theirs := base(), base()
+			tt.inOurs(ours)
+			tt.inTheirs(theirs)
+			wantOurs, wantTheirs := base(), base()
+			tt.inOurs(wantOurs)
+			tt.inTheirs(wantTheirs)
+
+			got, conflicts, err := Merge3(b, ours, theirs, tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("Merge3: did not get expected error, %s", diff)
+			}
+
+			var gotConflicts []string
+			for _, c := range conflicts {
+				gotConflicts = append(gotConflicts, conflictPathString(c))
+			}
+			if diff := cmp.Diff(tt.wantConflicts, gotConflicts); diff != "" {
+				t.Errorf("Merge3: did not get expected conflicts, diff(-want, +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantOurs, ours); diff != "" {
+				t.Errorf("Merge3: modified ours, diff(-want, +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(wantTheirs, theirs); diff != "" {
+				t.Errorf("Merge3: modified theirs, diff(-want, +got):\n%s", diff)
+			}
+
+			if err != nil {
+				return
+			}
+			want := base()
+			tt.want(want)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("Merge3: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,679 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/ygot/util"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc8072 for the YANG Patch media type,
+// and https://tools.ietf.org/html/rfc6902 for JSON Patch.
+
+// DiffToYANGPatch takes an original and modified GoStruct, which must be of
+// the same type, and returns a YANG Patch document, as per RFC8072, that
+// transforms the data tree original into modified. The document is encoded
+// as per RFC7951, and its patch-id is patchID.
+//
+// The target of each edit is a data resource identifier, as per RFC8040,
+// relative to the data tree represented by the supplied structs. List
+// entries are identified by the values of their keys, in the order of the
+// YANG key statement, each of which is percent-encoded. Containers and list
+// entries that are only present in modified are created as a whole, and
+// those that are only present in original are deleted as a whole. Entries of
+// ordered-by user lists are inserted, and moved, to their position within
+// modified. Since a GoStruct does not record whether a leaf-list is ordered-by
+// user, entries of leaf-lists are created at the end of the leaf-list. Since
+// the entries of keyless lists cannot be identified by a target, a change to
+// a keyless list replaces the data node that contains it.
+func DiffToYANGPatch(original, modified GoStruct, patchID string) ([]byte, error) {
+	ops, err := diffPatch(original, modified, true)
+	if err != nil {
+		return nil, err
+	}
+
+	edits := []*yangPatchEdit{}
+	for i, op := range ops {
+		e := &yangPatchEdit{
+			EditID: fmt.Sprintf("edit%d", i+1),
+			Target: op.resourceID(op.path),
+		}
+		switch op.kind {
+		case patchCreate:
+			e.Operation = "create"
+			if op.ordered {
+				e.Operation = "insert"
+				e.Where, e.Point = op.position()
+			}
+			e.Value = op.yangValue()
+		case patchDelete:
+			e.Operation = "delete"
+		case patchReplace:
+			e.Operation = "replace"
+			e.Value = op.yangValue()
+		case patchMove:
+			e.Operation = "move"
+			e.Where, e.Point = op.position()
+		}
+		edits = append(edits, e)
+	}
+
+	patch := map[string]interface{}{
+		"ietf-yang-patch:yang-patch": &yangPatch{PatchID: patchID, Edit: edits},
+	}
+	return marshalPatch(patch)
+}
+
+// DiffToJSONPatch takes an original and modified GoStruct, which must be of
+// the same type, and returns a JSON Patch document, as per RFC6902, that
+// transforms the RFC7951 JSON of original, with module names appended, into
+// that of modified.
+//
+// Since YANG lists are encoded as JSON arrays, the JSON Pointer of each
+// operation identifies a list entry by its index, which is determined by the
+// entries that precede it once the preceding operations have been applied.
+// Entries of keyed lists are matched between original and modified by the
+// values of their keys, such that a changed entry is patched, rather than
+// replaced. Entries of ordered-by user lists are moved to their position
+// within modified. Leaf-lists and keyless lists are replaced as a whole.
+func DiffToJSONPatch(original, modified GoStruct) ([]byte, error) {
+	ops, err := diffPatch(original, modified, false)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []*jsonPatchOp{}
+	for _, op := range ops {
+		jp := &jsonPatchOp{Path: jsonPointer(op.path)}
+		switch op.kind {
+		case patchCreate:
+			jp.Op, jp.Value = "add", op.value
+		case patchDelete:
+			jp.Op = "remove"
+		case patchReplace:
+			jp.Op, jp.Value = "replace", op.value
+		case patchMove:
+			from := append([]*patchStep{}, op.path...)
+			last := *from[len(from)-1]
+			last.index = op.from
+			from[len(from)-1] = &last
+			jp.Op, jp.From = "move", jsonPointer(from)
+		}
+		out = append(out, jp)
+	}
+	return marshalPatch(out)
+}
+
+// yangPatch is the yang-patch container of a YANG Patch document.
+type yangPatch struct {
+	PatchID string           `json:"patch-id"`
+	Edit    []*yangPatchEdit `json:"edit,omitempty"`
+}
+
+// yangPatchEdit is an entry of the edit list of a YANG Patch document.
+type yangPatchEdit struct {
+	EditID    string                 `json:"edit-id"`
+	Operation string                 `json:"operation"`
+	Target    string                 `json:"target"`
+	Point     string                 `json:"point,omitempty"`
+	Where     string                 `json:"where,omitempty"`
+	Value     map[string]interface{} `json:"value,omitempty"`
+}
+
+// jsonPatchOp is an operation of a JSON Patch document.
+type jsonPatchOp struct {
+	Op    string      `json:"op"`
+	From  string      `json:"from,omitempty"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// marshalPatch returns the indented JSON encoding of the patch document v.
+func marshalPatch(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("cannot encode patch: %v", err)
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// patchKind is the kind of a change to a data tree that is made by a patch.
+type patchKind int
+
+const (
+	// patchCreate creates a data node that does not exist.
+	patchCreate patchKind = iota
+	// patchDelete deletes a data node that exists.
+	patchDelete
+	// patchReplace replaces the value of a data node that exists.
+	patchReplace
+	// patchMove moves an entry of a list to another position within it.
+	patchMove
+)
+
+// patchStep is an element of the path of a data node within an RFC7951 JSON
+// document.
+type patchStep struct {
+	// name is the name of the member of the JSON object that contains the
+	// data node, which is qualified with the name of its module if it is
+	// in a different module to its parent.
+	name string
+	// module is the module in which the data node is defined.
+	module string
+	// index is the index of a list or leaf-list entry within the JSON
+	// array that is the value of the member, or -1 if the data node is not
+	// such an entry.
+	index int
+	// keys are the values of the keys of a list entry, in the order of
+	// the YANG key statement, or the value of a leaf-list entry.
+	keys []string
+}
+
+// patchOp is a change that is made to a data tree by a patch.
+type patchOp struct {
+	kind patchKind
+	// path is the path of the data node that is changed, in the data tree
+	// once the preceding changes have been made.
+	path []*patchStep
+	// value is the RFC7951 JSON value of the data node for patchCreate and
+	// patchReplace.
+	value interface{}
+	// ordered indicates that the data node is an entry of an ordered-by
+	// user list.
+	ordered bool
+	// from is the index of the list entry before it is moved.
+	from int
+	// after is the keys of the entry of the list that the created or moved
+	// entry follows, or nil if it is the first entry of the list.
+	after []string
+}
+
+// resourceID returns the RFC8040 data resource identifier of the path p.
+func (op *patchOp) resourceID(p []*patchStep) string {
+	var elems []*util.ResourceIDElem
+	for _, s := range p {
+		elems = append(elems, &util.ResourceIDElem{Name: s.name, Keys: s.keys})
+	}
+	return util.ResourceID(elems)
+}
+
+// position returns the where and point values of an insert or move edit of
+// a YANG Patch, for the list entry that is created or moved by op.
+func (op *patchOp) position() (string, string) {
+	if op.after == nil {
+		return "first", ""
+	}
+	p := append([]*patchStep{}, op.path...)
+	prev := *p[len(p)-1]
+	prev.keys = op.after
+	p[len(p)-1] = &prev
+	return "after", op.resourceID(p)
+}
+
+// yangValue returns the value of a YANG Patch edit that creates or replaces
+// the data node of op, which is an object whose sole member is the data node,
+// qualified with the name of its module.
+func (op *patchOp) yangValue() map[string]interface{} {
+	s := op.path[len(op.path)-1]
+	name := util.StripModulePrefix(s.name)
+	if s.module != "" {
+		name = fmt.Sprintf("%s:%s", s.module, name)
+	}
+	if s.index != -1 {
+		return map[string]interface{}{name: []interface{}{op.value}}
+	}
+	return map[string]interface{}{name: op.value}
+}
+
+// jsonPointer returns the RFC6901 JSON Pointer of the path p.
+func jsonPointer(p []*patchStep) string {
+	esc := strings.NewReplacer("~", "~0", "/", "~1")
+	var b strings.Builder
+	for _, s := range p {
+		b.WriteString("/")
+		b.WriteString(esc.Replace(s.name))
+		if s.index != -1 {
+			b.WriteString("/")
+			b.WriteString(strconv.Itoa(s.index))
+		}
+	}
+	return b.String()
+}
+
+// patchList describes a keyed list within a GoStruct.
+type patchList struct {
+	// keys is the names of the keys of the list, in the order of the YANG
+	// key statement.
+	keys []string
+	// ordered indicates that the list is ordered-by user.
+	ordered bool
+}
+
+// diffPatch returns the changes that transform the RFC7951 JSON of original
+// into that of modified, in the order in which they must be applied. If
+// perEntry is true, the changes are those that can be expressed by a YANG
+// Patch, such that leaf-lists and keyed lists are changed per entry, and
+// keyless lists are changed by replacing their parent.
+func diffPatch(original, modified GoStruct, perEntry bool) ([]*patchOp, error) {
+	if reflect.TypeOf(original) != reflect.TypeOf(modified) {
+		return nil, fmt.Errorf("cannot diff structs of different types, original: %T, modified: %T", original, modified)
+	}
+	w := &patchWalker{lists: map[string]*patchList{}, perEntry: perEntry}
+	var docs []map[string]interface{}
+	for _, s := range []GoStruct{original, modified} {
+		if err := findPatchLists(s, w.lists); err != nil {
+			return nil, err
+		}
+		d, err := patchDocument(s)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, d)
+	}
+	if err := w.object(nil, "", "", docs[0], docs[1]); err != nil {
+		return nil, err
+	}
+	return w.ops, nil
+}
+
+// patchDocument returns the RFC7951 JSON of s, with module names appended,
+// as decoded by encoding/json with numbers retained as json.Number.
+func patchDocument(s GoStruct) (map[string]interface{}, error) {
+	m, err := ConstructIETFJSON(s, &RFC7951JSONConfig{AppendModuleName: true})
+	if err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode JSON, %v", err)
+	}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	doc := map[string]interface{}{}
+	if err := d.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("cannot decode JSON, %v", err)
+	}
+	return doc, nil
+}
+
+// findPatchLists adds the keyed lists within s to lists, keyed by the
+// schema path of the list.
+func findPatchLists(s GoStruct, lists map[string]*patchList) error {
+	iterFunc := func(ni *util.NodeInfo, in, out interface{}) (errs util.Errors) {
+		if reflect.DeepEqual(ni.StructField, reflect.StructField{}) || util.IsYgotAnnotation(ni.StructField) {
+			return
+		}
+		sp, err := util.SchemaPaths(ni.StructField)
+		if err != nil {
+			return util.NewErrs(err)
+		}
+		vp, err := nodeValuePath(ni, sp)
+		if err != nil {
+			return util.NewErrs(err)
+		}
+		ni.Annotation = []interface{}{vp}
+
+		var keyType reflect.Type
+		switch {
+		case util.IsNilOrInvalidValue(ni.FieldValue):
+			return
+		case util.IsValueMap(ni.FieldValue):
+			keyType = ni.FieldValue.Type().Key()
+		case util.IsValueOrderedMap(ni.FieldValue):
+			keyType = util.OrderedMapKeyType(ni.FieldValue.Type())
+		default:
+			return
+		}
+
+		var keys []string
+		if keyType.Kind() == reflect.Struct {
+			// The fields of the struct that keys a multi-keyed list are in
+			// the order of the YANG key statement.
+			for i := 0; i < keyType.NumField(); i++ {
+				keys = append(keys, keyType.Field(i).Tag.Get("path"))
+			}
+		} else {
+			_, vals := util.KeyedListMembers(ni.FieldValue)
+			if len(vals) == 0 {
+				return
+			}
+			kh, ok := vals[0].Interface().(KeyHelperGoStruct)
+			if !ok {
+				return util.NewErrs(fmt.Errorf("member of list %s is not a KeyHelperGoStruct", ni.StructField.Name))
+			}
+			km, err := kh.ΛListKeyMap()
+			if err != nil {
+				return util.NewErrs(err)
+			}
+			for k := range km {
+				keys = append(keys, k)
+			}
+		}
+		for _, p := range vp.gNMIPaths {
+			var names []string
+			for _, e := range p.GetElem() {
+				names = append(names, e.GetName())
+			}
+			lists["/"+strings.Join(names, "/")] = &patchList{keys: keys, ordered: util.IsValueOrderedMap(ni.FieldValue)}
+		}
+		return
+	}
+	if errs := util.ForEachDataField(s, nil, nil, iterFunc); errs != nil {
+		return fmt.Errorf("could not extract lists from struct: %v", errs)
+	}
+	return nil
+}
+
+// patchWalker computes the changes between two RFC7951 JSON documents.
+type patchWalker struct {
+	// lists is the keyed lists of the documents, keyed by schema path.
+	lists map[string]*patchList
+	// perEntry specifies that leaf-lists and keyed lists are changed per
+	// entry, and keyless lists by replacing their parent.
+	perEntry bool
+	// ops is the changes, in order.
+	ops []*patchOp
+}
+
+// add appends the change op.
+func (w *patchWalker) add(op *patchOp) {
+	w.ops = append(w.ops, op)
+}
+
+// object adds the changes between the JSON objects a and b of the data node
+// at path p, whose schema path is sp, and which is defined in module mod.
+func (w *patchWalker) object(p []*patchStep, sp, mod string, a, b map[string]interface{}) error {
+	if w.perEntry && w.keylessChanged(sp, a, b) {
+		if len(p) == 0 {
+			return fmt.Errorf("cannot change keyless list at %s, since its parent cannot be replaced", sp)
+		}
+		w.add(&patchOp{kind: patchReplace, path: p, value: b})
+		return nil
+	}
+
+	var names []string
+	for n := range a {
+		names = append(names, n)
+	}
+	for n := range b {
+		if _, ok := a[n]; !ok {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	for _, n := range names {
+		av, aok := a[n]
+		bv, bok := b[n]
+		cmod := mod
+		if i := strings.Index(n, ":"); i != -1 {
+			cmod = n[:i]
+		}
+		cp := append(append([]*patchStep{}, p...), &patchStep{name: n, module: cmod, index: -1})
+		csp := sp + "/" + util.StripModulePrefix(n)
+		var err error
+		switch {
+		case !bok:
+			err = w.member(cp, csp, av, nil)
+		case !aok:
+			err = w.member(cp, csp, nil, bv)
+		default:
+			err = w.member(cp, csp, av, bv)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// keylessChanged returns true if a keyless list that is a member of the JSON
+// object a or b, whose schema path is sp, differs between them.
+func (w *patchWalker) keylessChanged(sp string, a, b map[string]interface{}) bool {
+	for _, m := range []map[string]interface{}{a, b} {
+		for n, v := range m {
+			l, ok := v.([]interface{})
+			if !ok || len(l) == 0 || w.lists[sp+"/"+util.StripModulePrefix(n)] != nil {
+				continue
+			}
+			if _, ok := l[0].(map[string]interface{}); ok && !reflect.DeepEqual(a[n], b[n]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// member adds the changes between the JSON values a and b of the data node
+// at path p, whose schema path is sp. A nil value indicates that the data
+// node does not exist.
+func (w *patchWalker) member(p []*patchStep, sp string, a, b interface{}) error {
+	al, aIsList := a.([]interface{})
+	bl, bIsList := b.([]interface{})
+	if (aIsList && isEmptyLeaf(al)) || (bIsList && isEmptyLeaf(bl)) {
+		aIsList, bIsList = false, false
+	}
+	l := w.lists[sp]
+
+	switch {
+	case (a == nil || aIsList) && (b == nil || bIsList) && l != nil:
+		if a == nil && !w.perEntry {
+			w.add(&patchOp{kind: patchCreate, path: p, value: b})
+			return nil
+		}
+		if b == nil && !w.perEntry {
+			w.add(&patchOp{kind: patchDelete, path: p})
+			return nil
+		}
+		return w.list(p, sp, l, al, bl)
+	case (a == nil || aIsList) && (b == nil || bIsList) && w.perEntry:
+		// Keyless lists are changed by keylessChanged, and hence the
+		// value is a leaf-list.
+		w.leafList(p, al, bl)
+		return nil
+	case a == nil:
+		w.add(&patchOp{kind: patchCreate, path: p, value: b})
+		return nil
+	case b == nil:
+		w.add(&patchOp{kind: patchDelete, path: p})
+		return nil
+	}
+
+	if am, ok := a.(map[string]interface{}); ok {
+		if bm, ok := b.(map[string]interface{}); ok {
+			return w.object(p, sp, p[len(p)-1].module, am, bm)
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		w.add(&patchOp{kind: patchReplace, path: p, value: b})
+	}
+	return nil
+}
+
+// list adds the changes between the entries a and b of the keyed list l at
+// path p, whose schema path is sp. Entries that are only in a are deleted,
+// and then the entries of b are created or changed in order. Entries of an
+// ordered-by user list are created at, or moved to, their position within b.
+// Since the order of other lists is not significant, their entries are
+// created at the end of the list, and are not moved.
+func (w *patchWalker) list(p []*patchStep, sp string, l *patchList, a, b []interface{}) error {
+	aKeys, aEntries, err := listEntries(l, a)
+	if err != nil {
+		return fmt.Errorf("list %s: %v", sp, err)
+	}
+	bKeys, bEntries, err := listEntries(l, b)
+	if err != nil {
+		return fmt.Errorf("list %s: %v", sp, err)
+	}
+
+	// cur is the identities of the entries of the list, in order, once
+	// the changes that have been added are applied.
+	cur := append([]string{}, aKeys...)
+	if !l.ordered {
+		// The order of the entries of the JSON array of an unordered list
+		// is not significant, and hence the entries are visited in the
+		// order of their keys, such that the changes are deterministic.
+		aKeys = append([]string{}, aKeys...)
+		sort.Strings(aKeys)
+		bKeys = append([]string{}, bKeys...)
+		sort.Strings(bKeys)
+	}
+	for _, id := range aKeys {
+		if _, ok := bEntries[id]; !ok {
+			k := indexOf(cur, id)
+			w.add(&patchOp{kind: patchDelete, path: entryPath(p, k, aEntries[id].keys)})
+			cur = append(cur[:k], cur[k+1:]...)
+		}
+	}
+
+	for pos, id := range bKeys {
+		var after []string
+		if pos > 0 {
+			after = bEntries[bKeys[pos-1]].keys
+		}
+		if !l.ordered {
+			pos = indexOf(cur, id)
+			if pos == -1 {
+				pos = len(cur)
+			}
+		}
+		ep := entryPath(p, pos, bEntries[id].keys)
+
+		k := indexOf(cur, id)
+		if k == -1 {
+			w.add(&patchOp{kind: patchCreate, path: ep, value: bEntries[id].value, ordered: l.ordered, after: after})
+			cur = append(cur[:pos], append([]string{id}, cur[pos:]...)...)
+			continue
+		}
+		// The entries of an ordered list within cur that precede pos are
+		// those of b, and hence the entry is at or after pos.
+		if k != pos {
+			w.add(&patchOp{kind: patchMove, path: ep, from: k, ordered: l.ordered, after: after})
+			cur = append(cur[:k], cur[k+1:]...)
+			cur = append(cur[:pos], append([]string{id}, cur[pos:]...)...)
+		}
+		if err := w.object(ep, sp, ep[len(ep)-1].module, aEntries[id].value, bEntries[id].value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// leafList adds the changes between the entries a and b of the leaf-list at
+// path p. Entries that are only in a are deleted, and those that are only in
+// b are created.
+func (w *patchWalker) leafList(p []*patchStep, a, b []interface{}) {
+	in := func(l []interface{}, v interface{}) bool {
+		for _, lv := range l {
+			if reflect.DeepEqual(lv, v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range a {
+		if !in(b, v) {
+			w.add(&patchOp{kind: patchDelete, path: entryPath(p, 0, []string{patchValueString(v)})})
+		}
+	}
+	for _, v := range b {
+		if !in(a, v) {
+			w.add(&patchOp{kind: patchCreate, path: entryPath(p, 0, []string{patchValueString(v)}), value: v})
+		}
+	}
+}
+
+// patchEntry is an entry of a keyed list.
+type patchEntry struct {
+	// keys is the values of the keys of the entry, in order.
+	keys []string
+	// value is the JSON object of the entry.
+	value map[string]interface{}
+}
+
+// listEntries returns the identities of the entries of the JSON array l of
+// the keyed list pl, in order, along with the entries keyed by identity.
+func listEntries(pl *patchList, l []interface{}) ([]string, map[string]*patchEntry, error) {
+	var ids []string
+	entries := map[string]*patchEntry{}
+	for _, v := range l {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("entry %v is not an object", v)
+		}
+		e := &patchEntry{value: m}
+		for _, k := range pl.keys {
+			kv, ok := m[k]
+			if !ok {
+				return nil, nil, fmt.Errorf("entry %v does not have key %s", v, k)
+			}
+			e.keys = append(e.keys, patchValueString(kv))
+		}
+		// The key values are joined with a character that is not valid
+		// within a YANG string, such that the identity is unique.
+		id := strings.Join(e.keys, "\x00")
+		ids = append(ids, id)
+		entries[id] = e
+	}
+	return ids, entries, nil
+}
+
+// entryPath returns the path of the entry at index i of the list or leaf-list
+// at path p, whose keys are keys.
+func entryPath(p []*patchStep, i int, keys []string) []*patchStep {
+	last := *p[len(p)-1]
+	last.index, last.keys = i, keys
+	return append(append([]*patchStep{}, p[:len(p)-1]...), &last)
+}
+
+// indexOf returns the index of s within l, or -1 if it is not found.
+func indexOf(l []string, s string) int {
+	for i, ls := range l {
+		if ls == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// isEmptyLeaf returns true if the JSON array l is the RFC7951 encoding of a
+// leaf of type empty.
+func isEmptyLeaf(l []interface{}) bool {
+	return len(l) == 1 && l[0] == nil
+}
+
+// patchValueString returns the string form of the RFC7951 JSON scalar v, as
+// used to identify list and leaf-list entries.
+func patchValueString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,355 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+// patchTestDevice is a GoStruct used to test the rendering of patches.
+type patchTestDevice struct {
+	Interface map[string]*patchTestInterface        `path:"interfaces/interface" module:"dev"`
+	Route     map[patchTestRouteKey]*patchTestRoute `path:"routes/route" module:"dev"`
+	Server    []*patchTestServer                    `path:"servers/server" module:"dev"`
+	System    *patchTestSystem                      `path:"system" module:"dev"`
+}
+
+func (*patchTestDevice) IsYANGGoStruct() {}
+
+type patchTestInterface struct {
+	Name    *string  `path:"config/name|name" module:"dev"`
+	Mtu     *uint16  `path:"config/mtu" module:"dev"`
+	Address []string `path:"config/address" module:"dev"`
+	Counter *uint64  `path:"state/counter" module:"ext"`
+}
+
+func (*patchTestInterface) IsYANGGoStruct() {}
+
+func (i *patchTestInterface) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+// patchTestRouteKey is the key of the route list, which is keyed by a prefix
+// and a union.
+type patchTestRouteKey struct {
+	Prefix string             `path:"prefix"`
+	Vrf    renderExampleUnion `path:"vrf"`
+}
+
+type patchTestRoute struct {
+	Prefix  *string            `path:"prefix" module:"dev"`
+	Vrf     renderExampleUnion `path:"vrf" module:"dev"`
+	NextHop *string            `path:"next-hop" module:"dev"`
+}
+
+func (*patchTestRoute) IsYANGGoStruct() {}
+
+func (r *patchTestRoute) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"prefix": *r.Prefix, "vrf": r.Vrf}, nil
+}
+
+// patchTestServer is a member of a keyless list.
+type patchTestServer struct {
+	Address *string `path:"address" module:"dev"`
+}
+
+func (*patchTestServer) IsYANGGoStruct() {}
+
+type patchTestSystem struct {
+	Hostname *string `path:"config/hostname" module:"dev"`
+	Domain   *string `path:"config/domain" module:"dev"`
+}
+
+func (*patchTestSystem) IsYANGGoStruct() {}
+
+// patchTestIntf returns an interface with the supplied name, MTU and
+// addresses.
+func patchTestIntf(name string, mtu uint16, addrs ...string) *patchTestInterface {
+	return &patchTestInterface{Name: String(name), Mtu: Uint16(mtu), Address: addrs}
+}
+
+// patchTestRoutes returns a route list containing the routes with the
+// supplied prefixes, VRFs and next-hops.
+func patchTestRoutes(routes ...*patchTestRoute) map[patchTestRouteKey]*patchTestRoute {
+	m := map[patchTestRouteKey]*patchTestRoute{}
+	for _, r := range routes {
+		m[patchTestRouteKey{Prefix: *r.Prefix, Vrf: r.Vrf}] = r
+	}
+	return m
+}
+
+// jsonDiff returns the differences between the JSON documents got and
+// want, or the empty string if they are equal.
+func jsonDiff(t *testing.T, got []byte, want string) string {
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("cannot decode returned JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("cannot decode wanted JSON %s: %v", want, err)
+	}
+	return pretty.Compare(g, w)
+}
+
+func TestDiffToYANGPatch(t *testing.T) {
+	vrfRed := &renderExampleUnionString{"red"}
+	vrf42 := &renderExampleUnionInt8{42}
+
+	tests := []struct {
+		desc             string
+		inOrig, inMod    GoStruct
+		want             string
+		wantErrSubstring string
+	}{{
+		desc:   "no changes",
+		inOrig: &patchTestDevice{System: &patchTestSystem{Hostname: String("a")}},
+		inMod:  &patchTestDevice{System: &patchTestSystem{Hostname: String("a")}},
+		want:   `{"ietf-yang-patch:yang-patch": {"patch-id": "p1"}}`,
+	}, {
+		desc:   "leaves and containers",
+		inOrig: &patchTestDevice{System: &patchTestSystem{Hostname: String("a"), Domain: String("example.com")}},
+		inMod:  &patchTestDevice{System: &patchTestSystem{Hostname: String("b")}},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "delete", "target": "/dev:system/config/domain"},
+			{"edit-id": "edit2", "operation": "replace", "target": "/dev:system/config/hostname",
+			 "value": {"dev:hostname": "b"}}
+		]}}`,
+	}, {
+		desc:   "container created",
+		inOrig: &patchTestDevice{},
+		inMod:  &patchTestDevice{System: &patchTestSystem{Hostname: String("b")}},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "create", "target": "/dev:system",
+			 "value": {"dev:system": {"config": {"hostname": "b"}}}}
+		]}}`,
+	}, {
+		desc: "single-keyed list",
+		inOrig: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0/1": patchTestIntf("eth0/1", 1500),
+			"eth1":   patchTestIntf("eth1", 1500),
+		}},
+		inMod: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0/1": patchTestIntf("eth0/1", 9000),
+			"eth2":   {Name: String("eth2"), Counter: Uint64(1)},
+		}},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "delete", "target": "/dev:interfaces/interface=eth1"},
+			{"edit-id": "edit2", "operation": "replace", "target": "/dev:interfaces/interface=eth0%2F1/config/mtu",
+			 "value": {"dev:mtu": 9000}},
+			{"edit-id": "edit3", "operation": "create", "target": "/dev:interfaces/interface=eth2",
+			 "value": {"dev:interface": [{"name": "eth2", "config": {"name": "eth2"}, "ext:state": {"counter": "1"}}]}}
+		]}}`,
+	}, {
+		desc: "leaf-list",
+		inOrig: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0": patchTestIntf("eth0", 1500, "10.0.0.1", "10.0.0.2"),
+		}},
+		inMod: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0": patchTestIntf("eth0", 1500, "10.0.0.2", "10.0.0.3"),
+		}},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "delete", "target": "/dev:interfaces/interface=eth0/config/address=10.0.0.1"},
+			{"edit-id": "edit2", "operation": "create", "target": "/dev:interfaces/interface=eth0/config/address=10.0.0.3",
+			 "value": {"dev:address": ["10.0.0.3"]}}
+		]}}`,
+	}, {
+		desc: "multi-keyed list with union key",
+		inOrig: &patchTestDevice{Route: patchTestRoutes(
+			&patchTestRoute{Prefix: String("10.0.0.0/8"), Vrf: vrfRed, NextHop: String("192.0.2.1")},
+			&patchTestRoute{Prefix: String("10.0.0.0/8"), Vrf: vrf42, NextHop: String("192.0.2.1")},
+		)},
+		inMod: &patchTestDevice{Route: patchTestRoutes(
+			&patchTestRoute{Prefix: String("10.0.0.0/8"), Vrf: vrfRed, NextHop: String("192.0.2.2")},
+			&patchTestRoute{Prefix: String("0.0.0.0/0"), Vrf: vrf42, NextHop: String("192.0.2.1")},
+		)},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "delete", "target": "/dev:routes/route=10.0.0.0%2F8,42"},
+			{"edit-id": "edit2", "operation": "create", "target": "/dev:routes/route=0.0.0.0%2F0,42",
+			 "value": {"dev:route": [{"prefix": "0.0.0.0/0", "vrf": 42, "next-hop": "192.0.2.1"}]}},
+			{"edit-id": "edit3", "operation": "replace", "target": "/dev:routes/route=10.0.0.0%2F8,red/next-hop",
+			 "value": {"dev:next-hop": "192.0.2.2"}}
+		]}}`,
+	}, {
+		desc:   "keyless list",
+		inOrig: &patchTestDevice{Server: []*patchTestServer{{Address: String("a")}}},
+		inMod:  &patchTestDevice{Server: []*patchTestServer{{Address: String("b")}}},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "replace", "target": "/dev:servers",
+			 "value": {"dev:servers": {"server": [{"address": "b"}]}}}
+		]}}`,
+	}, {
+		desc:             "different types",
+		inOrig:           &patchTestDevice{},
+		inMod:            &patchTestSystem{},
+		wantErrSubstring: "cannot diff structs of different types",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := DiffToYANGPatch(tt.inOrig, tt.inMod, "p1")
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("DiffToYANGPatch: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := jsonDiff(t, got, tt.want); diff != "" {
+				t.Errorf("DiffToYANGPatch: did not get expected patch, got: %s\ndiff(-got,+want):\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func TestDiffToYANGPatchOrdered(t *testing.T) {
+	tests := []struct {
+		desc          string
+		inOrig, inMod *orderedTestRoot
+		want          string
+	}{{
+		desc:   "insert",
+		inOrig: &orderedTestRoot{List: newOrderedTestList("a", "one", "c", "three")},
+		inMod:  &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one", "c", "three", "d", "four")},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "insert", "target": "/lists/list=b", "where": "first",
+			 "value": {"list": [{"name": "b", "config": {"name": "b", "value": "two"}}]}},
+			{"edit-id": "edit2", "operation": "insert", "target": "/lists/list=d", "where": "after", "point": "/lists/list=c",
+			 "value": {"list": [{"name": "d", "config": {"name": "d", "value": "four"}}]}}
+		]}}`,
+	}, {
+		desc:   "move and change",
+		inOrig: &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two", "c", "three")},
+		inMod:  &orderedTestRoot{List: newOrderedTestList("c", "three", "a", "one", "b", "2")},
+		want: `{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [
+			{"edit-id": "edit1", "operation": "move", "target": "/lists/list=c", "where": "first"},
+			{"edit-id": "edit2", "operation": "replace", "target": "/lists/list=b/config/value",
+			 "value": {"value": "2"}}
+		]}}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := DiffToYANGPatch(tt.inOrig, tt.inMod, "p1")
+			if err != nil {
+				t.Fatalf("DiffToYANGPatch: unexpected error: %v", err)
+			}
+			if diff := jsonDiff(t, got, tt.want); diff != "" {
+				t.Errorf("DiffToYANGPatch: did not get expected patch, got: %s\ndiff(-got,+want):\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func TestDiffToJSONPatch(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inOrig, inMod    GoStruct
+		want             string
+		wantErrSubstring string
+	}{{
+		desc:   "no changes",
+		inOrig: &patchTestDevice{},
+		inMod:  &patchTestDevice{},
+		want:   `[]`,
+	}, {
+		desc:   "leaves and containers",
+		inOrig: &patchTestDevice{System: &patchTestSystem{Hostname: String("a"), Domain: String("example.com")}},
+		inMod:  &patchTestDevice{System: &patchTestSystem{Hostname: String("b")}},
+		want: `[
+			{"op": "remove", "path": "/dev:system/config/domain"},
+			{"op": "replace", "path": "/dev:system/config/hostname", "value": "b"}
+		]`,
+	}, {
+		desc: "keyed list",
+		inOrig: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0": patchTestIntf("eth0", 1500, "10.0.0.1"),
+			"eth1": patchTestIntf("eth1", 1500),
+			"eth2": patchTestIntf("eth2", 1500),
+		}},
+		inMod: &patchTestDevice{Interface: map[string]*patchTestInterface{
+			"eth0": patchTestIntf("eth0", 1500, "10.0.0.2"),
+			"eth2": patchTestIntf("eth2", 9000),
+			"eth3": patchTestIntf("eth3", 1500),
+		}},
+		want: `[
+			{"op": "remove", "path": "/dev:interfaces/interface/1"},
+			{"op": "replace", "path": "/dev:interfaces/interface/0/config/address", "value": ["10.0.0.2"]},
+			{"op": "replace", "path": "/dev:interfaces/interface/1/config/mtu", "value": 9000},
+			{"op": "add", "path": "/dev:interfaces/interface/2",
+			 "value": {"name": "eth3", "config": {"name": "eth3", "mtu": 1500}}}
+		]`,
+	}, {
+		desc:   "list created",
+		inOrig: &patchTestDevice{},
+		inMod:  &patchTestDevice{Interface: map[string]*patchTestInterface{"eth0": patchTestIntf("eth0", 1500)}},
+		want: `[
+			{"op": "add", "path": "/dev:interfaces",
+			 "value": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}}]}}
+		]`,
+	}, {
+		desc:   "keyless list",
+		inOrig: &patchTestDevice{Server: []*patchTestServer{{Address: String("a")}}},
+		inMod:  &patchTestDevice{Server: []*patchTestServer{{Address: String("b")}}},
+		want: `[
+			{"op": "replace", "path": "/dev:servers/server", "value": [{"address": "b"}]}
+		]`,
+	}, {
+		desc:   "ordered list",
+		inOrig: &orderedTestRoot{List: newOrderedTestList("a", "one", "b", "two", "c", "three")},
+		inMod:  &orderedTestRoot{List: newOrderedTestList("c", "three", "d", "four", "a", "one")},
+		want: `[
+			{"op": "remove", "path": "/lists/list/1"},
+			{"op": "move", "from": "/lists/list/1", "path": "/lists/list/0"},
+			{"op": "add", "path": "/lists/list/1", "value": {"name": "d", "config": {"name": "d", "value": "four"}}}
+		]`,
+	}, {
+		desc:             "different types",
+		inOrig:           &patchTestDevice{},
+		inMod:            &orderedTestRoot{},
+		wantErrSubstring: "cannot diff structs of different types",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := DiffToJSONPatch(tt.inOrig, tt.inMod)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("DiffToJSONPatch: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := jsonDiff(t, got, tt.want); diff != "" {
+				t.Errorf("DiffToJSONPatch: did not get expected patch, got: %s\ndiff(-got,+want):\n%s", got, diff)
+			}
+		})
+	}
+}
+
+func TestPatchEncodings(t *testing.T) {
+	p := []*patchStep{
+		{name: "dev:a~b", module: "dev", index: -1},
+		{name: "c/d", module: "dev", index: 2, keys: []string{"x y", "10.0.0.0/8", "a,b", "é"}},
+	}
+	op := &patchOp{}
+	if got, want := op.resourceID(p), "/dev:a~b/c/d=x%20y,10.0.0.0%2F8,a%2Cb,%C3%A9"; got != want {
+		t.Errorf("resourceID: got %s, want %s", got, want)
+	}
+	if got, want := jsonPointer(p), "/dev:a~0b/c~1d/2"; got != want {
+		t.Errorf("jsonPointer: got %s, want %s", got, want)
+	}
+}
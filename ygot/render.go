@@ -42,6 +42,10 @@ const (
 // such that it can be used as a key for maps.
 type path struct {
 	p *gnmiPath
+	// order is the order in which the leaf was found when traversing the
+	// GoStruct, such that the order of members of ordered lists can be
+	// retained when the leaves are output.
+	order int
 }
 
 // gnmiPath provides a wrapper for gNMI path types, particularly
@@ -264,6 +268,10 @@ type GNMINotificationsConfig struct {
 	// of PathElem messages. This path format is used by gNMI 0.4.0 and
 	// above. Used if PathElem is set.
 	PathElemPrefix []*gnmipb.PathElem
+	// WithDefaults specifies how leaves that have a default value in the
+	// schema are rendered. Modes other than WithDefaultsExplicit require the
+	// GoStruct to implement the DefaultsGoStruct interface.
+	WithDefaults WithDefaultsMode
 }
 
 // TogNMINotifications takes an input GoStruct and renders it to slice of
@@ -276,6 +284,10 @@ type GNMINotificationsConfig struct {
 // abstraction. It can also be refactored to simply use the findSetleaves function
 // which has a cleaner implementation using the reworked iterfunction util.
 func TogNMINotifications(s GoStruct, ts int64, cfg GNMINotificationsConfig) ([]*gnmipb.Notification, error) {
+	s, err := applyWithDefaults(s, cfg.WithDefaults)
+	if err != nil {
+		return nil, err
+	}
 
 	var pfx *gnmiPath
 	if cfg.UsePathElem {
@@ -358,15 +370,41 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			// Determine whether this is a pointer to a struct (another YANG container), or a leaf.
 			switch fval.Elem().Kind() {
 			case reflect.Struct:
+				if util.IsValueOrderedMap(fval) {
+					// Ordered lists are mapped in the same way as maps, but
+					// their members are traversed in order.
+					keys, vals := util.OrderedMapKeys(fval), util.OrderedMapValues(fval)
+					for j, k := range keys {
+						childPath, err := mapValuePath(k, vals[j], mapPaths[0])
+						if err != nil {
+							errs.Add(err)
+							continue
+						}
+
+						goStruct, ok := vals[j].Interface().(GoStruct)
+						if !ok {
+							errs.Add(fmt.Errorf("%v: was not a valid GoStruct", mapPaths[0]))
+							continue
+						}
+						errs.Add(findUpdatedLeaves(leaves, goStruct, childPath))
+					}
+					continue
+				}
 				goStruct, ok := fval.Interface().(GoStruct)
 				if !ok {
 					errs.Add(fmt.Errorf("%v: was not a valid GoStruct", mapPaths[0]))
 					continue
 				}
+				if util.IsYangPresence(ftype) {
+					// The existence of a presence container is data in itself,
+					// hence it is output as an empty container, such that it is
+					// rendered even when it has no populated children.
+					leaves[&path{p: mapPaths[0], order: len(leaves)}] = reflect.New(fval.Elem().Type()).Interface()
+				}
 				errs.Add(findUpdatedLeaves(leaves, goStruct, mapPaths[0]))
 			default:
 				for _, p := range mapPaths {
-					leaves[&path{p}] = fval.Elem().Interface()
+					leaves[&path{p: p, order: len(leaves)}] = fval.Elem().Interface()
 				}
 			}
 		case reflect.Slice:
@@ -378,7 +416,7 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			}
 			// This is a leaf-list, so add it as though it were a leaf.
 			for _, p := range mapPaths {
-				leaves[&path{p}] = fval.Interface()
+				leaves[&path{p: p, order: len(leaves)}] = fval.Interface()
 			}
 		case reflect.Int64:
 			name, set, err := enumFieldToString(fval, false)
@@ -393,7 +431,7 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			}
 
 			for _, p := range mapPaths {
-				leaves[&path{p}] = name
+				leaves[&path{p: p, order: len(leaves)}] = name
 			}
 			continue
 		case reflect.Interface:
@@ -405,7 +443,7 @@ func findUpdatedLeaves(leaves map[*path]interface{}, s GoStruct, parent *gnmiPat
 			}
 
 			for _, p := range mapPaths {
-				leaves[&path{p}] = val
+				leaves[&path{p: p, order: len(leaves)}] = val
 			}
 			continue
 		}
@@ -538,6 +576,13 @@ func KeyValueAsString(v interface{}) (string, error) {
 		}
 		return name, nil
 	}
+	if b, isBits := v.(GoBits); isBits {
+		name, err := BitsName(b)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve bits type in key, got err: %v", err)
+		}
+		return name, nil
+	}
 
 	switch kv.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -567,7 +612,14 @@ func KeyValueAsString(v interface{}) (string, error) {
 func sliceToScalarArray(v []interface{}) (*gnmipb.ScalarArray, error) {
 	arr := &gnmipb.ScalarArray{}
 	for _, e := range v {
-		tv, err := value.FromScalar(e)
+		var tv *gnmipb.TypedValue
+		var err error
+		switch ev := e.(type) {
+		case Decimal64:
+			tv, err = decimal64TypedValue(ev)
+		default:
+			tv, err = value.FromScalar(e)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -594,7 +646,14 @@ func leavesToNotifications(leaves map[*path]interface{}, ts int64, pfx *gnmiPath
 	}
 	n.Prefix = p
 
-	for pk, v := range leaves {
+	var pks []*path
+	for pk := range leaves {
+		pks = append(pks, pk)
+	}
+	sort.Slice(pks, func(i, j int) bool { return pks[i].order < pks[j].order })
+
+	for _, pk := range pks {
+		v := leaves[pk]
 		path, err := pk.p.StripPrefix(pfx)
 		if err != nil {
 			return nil, err
@@ -669,9 +728,34 @@ func EncodeTypedValue(val interface{}, enc gnmipb.Encoding) (*gnmipb.TypedValue,
 		}
 	}
 
+	if b, ok := vv.Interface().(GoBits); ok {
+		bn, err := BitsName(b)
+		if err != nil {
+			return nil, fmt.Errorf("cannot marshal bits, %v", err)
+		}
+		return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{bn}}, nil
+	}
+
+	if d, ok := vv.Interface().(Decimal64); ok {
+		return decimal64TypedValue(d)
+	}
+
 	return value.FromScalar(vv.Interface())
 }
 
+// decimal64TypedValue returns the gNMI TypedValue for the Decimal64 d, which
+// is encoded as a gNMI Decimal64 message such that its precision is retained.
+func decimal64TypedValue(d Decimal64) (*gnmipb.TypedValue, error) {
+	digits, err := d.Digits()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal decimal64, %v", err)
+	}
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{
+		Digits:    digits,
+		Precision: uint32(d.Precision()),
+	}}}, nil
+}
+
 // marshalStruct encodes the struct s according to the encoding specified by enc. It
 // is returned as a TypedValue gNMI message.
 func marshalStruct(s GoStruct, enc gnmipb.Encoding) (*gnmipb.TypedValue, error) {
@@ -730,7 +814,11 @@ func leaflistToSlice(val reflect.Value, appendModuleName bool) ([]interface{}, e
 		// multiple types. This is represented as []interface{}
 		switch e.Kind() {
 		case reflect.String:
-			sval = append(sval, e.String())
+			if d, ok := e.Interface().(Decimal64); ok {
+				sval = append(sval, d)
+			} else {
+				sval = append(sval, e.String())
+			}
 		case reflect.Uint8:
 			sval = append(sval, uint8(e.Uint()))
 		case reflect.Uint16:
@@ -738,7 +826,15 @@ func leaflistToSlice(val reflect.Value, appendModuleName bool) ([]interface{}, e
 		case reflect.Uint32:
 			sval = append(sval, uint32(e.Uint()))
 		case reflect.Uint64, reflect.Uint:
-			sval = append(sval, e.Uint())
+			if b, ok := e.Interface().(GoBits); ok {
+				name, err := BitsName(b)
+				if err != nil {
+					return nil, err
+				}
+				sval = append(sval, name)
+			} else {
+				sval = append(sval, e.Uint())
+			}
 		case reflect.Int8:
 			sval = append(sval, int8(e.Int()))
 		case reflect.Int16:
@@ -952,7 +1048,9 @@ func structJSON(s GoStruct, parentMod string, args jsonOutputConfig) (map[string
 			continue
 		}
 
-		if mp, ok := value.(map[string]interface{}); ok && len(mp) == 0 {
+		// Empty containers are omitted, other than presence containers whose
+		// existence is meaningful, and which are hence output as {}.
+		if mp, ok := value.(map[string]interface{}); ok && len(mp) == 0 && !util.IsYangPresence(fType) {
 			continue
 		}
 
@@ -1057,11 +1155,14 @@ func writeIETFScalarJSON(i interface{}) interface{} {
 }
 
 // keyValue takes an input reflect.Value and returns its representation when used
-// in a key for a YANG list. If the value is an enumerated type then its string
+// in a key for a YANG list. If the value is an enumerated or bits type then its string
 // representation is returned, otherwise the value is returned as an interface{}.
 // If appendModuleName is set to true keys that are identity values in the YANG
 // schema are prepended with the module that defines them.
 func keyValue(v reflect.Value, appendModuleName bool) (interface{}, error) {
+	if b, isBits := v.Interface().(GoBits); isBits {
+		return BitsName(b)
+	}
 	if _, isEnum := v.Interface().(GoEnum); !isEnum {
 		return v.Interface(), nil
 	}
@@ -1074,29 +1175,31 @@ func keyValue(v reflect.Value, appendModuleName bool) (interface{}, error) {
 	return name, nil
 }
 
-// mapJSON takes an input reflect.Value containing a map, and
-// constructs the representation for JSON marshalling that corresponds to it.
-// The module within which the map is defined is specified by the parentMod
+// mapJSON takes an input reflect.Value containing a map, or an ordered map,
+// and constructs the representation for JSON marshalling that corresponds to
+// it. The module within which the map is defined is specified by the parentMod
 // argument.
 func mapJSON(field reflect.Value, parentMod string, args jsonOutputConfig) (interface{}, error) {
 	var errs errlist.List
 	mapKeyMap := map[string]reflect.Value{}
 	// Order of elements determines the order in which keys will be processed.
 	var mapKeys []string
+	listKeys, listVals := util.KeyedListMembers(field)
 	switch args.jType {
 	case RFC7951:
 		// YANG lists are marshalled into a JSON object array for IETF
 		// JSON. We handle the keys in alphabetical order to ensure that
-		// deterministic ordering is achieved in the output JSON.
-		for _, k := range field.MapKeys() {
+		// deterministic ordering is achieved in the output JSON, unless
+		// the list is ordered, in which case its order is retained.
+		for i, k := range listKeys {
 			kn := fmt.Sprintf("%v", k.Interface())
 			mapKeys = append(mapKeys, kn)
-			mapKeyMap[kn] = k
+			mapKeyMap[kn] = listVals[i]
 		}
 	case Internal:
 		// In non-IETF JSON, then we output a list as a JSON object. The keys
 		// are stored as strings.
-		for _, k := range field.MapKeys() {
+		for i, k := range listKeys {
 			var kn string
 			switch k.Kind() {
 			case reflect.Struct:
@@ -1122,12 +1225,14 @@ func mapJSON(field reflect.Value, parentMod string, args jsonOutputConfig) (inte
 				kn = fmt.Sprintf("%v", k.Interface())
 			}
 			mapKeys = append(mapKeys, kn)
-			mapKeyMap[kn] = k
+			mapKeyMap[kn] = listVals[i]
 		}
 	default:
 		return nil, fmt.Errorf("unknown JSON type: %v", args.jType)
 	}
-	sort.Strings(mapKeys)
+	if !util.IsValueOrderedMap(field) {
+		sort.Strings(mapKeys)
+	}
 
 	if len(mapKeys) == 0 {
 		return nil, nil
@@ -1148,8 +1253,7 @@ func mapJSON(field reflect.Value, parentMod string, args jsonOutputConfig) (inte
 		return nil, fmt.Errorf("invalid JSON format specified: %v", args.jType)
 	}
 	for _, kn := range mapKeys {
-		k := mapKeyMap[kn]
-		goStruct, ok := field.MapIndex(k).Interface().(GoStruct)
+		goStruct, ok := mapKeyMap[kn].Interface().(GoStruct)
 		if !ok {
 			errs.Add(fmt.Errorf("cannot map struct %v, invalid GoStruct", field))
 			continue
@@ -1209,6 +1313,14 @@ func jsonValue(field reflect.Value, parentMod string, args jsonOutputConfig) (in
 	case reflect.Ptr:
 		switch field.Elem().Kind() {
 		case reflect.Struct:
+			if util.IsValueOrderedMap(field) {
+				var err error
+				if value, err = mapJSON(field, parentMod, args); err != nil {
+					errs.Add(err)
+				}
+				break
+			}
+
 			goStruct, ok := field.Interface().(GoStruct)
 			if !ok {
 				return nil, fmt.Errorf("cannot map struct %v, invalid GoStruct", field)
@@ -1221,7 +1333,20 @@ func jsonValue(field reflect.Value, parentMod string, args jsonOutputConfig) (in
 			}
 		default:
 			value = field.Elem().Interface()
-			if args.jType == RFC7951 {
+			if d, ok := value.(Decimal64); ok {
+				// Exact decimal64 values are output as their string
+				// representation in both internal and RFC7951 JSON, such
+				// that they are not subject to floating point rounding.
+				value = string(d)
+			} else if b, ok := value.(GoBits); ok {
+				// Bits values are represented as a derived uint64 type in
+				// the generated Go structures, and are output as the
+				// space-separated names of the bits that are set.
+				var err error
+				if value, err = BitsName(b); err != nil {
+					return nil, err
+				}
+			} else if args.jType == RFC7951 {
 				value = writeIETFScalarJSON(value)
 			}
 		}
@@ -1316,7 +1441,9 @@ func jsonSlice(field reflect.Value, parentMod string, args jsonOutputConfig) (in
 		return nil, fmt.Errorf("could not map slice (leaf-list or unkeyed list): %v", err)
 	}
 	for j, e := range sl {
-		switch {
+		switch d, isDecimal := e.(Decimal64); {
+		case isDecimal:
+			sl[j] = string(d)
 		case reflect.TypeOf(e).Kind() == reflect.Slice:
 			// This is a slice within a slice which can only be a binary value,
 			// so we base64 encode it.
@@ -707,6 +707,10 @@ type renderExample struct {
 	InvalidPtr    *invalidGoStruct                    `path:"invalid-gostruct"`
 	Empty         YANGEmpty                           `path:"empty"`
 	EnumLeafList  []EnumTest                          `path:"enum-leaflist"`
+	Bits          *BitsTest                           `path:"bits"`
+	BitsLeafList  []BitsTest                          `path:"bits-leaflist"`
+	Decimal       *Decimal64                          `path:"decimal"`
+	DecimalList   []Decimal64                         `path:"decimal-leaflist"`
 }
 
 // IsYANGGoStruct ensures that the renderExample type implements the GoStruct
@@ -805,6 +809,28 @@ const (
 	EnumTestVALTHREE EnumTest = 3
 )
 
+// BitsTest is a synthesised derived type which is used to represent
+// a bits type in the YANG schema.
+type BitsTest uint64
+
+// IsYANGGoBits ensures that the BitsTest type implements the GoBits
+// interface.
+func (BitsTest) IsYANGGoBits() {}
+
+// ΛBits returns the bit position dictionary associated with BitsTest.
+func (BitsTest) ΛBits() map[string]map[uint32]string {
+	return map[string]map[uint32]string{
+		"BitsTest": {0: "BIT_ZERO", 3: "BIT_THREE"},
+	}
+}
+
+const (
+	// BitsTestBITZERO is the value of the BIT_ZERO bit.
+	BitsTestBITZERO BitsTest = 1 << 0
+	// BitsTestBITTHREE is the value of the BIT_THREE bit.
+	BitsTestBITTHREE BitsTest = 1 << 3
+)
+
 // pathElemExample is an example struct used for rendering using gNMI PathElems.
 type pathElemExample struct {
 	List        map[string]*pathElemExampleChild                                  `path:"list"`
@@ -1660,6 +1686,34 @@ func TestConstructJSON(t *testing.T) {
 			"str": "hello",
 		},
 		wantSame: true,
+	}, {
+		name: "bits render",
+		in: &renderExample{
+			Bits:         func(b BitsTest) *BitsTest { return &b }(BitsTestBITZERO | BitsTestBITTHREE),
+			BitsLeafList: []BitsTest{BitsTestBITTHREE, 0},
+		},
+		wantIETF: map[string]interface{}{
+			"bits":          "BIT_ZERO BIT_THREE",
+			"bits-leaflist": []interface{}{"BIT_THREE", ""},
+		},
+		wantSame: true,
+	}, {
+		name: "exact decimal64 render",
+		in: &renderExample{
+			Decimal:     func(d Decimal64) *Decimal64 { return &d }("0.300000000000000001"),
+			DecimalList: []Decimal64{"1.50", "-0.25"},
+		},
+		wantIETF: map[string]interface{}{
+			"decimal":          "0.300000000000000001",
+			"decimal-leaflist": []interface{}{"1.50", "-0.25"},
+		},
+		wantSame: true,
+	}, {
+		name: "bits with unknown position",
+		in: &renderExample{
+			Bits: func(b BitsTest) *BitsTest { return &b }(1 << 1),
+		},
+		wantErr: true,
 	}, {
 		name: "empty value",
 		in: &renderExample{
@@ -2504,6 +2558,14 @@ func TestKeyValueAsString(t *testing.T) {
 			i:                EnumTest(42),
 			wantErrSubstring: "cannot map enumerated value as type EnumTest has unknown value 42",
 		},
+		{
+			i:    BitsTestBITZERO | BitsTestBITTHREE,
+			want: "BIT_ZERO BIT_THREE",
+		},
+		{
+			i:                BitsTest(1 << 2),
+			wantErrSubstring: "cannot map bits value as type BitsTest has unknown bit at position 2",
+		},
 		{
 			i:                interface{}(nil),
 			wantErrSubstring: "cannot convert type invalid to a string for use in a key",
@@ -2553,6 +2615,44 @@ func TestEncodeTypedValue(t *testing.T) {
 				}},
 			},
 		}},
+	}, {
+		name:  "bits",
+		inVal: BitsTestBITTHREE,
+		want:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"BIT_THREE"}},
+	}, {
+		name:  "pointer to bits",
+		inVal: func(b BitsTest) *BitsTest { return &b }(BitsTestBITZERO | BitsTestBITTHREE),
+		want:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"BIT_ZERO BIT_THREE"}},
+	}, {
+		name:  "leaf-list of bits",
+		inVal: []BitsTest{BitsTestBITZERO},
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{
+			&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{{
+					Value: &gnmipb.TypedValue_StringVal{"BIT_ZERO"},
+				}},
+			},
+		}},
+	}, {
+		name:  "exact decimal64",
+		inVal: func(d Decimal64) *Decimal64 { return &d }("0.300000000000000001"),
+		want:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 300000000000000001, Precision: 18}}},
+	}, {
+		name:  "leaf-list of exact decimal64",
+		inVal: []Decimal64{"1.50", "-2.0"},
+		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_LeaflistVal{
+			&gnmipb.ScalarArray{
+				Element: []*gnmipb.TypedValue{{
+					Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: 150, Precision: 2}},
+				}, {
+					Value: &gnmipb.TypedValue_DecimalVal{&gnmipb.Decimal64{Digits: -20, Precision: 1}},
+				}},
+			},
+		}},
+	}, {
+		name:             "invalid exact decimal64",
+		inVal:            Decimal64("1.2.3"),
+		wantErrSubstring: "cannot marshal decimal64",
 	}, {
 		name:  "leaf-list of string",
 		inVal: []string{"one", "two"},
@@ -2615,6 +2715,13 @@ func TestEncodeTypedValue(t *testing.T) {
 		want: &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonVal{[]byte(`{
   "f1": "hi"
 }`)}},
+	}, {
+		name: "struct val - bytes",
+		inVal: &ietfRenderExample{
+			F1: String("hello"),
+		},
+		inEnc:            gnmipb.Encoding_BYTES,
+		wantErrSubstring: "invalid encoding BYTES",
 	}, {
 		name:             "unsupported encoding",
 		inVal:            &ietfRenderExample{},
@@ -2645,3 +2752,238 @@ func TestEncodeTypedValue(t *testing.T) {
 		})
 	}
 }
+
+// orderedTestMember is a member of the ordered-by user list that is
+// represented by orderedTestList.
+type orderedTestMember struct {
+	Name  *string `path:"config/name|name"`
+	Value *string `path:"config/value"`
+}
+
+func (*orderedTestMember) IsYANGGoStruct() {}
+
+func (m *orderedTestMember) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *m.Name}, nil
+}
+
+// orderedTestList is an ordered map of orderedTestMember structs, keyed by
+// their name, of the form that is generated for an ordered-by user list.
+type orderedTestList struct {
+	keys     []string
+	valueMap map[string]*orderedTestMember
+}
+
+func (*orderedTestList) IsYANGOrderedList() {}
+
+func (o *orderedTestList) Keys() []string {
+	if o == nil {
+		return nil
+	}
+	return append([]string{}, o.keys...)
+}
+
+func (o *orderedTestList) Values() []*orderedTestMember {
+	if o == nil {
+		return nil
+	}
+	var values []*orderedTestMember
+	for _, k := range o.keys {
+		values = append(values, o.valueMap[k])
+	}
+	return values
+}
+
+func (o *orderedTestList) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.keys)
+}
+
+func (o *orderedTestList) Get(key string) *orderedTestMember {
+	if o == nil {
+		return nil
+	}
+	return o.valueMap[key]
+}
+
+func (o *orderedTestList) Delete(key string) bool {
+	if _, ok := o.valueMap[key]; !ok {
+		return false
+	}
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			break
+		}
+	}
+	delete(o.valueMap, key)
+	return true
+}
+
+func (o *orderedTestList) Append(v *orderedTestMember) error {
+	if v == nil || v.Name == nil {
+		return fmt.Errorf("invalid member %v", v)
+	}
+	if _, ok := o.valueMap[*v.Name]; ok {
+		return fmt.Errorf("duplicate key %s", *v.Name)
+	}
+	if o.valueMap == nil {
+		o.valueMap = map[string]*orderedTestMember{}
+	}
+	o.keys = append(o.keys, *v.Name)
+	o.valueMap[*v.Name] = v
+	return nil
+}
+
+// newOrderedTestList returns an orderedTestList containing members with the
+// supplied names and values, in order.
+func newOrderedTestList(nameValues ...string) *orderedTestList {
+	o := &orderedTestList{}
+	for i := 0; i < len(nameValues); i += 2 {
+		if err := o.Append(&orderedTestMember{Name: String(nameValues[i]), Value: String(nameValues[i+1])}); err != nil {
+			panic(err)
+		}
+	}
+	return o
+}
+
+// orderedTestRoot is a GoStruct containing an ordered-by user list.
+type orderedTestRoot struct {
+	List *orderedTestList `path:"lists/list"`
+}
+
+func (*orderedTestRoot) IsYANGGoStruct()                         {}
+func (*orderedTestRoot) Validate(...ValidationOption) error      { return nil }
+func (*orderedTestRoot) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+func TestOrderedListRender(t *testing.T) {
+	in := &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one", "c", "three")}
+
+	gotJSON, err := ConstructIETFJSON(in, nil)
+	if err != nil {
+		t.Fatalf("ConstructIETFJSON: got unexpected error: %v", err)
+	}
+	wantJSON := map[string]interface{}{
+		"lists": map[string]interface{}{
+			"list": []interface{}{
+				map[string]interface{}{"name": "b", "config": map[string]interface{}{"name": "b", "value": "two"}},
+				map[string]interface{}{"name": "a", "config": map[string]interface{}{"name": "a", "value": "one"}},
+				map[string]interface{}{"name": "c", "config": map[string]interface{}{"name": "c", "value": "three"}},
+			},
+		},
+	}
+	if diff := cmp.Diff(wantJSON, gotJSON); diff != "" {
+		t.Errorf("ConstructIETFJSON: did not get expected JSON, diff(-want,+got):\n%s", diff)
+	}
+
+	gotNotifs, err := TogNMINotifications(in, 42, GNMINotificationsConfig{UsePathElem: true})
+	if err != nil {
+		t.Fatalf("TogNMINotifications: got unexpected error: %v", err)
+	}
+	if len(gotNotifs) != 1 {
+		t.Fatalf("TogNMINotifications: got %d notifications, want 1", len(gotNotifs))
+	}
+	var gotPaths []string
+	for _, u := range gotNotifs[0].GetUpdate() {
+		p, err := PathToString(u.GetPath())
+		if err != nil {
+			t.Fatalf("cannot convert path %v to string: %v", u.GetPath(), err)
+		}
+		gotPaths = append(gotPaths, p)
+	}
+	var wantPaths []string
+	for _, k := range []string{"b", "a", "c"} {
+		wantPaths = append(wantPaths,
+			fmt.Sprintf("/lists/list[name=%s]/config/name", k),
+			fmt.Sprintf("/lists/list[name=%s]/name", k),
+			fmt.Sprintf("/lists/list[name=%s]/config/value", k))
+	}
+	if diff := cmp.Diff(wantPaths, gotPaths); diff != "" {
+		t.Errorf("TogNMINotifications: did not get updates in expected order, diff(-want,+got):\n%s", diff)
+	}
+}
+
+// presenceTestRoot is a GoStruct containing a presence container.
+type presenceTestRoot struct {
+	Hostname *string              `path:"system/hostname"`
+	Logging  *presenceTestLogging `path:"system/logging" yangPresence:"true"`
+	Clock    *presenceTestLogging `path:"system/clock"`
+}
+
+func (*presenceTestRoot) IsYANGGoStruct()                         {}
+func (*presenceTestRoot) Validate(...ValidationOption) error      { return nil }
+func (*presenceTestRoot) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type presenceTestLogging struct {
+	Level *string `path:"level"`
+}
+
+func (*presenceTestLogging) IsYANGGoStruct() {}
+
+func TestPresenceContainerRender(t *testing.T) {
+	tests := []struct {
+		desc        string
+		in          *presenceTestRoot
+		wantJSON    map[string]interface{}
+		wantUpdates []*gnmipb.Update
+	}{{
+		desc: "empty presence container",
+		in:   &presenceTestRoot{Logging: &presenceTestLogging{}, Clock: &presenceTestLogging{}},
+		wantJSON: map[string]interface{}{
+			"system": map[string]interface{}{
+				"logging": map[string]interface{}{},
+			},
+		},
+		wantUpdates: []*gnmipb.Update{{
+			Path: mustPath(t, "/system/logging"),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonVal{[]byte("{}")}},
+		}},
+	}, {
+		desc: "populated presence container",
+		in:   &presenceTestRoot{Hostname: String("rtr1"), Logging: &presenceTestLogging{Level: String("info")}},
+		wantJSON: map[string]interface{}{
+			"system": map[string]interface{}{
+				"hostname": "rtr1",
+				"logging":  map[string]interface{}{"level": "info"},
+			},
+		},
+		wantUpdates: []*gnmipb.Update{{
+			Path: mustPath(t, "/system/hostname"),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"rtr1"}},
+		}, {
+			Path: mustPath(t, "/system/logging"),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonVal{[]byte("{}")}},
+		}, {
+			Path: mustPath(t, "/system/logging/level"),
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"info"}},
+		}},
+	}, {
+		desc:     "unset presence container",
+		in:       &presenceTestRoot{Clock: &presenceTestLogging{}},
+		wantJSON: map[string]interface{}{},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			gotJSON, err := ConstructIETFJSON(tt.in, nil)
+			if err != nil {
+				t.Fatalf("ConstructIETFJSON: got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantJSON, gotJSON); diff != "" {
+				t.Errorf("ConstructIETFJSON: did not get expected JSON, diff(-want,+got):\n%s", diff)
+			}
+
+			gotNotifs, err := TogNMINotifications(tt.in, 42, GNMINotificationsConfig{UsePathElem: true})
+			if err != nil {
+				t.Fatalf("TogNMINotifications: got unexpected error: %v", err)
+			}
+			if len(gotNotifs) != 1 {
+				t.Fatalf("TogNMINotifications: got %d notifications, want 1", len(gotNotifs))
+			}
+			if diff := cmp.Diff(tt.wantUpdates, gotNotifs[0].GetUpdate(), cmp.Comparer(proto.Equal)); diff != "" {
+				t.Errorf("TogNMINotifications: did not get expected updates, diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
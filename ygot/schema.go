@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
 )
 
 // GzipToSchema takes an input byte slice, and returns it as
@@ -51,7 +52,8 @@ func GzipToSchema(gzj []byte) (map[string]*yang.Entry, error) {
 // rebuildSchemaMap takes an input yang.Entry and appends it to the
 // schema map. The key of the map is the stored name of the generated
 // struct which is stored in the Annotation field of the yang.Entry when
-// serialised.
+// serialised. The input and output entries of RPCs and actions, which are
+// not stored within the Dir of their parent, are also appended.
 func rebuildSchemaMap(e, parent *yang.Entry, schema map[string]*yang.Entry) {
 	if n, ok := e.Annotation["structname"]; ok {
 		if s, ok := n.(string); ok {
@@ -63,4 +65,8 @@ func rebuildSchemaMap(e, parent *yang.Entry, schema map[string]*yang.Entry) {
 	for _, ch := range e.Dir {
 		rebuildSchemaMap(ch, e, schema)
 	}
+
+	for _, io := range util.RPCInputOutput(e) {
+		rebuildSchemaMap(io, e, schema)
+	}
 }
@@ -104,3 +104,52 @@ func TestGzipToSchema(t *testing.T) {
 		}
 	}
 }
+
+func TestRebuildSchemaMapRPC(t *testing.T) {
+	input := &yang.Entry{
+		Name: "input",
+		Kind: yang.InputEntry,
+		Annotation: map[string]interface{}{
+			"structname": "Reboot_Input",
+		},
+		Dir: map[string]*yang.Entry{
+			"delay": {Name: "delay"},
+		},
+	}
+	rpc := &yang.Entry{
+		Name: "reboot",
+		RPC:  &yang.RPCEntry{Input: input},
+	}
+	notification := &yang.Entry{
+		Name: "link-down",
+		Kind: yang.NotificationEntry,
+		Annotation: map[string]interface{}{
+			"structname": "LinkDown",
+		},
+	}
+	root := &yang.Entry{
+		Dir: map[string]*yang.Entry{
+			"reboot":    rpc,
+			"link-down": notification,
+		},
+	}
+
+	got := map[string]*yang.Entry{}
+	rebuildSchemaMap(root, nil, got)
+
+	want := map[string]*yang.Entry{
+		"Reboot_Input": input,
+		"LinkDown":     notification,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rebuildSchemaMap: did not get expected schema map, got: %v, want: %v", got, want)
+	}
+
+	if input.Parent != rpc {
+		t.Errorf("rebuildSchemaMap: did not get expected parent for input, got: %v, want: %v", input.Parent, rpc)
+	}
+
+	if got := input.Dir["delay"].Parent; got != input {
+		t.Errorf("rebuildSchemaMap: did not get expected parent for input leaf, got: %v, want: %v", got, input)
+	}
+}
@@ -0,0 +1,403 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/openconfig/ygot/util"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// SetRequestOpt is a DiffOpt that controls the form of the SetRequest that is
+// returned by DiffToSetRequest. It is ignored by Diff.
+type SetRequestOpt struct {
+	// ReplaceContainers specifies that, rather than updating or deleting
+	// each leaf that has changed, the container or list member that
+	// directly encloses the changed leaves is replaced with its contents
+	// in the modified struct. Since containers can only be represented as
+	// encoded subtrees, the values of replace operations are always
+	// JSON_IETF encoded.
+	ReplaceContainers bool
+	// JSONIETF specifies that the values of updates are JSON_IETF encoded,
+	// rather than being scalar TypedValues. Containers and list members
+	// that are not present in the original struct are sent as a single
+	// update of the entire subtree, rather than an update per leaf.
+	JSONIETF bool
+	// CommonPrefix specifies that the longest common prefix of the paths
+	// of the operations within the SetRequest is used as its prefix, and
+	// is removed from the path of each operation. The prefix is chosen
+	// such that no operation has an empty path.
+	CommonPrefix bool
+}
+
+// IsDiffOpt marks SetRequestOpt as a diff option.
+func (*SetRequestOpt) IsDiffOpt() {}
+
+// hasSetRequestOpt extracts a SetRequestOpt from the opts slice provided. In
+// the case that there are multiple SetRequestOpt structs within the opts
+// slice, the first is returned.
+func hasSetRequestOpt(opts []DiffOpt) *SetRequestOpt {
+	for _, o := range opts {
+		if so, ok := o.(*SetRequestOpt); ok {
+			return so
+		}
+	}
+	return nil
+}
+
+// DiffToSetRequest takes an original and modified GoStruct, which must be of
+// the same type, and returns a gNMI SetRequest that, when applied to a target
+// whose data tree is original, results in the data tree modified. By default,
+// the SetRequest contains a delete for each leaf that is set only in original,
+// and an update for each leaf whose value is changed or that is set only in
+// modified, as returned by Diff.
+//
+// The supplied DiffOpts are handed to Diff, such that they control the paths
+// that are used. A SetRequestOpt can be supplied to control whether
+// containers are replaced, how values are encoded and whether a common prefix
+// is used. Where containers are replaced, or values are JSON_IETF encoded,
+// containers and list members that are set only in original are deleted by a
+// single delete operation.
+//
+// Operations within the returned SetRequest are sorted by path. As per Diff,
+// if original and modified do not represent the root of the schema tree, the
+// paths within the SetRequest are relative to the supplied structs.
+func DiffToSetRequest(original, modified GoStruct, opts ...DiffOpt) (*gnmipb.SetRequest, error) {
+	n, err := Diff(original, modified, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	sopt := hasSetRequestOpt(opts)
+	if sopt == nil {
+		sopt = &SetRequestOpt{}
+	}
+
+	req := &gnmipb.SetRequest{
+		Delete: n.Delete,
+		Update: n.Update,
+	}
+	if sopt.ReplaceContainers || sopt.JSONIETF {
+		b, err := newSetRequestBuilder(original, modified, sopt.ReplaceContainers, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range n.Delete {
+			b.delete(p)
+		}
+		for _, u := range n.Update {
+			if err := b.update(u.Path); err != nil {
+				return nil, err
+			}
+		}
+		if req, err = b.setRequest(); err != nil {
+			return nil, err
+		}
+	}
+
+	sortSetRequest(req)
+	if sopt.CommonPrefix {
+		setRequestPrefix(req)
+	}
+	return req, nil
+}
+
+// setContainer is a container or list member within a GoStruct.
+type setContainer struct {
+	// path is the path of the container.
+	path *gnmipb.Path
+	// s is the GoStruct that represents the container.
+	s GoStruct
+}
+
+// setRequestBuilder forms a SetRequest from the updates and deletes that are
+// returned by Diff.
+type setRequestBuilder struct {
+	// orig and mod are the containers that are set within the original and
+	// modified structs, keyed by the string form of their path.
+	orig, mod map[string]*setContainer
+	// replaceContainers specifies whether the container enclosing changed
+	// leaves is replaced.
+	replaceContainers bool
+	// filter restricts the containers that may be replaced, updated or
+	// deleted as a whole to those within its subtrees.
+	filter *DiffFilterOpt
+	// deletes, replaces and updates are the operations within the
+	// SetRequest, keyed by the string form of their path.
+	deletes  map[string]*gnmipb.Path
+	replaces map[string]*setContainer
+	updates  map[string]*gnmipb.Update
+	// json caches the RFC7951 JSON of containers of the modified struct,
+	// keyed by the string form of their path.
+	json map[string]map[string]interface{}
+}
+
+// newSetRequestBuilder returns a setRequestBuilder for the supplied original
+// and modified GoStructs. The opts are used to determine the paths of the
+// containers within the structs.
+func newSetRequestBuilder(original, modified GoStruct, replaceContainers bool, opts []DiffOpt) (*setRequestBuilder, error) {
+	orig, err := findSetContainers(original, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract set containers from original struct: %v", err)
+	}
+	mod, err := findSetContainers(modified, opts)
+	if err != nil {
+		return nil, fmt.Errorf("could not extract set containers from modified struct: %v", err)
+	}
+	return &setRequestBuilder{
+		orig:              orig,
+		mod:               mod,
+		replaceContainers: replaceContainers,
+		filter:            hasDiffFilterOpt(opts),
+		deletes:           map[string]*gnmipb.Path{},
+		replaces:          map[string]*setContainer{},
+		updates:           map[string]*gnmipb.Update{},
+		json:              map[string]map[string]interface{}{},
+	}, nil
+}
+
+// findSetContainers returns the containers and list members that are set
+// within s, including s itself, keyed by the string form of their path.
+func findSetContainers(s GoStruct, opts []DiffOpt) (map[string]*setContainer, error) {
+	nodes, err := findSetNodes(s, opts...)
+	if err != nil {
+		return nil, err
+	}
+	out := map[string]*setContainer{
+		"/": {path: &gnmipb.Path{}, s: s},
+	}
+	for ps, c := range nodes.containers {
+		for _, p := range ps.gNMIPaths {
+			k, err := PathToString(p)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = &setContainer{path: p, s: c}
+		}
+	}
+	return out, nil
+}
+
+// containerAt returns the container of m whose path is the first i elements
+// of p, or nil if there is no such container.
+func containerAt(m map[string]*setContainer, p *gnmipb.Path, i int) *setContainer {
+	k, err := PathToString(&gnmipb.Path{Elem: p.GetElem()[:i]})
+	if err != nil {
+		return nil
+	}
+	return m[k]
+}
+
+// highestContainer returns the container with the shortest path that is a
+// proper prefix of p, is within the filter f, and is set in the in map, but
+// not the notIn map. It returns nil if there is no such container.
+func highestContainer(in, notIn map[string]*setContainer, f *DiffFilterOpt, p *gnmipb.Path) *setContainer {
+	for i := 1; i < len(p.GetElem()); i++ {
+		if c := containerAt(in, p, i); c != nil && containerAt(notIn, p, i) == nil && f.matches(c.path) {
+			return c
+		}
+	}
+	return nil
+}
+
+// enclosingContainer returns the container of the modified struct with the
+// longest path that is a proper prefix of p. If filtered is true, only
+// containers within the builder's filter are considered. It returns nil if
+// there is no such container.
+func (b *setRequestBuilder) enclosingContainer(p *gnmipb.Path, filtered bool) *setContainer {
+	for i := len(p.GetElem()) - 1; i >= 0; i-- {
+		if c := containerAt(b.mod, p, i); c != nil && (!filtered || b.filter.matches(c.path)) {
+			return c
+		}
+	}
+	return nil
+}
+
+// delete adds the operations that delete the leaf at path p.
+func (b *setRequestBuilder) delete(p *gnmipb.Path) {
+	if c := highestContainer(b.orig, b.mod, b.filter, p); c != nil {
+		b.deletes[pathKey(c.path)] = c.path
+		return
+	}
+	if c := b.enclosingContainer(p, true); c != nil && b.replaceContainers {
+		b.replaces[pathKey(c.path)] = c
+		return
+	}
+	b.deletes[pathKey(p)] = p
+}
+
+// update adds the operations that update the leaf at path p to its value in
+// the modified struct.
+func (b *setRequestBuilder) update(p *gnmipb.Path) error {
+	if c := highestContainer(b.mod, b.orig, b.filter, p); c != nil {
+		if b.replaceContainers {
+			b.replaces[pathKey(c.path)] = c
+			return nil
+		}
+		v, err := EncodeTypedValue(c.s, gnmipb.Encoding_JSON_IETF)
+		if err != nil {
+			return err
+		}
+		b.updates[pathKey(c.path)] = &gnmipb.Update{Path: c.path, Val: v}
+		return nil
+	}
+
+	if c := b.enclosingContainer(p, true); c != nil && b.replaceContainers {
+		b.replaces[pathKey(c.path)] = c
+		return nil
+	}
+	v, err := b.leafValue(b.enclosingContainer(p, false), p)
+	if err != nil {
+		return err
+	}
+	b.updates[pathKey(p)] = &gnmipb.Update{Path: p, Val: v}
+	return nil
+}
+
+// leafValue returns the JSON_IETF encoded value of the leaf at path p, which
+// is a descendant of the container c of the modified struct.
+func (b *setRequestBuilder) leafValue(c *setContainer, p *gnmipb.Path) (*gnmipb.TypedValue, error) {
+	k := pathKey(c.path)
+	j, ok := b.json[k]
+	if !ok {
+		var err error
+		if j, err = ConstructIETFJSON(c.s, &RFC7951JSONConfig{AppendModuleName: true}); err != nil {
+			return nil, err
+		}
+		b.json[k] = j
+	}
+
+	var v interface{} = j
+	for _, e := range p.GetElem()[len(c.path.GetElem()):] {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("cannot find value of %s in JSON of %s", pathKey(p), k)
+		}
+		if v, ok = jsonChild(m, e.Name); !ok {
+			return nil, fmt.Errorf("cannot find value of %s in JSON of %s", pathKey(p), k)
+		}
+	}
+
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot encode JSON, %v", err)
+	}
+	return &gnmipb.TypedValue{Value: &gnmipb.TypedValue_JsonIetfVal{js}}, nil
+}
+
+// jsonChild returns the child of the RFC7951 JSON object m with the supplied
+// name, which may be qualified with the name of a module.
+func jsonChild(m map[string]interface{}, name string) (interface{}, bool) {
+	if v, ok := m[name]; ok {
+		return v, true
+	}
+	for k, v := range m {
+		if util.StripModulePrefix(k) == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// setRequest returns the SetRequest that contains the operations that have
+// been added to the builder. Operations on the descendants of replaced
+// containers are not included, since they are made redundant by the replace.
+func (b *setRequestBuilder) setRequest() (*gnmipb.SetRequest, error) {
+	replaced := func(p *gnmipb.Path) bool {
+		for _, c := range b.replaces {
+			if len(p.GetElem()) > len(c.path.GetElem()) && util.PathMatchesPathElemPrefix(p, c.path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	req := &gnmipb.SetRequest{}
+	for _, p := range b.deletes {
+		if !replaced(p) {
+			req.Delete = append(req.Delete, p)
+		}
+	}
+	for _, c := range b.replaces {
+		if replaced(c.path) {
+			continue
+		}
+		v, err := EncodeTypedValue(c.s, gnmipb.Encoding_JSON_IETF)
+		if err != nil {
+			return nil, err
+		}
+		req.Replace = append(req.Replace, &gnmipb.Update{Path: c.path, Val: v})
+	}
+	for _, u := range b.updates {
+		if !replaced(u.Path) {
+			req.Update = append(req.Update, u)
+		}
+	}
+	return req, nil
+}
+
+// pathKey returns the string form of the path p, which is used to key maps
+// of paths.
+func pathKey(p *gnmipb.Path) string {
+	s, err := PathToString(p)
+	if err != nil {
+		return p.String()
+	}
+	return s
+}
+
+// sortSetRequest sorts the operations within req by their path.
+func sortSetRequest(req *gnmipb.SetRequest) {
+	sort.Slice(req.Delete, func(i, j int) bool { return pathKey(req.Delete[i]) < pathKey(req.Delete[j]) })
+	for _, u := range [][]*gnmipb.Update{req.Replace, req.Update} {
+		u := u
+		sort.Slice(u, func(i, j int) bool { return pathKey(u[i].Path) < pathKey(u[j].Path) })
+	}
+}
+
+// setRequestPrefix sets the prefix of req to the longest common prefix of
+// the paths of its operations, such that no operation has an empty path, and
+// removes the prefix from each path.
+func setRequestPrefix(req *gnmipb.SetRequest) {
+	paths := append([]*gnmipb.Path{}, req.Delete...)
+	for _, u := range append(append([]*gnmipb.Update{}, req.Replace...), req.Update...) {
+		paths = append(paths, u.Path)
+	}
+	if len(paths) == 0 {
+		return
+	}
+
+	pfx := util.FindPathElemPrefix(paths)
+	for _, p := range paths {
+		if pfx != nil && len(pfx.Elem) >= len(p.GetElem()) {
+			pfx.Elem = pfx.Elem[:len(p.GetElem())-1]
+		}
+	}
+	if len(pfx.GetElem()) == 0 {
+		return
+	}
+
+	req.Prefix = pfx
+	for i, p := range req.Delete {
+		req.Delete[i] = util.TrimGNMIPathElemPrefix(p, pfx)
+	}
+	for _, u := range append(append([]*gnmipb.Update{}, req.Replace...), req.Update...) {
+		u.Path = util.TrimGNMIPathElemPrefix(u.Path, pfx)
+	}
+}
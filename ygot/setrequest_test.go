@@ -0,0 +1,323 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/testutil"
+
+	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+type setReqDevice struct {
+	Interface map[string]*setReqInterface `path:"interfaces/interface"`
+	System    *setReqSystem               `path:"system"`
+}
+
+func (*setReqDevice) IsYANGGoStruct() {}
+
+type setReqInterface struct {
+	Name        *string `path:"config/name|name"`
+	Mtu         *uint16 `path:"config/mtu"`
+	Description *string `path:"config/description"`
+}
+
+func (*setReqInterface) IsYANGGoStruct() {}
+
+func (i *setReqInterface) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+type setReqSystem struct {
+	Hostname   *string      `path:"config/hostname"`
+	DomainName *string      `path:"config/domain-name"`
+	Clock      *setReqClock `path:"clock"`
+}
+
+func (*setReqSystem) IsYANGGoStruct() {}
+
+type setReqClock struct {
+	TimezoneName *string `path:"config/timezone-name"`
+}
+
+func (*setReqClock) IsYANGGoStruct() {}
+
+// mustPath returns the gNMI path corresponding to the string path s.
+func mustPath(t *testing.T, s string) *gnmipb.Path {
+	p, err := StringToStructuredPath(s)
+	if err != nil {
+		t.Fatalf("cannot parse path %s: %v", s, err)
+	}
+	return p
+}
+
+// compactJSONValues rewrites each JSON_IETF value within req in compact form,
+// such that it can be compared to an expected value.
+func compactJSONValues(t *testing.T, req *gnmipb.SetRequest) {
+	for _, u := range append(append([]*gnmipb.Update{}, req.Replace...), req.Update...) {
+		j := u.GetVal().GetJsonIetfVal()
+		if j == nil {
+			continue
+		}
+		var v interface{}
+		if err := json.Unmarshal(j, &v); err != nil {
+			t.Fatalf("invalid JSON value %s: %v", j, err)
+		}
+		c, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("cannot marshal JSON value %v: %v", v, err)
+		}
+		u.Val = testutil.JSONIETFVal(string(c))
+	}
+}
+
+func TestDiffToSetRequest(t *testing.T) {
+	device := func() *setReqDevice {
+		return &setReqDevice{
+			Interface: map[string]*setReqInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint16(1500)},
+			},
+			System: &setReqSystem{
+				Hostname:   String("rtr1"),
+				DomainName: String("example.com"),
+				Clock:      &setReqClock{TimezoneName: String("UTC")},
+			},
+		}
+	}
+	singlePath := &DiffPathOpt{MapToSinglePath: true}
+
+	tests := []struct {
+		desc          string
+		inOrig        GoStruct
+		inMod         func(*setReqDevice)
+		inOpts        []DiffOpt
+		want          func(t *testing.T) *gnmipb.SetRequest
+		wantErrSubstr string
+	}{{
+		desc: "no change",
+		inMod: func(*setReqDevice) {
+		},
+		want: func(*testing.T) *gnmipb.SetRequest { return &gnmipb.SetRequest{} },
+	}, {
+		desc: "leaf updates and deletes",
+		inMod: func(d *setReqDevice) {
+			d.System.Hostname = String("rtr2")
+			d.System.DomainName = nil
+		},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Delete: []*gnmipb.Path{mustPath(t, "/system/config/domain-name")},
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/system/config/hostname"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"rtr2"}},
+				}},
+			}
+		},
+	}, {
+		desc: "JSON_IETF leaf updates",
+		inMod: func(d *setReqDevice) {
+			d.System.Hostname = String("rtr2")
+			d.Interface["eth0"].Mtu = Uint16(9000)
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{JSONIETF: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth0]/config/mtu"),
+					Val:  testutil.JSONIETFVal(`9000`),
+				}, {
+					Path: mustPath(t, "/system/config/hostname"),
+					Val:  testutil.JSONIETFVal(`"rtr2"`),
+				}},
+			}
+		},
+	}, {
+		desc: "JSON_IETF subtree for new list member",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth1"] = &setReqInterface{Name: String("eth1"), Description: String("uplink")}
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{JSONIETF: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth1]"),
+					Val:  testutil.JSONIETFVal(`{"config":{"description":"uplink","name":"eth1"},"name":"eth1"}`),
+				}},
+			}
+		},
+	}, {
+		desc: "JSON_IETF single delete of removed containers",
+		inMod: func(d *setReqDevice) {
+			d.Interface = nil
+			d.System.Clock = nil
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{JSONIETF: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Delete: []*gnmipb.Path{
+					mustPath(t, "/interfaces/interface[name=eth0]"),
+					mustPath(t, "/system/clock"),
+				},
+			}
+		},
+	}, {
+		desc: "replace enclosing container",
+		inMod: func(d *setReqDevice) {
+			d.System.Hostname = String("rtr2")
+			d.System.DomainName = nil
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{ReplaceContainers: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Replace: []*gnmipb.Update{{
+					Path: mustPath(t, "/system"),
+					Val:  testutil.JSONIETFVal(`{"clock":{"config":{"timezone-name":"UTC"}},"config":{"hostname":"rtr2"}}`),
+				}},
+			}
+		},
+	}, {
+		desc: "replace list members",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth0"].Description = String("core")
+			d.Interface["eth1"] = &setReqInterface{Name: String("eth1")}
+		},
+		inOpts: []DiffOpt{singlePath, &SetRequestOpt{ReplaceContainers: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Replace: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth0]"),
+					Val:  testutil.JSONIETFVal(`{"config":{"description":"core","mtu":1500,"name":"eth0"},"name":"eth0"}`),
+				}, {
+					Path: mustPath(t, "/interfaces/interface[name=eth1]"),
+					Val:  testutil.JSONIETFVal(`{"config":{"name":"eth1"},"name":"eth1"}`),
+				}},
+			}
+		},
+	}, {
+		desc: "replace of descendant is redundant",
+		inMod: func(d *setReqDevice) {
+			d.System.Hostname = String("rtr2")
+			d.System.Clock.TimezoneName = String("PST")
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{ReplaceContainers: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Replace: []*gnmipb.Update{{
+					Path: mustPath(t, "/system"),
+					Val:  testutil.JSONIETFVal(`{"clock":{"config":{"timezone-name":"PST"}},"config":{"domain-name":"example.com","hostname":"rtr2"}}`),
+				}},
+			}
+		},
+	}, {
+		desc: "removed container is deleted when replacing",
+		inMod: func(d *setReqDevice) {
+			d.System.Clock = nil
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{ReplaceContainers: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Delete: []*gnmipb.Path{mustPath(t, "/system/clock")},
+			}
+		},
+	}, {
+		desc: "common prefix",
+		inMod: func(d *setReqDevice) {
+			d.System.Hostname = String("rtr2")
+			d.System.DomainName = nil
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{CommonPrefix: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Prefix: mustPath(t, "/system/config"),
+				Delete: []*gnmipb.Path{mustPath(t, "/domain-name")},
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/hostname"),
+					Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"rtr2"}},
+				}},
+			}
+		},
+	}, {
+		desc: "common prefix leaves non-empty path",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth0"].Mtu = Uint16(9000)
+		},
+		inOpts: []DiffOpt{&SetRequestOpt{CommonPrefix: true, ReplaceContainers: true}},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Prefix: mustPath(t, "/interfaces"),
+				Replace: []*gnmipb.Update{{
+					Path: mustPath(t, "/interface[name=eth0]"),
+					Val:  testutil.JSONIETFVal(`{"config":{"mtu":9000,"name":"eth0"},"name":"eth0"}`),
+				}},
+			}
+		},
+	}, {
+		desc: "filter restricts replaced containers",
+		inMod: func(d *setReqDevice) {
+			d.Interface["eth0"].Mtu = Uint16(9000)
+			d.System.Hostname = String("rtr2")
+		},
+		inOpts: []DiffOpt{
+			singlePath,
+			&SetRequestOpt{ReplaceContainers: true},
+			&DiffFilterOpt{Paths: []*gnmipb.Path{mustPath(t, "/system/config"), mustPath(t, "/interfaces/interface")}},
+		},
+		want: func(t *testing.T) *gnmipb.SetRequest {
+			return &gnmipb.SetRequest{
+				Replace: []*gnmipb.Update{{
+					Path: mustPath(t, "/interfaces/interface[name=eth0]"),
+					Val:  testutil.JSONIETFVal(`{"config":{"mtu":9000,"name":"eth0"},"name":"eth0"}`),
+				}},
+				Update: []*gnmipb.Update{{
+					Path: mustPath(t, "/system/config/hostname"),
+					Val:  testutil.JSONIETFVal(`"rtr2"`),
+				}},
+			}
+		},
+	}, {
+		desc:          "different types",
+		inOrig:        &renderExample{},
+		inMod:         func(*setReqDevice) {},
+		wantErrSubstr: "cannot diff structs of different types",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig := tt.inOrig
+			if orig == nil {
+				orig = device()
+			}
+			mod := device()
+			tt.inMod(mod)
+
+			got, err := DiffToSetRequest(orig, mod, tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("DiffToSetRequest: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			compactJSONValues(t, got)
+			if want := tt.want(t); !proto.Equal(got, want) {
+				t.Errorf("DiffToSetRequest: did not get expected SetRequest, diff(-got,+want):\n%s", pretty.Compare(got, want))
+			}
+		})
+	}
+}
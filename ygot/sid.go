@@ -0,0 +1,257 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/ygot/util"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc9595 for the format of YANG
+// Schema Item iDentifier (SID) files.
+
+// SID namespaces, as used within the items of a SID file.
+const (
+	// SIDNamespaceModule is the namespace of the SIDs assigned to modules.
+	SIDNamespaceModule = "module"
+	// SIDNamespaceIdentity is the namespace of the SIDs assigned to
+	// identities.
+	SIDNamespaceIdentity = "identity"
+	// SIDNamespaceFeature is the namespace of the SIDs assigned to
+	// features.
+	SIDNamespaceFeature = "feature"
+	// SIDNamespaceData is the namespace of the SIDs assigned to schema
+	// nodes.
+	SIDNamespaceData = "data"
+)
+
+// sidFileKey is the name of the top-level member of the JSON encoding of a
+// SID file.
+const sidFileKey = "ietf-sid-file:sid-file"
+
+// SIDFile is a SID file, which assigns SIDs to the items of a YANG module.
+type SIDFile struct {
+	// ModuleName is the name of the module that the SIDs are assigned for.
+	ModuleName string
+	// ModuleRevision is the revision of the module.
+	ModuleRevision string
+	// AssignmentRanges are the ranges of SIDs that are allocated to the
+	// module.
+	AssignmentRanges []SIDRange
+	// Items are the SIDs that are assigned to the items of the module.
+	Items []SIDItem
+}
+
+// SIDRange is a range of SIDs.
+type SIDRange struct {
+	// EntryPoint is the first SID of the range.
+	EntryPoint uint64
+	// Size is the number of SIDs in the range.
+	Size uint64
+}
+
+// SIDItem is a SID that is assigned to an item of a YANG module.
+type SIDItem struct {
+	// Namespace is the namespace of the item, which is one of the
+	// SIDNamespace values.
+	Namespace string
+	// Identifier identifies the item. It is the name of the module, the
+	// name of the identity or feature, or the schema node path of a data
+	// node, with each node qualified by the name of its module where it
+	// differs from that of its parent, as per RFC7951 Section 6.11.
+	Identifier string
+	// SID is the SID assigned to the item.
+	SID uint64
+}
+
+// jsonSIDFile is the JSON encoding of a SID file.
+type jsonSIDFile struct {
+	ModuleName       string         `json:"module-name"`
+	ModuleRevision   string         `json:"module-revision,omitempty"`
+	AssignmentRanges []jsonSIDRange `json:"assignment-range,omitempty"`
+	Items            []jsonSIDItem  `json:"item,omitempty"`
+}
+
+// jsonSIDRange is the JSON encoding of a SIDRange.
+type jsonSIDRange struct {
+	EntryPoint sidUint64 `json:"entry-point"`
+	Size       sidUint64 `json:"size"`
+}
+
+// jsonSIDItem is the JSON encoding of a SIDItem.
+type jsonSIDItem struct {
+	Namespace  string    `json:"namespace"`
+	Identifier string    `json:"identifier"`
+	SID        sidUint64 `json:"sid"`
+}
+
+// sidUint64 is a uint64 value within a SID file. It is encoded as a string,
+// as per RFC7951 Section 6.1, but may be decoded from either a string or a
+// number.
+type sidUint64 uint64
+
+// MarshalJSON implements the json.Marshaler interface.
+func (u sidUint64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strconv.FormatUint(uint64(u), 10))
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (u *sidUint64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(strings.Trim(string(b), `"`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid uint64 value %s", b)
+	}
+	*u = sidUint64(v)
+	return nil
+}
+
+// ParseSIDFile parses the JSON encoded SID file in data.
+func ParseSIDFile(data []byte) (*SIDFile, error) {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return nil, fmt.Errorf("cannot parse SID file: %v", err)
+	}
+	raw, ok := top[sidFileKey]
+	if !ok {
+		return nil, fmt.Errorf("cannot parse SID file: missing %s", sidFileKey)
+	}
+	var jf jsonSIDFile
+	if err := json.Unmarshal(raw, &jf); err != nil {
+		return nil, fmt.Errorf("cannot parse SID file: %v", err)
+	}
+
+	f := &SIDFile{ModuleName: jf.ModuleName, ModuleRevision: jf.ModuleRevision}
+	for _, r := range jf.AssignmentRanges {
+		f.AssignmentRanges = append(f.AssignmentRanges, SIDRange{EntryPoint: uint64(r.EntryPoint), Size: uint64(r.Size)})
+	}
+	for _, i := range jf.Items {
+		f.Items = append(f.Items, SIDItem{Namespace: i.Namespace, Identifier: i.Identifier, SID: uint64(i.SID)})
+	}
+	return f, nil
+}
+
+// MarshalJSON implements the json.Marshaler interface, returning the JSON
+// encoding of the SID file as per RFC9595.
+func (f *SIDFile) MarshalJSON() ([]byte, error) {
+	jf := jsonSIDFile{ModuleName: f.ModuleName, ModuleRevision: f.ModuleRevision}
+	for _, r := range f.AssignmentRanges {
+		jf.AssignmentRanges = append(jf.AssignmentRanges, jsonSIDRange{EntryPoint: sidUint64(r.EntryPoint), Size: sidUint64(r.Size)})
+	}
+	for _, i := range f.Items {
+		jf.Items = append(jf.Items, jsonSIDItem{Namespace: i.Namespace, Identifier: i.Identifier, SID: sidUint64(i.SID)})
+	}
+	return json.Marshal(map[string]interface{}{sidFileKey: jf})
+}
+
+// SIDMap maps between SIDs and the items that they are assigned to, as
+// defined by a set of SID files.
+type SIDMap struct {
+	// data is a map, keyed by schema node path without module names, of
+	// the SIDs of data nodes.
+	data map[string]uint64
+	// dataPaths is a map, keyed by SID, of the schema node paths of data
+	// nodes, as specified in the SID files.
+	dataPaths map[uint64]string
+	// identities is a map, keyed by the name of an identity qualified with
+	// the name of its module, of the SIDs of identities.
+	identities map[string]uint64
+	// identityNames is a map, keyed by SID, of the module-qualified names
+	// of identities.
+	identityNames map[uint64]string
+}
+
+// NewSIDMap returns a SIDMap for the items that are defined by the supplied
+// SID files. An error is returned if a SID is assigned to more than one item.
+func NewSIDMap(files ...*SIDFile) (*SIDMap, error) {
+	m := &SIDMap{
+		data:          map[string]uint64{},
+		dataPaths:     map[uint64]string{},
+		identities:    map[string]uint64{},
+		identityNames: map[uint64]string{},
+	}
+	assigned := map[uint64]string{}
+	var errs errlist.List
+	for _, f := range files {
+		for _, i := range f.Items {
+			if id, ok := assigned[i.SID]; ok {
+				errs.Add(fmt.Errorf("SID %d is assigned to both %s and %s", i.SID, id, i.Identifier))
+				continue
+			}
+			assigned[i.SID] = i.Identifier
+
+			switch i.Namespace {
+			case SIDNamespaceData:
+				m.data[sidDataKey(i.Identifier)] = i.SID
+				m.dataPaths[i.SID] = i.Identifier
+			case SIDNamespaceIdentity:
+				name := i.Identifier
+				if !strings.Contains(name, ":") {
+					name = fmt.Sprintf("%s:%s", f.ModuleName, name)
+				}
+				m.identities[name] = i.SID
+				m.identityNames[i.SID] = name
+			}
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// sidDataKey returns the key of the schema node path p within a SIDMap, which
+// omits the module names that qualify the nodes of the path, such that paths
+// can be looked up regardless of where they are qualified.
+func sidDataKey(p string) string {
+	elems := strings.Split(p, "/")
+	for i, e := range elems {
+		elems[i] = util.StripModulePrefix(e)
+	}
+	return strings.Join(elems, "/")
+}
+
+// DataSID returns the SID of the data node with the schema node path p, and
+// whether it is found. The nodes of p may be qualified with module names.
+func (m *SIDMap) DataSID(p string) (uint64, bool) {
+	sid, ok := m.data[sidDataKey(p)]
+	return sid, ok
+}
+
+// DataPath returns the schema node path of the data node with the SID sid, as
+// specified by its SID file, and whether it is found.
+func (m *SIDMap) DataPath(sid uint64) (string, bool) {
+	p, ok := m.dataPaths[sid]
+	return p, ok
+}
+
+// IdentitySID returns the SID of the identity with the supplied name, which
+// is qualified with the name of the module that defines it, and whether it is
+// found.
+func (m *SIDMap) IdentitySID(name string) (uint64, bool) {
+	sid, ok := m.identities[name]
+	return sid, ok
+}
+
+// Identity returns the name, qualified with the name of the module that
+// defines it, of the identity with the SID sid, and whether it is found.
+func (m *SIDMap) Identity(sid uint64) (string, bool) {
+	n, ok := m.identityNames[sid]
+	return n, ok
+}
@@ -0,0 +1,144 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+)
+
+func TestParseSIDFile(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               string
+		want             *SIDFile
+		wantErrSubstring string
+	}{{
+		desc: "SIDs as strings and numbers",
+		in: `{
+  "ietf-sid-file:sid-file": {
+    "module-name": "ietf-system",
+    "module-revision": "2014-08-06",
+    "sid-file-version": 0,
+    "assignment-range": [{"entry-point": "1700", "size": "100"}],
+    "item": [
+      {"namespace": "module", "identifier": "ietf-system", "sid": "1700"},
+      {"namespace": "identity", "identifier": "radius", "sid": 1701},
+      {"namespace": "data", "identifier": "/ietf-system:system", "sid": "1702"}
+    ]
+  }
+}`,
+		want: &SIDFile{
+			ModuleName:       "ietf-system",
+			ModuleRevision:   "2014-08-06",
+			AssignmentRanges: []SIDRange{{EntryPoint: 1700, Size: 100}},
+			Items: []SIDItem{
+				{Namespace: SIDNamespaceModule, Identifier: "ietf-system", SID: 1700},
+				{Namespace: SIDNamespaceIdentity, Identifier: "radius", SID: 1701},
+				{Namespace: SIDNamespaceData, Identifier: "/ietf-system:system", SID: 1702},
+			},
+		},
+	}, {
+		desc:             "missing sid-file",
+		in:               `{"module-name": "ietf-system"}`,
+		wantErrSubstring: "missing ietf-sid-file:sid-file",
+	}, {
+		desc:             "invalid sid",
+		in:               `{"ietf-sid-file:sid-file": {"item": [{"namespace": "data", "identifier": "/a", "sid": "-1"}]}}`,
+		wantErrSubstring: "invalid uint64 value",
+	}, {
+		desc:             "invalid JSON",
+		in:               `{`,
+		wantErrSubstring: "cannot parse SID file",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := ParseSIDFile([]byte(tt.in))
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("ParseSIDFile: did not get expected error, %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("ParseSIDFile: did not get expected file, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSIDFileMarshalJSON(t *testing.T) {
+	in := &SIDFile{
+		ModuleName:       "dev",
+		AssignmentRanges: []SIDRange{{EntryPoint: 60000, Size: 50}},
+		Items:            []SIDItem{{Namespace: SIDNamespaceData, Identifier: "/dev:system", SID: 60001}},
+	}
+	got, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal: got unexpected error: %v", err)
+	}
+	want := `{"ietf-sid-file:sid-file":{"module-name":"dev","assignment-range":[{"entry-point":"60000","size":"50"}],"item":[{"namespace":"data","identifier":"/dev:system","sid":"60001"}]}}`
+	if string(got) != want {
+		t.Errorf("json.Marshal: got: %s, want: %s", got, want)
+	}
+
+	f, err := ParseSIDFile(got)
+	if err != nil {
+		t.Fatalf("ParseSIDFile: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(in, f); diff != "" {
+		t.Errorf("ParseSIDFile: did not get marshalled file, diff(-want, +got):\n%s", diff)
+	}
+}
+
+func TestSIDMap(t *testing.T) {
+	dev := &SIDFile{ModuleName: "dev", Items: []SIDItem{
+		{Namespace: SIDNamespaceModule, Identifier: "dev", SID: 100},
+		{Namespace: SIDNamespaceIdentity, Identifier: "ETH", SID: 101},
+		{Namespace: SIDNamespaceData, Identifier: "/dev:system", SID: 102},
+		{Namespace: SIDNamespaceData, Identifier: "/dev:system/ext:clock", SID: 103},
+	}}
+	m, err := NewSIDMap(dev)
+	if err != nil {
+		t.Fatalf("NewSIDMap: got unexpected error: %v", err)
+	}
+
+	for _, p := range []string{"/dev:system/ext:clock", "/system/clock", "/dev:system/clock"} {
+		if sid, ok := m.DataSID(p); !ok || sid != 103 {
+			t.Errorf("DataSID(%s): got: %d, %v, want: 103, true", p, sid, ok)
+		}
+	}
+	if p, ok := m.DataPath(103); !ok || p != "/dev:system/ext:clock" {
+		t.Errorf("DataPath(103): got: %s, %v, want: /dev:system/ext:clock, true", p, ok)
+	}
+	if _, ok := m.DataSID("/dev:interfaces"); ok {
+		t.Errorf("DataSID(/dev:interfaces): got found, want not found")
+	}
+	if sid, ok := m.IdentitySID("dev:ETH"); !ok || sid != 101 {
+		t.Errorf("IdentitySID(dev:ETH): got: %d, %v, want: 101, true", sid, ok)
+	}
+	if n, ok := m.Identity(101); !ok || n != "dev:ETH" {
+		t.Errorf("Identity(101): got: %s, %v, want: dev:ETH, true", n, ok)
+	}
+	if _, ok := m.Identity(102); ok {
+		t.Errorf("Identity(102): got found, want not found")
+	}
+
+	dup := &SIDFile{ModuleName: "ext", Items: []SIDItem{{Namespace: SIDNamespaceData, Identifier: "/ext:clock", SID: 102}}}
+	if _, err := NewSIDMap(dev, dup); err == nil {
+		t.Errorf("NewSIDMap: did not get expected error for duplicate SID")
+	}
+}
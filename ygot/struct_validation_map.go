@@ -29,6 +29,7 @@ import (
 	"reflect"
 	"strings"
 
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
 )
 
@@ -130,6 +131,35 @@ func enumFieldToString(field reflect.Value, appendModuleName bool) (string, bool
 	return n, true, nil
 }
 
+// BitsName returns the string representation of an input GoBits b. The
+// string consists of the names of each bit that is set within b, ordered by
+// their position and separated by spaces, as per the encoding rules in
+// RFC7950 and RFC7951. An empty string is returned when no bits are set.
+func BitsName(b GoBits) (string, error) {
+	v := reflect.ValueOf(b)
+	if v.Kind() != reflect.Uint64 {
+		return "", fmt.Errorf("supplied value was not a valid GoBits: %v", v.Type())
+	}
+
+	lookup, ok := b.ΛBits()[v.Type().Name()]
+	if !ok {
+		return "", fmt.Errorf("cannot map bits value as type %s was unknown", v.Type().Name())
+	}
+
+	var names []string
+	for i, u := uint32(0), v.Uint(); u != 0; i, u = i+1, u>>1 {
+		if u&1 == 0 {
+			continue
+		}
+		n, ok := lookup[i]
+		if !ok {
+			return "", fmt.Errorf("cannot map bits value as type %s has unknown bit at position %d", v.Type().Name(), i)
+		}
+		names = append(names, n)
+	}
+	return strings.Join(names, " "), nil
+}
+
 // BuildEmptyTree initialises the YANG tree starting at the root GoStruct
 // provided. This allows the YANG container hierarchy (i.e., any structs within
 // the tree) to be pre-initialised rather than requiring the user to initialise
@@ -147,10 +177,11 @@ func initialiseTree(t reflect.Type, v reflect.Value) {
 		fVal := v.Field(i)
 		fType := t.Field(i)
 
-		if util.IsTypeStructPtr(fType.Type) {
+		if util.IsTypeStructPtr(fType.Type) && !util.IsTypeOrderedMap(fType.Type) {
 			// Only initialise nested struct pointers, since all struct fields within
 			// a GoStruct are expected to be pointers, and we do not want to initialise
 			// non-struct values. If the struct pointer is not nil, it is skipped.
+			// Ordered maps are lists, and hence are not initialised.
 			if !fVal.IsNil() {
 				continue
 			}
@@ -167,7 +198,8 @@ func initialiseTree(t reflect.Type, v reflect.Value) {
 // initialised with BuildEmptyTree to have those branches that were not populated
 // removed from the tree. All subtrees rooted at the supplied GoStruct are traversed
 // and any encountered GoStruct pointer fields are removed if they equate to
-// the zero value (i.e. are unpopulated).
+// the zero value (i.e. are unpopulated). Presence containers, whose fields are
+// tagged with yangPresence, are not removed since their existence has meaning.
 func PruneEmptyBranches(s GoStruct) {
 	v := reflect.ValueOf(s).Elem()
 	pruneBranchesInternal(v.Type(), v)
@@ -188,6 +220,22 @@ func pruneBranchesInternal(t reflect.Type, v reflect.Value) bool {
 	for i := 0; i < v.NumField(); i++ {
 		fVal := v.Field(i)
 		fType := t.Field(i)
+		if util.IsTypeOrderedMap(fType.Type) {
+			if fVal.IsNil() {
+				continue
+			}
+			if fVal.Interface().(GoOrderedMap).Len() != 0 {
+				allChildrenPruned = false
+			}
+			// Recurse into the members of the ordered map, which cannot
+			// be pruned since the list is populated.
+			for _, mi := range util.OrderedMapValues(fVal) {
+				sv := mi.Elem()
+				_ = pruneBranchesInternal(sv.Type(), sv)
+			}
+			continue
+		}
+
 		if util.IsTypeStructPtr(fType.Type) {
 			// Create an empty version of the struct that is within the struct pointer.
 			// We can safely call Elem() here since we verified above that this type
@@ -199,6 +247,12 @@ func pruneBranchesInternal(t reflect.Type, v reflect.Value) bool {
 				// Ensure that if the field value was actually nil, we skip over this
 				// field since its already nil.
 				continue
+			case util.IsYangPresence(fType):
+				// A presence container is retained even if it is empty, since its
+				// existence has meaning, but its empty children are removed.
+				sv := fVal.Elem()
+				_ = pruneBranchesInternal(sv.Type(), sv)
+				allChildrenPruned = false
 			case reflect.DeepEqual(zVal.Interface(), fVal.Elem().Interface()):
 				// In the case that the zero value's interface is the same as the
 				// dereferenced field value's nil value, then we set it to the zero value
@@ -268,6 +322,36 @@ func pruneBranchesInternal(t reflect.Type, v reflect.Value) bool {
 	return allChildrenPruned
 }
 
+// PruneInactiveWhen removes the data nodes within the GoStruct s, which is
+// described by schema, whose YANG when statements evaluate to false. Since
+// removing a data node may cause the when statements of other data nodes to
+// evaluate to false, the when statements are re-evaluated until no further
+// data nodes are removed. The GoStruct is modified in-place, and an error is
+// returned if a when statement cannot be evaluated.
+func PruneInactiveWhen(schema *yang.Entry, s GoStruct) error {
+	for {
+		n, err := util.NewXPathTree(schema, s)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			return nil
+		}
+		inactive, errs := util.InactiveWhenNodes(n)
+		if errs != nil {
+			return errs
+		}
+		if len(inactive) == 0 {
+			return nil
+		}
+		for _, i := range inactive {
+			if err := i.Remove(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // InitContainer initialises the container cname of the GoStruct s, it can be
 // used to initialise an arbitrary named child container within a YANG
 // structure in a generic manner. This allows the caller to generically
@@ -309,6 +393,56 @@ const (
 	RFC7951
 )
 
+// WithDefaultsMode is an enumerated integer value indicating how leaves that
+// have a default value in the YANG schema are rendered, as per the
+// with-defaults retrieval modes defined in RFC6243.
+type WithDefaultsMode int
+
+const (
+	// WithDefaultsExplicit renders the leaves that are set within the
+	// GoStruct, regardless of whether their value is the default value. It is
+	// the default mode.
+	WithDefaultsExplicit WithDefaultsMode = iota
+	// WithDefaultsReportAll renders the default value of each unset leaf that
+	// has one, in addition to the leaves that are set within the GoStruct.
+	WithDefaultsReportAll
+	// WithDefaultsTrim omits each leaf whose value is equal to its default
+	// value.
+	WithDefaultsTrim
+)
+
+// applyWithDefaults returns a copy of the GoStruct s in which the leaves that
+// have default values are populated or trimmed according to mode. The
+// GoStruct s itself is returned for WithDefaultsExplicit. An error is
+// returned if s does not implement the DefaultsGoStruct interface where it is
+// required by mode.
+func applyWithDefaults(s GoStruct, mode WithDefaultsMode) (GoStruct, error) {
+	if mode == WithDefaultsExplicit {
+		return s, nil
+	}
+	if _, ok := s.(DefaultsGoStruct); !ok {
+		return nil, fmt.Errorf("cannot apply with-defaults mode %d to %T, which does not implement DefaultsGoStruct", mode, s)
+	}
+	c, err := DeepCopy(s)
+	if err != nil {
+		return nil, err
+	}
+
+	d := c.(DefaultsGoStruct)
+	switch mode {
+	case WithDefaultsReportAll:
+		err = d.PopulateDefaults()
+	case WithDefaultsTrim:
+		err = d.TrimDefaults()
+	default:
+		return nil, fmt.Errorf("invalid with-defaults mode %d", mode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
 // EmitJSONConfig specifies the how JSON should be created by the EmitJSON function.
 type EmitJSONConfig struct {
 	// Format specifies the JSON format that should be output by the EmitJSON
@@ -330,6 +464,10 @@ type EmitJSONConfig struct {
 	// validation rules in the case that a partially populated data instance is
 	// to be emitted.
 	ValidationOpts []ValidationOption
+	// WithDefaults specifies how leaves that have a default value in the
+	// schema are rendered. Modes other than WithDefaultsExplicit require the
+	// GoStruct to implement the DefaultsGoStruct interface.
+	WithDefaults WithDefaultsMode
 }
 
 // EmitJSON takes an input ValidatedGoStruct (produced by ygen with validation enabled)
@@ -349,7 +487,16 @@ func EmitJSON(s ValidatedGoStruct, opts *EmitJSONConfig) (string, error) {
 		return "", fmt.Errorf("validation err: %v", err)
 	}
 
-	v, err := makeJSON(s, opts)
+	var wd WithDefaultsMode
+	if opts != nil {
+		wd = opts.WithDefaults
+	}
+	ds, err := applyWithDefaults(s, wd)
+	if err != nil {
+		return "", err
+	}
+
+	v, err := makeJSON(ds, opts)
 	if err != nil {
 		return "", err
 	}
@@ -505,33 +652,32 @@ func copyStruct(dstVal, srcVal reflect.Value) error {
 	}
 
 	for i := 0; i < srcVal.NumField(); i++ {
-		srcField := srcVal.Field(i)
-		dstField := dstVal.Field(i)
-
-		switch srcField.Kind() {
-		case reflect.Ptr:
-			if err := copyPtrField(dstField, srcField); err != nil {
-				return err
-			}
-		case reflect.Interface:
-			if err := copyInterfaceField(dstField, srcField); err != nil {
-				return err
-			}
-		case reflect.Map:
-			if err := copyMapField(dstField, srcField); err != nil {
-				return err
-			}
-		case reflect.Slice:
-			if err := copySliceField(dstField, srcField); err != nil {
-				return err
-			}
-		default:
-			dstField.Set(srcField)
+		if err := copyField(dstVal.Field(i), srcVal.Field(i)); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// copyField copies the struct field srcField into the struct field dstField,
+// merging their contents as described by the copy function for the kind of
+// the field.
+func copyField(dstField, srcField reflect.Value) error {
+	switch srcField.Kind() {
+	case reflect.Ptr:
+		return copyPtrField(dstField, srcField)
+	case reflect.Interface:
+		return copyInterfaceField(dstField, srcField)
+	case reflect.Map:
+		return copyMapField(dstField, srcField)
+	case reflect.Slice:
+		return copySliceField(dstField, srcField)
+	default:
+		dstField.Set(srcField)
+	}
+	return nil
+}
+
 // copyPtrField copies srcField to dstField. srcField and dstField must be
 // reflect.Value structs which represent pointers. If the source and destination
 // are struct pointers, then their contents are merged. If the source and
@@ -549,6 +695,10 @@ func copyPtrField(dstField, srcField reflect.Value) error {
 		return fmt.Errorf("received non-ptr type: %v", srcField.Kind())
 	}
 
+	if util.IsValueOrderedMap(srcField) {
+		return copyOrderedMapField(dstField, srcField)
+	}
+
 	// Check for struct ptr, or ptr to avoid panic.
 	if util.IsValueStructPtr(srcField) {
 		var d reflect.Value
@@ -662,6 +812,44 @@ func copyMapField(dstField, srcField reflect.Value) error {
 	return nil
 }
 
+// copyOrderedMapField copies srcField into dstField. Both srcField and dstField
+// are reflect.Value structs which contain an ordered map. The members of
+// dstField are retained in their existing order, and the contents of members
+// of srcField with the same key are merged into them if they do not overlap,
+// otherwise an error is returned. Members that are only within srcField are
+// appended in the order in which they appear in srcField.
+func copyOrderedMapField(dstField, srcField reflect.Value) error {
+	if st, dt := srcField.Type(), dstField.Type(); st != dt {
+		return fmt.Errorf("invalid ordered maps, src and dst types are different, %v != %v", st, dt)
+	}
+
+	nm := reflect.New(srcField.Type().Elem())
+	for _, field := range []reflect.Value{dstField, srcField} {
+		if util.IsNilOrInvalidValue(field) {
+			continue
+		}
+		keys := util.OrderedMapKeys(field)
+		for i, v := range util.OrderedMapValues(field) {
+			if d := util.OrderedMapGet(nm, keys[i]); !util.IsNilOrInvalidValue(d) {
+				if err := copyStruct(d.Elem(), v.Elem()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			d := reflect.New(v.Type().Elem())
+			if err := copyStruct(d.Elem(), v.Elem()); err != nil {
+				return err
+			}
+			if err := util.InsertIntoOrderedMap(nm.Interface(), d.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	dstField.Set(nm)
+	return nil
+}
+
 // mapTypes provides a specification of a map.
 type mapType struct {
 	key   reflect.Type // key is the type of the key of the map.
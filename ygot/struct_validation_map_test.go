@@ -20,6 +20,7 @@ import (
 	"io/ioutil"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/golang/protobuf/proto"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/openconfig/gnmi/errdiff"
 	gnmipb "github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/testutil"
 )
 
@@ -297,6 +299,76 @@ func TestEnumName(t *testing.T) {
 	}
 }
 
+type bitsTest uint64
+
+func (bitsTest) IsYANGGoBits() {}
+
+func (bitsTest) ΛBits() map[string]map[uint32]string {
+	return map[string]map[uint32]string{
+		"bitsTest": {0: "BIT_ZERO", 1: "BIT_ONE", 5: "BIT_FIVE"},
+	}
+}
+
+type badBitsTest uint64
+
+func (badBitsTest) IsYANGGoBits() {}
+
+func (badBitsTest) ΛBits() map[string]map[uint32]string {
+	return nil
+}
+
+type badKindBitsTest string
+
+func (badKindBitsTest) IsYANGGoBits() {}
+
+func (badKindBitsTest) ΛBits() map[string]map[uint32]string {
+	return nil
+}
+
+func TestBitsName(t *testing.T) {
+	tests := []struct {
+		name             string
+		in               GoBits
+		want             string
+		wantErrSubstring string
+	}{{
+		name: "single bit",
+		in:   bitsTest(1 << 1),
+		want: "BIT_ONE",
+	}, {
+		name: "multiple bits in position order",
+		in:   bitsTest(1<<5 | 1<<0),
+		want: "BIT_ZERO BIT_FIVE",
+	}, {
+		name: "no bits set",
+		in:   bitsTest(0),
+		want: "",
+	}, {
+		name:             "unknown bit position",
+		in:               bitsTest(1 << 2),
+		wantErrSubstring: "cannot map bits value as type bitsTest has unknown bit at position 2",
+	}, {
+		name:             "unknown type",
+		in:               badBitsTest(1),
+		wantErrSubstring: "cannot map bits value as type badBitsTest was unknown",
+	}, {
+		name:             "not a uint64",
+		in:               badKindBitsTest("BIT_ONE"),
+		wantErrSubstring: "supplied value was not a valid GoBits",
+	}}
+
+	for _, tt := range tests {
+		got, err := BitsName(tt.in)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: BitsName(%v): did not get expected error, %s", tt.name, tt.in, diff)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: BitsName(%v): did not get expected value, got: %s, want: %s", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
 // mapStructTestOne is the base struct used for the simple-schema test.
 type mapStructTestOne struct {
 	Child *mapStructTestOneChild `path:"child" module:"test-one"`
@@ -669,6 +741,15 @@ func TestPruneEmptyBranches(t *testing.T) {
 		name:     "struct with no children",
 		inStruct: &emptyBranchTestOne{},
 		want:     &emptyBranchTestOne{},
+	}, {
+		name: "struct with empty presence container",
+		inStruct: &presenceTestRoot{
+			Logging: &presenceTestLogging{},
+			Clock:   &presenceTestLogging{},
+		},
+		want: &presenceTestRoot{
+			Logging: &presenceTestLogging{},
+		},
 	}, {
 		name: "struct with empty child",
 		inStruct: &emptyBranchTestOne{
@@ -777,6 +858,135 @@ func TestPruneEmptyBranches(t *testing.T) {
 	}
 }
 
+// pruneWhenRoot, pruneWhenA and pruneWhenB are synthesised GoStructs used to
+// test PruneInactiveWhen.
+type pruneWhenRoot struct {
+	A *pruneWhenA `path:"a"`
+	B *pruneWhenB `path:"b"`
+}
+
+func (*pruneWhenRoot) IsYANGGoStruct() {}
+
+type pruneWhenA struct {
+	X *string  `path:"x"`
+	Y *string  `path:"y"`
+	Z []string `path:"z"`
+}
+
+func (*pruneWhenA) IsYANGGoStruct() {}
+
+type pruneWhenB struct {
+	Value *string `path:"value"`
+}
+
+func (*pruneWhenB) IsYANGGoStruct() {}
+
+// pruneWhenSchema returns the schema that describes pruneWhenRoot, with
+// the when statements in whens applied to the entries at the corresponding
+// schema paths.
+func pruneWhenSchema(whens map[string]string) *yang.Entry {
+	leaf := func(name string) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}}
+	}
+	z := leaf("z")
+	z.ListAttr = &yang.ListAttr{}
+	root := &yang.Entry{
+		Name: "root",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"a": {
+				Name: "a",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"x": leaf("x"),
+					"y": leaf("y"),
+					"z": z,
+				},
+			},
+			"b": {
+				Name: "b",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"value": leaf("value"),
+				},
+			},
+		},
+	}
+	for _, c := range root.Dir {
+		c.Parent = root
+		for _, l := range c.Dir {
+			l.Parent = c
+		}
+	}
+	for p, w := range whens {
+		e := root
+		for _, n := range strings.Split(p, "/")[1:] {
+			e = e.Dir[n]
+		}
+		e.Extra = map[string][]interface{}{"when": {&yang.Value{Name: w}}}
+	}
+	return root
+}
+
+func TestPruneInactiveWhen(t *testing.T) {
+	tests := []struct {
+		name             string
+		inWhens          map[string]string
+		inStruct         *pruneWhenRoot
+		want             *pruneWhenRoot
+		wantErrSubstring string
+	}{{
+		name:     "no when statements",
+		inStruct: &pruneWhenRoot{A: &pruneWhenA{X: String("x")}},
+		want:     &pruneWhenRoot{A: &pruneWhenA{X: String("x")}},
+	}, {
+		name:     "when statement true",
+		inWhens:  map[string]string{"/a/x": "../y = 'on'"},
+		inStruct: &pruneWhenRoot{A: &pruneWhenA{X: String("x"), Y: String("on")}},
+		want:     &pruneWhenRoot{A: &pruneWhenA{X: String("x"), Y: String("on")}},
+	}, {
+		name:     "leaf removed",
+		inWhens:  map[string]string{"/a/x": "../y = 'on'"},
+		inStruct: &pruneWhenRoot{A: &pruneWhenA{X: String("x"), Y: String("off")}},
+		want:     &pruneWhenRoot{A: &pruneWhenA{Y: String("off")}},
+	}, {
+		name:     "leaf-list elements removed",
+		inWhens:  map[string]string{"/a/z": ". != 'two'"},
+		inStruct: &pruneWhenRoot{A: &pruneWhenA{Z: []string{"one", "two", "three"}}},
+		want:     &pruneWhenRoot{A: &pruneWhenA{Z: []string{"one", "three"}}},
+	}, {
+		name: "container removed after dependent leaf is removed",
+		inWhens: map[string]string{
+			"/a/x": "../y = 'on'",
+			"/b":   "/a/x",
+		},
+		inStruct: &pruneWhenRoot{
+			A: &pruneWhenA{X: String("x"), Y: String("off")},
+			B: &pruneWhenB{Value: String("value")},
+		},
+		want: &pruneWhenRoot{A: &pruneWhenA{Y: String("off")}},
+	}, {
+		name:             "invalid when statement",
+		inWhens:          map[string]string{"/b": "count("},
+		inStruct:         &pruneWhenRoot{B: &pruneWhenB{Value: String("value")}},
+		wantErrSubstring: "invalid when statement",
+	}}
+
+	for _, tt := range tests {
+		err := PruneInactiveWhen(pruneWhenSchema(tt.inWhens), tt.inStruct)
+		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+			t.Errorf("%s: PruneInactiveWhen(%#v): did not get expected error, %s", tt.name, tt.inStruct, diff)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tt.inStruct, tt.want); diff != "" {
+			t.Errorf("%s: PruneInactiveWhen(%#v): did not get expected output, diff(-got,+want):\n%s", tt.name, tt.inStruct, diff)
+		}
+	}
+}
+
 // initContainerTest is a synthesised GoStruct for use in
 // testing InitContainer.
 type initContainerTest struct {
@@ -2077,3 +2287,175 @@ func TestUniqueSlices(t *testing.T) {
 		})
 	}
 }
+
+func TestCopyOrderedMap(t *testing.T) {
+	in := &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one")}
+	got, err := DeepCopy(in)
+	if err != nil {
+		t.Fatalf("DeepCopy: got unexpected error: %v", err)
+	}
+	gotRoot := got.(*orderedTestRoot)
+	if diff := cmp.Diff([]string{"b", "a"}, gotRoot.List.Keys()); diff != "" {
+		t.Errorf("DeepCopy: did not get expected order, diff(-want,+got):\n%s", diff)
+	}
+	if gotRoot.List == in.List || gotRoot.List.Get("a") == in.List.Get("a") {
+		t.Errorf("DeepCopy: copied ordered map shares members with the input")
+	}
+	if diff := pretty.Compare(in, got); diff != "" {
+		t.Errorf("DeepCopy: did not get identical copy, diff(-want,+got):\n%s", diff)
+	}
+
+	tests := []struct {
+		desc          string
+		inA           *orderedTestRoot
+		inB           *orderedTestRoot
+		want          *orderedTestRoot
+		wantErrSubstr string
+	}{{
+		desc: "disjoint members",
+		inA:  &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one")},
+		inB:  &orderedTestRoot{List: newOrderedTestList("d", "four", "c", "three")},
+		want: &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one", "d", "four", "c", "three")},
+	}, {
+		desc: "overlapping members retain order of first struct",
+		inA:  &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one")},
+		inB:  &orderedTestRoot{List: newOrderedTestList("c", "three", "a", "one", "b", "two")},
+		want: &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one", "c", "three")},
+	}, {
+		desc: "list only in second struct",
+		inA:  &orderedTestRoot{},
+		inB:  &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one")},
+		want: &orderedTestRoot{List: newOrderedTestList("b", "two", "a", "one")},
+	}, {
+		desc:          "conflicting member contents",
+		inA:           &orderedTestRoot{List: newOrderedTestList("a", "one")},
+		inB:           &orderedTestRoot{List: newOrderedTestList("a", "1")},
+		wantErrSubstr: "destination value was set, but was not equal to source value",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := MergeStructs(tt.inA, tt.inB)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("MergeStructs: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := pretty.Compare(tt.want, got); diff != "" {
+				t.Errorf("MergeStructs: did not get expected struct, diff(-want,+got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// defaultsTestStruct is a GoStruct that implements the DefaultsGoStruct
+// interface, whose Mtu leaf has a default value of 1500.
+type defaultsTestStruct struct {
+	Name *string `path:"name"`
+	Mtu  *uint16 `path:"mtu"`
+}
+
+func (*defaultsTestStruct) Validate(...ValidationOption) error      { return nil }
+func (*defaultsTestStruct) IsYANGGoStruct()                         {}
+func (*defaultsTestStruct) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+func (d *defaultsTestStruct) PopulateDefaults() error {
+	if d.Mtu == nil {
+		d.Mtu = Uint16(1500)
+	}
+	return nil
+}
+
+func (d *defaultsTestStruct) TrimDefaults() error {
+	if d.Mtu != nil && *d.Mtu == 1500 {
+		d.Mtu = nil
+	}
+	return nil
+}
+
+func TestWithDefaults(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inStruct         ValidatedGoStruct
+		inMode           WithDefaultsMode
+		wantJSON         map[string]interface{}
+		wantUpdates      []*gnmipb.Update
+		wantErrSubstring string
+	}{{
+		desc:     "explicit",
+		inStruct: &defaultsTestStruct{Name: String("eth0"), Mtu: Uint16(1500)},
+		wantJSON: map[string]interface{}{"name": "eth0", "mtu": float64(1500)},
+		wantUpdates: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "name"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "mtu"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{1500}},
+		}},
+	}, {
+		desc:     "report-all",
+		inStruct: &defaultsTestStruct{Name: String("eth0")},
+		inMode:   WithDefaultsReportAll,
+		wantJSON: map[string]interface{}{"name": "eth0", "mtu": float64(1500)},
+		wantUpdates: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "name"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}, {
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "mtu"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_UintVal{1500}},
+		}},
+	}, {
+		desc:     "trim",
+		inStruct: &defaultsTestStruct{Name: String("eth0"), Mtu: Uint16(1500)},
+		inMode:   WithDefaultsTrim,
+		wantJSON: map[string]interface{}{"name": "eth0"},
+		wantUpdates: []*gnmipb.Update{{
+			Path: &gnmipb.Path{Elem: []*gnmipb.PathElem{{Name: "name"}}},
+			Val:  &gnmipb.TypedValue{Value: &gnmipb.TypedValue_StringVal{"eth0"}},
+		}},
+	}, {
+		desc:             "struct without defaults methods",
+		inStruct:         &validatedMergeTest{String: String("eth0")},
+		inMode:           WithDefaultsTrim,
+		wantErrSubstring: "does not implement DefaultsGoStruct",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, err := DeepCopy(tt.inStruct)
+			if err != nil {
+				t.Fatalf("DeepCopy: cannot copy input struct: %v", err)
+			}
+
+			gotJSON, err := EmitJSON(tt.inStruct, &EmitJSONConfig{Format: RFC7951, WithDefaults: tt.inMode})
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("EmitJSON: did not get expected error, %s", diff)
+			}
+			if err == nil {
+				got := map[string]interface{}{}
+				if err := json.Unmarshal([]byte(gotJSON), &got); err != nil {
+					t.Fatalf("EmitJSON: cannot unmarshal output JSON: %v", err)
+				}
+				if diff := cmp.Diff(tt.wantJSON, got); diff != "" {
+					t.Errorf("EmitJSON: did not get expected JSON, diff(-want, +got):\n%s", diff)
+				}
+			}
+
+			gotNotifs, err := TogNMINotifications(tt.inStruct, 42, GNMINotificationsConfig{UsePathElem: true, WithDefaults: tt.inMode})
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("TogNMINotifications: did not get expected error, %s", diff)
+			}
+			if err == nil {
+				want := []*gnmipb.Notification{{Timestamp: 42, Update: tt.wantUpdates}}
+				if diff := cmp.Diff(want, gotNotifs, cmp.Comparer(proto.Equal)); diff != "" {
+					t.Errorf("TogNMINotifications: did not get expected notifications, diff(-want, +got):\n%s", diff)
+				}
+			}
+
+			if diff := cmp.Diff(orig, tt.inStruct); diff != "" {
+				t.Errorf("input struct was modified, diff(-original, +got):\n%s", diff)
+			}
+		})
+	}
+}
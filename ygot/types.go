@@ -44,6 +44,22 @@ type ValidatedGoStruct interface {
 	ΛEnumTypeMap() map[string][]reflect.Type
 }
 
+// DefaultsGoStruct is an interface which can be implemented by Go structs
+// that are generated to represent a YANG container or list member that have
+// the corresponding functions to populate and trim the default values of
+// their leaves according to the YANG schema.
+type DefaultsGoStruct interface {
+	// GoStruct ensures that the interface for a standard GoStruct
+	// is embedded.
+	GoStruct
+	// PopulateDefaults sets each unset leaf within the implementing struct
+	// that has a default value in the YANG schema to its default value.
+	PopulateDefaults() error
+	// TrimDefaults unsets each leaf within the implementing struct whose
+	// value is equal to its default value in the YANG schema.
+	TrimDefaults() error
+}
+
 // ValidationOption is an interface that is implemented for each struct
 // which presents configuration parameters for validation options through the
 // Validate public API.
@@ -63,6 +79,20 @@ type KeyHelperGoStruct interface {
 	ΛListKeyMap() (map[string]interface{}, error)
 }
 
+// GoOrderedMap is an interface which is implemented by the types that are
+// generated to represent keyed YANG lists that are "ordered-by user", when the
+// generation of ordered maps is enabled. An ordered map retains the order of
+// the members of the list, in addition to allowing them to be accessed by key.
+// The typed Keys, Values, Get and Append methods of each generated ordered map
+// are used by handling code to iterate and construct the list.
+type GoOrderedMap interface {
+	// IsYANGOrderedList is a marker method that indicates that the type
+	// implements the GoOrderedMap interface.
+	IsYANGOrderedList()
+	// Len returns the number of members of the list.
+	Len() int
+}
+
 // GoEnum is an interface which can be implemented by derived types which
 // represent an enumerated value within a YANG schema. This allows handling
 // code that finds struct fields that implement this interface to do specific
@@ -78,6 +108,22 @@ type GoEnum interface {
 	ΛMap() map[string]map[int64]EnumDefinition
 }
 
+// GoBits is an interface which can be implemented by derived types which
+// represent a YANG bits type. Each bit that is set within the value of the
+// derived type corresponds to the bit with the same position within the YANG
+// schema.
+type GoBits interface {
+	// IsYANGGoBits is a marker method that indicates that the type
+	// implements the GoBits interface.
+	IsYANGGoBits()
+	// ΛBits is a method associated with each bits type that retrieves a
+	// map of the bits types to their bit positions and names. The ygen
+	// library generates a static map, keyed by the name of the Go type,
+	// whose values are maps from each bit's position to the name of the
+	// bit within the YANG schema.
+	ΛBits() map[string]map[uint32]string
+}
+
 // EnumDefinition is used to store the details of an enumerated value. All YANG
 // enumerated values (enumeration, identityref) has a Name which represents the
 // string name used for the enumerated value in the YANG module (which may not
@@ -109,7 +155,9 @@ type EnumDefinition struct {
 // Each Annotation must implement the MarshalJSON and UnmarshalJSON methods,
 // such that its content can be serialised and deserialised from JSON. Using
 // the approach described in RFC7952 can be used to store metadata within
-// RFC7951-serialised JSON.
+// RFC7951-serialised JSON. When unmarshalling such JSON, the ytypes package
+// determines the type of each annotation from an AnnotationRegistry, which
+// maps the RFC7952 names of the annotations to their implementations.
 type Annotation interface {
 	// MarshalJSON is used to marshal the annotation to JSON. It ensures that
 	// the json.Marshaler interface is implemented.
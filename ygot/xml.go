@@ -0,0 +1,513 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/gnmi/errlist"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7950#section-7 for the XML
+// encoding of each YANG data node.
+
+// EmitXMLConfig is used to control the behaviour of EmitXML.
+type EmitXMLConfig struct {
+	// Indent is the string used for indentation within the XML output. The
+	// default value is three spaces.
+	Indent string
+	// SkipValidation specifies whether the GoStruct supplied to EmitXML should
+	// be validated before emitting its content. Validation is skipped when it
+	// is set to true.
+	SkipValidation bool
+	// ValidationOpts is the set of options that should be used to determine how
+	// the schema should be validated.
+	ValidationOpts []ValidationOption
+	// WithDefaults specifies how leaves that have a default value in the
+	// schema are rendered. Modes other than WithDefaultsExplicit require the
+	// GoStruct to implement the DefaultsGoStruct interface.
+	WithDefaults WithDefaultsMode
+}
+
+// EmitXML takes an input ValidatedGoStruct (produced by ygen with validation
+// enabled), along with the schema that describes it, and serialises its
+// contents to XML as per RFC7950. The output consists of an element for each
+// data node that is set within s, such that when s is the root of the schema
+// tree, the output can be used as the content of a NETCONF <config> element.
+// The XML namespace of each element, and of the module that defines each
+// identity that is used as an identityref value, is determined from the
+// module namespaces and prefixes that are stored within the schema by ygen.
+func EmitXML(schema *yang.Entry, s ValidatedGoStruct, opts *EmitXMLConfig) (string, error) {
+	var (
+		vopts          []ValidationOption
+		skipValidation bool
+		wd             WithDefaultsMode
+	)
+
+	if opts != nil {
+		vopts = opts.ValidationOpts
+		skipValidation = opts.SkipValidation
+		wd = opts.WithDefaults
+	}
+
+	if schema == nil {
+		return "", fmt.Errorf("cannot emit XML for %T with nil schema", s)
+	}
+
+	if err := s.Validate(vopts...); !skipValidation && err != nil {
+		return "", fmt.Errorf("validation err: %v", err)
+	}
+
+	ds, err := applyWithDefaults(s, wd)
+	if err != nil {
+		return "", err
+	}
+
+	mods := &xmlModules{
+		namespaces: util.ModuleNamespaces(schema),
+		prefixes:   util.ModulePrefixes(schema),
+	}
+	root := &xmlNode{}
+	if err := structXML(root, schema, reflect.ValueOf(ds), "", mods); err != nil {
+		return "", err
+	}
+
+	indent := indentString
+	if opts != nil && opts.Indent != "" {
+		indent = opts.Indent
+	}
+
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", indent)
+	for _, c := range root.children {
+		if err := c.encode(enc, ""); err != nil {
+			return "", fmt.Errorf("XML marshalling error: %v", err)
+		}
+	}
+	if err := enc.Flush(); err != nil {
+		return "", fmt.Errorf("XML marshalling error: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// xmlModules stores the details of the modules that a schema tree was
+// generated from, as stored within the schema by ygen.
+type xmlModules struct {
+	// namespaces is a map, keyed by module name, of the XML namespace of
+	// each module.
+	namespaces map[string]string
+	// prefixes is a map, keyed by module prefix, of the name of each module.
+	prefixes map[string]string
+}
+
+// module returns the name of the module that defines the schema entry e. The
+// module is determined from the prefix of e, and parentMod is returned if it
+// cannot be determined.
+func (m *xmlModules) module(e *yang.Entry, parentMod string) string {
+	if e.Prefix != nil {
+		if mod, ok := m.prefixes[e.Prefix.Name]; ok {
+			return mod
+		}
+	}
+	return parentMod
+}
+
+// xmlNode is an element of the XML document that is output by EmitXML.
+type xmlNode struct {
+	// name is the local name of the element.
+	name string
+	// ns is the XML namespace of the element. It is declared only where
+	// it differs from the namespace of the parent element.
+	ns string
+	// prefixes is a map, keyed by prefix, of the namespaces that are
+	// declared on the element, such that the identities that are referred
+	// to by identityref values can be qualified.
+	prefixes map[string]string
+	// text is the character data of the element.
+	text string
+	// isContainer specifies whether the element corresponds to a YANG
+	// container.
+	isContainer bool
+	// children is the set of child elements, in the order that they are
+	// output.
+	children []*xmlNode
+	// containers is a map, keyed by name, of the child elements that
+	// correspond to YANG containers, such that the fields of a compressed
+	// GoStruct that share a parent container are output within the same
+	// element.
+	containers map[string]*xmlNode
+}
+
+// container returns the child element of n that corresponds to the container
+// with the supplied name, creating it if it does not exist.
+func (n *xmlNode) container(name, ns string) *xmlNode {
+	if c, ok := n.containers[name]; ok {
+		return c
+	}
+	c := &xmlNode{name: name, ns: ns, isContainer: true}
+	n.addContainer(c)
+	return c
+}
+
+// addContainer appends the container element c to the children of n. If n
+// already has a child container of the same name, the children of c are
+// appended to it instead.
+func (n *xmlNode) addContainer(c *xmlNode) {
+	if e, ok := n.containers[c.name]; ok {
+		for _, ch := range c.children {
+			if ch.isContainer {
+				e.addContainer(ch)
+				continue
+			}
+			e.children = append(e.children, ch)
+		}
+		return
+	}
+	if n.containers == nil {
+		n.containers = map[string]*xmlNode{}
+	}
+	n.containers[c.name] = c
+	n.children = append(n.children, c)
+}
+
+// encode writes the element n, and its children, to the XML encoder enc. The
+// namespace of the parent of n is specified by parentNS.
+func (n *xmlNode) encode(enc *xml.Encoder, parentNS string) error {
+	start := xml.StartElement{Name: xml.Name{Local: n.name}}
+	ns := parentNS
+	if n.ns != "" && n.ns != parentNS {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: n.ns})
+		ns = n.ns
+	}
+
+	var pfxs []string
+	for p := range n.prefixes {
+		pfxs = append(pfxs, p)
+	}
+	sort.Strings(pfxs)
+	for _, p := range pfxs {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + p}, Value: n.prefixes[p]})
+	}
+
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.text != "" {
+		if err := enc.EncodeToken(xml.CharData(n.text)); err != nil {
+			return err
+		}
+	}
+	for _, c := range n.children {
+		if err := c.encode(enc, ns); err != nil {
+			return err
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// structXML appends the elements that correspond to the fields of the struct
+// pointer s, which is described by schema, to parent. The module that s is
+// within is specified by mod. The module of each field is that specified by
+// its module tag, or otherwise that which defines its schema entry.
+func structXML(parent *xmlNode, schema *yang.Entry, s reflect.Value, mod string, mods *xmlModules) error {
+	if !util.IsValueStructPtr(s) {
+		return fmt.Errorf("cannot emit XML for %v, not a struct pointer", s.Type())
+	}
+
+	var errs errlist.List
+	sv := s.Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		f, ft := sv.Field(i), sv.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		tagMod, hasTagMod := ft.Tag.Lookup("module")
+
+		paths, err := util.SchemaPaths(ft)
+		if err != nil {
+			errs.Add(err)
+			continue
+		}
+
+		for _, p := range paths {
+			if schema.IsContainer() && len(p) > 1 && util.StripModulePrefix(p[0]) == schema.Name && util.FirstChild(schema, p[:1]) == nil {
+				p = p[1:]
+			}
+			if len(p) == 0 {
+				errs.Add(fmt.Errorf("invalid schema path for field %s", ft.Name))
+				continue
+			}
+
+			// Find the schema of each element of the path, such that the
+			// intermediate containers of compressed structs are output.
+			cs := schema
+			var inter []*yang.Entry
+			for _, e := range p {
+				if cs = util.FirstChild(cs, []string{e}); cs == nil {
+					break
+				}
+				inter = append(inter, cs)
+			}
+			if cs == nil {
+				errs.Add(fmt.Errorf("cannot find schema for field %s with path %v in %s", ft.Name, p, schema.Name))
+				continue
+			}
+			inter = inter[:len(inter)-1]
+
+			chMod := mods.module(cs, mod)
+			if hasTagMod && tagMod != "" {
+				chMod = tagMod
+			}
+			elems, err := fieldXML(f, ft, cs, chMod, mods)
+			if err != nil {
+				errs.Add(err)
+				continue
+			}
+			if len(elems) == 0 {
+				continue
+			}
+
+			n, nMod := parent, mod
+			for _, e := range inter {
+				nMod = mods.module(e, nMod)
+				n = n.container(e.Name, mods.namespaces[nMod])
+			}
+			for _, e := range elems {
+				if e.isContainer {
+					n.addContainer(e)
+					continue
+				}
+				n.children = append(n.children, e)
+			}
+		}
+	}
+	return errs.Err()
+}
+
+// fieldXML returns the elements that correspond to the value of the struct
+// field f, whose type is ft, and which is described by schema. The module
+// that instantiates the field is specified by mod. No elements are returned
+// if the field is unset.
+func fieldXML(f reflect.Value, ft reflect.StructField, schema *yang.Entry, mod string, mods *xmlModules) ([]*xmlNode, error) {
+	if util.IsNilOrInvalidValue(f) {
+		return nil, nil
+	}
+	ns := mods.namespaces[mod]
+
+	switch {
+	case schema.IsList():
+		return listXML(f, schema, mod, mods)
+	case schema.IsContainer():
+		n := &xmlNode{name: schema.Name, ns: ns, isContainer: true}
+		if err := structXML(n, schema, f, mod, mods); err != nil {
+			return nil, err
+		}
+		// Empty containers are not output, other than presence containers
+		// whose existence is meaningful.
+		if len(n.children) == 0 && !util.IsYangPresence(ft) && !util.IsPresenceContainer(schema) {
+			return nil, nil
+		}
+		return []*xmlNode{n}, nil
+	case schema.IsLeafList():
+		if f.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("invalid type %v for leaf-list %s", f.Type(), schema.Name)
+		}
+		var elems []*xmlNode
+		for i := 0; i < f.Len(); i++ {
+			n, err := leafXML(f.Index(i), schema.Name, ns, mods)
+			if err != nil {
+				return nil, err
+			}
+			if n != nil {
+				elems = append(elems, n)
+			}
+		}
+		return elems, nil
+	}
+
+	n, err := leafXML(f, schema.Name, ns, mods)
+	if err != nil || n == nil {
+		return nil, err
+	}
+	return []*xmlNode{n}, nil
+}
+
+// listXML returns an element for each member of the list f, which is
+// described by schema. Keyed list members are output in the order of their
+// keys, unless the list is ordered, with the key leaves of each member output
+// first as per RFC7950 Section 7.8.5.
+func listXML(f reflect.Value, schema *yang.Entry, mod string, mods *xmlModules) ([]*xmlNode, error) {
+	var members []reflect.Value
+	switch {
+	case util.IsValueSlice(f):
+		for i := 0; i < f.Len(); i++ {
+			members = append(members, f.Index(i))
+		}
+	case util.IsValueMap(f), util.IsValueOrderedMap(f):
+		keys, vals := util.KeyedListMembers(f)
+		idx := make([]int, len(keys))
+		for i := range idx {
+			idx[i] = i
+		}
+		if !util.IsValueOrderedMap(f) {
+			sort.SliceStable(idx, func(i, j int) bool {
+				return fmt.Sprintf("%v", keys[idx[i]].Interface()) < fmt.Sprintf("%v", keys[idx[j]].Interface())
+			})
+		}
+		for _, i := range idx {
+			members = append(members, vals[i])
+		}
+	default:
+		return nil, fmt.Errorf("invalid type %v for list %s", f.Type(), schema.Name)
+	}
+
+	keys := strings.Fields(schema.Key)
+	var elems []*xmlNode
+	for _, m := range members {
+		if util.IsNilOrInvalidValue(m) {
+			continue
+		}
+		n := &xmlNode{name: schema.Name, ns: mods.namespaces[mod]}
+		if err := structXML(n, schema, m, mod, mods); err != nil {
+			return nil, err
+		}
+
+		// Move the key leaves to the start of the member, in the order in
+		// which they are specified by the key statement.
+		var keyElems, others []*xmlNode
+		for _, k := range keys {
+			for _, c := range n.children {
+				if c.name == k && !c.isContainer {
+					keyElems = append(keyElems, c)
+					break
+				}
+			}
+		}
+		for _, c := range n.children {
+			isKey := false
+			for _, k := range keyElems {
+				if c == k {
+					isKey = true
+				}
+			}
+			if !isKey {
+				others = append(others, c)
+			}
+		}
+		n.children = append(keyElems, others...)
+		elems = append(elems, n)
+	}
+	return elems, nil
+}
+
+// leafXML returns the element with the supplied name that corresponds to the
+// leaf or leaf-list value v. It returns nil if the value is unset. Where the
+// value is an identity, the module that defines it is declared as a prefix
+// of the element.
+func leafXML(v reflect.Value, name, ns string, mods *xmlModules) (*xmlNode, error) {
+	text, idMod, set, err := xmlLeafText(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot emit XML for leaf %s: %v", name, err)
+	}
+	if !set {
+		return nil, nil
+	}
+	n := &xmlNode{name: name, ns: ns, text: text}
+	if idMod != "" {
+		if idNS, ok := mods.namespaces[idMod]; ok {
+			n.prefixes = map[string]string{idMod: idNS}
+		}
+	}
+	return n, nil
+}
+
+// xmlLeafText returns the text of the XML element that corresponds to the
+// leaf value v, and whether the value is set. If the value is an identity,
+// the name of the module that defines it is returned, and the text is
+// qualified with the module name as a prefix.
+func xmlLeafText(v reflect.Value) (string, string, bool, error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", "", false, nil
+		}
+		if util.IsValueInterfaceToStructPtr(v) || util.IsValueStructPtr(v) {
+			// Union values are stored as a pointer to a struct with a single
+			// field, which contains the value.
+			s := v.Elem()
+			if s.Kind() == reflect.Ptr {
+				s = s.Elem()
+			}
+			if !util.IsStructValueWithNFields(s, 1) {
+				return "", "", false, fmt.Errorf("invalid union type %v", s.Type())
+			}
+			return xmlLeafText(s.Field(0))
+		}
+		return xmlLeafText(v.Elem())
+	case reflect.Bool:
+		if v.Type().Name() == EmptyTypeName {
+			// An empty leaf is represented by an element with no content.
+			return "", "", v.Bool(), nil
+		}
+		return strconv.FormatBool(v.Bool()), "", true, nil
+	case reflect.Slice:
+		if v.Type().Name() != BinaryTypeName {
+			return "", "", false, fmt.Errorf("unknown slice type %v", v.Type())
+		}
+		return binaryBase64(v.Bytes()), "", true, nil
+	}
+
+	switch i := v.Interface().(type) {
+	case GoEnum:
+		name, set, err := enumFieldToString(v, false)
+		if err != nil || !set {
+			return "", "", false, err
+		}
+		qname, _, err := enumFieldToString(v, true)
+		if err != nil {
+			return "", "", false, err
+		}
+		// Only identities are qualified with the module that defines them.
+		if qname != name {
+			return qname, strings.TrimSuffix(qname, ":"+name), true, nil
+		}
+		return name, "", true, nil
+	case GoBits:
+		name, err := BitsName(i)
+		return name, "", err == nil, err
+	case Decimal64:
+		return string(i), "", true, nil
+	case float64:
+		return strconv.FormatFloat(i, 'f', -1, 64), "", true, nil
+	case string:
+		return i, "", true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", v.Interface()), "", true, nil
+	}
+	return "", "", false, fmt.Errorf("unknown value type %v", v.Type())
+}
@@ -0,0 +1,239 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ygot
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/encodingtest"
+)
+
+// xmlTestDevice is a compressed GoStruct used to test EmitXML.
+type xmlTestDevice struct {
+	Hostname  *string                      `path:"system/config/hostname"`
+	DNS       []string                     `path:"system/config/dns"`
+	ExtLeaf   *string                      `path:"system/config/ext-leaf"`
+	Flag      YANGEmpty                    `path:"system/config/flag"`
+	KeyData   Binary                       `path:"system/config/key-data"`
+	Ident     EnumTest                     `path:"system/config/ident"`
+	Logging   *xmlTestLogging              `path:"system/logging" yangPresence:"true"`
+	Interface map[string]*xmlTestInterface `path:"interfaces/interface"`
+	Server    []*xmlTestServer             `path:"servers/server"`
+}
+
+func (*xmlTestDevice) IsYANGGoStruct()                         {}
+func (*xmlTestDevice) Validate(...ValidationOption) error      { return nil }
+func (*xmlTestDevice) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type xmlTestLogging struct {
+	Level *string `path:"level"`
+}
+
+func (*xmlTestLogging) IsYANGGoStruct() {}
+
+type xmlTestInterface struct {
+	Mtu  *uint16 `path:"config/mtu"`
+	Name *string `path:"config/name|name"`
+}
+
+func (*xmlTestInterface) IsYANGGoStruct() {}
+
+type xmlTestServer struct {
+	Address *string `path:"address"`
+	Port    *uint16 `path:"port"`
+}
+
+func (*xmlTestServer) IsYANGGoStruct() {}
+
+// xmlTestUncompressedDevice is an uncompressed GoStruct used to test
+// EmitXML.
+type xmlTestUncompressedDevice struct {
+	System *xmlTestSystem `path:"system"`
+}
+
+func (*xmlTestUncompressedDevice) IsYANGGoStruct()                         {}
+func (*xmlTestUncompressedDevice) Validate(...ValidationOption) error      { return nil }
+func (*xmlTestUncompressedDevice) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type xmlTestSystem struct {
+	Config *xmlTestSystemConfig `path:"config"`
+}
+
+func (*xmlTestSystem) IsYANGGoStruct() {}
+
+type xmlTestSystemConfig struct {
+	Hostname *string `path:"hostname"`
+	ExtLeaf  *string `path:"ext-leaf" module:"foo"`
+}
+
+func (*xmlTestSystemConfig) IsYANGGoStruct() {}
+
+// xmlTestInvalid is a GoStruct whose field does not correspond to the schema.
+type xmlTestInvalid struct {
+	Hostname *string `path:"system/config/fqdn"`
+}
+
+func (*xmlTestInvalid) IsYANGGoStruct()                         {}
+func (*xmlTestInvalid) Validate(...ValidationOption) error      { return fmt.Errorf("invalid") }
+func (*xmlTestInvalid) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+func TestEmitXML(t *testing.T) {
+	tests := []struct {
+		name             string
+		inSchema         *yang.Entry
+		inStruct         ValidatedGoStruct
+		inConfig         *EmitXMLConfig
+		want             string
+		wantErrSubstring string
+	}{{
+		name:     "empty struct",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestDevice{},
+		want:     "",
+	}, {
+		name:     "leaves of compressed struct",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestDevice{
+			Hostname: String("rtr<1>"),
+			DNS:      []string{"10.0.0.1", "10.0.0.2"},
+			ExtLeaf:  String("ext"),
+			Flag:     true,
+			KeyData:  Binary("abc"),
+			Ident:    EnumTestVALONE,
+		},
+		inConfig: &EmitXMLConfig{Indent: "  "},
+		want: `<system xmlns="urn:dev">
+  <config>
+    <hostname>rtr&lt;1&gt;</hostname>
+    <dns>10.0.0.1</dns>
+    <dns>10.0.0.2</dns>
+    <ext-leaf xmlns="urn:ext">ext</ext-leaf>
+    <flag></flag>
+    <key-data>YWJj</key-data>
+    <ident xmlns:foo="urn:foo">foo:VAL_ONE</ident>
+  </config>
+</system>`,
+	}, {
+		name:     "identity from module with unknown namespace",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestDevice{Ident: EnumTestVALTWO},
+		inConfig: &EmitXMLConfig{Indent: "  "},
+		want: `<system xmlns="urn:dev">
+  <config>
+    <ident>bar:VAL_TWO</ident>
+  </config>
+</system>`,
+	}, {
+		name:     "keyed and keyless lists",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestDevice{
+			Interface: map[string]*xmlTestInterface{
+				"eth1": {Name: String("eth1"), Mtu: Uint16(9000)},
+				"eth0": {Name: String("eth0")},
+			},
+			Server: []*xmlTestServer{
+				{Address: String("192.0.2.2"), Port: Uint16(53)},
+				{Address: String("192.0.2.1")},
+			},
+		},
+		inConfig: &EmitXMLConfig{Indent: "  "},
+		want: `<interfaces xmlns="urn:dev">
+  <interface>
+    <name>eth0</name>
+    <config>
+      <name>eth0</name>
+    </config>
+  </interface>
+  <interface>
+    <name>eth1</name>
+    <config>
+      <mtu>9000</mtu>
+      <name>eth1</name>
+    </config>
+  </interface>
+</interfaces>
+<servers xmlns="urn:dev">
+  <server>
+    <address>192.0.2.2</address>
+    <port>53</port>
+  </server>
+  <server>
+    <address>192.0.2.1</address>
+  </server>
+</servers>`,
+	}, {
+		name:     "empty presence container",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestDevice{Logging: &xmlTestLogging{}},
+		inConfig: &EmitXMLConfig{Indent: "  "},
+		want: `<system xmlns="urn:dev">
+  <logging></logging>
+</system>`,
+	}, {
+		name:     "uncompressed struct with module tag",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestUncompressedDevice{
+			System: &xmlTestSystem{
+				Config: &xmlTestSystemConfig{Hostname: String("rtr1"), ExtLeaf: String("ext")},
+			},
+		},
+		inConfig: &EmitXMLConfig{Indent: "  "},
+		want: `<system xmlns="urn:dev">
+  <config>
+    <hostname>rtr1</hostname>
+    <ext-leaf xmlns="urn:foo">ext</ext-leaf>
+  </config>
+</system>`,
+	}, {
+		name:     "empty uncompressed containers",
+		inSchema: encodingtest.Schema(),
+		inStruct: &xmlTestUncompressedDevice{
+			System: &xmlTestSystem{Config: &xmlTestSystemConfig{}},
+		},
+		want: "",
+	}, {
+		name:             "nil schema",
+		inStruct:         &xmlTestDevice{},
+		wantErrSubstring: "nil schema",
+	}, {
+		name:             "validation error",
+		inSchema:         encodingtest.Schema(),
+		inStruct:         &xmlTestInvalid{},
+		wantErrSubstring: "validation err: invalid",
+	}, {
+		name:             "field not in schema",
+		inSchema:         encodingtest.Schema(),
+		inStruct:         &xmlTestInvalid{Hostname: String("rtr1")},
+		inConfig:         &EmitXMLConfig{SkipValidation: true},
+		wantErrSubstring: "cannot find schema for field Hostname",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EmitXML(tt.inSchema, tt.inStruct, tt.inConfig)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("EmitXML: did not get expected error, %s", diff)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("EmitXML: did not get expected XML, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
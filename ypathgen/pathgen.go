@@ -148,6 +148,16 @@ func (cg *GenConfig) GeneratePathCode(yangFiles, includePaths []string) (*Genera
 		return nil, nil, errs
 	}
 
+	// Path structs are only generated for the data tree, so remove the
+	// directories that describe the contents of RPCs, actions and
+	// notifications.
+	for path, dir := range directories {
+		if isWithinOperation(dir.Entry) {
+			delete(directories, path)
+			delete(leafTypeMap, path)
+		}
+	}
+
 	genCode := &GeneratedPathCode{}
 	errs = util.Errors{}
 	if err := writeHeader(yangFiles, includePaths, cg, genCode); err != nil {
@@ -189,6 +199,17 @@ func (cg *GenConfig) GeneratePathCode(yangFiles, includePaths []string) (*Genera
 	return genCode, nodeDataMap, errs
 }
 
+// isWithinOperation returns true if the supplied yang.Entry is, or is a
+// descendant of, the input or output of an RPC or action, or a notification.
+func isWithinOperation(e *yang.Entry) bool {
+	for ; e != nil; e = e.Parent {
+		if util.IsOperationContainer(e) {
+			return true
+		}
+	}
+	return false
+}
+
 // GeneratedPathCode contains generated code snippets that can be processed by the calling
 // application. The generated code is divided into two types of objects - both represented
 // as a slice of strings: Structs contains a set of Go structures that have been generated,
@@ -820,6 +820,44 @@ func (n *Device) List(Key1 string, Key2 oc.Binary, UnionKey oc.RootModule_List_U
 }
 `
 
+func TestIsWithinOperation(t *testing.T) {
+	module := &yang.Entry{Name: "module", Kind: yang.DirectoryEntry}
+	container := &yang.Entry{Name: "container", Kind: yang.DirectoryEntry, Parent: module}
+	rpc := &yang.Entry{Name: "rpc", Kind: yang.DirectoryEntry, Parent: module}
+	input := &yang.Entry{Name: "input", Kind: yang.InputEntry, Parent: rpc}
+	notification := &yang.Entry{Name: "notification", Kind: yang.NotificationEntry, Parent: module}
+
+	tests := []struct {
+		desc string
+		in   *yang.Entry
+		want bool
+	}{{
+		desc: "container",
+		in:   container,
+	}, {
+		desc: "leaf within container",
+		in:   &yang.Entry{Name: "leaf", Parent: container},
+	}, {
+		desc: "rpc input",
+		in:   input,
+		want: true,
+	}, {
+		desc: "container within rpc input",
+		in:   &yang.Entry{Name: "container", Kind: yang.DirectoryEntry, Parent: input},
+		want: true,
+	}, {
+		desc: "container within notification",
+		in:   &yang.Entry{Name: "container", Kind: yang.DirectoryEntry, Parent: notification},
+		want: true,
+	}}
+
+	for _, tt := range tests {
+		if got := isWithinOperation(tt.in); got != tt.want {
+			t.Errorf("%s: isWithinOperation(%s): got: %v, want: %v", tt.desc, tt.in.Path(), got, tt.want)
+		}
+	}
+}
+
 func TestGetNodeDataMap(t *testing.T) {
 	_, directories, leafTypeMap := getSchemaAndDirs()
 
@@ -0,0 +1,142 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7952 for the encoding of metadata
+// annotations in JSON.
+
+// annotationSliceType is the type of the annotation fields of a GoStruct.
+var annotationSliceType = reflect.TypeOf([]ygot.Annotation{})
+
+// AnnotationRegistry is an unmarshal option that specifies the types of the
+// RFC7952 metadata annotations that are unmarshalled into the annotation
+// fields of GoStructs. Each annotation is identified by its name, qualified
+// with the name of the module that defines it, e.g., "ietf-origin:origin",
+// which is the name of its member within a metadata object.
+//
+// Each member of a metadata object is unmarshalled into a new instance of the
+// Annotation registered for its name, whose UnmarshalJSON method is supplied
+// a JSON object containing only that member. The MarshalJSON method of an
+// Annotation is expected to output the same object, such that JSON output by
+// ygot.EmitJSON can be unmarshalled without loss. Metadata annotations whose
+// names are not registered are an error, unless the IgnoreExtraFields option
+// is also specified.
+type AnnotationRegistry struct {
+	types map[string]func() ygot.Annotation
+}
+
+// NewAnnotationRegistry returns an AnnotationRegistry with no registered
+// annotations.
+func NewAnnotationRegistry() *AnnotationRegistry {
+	return &AnnotationRegistry{types: map[string]func() ygot.Annotation{}}
+}
+
+// Register registers the metadata annotation with the supplied module
+// qualified name, such that it is unmarshalled into the Annotation returned
+// by newFn. It returns an error if the name is already registered.
+func (r *AnnotationRegistry) Register(name string, newFn func() ygot.Annotation) error {
+	if _, ok := r.types[name]; ok {
+		return fmt.Errorf("annotation %s is already registered", name)
+	}
+	r.types[name] = newFn
+	return nil
+}
+
+// IsUnmarshalOpt marks AnnotationRegistry as a valid UnmarshalOpt.
+func (*AnnotationRegistry) IsUnmarshalOpt() {}
+
+// annotationRegistry returns the AnnotationRegistry within the supplied slice
+// of UnmarshalOpts, or nil if there is none.
+func annotationRegistry(opts []UnmarshalOpt) *AnnotationRegistry {
+	for _, o := range opts {
+		if r, ok := o.(*AnnotationRegistry); ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// unmarshalAnnotations appends the metadata annotations within the RFC7952
+// metadata value v to the annotation field f. The value is a metadata object,
+// or an array of them, as is used for the entries of a leaf-list and as is
+// output by ygot.EmitJSON. Entries of the array that are null, which RFC7952
+// uses for leaf-list entries without metadata, are skipped.
+func unmarshalAnnotations(f reflect.Value, v interface{}, opts []UnmarshalOpt) error {
+	if f.Type() != annotationSliceType {
+		return fmt.Errorf("annotation field has type %v, expect %v", f.Type(), annotationSliceType)
+	}
+
+	var objs []interface{}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		objs = []interface{}{v}
+	case []interface{}:
+		objs = v
+	default:
+		return fmt.Errorf("metadata value %v has type %T, expect object or array", v, v)
+	}
+
+	r := annotationRegistry(opts)
+	for _, o := range objs {
+		if o == nil {
+			continue
+		}
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("metadata value %v has type %T, expect object", o, o)
+		}
+
+		// Sort the names such that annotations are appended in a
+		// deterministic order.
+		var names []string
+		for n := range m {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+
+		for _, n := range names {
+			var newFn func() ygot.Annotation
+			if r != nil {
+				newFn = r.types[n]
+			}
+			if newFn == nil {
+				if hasIgnoreExtraFields(opts) {
+					continue
+				}
+				return fmt.Errorf("metadata annotation %s is not registered", n)
+			}
+
+			b, err := json.Marshal(map[string]interface{}{n: m[n]})
+			if err != nil {
+				return fmt.Errorf("cannot marshal metadata annotation %s: %v", n, err)
+			}
+			a := newFn()
+			if err := a.UnmarshalJSON(b); err != nil {
+				return fmt.Errorf("cannot unmarshal metadata annotation %s into %T: %v", n, a, err)
+			}
+			f.Set(reflect.Append(f, reflect.ValueOf(a)))
+		}
+	}
+	return nil
+}
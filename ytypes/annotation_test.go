@@ -0,0 +1,292 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/yangtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// annotationTestModule is the module whose schema is that of the
+// annotationDevice struct.
+const annotationTestModule = `
+module annotation-test {
+  prefix "at";
+  namespace "urn:at";
+
+  container system {
+    container config {
+      leaf hostname {
+        type string;
+      }
+
+      leaf-list dns {
+        type string;
+      }
+    }
+  }
+
+  container interfaces {
+    list interface {
+      key "name";
+
+      leaf name {
+        type leafref {
+          path "../config/name";
+        }
+      }
+
+      container config {
+        leaf name {
+          type string;
+        }
+      }
+    }
+  }
+}
+`
+
+type annotationDevice struct {
+	ΛMetadata []ygot.Annotation               `path:"@" ygotAnnotation:"true"`
+	Hostname  *string                         `path:"system/config/hostname"`
+	ΛHostname []ygot.Annotation               `path:"system/config/@hostname" ygotAnnotation:"true"`
+	DNS       []string                        `path:"system/config/dns"`
+	ΛDNS      []ygot.Annotation               `path:"system/config/@dns" ygotAnnotation:"true"`
+	Interface map[string]*annotationInterface `path:"interfaces/interface"`
+}
+
+func (*annotationDevice) IsYANGGoStruct()                         {}
+func (*annotationDevice) Validate(...ygot.ValidationOption) error { return nil }
+func (*annotationDevice) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type annotationInterface struct {
+	ΛMetadata []ygot.Annotation `path:"@" ygotAnnotation:"true"`
+	Name      *string           `path:"config/name|name"`
+	ΛName     []ygot.Annotation `path:"config/@name|@name" ygotAnnotation:"true"`
+}
+
+func (*annotationInterface) IsYANGGoStruct() {}
+
+// testOrigin is an Annotation that stores the ietf-origin:origin metadata
+// annotation.
+type testOrigin struct {
+	Origin string
+}
+
+func (o *testOrigin) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"ietf-origin:origin": o.Origin})
+}
+
+func (o *testOrigin) UnmarshalJSON(b []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	o.Origin = m["ietf-origin:origin"]
+	return nil
+}
+
+// testComment is an Annotation that stores the ex:comment metadata
+// annotation.
+type testComment struct {
+	Comment string
+}
+
+func (c *testComment) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"ex:comment": c.Comment})
+}
+
+func (c *testComment) UnmarshalJSON(b []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	c.Comment = m["ex:comment"]
+	return nil
+}
+
+// annotationTestRegistry returns an AnnotationRegistry containing testOrigin
+// and testComment.
+func annotationTestRegistry(t *testing.T) *AnnotationRegistry {
+	r := NewAnnotationRegistry()
+	if err := r.Register("ietf-origin:origin", func() ygot.Annotation { return &testOrigin{} }); err != nil {
+		t.Fatalf("Register: got unexpected error: %v", err)
+	}
+	if err := r.Register("ex:comment", func() ygot.Annotation { return &testComment{} }); err != nil {
+		t.Fatalf("Register: got unexpected error: %v", err)
+	}
+	return r
+}
+
+func TestUnmarshalAnnotations(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               string
+		inOpts           []UnmarshalOpt
+		inNoRegistry     bool
+		want             *annotationDevice
+		wantErrSubstring string
+	}{{
+		desc: "leaf metadata object",
+		in: `{"system": {"config": {
+			"hostname": "rtr1",
+			"@hostname": {"ietf-origin:origin": "ietf-origin:intended", "ex:comment": "set by hand"}
+		}}}`,
+		want: &annotationDevice{
+			Hostname:  ygot.String("rtr1"),
+			ΛHostname: []ygot.Annotation{&testComment{Comment: "set by hand"}, &testOrigin{Origin: "ietf-origin:intended"}},
+		},
+	}, {
+		desc: "module qualified member name",
+		in:   `{"system": {"config": {"dev:hostname": "rtr1", "@dev:hostname": {"ex:comment": "qualified"}}}}`,
+		want: &annotationDevice{
+			Hostname:  ygot.String("rtr1"),
+			ΛHostname: []ygot.Annotation{&testComment{Comment: "qualified"}},
+		},
+	}, {
+		desc: "leaf-list metadata with entry without metadata",
+		in: `{"system": {"config": {
+			"dns": ["192.0.2.1", "192.0.2.2"],
+			"@dns": [null, {"ex:comment": "backup"}]
+		}}}`,
+		want: &annotationDevice{
+			DNS:  []string{"192.0.2.1", "192.0.2.2"},
+			ΛDNS: []ygot.Annotation{&testComment{Comment: "backup"}},
+		},
+	}, {
+		desc: "container and list member metadata",
+		in: `{
+			"@": {"ietf-origin:origin": "ietf-origin:system"},
+			"interfaces": {"interface": [{
+				"name": "eth0",
+				"@": {"ex:comment": "uplink"},
+				"config": {"name": "eth0", "@name": {"ietf-origin:origin": "ietf-origin:learned"}}
+			}]}
+		}`,
+		want: &annotationDevice{
+			ΛMetadata: []ygot.Annotation{&testOrigin{Origin: "ietf-origin:system"}},
+			Interface: map[string]*annotationInterface{"eth0": {
+				ΛMetadata: []ygot.Annotation{&testComment{Comment: "uplink"}},
+				Name:      ygot.String("eth0"),
+				ΛName:     []ygot.Annotation{&testOrigin{Origin: "ietf-origin:learned"}},
+			}},
+		},
+	}, {
+		desc:             "unregistered annotation",
+		in:               `{"system": {"config": {"@hostname": {"ex:colour": "blue"}}}}`,
+		wantErrSubstring: "metadata annotation ex:colour is not registered",
+	}, {
+		desc:   "unregistered annotation ignored",
+		in:     `{"system": {"config": {"@hostname": {"ex:colour": "blue", "ex:comment": "kept"}}}}`,
+		inOpts: []UnmarshalOpt{&IgnoreExtraFields{}},
+		want:   &annotationDevice{ΛHostname: []ygot.Annotation{&testComment{Comment: "kept"}}},
+	}, {
+		desc:             "no registry",
+		in:               `{"system": {"config": {"@hostname": {"ex:comment": "x"}}}}`,
+		inNoRegistry:     true,
+		wantErrSubstring: "metadata annotation ex:comment is not registered",
+	}, {
+		desc:             "invalid metadata value",
+		in:               `{"system": {"config": {"@hostname": "x"}}}`,
+		wantErrSubstring: "metadata value x has type string, expect object or array",
+	}, {
+		desc:             "invalid leaf-list metadata entry",
+		in:               `{"system": {"config": {"@dns": [42]}}}`,
+		wantErrSubstring: "metadata value 42 has type float64, expect object",
+	}, {
+		desc:             "annotation cannot be unmarshalled",
+		in:               `{"system": {"config": {"@hostname": {"ex:comment": 42}}}}`,
+		wantErrSubstring: "cannot unmarshal metadata annotation ex:comment into *ytypes.testComment",
+	}, {
+		desc:             "metadata of unknown field",
+		in:               `{"@mtu": {"ex:comment": "x"}}`,
+		wantErrSubstring: "JSON contains unexpected field @mtu",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var in interface{}
+			if err := json.Unmarshal([]byte(tt.in), &in); err != nil {
+				t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", tt.in, err)
+			}
+			opts := tt.inOpts
+			if !tt.inNoRegistry {
+				opts = append(opts, annotationTestRegistry(t))
+			}
+
+			got := &annotationDevice{}
+			err := Unmarshal(yangtest.ParseModule(t, "annotation-test", annotationTestModule), got, in, opts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("Unmarshal: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Unmarshal: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalAnnotationsEmitJSON(t *testing.T) {
+	in := &annotationDevice{
+		ΛMetadata: []ygot.Annotation{&testOrigin{Origin: "ietf-origin:intended"}},
+		Hostname:  ygot.String("rtr1"),
+		ΛHostname: []ygot.Annotation{&testComment{Comment: "one"}, &testComment{Comment: "two"}},
+		DNS:       []string{"192.0.2.1"},
+		ΛDNS:      []ygot.Annotation{&testOrigin{Origin: "ietf-origin:learned"}},
+		Interface: map[string]*annotationInterface{"eth0": {
+			ΛMetadata: []ygot.Annotation{&testComment{Comment: "uplink"}},
+			Name:      ygot.String("eth0"),
+			ΛName:     []ygot.Annotation{&testOrigin{Origin: "ietf-origin:system"}},
+		}},
+	}
+
+	js, err := ygot.EmitJSON(in, &ygot.EmitJSONConfig{Format: ygot.RFC7951})
+	if err != nil {
+		t.Fatalf("EmitJSON: got unexpected error: %v", err)
+	}
+	var tree interface{}
+	if err := json.Unmarshal([]byte(js), &tree); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+
+	got := &annotationDevice{}
+	if err := Unmarshal(yangtest.ParseModule(t, "annotation-test", annotationTestModule), got, tree, annotationTestRegistry(t)); err != nil {
+		t.Fatalf("Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	if diff := cmp.Diff(in, got); diff != "" {
+		t.Errorf("Unmarshal(%s): did not get EmitJSON input, diff(-want, +got):\n%s", js, diff)
+	}
+}
+
+func TestAnnotationRegistryRegister(t *testing.T) {
+	r := NewAnnotationRegistry()
+	newFn := func() ygot.Annotation { return &testComment{} }
+	if err := r.Register("ex:comment", newFn); err != nil {
+		t.Fatalf("Register(ex:comment): got unexpected error: %v", err)
+	}
+	err := r.Register("ex:comment", newFn)
+	if diff := errdiff.Substring(err, "annotation ex:comment is already registered"); diff != "" {
+		t.Errorf("Register(ex:comment): did not get expected error, %s", diff)
+	}
+}
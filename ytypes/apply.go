@@ -0,0 +1,268 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// ApplySetRequest applies the supplied gNMI SetRequest to root, which must be
+// the GoStruct described by schema. The paths within the request are
+// interpreted relative to its prefix, and the operations are applied in the
+// order specified by gNMI: all deletes are processed first, followed by
+// replaces, and then updates. Values may be scalar TypedValues, or JSON and
+// JSON_IETF encoded subtrees.
+//
+// The request is applied atomically - if any operation within it fails,
+// root is left unchanged. The error returned is a gRPC status error whose
+// code indicates the reason for the failure. To achieve this, the request is
+// applied to a deep copy of root, whose contents then replace those of root.
+// Hence, after the request is applied successfully, pointers to the nodes
+// within root that were obtained before the call, such as containers and
+// list members, refer to the old tree rather than to the contents of root.
+func ApplySetRequest(schema *yang.Entry, root ygot.GoStruct, req *gpb.SetRequest) error {
+	if req == nil {
+		return status.Error(codes.InvalidArgument, "nil SetRequest")
+	}
+	return applyAtomic(schema, root, func(r ygot.GoStruct) error {
+		for _, p := range req.GetDelete() {
+			if err := applyDelete(schema, r, req.GetPrefix(), p); err != nil {
+				return err
+			}
+		}
+		for _, u := range req.GetReplace() {
+			if err := applyUpdate(schema, r, req.GetPrefix(), u, true); err != nil {
+				return err
+			}
+		}
+		for _, u := range req.GetUpdate() {
+			if err := applyUpdate(schema, r, req.GetPrefix(), u, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ApplyNotification applies the supplied gNMI Notification to root, which
+// must be the GoStruct described by schema, such that it is the inverse of
+// ygot.TogNMINotifications. The deletes within the notification are
+// processed before its updates. Like ApplySetRequest, the notification is
+// applied atomically to a copy of root that replaces its contents, such that
+// pointers into root obtained before the call are stale once it succeeds,
+// and errors are returned as gRPC status errors.
+func ApplyNotification(schema *yang.Entry, root ygot.GoStruct, n *gpb.Notification) error {
+	if n == nil {
+		return status.Error(codes.InvalidArgument, "nil Notification")
+	}
+	return applyAtomic(schema, root, func(r ygot.GoStruct) error {
+		for _, p := range n.GetDelete() {
+			if err := applyDelete(schema, r, n.GetPrefix(), p); err != nil {
+				return err
+			}
+		}
+		for _, u := range n.GetUpdate() {
+			if err := applyUpdate(schema, r, n.GetPrefix(), u, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyAtomic calls apply with a deep copy of root, and sets the contents of
+// root to those of the copy only if apply succeeds.
+func applyAtomic(schema *yang.Entry, root ygot.GoStruct, apply func(ygot.GoStruct) error) error {
+	switch {
+	case schema == nil:
+		return status.Error(codes.InvalidArgument, "nil schema")
+	case util.IsValueNil(root):
+		return status.Error(codes.InvalidArgument, "nil root")
+	}
+	c, err := ygot.DeepCopy(root)
+	if err != nil {
+		return status.Errorf(codes.Internal, "cannot copy root %T: %v", root, err)
+	}
+	if err := apply(c); err != nil {
+		return err
+	}
+	reflect.ValueOf(root).Elem().Set(reflect.ValueOf(c).Elem())
+	return nil
+}
+
+// applyDelete deletes the node at path p, relative to prefix, from root.
+// Deleting a node that does not exist is not an error.
+func applyDelete(schema *yang.Entry, root ygot.GoStruct, prefix, p *gpb.Path) error {
+	fp, err := applyPath(prefix, p)
+	if err != nil {
+		return applyErr("delete", p, err)
+	}
+	if len(fp.GetElem()) == 0 {
+		zeroRoot(root)
+		return nil
+	}
+	if err := DeleteNode(schema, root, fp); err != nil {
+		return applyErr("delete", fp, err)
+	}
+	return nil
+}
+
+// applyUpdate applies the update u, whose path is relative to prefix, to
+// root. If replace is true, the node at the path of u is removed before the
+// value is applied, such that any of its contents that are not in the value
+// are deleted. Leaf-lists are always replaced in their entirety.
+func applyUpdate(schema *yang.Entry, root ygot.GoStruct, prefix *gpb.Path, u *gpb.Update, replace bool) error {
+	op := "update"
+	if replace {
+		op = "replace"
+	}
+	fp, err := applyPath(prefix, u.GetPath())
+	if err != nil {
+		return applyErr(op, u.GetPath(), err)
+	}
+	val, isJSON, err := applyValue(u.GetVal())
+	if err != nil {
+		return applyErr(op, fp, err)
+	}
+
+	if replace {
+		if len(fp.GetElem()) == 0 {
+			zeroRoot(root)
+		} else if err := DeleteNode(schema, root, fp); err != nil {
+			return applyErr(op, fp, err)
+		}
+	}
+
+	node, nodeSchema, err := GetOrCreateNode(schema, root, fp)
+	if err != nil {
+		return applyErr(op, fp, err)
+	}
+
+	if nodeSchema.IsLeaf() || nodeSchema.IsLeafList() {
+		if nodeSchema.IsLeafList() && !replace {
+			if err := DeleteNode(schema, root, fp); err != nil {
+				return applyErr(op, fp, err)
+			}
+		}
+		if _, err := retrieveNode(schema, root, fp, nil, retrieveNodeArgs{modifyRoot: true, val: val, valJSON: isJSON}); err != nil {
+			return applyErr(op, fp, err)
+		}
+		return nil
+	}
+
+	if !isJSON {
+		return applyErr(op, fp, fmt.Errorf("non-leaf schema %s cannot be set to a scalar value", nodeSchema.Name))
+	}
+	if err := Unmarshal(nodeSchema, node, val); err != nil {
+		return applyErr(op, fp, err)
+	}
+	if nodeSchema.IsList() {
+		if err := checkListMemberKeys(node, fp); err != nil {
+			return applyErr(op, fp, err)
+		}
+	}
+	return nil
+}
+
+// applyPath returns the path p prefixed with the elements of prefix. Paths
+// that use the deprecated element field are not supported.
+func applyPath(prefix, p *gpb.Path) (*gpb.Path, error) {
+	if len(prefix.GetElement()) != 0 || len(p.GetElement()) != 0 {
+		return nil, status.Error(codes.Unimplemented, "paths using the deprecated element field are not supported")
+	}
+	fp := &gpb.Path{}
+	for _, e := range append(append([]*gpb.PathElem{}, prefix.GetElem()...), p.GetElem()...) {
+		fp.Elem = append(fp.Elem, proto.Clone(e).(*gpb.PathElem))
+	}
+	return fp, nil
+}
+
+// applyValue returns the value within the TypedValue tv that should be
+// used to set a node. JSON and JSON_IETF values are decoded, and returned
+// with isJSON set to true. Other scalar values are returned as the supplied
+// TypedValue.
+func applyValue(tv *gpb.TypedValue) (val interface{}, isJSON bool, err error) {
+	var j []byte
+	switch v := tv.GetValue().(type) {
+	case nil:
+		return nil, false, status.Error(codes.InvalidArgument, "no value specified")
+	case *gpb.TypedValue_JsonVal:
+		j = v.JsonVal
+	case *gpb.TypedValue_JsonIetfVal:
+		j = v.JsonIetfVal
+	case *gpb.TypedValue_AsciiVal, *gpb.TypedValue_ProtoBytes, *gpb.TypedValue_AnyVal:
+		return nil, false, status.Errorf(codes.Unimplemented, "unsupported value type %T", v)
+	default:
+		return tv, false, nil
+	}
+	if err := json.Unmarshal(j, &val); err != nil {
+		return nil, false, status.Errorf(codes.InvalidArgument, "cannot decode JSON value %s: %v", j, err)
+	}
+	return val, true, nil
+}
+
+// checkListMemberKeys checks that the keys of the list member node match the
+// keys that are specified in the last element of its path p. An error is
+// returned if they do not, since the value used to set the member changed
+// its keys.
+func checkListMemberKeys(node interface{}, p *gpb.Path) error {
+	if _, ok := node.(ygot.KeyHelperGoStruct); !ok || len(p.GetElem()) == 0 {
+		return nil
+	}
+	keys, err := ygot.PathKeyFromStruct(reflect.ValueOf(node))
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if want := p.GetElem()[len(p.GetElem())-1].GetKey(); !reflect.DeepEqual(keys, want) {
+		return status.Errorf(codes.InvalidArgument, "value has keys %v, path has keys %v", keys, want)
+	}
+	return nil
+}
+
+// zeroRoot removes all contents of the root GoStruct.
+func zeroRoot(root ygot.GoStruct) {
+	v := reflect.ValueOf(root).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
+
+// applyErr returns a gRPC status error for the failure err of the operation
+// op at path p. The code of err is preserved if it is a gRPC status error
+// with a code other than Unknown. Other errors, such as those returned when
+// a value cannot be unmarshalled or a path does not match the schema, are
+// caused by the contents of the request, and hence are reported with the
+// InvalidArgument code rather than Unknown.
+func applyErr(op string, p *gpb.Path, err error) error {
+	ps, perr := ygot.PathToString(p)
+	if perr != nil {
+		ps = proto.CompactTextString(p)
+	}
+	s := status.Convert(err)
+	code := s.Code()
+	if code == codes.Unknown {
+		code = codes.InvalidArgument
+	}
+	return status.Errorf(code, "%s %s: %s", op, ps, s.Message())
+}
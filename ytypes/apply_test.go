@@ -0,0 +1,313 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/yangtest"
+	"github.com/openconfig/ygot/testutil"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+func stringVal(s string) *gpb.TypedValue {
+	return &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{s}}
+}
+
+func TestApplySetRequest(t *testing.T) {
+	device := func() *xpathDevice {
+		return &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{
+				Hostname: String("rtr1"),
+				Server:   []string{"s1", "s2"},
+			},
+		}
+	}
+
+	tests := []struct {
+		desc          string
+		in            *gpb.SetRequest
+		want          *xpathDevice
+		wantCode      codes.Code
+		wantErrSubstr string
+	}{{
+		desc: "scalar update creates list member",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth1]/config/mtu"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{9000}},
+			}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+				"eth1": {Name: String("eth1"), Mtu: Uint32(9000)},
+			},
+			System: &xpathSystem{Hostname: String("rtr1"), Server: []string{"s1", "s2"}},
+		},
+	}, {
+		desc: "paths relative to prefix",
+		in: &gpb.SetRequest{
+			Prefix: mustPath("/system"),
+			Update: []*gpb.Update{{Path: mustPath("/hostname"), Val: stringVal("rtr2")}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{Hostname: String("rtr2"), Server: []string{"s1", "s2"}},
+		},
+	}, {
+		desc: "deletes processed before updates",
+		in: &gpb.SetRequest{
+			Delete: []*gpb.Path{mustPath("/system")},
+			Update: []*gpb.Update{{Path: mustPath("/system/hostname"), Val: stringVal("rtr2")}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{Hostname: String("rtr2")},
+		},
+	}, {
+		desc: "replace container with JSON_IETF",
+		in: &gpb.SetRequest{
+			Replace: []*gpb.Update{{Path: mustPath("/system"), Val: testutil.JSONIETFVal(`{"example:hostname": "rtr2"}`)}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{Hostname: String("rtr2")},
+		},
+	}, {
+		desc: "update container with JSON merges",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonVal{[]byte(`{"config": {"enabled": true}}`)}},
+			}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500), Enabled: ygot.Bool(true)},
+			},
+			System: &xpathSystem{Hostname: String("rtr1"), Server: []string{"s1", "s2"}},
+		},
+	}, {
+		desc: "replace list member",
+		in: &gpb.SetRequest{
+			Replace: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]"),
+				Val:  testutil.JSONIETFVal(`{"name": "eth0", "config": {"name": "eth0", "enabled": false}}`),
+			}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Enabled: ygot.Bool(false)},
+			},
+			System: &xpathSystem{Hostname: String("rtr1"), Server: []string{"s1", "s2"}},
+		},
+	}, {
+		desc: "leaf-list update replaces all values",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{
+				Path: mustPath("/system/server"),
+				Val: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{&gpb.ScalarArray{
+					Element: []*gpb.TypedValue{stringVal("s3")},
+				}}},
+			}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{Hostname: String("rtr1"), Server: []string{"s3"}},
+		},
+	}, {
+		desc: "JSON leaf values",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{
+				Path: mustPath("/system/hostname"),
+				Val:  testutil.JSONIETFVal(`"rtr2"`),
+			}, {
+				Path: mustPath("/system/server"),
+				Val:  testutil.JSONIETFVal(`["s4", "s5"]`),
+			}, {
+				Path: mustPath("/interfaces/interface[name=eth0]/config/mtu"),
+				Val:  testutil.JSONIETFVal(`9000`),
+			}},
+		},
+		want: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(9000)},
+			},
+			System: &xpathSystem{Hostname: String("rtr2"), Server: []string{"s4", "s5"}},
+		},
+	}, {
+		desc: "delete entire list and missing node",
+		in: &gpb.SetRequest{
+			Delete: []*gpb.Path{
+				mustPath("/interfaces/interface"),
+				mustPath("/interfaces/interface[name=eth9]"),
+				mustPath("/system/ref"),
+			},
+		},
+		want: &xpathDevice{
+			System: &xpathSystem{Hostname: String("rtr1"), Server: []string{"s1", "s2"}},
+		},
+	}, {
+		desc: "replace root",
+		in: &gpb.SetRequest{
+			Replace: []*gpb.Update{{Path: &gpb.Path{}, Val: testutil.JSONIETFVal(`{"system": {"hostname": "rtr3"}}`)}},
+		},
+		want: &xpathDevice{
+			System: &xpathSystem{Hostname: String("rtr3")},
+		},
+	}, {
+		desc: "failed update is rolled back",
+		in: &gpb.SetRequest{
+			Delete: []*gpb.Path{mustPath("/system")},
+			Update: []*gpb.Update{{Path: mustPath("/system/hostname"), Val: &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{42}}}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "update /system/hostname: failed to update struct field Hostname",
+	}, {
+		desc: "key changed by value",
+		in: &gpb.SetRequest{
+			Replace: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]"),
+				Val:  testutil.JSONIETFVal(`{"name": "eth1", "config": {"name": "eth1"}}`),
+			}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "replace /interfaces/interface[name=eth0]: value has keys map[name:eth1], path has keys map[name:eth0]",
+	}, {
+		desc: "unknown path",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{Path: mustPath("/system/location"), Val: stringVal("lab")}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "update /system/location: no match found",
+	}, {
+		desc: "scalar value for container",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{Path: mustPath("/system"), Val: stringVal("rtr2")}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "non-leaf schema system cannot be set to a scalar value",
+	}, {
+		desc: "invalid JSON",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{Path: mustPath("/system"), Val: testutil.JSONIETFVal(`{`)}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "cannot decode JSON value",
+	}, {
+		desc: "JSON with unknown field",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{Path: mustPath("/system"), Val: testutil.JSONIETFVal(`{"location": "lab"}`)}},
+		},
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "JSON contains unexpected field location",
+	}, {
+		desc: "unsupported value",
+		in: &gpb.SetRequest{
+			Update: []*gpb.Update{{Path: mustPath("/system/hostname"), Val: &gpb.TypedValue{Value: &gpb.TypedValue_AsciiVal{"rtr2"}}}},
+		},
+		wantCode:      codes.Unimplemented,
+		wantErrSubstr: "unsupported value type",
+	}, {
+		desc: "deprecated path elements",
+		in: &gpb.SetRequest{
+			Delete: []*gpb.Path{{Element: []string{"system"}}},
+		},
+		wantCode:      codes.Unimplemented,
+		wantErrSubstr: "deprecated element field",
+	}, {
+		desc:          "nil request",
+		in:            nil,
+		wantCode:      codes.InvalidArgument,
+		wantErrSubstr: "nil SetRequest",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := device()
+			err := ApplySetRequest(yangtest.XPathSchema(t), got, tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("ApplySetRequest: did not get expected error, %s", diff)
+			}
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("ApplySetRequest: got error code %s, want: %s", code, tt.wantCode)
+			}
+			want := tt.want
+			if err != nil {
+				// A failed request must not modify the root.
+				want = device()
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("ApplySetRequest: did not get expected root, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestApplyNotification(t *testing.T) {
+	want := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth0": {Name: String("eth0"), Mtu: Uint32(1500), Enabled: ygot.Bool(true)},
+			"eth1": {Name: String("eth1")},
+		},
+		System: &xpathSystem{
+			Hostname: String("rtr1"),
+			Server:   []string{"s1", "s2"},
+		},
+	}
+	ns, err := ygot.TogNMINotifications(want, 42, ygot.GNMINotificationsConfig{UsePathElem: true})
+	if err != nil {
+		t.Fatalf("TogNMINotifications: got unexpected error: %v", err)
+	}
+	ns[0].Delete = []*gpb.Path{mustPath("/system/ref")}
+
+	got := &xpathDevice{System: &xpathSystem{Ref: String("eth0")}}
+	for _, n := range ns {
+		if err := ApplyNotification(yangtest.XPathSchema(t), got, n); err != nil {
+			t.Fatalf("ApplyNotification: got unexpected error: %v", err)
+		}
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ApplyNotification: did not get expected root, diff(-want, +got):\n%s", diff)
+	}
+
+	err = ApplyNotification(yangtest.XPathSchema(t), got, &gpb.Notification{
+		Update: []*gpb.Update{{Path: mustPath("/system/hostname"), Val: stringVal("rtr2")}, {Path: mustPath("/system/invalid"), Val: stringVal("x")}},
+	})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Errorf("ApplyNotification: got error %v, want code %s", err, codes.InvalidArgument)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ApplyNotification: failed notification modified root, diff(-want, +got):\n%s", diff)
+	}
+}
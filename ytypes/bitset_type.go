@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // Refer to: https://tools.ietf.org/html/rfc6020#section-9.7.
@@ -48,6 +49,29 @@ func validateBitset(schema *yang.Entry, value interface{}) error {
 	return nil
 }
 
+// validateBits validates value, which must be a generated GoBits type, against
+// the given schema.
+func validateBits(schema *yang.Entry, value interface{}) error {
+	// Check that the schema itself is valid.
+	if err := validateBitsetSchema(schema); err != nil {
+		return err
+	}
+
+	b, ok := value.(ygot.GoBits)
+	if !ok {
+		return fmt.Errorf("non bits type %T with value %v for schema %s", value, value, schema.Name)
+	}
+	names, err := ygot.BitsName(b)
+	if err != nil {
+		return fmt.Errorf("%v for schema %s", err, schema.Name)
+	}
+	if names == "" {
+		// No bits are set, which is a valid value for any bits type.
+		return nil
+	}
+	return validateBitset(schema, names)
+}
+
 // validateBitsetSlice validates value, which must be a Go string slice type,
 // against the given schema.
 func validateBitsetSlice(schema *yang.Entry, value interface{}) error {
@@ -17,7 +17,11 @@ package ytypes
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
 	"github.com/openconfig/goyang/pkg/yang"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 var validBitsetSchema = mapToBitsetSchema("valid-bitset-schema", map[string]int64{"name1": 0, "name2": 1, "name3": 2})
@@ -159,3 +163,160 @@ func TestValidateBitsetSlice(t *testing.T) {
 		})
 	}
 }
+
+// BitsType is a generated bits type used for testing.
+type BitsType uint64
+
+func (BitsType) IsYANGGoBits() {}
+
+func (BitsType) ΛBits() map[string]map[uint32]string {
+	return map[string]map[uint32]string{
+		"BitsType": {0: "name1", 1: "name2", 2: "name3"},
+	}
+}
+
+type BitsContainerStruct struct {
+	BitsLeaf     *BitsType  `path:"bits-leaf"`
+	BitsLeafList []BitsType `path:"bits-leaflist"`
+}
+
+func (*BitsContainerStruct) IsYANGGoStruct() {}
+
+var bitsContainerSchema = &yang.Entry{
+	Name: "bits-container",
+	Kind: yang.DirectoryEntry,
+	Dir: map[string]*yang.Entry{
+		"bits-leaf":     mapToBitsetSchema("bits-leaf", map[string]int64{"name1": 0, "name2": 1, "name3": 2}),
+		"bits-leaflist": mapToBitsetSchema("bits-leaflist", map[string]int64{"name1": 0, "name2": 1, "name3": 2}),
+	},
+}
+
+func init() {
+	bitsContainerSchema.Dir["bits-leaflist"].ListAttr = &yang.ListAttr{}
+	for _, e := range bitsContainerSchema.Dir {
+		e.Kind = yang.LeafEntry
+		e.Parent = bitsContainerSchema
+	}
+}
+
+func TestValidateBits(t *testing.T) {
+	schema := mapToBitsetSchema("valid-bits-schema", map[string]int64{"name1": 0, "name3": 2})
+
+	tests := []struct {
+		desc    string
+		in      interface{}
+		wantErr bool
+	}{{
+		desc: "no bits set",
+		in:   BitsType(0),
+	}, {
+		desc: "multiple bits set",
+		in:   BitsType(1<<0 | 1<<2),
+	}, {
+		desc:    "bit not in type",
+		in:      BitsType(1 << 5),
+		wantErr: true,
+	}, {
+		desc:    "bit not in schema",
+		in:      BitsType(1 << 1),
+		wantErr: true,
+	}, {
+		desc:    "not a bits type",
+		in:      "name1",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := validateBits(schema, tt.in)
+			if got, want := err != nil, tt.wantErr; got != want {
+				t.Errorf("validateBits(%v): got error: %v, want error? %v", tt.in, err, want)
+			}
+			testErrLog(t, tt.desc, err)
+		})
+	}
+
+	leaf := &BitsContainerStruct{BitsLeaf: func(b BitsType) *BitsType { return &b }(1 << 1)}
+	if errs := Validate(bitsContainerSchema, leaf); errs != nil {
+		t.Errorf("Validate(%v): got unexpected error: %v", leaf, errs)
+	}
+	leafList := &BitsContainerStruct{BitsLeafList: []BitsType{1 << 0, 1 << 7}}
+	if errs := Validate(bitsContainerSchema, leafList); errs == nil {
+		t.Errorf("Validate(%v): did not get expected error", leafList)
+	}
+}
+
+func TestUnmarshalBits(t *testing.T) {
+	bits := func(b BitsType) *BitsType { return &b }
+
+	tests := []struct {
+		desc          string
+		inSchema      *yang.Entry
+		inVal         interface{}
+		inEnc         Encoding
+		want          *BitsContainerStruct
+		wantErrSubstr string
+	}{{
+		desc:  "JSON leaf",
+		inVal: map[string]interface{}{"bits-leaf": "name1 name3"},
+		want:  &BitsContainerStruct{BitsLeaf: bits(1<<0 | 1<<2)},
+	}, {
+		desc:  "JSON leaf with no bits set",
+		inVal: map[string]interface{}{"bits-leaf": ""},
+		want:  &BitsContainerStruct{BitsLeaf: bits(0)},
+	}, {
+		desc:  "JSON leaf-list",
+		inVal: map[string]interface{}{"bits-leaflist": []interface{}{"name2", "name1 name2"}},
+		want:  &BitsContainerStruct{BitsLeafList: []BitsType{1 << 1, 1<<0 | 1<<1}},
+	}, {
+		desc:          "JSON leaf with unknown bit",
+		inVal:         map[string]interface{}{"bits-leaf": "name1 name4"},
+		wantErrSubstr: "name4 is not a valid bit name for bits type BitsType",
+	}, {
+		desc:          "JSON leaf with wrong type",
+		inVal:         map[string]interface{}{"bits-leaf": float64(1)},
+		wantErrSubstr: "got float64 type for field bits-leaf, expect string",
+	}, {
+		desc:     "gNMI leaf",
+		inSchema: bitsContainerSchema.Dir["bits-leaf"],
+		inVal:    &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "name2 name3"}},
+		inEnc:    GNMIEncoding,
+		want:     &BitsContainerStruct{BitsLeaf: bits(1<<1 | 1<<2)},
+	}, {
+		desc:     "gNMI leaf-list",
+		inSchema: bitsContainerSchema.Dir["bits-leaflist"],
+		inVal: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{LeaflistVal: &gpb.ScalarArray{
+			Element: []*gpb.TypedValue{
+				{Value: &gpb.TypedValue_StringVal{StringVal: "name3"}},
+			},
+		}}},
+		inEnc: GNMIEncoding,
+		want:  &BitsContainerStruct{BitsLeafList: []BitsType{1 << 2}},
+	}, {
+		desc:          "gNMI leaf with wrong type",
+		inSchema:      bitsContainerSchema.Dir["bits-leaf"],
+		inVal:         &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{UintVal: 1}},
+		inEnc:         GNMIEncoding,
+		wantErrSubstr: "failed to unmarshal",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			schema := bitsContainerSchema
+			if tt.inSchema != nil {
+				schema = tt.inSchema
+			}
+			got := &BitsContainerStruct{}
+			err := unmarshalGeneric(schema, got, tt.inVal, tt.inEnc)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("unmarshalGeneric: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmarshalGeneric: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
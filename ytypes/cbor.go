@@ -0,0 +1,341 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc9254 for the CBOR encoding of
+// YANG data.
+
+// UnmarshalCBOR unmarshals the CBOR encoded data into the parent GoStruct,
+// which is described by schema. The keys of the CBOR maps may be the names of
+// the data nodes, as output by ygot.ConstructCBOR, or their SIDs, in which
+// case sids must specify the SIDs of the schema nodes and identities. As per
+// RFC9254, the SIDs of nested data nodes are the delta from the SID of their
+// parent. Values that are already set within parent are retained, other than
+// where they are overwritten by the data. Keys that do not correspond to the
+// schema are an error, unless the IgnoreExtraFields option is specified.
+func UnmarshalCBOR(schema *yang.Entry, parent interface{}, data []byte, sids *ygot.SIDMap, opts ...UnmarshalOpt) error {
+	if schema == nil {
+		return fmt.Errorf("nil schema for parent type %T", parent)
+	}
+
+	v, err := util.UnmarshalCBOR(data)
+	if err != nil {
+		return fmt.Errorf("cannot parse CBOR: %v", err)
+	}
+	m, ok := v.(util.CBORMap)
+	if !ok {
+		return fmt.Errorf("CBOR data is %T, must be a map", v)
+	}
+
+	d := &cborDecoder{sids: sids, opts: opts}
+	tree, err := d.cborToJSON(schema, m, 0, true)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(schema, parent, tree, opts...)
+}
+
+// cborDecoder converts decoded CBOR data to RFC7951 JSON, such that it can be
+// unmarshalled.
+type cborDecoder struct {
+	// sids are the SIDs of the schema nodes and identities, or nil if the
+	// data uses names.
+	sids *ygot.SIDMap
+	// opts are the options that the data is unmarshalled with.
+	opts []UnmarshalOpt
+}
+
+// cborToJSON returns the JSON object that corresponds to the CBOR map m, which
+// is the content of the data node described by schema, whose SID is sid. If
+// top is true, the keys of m are absolute SIDs, rather than deltas.
+func (d *cborDecoder) cborToJSON(schema *yang.Entry, m util.CBORMap, sid uint64, top bool) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, p := range m {
+		name, csid, err := d.keyName(p.Key, sid, top)
+		if err != nil {
+			return nil, err
+		}
+		cs := util.FirstChild(schema, []string{name})
+		if cs == nil {
+			if hasIgnoreExtraFields(d.opts) {
+				continue
+			}
+			return nil, fmt.Errorf("%s is not a valid child of %s", name, schema.Name)
+		}
+
+		switch {
+		case cs.IsList():
+			l, ok := p.Value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("value of list %s is %T, must be an array", name, p.Value)
+			}
+			var members []interface{}
+			for _, e := range l {
+				em, ok := e.(util.CBORMap)
+				if !ok {
+					return nil, fmt.Errorf("member of list %s is %T, must be a map", name, e)
+				}
+				jm, err := d.cborToJSON(cs, em, csid, false)
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, jm)
+			}
+			out[cs.Name] = members
+		case cs.IsContainer():
+			cm, ok := p.Value.(util.CBORMap)
+			if !ok {
+				return nil, fmt.Errorf("value of container %s is %T, must be a map", name, p.Value)
+			}
+			jm, err := d.cborToJSON(cs, cm, csid, false)
+			if err != nil {
+				return nil, err
+			}
+			out[cs.Name] = jm
+		case cs.IsLeaf(), cs.IsLeafList():
+			rs, err := util.ResolveIfLeafRef(cs)
+			if err != nil {
+				return nil, err
+			}
+			if rs.Type == nil {
+				return nil, fmt.Errorf("leaf schema type is nil for schema %s", cs.Name)
+			}
+			if cs.IsLeaf() {
+				v, ok := d.leafValue(rs.Type, p.Value)
+				if !ok {
+					return nil, fmt.Errorf("invalid %v value %v for leaf %s", rs.Type.Kind, p.Value, name)
+				}
+				out[cs.Name] = v
+				continue
+			}
+			l, ok := p.Value.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("value of leaf-list %s is %T, must be an array", name, p.Value)
+			}
+			var vals []interface{}
+			for _, e := range l {
+				v, ok := d.leafValue(rs.Type, e)
+				if !ok {
+					return nil, fmt.Errorf("invalid %v value %v for leaf-list %s", rs.Type.Kind, e, name)
+				}
+				vals = append(vals, v)
+			}
+			out[cs.Name] = vals
+		default:
+			return nil, fmt.Errorf("unsupported schema node %s for CBOR key %v", cs.Name, p.Key)
+		}
+	}
+	return out, nil
+}
+
+// keyName returns the name of the data node with the CBOR map key k, along
+// with its SID if the decoder has a SIDMap. The SID of the parent of the data
+// node is psid, and k is an absolute SID rather than a delta if top is true.
+func (d *cborDecoder) keyName(k interface{}, psid uint64, top bool) (string, uint64, error) {
+	if s, ok := k.(string); ok {
+		return util.StripModulePrefix(s), 0, nil
+	}
+	if d.sids == nil {
+		return "", 0, fmt.Errorf("invalid key %v of type %T, must be a string when SIDs are not specified", k, k)
+	}
+
+	var sid uint64
+	switch k := k.(type) {
+	case uint64:
+		sid = k
+		if !top {
+			sid = psid + k
+		}
+	case int64:
+		if top {
+			return "", 0, fmt.Errorf("invalid negative SID %d", k)
+		}
+		sid = psid + uint64(k)
+	default:
+		return "", 0, fmt.Errorf("invalid key %v of type %T, must be a SID", k, k)
+	}
+	p, ok := d.sids.DataPath(sid)
+	if !ok {
+		return "", 0, fmt.Errorf("unknown SID %d", sid)
+	}
+	return util.StripModulePrefix(p[strings.LastIndex(p, "/")+1:]), sid, nil
+}
+
+// leafValue returns the RFC7951 JSON value of the CBOR value v of a leaf of
+// type t, and whether it is a valid value of the type.
+func (d *cborDecoder) leafValue(t *yang.YangType, v interface{}) (interface{}, bool) {
+	switch t.Kind {
+	case yang.Yunion:
+		for _, mt := range t.Type {
+			if jv, ok := d.leafValue(mt, v); ok {
+				return jv, true
+			}
+		}
+	case yang.Ystring:
+		if s, ok := v.(string); ok {
+			return s, true
+		}
+	case yang.Yempty:
+		if v == nil {
+			return []interface{}{nil}, true
+		}
+	case yang.Ybool:
+		if b, ok := v.(bool); ok {
+			return b, true
+		}
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		switch i := v.(type) {
+		case uint64:
+			return float64(i), true
+		case int64:
+			if t.Kind == yang.Yint8 || t.Kind == yang.Yint16 || t.Kind == yang.Yint32 {
+				return float64(i), true
+			}
+		}
+	case yang.Yint64, yang.Yuint64:
+		switch i := v.(type) {
+		case uint64:
+			return strconv.FormatUint(i, 10), true
+		case int64:
+			if t.Kind == yang.Yint64 {
+				return strconv.FormatInt(i, 10), true
+			}
+		}
+	case yang.Ydecimal64:
+		if tag, ok := v.(util.CBORTag); ok && tag.Number == ygot.CBORTagDecimalFraction {
+			if s, ok := cborDecimalString(tag.Content); ok {
+				return s, true
+			}
+		}
+	case yang.Ybinary:
+		if b, ok := v.([]byte); ok {
+			return base64.StdEncoding.EncodeToString(b), true
+		}
+	case yang.Yidentityref:
+		switch i := v.(type) {
+		case string:
+			return i, true
+		case uint64:
+			return d.identity(i)
+		case util.CBORTag:
+			if sid, ok := i.Content.(uint64); ok && i.Number == ygot.CBORTagIdentityRef {
+				return d.identity(sid)
+			}
+		}
+	case yang.Yenum:
+		switch i := v.(type) {
+		case string:
+			return i, true
+		case uint64, int64:
+			// Enumerations may be encoded as the integer value that is
+			// assigned to them in the schema.
+			if t.Enum == nil {
+				return nil, false
+			}
+			if n := t.Enum.Name(cborInt64(i)); n != "" {
+				return n, true
+			}
+		case util.CBORTag:
+			if s, ok := i.Content.(string); ok && i.Number == ygot.CBORTagEnumeration {
+				return s, true
+			}
+		}
+	case yang.Ybits:
+		switch i := v.(type) {
+		case string:
+			return i, true
+		case []byte:
+			// Bits may be encoded as a bitmap in which position 0 is the
+			// least significant bit of the first byte.
+			if t.Bit == nil {
+				return nil, false
+			}
+			var names []string
+			for pos := 0; pos < len(i)*8; pos++ {
+				if i[pos/8]&(1<<uint(pos%8)) == 0 {
+					continue
+				}
+				n := t.Bit.Name(int64(pos))
+				if n == "" {
+					return nil, false
+				}
+				names = append(names, n)
+			}
+			return strings.Join(names, " "), true
+		case util.CBORTag:
+			if s, ok := i.Content.(string); ok && i.Number == ygot.CBORTagBits {
+				return s, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// identity returns the module-qualified name of the identity with the SID
+// sid, and whether it is found.
+func (d *cborDecoder) identity(sid uint64) (interface{}, bool) {
+	if d.sids == nil {
+		return nil, false
+	}
+	n, ok := d.sids.Identity(sid)
+	return n, ok
+}
+
+// cborInt64 returns the value of the CBOR integer i, which is a uint64 or an
+// int64.
+func cborInt64(i interface{}) int64 {
+	if u, ok := i.(uint64); ok {
+		return int64(u)
+	}
+	return i.(int64)
+}
+
+// cborDecimalString returns the decimal string representation of the content
+// of a CBOR decimal fraction, which is an array of its exponent and mantissa,
+// and whether the content is valid.
+func cborDecimalString(c interface{}) (string, bool) {
+	a, ok := c.([]interface{})
+	if !ok || len(a) != 2 {
+		return "", false
+	}
+	var exp, m int64
+	for i, v := range a {
+		switch v.(type) {
+		case uint64, int64:
+		default:
+			return "", false
+		}
+		if i == 0 {
+			exp = cborInt64(v)
+		} else {
+			m = cborInt64(v)
+		}
+	}
+	if exp > 0 || exp < -18 {
+		return "", false
+	}
+	return string(ygot.NewDecimal64(m, uint8(-exp))), true
+}
@@ -0,0 +1,305 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// cborTestSIDs returns the SIDs of the schema nodes of encodingtest.Schema,
+// which are assigned by encodingtest.SIDFiles.
+func cborTestSIDs(t *testing.T) *ygot.SIDMap {
+	var files []*ygot.SIDFile
+	for _, d := range encodingtest.SIDFiles {
+		f, err := ygot.ParseSIDFile([]byte(d))
+		if err != nil {
+			t.Fatalf("ParseSIDFile: got unexpected error: %v", err)
+		}
+		files = append(files, f)
+	}
+	m, err := ygot.NewSIDMap(files...)
+	if err != nil {
+		t.Fatalf("NewSIDMap: got unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               interface{}
+		inSIDs           bool
+		inParent         *xmlDevice
+		inOpts           []UnmarshalOpt
+		want             *xmlDevice
+		wantErrSubstring string
+	}{{
+		desc: "leaves with names",
+		in: util.CBORMap{{Key: "dev:system", Value: util.CBORMap{
+			{Key: "config", Value: util.CBORMap{
+				{Key: "hostname", Value: "rtr1"},
+				{Key: "dns", Value: []interface{}{"192.0.2.1", "192.0.2.2"}},
+				{Key: "mtu", Value: uint64(1500)},
+				{Key: "counter", Value: uint64(18446744073709551615)},
+				{Key: "enabled", Value: true},
+				{Key: "flag", Value: nil},
+				{Key: "key-data", Value: []byte("abc")},
+				{Key: "ident", Value: "foo:E_VALUE_FORTY_TWO"},
+			}},
+			{Key: "logging", Value: util.CBORMap{}},
+		}}},
+		want: &xmlDevice{
+			Hostname: ygot.String("rtr1"),
+			DNS:      []string{"192.0.2.1", "192.0.2.2"},
+			Mtu:      ygot.Uint16(1500),
+			Counter:  ygot.Uint64(18446744073709551615),
+			Enabled:  ygot.Bool(true),
+			Flag:     true,
+			KeyData:  Binary("abc"),
+			Ident:    42,
+			Logging:  &xmlLogging{},
+		},
+	}, {
+		desc: "lists with names",
+		in: util.CBORMap{
+			{Key: "interfaces", Value: util.CBORMap{{Key: "interface", Value: []interface{}{
+				util.CBORMap{{Key: "name", Value: "eth0"}, {Key: "config", Value: util.CBORMap{{Key: "name", Value: "eth0"}, {Key: "mtu", Value: uint64(9000)}}}},
+			}}}},
+			{Key: "servers", Value: util.CBORMap{{Key: "server", Value: []interface{}{
+				util.CBORMap{{Key: "address", Value: "192.0.2.1"}},
+			}}}},
+		},
+		inParent: &xmlDevice{Hostname: ygot.String("rtr1")},
+		want: &xmlDevice{
+			Hostname:  ygot.String("rtr1"),
+			Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(9000)}},
+			Server:    []*xmlServer{{Address: ygot.String("192.0.2.1")}},
+		},
+	}, {
+		desc: "SIDs",
+		in: util.CBORMap{
+			{Key: uint64(1000), Value: util.CBORMap{{Key: uint64(1), Value: util.CBORMap{
+				{Key: uint64(1), Value: "rtr1"},
+				{Key: int64(-2), Value: uint64(3001)},
+				{Key: uint64(4), Value: []interface{}{"192.0.2.1"}},
+			}}}},
+			{Key: uint64(1010), Value: util.CBORMap{{Key: uint64(1), Value: []interface{}{
+				util.CBORMap{{Key: uint64(1), Value: "eth0"}, {Key: uint64(2), Value: util.CBORMap{{Key: uint64(1), Value: "eth0"}, {Key: uint64(2), Value: uint64(9000)}}}},
+			}}}},
+		},
+		inSIDs: true,
+		want: &xmlDevice{
+			Hostname:  ygot.String("rtr1"),
+			Ident:     42,
+			DNS:       []string{"192.0.2.1"},
+			Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(9000)}},
+		},
+	}, {
+		desc:             "negative SID delta",
+		in:               util.CBORMap{{Key: uint64(1010), Value: util.CBORMap{{Key: int64(-10), Value: util.CBORMap{}}}}},
+		inSIDs:           true,
+		wantErrSubstring: "system is not a valid child of interfaces",
+	}, {
+		desc:             "unknown SID",
+		in:               util.CBORMap{{Key: uint64(1000), Value: util.CBORMap{{Key: uint64(50), Value: "x"}}}},
+		inSIDs:           true,
+		wantErrSubstring: "unknown SID 1050",
+	}, {
+		desc:             "SID without SID map",
+		in:               util.CBORMap{{Key: uint64(1000), Value: util.CBORMap{}}},
+		wantErrSubstring: "must be a string when SIDs are not specified",
+	}, {
+		desc:             "unknown name",
+		in:               util.CBORMap{{Key: "system", Value: util.CBORMap{{Key: "clock", Value: util.CBORMap{}}}}},
+		wantErrSubstring: "clock is not a valid child of system",
+	}, {
+		desc:   "unknown name ignored",
+		in:     util.CBORMap{{Key: "system", Value: util.CBORMap{{Key: "clock", Value: util.CBORMap{}}, {Key: "logging", Value: util.CBORMap{}}}}},
+		inOpts: []UnmarshalOpt{&IgnoreExtraFields{}},
+		want:   &xmlDevice{Logging: &xmlLogging{}},
+	}, {
+		desc:             "invalid leaf value",
+		in:               util.CBORMap{{Key: "system", Value: util.CBORMap{{Key: "config", Value: util.CBORMap{{Key: "mtu", Value: "jumbo"}}}}}},
+		wantErrSubstring: "invalid uint16 value jumbo for leaf mtu",
+	}, {
+		desc:             "invalid container value",
+		in:               util.CBORMap{{Key: "system", Value: "up"}},
+		wantErrSubstring: "value of container system is string, must be a map",
+	}, {
+		desc:             "not a map",
+		in:               []interface{}{uint64(1)},
+		wantErrSubstring: "must be a map",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			in, err := util.MarshalCBOR(tt.in)
+			if err != nil {
+				t.Fatalf("MarshalCBOR(%v): got unexpected error: %v", tt.in, err)
+			}
+			var sids *ygot.SIDMap
+			if tt.inSIDs {
+				sids = cborTestSIDs(t)
+			}
+			got := tt.inParent
+			if got == nil {
+				got = &xmlDevice{}
+			}
+
+			err = UnmarshalCBOR(encodingtest.Schema(), got, in, sids, tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("UnmarshalCBOR: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("UnmarshalCBOR: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalCBORConstructCBOR(t *testing.T) {
+	in := &xmlDevice{
+		Hostname:  ygot.String("rtr1"),
+		DNS:       []string{"192.0.2.1"},
+		Counter:   ygot.Uint64(42),
+		Flag:      true,
+		KeyData:   Binary("abc"),
+		Logging:   &xmlLogging{Level: ygot.String("info")},
+		Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(9000)}},
+		Server:    []*xmlServer{{Address: ygot.String("192.0.2.1"), Port: ygot.Uint16(53)}},
+	}
+	b, err := ygot.ConstructCBOR(in, nil)
+	if err != nil {
+		t.Fatalf("ConstructCBOR: got unexpected error: %v", err)
+	}
+	got := &xmlDevice{}
+	if err := UnmarshalCBOR(encodingtest.Schema(), got, b, nil); err != nil {
+		t.Fatalf("UnmarshalCBOR: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(in, got); diff != "" {
+		t.Errorf("UnmarshalCBOR: did not get ConstructCBOR input, diff(-want, +got):\n%s", diff)
+	}
+}
+
+func TestCBORLeafValue(t *testing.T) {
+	enum := yang.NewEnumType()
+	enum.Set("UP", 1)
+	enum.Set("DOWN", -1)
+	bits := yang.NewBitfield()
+	bits.Set("A", 0)
+	bits.Set("B", 9)
+	union := &yang.YangType{
+		Kind: yang.Yunion,
+		Type: []*yang.YangType{{Kind: yang.Yint8}, {Kind: yang.Yenum, Enum: enum}, {Kind: yang.Ystring}},
+	}
+
+	tests := []struct {
+		desc   string
+		inType *yang.YangType
+		in     interface{}
+		want   interface{}
+		wantOK bool
+	}{{
+		desc:   "negative int32",
+		inType: &yang.YangType{Kind: yang.Yint32},
+		in:     int64(-42),
+		want:   float64(-42),
+		wantOK: true,
+	}, {
+		desc:   "negative uint32",
+		inType: &yang.YangType{Kind: yang.Yuint32},
+		in:     int64(-42),
+	}, {
+		desc:   "int64",
+		inType: &yang.YangType{Kind: yang.Yint64},
+		in:     int64(-42),
+		want:   "-42",
+		wantOK: true,
+	}, {
+		desc:   "decimal64",
+		inType: &yang.YangType{Kind: yang.Ydecimal64},
+		in:     util.CBORTag{Number: ygot.CBORTagDecimalFraction, Content: []interface{}{int64(-2), uint64(27315)}},
+		want:   "273.15",
+		wantOK: true,
+	}, {
+		desc:   "decimal64 with positive exponent",
+		inType: &yang.YangType{Kind: yang.Ydecimal64},
+		in:     util.CBORTag{Number: ygot.CBORTagDecimalFraction, Content: []interface{}{uint64(2), uint64(1)}},
+	}, {
+		desc:   "enumeration value",
+		inType: &yang.YangType{Kind: yang.Yenum, Enum: enum},
+		in:     int64(-1),
+		want:   "DOWN",
+		wantOK: true,
+	}, {
+		desc:   "unknown enumeration value",
+		inType: &yang.YangType{Kind: yang.Yenum, Enum: enum},
+		in:     uint64(2),
+	}, {
+		desc:   "bits bitmap",
+		inType: &yang.YangType{Kind: yang.Ybits, Bit: bits},
+		in:     []byte{0x01, 0x02},
+		want:   "A B",
+		wantOK: true,
+	}, {
+		desc:   "bits name",
+		inType: &yang.YangType{Kind: yang.Ybits, Bit: bits},
+		in:     util.CBORTag{Number: ygot.CBORTagBits, Content: "B"},
+		want:   "B",
+		wantOK: true,
+	}, {
+		desc:   "identityref without SIDs",
+		inType: &yang.YangType{Kind: yang.Yidentityref},
+		in:     uint64(50),
+	}, {
+		desc:   "union of int8",
+		inType: union,
+		in:     uint64(42),
+		want:   float64(42),
+		wantOK: true,
+	}, {
+		desc:   "union of enumeration",
+		inType: union,
+		in:     util.CBORTag{Number: ygot.CBORTagEnumeration, Content: "UP"},
+		want:   "UP",
+		wantOK: true,
+	}, {
+		desc:   "union of string",
+		inType: union,
+		in:     "UP",
+		want:   "UP",
+		wantOK: true,
+	}}
+
+	for _, tt := range tests {
+		got, ok := (&cborDecoder{}).leafValue(tt.inType, tt.in)
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: leafValue(%v, %v): did not get expected value, diff(-want, +got):\n%s", tt.desc, tt.inType, tt.in, diff)
+		}
+		if ok != tt.wantOK {
+			t.Errorf("%s: leafValue(%v, %v): got ok: %v, want: %v", tt.desc, tt.inType, tt.in, ok, tt.wantOK)
+		}
+	}
+}
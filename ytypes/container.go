@@ -68,7 +68,7 @@ func validateContainer(schema *yang.Entry, value ygot.GoStruct) util.Errors {
 				continue
 			case cschema != nil:
 				// Regular named child.
-				if errs := Validate(cschema, fieldValue); errs != nil {
+				if errs := validate(cschema, fieldValue); errs != nil {
 					errors = util.AppendErrs(errors, util.PrefixErrors(errs, cschema.Path()))
 				}
 			case !util.IsValueNilOrDefault(structElems.Field(i).Interface()):
@@ -152,9 +152,24 @@ func unmarshalStruct(schema *yang.Entry, parent interface{}, jsonTree map[string
 		f := destv.Field(i)
 		ft := destv.Type().Field(i)
 
-		// Skip annotation fields since they do not have a schema.
-		// TODO(robjs): Implement unmarshalling annotations.
+		// Annotation fields do not have a schema, the RFC7952 metadata
+		// that they store is found at their paths relative to the struct.
 		if util.IsYgotAnnotation(ft) {
+			jsonValue, err := getJSONTreeValForField(schema, schema, ft, jsonTree)
+			if err != nil {
+				return err
+			}
+			sp, err := dataTreePaths(schema, schema, ft)
+			if err != nil {
+				return err
+			}
+			allSchemaPaths = append(allSchemaPaths, sp...)
+			if jsonValue == nil {
+				continue
+			}
+			if err := unmarshalAnnotations(f, jsonValue, opts); err != nil {
+				return fmt.Errorf("field %s: %v", ft.Name, err)
+			}
 			continue
 		}
 
@@ -229,7 +244,7 @@ func validateContainerSchema(schema *yang.Entry) error {
 	if schema == nil {
 		return fmt.Errorf("container schema is nil")
 	}
-	if !schema.IsContainer() {
+	if !schema.IsContainer() && !util.IsOperationContainer(schema) {
 		return fmt.Errorf("container schema %s is not a container type", schema.Name)
 	}
 
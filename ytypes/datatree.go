@@ -0,0 +1,573 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// DataNode is a node within a data tree that is described directly by a
+// YANG schema, such that data can be handled without generated GoStructs.
+// Each container, list entry, leaf and leaf-list element is a separate
+// DataNode. Choice and case schema nodes do not have a DataNode, the data
+// nodes within them are children of the DataNode of the enclosing container
+// or list entry. The value of a leaf or leaf-list element is the canonical Go
+// value of its YANG type, as described in datatree_value.go.
+//
+// A DataNode tree is created with NewDataTree, and is modified only through
+// its methods, such that every value within the tree is valid for its type.
+type DataNode struct {
+	// schema is the schema of the data node.
+	schema *yang.Entry
+	// parent is the parent of the node, which is nil for the root of the
+	// data tree.
+	parent *DataNode
+	// children are the children of a container or list entry, in document
+	// order.
+	children []*DataNode
+	// value is the value of a leaf or leaf-list element.
+	value interface{}
+}
+
+// NewDataTree returns an empty data tree described by schema, which must be
+// a container schema, such as the root of a schema tree.
+func NewDataTree(schema *yang.Entry) (*DataNode, error) {
+	if err := validateContainerSchema(schema); err != nil {
+		return nil, err
+	}
+	return &DataNode{schema: schema}, nil
+}
+
+// Schema returns the schema of the data node n.
+func (n *DataNode) Schema() *yang.Entry { return n.schema }
+
+// Parent returns the parent of the data node n, which is nil if n is the root
+// of its data tree.
+func (n *DataNode) Parent() *DataNode { return n.parent }
+
+// Children returns the children of the container or list entry n, in document
+// order.
+func (n *DataNode) Children() []*DataNode {
+	return append([]*DataNode{}, n.children...)
+}
+
+// Value returns the value of the leaf or leaf-list element n, which is nil
+// for other data nodes.
+func (n *DataNode) Value() interface{} { return n.value }
+
+// Path returns the gNMI path of the data node n, relative to the root of its
+// data tree. The path of a list entry includes its keys, whereas the path of
+// a leaf-list element is the path of the leaf-list.
+func (n *DataNode) Path() *gpb.Path {
+	p := &gpb.Path{}
+	for c := n; c.parent != nil; c = c.parent {
+		e := &gpb.PathElem{Name: c.schema.Name}
+		if util.IsKeyedList(c.schema) {
+			e.Key = c.keys()
+		}
+		p.Elem = append([]*gpb.PathElem{e}, p.Elem...)
+	}
+	return p
+}
+
+// isLeaf returns true if n is a leaf or a leaf-list element.
+func (n *DataNode) isLeaf() bool {
+	return n.schema.IsLeaf() || n.schema.IsLeafList()
+}
+
+// keys returns the string values of the keys of the list entry n, keyed by
+// the name of the key leaf. Keys that are not populated are omitted.
+func (n *DataNode) keys() map[string]string {
+	keys := map[string]string{}
+	for _, k := range strings.Fields(n.schema.Key) {
+		if c := n.child(k); c != nil {
+			keys[k] = dataValueString(c.value)
+		}
+	}
+	return keys
+}
+
+// child returns the first child of n with the supplied schema name, or nil if
+// there is none.
+func (n *DataNode) child(name string) *DataNode {
+	for _, c := range n.children {
+		if c.schema.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// childrenWithSchema returns the children of n that are described by schema.
+func (n *DataNode) childrenWithSchema(schema *yang.Entry) []*DataNode {
+	var out []*DataNode
+	for _, c := range n.children {
+		if c.schema == schema {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// addChild appends a new child described by schema to n, and returns it.
+func (n *DataNode) addChild(schema *yang.Entry, v interface{}) *DataNode {
+	c := &DataNode{schema: schema, parent: n, value: v}
+	n.children = append(n.children, c)
+	return c
+}
+
+// removeChild removes the child c from n.
+func (n *DataNode) removeChild(c *DataNode) {
+	for i, e := range n.children {
+		if e == c {
+			n.children = append(n.children[:i], n.children[i+1:]...)
+			c.parent = nil
+			return
+		}
+	}
+}
+
+// childSchema returns the schema of the data node child of n with the
+// supplied name, which may be prefixed with a module name. Data nodes within
+// choice and case statements are children of n.
+func (n *DataNode) childSchema(name string) (*yang.Entry, error) {
+	if n.isLeaf() {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot traverse leaf %s to find child %s", n.schema.Name, name)
+	}
+	cs := util.FirstChild(n.schema, []string{util.StripModulePrefix(name)})
+	if cs == nil || util.IsChoiceOrCase(cs) {
+		return nil, status.Errorf(codes.InvalidArgument, "schema %s has no child %s", n.schema.Name, name)
+	}
+	return cs, nil
+}
+
+// hasSchemaPath returns true if the path elements elems identify a schema
+// node below the schema of n.
+func (n *DataNode) hasSchemaPath(elems []*gpb.PathElem) bool {
+	c := &DataNode{schema: n.schema}
+	for _, e := range elems {
+		cs, err := c.childSchema(e.GetName())
+		if err != nil {
+			return false
+		}
+		c = &DataNode{schema: cs}
+	}
+	return true
+}
+
+// listKeyValues converts the string values of the keys of the list entry
+// described by schema, which are keyed by the name of the key leaf, to the
+// canonical Go value of each key leaf, in the order of the keys of the list.
+func listKeyValues(schema *yang.Entry, keys map[string]string) ([]interface{}, error) {
+	names := strings.Fields(schema.Key)
+	if len(keys) != len(names) {
+		return nil, status.Errorf(codes.InvalidArgument, "got keys %v, which are not the keys %v of list %s", keys, names, schema.Name)
+	}
+	var vals []interface{}
+	for _, k := range names {
+		s, ok := keys[k]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "key %s of list %s is not specified", k, schema.Name)
+		}
+		ks, ok := schema.Dir[k]
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "schema for key %s of list %s does not exist", k, schema.Name)
+		}
+		v, _, err := convertDataLeaf(ks, s, stringLeafValue)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid key %s of list %s: %v", k, schema.Name, err)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// listEntry returns the entry of the list described by schema, a child of n,
+// whose keys have the values vals, or nil if there is none.
+func (n *DataNode) listEntry(schema *yang.Entry, vals []interface{}) *DataNode {
+	names := strings.Fields(schema.Key)
+	for _, c := range n.childrenWithSchema(schema) {
+		match := true
+		for i, k := range names {
+			kn := c.child(k)
+			if kn == nil || !reflect.DeepEqual(kn.value, vals[i]) {
+				match = false
+				break
+			}
+		}
+		if match {
+			return c
+		}
+	}
+	return nil
+}
+
+// addListEntry appends a new entry of the list described by schema to n, with
+// its keys set to vals.
+func (n *DataNode) addListEntry(schema *yang.Entry, vals []interface{}) *DataNode {
+	e := n.addChild(schema, nil)
+	for i, k := range strings.Fields(schema.Key) {
+		e.addChild(schema.Dir[k], vals[i])
+	}
+	return e
+}
+
+// matches returns true if the data node n matches the path element e, which
+// has the schema of n. The keys of list entries are compared with the keys of
+// e, where a key that is not specified in e matches only if partialKeyMatch
+// is set, and a key with the value "*" matches only if wildcards is set.
+func (n *DataNode) matches(e *gpb.PathElem, partialKeyMatch, wildcards bool) (bool, error) {
+	if !util.IsKeyedList(n.schema) {
+		if len(e.GetKey()) != 0 {
+			return false, status.Errorf(codes.InvalidArgument, "path element %v specifies keys for %s, which is not a keyed list", e, n.schema.Name)
+		}
+		return true, nil
+	}
+
+	names := strings.Fields(n.schema.Key)
+	for k := range e.GetKey() {
+		if !isListKey(n.schema, k) {
+			return false, status.Errorf(codes.InvalidArgument, "%s is not a key of list %s", k, n.schema.Name)
+		}
+	}
+	for _, k := range names {
+		pv, ok := e.GetKey()[k]
+		switch {
+		case !ok && !partialKeyMatch:
+			return false, status.Errorf(codes.InvalidArgument, "key %s of list %s is not specified in path element %v", k, n.schema.Name, e)
+		case !ok, wildcards && pv == "*":
+			continue
+		}
+		kn := n.child(k)
+		if kn == nil {
+			return false, nil
+		}
+		v, _, err := convertDataLeaf(kn.schema, pv, stringLeafValue)
+		if err != nil || !reflect.DeepEqual(v, kn.value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findNodes returns the descendants of n that match the path elements elems,
+// or n itself if elems is empty.
+func (n *DataNode) findNodes(elems []*gpb.PathElem, partialKeyMatch, wildcards bool) ([]*DataNode, error) {
+	if len(elems) == 0 {
+		return []*DataNode{n}, nil
+	}
+	cs, err := n.childSchema(elems[0].GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*DataNode
+	for _, c := range n.childrenWithSchema(cs) {
+		ok, err := c.matches(elems[0], partialKeyMatch, wildcards)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		nodes, err := c.findNodes(elems[1:], partialKeyMatch, wildcards)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, nodes...)
+	}
+	return matches, nil
+}
+
+// GetNode returns the data nodes below n that match the supplied path, which
+// is relative to n. The elements of a leaf-list are each returned as a
+// separate node. The supplied options specify whether list keys may be
+// omitted from, or be wildcards within, the path. An error with code
+// NotFound is returned if there are no matches for the path.
+func (n *DataNode) GetNode(path *gpb.Path, opts ...GetNodeOpt) ([]*DataNode, error) {
+	nodes, err := n.findNodes(path.GetElem(), hasPartialKeyMatch(opts), hasHandleWildcards(opts))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, status.Errorf(codes.NotFound, "could not find data nodes at path %v", path)
+	}
+	return nodes, nil
+}
+
+// SetNode sets the data node at the supplied path, which is relative to n, to
+// val. The value of a leaf is a gNMI TypedValue, or a Go value, and that of a
+// leaf-list is a TypedValue, or a slice of Go values, which replace the
+// existing elements of the leaf-list. The value of a container or list entry
+// is a JSON or JSON_IETF TypedValue, or a JSON object as decoded by
+// encoding/json, that is merged into the existing data of the node.
+//
+// The data node itself is created if it does not exist, but its ancestors
+// are created only if the InitMissingElements option is specified. The path
+// must specify each of the keys of the list entries within it.
+func (n *DataNode) SetNode(path *gpb.Path, val interface{}, opts ...SetNodeOpt) error {
+	return n.setNode(path.GetElem(), val, hasInitMissingElements(opts), nil)
+}
+
+// setNode implements SetNode, for the path elements elems. The supplied
+// UnmarshalOpts are used when merging JSON values.
+func (n *DataNode) setNode(elems []*gpb.PathElem, val interface{}, initMissing bool, opts []UnmarshalOpt) error {
+	if len(elems) == 0 {
+		if n.isLeaf() {
+			return status.Errorf(codes.InvalidArgument, "cannot set %s, use the path of the leaf relative to its parent", n.schema.Name)
+		}
+		jv, err := jsonObjectValue(n.schema, val)
+		if err != nil {
+			return err
+		}
+		if err := n.unmarshalJSON(jv, opts); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	}
+
+	e := elems[0]
+	cs, err := n.childSchema(e.GetName())
+	if err != nil {
+		return err
+	}
+	last := len(elems) == 1
+
+	switch {
+	case cs.IsLeaf():
+		if !last {
+			return status.Errorf(codes.InvalidArgument, "cannot traverse leaf %s to set %v", cs.Name, elems[1:])
+		}
+		v, _, err := convertDataLeaf(cs, val, dataLeafConverter(val))
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		if err := n.setLeaf(cs, v); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	case cs.IsLeafList():
+		if !last {
+			return status.Errorf(codes.InvalidArgument, "cannot traverse leaf-list %s to set %v", cs.Name, elems[1:])
+		}
+		vals, err := leafListValues(cs, val)
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		for _, c := range n.childrenWithSchema(cs) {
+			n.removeChild(c)
+		}
+		for _, v := range vals {
+			n.addChild(cs, v)
+		}
+		return nil
+	}
+
+	var c *DataNode
+	switch {
+	case util.IsUnkeyedList(cs):
+		return status.Errorf(codes.InvalidArgument, "entries of unkeyed list %s cannot be addressed by a path", cs.Name)
+	case cs.IsList():
+		kv, err := listKeyValues(cs, e.GetKey())
+		if err != nil {
+			return err
+		}
+		if c = n.listEntry(cs, kv); c == nil {
+			if !last && !initMissing {
+				return status.Errorf(codes.NotFound, "list %s has no entry with keys %v", cs.Name, e.GetKey())
+			}
+			c = n.addListEntry(cs, kv)
+		}
+	default:
+		if c = n.child(cs.Name); c == nil {
+			if !last && !initMissing {
+				return status.Errorf(codes.NotFound, "container %s does not exist", cs.Name)
+			}
+			c = n.addChild(cs, nil)
+		}
+	}
+	return c.setNode(elems[1:], val, initMissing, opts)
+}
+
+// dataLeafConverter returns the leafConverter for the value val, which is a
+// gNMI TypedValue or a Go value.
+func dataLeafConverter(val interface{}) leafConverter {
+	if _, ok := val.(*gpb.TypedValue); ok {
+		return typedLeafValue
+	}
+	return goLeafValue
+}
+
+// leafListValues converts val, which is a gNMI TypedValue, or a slice of Go
+// values, to the canonical Go values of the elements of the leaf-list
+// described by schema.
+func leafListValues(schema *yang.Entry, val interface{}) ([]interface{}, error) {
+	var in []interface{}
+	conv := goLeafValue
+	switch v := val.(type) {
+	case *gpb.TypedValue:
+		switch tv := v.GetValue().(type) {
+		case *gpb.TypedValue_LeaflistVal:
+			for _, e := range tv.LeaflistVal.GetElement() {
+				in = append(in, e)
+			}
+			conv = typedLeafValue
+		case *gpb.TypedValue_JsonVal, *gpb.TypedValue_JsonIetfVal:
+			jv, err := decodeJSONValue(v)
+			if err != nil {
+				return nil, err
+			}
+			a, ok := jv.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("JSON value %v has type %T, expect array for leaf-list %s", util.ValueStr(jv), jv, schema.Name)
+			}
+			in, conv = a, jsonLeafValue
+		default:
+			in, conv = []interface{}{v}, typedLeafValue
+		}
+	default:
+		rv := reflect.ValueOf(val)
+		if rv.Kind() != reflect.Slice || rv.Type().Elem().Kind() == reflect.Uint8 {
+			return nil, fmt.Errorf("value %v of type %T is not a slice for leaf-list %s", util.ValueStr(val), val, schema.Name)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			in = append(in, rv.Index(i).Interface())
+		}
+	}
+
+	var out []interface{}
+	for _, e := range in {
+		v, _, err := convertDataLeaf(schema, e, conv)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// jsonObjectValue returns the JSON object within val, the value of the
+// container or list entry described by schema, which is a JSON or JSON_IETF
+// TypedValue, or a JSON object as decoded by encoding/json.
+func jsonObjectValue(schema *yang.Entry, val interface{}) (map[string]interface{}, error) {
+	jv := val
+	if tv, ok := val.(*gpb.TypedValue); ok {
+		var err error
+		if jv, err = decodeJSONValue(tv); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "non-leaf schema %s cannot be set to %v: %v", schema.Name, tv, err)
+		}
+	}
+	m, ok := jv.(map[string]interface{})
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "non-leaf schema %s cannot be set to a value of type %T", schema.Name, jv)
+	}
+	return m, nil
+}
+
+// decodeJSONValue decodes the JSON or JSON_IETF value within tv.
+func decodeJSONValue(tv *gpb.TypedValue) (interface{}, error) {
+	var j []byte
+	switch v := tv.GetValue().(type) {
+	case *gpb.TypedValue_JsonVal:
+		j = v.JsonVal
+	case *gpb.TypedValue_JsonIetfVal:
+		j = v.JsonIetfVal
+	default:
+		return nil, fmt.Errorf("value of type %T is not a JSON value", v)
+	}
+	var jv interface{}
+	if err := json.Unmarshal(j, &jv); err != nil {
+		return nil, fmt.Errorf("cannot decode JSON value %s: %v", j, err)
+	}
+	return jv, nil
+}
+
+// setLeaf sets the value of the leaf child of n that is described by schema
+// to v. The value of a key of a list entry cannot be changed.
+func (n *DataNode) setLeaf(schema *yang.Entry, v interface{}) error {
+	c := n.child(schema.Name)
+	switch {
+	case c == nil:
+		n.addChild(schema, v)
+	case n.schema.IsList() && isListKey(n.schema, schema.Name) && !reflect.DeepEqual(c.value, v):
+		return fmt.Errorf("cannot change the value of key %s of list %s from %v to %v", schema.Name, n.schema.Name, dataValueString(c.value), dataValueString(v))
+	default:
+		c.value = v
+	}
+	return nil
+}
+
+// isListKey returns true if name is the name of a key of the list schema.
+func isListKey(schema *yang.Entry, name string) bool {
+	for _, k := range strings.Fields(schema.Key) {
+		if k == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteNode deletes the data nodes at the supplied path, which is relative to
+// n, and all of their descendants. A path to a keyed list that does not
+// specify all of its keys deletes each matching entry, and keys with the
+// value "*" match any value. Deleting a data node that does not exist is a
+// no-op. A path with no elements deletes all of the children of n.
+func (n *DataNode) DeleteNode(path *gpb.Path) error {
+	if len(path.GetElem()) == 0 {
+		for _, c := range n.Children() {
+			n.removeChild(c)
+		}
+		return nil
+	}
+	nodes, err := n.findNodes(path.GetElem(), true, true)
+	if err != nil {
+		return err
+	}
+	for _, d := range nodes {
+		d.parent.removeChild(d)
+	}
+	return nil
+}
+
+// dataNodeXPath implements util.XPathData for a DataNode, such that XPath
+// expressions can be evaluated against a DataNode tree.
+type dataNodeXPath struct {
+	n *DataNode
+}
+
+// XPathSchema implements the util.XPathData interface.
+func (d dataNodeXPath) XPathSchema() *yang.Entry { return d.n.schema }
+
+// XPathValue implements the util.XPathData interface.
+func (d dataNodeXPath) XPathValue() interface{} { return d.n.value }
+
+// XPathChildren implements the util.XPathData interface.
+func (d dataNodeXPath) XPathChildren() []util.XPathData {
+	var out []util.XPathData
+	for _, c := range d.n.children {
+		out = append(out, dataNodeXPath{c})
+	}
+	return out
+}
@@ -0,0 +1,408 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// Unmarshal merges the RFC7951 JSON object value, as decoded by encoding/json,
+// into the container or list entry n. Leaves are set to their value in the
+// JSON, entries of keyed lists are merged into the existing entry with the
+// same keys, and the elements of leaf-lists that do not already exist are
+// appended. Members of the JSON object that do not correspond to a data node
+// in the schema are an error, unless the IgnoreExtraFields option is
+// specified. RFC7952 metadata members are ignored.
+func (n *DataNode) Unmarshal(value interface{}, opts ...UnmarshalOpt) error {
+	if n.isLeaf() {
+		return fmt.Errorf("cannot unmarshal JSON into leaf %s", n.schema.Name)
+	}
+	if util.IsValueNil(value) {
+		return nil
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("unmarshal of schema %s: got JSON type %T, expect map[string]interface{}", n.schema.Name, value)
+	}
+	return n.unmarshalJSON(m, opts)
+}
+
+// unmarshalJSON merges the JSON object m into the container or list entry n.
+// The members of the object are merged in order of their names, such that
+// the resulting data tree is deterministic.
+func (n *DataNode) unmarshalJSON(m map[string]interface{}, opts []UnmarshalOpt) error {
+	var names []string
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		if strings.HasPrefix(k, "@") {
+			continue
+		}
+		cs, err := n.childSchema(k)
+		if err != nil {
+			if hasIgnoreExtraFields(opts) {
+				continue
+			}
+			return fmt.Errorf("parent container %s: JSON contains unexpected field %s", n.schema.Name, k)
+		}
+		if err := n.unmarshalJSONChild(cs, m[k], opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalJSONChild merges the JSON value v of the child of n described by
+// schema into n.
+func (n *DataNode) unmarshalJSONChild(schema *yang.Entry, v interface{}, opts []UnmarshalOpt) error {
+	if v == nil {
+		return nil
+	}
+	switch {
+	case schema.IsLeaf():
+		lv, _, err := convertDataLeaf(schema, v, jsonLeafValue)
+		if err != nil {
+			return err
+		}
+		return n.setLeaf(schema, lv)
+	case schema.IsLeafList():
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("JSON value %v has type %T, expect array for leaf-list %s", util.ValueStr(v), v, schema.Name)
+		}
+		existing := n.childrenWithSchema(schema)
+		for _, e := range a {
+			lv, _, err := convertDataLeaf(schema, e, jsonLeafValue)
+			if err != nil {
+				return err
+			}
+			if !hasLeafValue(existing, lv) {
+				existing = append(existing, n.addChild(schema, lv))
+			}
+		}
+		return nil
+	case schema.IsList():
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("JSON value %v has type %T, expect array for list %s", util.ValueStr(v), v, schema.Name)
+		}
+		for _, e := range a {
+			em, ok := e.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("JSON value %v has type %T, expect object for entry of list %s", util.ValueStr(e), e, schema.Name)
+			}
+			entry, err := n.jsonListEntry(schema, em)
+			if err != nil {
+				return err
+			}
+			if err := entry.unmarshalJSON(em, opts); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("JSON value %v has type %T, expect object for container %s", util.ValueStr(v), v, schema.Name)
+	}
+	c := n.child(schema.Name)
+	if c == nil {
+		c = n.addChild(schema, nil)
+	}
+	return c.unmarshalJSON(m, opts)
+}
+
+// hasLeafValue returns true if one of the leaf-list elements nodes has the
+// value v.
+func hasLeafValue(nodes []*DataNode, v interface{}) bool {
+	for _, c := range nodes {
+		if dataValueString(c.value) == dataValueString(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonListEntry returns the entry of the list described by schema, a child of
+// n, that the JSON object m is merged into. This is the existing entry with
+// the keys within m for a keyed list, which is created if it does not exist,
+// and a new entry for a keyless list.
+func (n *DataNode) jsonListEntry(schema *yang.Entry, m map[string]interface{}) (*DataNode, error) {
+	if util.IsUnkeyedList(schema) {
+		return n.addChild(schema, nil), nil
+	}
+
+	var vals []interface{}
+	for _, k := range strings.Fields(schema.Key) {
+		jv, ok := jsonMember(m, k)
+		if !ok {
+			return nil, fmt.Errorf("entry of list %s does not contain key %s: %v", schema.Name, k, util.ValueStr(m))
+		}
+		ks, ok := schema.Dir[k]
+		if !ok {
+			return nil, fmt.Errorf("schema for key %s of list %s does not exist", k, schema.Name)
+		}
+		v, _, err := convertDataLeaf(ks, jv, jsonLeafValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s of list %s: %v", k, schema.Name, err)
+		}
+		vals = append(vals, v)
+	}
+	if e := n.listEntry(schema, vals); e != nil {
+		return e, nil
+	}
+	return n.addListEntry(schema, vals), nil
+}
+
+// jsonMember returns the value of the member of the JSON object m with the
+// supplied name, whether or not it is qualified with a module name.
+func jsonMember(m map[string]interface{}, name string) (interface{}, bool) {
+	for k, v := range m {
+		if util.StripModulePrefix(k) == name {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// UnmarshalNotification applies the gNMI Notification notif to the data tree
+// rooted at n. The paths within the notification, which are joined with its
+// prefix, are relative to n. The deletes within the notification are applied
+// before its updates. Each update is applied as per SetNode, with the
+// InitMissingElements option, such that the value of a leaf-list replaces its
+// existing elements. Paths that do not correspond to a data node in the
+// schema are an error, unless the IgnoreExtraFields option is specified.
+func (n *DataNode) UnmarshalNotification(notif *gpb.Notification, opts ...UnmarshalOpt) error {
+	ignore := hasIgnoreExtraFields(opts)
+	for _, d := range notif.GetDelete() {
+		p, err := applyPath(notif.GetPrefix(), d)
+		if err != nil {
+			return err
+		}
+		if ignore && !n.hasSchemaPath(p.GetElem()) {
+			continue
+		}
+		if err := n.DeleteNode(p); err != nil {
+			return fmt.Errorf("cannot delete %v: %v", p, err)
+		}
+	}
+	for _, u := range notif.GetUpdate() {
+		p, err := applyPath(notif.GetPrefix(), u.GetPath())
+		if err != nil {
+			return err
+		}
+		if ignore && !n.hasSchemaPath(p.GetElem()) {
+			continue
+		}
+		if err := n.setNode(p.GetElem(), u.GetVal(), true, opts); err != nil {
+			return fmt.Errorf("cannot update %v: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// ConstructIETFJSON returns the RFC7951 JSON encoding of the container or list
+// entry n, as a map that can be marshalled by encoding/json. If the
+// AppendModuleName field of args is set, member names are qualified with
+// the name of their module where it differs from that of their parent, and
+// the children of the root of the schema tree are always qualified.
+// Non-presence containers that do not contain any data are omitted.
+func (n *DataNode) ConstructIETFJSON(args *ygot.RFC7951JSONConfig) (map[string]interface{}, error) {
+	if n.isLeaf() {
+		return nil, fmt.Errorf("cannot construct JSON for leaf %s", n.schema.Name)
+	}
+	appendMod := args != nil && args.AppendModuleName
+	var mod string
+	if appendMod && n.schema.Parent != nil {
+		var err error
+		if mod, err = util.EntryModule(n.schema); err != nil {
+			return nil, fmt.Errorf("cannot determine the module of %s: %v", n.schema.Name, err)
+		}
+	}
+	return n.jsonTree(appendMod, mod)
+}
+
+// jsonTree returns the RFC7951 JSON object for the container or list entry n,
+// which is defined by the module parentMod, which is used to determine the
+// member names to qualify if appendMod is set.
+func (n *DataNode) jsonTree(appendMod bool, parentMod string) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, c := range n.children {
+		name, mod := c.schema.Name, parentMod
+		if appendMod {
+			var err error
+			if mod, err = util.EntryModule(c.schema); err != nil {
+				return nil, fmt.Errorf("cannot determine the module of %s: %v", c.schema.Name, err)
+			}
+			if mod != parentMod {
+				name = fmt.Sprintf("%s:%s", mod, name)
+			}
+		}
+
+		switch {
+		case c.isLeaf():
+			_, s, err := convertDataLeaf(c.schema, c.value, goLeafValue)
+			if err != nil {
+				return nil, err
+			}
+			v, err := jsonDataValue(s, c.value)
+			if err != nil {
+				return nil, err
+			}
+			if c.schema.IsLeaf() {
+				out[name] = v
+				continue
+			}
+			a, _ := out[name].([]interface{})
+			out[name] = append(a, v)
+		case c.schema.IsList():
+			v, err := c.jsonTree(appendMod, mod)
+			if err != nil {
+				return nil, err
+			}
+			a, _ := out[name].([]interface{})
+			out[name] = append(a, v)
+		default:
+			v, err := c.jsonTree(appendMod, mod)
+			if err != nil {
+				return nil, err
+			}
+			if len(v) == 0 && !util.IsPresenceContainer(c.schema) {
+				continue
+			}
+			out[name] = v
+		}
+	}
+	return out, nil
+}
+
+// TogNMINotifications returns the gNMI Notifications that contain the data of
+// the tree rooted at n, with the supplied timestamp. The prefix of each
+// notification is the path of n, and it contains an update for each leaf
+// and leaf-list, in document order, with a path relative to n. Presence
+// containers that do not contain any leaves cannot be represented.
+func (n *DataNode) TogNMINotifications(ts int64) ([]*gpb.Notification, error) {
+	notif := &gpb.Notification{Timestamp: ts, Prefix: n.Path()}
+	var errs util.Errors
+	var walk func(c *DataNode, p *gpb.Path)
+	walk = func(c *DataNode, p *gpb.Path) {
+		var lists map[*yang.Entry]*gpb.Update
+		for _, cc := range c.children {
+			cp := appendElem(p, &gpb.PathElem{Name: cc.schema.Name})
+			if util.IsKeyedList(cc.schema) {
+				cp.Elem[len(cp.Elem)-1].Key = cc.keys()
+			}
+			switch {
+			case cc.schema.IsLeafList():
+				tv, err := typedDataValue(cc.value)
+				if err != nil {
+					errs = util.AppendErr(errs, fmt.Errorf("cannot encode value of %v: %v", cp, err))
+					continue
+				}
+				if lists == nil {
+					lists = map[*yang.Entry]*gpb.Update{}
+				}
+				u, ok := lists[cc.schema]
+				if !ok {
+					u = &gpb.Update{Path: cp, Val: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{&gpb.ScalarArray{}}}}
+					lists[cc.schema] = u
+					notif.Update = append(notif.Update, u)
+				}
+				arr := u.Val.GetLeaflistVal()
+				arr.Element = append(arr.Element, tv)
+			case cc.schema.IsLeaf():
+				tv, err := typedDataValue(cc.value)
+				if err != nil {
+					errs = util.AppendErr(errs, fmt.Errorf("cannot encode value of %v: %v", cp, err))
+					continue
+				}
+				notif.Update = append(notif.Update, &gpb.Update{Path: cp, Val: tv})
+			default:
+				walk(cc, cp)
+			}
+		}
+	}
+	walk(n, nil)
+	if errs != nil {
+		return nil, errs
+	}
+	return []*gpb.Notification{notif}, nil
+}
+
+// NewDataTreeFromGoStruct returns a data tree, described by schema, which
+// contains the data of the GoStruct s, which must also be described by
+// schema. This allows data held in generated GoStructs to be handled by
+// code that operates on DataNodes.
+func NewDataTreeFromGoStruct(schema *yang.Entry, s ygot.GoStruct) (*DataNode, error) {
+	n, err := NewDataTree(schema)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ygot.ConstructIETFJSON(s, &ygot.RFC7951JSONConfig{AppendModuleName: true})
+	if err != nil {
+		return nil, err
+	}
+	jv, err := decodedJSON(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := n.Unmarshal(jv); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// ToGoStruct unmarshals the data of the container or list entry n into the
+// GoStruct s, which must be described by the schema of n. The supplied
+// options are used when unmarshalling into s.
+func (n *DataNode) ToGoStruct(s ygot.GoStruct, opts ...UnmarshalOpt) error {
+	m, err := n.ConstructIETFJSON(&ygot.RFC7951JSONConfig{AppendModuleName: true})
+	if err != nil {
+		return err
+	}
+	jv, err := decodedJSON(m)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(n.schema, s, jv, opts...)
+}
+
+// decodedJSON returns the JSON tree m, which may contain Go values of any
+// type that encoding/json can marshal, with each value replaced by the type
+// that encoding/json decodes it to, such as float64 for numbers.
+func decodedJSON(m map[string]interface{}) (interface{}, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal JSON: %v", err)
+	}
+	var jv interface{}
+	if err := json.Unmarshal(b, &jv); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal JSON: %v", err)
+	}
+	return jv, nil
+}
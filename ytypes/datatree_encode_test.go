@@ -0,0 +1,386 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// dataTreeDevice and its children are the uncompressed GoStructs for the
+// dataTreeTestSchema schema.
+type dataTreeDevice struct {
+	Interfaces *dataTreeInterfaces `path:"interfaces" module:"dev"`
+	System     *dataTreeSystem     `path:"system" module:"dev"`
+}
+
+func (*dataTreeDevice) IsYANGGoStruct()                         {}
+func (*dataTreeDevice) Validate(...ygot.ValidationOption) error { return nil }
+func (*dataTreeDevice) ΛEnumTypeMap() map[string][]reflect.Type { return nil }
+
+type dataTreeInterfaces struct {
+	Interface map[string]*dataTreeInterface `path:"interface" module:"dev"`
+}
+
+func (*dataTreeInterfaces) IsYANGGoStruct() {}
+
+type dataTreeInterface struct {
+	Name   *string                  `path:"name" module:"dev"`
+	Config *dataTreeInterfaceConfig `path:"config" module:"dev"`
+}
+
+func (*dataTreeInterface) IsYANGGoStruct() {}
+
+type dataTreeInterfaceConfig struct {
+	Name    *string `path:"name" module:"dev"`
+	Mtu     *uint16 `path:"mtu" module:"dev"`
+	Counter *uint64 `path:"counter" module:"ext"`
+}
+
+func (*dataTreeInterfaceConfig) IsYANGGoStruct() {}
+
+type dataTreeSystem struct {
+	Hostname *string  `path:"hostname" module:"dev"`
+	DNS      []string `path:"dns" module:"dev"`
+}
+
+func (*dataTreeSystem) IsYANGGoStruct() {}
+
+// mustJSON returns the value of the JSON document js as decoded by
+// encoding/json.
+func mustJSON(t *testing.T, js string) interface{} {
+	t.Helper()
+	var jv interface{}
+	if err := json.Unmarshal([]byte(js), &jv); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	return jv
+}
+
+func TestDataNodeUnmarshal(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inJSON           string
+		inOpts           []UnmarshalOpt
+		want             string
+		wantErrSubstring string
+	}{{
+		desc:   "merge into existing entries",
+		inJSON: `{"interfaces": {"interface": [{"name": "eth1", "config": {"mtu": 9000}}]}, "system": {"dns": ["192.0.2.2", "192.0.2.3"]}}`,
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+				{"name": "eth1", "config": {"name": "eth1", "mtu": 9000}}
+			]},
+			"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2", "192.0.2.3"]}
+		}`,
+	}, {
+		desc:   "module qualified names and metadata",
+		inJSON: `{"dev:system": {"@hostname": {"ietf-origin:origin": "intended"}, "hostname": "rtr2", "mode": "STANDBY"}}`,
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+				{"name": "eth1", "config": {"name": "eth1"}}
+			]},
+			"system": {"hostname": "rtr2", "dns": ["192.0.2.1", "192.0.2.2"], "mode": "STANDBY"}
+		}`,
+	}, {
+		desc:             "unexpected field",
+		inJSON:           `{"system": {"location": "lab"}}`,
+		wantErrSubstring: "parent container system: JSON contains unexpected field location",
+	}, {
+		desc:   "unexpected field ignored",
+		inJSON: `{"system": {"location": "lab"}}`,
+		inOpts: []UnmarshalOpt{&IgnoreExtraFields{}},
+		want:   dataTreeTestJSON,
+	}, {
+		desc:             "list entry without key",
+		inJSON:           `{"interfaces": {"interface": [{"config": {"mtu": 9000}}]}}`,
+		wantErrSubstring: "entry of list interface does not contain key name",
+	}, {
+		desc:             "invalid leaf value",
+		inJSON:           `{"system": {"mode": "OFF"}}`,
+		wantErrSubstring: "OFF (string) is not a valid value for enumeration type mode",
+	}, {
+		desc:             "leaf with wrong JSON type",
+		inJSON:           `{"system": {"hostname": 1}}`,
+		wantErrSubstring: "JSON value 1 has type float64",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := newTestDataTree(t, dataTreeTestJSON)
+			err := n.Unmarshal(mustJSON(t, tt.inJSON), tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("Unmarshal(%s): did not get expected error, %s", tt.inJSON, diff)
+			}
+			if err != nil {
+				return
+			}
+			want := dataTreeJSON(t, newTestDataTree(t, tt.want))
+			if diff := cmp.Diff(want, dataTreeJSON(t, n)); diff != "" {
+				t.Errorf("Unmarshal(%s): did not get expected tree, diff(-want, +got):\n%s", tt.inJSON, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodeUnmarshalNotification(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inNotif          *gpb.Notification
+		inOpts           []UnmarshalOpt
+		want             string
+		wantErrSubstring string
+	}{{
+		desc: "updates and deletes with prefix",
+		inNotif: &gpb.Notification{
+			Prefix: mustPath("/interfaces/interface[name=eth0]"),
+			Delete: []*gpb.Path{mustPath("subinterface[index=0]")},
+			Update: []*gpb.Update{{
+				Path: mustPath("config/mtu"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{9000}},
+			}, {
+				Path: mustPath("subinterface[index=2]/vlan"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{20}},
+			}},
+		},
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0", "mtu": 9000}, "subinterface": [{"index": 1, "vlan": 10}, {"index": 2, "vlan": 20}]},
+				{"name": "eth1", "config": {"name": "eth1"}}
+			]},
+			"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2"]}
+		}`,
+	}, {
+		desc: "leaf-list replaces existing elements",
+		inNotif: &gpb.Notification{
+			Update: []*gpb.Update{{
+				Path: mustPath("/system/dns"),
+				Val: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{&gpb.ScalarArray{Element: []*gpb.TypedValue{
+					{Value: &gpb.TypedValue_StringVal{"198.51.100.1"}},
+				}}}},
+			}},
+		},
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+				{"name": "eth1", "config": {"name": "eth1"}}
+			]},
+			"system": {"hostname": "rtr1", "dns": ["198.51.100.1"]}
+		}`,
+	}, {
+		desc: "unknown path",
+		inNotif: &gpb.Notification{
+			Update: []*gpb.Update{{
+				Path: mustPath("/system/location"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"lab"}},
+			}},
+		},
+		wantErrSubstring: "schema system has no child location",
+	}, {
+		desc: "unknown path ignored",
+		inNotif: &gpb.Notification{
+			Delete: []*gpb.Path{mustPath("/system/location")},
+			Update: []*gpb.Update{{
+				Path: mustPath("/system/location"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"lab"}},
+			}},
+		},
+		inOpts: []UnmarshalOpt{&IgnoreExtraFields{}},
+		want:   dataTreeTestJSON,
+	}, {
+		desc: "invalid value",
+		inNotif: &gpb.Notification{
+			Update: []*gpb.Update{{
+				Path: mustPath("/interfaces/interface[name=eth0]/config/mtu"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{-1}},
+			}},
+		},
+		wantErrSubstring: "value -1 is outside the range of type uint16",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := newTestDataTree(t, dataTreeTestJSON)
+			err := n.UnmarshalNotification(tt.inNotif, tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("UnmarshalNotification(%v): did not get expected error, %s", tt.inNotif, diff)
+			}
+			if err != nil {
+				return
+			}
+			want := dataTreeJSON(t, newTestDataTree(t, tt.want))
+			if diff := cmp.Diff(want, dataTreeJSON(t, n)); diff != "" {
+				t.Errorf("UnmarshalNotification(%v): did not get expected tree, diff(-want, +got):\n%s", tt.inNotif, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodeConstructIETFJSON(t *testing.T) {
+	n := newTestDataTree(t, `{
+		"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "type": "ext:TUNNEL", "speed": "2.5", "counter": "42"}}]},
+		"system": {"hostname": "rtr1", "debug": [null]}
+	}`)
+
+	tests := []struct {
+		desc   string
+		inNode *DataNode
+		inArgs *ygot.RFC7951JSONConfig
+		want   string
+	}{{
+		desc:   "without module names",
+		inNode: n,
+		want: `{
+			"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "type": "ext:TUNNEL", "speed": "2.50", "counter": "42"}}]},
+			"system": {"hostname": "rtr1", "debug": [null]}
+		}`,
+	}, {
+		desc:   "with module names",
+		inNode: n,
+		inArgs: &ygot.RFC7951JSONConfig{AppendModuleName: true},
+		want: `{
+			"dev:interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "type": "ext:TUNNEL", "speed": "2.50", "ext:counter": "42"}}]},
+			"dev:system": {"hostname": "rtr1", "debug": [null]}
+		}`,
+	}, {
+		desc:   "list entry",
+		inNode: n.child("interfaces").child("interface"),
+		inArgs: &ygot.RFC7951JSONConfig{AppendModuleName: true},
+		want:   `{"name": "eth0", "config": {"name": "eth0", "type": "ext:TUNNEL", "speed": "2.50", "ext:counter": "42"}}`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.inNode.ConstructIETFJSON(tt.inArgs)
+			if err != nil {
+				t.Fatalf("ConstructIETFJSON(%v): got unexpected error: %v", tt.inArgs, err)
+			}
+			gotJSON, err := decodedJSON(got)
+			if err != nil {
+				t.Fatalf("ConstructIETFJSON(%v): cannot decode JSON: %v", tt.inArgs, err)
+			}
+			if diff := cmp.Diff(mustJSON(t, tt.want), gotJSON); diff != "" {
+				t.Errorf("ConstructIETFJSON(%v): did not get expected JSON, diff(-want, +got):\n%s", tt.inArgs, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodeTogNMINotifications(t *testing.T) {
+	n := newTestDataTree(t, dataTreeTestJSON)
+
+	tests := []struct {
+		desc   string
+		inNode *DataNode
+		want   *gpb.Notification
+	}{{
+		desc:   "container",
+		inNode: n.child("system"),
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    mustPath("/system"),
+			Update: []*gpb.Update{{
+				Path: mustPath("dns"),
+				Val: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{&gpb.ScalarArray{Element: []*gpb.TypedValue{
+					{Value: &gpb.TypedValue_StringVal{"192.0.2.1"}},
+					{Value: &gpb.TypedValue_StringVal{"192.0.2.2"}},
+				}}}},
+			}, {
+				Path: mustPath("hostname"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"rtr1"}},
+			}},
+		},
+	}, {
+		desc:   "list entry",
+		inNode: n.child("interfaces").child("interface"),
+		want: &gpb.Notification{
+			Timestamp: 42,
+			Prefix:    mustPath("/interfaces/interface[name=eth0]"),
+			Update: []*gpb.Update{{
+				Path: mustPath("name"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"eth0"}},
+			}, {
+				Path: mustPath("config/mtu"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{1500}},
+			}, {
+				Path: mustPath("config/name"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"eth0"}},
+			}, {
+				Path: mustPath("subinterface[index=0]/index"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{0}},
+			}, {
+				Path: mustPath("subinterface[index=1]/index"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{1}},
+			}, {
+				Path: mustPath("subinterface[index=1]/vlan"),
+				Val:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{10}},
+			}},
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := tt.inNode.TogNMINotifications(42)
+			if err != nil {
+				t.Fatalf("TogNMINotifications: got unexpected error: %v", err)
+			}
+			if len(got) != 1 {
+				t.Fatalf("TogNMINotifications: got %d notifications, want 1", len(got))
+			}
+			if !proto.Equal(got[0], tt.want) {
+				t.Errorf("TogNMINotifications: did not get expected notification, got: %s, want: %s", proto.MarshalTextString(got[0]), proto.MarshalTextString(tt.want))
+			}
+		})
+	}
+}
+
+func TestDataTreeGoStruct(t *testing.T) {
+	in := &dataTreeDevice{
+		Interfaces: &dataTreeInterfaces{Interface: map[string]*dataTreeInterface{
+			"eth0": {Name: ygot.String("eth0"), Config: &dataTreeInterfaceConfig{Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500), Counter: ygot.Uint64(42)}},
+		}},
+		System: &dataTreeSystem{Hostname: ygot.String("rtr1"), DNS: []string{"192.0.2.1"}},
+	}
+
+	n, err := NewDataTreeFromGoStruct(dataTreeTestSchema, in)
+	if err != nil {
+		t.Fatalf("NewDataTreeFromGoStruct: got unexpected error: %v", err)
+	}
+	want := newTestDataTree(t, `{
+		"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 1500, "counter": "42"}}]},
+		"system": {"hostname": "rtr1", "dns": ["192.0.2.1"]}
+	}`)
+	if diff := cmp.Diff(dataTreeJSON(t, want), dataTreeJSON(t, n)); diff != "" {
+		t.Errorf("NewDataTreeFromGoStruct: did not get expected tree, diff(-want, +got):\n%s", diff)
+	}
+
+	got := &dataTreeDevice{}
+	if err := n.ToGoStruct(got); err != nil {
+		t.Fatalf("ToGoStruct: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(in, got); diff != "" {
+		t.Errorf("ToGoStruct: did not get expected GoStruct, diff(-want, +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,560 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// dataTreeTestSchema is the schema of the dev module, which is augmented by
+// the ext module, that is used to test DataNode trees. As per a generated
+// schema, the entries have no AST node, and the modules are found from the
+// prefixes annotation of the root. The hostname leaf must be alphanumeric,
+// and the vlans of the subinterfaces of an interface must be unique.
+var dataTreeTestSchema = &yang.Entry{
+	Name:       "device",
+	Kind:       yang.DirectoryEntry,
+	Annotation: map[string]interface{}{util.ModulePrefixesAnnotation: map[string]string{"dev": "dev", "ext": "ext"}},
+	Dir: map[string]*yang.Entry{
+		"interfaces": {
+			Name:   "interfaces",
+			Kind:   yang.DirectoryEntry,
+			Prefix: &yang.Value{Name: "dev"},
+			Dir: map[string]*yang.Entry{
+				"interface": {
+					Name:     "interface",
+					Kind:     yang.DirectoryEntry,
+					Prefix:   &yang.Value{Name: "dev"},
+					ListAttr: &yang.ListAttr{},
+					Key:      "name",
+					Dir: map[string]*yang.Entry{
+						"name": {
+							Name:   "name",
+							Kind:   yang.LeafEntry,
+							Prefix: &yang.Value{Name: "dev"},
+							Type:   &yang.YangType{Kind: yang.Yleafref, Path: "../config/name"},
+						},
+						"config": {
+							Name:   "config",
+							Kind:   yang.DirectoryEntry,
+							Prefix: &yang.Value{Name: "dev"},
+							Dir: map[string]*yang.Entry{
+								"name": {
+									Name:   "name",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Ystring},
+								},
+								"mtu": {
+									Name:   "mtu",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Yuint16},
+								},
+								"type": {
+									Name:   "type",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Name: "identityref", Kind: yang.Yidentityref},
+								},
+								"speed": {
+									Name:   "speed",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2},
+								},
+								"counter": {
+									Name:   "counter",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "ext"},
+									Type:   &yang.YangType{Kind: yang.Yuint64},
+								},
+							},
+						},
+						"subinterface": {
+							Name:     "subinterface",
+							Kind:     yang.DirectoryEntry,
+							Prefix:   &yang.Value{Name: "dev"},
+							ListAttr: &yang.ListAttr{},
+							Key:      "index",
+							Extra: map[string][]interface{}{
+								"unique": {&yang.Value{Name: "vlan"}},
+							},
+							Dir: map[string]*yang.Entry{
+								"index": {
+									Name:   "index",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Yuint32},
+								},
+								"vlan": {
+									Name:   "vlan",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Yuint16},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"system": {
+			Name:   "system",
+			Kind:   yang.DirectoryEntry,
+			Prefix: &yang.Value{Name: "dev"},
+			Dir: map[string]*yang.Entry{
+				"hostname": {
+					Name:      "hostname",
+					Kind:      yang.LeafEntry,
+					Prefix:    &yang.Value{Name: "dev"},
+					Mandatory: yang.TSTrue,
+					Type:      &yang.YangType{Kind: yang.Ystring},
+					Extra: map[string][]interface{}{
+						"must": {&yang.Must{Name: "re-match(., '[a-z0-9]+')"}},
+					},
+				},
+				"dns": {
+					Name:     "dns",
+					Kind:     yang.LeafEntry,
+					Prefix:   &yang.Value{Name: "dev"},
+					ListAttr: &yang.ListAttr{},
+					Type:     &yang.YangType{Kind: yang.Ystring},
+				},
+				"mode": {
+					Name:   "mode",
+					Kind:   yang.LeafEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Type:   &yang.YangType{Name: "mode", Kind: yang.Yenum},
+				},
+				"address": {
+					Name:   "address",
+					Kind:   yang.LeafEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Type: &yang.YangType{Kind: yang.Yunion, Type: []*yang.YangType{
+						{Kind: yang.Yuint16},
+						{Kind: yang.Ystring},
+					}},
+				},
+				"debug": {
+					Name:   "debug",
+					Kind:   yang.LeafEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Type:   &yang.YangType{Kind: yang.Yempty},
+				},
+				"key": {
+					Name:   "key",
+					Kind:   yang.LeafEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Type:   &yang.YangType{Kind: yang.Ybinary},
+				},
+				"uplink": {
+					Name:   "uplink",
+					Kind:   yang.LeafEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Type:   &yang.YangType{Kind: yang.Yleafref, Path: "/interfaces/interface/name"},
+				},
+				"transport": {
+					Name:   "transport",
+					Kind:   yang.ChoiceEntry,
+					Prefix: &yang.Value{Name: "dev"},
+					Dir: map[string]*yang.Entry{
+						"tcp": {
+							Name:   "tcp",
+							Kind:   yang.CaseEntry,
+							Prefix: &yang.Value{Name: "dev"},
+							Dir: map[string]*yang.Entry{
+								"port": {
+									Name:   "port",
+									Kind:   yang.LeafEntry,
+									Prefix: &yang.Value{Name: "dev"},
+									Type:   &yang.YangType{Kind: yang.Yuint16},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	},
+}
+
+func init() {
+	// The base of the type leaf is the IF_TYPE identity, which is defined by
+	// the dev module, and is the base of the TUNNEL identity of the ext
+	// module.
+	devMod := &yang.Module{Name: "dev"}
+	ifType := &yang.Identity{Name: "IF_TYPE", Parent: devMod}
+	ifType.Values = []*yang.Identity{
+		{Name: "ETHERNET", Parent: devMod},
+		{Name: "TUNNEL", Parent: &yang.Module{Name: "ext"}},
+	}
+	dataTreeTestSchema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["type"].Type.IdentityBase = ifType
+
+	mode := yang.NewEnumType()
+	mode.Set("ACTIVE", 0)
+	mode.Set("STANDBY", 1)
+	dataTreeTestSchema.Dir["system"].Dir["mode"].Type.Enum = mode
+
+	addParents(dataTreeTestSchema)
+}
+
+// newTestDataTree returns a DataNode tree of the dataTreeTestSchema schema that
+// contains the supplied RFC7951 JSON.
+func newTestDataTree(t *testing.T, js string) *DataNode {
+	t.Helper()
+	n, err := NewDataTree(dataTreeTestSchema)
+	if err != nil {
+		t.Fatalf("NewDataTree: got unexpected error: %v", err)
+	}
+	var jv interface{}
+	if err := json.Unmarshal([]byte(js), &jv); err != nil {
+		t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	if err := n.Unmarshal(jv); err != nil {
+		t.Fatalf("Unmarshal(%s): got unexpected error: %v", js, err)
+	}
+	return n
+}
+
+// dataTreeJSON returns the RFC7951 JSON of the DataNode n, without module
+// names.
+func dataTreeJSON(t *testing.T, n *DataNode) map[string]interface{} {
+	t.Helper()
+	m, err := n.ConstructIETFJSON(nil)
+	if err != nil {
+		t.Fatalf("ConstructIETFJSON: got unexpected error: %v", err)
+	}
+	return m
+}
+
+const dataTreeTestJSON = `{
+	"interfaces": {"interface": [
+		{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+		{"name": "eth1", "config": {"name": "eth1"}}
+	]},
+	"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2"]}
+}`
+
+func TestNewDataTree(t *testing.T) {
+	if _, err := NewDataTree(dataTreeTestSchema); err != nil {
+		t.Errorf("NewDataTree(device): got unexpected error: %v", err)
+	}
+	if _, err := NewDataTree(dataTreeTestSchema.Dir["interfaces"].Dir["interface"]); err == nil {
+		t.Errorf("NewDataTree(interface): did not get expected error for list schema")
+	}
+	if _, err := NewDataTree(nil); err == nil {
+		t.Errorf("NewDataTree(nil): did not get expected error for nil schema")
+	}
+}
+
+func TestDataNodeGetNode(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inPath           string
+		inOpts           []GetNodeOpt
+		wantPaths        []string
+		wantValues       []interface{}
+		wantErrSubstring string
+		wantCode         codes.Code
+	}{{
+		desc:       "leaf",
+		inPath:     "/interfaces/interface[name=eth0]/config/mtu",
+		wantPaths:  []string{"/interfaces/interface[name=eth0]/config/mtu"},
+		wantValues: []interface{}{uint16(1500)},
+	}, {
+		desc:       "leaf-list elements",
+		inPath:     "/system/dns",
+		wantPaths:  []string{"/system/dns", "/system/dns"},
+		wantValues: []interface{}{"192.0.2.1", "192.0.2.2"},
+	}, {
+		desc:       "module qualified path",
+		inPath:     "/dev:system/hostname",
+		wantPaths:  []string{"/system/hostname"},
+		wantValues: []interface{}{"rtr1"},
+	}, {
+		desc:       "list entry with integer key",
+		inPath:     "/interfaces/interface[name=eth0]/subinterface[index=1]/vlan",
+		wantPaths:  []string{"/interfaces/interface[name=eth0]/subinterface[index=1]/vlan"},
+		wantValues: []interface{}{uint16(10)},
+	}, {
+		desc:       "wildcard key",
+		inPath:     "/interfaces/interface[name=*]/config/name",
+		inOpts:     []GetNodeOpt{&GetHandleWildcards{}},
+		wantPaths:  []string{"/interfaces/interface[name=eth0]/config/name", "/interfaces/interface[name=eth1]/config/name"},
+		wantValues: []interface{}{"eth0", "eth1"},
+	}, {
+		desc:       "partial key match",
+		inPath:     "/interfaces/interface/name",
+		inOpts:     []GetNodeOpt{&GetPartialKeyMatch{}},
+		wantPaths:  []string{"/interfaces/interface[name=eth0]/name", "/interfaces/interface[name=eth1]/name"},
+		wantValues: []interface{}{"eth0", "eth1"},
+	}, {
+		desc:             "missing key without partial key match",
+		inPath:           "/interfaces/interface/name",
+		wantErrSubstring: "key name of list interface is not specified",
+		wantCode:         codes.InvalidArgument,
+	}, {
+		desc:             "no matching node",
+		inPath:           "/interfaces/interface[name=eth2]",
+		wantErrSubstring: "could not find data nodes",
+		wantCode:         codes.NotFound,
+	}, {
+		desc:             "unknown schema node",
+		inPath:           "/system/colour",
+		wantErrSubstring: "schema system has no child colour",
+		wantCode:         codes.InvalidArgument,
+	}, {
+		desc:             "key that is not a key of the list",
+		inPath:           "/interfaces/interface[mtu=1500]",
+		wantErrSubstring: "mtu is not a key of list interface",
+		wantCode:         codes.InvalidArgument,
+	}}
+
+	n := newTestDataTree(t, dataTreeTestJSON)
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := n.GetNode(mustPath(tt.inPath), tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("GetNode(%s): did not get expected error, %s", tt.inPath, diff)
+			}
+			if err != nil {
+				if status.Code(err) != tt.wantCode {
+					t.Errorf("GetNode(%s): got error code %v, want %v", tt.inPath, status.Code(err), tt.wantCode)
+				}
+				return
+			}
+			var gotPaths []string
+			var gotValues []interface{}
+			for _, d := range got {
+				p, err := ygot.PathToString(d.Path())
+				if err != nil {
+					t.Fatalf("PathToString(%v): got unexpected error: %v", d.Path(), err)
+				}
+				gotPaths = append(gotPaths, p)
+				gotValues = append(gotValues, d.Value())
+			}
+			if diff := cmp.Diff(tt.wantPaths, gotPaths); diff != "" {
+				t.Errorf("GetNode(%s): did not get expected paths, diff(-want, +got):\n%s", tt.inPath, diff)
+			}
+			if diff := cmp.Diff(tt.wantValues, gotValues); diff != "" {
+				t.Errorf("GetNode(%s): did not get expected values, diff(-want, +got):\n%s", tt.inPath, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodeSetNode(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inPath           string
+		inVal            interface{}
+		inOpts           []SetNodeOpt
+		want             string
+		wantErrSubstring string
+	}{{
+		desc:   "leaf from Go value",
+		inPath: "/system/hostname",
+		inVal:  "rtr2",
+		inOpts: []SetNodeOpt{&InitMissingElements{}},
+		want:   `{"system": {"hostname": "rtr2"}}`,
+	}, {
+		desc:   "integer leaf from Go value of another integer type",
+		inPath: "/interfaces/interface[name=eth0]/config/mtu",
+		inVal:  9000,
+		want:   `{"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 9000}}]}}`,
+	}, {
+		desc:   "leaf from TypedValue",
+		inPath: "/interfaces/interface[name=eth0]/config/mtu",
+		inVal:  &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{1400}},
+		want:   `{"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 1400}}]}}`,
+	}, {
+		desc:   "leaf-list from TypedValue",
+		inPath: "/system/dns",
+		inOpts: []SetNodeOpt{&InitMissingElements{}},
+		inVal: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{&gpb.ScalarArray{Element: []*gpb.TypedValue{
+			{Value: &gpb.TypedValue_StringVal{"192.0.2.3"}},
+		}}}},
+		want: `{"system": {"dns": ["192.0.2.3"]}}`,
+	}, {
+		desc:   "leaf-list from Go slice",
+		inPath: "/system/dns",
+		inVal:  []string{"192.0.2.4", "192.0.2.5"},
+		inOpts: []SetNodeOpt{&InitMissingElements{}},
+		want:   `{"system": {"dns": ["192.0.2.4", "192.0.2.5"]}}`,
+	}, {
+		desc:   "container from JSON TypedValue",
+		inPath: "/system",
+		inVal:  &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{[]byte(`{"dev:hostname": "rtr3", "dns": ["192.0.2.9"]}`)}},
+		want:   `{"system": {"hostname": "rtr3", "dns": ["192.0.2.9"]}}`,
+	}, {
+		desc:   "new list entry",
+		inPath: "/interfaces/interface[name=eth2]",
+		inVal:  map[string]interface{}{"config": map[string]interface{}{"name": "eth2"}},
+		want:   `{"interfaces": {"interface": [{"name": "eth2", "config": {"name": "eth2"}}]}}`,
+	}, {
+		desc:   "leaf with missing ancestors",
+		inPath: "/interfaces/interface[name=eth3]/config/mtu",
+		inVal:  uint16(1500),
+		inOpts: []SetNodeOpt{&InitMissingElements{}},
+		want:   `{"interfaces": {"interface": [{"name": "eth3", "config": {"mtu": 1500}}]}}`,
+	}, {
+		desc:             "leaf with missing ancestors without InitMissingElements",
+		inPath:           "/interfaces/interface[name=eth3]/config/mtu",
+		inVal:            uint16(1500),
+		wantErrSubstring: "list interface has no entry with keys map[name:eth3]",
+	}, {
+		desc:             "value out of range",
+		inPath:           "/interfaces/interface[name=eth0]/config/mtu",
+		inVal:            70000,
+		wantErrSubstring: "value 70000 is outside the range of type uint16",
+	}, {
+		desc:             "change key value",
+		inPath:           "/interfaces/interface[name=eth0]",
+		inVal:            map[string]interface{}{"name": "eth9"},
+		wantErrSubstring: "cannot change the value of key name of list interface from eth0 to eth9",
+	}, {
+		desc:             "container set to scalar",
+		inPath:           "/system",
+		inVal:            &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"x"}},
+		wantErrSubstring: "non-leaf schema system cannot be set",
+	}}
+
+	const initial = `{"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}}]}}`
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := newTestDataTree(t, initial)
+			err := n.SetNode(mustPath(tt.inPath), tt.inVal, tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("SetNode(%s, %v): did not get expected error, %s", tt.inPath, tt.inVal, diff)
+			}
+			if err != nil {
+				return
+			}
+			// The expected tree is the initial tree with the JSON of the
+			// change merged into it.
+			want := newTestDataTree(t, initial)
+			var jv interface{}
+			if err := json.Unmarshal([]byte(tt.want), &jv); err != nil {
+				t.Fatalf("json.Unmarshal(%s): got unexpected error: %v", tt.want, err)
+			}
+			if err := want.Unmarshal(jv); err != nil {
+				t.Fatalf("Unmarshal(%s): got unexpected error: %v", tt.want, err)
+			}
+			if diff := cmp.Diff(dataTreeJSON(t, want), dataTreeJSON(t, n)); diff != "" {
+				t.Errorf("SetNode(%s, %v): did not get expected tree, diff(-want, +got):\n%s", tt.inPath, tt.inVal, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodeDeleteNode(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inPath           string
+		want             string
+		wantErrSubstring string
+	}{{
+		desc:   "leaf",
+		inPath: "/interfaces/interface[name=eth0]/config/mtu",
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0"}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+				{"name": "eth1", "config": {"name": "eth1"}}
+			]},
+			"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2"]}
+		}`,
+	}, {
+		desc:   "list entry",
+		inPath: "/interfaces/interface[name=eth0]",
+		want: `{
+			"interfaces": {"interface": [{"name": "eth1", "config": {"name": "eth1"}}]},
+			"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2"]}
+		}`,
+	}, {
+		desc:   "all entries of list",
+		inPath: "/interfaces/interface",
+		want:   `{"system": {"hostname": "rtr1", "dns": ["192.0.2.1", "192.0.2.2"]}}`,
+	}, {
+		desc:   "leaf-list",
+		inPath: "/system/dns",
+		want: `{
+			"interfaces": {"interface": [
+				{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}, "subinterface": [{"index": 0}, {"index": 1, "vlan": 10}]},
+				{"name": "eth1", "config": {"name": "eth1"}}
+			]},
+			"system": {"hostname": "rtr1"}
+		}`,
+	}, {
+		desc:   "node that does not exist",
+		inPath: "/interfaces/interface[name=eth9]/config",
+		want:   dataTreeTestJSON,
+	}, {
+		desc:   "root",
+		inPath: "/",
+		want:   `{}`,
+	}, {
+		desc:             "unknown schema node",
+		inPath:           "/system/colour",
+		wantErrSubstring: "schema system has no child colour",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n := newTestDataTree(t, dataTreeTestJSON)
+			err := n.DeleteNode(mustPath(tt.inPath))
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("DeleteNode(%s): did not get expected error, %s", tt.inPath, diff)
+			}
+			if err != nil {
+				return
+			}
+			want := dataTreeJSON(t, newTestDataTree(t, tt.want))
+			if diff := cmp.Diff(want, dataTreeJSON(t, n)); diff != "" {
+				t.Errorf("DeleteNode(%s): did not get expected tree, diff(-want, +got):\n%s", tt.inPath, diff)
+			}
+		})
+	}
+}
+
+func TestDataNodePath(t *testing.T) {
+	n := newTestDataTree(t, dataTreeTestJSON)
+	nodes, err := n.GetNode(mustPath("/interfaces/interface[name=eth0]/subinterface[index=1]"))
+	if err != nil {
+		t.Fatalf("GetNode: got unexpected error: %v", err)
+	}
+	want := mustPath("/interfaces/interface[name=eth0]/subinterface[index=1]")
+	if got := nodes[0].Path(); !proto.Equal(got, want) {
+		t.Errorf("Path: got %v, want %v", got, want)
+	}
+	if got := n.Path(); len(got.GetElem()) != 0 {
+		t.Errorf("Path of root: got %v, want empty path", got)
+	}
+	if got, want := nodes[0].Parent().Schema().Name, "interface"; got != want {
+		t.Errorf("Parent: got schema %s, want %s", got, want)
+	}
+}
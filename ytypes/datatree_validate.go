@@ -0,0 +1,218 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Validate validates the data tree rooted at n against its schema, with the
+// same rules that Validate applies to a GoStruct. The value of each leaf,
+// the keys of each list entry, the unique statements and size of each list,
+// are checked, as modified by the supplied options. Leafrefs are checked only
+// if n is the root of its data tree, and must and when statements, and
+// mandatory nodes, are checked only if the EvaluateMustStatements,
+// EvaluateWhenStatements and EnforceMandatory options are specified.
+func (n *DataNode) Validate(opts ...ygot.ValidationOption) util.Errors {
+	var leafrefOpt *LeafrefOptions
+	for _, o := range opts {
+		if lo, ok := o.(*LeafrefOptions); ok {
+			leafrefOpt = lo
+		}
+	}
+
+	var errs util.Errors
+	if n.parent == nil {
+		// Leafref validation traverses the entire tree from the root.
+		errs = ValidateLeafRefData(n.schema, dataNodeXPath{n}, leafrefOpt)
+	}
+	if hasEvaluateMustStatements(opts) {
+		errs = util.AppendErrs(errs, validateMust(n.schema, dataNodeXPath{n}))
+	}
+	if hasEvaluateWhenStatements(opts) {
+		errs = util.AppendErrs(errs, validateWhen(n.schema, dataNodeXPath{n}))
+	}
+	if hasEnforceMandatory(opts) && !n.isLeaf() {
+		errs = util.AppendErrs(errs, validateDataNodeMandatory(n))
+	}
+	return util.AppendErrs(errs, n.validateData())
+}
+
+// pathString returns the string form of the path of n, for use in errors.
+func (n *DataNode) pathString() string {
+	s, err := ygot.PathToString(n.Path())
+	if err != nil {
+		return util.SchemaTreePathNoModule(n.schema)
+	}
+	return s
+}
+
+// validateData validates the value of each leaf within the tree rooted at n,
+// and checks that each list entry has all of its keys, and that the entries
+// of each list satisfy its unique statements.
+func (n *DataNode) validateData() util.Errors {
+	if n.isLeaf() {
+		if _, _, err := convertDataLeaf(n.schema, n.value, goLeafValue); err != nil {
+			return util.NewErrs(fmt.Errorf("%s: %v", n.pathString(), err))
+		}
+		return nil
+	}
+
+	var errs util.Errors
+	if util.IsKeyedList(n.schema) {
+		for _, k := range strings.Fields(n.schema.Key) {
+			if n.child(k) == nil {
+				errs = util.AppendErr(errs, fmt.Errorf("%s: list entry does not contain key %s", n.pathString(), k))
+			}
+		}
+	}
+
+	var lists []*yang.Entry
+	seen := map[*yang.Entry]bool{}
+	for _, c := range n.children {
+		errs = util.AppendErrs(errs, c.validateData())
+		if c.schema.IsList() && !seen[c.schema] {
+			seen[c.schema] = true
+			lists = append(lists, c.schema)
+		}
+	}
+	for _, l := range lists {
+		errs = util.AppendErrs(errs, validateListAttrPath(l, n.childrenWithSchema(l)))
+		errs = util.AppendErrs(errs, n.validateDataUnique(l))
+	}
+	return errs
+}
+
+// validateDataUnique checks that the entries of the list described by schema,
+// which are children of n, satisfy each of the unique statements of the list.
+func (n *DataNode) validateDataUnique(schema *yang.Entry) util.Errors {
+	uniques := util.SchemaXPathStatements(schema, "unique")
+	if len(uniques) == 0 {
+		return nil
+	}
+
+	var entries []*uniqueEntry
+	for i, e := range n.childrenWithSchema(schema) {
+		xn, err := util.NewXPathTree(schema, dataNodeXPath{e})
+		if err != nil {
+			return util.NewErrs(err)
+		}
+		name := fmt.Sprintf("at index %d", i)
+		if util.IsKeyedList(schema) {
+			keys := e.keys()
+			name = fmt.Sprintf("with key %v", keys)
+			if k, ok := keys[schema.Key]; ok && len(keys) == 1 {
+				name = fmt.Sprintf("with key %s", k)
+			}
+		}
+		entries = append(entries, &uniqueEntry{name: name, node: xn})
+	}
+	return checkUniqueEntries(schema, uniques, entries)
+}
+
+// validateDataNodeMandatory checks the mandatory nodes and list size
+// constraints of the children of the container or list entry n, and
+// recursively of the containers and list entries that it contains. It is
+// the equivalent of validateStructMandatory for DataNodes, hence the size of
+// lists that are populated is checked by validateData.
+func validateDataNodeMandatory(n *DataNode) util.Errors {
+	// selected stores the case and choice schema nodes which contain a
+	// populated child.
+	selected := map[*yang.Entry]bool{}
+	for _, c := range n.children {
+		for e := c.schema.Parent; e != nil && e != n.schema; e = e.Parent {
+			if util.IsChoiceOrCase(e) {
+				selected[e] = true
+			}
+		}
+	}
+
+	var errs util.Errors
+	children, choices := dataSchemaChildren(n.schema)
+	for _, cs := range children {
+		if !isCaseSelected(cs, n.schema, selected) {
+			continue
+		}
+		nodes := n.childrenWithSchema(cs)
+		switch {
+		case cs.IsLeaf():
+			if len(nodes) == 0 && cs.Mandatory == yang.TSTrue {
+				errs = util.AppendErr(errs, fmt.Errorf("schema path %s: mandatory leaf is not populated", util.SchemaTreePathNoModule(cs)))
+			}
+		case cs.IsLeafList():
+			errs = util.AppendErrs(errs, validateListAttrPath(cs, nodes))
+		case cs.IsList():
+			if len(nodes) == 0 {
+				errs = util.AppendErrs(errs, validateListAttrPath(cs, nodes))
+			}
+			for _, c := range nodes {
+				errs = util.AppendErrs(errs, validateDataNodeMandatory(c))
+			}
+		case cs.IsContainer():
+			switch {
+			case len(nodes) != 0:
+				errs = util.AppendErrs(errs, validateDataNodeMandatory(nodes[0]))
+			case !util.IsPresenceContainer(cs):
+				// A non-presence container is a mandatory node if any of its
+				// children are mandatory nodes, hence check an empty instance
+				// of the container.
+				errs = util.AppendErrs(errs, validateDataNodeMandatory(&DataNode{schema: cs}))
+			}
+		}
+	}
+
+	for _, c := range choices {
+		if c.Mandatory == yang.TSTrue && !selected[c] && isCaseSelected(c, n.schema, selected) {
+			p := util.SchemaTreePathNoModule(n.schema)
+			if p == "" {
+				p = "/"
+			}
+			errs = util.AppendErr(errs, fmt.Errorf("schema path %s: no case is selected for mandatory choice %s", p, c.Name))
+		}
+	}
+	return errs
+}
+
+// dataSchemaChildren returns the schemas of the data nodes that are children
+// of schema, including those within choice and case statements, and the
+// choices within schema, each sorted by name such that errors are reported
+// deterministically.
+func dataSchemaChildren(schema *yang.Entry) ([]*yang.Entry, []*yang.Entry) {
+	var children, choices []*yang.Entry
+	var add func(e *yang.Entry)
+	add = func(e *yang.Entry) {
+		for _, c := range e.Dir {
+			switch {
+			case c.IsChoice():
+				choices = append(choices, c)
+				add(c)
+			case c.IsCase():
+				add(c)
+			default:
+				children = append(children, c)
+			}
+		}
+	}
+	add(schema)
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	sort.Slice(choices, func(i, j int) bool { return choices[i].Name < choices[j].Name })
+	return children, choices
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/ygot/ygot"
+)
+
+func TestDataNodeValidate(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inJSON   string
+		inOpts   []ygot.ValidationOption
+		inModify func(*DataNode)
+		wantErrs []string
+	}{{
+		desc:   "valid tree",
+		inJSON: `{"system": {"uplink": "eth0"}}`,
+	}, {
+		desc: "missing mandatory leaf",
+		inModify: func(n *DataNode) {
+			if err := n.DeleteNode(mustPath("/system/hostname")); err != nil {
+				t.Fatalf("DeleteNode: got unexpected error: %v", err)
+			}
+		},
+		inOpts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		wantErrs: []string{"schema path /system/hostname: mandatory leaf is not populated"},
+	}, {
+		desc: "missing mandatory leaf not checked without EnforceMandatory",
+		inModify: func(n *DataNode) {
+			if err := n.DeleteNode(mustPath("/system/hostname")); err != nil {
+				t.Fatalf("DeleteNode: got unexpected error: %v", err)
+			}
+		},
+	}, {
+		desc:   "leafref without target",
+		inJSON: `{"system": {"uplink": "eth2"}}`,
+		wantErrs: []string{
+			`field name uplink value eth2 (string) schema path /device/system/uplink has leafref path /interfaces/interface/name not equal to any target nodes`,
+		},
+	}, {
+		desc:   "duplicate unique values",
+		inJSON: `{"interfaces": {"interface": [{"name": "eth0", "subinterface": [{"index": 2, "vlan": 10}]}]}}`,
+		wantErrs: []string{
+			`schema path /interfaces/interface/subinterface: list entry with key 2 has the same values [10] as list entry with key 1 for unique statement "vlan"`,
+		},
+	}, {
+		desc:   "must statement not evaluated without option",
+		inJSON: `{"system": {"hostname": "RTR1"}}`,
+	}, {
+		desc:     "must statement",
+		inJSON:   `{"system": {"hostname": "RTR1"}}`,
+		inOpts:   []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{`schema path /system/hostname: must statement "re-match(., '[a-z0-9]+')" is not satisfied`},
+	}, {
+		desc: "invalid leaf value",
+		inModify: func(n *DataNode) {
+			n.child("system").child("hostname").value = 42
+		},
+		wantErrs: []string{"/system/hostname: value 42 (int) of type int cannot be converted to type string of schema hostname"},
+	}, {
+		desc: "list entry without key",
+		inModify: func(n *DataNode) {
+			e := n.child("interfaces").child("interface")
+			e.removeChild(e.child("name"))
+		},
+		wantErrs: []string{"/interfaces/interface: list entry does not contain key name"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			n, err := NewDataTree(dataTreeTestSchema)
+			if err != nil {
+				t.Fatalf("NewDataTree: got unexpected error: %v", err)
+			}
+			for _, js := range []string{dataTreeTestJSON, tt.inJSON} {
+				if js == "" {
+					continue
+				}
+				if err := n.Unmarshal(mustJSON(t, js)); err != nil {
+					t.Fatalf("Unmarshal(%s): got unexpected error: %v", js, err)
+				}
+			}
+			if tt.inModify != nil {
+				tt.inModify(n)
+			}
+
+			var got []string
+			for _, err := range n.Validate(tt.inOpts...) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Validate: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,445 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/openconfig/gnmi/value"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// The value of a leaf, or leaf-list element, within a DataNode tree is stored
+// as the canonical Go value for its YANG type:
+//   - string, enumeration, identityref, bits and instance-identifier values
+//     are a string. Enumerated and identity values are the name of the enum
+//     or identity, without a module prefix, and bits values are the names of
+//     the set bits separated by spaces.
+//   - integer values are the Go integer type with the same name as the YANG
+//     type, e.g., uint16.
+//   - boolean values are a bool, and empty values are YANGEmpty(true).
+//   - binary values are a Binary.
+//   - decimal64 values are a ygot.Decimal64 with the precision specified by
+//     the fraction-digits of the type.
+//   - union values are the value of the first member type that the input
+//     value can be converted to, and is valid for.
+//   - leafref values are the value of the leaf that the leafref refers to.
+
+// leafConverter converts the input value in to the canonical Go value of the
+// leaf described by schema, whose type is not a union.
+type leafConverter func(schema *yang.Entry, in interface{}) (interface{}, error)
+
+// convertDataLeaf converts the input value in to the canonical Go value of
+// the leaf or leaf-list described by schema, using conv. The converted value
+// is validated against the schema. It returns the value, and the schema with
+// the type that the value was converted to, which is the selected member type
+// for a union, or the resolved type for a leafref.
+func convertDataLeaf(schema *yang.Entry, in interface{}, conv leafConverter) (interface{}, *yang.Entry, error) {
+	if err := validateLeafSchema(schema); err != nil {
+		return nil, nil, err
+	}
+	s, err := util.ResolveIfLeafRef(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	return convertDataLeafType(s, s.Type, in, conv)
+}
+
+// convertDataLeafType converts the input value in to the canonical Go value
+// of type t, for the leaf described by schema. Each of the member types of a
+// union is attempted in turn.
+func convertDataLeafType(schema *yang.Entry, t *yang.YangType, in interface{}, conv leafConverter) (interface{}, *yang.Entry, error) {
+	if t.Kind == yang.Yunion {
+		var errs util.Errors
+		for _, mt := range t.Type {
+			v, s, err := convertDataLeafType(schema, mt, in, conv)
+			if err == nil {
+				return v, s, nil
+			}
+			errs = util.AppendErr(errs, err)
+		}
+		return nil, nil, fmt.Errorf("value %v does not match any member type of union for schema %s: %v", util.ValueStr(in), schema.Name, errs)
+	}
+
+	s := dataLeafSchema(schema, t)
+	v, err := conv(s, in)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateDataLeafValue(s, v); err != nil {
+		return nil, nil, err
+	}
+	return v, s, nil
+}
+
+// dataLeafSchema returns a copy of the leaf or leaf-list schema, with its type
+// replaced by t, such that values can be validated against a member type of
+// a union.
+func dataLeafSchema(schema *yang.Entry, t *yang.YangType) *yang.Entry {
+	if schema.Type == t {
+		return schema
+	}
+	s := *schema
+	s.Type = t
+	return &s
+}
+
+// validateDataLeafValue validates the canonical Go value v against the leaf
+// schema, whose type is not a union or leafref.
+func validateDataLeafValue(schema *yang.Entry, v interface{}) error {
+	k := schema.Type.Kind
+	switch k {
+	case yang.Ystring:
+		return validateString(schema, v)
+	case yang.Ybool:
+		return validateBool(schema, v)
+	case yang.Yempty:
+		return validateEmpty(schema, v)
+	case yang.Ybinary:
+		return validateBinary(schema, v)
+	case yang.Ydecimal64:
+		return validateDecimal(schema, v)
+	case yang.Ybits:
+		if v == "" {
+			// No bits are set, which is a valid value for any bits type.
+			return nil
+		}
+		return validateBitset(schema, v)
+	case yang.Yenum:
+		if s, ok := v.(string); !ok || schema.Type.Enum == nil || !schema.Type.Enum.IsDefined(s) {
+			return fmt.Errorf("%v is not a valid value for enumeration type %s of schema %s", util.ValueStr(v), schema.Type.Name, schema.Name)
+		}
+		return nil
+	case yang.Yidentityref:
+		if s, ok := v.(string); !ok || !identityDefined(schema.Type.IdentityBase, s) {
+			return fmt.Errorf("%v is not a valid value for identityref type %s of schema %s", util.ValueStr(v), schema.Type.Name, schema.Name)
+		}
+		return nil
+	case yang.YinstanceIdentifier:
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("non string type %T with value %v for schema %s", v, v, schema.Name)
+		}
+		return nil
+	}
+	if isIntegerType(k) {
+		return validateInt(schema, v)
+	}
+	return fmt.Errorf("unsupported type %v for schema %s", k, schema.Name)
+}
+
+// identityDefined returns true if name is the name of an identity that is
+// derived from the identity base.
+func identityDefined(base *yang.Identity, name string) bool {
+	return dataIdentity(base, name) != nil
+}
+
+// dataIdentity returns the identity with the supplied name that is derived
+// from the identity base, or nil if there is none.
+func dataIdentity(base *yang.Identity, name string) *yang.Identity {
+	if base == nil {
+		return nil
+	}
+	for _, i := range base.Values {
+		if i.Name == name {
+			return i
+		}
+	}
+	return nil
+}
+
+// jsonLeafValue is a leafConverter for values that are encoded as per RFC7951,
+// and decoded by encoding/json.
+func jsonLeafValue(schema *yang.Entry, in interface{}) (interface{}, error) {
+	k := schema.Type.Kind
+	switch k {
+	case yang.Ybool:
+		if _, ok := in.(bool); !ok {
+			return nil, jsonTypeErr(schema, in)
+		}
+		return in, nil
+	case yang.Yempty:
+		if a, ok := in.([]interface{}); !ok || len(a) != 1 || a[0] != nil {
+			return nil, jsonTypeErr(schema, in)
+		}
+		return YANGEmpty(true), nil
+	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		f, ok := in.(float64)
+		if !ok {
+			return nil, jsonTypeErr(schema, in)
+		}
+		return goIntLeafValue(schema, f)
+	case yang.Ydecimal64:
+		if f, ok := in.(float64); ok {
+			return goLeafValue(schema, f)
+		}
+	}
+
+	s, ok := in.(string)
+	if !ok {
+		return nil, jsonTypeErr(schema, in)
+	}
+	return stringLeafValue(schema, s)
+}
+
+// jsonTypeErr returns the error that is reported when the JSON value in does
+// not have the JSON type expected for the leaf described by schema.
+func jsonTypeErr(schema *yang.Entry, in interface{}) error {
+	return fmt.Errorf("JSON value %v has type %T, which is not valid for type %v of schema %s", in, in, schema.Type.Kind, schema.Name)
+}
+
+// stringLeafValue is a leafConverter for values that are encoded as the
+// string value of the leaf, as used for the keys of gNMI paths, and for the
+// values of RFC7951 JSON that are encoded as strings. The module prefix of
+// identityref values is removed.
+func stringLeafValue(schema *yang.Entry, in interface{}) (interface{}, error) {
+	s, ok := in.(string)
+	if !ok {
+		return nil, fmt.Errorf("non string type %T with value %v for schema %s", in, in, schema.Name)
+	}
+	k := schema.Type.Kind
+	switch k {
+	case yang.Ystring, yang.Yenum, yang.Ybits, yang.YinstanceIdentifier:
+		return s, nil
+	case yang.Yidentityref:
+		return util.StripModulePrefix(s), nil
+	case yang.Ybool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean value %q for schema %s", s, schema.Name)
+		}
+		return b, nil
+	case yang.Ybinary:
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 binary value %q for schema %s: %v", s, schema.Name, err)
+		}
+		return Binary(b), nil
+	case yang.Ydecimal64:
+		return decimalLeafValue(schema, s)
+	}
+	if isIntegerType(k) {
+		if isSigned(k) {
+			i, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid integer value %q for schema %s", s, schema.Name)
+			}
+			return goIntLeafValue(schema, i)
+		}
+		u, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unsigned integer value %q for schema %s", s, schema.Name)
+		}
+		return goIntLeafValue(schema, u)
+	}
+	return nil, fmt.Errorf("value %q cannot be converted to type %v of schema %s", s, k, schema.Name)
+}
+
+// goLeafValue is a leafConverter for Go values, which are of the Go type of
+// the canonical value, or of another Go type that represents the same value
+// without loss, such as any Go integer or float type for integer and
+// decimal64 values, []byte for binary values, and bool for empty values.
+func goLeafValue(schema *yang.Entry, in interface{}) (interface{}, error) {
+	k := schema.Type.Kind
+	v := reflect.ValueOf(in)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil value for schema %s", schema.Name)
+	}
+	switch {
+	case k == yang.Yempty && v.Kind() == reflect.Bool:
+		return YANGEmpty(v.Bool()), nil
+	case k == yang.Ybool && v.Kind() == reflect.Bool:
+		return v.Bool(), nil
+	case k == yang.Ybinary && v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		return Binary(v.Bytes()), nil
+	case k == yang.Ydecimal64:
+		switch d := in.(type) {
+		case ygot.Decimal64:
+			return decimalLeafValue(schema, string(d))
+		case float32:
+			return decimalLeafValue(schema, strconv.FormatFloat(float64(d), 'f', -1, 32))
+		case float64:
+			return decimalLeafValue(schema, strconv.FormatFloat(d, 'f', -1, 64))
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return decimalLeafValue(schema, strconv.FormatInt(v.Int(), 10))
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return decimalLeafValue(schema, strconv.FormatUint(v.Uint(), 10))
+		}
+	case isIntegerType(k):
+		return goIntLeafValue(schema, in)
+	case v.Kind() == reflect.String:
+		return stringLeafValue(schema, v.String())
+	}
+	return nil, fmt.Errorf("value %v of type %T cannot be converted to type %v of schema %s", util.ValueStr(in), in, k, schema.Name)
+}
+
+// goIntLeafValue converts the Go integer or float value in to the Go integer
+// type that corresponds to the integer type of schema. An error is returned
+// if in is not an integral value within the range of that Go type.
+func goIntLeafValue(schema *yang.Entry, in interface{}) (interface{}, error) {
+	k := schema.Type.Kind
+	out := reflect.New(reflect.TypeOf(yangBuiltinTypeToGoType(k))).Elem()
+	rangeErr := fmt.Errorf("value %v is outside the range of type %v for schema %s", in, k, schema.Name)
+
+	v := reflect.ValueOf(in)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := v.Int()
+		switch {
+		case isSigned(k) && !out.OverflowInt(i):
+			out.SetInt(i)
+		case !isSigned(k) && i >= 0 && !out.OverflowUint(uint64(i)):
+			out.SetUint(uint64(i))
+		default:
+			return nil, rangeErr
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := v.Uint()
+		switch {
+		case isSigned(k) && u <= math.MaxInt64 && !out.OverflowInt(int64(u)):
+			out.SetInt(int64(u))
+		case !isSigned(k) && !out.OverflowUint(u):
+			out.SetUint(u)
+		default:
+			return nil, rangeErr
+		}
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case f != math.Trunc(f):
+			return nil, fmt.Errorf("value %v is not an integer for type %v of schema %s", in, k, schema.Name)
+		case f >= math.MinInt64 && f < math.MaxInt64:
+			return goIntLeafValue(schema, int64(f))
+		case f >= 0 && f < math.MaxUint64:
+			return goIntLeafValue(schema, uint64(f))
+		default:
+			return nil, rangeErr
+		}
+	default:
+		return nil, fmt.Errorf("value %v of type %T cannot be converted to type %v of schema %s", util.ValueStr(in), in, k, schema.Name)
+	}
+	return out.Interface(), nil
+}
+
+// decimalLeafValue parses the decimal string s as a ygot.Decimal64 with the
+// precision specified by the fraction-digits of the decimal64 type of schema.
+func decimalLeafValue(schema *yang.Entry, s string) (interface{}, error) {
+	d, err := ygot.ParseDecimal64(s, uint8(schema.Type.FractionDigits))
+	if err != nil {
+		return nil, fmt.Errorf("%v for schema %s", err, schema.Name)
+	}
+	return d, nil
+}
+
+// typedLeafValue is a leafConverter for gNMI TypedValues, which must not be
+// leaf-list values. JSON encoded values are decoded, and converted as per
+// RFC7951.
+func typedLeafValue(schema *yang.Entry, in interface{}) (interface{}, error) {
+	tv, ok := in.(*gpb.TypedValue)
+	if !ok {
+		return nil, fmt.Errorf("non TypedValue type %T with value %v for schema %s", in, in, schema.Name)
+	}
+
+	var j []byte
+	switch v := tv.GetValue().(type) {
+	case *gpb.TypedValue_JsonVal:
+		j = v.JsonVal
+	case *gpb.TypedValue_JsonIetfVal:
+		j = v.JsonIetfVal
+	case *gpb.TypedValue_DecimalVal:
+		if schema.Type.Kind != yang.Ydecimal64 {
+			return nil, fmt.Errorf("decimal value %v cannot be converted to type %v of schema %s", v.DecimalVal, schema.Type.Kind, schema.Name)
+		}
+		return decimalLeafValue(schema, string(ygot.NewDecimal64(v.DecimalVal.GetDigits(), uint8(v.DecimalVal.GetPrecision()))))
+	case *gpb.TypedValue_LeaflistVal:
+		return nil, fmt.Errorf("leaf-list value cannot be converted to type %v of schema %s", schema.Type.Kind, schema.Name)
+	default:
+		sv, err := value.ToScalar(tv)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert %v for schema %s: %v", tv, schema.Name, err)
+		}
+		return goLeafValue(schema, sv)
+	}
+
+	var jv interface{}
+	if err := json.Unmarshal(j, &jv); err != nil {
+		return nil, fmt.Errorf("cannot decode JSON value %s for schema %s: %v", j, schema.Name, err)
+	}
+	return jsonLeafValue(schema, jv)
+}
+
+// dataValueString returns the string value of the canonical Go value v, as
+// used for the keys of gNMI paths.
+func dataValueString(v interface{}) string {
+	switch v := v.(type) {
+	case Binary:
+		return base64.StdEncoding.EncodeToString(v)
+	case YANGEmpty:
+		return ""
+	case ygot.Decimal64:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// jsonDataValue returns the RFC7951 encoding of the canonical Go value v of
+// the leaf described by schema, whose type is not a union or leafref.
+func jsonDataValue(schema *yang.Entry, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case int64, uint64, ygot.Decimal64:
+		return dataValueString(v), nil
+	case Binary:
+		return dataValueString(v), nil
+	case YANGEmpty:
+		return []interface{}{nil}, nil
+	}
+	if schema.Type.Kind == yang.Yidentityref {
+		i := dataIdentity(schema.Type.IdentityBase, v.(string))
+		if i == nil {
+			return nil, fmt.Errorf("%v is not a valid value for identityref type %s of schema %s", v, schema.Type.Name, schema.Name)
+		}
+		if m := yang.RootNode(i); m != nil {
+			return fmt.Sprintf("%s:%s", m.Name, i.Name), nil
+		}
+	}
+	return v, nil
+}
+
+// typedDataValue returns the gNMI TypedValue of the canonical Go value v.
+func typedDataValue(v interface{}) (*gpb.TypedValue, error) {
+	switch v := v.(type) {
+	case Binary:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{v}}, nil
+	case YANGEmpty:
+		return &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{bool(v)}}, nil
+	case ygot.Decimal64:
+		d, err := v.Digits()
+		if err != nil {
+			return nil, err
+		}
+		return &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{&gpb.Decimal64{Digits: d, Precision: uint32(v.Precision())}}}, nil
+	}
+	return value.FromScalar(v)
+}
@@ -0,0 +1,344 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// dataTreeTestLeaf returns the schema of the leaf at the supplied path within
+// dataTreeTestSchema.
+func dataTreeTestLeaf(path ...string) *yang.Entry {
+	e := dataTreeTestSchema
+	for _, p := range path {
+		e = e.Dir[p]
+	}
+	return e
+}
+
+func TestConvertDataLeaf(t *testing.T) {
+	mtu := dataTreeTestLeaf("interfaces", "interface", "config", "mtu")
+	speed := dataTreeTestLeaf("interfaces", "interface", "config", "speed")
+	ifType := dataTreeTestLeaf("interfaces", "interface", "config", "type")
+	counter := dataTreeTestLeaf("interfaces", "interface", "config", "counter")
+	name := dataTreeTestLeaf("interfaces", "interface", "name")
+	mode := dataTreeTestLeaf("system", "mode")
+	address := dataTreeTestLeaf("system", "address")
+	debug := dataTreeTestLeaf("system", "debug")
+	key := dataTreeTestLeaf("system", "key")
+
+	tests := []struct {
+		desc             string
+		inSchema         *yang.Entry
+		in               interface{}
+		inConv           leafConverter
+		want             interface{}
+		wantErrSubstring string
+	}{{
+		desc:     "JSON uint16",
+		inSchema: mtu,
+		in:       float64(1500),
+		inConv:   jsonLeafValue,
+		want:     uint16(1500),
+	}, {
+		desc:             "JSON uint16 out of range",
+		inSchema:         mtu,
+		in:               float64(65536),
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "value 65536 is outside the range of type uint16",
+	}, {
+		desc:             "JSON uint16 that is not an integer",
+		inSchema:         mtu,
+		in:               float64(1.5),
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "value 1.5 is not an integer",
+	}, {
+		desc:             "JSON uint16 encoded as string",
+		inSchema:         mtu,
+		in:               "1500",
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "JSON value 1500 has type string",
+	}, {
+		desc:     "JSON uint64 encoded as string",
+		inSchema: counter,
+		in:       "18446744073709551615",
+		inConv:   jsonLeafValue,
+		want:     uint64(18446744073709551615),
+	}, {
+		desc:     "JSON decimal64 string",
+		inSchema: speed,
+		in:       "2.5",
+		inConv:   jsonLeafValue,
+		want:     ygot.Decimal64("2.50"),
+	}, {
+		desc:     "JSON decimal64 number",
+		inSchema: speed,
+		in:       float64(10),
+		inConv:   jsonLeafValue,
+		want:     ygot.Decimal64("10.00"),
+	}, {
+		desc:             "JSON decimal64 with too many fraction digits",
+		inSchema:         speed,
+		in:               "2.555",
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "cannot be represented with precision 2",
+	}, {
+		desc:     "JSON identityref with module prefix",
+		inSchema: ifType,
+		in:       "ext:TUNNEL",
+		inConv:   jsonLeafValue,
+		want:     "TUNNEL",
+	}, {
+		desc:             "JSON identityref that is not derived from the base",
+		inSchema:         ifType,
+		in:               "dev:LOOPBACK",
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "LOOPBACK (string) is not a valid value for identityref type identityref",
+	}, {
+		desc:     "JSON enumeration",
+		inSchema: mode,
+		in:       "STANDBY",
+		inConv:   jsonLeafValue,
+		want:     "STANDBY",
+	}, {
+		desc:             "JSON enumeration with undefined value",
+		inSchema:         mode,
+		in:               "OFF",
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "OFF (string) is not a valid value for enumeration type mode",
+	}, {
+		desc:     "JSON empty",
+		inSchema: debug,
+		in:       []interface{}{nil},
+		inConv:   jsonLeafValue,
+		want:     YANGEmpty(true),
+	}, {
+		desc:     "JSON binary",
+		inSchema: key,
+		in:       "AQI=",
+		inConv:   jsonLeafValue,
+		want:     Binary{1, 2},
+	}, {
+		desc:     "JSON union selects first member type",
+		inSchema: address,
+		in:       float64(80),
+		inConv:   jsonLeafValue,
+		want:     uint16(80),
+	}, {
+		desc:     "JSON union selects second member type",
+		inSchema: address,
+		in:       "192.0.2.1",
+		inConv:   jsonLeafValue,
+		want:     "192.0.2.1",
+	}, {
+		desc:             "JSON union without matching member type",
+		inSchema:         address,
+		in:               true,
+		inConv:           jsonLeafValue,
+		wantErrSubstring: "does not match any member type of union",
+	}, {
+		desc:     "leafref has type of target",
+		inSchema: name,
+		in:       "eth0",
+		inConv:   jsonLeafValue,
+		want:     "eth0",
+	}, {
+		desc:     "string uint64 key",
+		inSchema: counter,
+		in:       "42",
+		inConv:   stringLeafValue,
+		want:     uint64(42),
+	}, {
+		desc:             "string that is not an integer",
+		inSchema:         counter,
+		in:               "forty-two",
+		inConv:           stringLeafValue,
+		wantErrSubstring: `invalid unsigned integer value "forty-two"`,
+	}, {
+		desc:     "Go int for uint16",
+		inSchema: mtu,
+		in:       9000,
+		inConv:   goLeafValue,
+		want:     uint16(9000),
+	}, {
+		desc:             "Go negative int for uint16",
+		inSchema:         mtu,
+		in:               -1,
+		inConv:           goLeafValue,
+		wantErrSubstring: "value -1 is outside the range of type uint16",
+	}, {
+		desc:     "Go float64 for decimal64",
+		inSchema: speed,
+		in:       float64(2.25),
+		inConv:   goLeafValue,
+		want:     ygot.Decimal64("2.25"),
+	}, {
+		desc:     "Go bool for empty",
+		inSchema: debug,
+		in:       true,
+		inConv:   goLeafValue,
+		want:     YANGEmpty(true),
+	}, {
+		desc:     "Go []byte for binary",
+		inSchema: key,
+		in:       []byte{1, 2},
+		inConv:   goLeafValue,
+		want:     Binary{1, 2},
+	}, {
+		desc:             "TypedValue decimal with too many fraction digits",
+		inSchema:         speed,
+		in:               &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{&gpb.Decimal64{Digits: 1005, Precision: 3}}},
+		inConv:           typedLeafValue,
+		wantErrSubstring: "cannot be represented with precision 2",
+	}, {
+		desc:     "TypedValue exact decimal",
+		inSchema: speed,
+		in:       &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{&gpb.Decimal64{Digits: 150, Precision: 1}}},
+		inConv:   typedLeafValue,
+		want:     ygot.Decimal64("15.00"),
+	}, {
+		desc:     "TypedValue uint",
+		inSchema: mtu,
+		in:       &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{1500}},
+		inConv:   typedLeafValue,
+		want:     uint16(1500),
+	}, {
+		desc:     "TypedValue JSON",
+		inSchema: counter,
+		in:       &gpb.TypedValue{Value: &gpb.TypedValue_JsonIetfVal{[]byte(`"42"`)}},
+		inConv:   typedLeafValue,
+		want:     uint64(42),
+	}, {
+		desc:     "TypedValue enumeration",
+		inSchema: ifType,
+		in:       &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"ETHERNET"}},
+		inConv:   typedLeafValue,
+		want:     "ETHERNET",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, _, err := convertDataLeaf(tt.inSchema, tt.in, tt.inConv)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("convertDataLeaf(%v): did not get expected error, %s", tt.in, diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("convertDataLeaf(%v): did not get expected value, diff(-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestJSONDataValue(t *testing.T) {
+	tests := []struct {
+		desc     string
+		inSchema *yang.Entry
+		in       interface{}
+		want     interface{}
+	}{{
+		desc:     "uint16",
+		inSchema: dataTreeTestLeaf("interfaces", "interface", "config", "mtu"),
+		in:       uint16(1500),
+		want:     uint16(1500),
+	}, {
+		desc:     "uint64",
+		inSchema: dataTreeTestLeaf("interfaces", "interface", "config", "counter"),
+		in:       uint64(42),
+		want:     "42",
+	}, {
+		desc:     "decimal64",
+		inSchema: dataTreeTestLeaf("interfaces", "interface", "config", "speed"),
+		in:       ygot.Decimal64("2.50"),
+		want:     "2.50",
+	}, {
+		desc:     "identityref from another module",
+		inSchema: dataTreeTestLeaf("interfaces", "interface", "config", "type"),
+		in:       "TUNNEL",
+		want:     "ext:TUNNEL",
+	}, {
+		desc:     "empty",
+		inSchema: dataTreeTestLeaf("system", "debug"),
+		in:       YANGEmpty(true),
+		want:     []interface{}{nil},
+	}, {
+		desc:     "binary",
+		inSchema: dataTreeTestLeaf("system", "key"),
+		in:       Binary{1, 2},
+		want:     "AQI=",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := jsonDataValue(tt.inSchema, tt.in)
+			if err != nil {
+				t.Fatalf("jsonDataValue(%v): got unexpected error: %v", tt.in, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("jsonDataValue(%v): did not get expected value, diff(-want, +got):\n%s", tt.in, diff)
+			}
+		})
+	}
+}
+
+func TestTypedDataValue(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   interface{}
+		want *gpb.TypedValue
+	}{{
+		desc: "uint16",
+		in:   uint16(1500),
+		want: &gpb.TypedValue{Value: &gpb.TypedValue_UintVal{1500}},
+	}, {
+		desc: "string",
+		in:   "eth0",
+		want: &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{"eth0"}},
+	}, {
+		desc: "decimal64",
+		in:   ygot.Decimal64("2.50"),
+		want: &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{&gpb.Decimal64{Digits: 250, Precision: 2}}},
+	}, {
+		desc: "empty",
+		in:   YANGEmpty(true),
+		want: &gpb.TypedValue{Value: &gpb.TypedValue_BoolVal{true}},
+	}, {
+		desc: "binary",
+		in:   Binary{1, 2},
+		want: &gpb.TypedValue{Value: &gpb.TypedValue_BytesVal{[]byte{1, 2}}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := typedDataValue(tt.in)
+			if err != nil {
+				t.Fatalf("typedDataValue(%v): got unexpected error: %v", tt.in, err)
+			}
+			if !proto.Equal(got, tt.want) {
+				t.Errorf("typedDataValue(%v): got %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -16,20 +16,26 @@ package ytypes
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // Refer to: https://tools.ietf.org/html/rfc6020#section-9.3.
 
-// validateDecimal validates value, which must be a Go float64 type, against the
-// given schema.
+// validateDecimal validates value, which must be a Go float64 or a
+// ygot.Decimal64 type, against the given schema.
 func validateDecimal(schema *yang.Entry, value interface{}) error {
 	// Check that the schema itself is valid.
 	if err := validateDecimalSchema(schema); err != nil {
 		return err
 	}
 
+	if d, ok := value.(ygot.Decimal64); ok {
+		return validateExactDecimal(schema, d)
+	}
+
 	// Check that type of value is the type expected from the schema.
 	f, ok := value.(float64)
 	if !ok {
@@ -43,14 +49,85 @@ func validateDecimal(schema *yang.Entry, value interface{}) error {
 	return nil
 }
 
-// validateDecimalSlice validates value, which must be a Go float64 slice type,
-// against the given schema.
+// validateExactDecimal validates the ygot.Decimal64 value d against the given
+// schema. The value must be a valid decimal64, have the number of fraction
+// digits that is specified by the schema, and be within its ranges.
+func validateExactDecimal(schema *yang.Entry, d ygot.Decimal64) error {
+	if _, err := d.Digits(); err != nil {
+		return fmt.Errorf("invalid decimal value for schema %s: %v", schema.Name, err)
+	}
+
+	if fd := schema.Type.FractionDigits; fd != 0 && int(d.Precision()) != fd {
+		return fmt.Errorf("decimal value %s has %d fraction digits, expect %d for schema %s", d, d.Precision(), fd, schema.Name)
+	}
+
+	if !isExactDecimalInRanges(schema.Type.Range, d) {
+		return fmt.Errorf("decimal value %s is outside specified ranges for schema %s", d, schema.Name)
+	}
+
+	return nil
+}
+
+// isExactDecimalInRanges reports whether the ygot.Decimal64 d, which must be a
+// valid decimal value, falls within any of the ranges in yrs. The comparison
+// is made exactly, rather than by converting the values to float64.
+func isExactDecimalInRanges(yrs yang.YangRange, d ygot.Decimal64) bool {
+	if len(yrs) == 0 {
+		return true
+	}
+	v, ok := new(big.Rat).SetString(string(d))
+	if !ok {
+		return false
+	}
+	for _, yr := range yrs {
+		minCmp, minOK := compareNumberToRat(yr.Min, v)
+		maxCmp, maxOK := compareNumberToRat(yr.Max, v)
+		if minOK && maxOK && minCmp <= 0 && maxCmp >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// compareNumberToRat compares the range boundary n to v, returning -1 if n is
+// less than v, 0 if they are equal, and +1 if n is greater than v. The
+// returned bool is false if n cannot be represented as a rational number.
+func compareNumberToRat(n yang.Number, v *big.Rat) (int, bool) {
+	switch n.Kind {
+	case yang.MinNumber:
+		return -1, true
+	case yang.MaxNumber:
+		return 1, true
+	}
+	r, ok := new(big.Rat).SetString(n.String())
+	if !ok {
+		return 0, false
+	}
+	return r.Cmp(v), true
+}
+
+// validateDecimalSlice validates value, which must be a Go float64 or
+// ygot.Decimal64 slice type, against the given schema.
 func validateDecimalSlice(schema *yang.Entry, value interface{}) error {
 	// Check that the schema itself is valid.
 	if err := validateDecimalSchema(schema); err != nil {
 		return err
 	}
 
+	if ds, ok := value.([]ygot.Decimal64); ok {
+		tbl := make(map[ygot.Decimal64]bool, len(ds))
+		for i, val := range ds {
+			if err := validateExactDecimal(schema, val); err != nil {
+				return fmt.Errorf("invalid element at index %d: %v for schema %s", i, err, schema.Name)
+			}
+			if tbl[val] {
+				return fmt.Errorf("duplicate decimal: %v for schema %s", val, schema.Name)
+			}
+			tbl[val] = true
+		}
+		return nil
+	}
+
 	// Check that type of value is the type expected from the schema.
 	slice, ok := value.([]float64)
 	if !ok {
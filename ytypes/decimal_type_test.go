@@ -17,8 +17,13 @@ package ytypes
 import (
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 const (
@@ -308,6 +313,23 @@ func TestValidateDecimalSlice(t *testing.T) {
 			val:     []float64{4.4, 5.0, 5.0},
 			wantErr: true,
 		},
+		{
+			desc:   "exact decimal success",
+			schema: validDecimalSchema,
+			val:    []ygot.Decimal64{"4.4", "5.0"},
+		},
+		{
+			desc:    "exact decimal invalid element",
+			schema:  validDecimalSchema,
+			val:     []ygot.Decimal64{"4.4", "five"},
+			wantErr: true,
+		},
+		{
+			desc:    "exact decimal duplicate element",
+			schema:  validDecimalSchema,
+			val:     []ygot.Decimal64{"4.4", "5.0", "5.0"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -320,3 +342,174 @@ func TestValidateDecimalSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateExactDecimal(t *testing.T) {
+	schema := &yang.Entry{
+		Name: "exact-decimal-schema",
+		Type: &yang.YangType{
+			Kind:           yang.Ydecimal64,
+			FractionDigits: 18,
+			Range: yang.YangRange{
+				yang.YRange{Min: mustParseNumber(t, "-1.5"), Max: mustParseNumber(t, "2.000000000000000001")},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc    string
+		in      ygot.Decimal64
+		wantErr bool
+	}{{
+		desc: "maximum of range",
+		in:   "2.000000000000000001",
+	}, {
+		desc: "minimum of range",
+		in:   "-1.500000000000000000",
+	}, {
+		desc: "value that cannot be represented exactly as float64",
+		in:   "0.300000000000000001",
+	}, {
+		desc:    "above range by smallest increment",
+		in:      "2.000000000000000002",
+		wantErr: true,
+	}, {
+		desc:    "below range by smallest increment",
+		in:      "-1.500000000000000001",
+		wantErr: true,
+	}, {
+		desc:    "wrong number of fraction digits",
+		in:      "0.3",
+		wantErr: true,
+	}, {
+		desc:    "invalid value",
+		in:      "0.3.0",
+		wantErr: true,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			err := validateDecimal(schema, tt.in)
+			if got, want := (err != nil), tt.wantErr; got != want {
+				t.Errorf("%s: validateDecimal(%v) got error: %v, want error? %v", tt.desc, tt.in, err, tt.wantErr)
+			}
+			testErrLog(t, tt.desc, err)
+		})
+	}
+}
+
+func mustParseNumber(t *testing.T, s string) yang.Number {
+	n, err := yang.ParseNumber(s)
+	if err != nil {
+		t.Fatalf("cannot parse number %s: %v", s, err)
+	}
+	return n
+}
+
+type ExactDecimalStruct struct {
+	Decimal     *ygot.Decimal64  `path:"decimal"`
+	DecimalList []ygot.Decimal64 `path:"decimal-leaflist"`
+}
+
+func (*ExactDecimalStruct) IsYANGGoStruct() {}
+
+func TestUnmarshalExactDecimal(t *testing.T) {
+	leaf := &yang.Entry{
+		Name: "decimal",
+		Kind: yang.LeafEntry,
+		Type: &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2},
+	}
+	leafList := &yang.Entry{
+		Name:     "decimal-leaflist",
+		Kind:     yang.LeafEntry,
+		ListAttr: &yang.ListAttr{},
+		Type:     &yang.YangType{Kind: yang.Ydecimal64, FractionDigits: 2},
+	}
+	containerSchema := &yang.Entry{
+		Name: "container",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"decimal":          leaf,
+			"decimal-leaflist": leafList,
+		},
+	}
+	leaf.Parent, leafList.Parent = containerSchema, containerSchema
+
+	dec := func(d ygot.Decimal64) *ygot.Decimal64 { return &d }
+
+	tests := []struct {
+		desc          string
+		inSchema      *yang.Entry
+		inVal         interface{}
+		inEnc         Encoding
+		want          *ExactDecimalStruct
+		wantErrSubstr string
+	}{{
+		desc:     "JSON leaf",
+		inSchema: containerSchema,
+		inVal:    map[string]interface{}{"decimal": "1.5"},
+		want:     &ExactDecimalStruct{Decimal: dec("1.50")},
+	}, {
+		desc:     "JSON leaf-list",
+		inSchema: containerSchema,
+		inVal:    map[string]interface{}{"decimal-leaflist": []interface{}{"-0.25", "42"}},
+		want:     &ExactDecimalStruct{DecimalList: []ygot.Decimal64{"-0.25", "42.00"}},
+	}, {
+		desc:          "JSON leaf with too many fraction digits",
+		inSchema:      containerSchema,
+		inVal:         map[string]interface{}{"decimal": "1.255"},
+		wantErrSubstr: "cannot be represented with precision 2",
+	}, {
+		desc:     "gNMI DecimalVal",
+		inSchema: leaf,
+		inVal:    &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{DecimalVal: &gpb.Decimal64{Digits: 15, Precision: 1}}},
+		inEnc:    GNMIEncoding,
+		want:     &ExactDecimalStruct{Decimal: dec("1.50")},
+	}, {
+		desc:     "gNMI DecimalVal with larger precision",
+		inSchema: leaf,
+		inVal:    &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{DecimalVal: &gpb.Decimal64{Digits: 1500, Precision: 3}}},
+		inEnc:    GNMIEncoding,
+		want:     &ExactDecimalStruct{Decimal: dec("1.50")},
+	}, {
+		desc:          "gNMI DecimalVal that cannot be represented",
+		inSchema:      leaf,
+		inVal:         &gpb.TypedValue{Value: &gpb.TypedValue_DecimalVal{DecimalVal: &gpb.Decimal64{Digits: 1505, Precision: 3}}},
+		inEnc:         GNMIEncoding,
+		wantErrSubstr: "cannot be represented with precision 2",
+	}, {
+		desc:     "gNMI FloatVal",
+		inSchema: leaf,
+		inVal:    &gpb.TypedValue{Value: &gpb.TypedValue_FloatVal{FloatVal: 1.25}},
+		inEnc:    GNMIEncoding,
+		want:     &ExactDecimalStruct{Decimal: dec("1.25")},
+	}, {
+		desc:     "gNMI leaf-list",
+		inSchema: leafList,
+		inVal: &gpb.TypedValue{Value: &gpb.TypedValue_LeaflistVal{LeaflistVal: &gpb.ScalarArray{
+			Element: []*gpb.TypedValue{
+				{Value: &gpb.TypedValue_DecimalVal{DecimalVal: &gpb.Decimal64{Digits: -3, Precision: 2}}},
+			},
+		}}},
+		inEnc: GNMIEncoding,
+		want:  &ExactDecimalStruct{DecimalList: []ygot.Decimal64{"-0.03"}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := &ExactDecimalStruct{}
+			err := unmarshalGeneric(tt.inSchema, got, tt.inVal, tt.inEnc)
+			if diff := errdiff.Substring(err, tt.wantErrSubstr); diff != "" {
+				t.Fatalf("unmarshalGeneric: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unmarshalGeneric: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+			if errs := Validate(containerSchema, got); errs != nil {
+				t.Errorf("Validate: got unexpected error: %v", errs)
+			}
+		})
+	}
+}
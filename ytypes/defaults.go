@@ -0,0 +1,364 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7950#section-7.6.1.
+
+// PopulateDefaults sets each leaf within the GoStruct value, which is
+// described by schema, that is unset and has a default value in the schema to
+// that default value. Non-presence containers are created where they would
+// contain a default value. As per RFC7950 Section 7.6.1, a default value is
+// only used where the case that the leaf is within, if any, is selected, or is
+// the default case of its choice and no other case of the choice is selected.
+// Default values whose data nodes have a when statement that evaluates to false
+// once the defaults have been populated are removed, such that only the data
+// nodes that were already populated are subject to when statements. The
+// GoStruct is modified in-place.
+func PopulateDefaults(schema *yang.Entry, value ygot.GoStruct) error {
+	v := reflect.ValueOf(value)
+	if schema == nil || !util.IsValueStructPtr(v) || v.IsNil() {
+		return fmt.Errorf("cannot populate defaults of %T with schema %v", value, schema)
+	}
+
+	added := map[interface{}]bool{}
+	if errs := populateDefaults(schema, v, added); errs != nil {
+		return errs
+	}
+	return removeInactiveDefaults(schema, value, added)
+}
+
+// TrimDefaults unsets each leaf within the GoStruct value, which is described
+// by schema, whose value is equal to its default value in the schema. A leaf
+// is only unset if the default value would still be used in its place, such
+// that a leaf which is the only data node of a case that is not the default
+// case of its choice is retained. Non-presence containers that are left empty
+// are removed. The GoStruct is modified in-place.
+func TrimDefaults(schema *yang.Entry, value ygot.GoStruct) error {
+	v := reflect.ValueOf(value)
+	if schema == nil || !util.IsValueStructPtr(v) || v.IsNil() {
+		return fmt.Errorf("cannot trim defaults of %T with schema %v", value, schema)
+	}
+	if errs := trimDefaults(schema, v); errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// populateDefaults populates the default values within the struct pointer v,
+// which is described by schema. The address of each field that is set to a
+// default value, or created to hold one, is added to added.
+func populateDefaults(schema *yang.Entry, v reflect.Value, added map[interface{}]bool) util.Errors {
+	sv := v.Elem()
+	selected, errs := selectedCases(schema, sv, -1)
+	if errs != nil {
+		return errs
+	}
+
+	for i := 0; i < sv.NumField(); i++ {
+		f, ft := sv.Field(i), sv.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		cs, err := util.ChildSchema(schema, ft)
+		if err != nil {
+			errs = util.AppendErr(errs, err)
+			continue
+		}
+		if cs == nil || !isCaseActive(schema, cs, selected) {
+			continue
+		}
+
+		switch {
+		case cs.IsLeaf():
+			if !util.IsValueNilOrDefault(f.Interface()) {
+				continue
+			}
+			ok, err := setLeafDefault(cs, v)
+			switch {
+			case err != nil:
+				errs = util.AppendErr(errs, err)
+			case ok:
+				added[f.Addr().Interface()] = true
+			}
+		case cs.IsContainer():
+			if !util.IsTypeStructPtr(ft.Type) {
+				continue
+			}
+			if !f.IsNil() {
+				errs = util.AppendErrs(errs, populateDefaults(cs, f, added))
+				continue
+			}
+			if util.IsPresenceContainer(cs) {
+				// The existence of a presence container has meaning, and
+				// hence it is not created to hold default values.
+				continue
+			}
+			nv := reflect.New(ft.Type.Elem())
+			errs = util.AppendErrs(errs, populateDefaults(cs, nv, added))
+			if !reflect.DeepEqual(nv.Elem().Interface(), reflect.Zero(ft.Type.Elem()).Interface()) {
+				f.Set(nv)
+				added[f.Addr().Interface()] = true
+			}
+		case cs.IsList():
+			for _, m := range listMembers(f) {
+				errs = util.AppendErrs(errs, populateDefaults(cs, m, added))
+			}
+		}
+	}
+	return errs
+}
+
+// trimDefaults unsets the leaves within the struct pointer v, which is
+// described by schema, that are set to their default value.
+func trimDefaults(schema *yang.Entry, v reflect.Value) util.Errors {
+	sv := v.Elem()
+
+	var errs util.Errors
+	for i := 0; i < sv.NumField(); i++ {
+		f, ft := sv.Field(i), sv.Type().Field(i)
+		if util.IsYgotAnnotation(ft) || util.IsValueNilOrDefault(f.Interface()) {
+			continue
+		}
+		cs, err := util.ChildSchema(schema, ft)
+		if err != nil {
+			errs = util.AppendErr(errs, err)
+			continue
+		}
+		if cs == nil {
+			continue
+		}
+
+		switch {
+		case cs.IsLeaf():
+			// The leaf is not trimmed if its case would no longer be
+			// active without it, since its default would then not apply.
+			selected, serrs := selectedCases(schema, sv, i)
+			if serrs != nil {
+				errs = util.AppendErrs(errs, serrs)
+				continue
+			}
+			if !isCaseActive(schema, cs, selected) {
+				continue
+			}
+			dv := reflect.New(sv.Type())
+			ok, err := setLeafDefault(cs, dv)
+			if err != nil {
+				errs = util.AppendErr(errs, err)
+				continue
+			}
+			if ok && reflect.DeepEqual(f.Interface(), dv.Elem().Field(i).Interface()) {
+				f.Set(reflect.Zero(ft.Type))
+			}
+		case cs.IsContainer():
+			if !util.IsValueStructPtr(f) {
+				continue
+			}
+			errs = util.AppendErrs(errs, trimDefaults(cs, f))
+			if !util.IsPresenceContainer(cs) && reflect.DeepEqual(f.Elem().Interface(), reflect.Zero(ft.Type.Elem()).Interface()) {
+				f.Set(reflect.Zero(ft.Type))
+			}
+		case cs.IsList():
+			for _, m := range listMembers(f) {
+				errs = util.AppendErrs(errs, trimDefaults(cs, m))
+			}
+		}
+	}
+	return errs
+}
+
+// listMembers returns the members of the list v, which is a map, an ordered
+// map or a slice of struct pointers. Nil members are omitted.
+func listMembers(v reflect.Value) []reflect.Value {
+	var members []reflect.Value
+	switch {
+	case util.IsNilOrInvalidValue(v):
+	case util.IsValueMap(v), util.IsValueOrderedMap(v):
+		_, members = util.KeyedListMembers(v)
+	case util.IsValueSlice(v):
+		for i := 0; i < v.Len(); i++ {
+			members = append(members, v.Index(i))
+		}
+	}
+
+	var out []reflect.Value
+	for _, m := range members {
+		if util.IsValueStructPtr(m) && !m.IsNil() {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// selectedCases returns the set of case schema entries, between the struct
+// value sv and the fields within it, that contain data in sv. The field with
+// the index skip is not considered, such that the cases that would remain
+// selected without it can be determined.
+func selectedCases(schema *yang.Entry, sv reflect.Value, skip int) (map[*yang.Entry]bool, util.Errors) {
+	selected := map[*yang.Entry]bool{}
+	var errs util.Errors
+	for i := 0; i < sv.NumField(); i++ {
+		ft := sv.Type().Field(i)
+		if i == skip || util.IsYgotAnnotation(ft) || util.IsValueNilOrDefault(sv.Field(i).Interface()) {
+			continue
+		}
+		cs, err := util.ChildSchema(schema, ft)
+		if err != nil {
+			errs = util.AppendErr(errs, err)
+			continue
+		}
+		for e := cs; e != nil && e != schema; e = e.Parent {
+			if e.IsCase() {
+				selected[e] = true
+			}
+		}
+	}
+	return selected, errs
+}
+
+// isCaseActive reports whether each case between the data node described by
+// cs and the struct described by schema is active, given the set of selected
+// cases. A case is active if it is selected, or it is the default case of its
+// choice and no other case of the choice is selected.
+func isCaseActive(schema, cs *yang.Entry, selected map[*yang.Entry]bool) bool {
+	for e := cs; e != nil && e != schema; e = e.Parent {
+		if !e.IsCase() || selected[e] {
+			continue
+		}
+		choice := e.Parent
+		if choice == nil || choice.Default != e.Name {
+			return false
+		}
+		for _, c := range choice.Dir {
+			if selected[c] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// leafDefault returns the default value of the leaf described by schema,
+// which is specified either by the leaf itself, or by its type. The empty
+// string is returned if the leaf has no default value.
+func leafDefault(schema *yang.Entry) string {
+	if schema.Default != "" {
+		return schema.Default
+	}
+	if schema.Type != nil {
+		return schema.Type.Default
+	}
+	return ""
+}
+
+// setLeafDefault sets the leaf described by schema within the struct pointer
+// parent to its default value. It returns true if the leaf has a default
+// value, and an error if the default value cannot be stored in the field.
+func setLeafDefault(schema *yang.Entry, parent reflect.Value) (bool, error) {
+	d := leafDefault(schema)
+	if d == "" {
+		return false, nil
+	}
+
+	rs, err := util.ResolveIfLeafRef(schema)
+	if err != nil {
+		return false, err
+	}
+	if rs.Type == nil {
+		return false, fmt.Errorf("leaf schema type is nil for schema %s", schema.Name)
+	}
+
+	// The default value is mapped to each of the RFC7951 JSON values that
+	// it may be encoded as, and the first that the leaf accepts is used.
+	err = fmt.Errorf("not a valid %v value", rs.Type.Kind)
+	for _, jv := range defaultJSONValues(rs.Type.Kind, d) {
+		if err = unmarshalLeaf(schema, parent.Interface(), jv, JSONEncoding); err == nil {
+			return true, nil
+		}
+	}
+	return false, fmt.Errorf("schema path %s: invalid default value %q: %v", util.SchemaTreePathNoModule(schema), d, err)
+}
+
+// defaultJSONValues returns the RFC7951 JSON values that the default value d
+// of a leaf of YANG type kind k may be represented by. Since the members of a
+// union may have any type, all possible representations are returned for
+// union leaves, with numeric and boolean values preferred over strings.
+func defaultJSONValues(k yang.TypeKind, d string) []interface{} {
+	var out []interface{}
+	if k == yang.Yunion || yangToJSONType(k) == reflect.TypeOf(float64(0)) {
+		if f, err := strconv.ParseFloat(d, 64); err == nil {
+			out = append(out, f)
+		}
+	}
+	if k == yang.Yunion || k == yang.Ybool {
+		if b, err := strconv.ParseBool(d); err == nil && (d == "true" || d == "false") {
+			out = append(out, b)
+		}
+	}
+	if k == yang.Yunion || yangToJSONType(k) == reflect.TypeOf("") {
+		out = append(out, d)
+	}
+	return out
+}
+
+// removeInactiveDefaults removes the data nodes that were added by
+// PopulateDefaults, identified by the addresses of their fields in added,
+// where they are within a data node whose when statement evaluates to false.
+// The when statements are re-evaluated until no further data nodes are
+// removed.
+func removeInactiveDefaults(schema *yang.Entry, value ygot.GoStruct, added map[interface{}]bool) error {
+	for {
+		n, err := util.NewXPathTree(schema, value)
+		if err != nil {
+			return err
+		}
+		if n == nil {
+			return nil
+		}
+		inactive, errs := util.InactiveWhenNodes(n)
+		if errs != nil {
+			return errs
+		}
+
+		var remove []*util.XPathNode
+		for _, i := range inactive {
+			i.Walk(func(d *util.XPathNode) bool {
+				if f := d.Field(); f.IsValid() && f.CanAddr() && added[f.Addr().Interface()] {
+					remove = append(remove, d)
+					return false
+				}
+				return true
+			})
+		}
+		if len(remove) == 0 {
+			return nil
+		}
+		for _, r := range remove {
+			delete(added, r.Field().Addr().Interface())
+			if err := r.Remove(); err != nil {
+				return err
+			}
+		}
+	}
+}
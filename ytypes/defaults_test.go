@@ -0,0 +1,292 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// defaultsTestModule is the module used to test the population and trimming
+// of default values. The system container has a choice whose default case is
+// udp, the logging container is a presence container, and the description of
+// an interface is only valid when it is disabled.
+const defaultsTestModule = `
+module defaults-test {
+  prefix "dt";
+  namespace "urn:dt";
+
+  typedef mtu-type {
+    type uint32;
+    default 1500;
+  }
+
+  container interfaces {
+    list interface {
+      key "name";
+
+      leaf name {
+        type leafref {
+          path "../config/name";
+        }
+      }
+
+      container config {
+        leaf name {
+          type string;
+        }
+
+        leaf mtu {
+          type mtu-type;
+        }
+
+        leaf type {
+          type enumeration {
+            enum E_VALUE_FORTY_TWO {
+              value 42;
+            }
+          }
+          default E_VALUE_FORTY_TWO;
+        }
+
+        leaf enabled {
+          type boolean;
+          default true;
+        }
+
+        leaf description {
+          when "../enabled = 'false'";
+          type string;
+          default down;
+        }
+      }
+    }
+  }
+
+  container system {
+    leaf hostname {
+      type string;
+      default localhost;
+    }
+
+    choice transport {
+      default udp;
+
+      case udp {
+        leaf udp-port {
+          type uint16;
+          default 514;
+        }
+      }
+
+      case tcp {
+        leaf tcp-port {
+          type uint16;
+          default 601;
+        }
+      }
+    }
+  }
+
+  container logging {
+    presence "enables logging";
+
+    leaf level {
+      type string;
+      default info;
+    }
+  }
+}
+`
+
+type defaultsInterface struct {
+	Name        *string  `path:"config/name|name"`
+	Mtu         *uint32  `path:"config/mtu"`
+	Type        EnumType `path:"config/type"`
+	Enabled     *bool    `path:"config/enabled"`
+	Description *string  `path:"config/description"`
+}
+
+func (*defaultsInterface) IsYANGGoStruct() {}
+
+type defaultsSystem struct {
+	Hostname *string `path:"hostname"`
+	UdpPort  *uint16 `path:"udp-port"`
+	TcpPort  *uint16 `path:"tcp-port"`
+}
+
+func (*defaultsSystem) IsYANGGoStruct() {}
+
+type defaultsLogging struct {
+	Level *string `path:"level"`
+}
+
+func (*defaultsLogging) IsYANGGoStruct() {}
+
+type defaultsDevice struct {
+	Interface map[string]*defaultsInterface `path:"interfaces/interface"`
+	System    *defaultsSystem               `path:"system"`
+	Logging   *defaultsLogging              `path:"logging"`
+}
+
+func (*defaultsDevice) IsYANGGoStruct() {}
+
+func TestPopulateDefaults(t *testing.T) {
+	tests := []struct {
+		desc             string
+		inSchema         func(*yang.Entry)
+		in               *defaultsDevice
+		want             *defaultsDevice
+		wantErrSubstring string
+	}{{
+		desc: "empty struct",
+		in:   &defaultsDevice{},
+		want: &defaultsDevice{
+			System: &defaultsSystem{Hostname: String("localhost"), UdpPort: ygot.Uint16(514)},
+		},
+	}, {
+		desc: "set leaves are retained",
+		in: &defaultsDevice{
+			System: &defaultsSystem{Hostname: String("router"), UdpPort: ygot.Uint16(1514)},
+		},
+		want: &defaultsDevice{
+			System: &defaultsSystem{Hostname: String("router"), UdpPort: ygot.Uint16(1514)},
+		},
+	}, {
+		desc: "non-default case selected",
+		in: &defaultsDevice{
+			System: &defaultsSystem{TcpPort: ygot.Uint16(6514)},
+		},
+		want: &defaultsDevice{
+			System: &defaultsSystem{Hostname: String("localhost"), TcpPort: ygot.Uint16(6514)},
+		},
+	}, {
+		desc: "list member with default from type and false when statement",
+		in: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{"eth0": {Name: String("eth0")}},
+		},
+		want: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{
+				"eth0": {Name: String("eth0"), Mtu: ygot.Uint32(1500), Type: 42, Enabled: ygot.Bool(true)},
+			},
+			System: &defaultsSystem{Hostname: String("localhost"), UdpPort: ygot.Uint16(514)},
+		},
+	}, {
+		desc: "list member with true when statement",
+		in: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{"eth0": {Name: String("eth0"), Enabled: ygot.Bool(false)}},
+		},
+		want: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{
+				"eth0": {Name: String("eth0"), Mtu: ygot.Uint32(1500), Type: 42, Enabled: ygot.Bool(false), Description: String("down")},
+			},
+			System: &defaultsSystem{Hostname: String("localhost"), UdpPort: ygot.Uint16(514)},
+		},
+	}, {
+		desc: "presence container",
+		in:   &defaultsDevice{Logging: &defaultsLogging{}},
+		want: &defaultsDevice{
+			System:  &defaultsSystem{Hostname: String("localhost"), UdpPort: ygot.Uint16(514)},
+			Logging: &defaultsLogging{Level: String("info")},
+		},
+	}, {
+		desc: "invalid default value",
+		inSchema: func(s *yang.Entry) {
+			s.Dir["system"].Dir["hostname"].Type = &yang.YangType{Kind: yang.Yuint8}
+		},
+		in:               &defaultsDevice{},
+		wantErrSubstring: `schema path /system/hostname: invalid default value "localhost": not a valid uint8 value`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			schema := yangtest.ParseModule(t, "defaults-test", defaultsTestModule)
+			if tt.inSchema != nil {
+				tt.inSchema(schema)
+			}
+			err := PopulateDefaults(schema, tt.in)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("PopulateDefaults: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, tt.in); diff != "" {
+				t.Errorf("PopulateDefaults: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestTrimDefaults(t *testing.T) {
+	tests := []struct {
+		desc string
+		in   *defaultsDevice
+		want *defaultsDevice
+	}{{
+		desc: "all leaves have their default value",
+		in: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{
+				"eth0": {Name: String("eth0"), Mtu: ygot.Uint32(1500), Type: 42, Enabled: ygot.Bool(true)},
+			},
+			System: &defaultsSystem{Hostname: String("localhost"), UdpPort: ygot.Uint16(514)},
+		},
+		want: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{"eth0": {Name: String("eth0")}},
+		},
+	}, {
+		desc: "non-default values are retained",
+		in: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{
+				"eth0": {Name: String("eth0"), Mtu: ygot.Uint32(9000), Enabled: ygot.Bool(false), Description: String("down")},
+			},
+			System: &defaultsSystem{Hostname: String("router")},
+		},
+		want: &defaultsDevice{
+			Interface: map[string]*defaultsInterface{
+				"eth0": {Name: String("eth0"), Mtu: ygot.Uint32(9000), Enabled: ygot.Bool(false)},
+			},
+			System: &defaultsSystem{Hostname: String("router")},
+		},
+	}, {
+		desc: "only leaf of non-default case is retained",
+		in: &defaultsDevice{
+			System: &defaultsSystem{Hostname: String("localhost"), TcpPort: ygot.Uint16(601)},
+		},
+		want: &defaultsDevice{
+			System: &defaultsSystem{TcpPort: ygot.Uint16(601)},
+		},
+	}, {
+		desc: "presence container is retained",
+		in:   &defaultsDevice{Logging: &defaultsLogging{Level: String("info")}},
+		want: &defaultsDevice{Logging: &defaultsLogging{}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			if err := TrimDefaults(yangtest.ParseModule(t, "defaults-test", defaultsTestModule), tt.in); err != nil {
+				t.Fatalf("TrimDefaults: got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, tt.in); diff != "" {
+				t.Errorf("TrimDefaults: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,504 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc6241#section-7.2 for the
+// semantics of the edit-config operations.
+
+// EditOperation is a NETCONF edit-config operation.
+type EditOperation string
+
+const (
+	// EditMerge merges the configuration data with the existing data at
+	// the same level of the datastore.
+	EditMerge EditOperation = "merge"
+	// EditReplace replaces the existing data with the configuration data.
+	EditReplace EditOperation = "replace"
+	// EditCreate adds the configuration data, which must not already
+	// exist.
+	EditCreate EditOperation = "create"
+	// EditDelete deletes the configuration data, which must exist.
+	EditDelete EditOperation = "delete"
+	// EditRemove deletes the configuration data if it exists.
+	EditRemove EditOperation = "remove"
+	// EditNone leaves the existing data unchanged, unless an operation is
+	// specified for a descendant node. It is only valid as the default
+	// operation.
+	EditNone EditOperation = "none"
+)
+
+// NETCONF error-tag values, as per RFC6241 Appendix A, that are used in the
+// errors returned by ApplyEditConfig and ApplyYANGPatch.
+const (
+	// ErrorTagDataExists indicates that data to be created already exists.
+	ErrorTagDataExists = "data-exists"
+	// ErrorTagDataMissing indicates that data to be deleted, or the parent
+	// of data to be modified, does not exist.
+	ErrorTagDataMissing = "data-missing"
+	// ErrorTagUnknownElement indicates that an element is not described by
+	// the schema.
+	ErrorTagUnknownElement = "unknown-element"
+	// ErrorTagUnknownNamespace indicates that an element is qualified by a
+	// module that does not define it.
+	ErrorTagUnknownNamespace = "unknown-namespace"
+	// ErrorTagMissingElement indicates that an expected element, such as
+	// the key of a list, is missing.
+	ErrorTagMissingElement = "missing-element"
+	// ErrorTagBadAttribute indicates that an attribute has an invalid value.
+	ErrorTagBadAttribute = "bad-attribute"
+	// ErrorTagInvalidValue indicates that a value is not valid for its type.
+	ErrorTagInvalidValue = "invalid-value"
+	// ErrorTagOperationNotSupported indicates that an operation cannot be
+	// applied to a data node.
+	ErrorTagOperationNotSupported = "operation-not-supported"
+	// ErrorTagOperationFailed indicates that an operation failed for a
+	// reason not covered by another error-tag.
+	ErrorTagOperationFailed = "operation-failed"
+	// ErrorTagMalformedMessage indicates that a message could not be
+	// parsed.
+	ErrorTagMalformedMessage = "malformed-message"
+)
+
+// NETCONFError is an error that results from a NETCONF operation. Its fields
+// correspond to those of an rpc-error, as per RFC6241 Section 4.3.
+type NETCONFError struct {
+	// Tag is the error-tag that identifies the error condition.
+	Tag string
+	// Path is the error-path, which identifies the data node that the
+	// error relates to.
+	Path string
+	// Message is the error-message, which describes the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *NETCONFError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%s: %s", e.Tag, e.Message)
+	}
+	return fmt.Sprintf("%s at %s: %s", e.Tag, e.Path, e.Message)
+}
+
+// ApplyEditConfig applies the XML config data of a NETCONF edit-config
+// operation to root, which must be the GoStruct described by schema. The
+// data is encoded as per UnmarshalXML, and each of its elements may specify
+// an operation attribute in the NETCONF namespace. Elements that do not
+// specify an operation use the operation of their parent, or the default
+// operation defaultOp, which must be EditMerge, EditReplace or EditNone, for
+// top-level elements. The empty string is treated as EditMerge.
+//
+// As per RFC6241, a create operation fails if the data node already exists,
+// and a delete operation fails if it does not. With the EditNone default
+// operation, the ancestors of a data node to which an operation applies must
+// already exist. The insert attributes of ordered-by user lists are not
+// supported.
+//
+// The edit is applied atomically - if it fails, root is left unchanged. The
+// error returned is a *NETCONFError.
+func ApplyEditConfig(schema *yang.Entry, root ygot.GoStruct, config []byte, defaultOp EditOperation) error {
+	switch {
+	case schema == nil:
+		return &NETCONFError{Tag: ErrorTagOperationFailed, Message: "nil schema"}
+	case util.IsValueNil(root):
+		return &NETCONFError{Tag: ErrorTagOperationFailed, Message: "nil root"}
+	}
+
+	if defaultOp == "" {
+		defaultOp = EditMerge
+	}
+	replaceAll := false
+	switch defaultOp {
+	case EditMerge, EditNone:
+	case EditReplace:
+		// The whole datastore is replaced by the configuration data,
+		// which is then merged into the empty datastore.
+		replaceAll, defaultOp = true, EditMerge
+	default:
+		return &NETCONFError{Tag: ErrorTagInvalidValue, Message: fmt.Sprintf("invalid default operation %s", defaultOp)}
+	}
+
+	elems, err := parseXMLData(config)
+	if err != nil {
+		return &NETCONFError{Tag: ErrorTagOperationFailed, Message: err.Error()}
+	}
+
+	tree := map[string]interface{}{}
+	if !replaceAll {
+		if tree, err = editTree(root, nil); err != nil {
+			return &NETCONFError{Tag: ErrorTagOperationFailed, Message: err.Error()}
+		}
+	}
+
+	e := &editor{modules: namespaceModules(schema)}
+	if err := e.apply(schema, tree, elems, defaultOp, ""); err != nil {
+		return err
+	}
+
+	n := reflect.New(reflect.TypeOf(root).Elem())
+	if err := Unmarshal(schema, n.Interface(), tree); err != nil {
+		return &NETCONFError{Tag: ErrorTagInvalidValue, Message: err.Error()}
+	}
+	reflect.ValueOf(root).Elem().Set(n.Elem())
+	return nil
+}
+
+// editTree returns the RFC7951 JSON representation of root, rendered with
+// the supplied config, as would be decoded by encoding/json, such that it can
+// be edited and unmarshalled.
+func editTree(root ygot.GoStruct, args *ygot.RFC7951JSONConfig) (map[string]interface{}, error) {
+	m, err := ygot.ConstructIETFJSON(root, args)
+	if err != nil {
+		return nil, err
+	}
+	j, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	tree := map[string]interface{}{}
+	if err := json.Unmarshal(j, &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// editor applies the elements of edit-config data to an RFC7951 JSON tree.
+type editor struct {
+	// modules is a map, keyed by XML namespace, of module names.
+	modules map[string]string
+}
+
+// apply applies the elements elems, which are the children of the data node
+// described by schema, to tree, which is the JSON object for that data node.
+// The operation of elements that do not specify one is op, and the path of
+// the data node is path.
+func (e *editor) apply(schema *yang.Entry, tree map[string]interface{}, elems []*xmlElement, op EditOperation, path string) error {
+	for _, el := range elems {
+		cs := util.FirstChild(schema, []string{el.name})
+		if cs == nil {
+			return &NETCONFError{Tag: ErrorTagUnknownElement, Path: path + "/" + el.name, Message: fmt.Sprintf("element %s is not a valid child of %s", el.name, schema.Name)}
+		}
+
+		elOp := op
+		if el.operation != "" {
+			elOp = EditOperation(el.operation)
+			switch elOp {
+			case EditMerge, EditReplace, EditCreate, EditDelete, EditRemove:
+			default:
+				return &NETCONFError{Tag: ErrorTagBadAttribute, Path: path + "/" + el.name, Message: fmt.Sprintf("invalid operation %s", el.operation)}
+			}
+		}
+
+		var err error
+		switch {
+		case cs.IsList():
+			err = e.applyList(cs, tree, el, elOp, path)
+		case cs.IsContainer():
+			err = e.applyContainer(cs, tree, el, elOp, path)
+		case cs.IsLeafList():
+			err = e.applyLeafList(cs, tree, el, elOp, path)
+		case cs.IsLeaf():
+			err = e.applyLeaf(cs, tree, el, elOp, path)
+		default:
+			err = &NETCONFError{Tag: ErrorTagOperationNotSupported, Path: path + "/" + el.name, Message: fmt.Sprintf("unsupported schema node %s", cs.Name)}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyContainer applies the element el, which is the container described by
+// schema, to tree, which is the JSON object of its parent.
+func (e *editor) applyContainer(schema *yang.Entry, tree map[string]interface{}, el *xmlElement, op EditOperation, path string) error {
+	path = path + "/" + schema.Name
+	cur, ok := tree[schema.Name].(map[string]interface{})
+	cur, childOp, err := editNode(cur, ok, op, path)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		delete(tree, schema.Name)
+		return nil
+	}
+	if err := e.apply(schema, cur, el.children, childOp, path); err != nil {
+		return err
+	}
+	if len(cur) == 0 && !util.IsPresenceContainer(schema) {
+		// Non-presence containers without any contents do not exist.
+		delete(tree, schema.Name)
+		return nil
+	}
+	tree[schema.Name] = cur
+	return nil
+}
+
+// applyList applies the element el, which is a member of the list described
+// by schema, to tree, which is the JSON object of the parent of the list.
+// The member is identified by the key leaves within el.
+func (e *editor) applyList(schema *yang.Entry, tree map[string]interface{}, el *xmlElement, op EditOperation, path string) error {
+	list, _ := tree[schema.Name].([]interface{})
+	keys := strings.Fields(schema.Key)
+	if len(keys) == 0 {
+		// Members of keyless lists cannot be identified, and hence can only
+		// be added.
+		if op != EditMerge && op != EditCreate {
+			return &NETCONFError{Tag: ErrorTagOperationNotSupported, Path: path + "/" + schema.Name, Message: fmt.Sprintf("operation %s is not supported for keyless list %s", op, schema.Name)}
+		}
+		m := map[string]interface{}{}
+		if err := e.apply(schema, m, el.children, EditMerge, path+"/"+schema.Name); err != nil {
+			return err
+		}
+		tree[schema.Name] = append(list, m)
+		return nil
+	}
+
+	keyVals := map[string]interface{}{}
+	var preds []string
+	for _, k := range keys {
+		kel := xmlChild(el, k)
+		ks := util.FirstChild(schema, []string{k})
+		if kel == nil || ks == nil {
+			return &NETCONFError{Tag: ErrorTagMissingElement, Path: path + "/" + schema.Name, Message: fmt.Sprintf("key %s of list %s is missing", k, schema.Name)}
+		}
+		v, err := e.leafValue(ks, kel, path+"/"+schema.Name)
+		if err != nil {
+			return err
+		}
+		keyVals[k] = v
+		preds = append(preds, fmt.Sprintf("[%s='%s']", k, editValueString(v)))
+	}
+	path = path + "/" + schema.Name + strings.Join(preds, "")
+
+	idx := -1
+	for i, m := range list {
+		mm, ok := m.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		match := true
+		for k, v := range keyVals {
+			if mv, ok := mm[k]; !ok || editValueString(mv) != editValueString(v) {
+				match = false
+				break
+			}
+		}
+		if match {
+			idx = i
+			break
+		}
+	}
+
+	var cur map[string]interface{}
+	if idx != -1 {
+		cur, _ = list[idx].(map[string]interface{})
+	}
+	cur, childOp, err := editNode(cur, idx != -1, op, path)
+	if err != nil {
+		return err
+	}
+	if cur == nil {
+		if idx != -1 {
+			list = append(list[:idx], list[idx+1:]...)
+		}
+		if len(list) == 0 {
+			delete(tree, schema.Name)
+			return nil
+		}
+		tree[schema.Name] = list
+		return nil
+	}
+
+	// The key leaves of a member always exist, regardless of the operation.
+	for k, v := range keyVals {
+		cur[k] = v
+	}
+	if err := e.apply(schema, cur, el.children, childOp, path); err != nil {
+		return err
+	}
+	if idx == -1 {
+		list = append(list, cur)
+	} else {
+		list[idx] = cur
+	}
+	tree[schema.Name] = list
+	return nil
+}
+
+// applyLeafList applies the element el, which is an entry of the leaf-list
+// described by schema, to tree, which is the JSON object of the parent of the
+// leaf-list.
+func (e *editor) applyLeafList(schema *yang.Entry, tree map[string]interface{}, el *xmlElement, op EditOperation, path string) error {
+	v, err := e.leafValue(schema, el, path)
+	if err != nil {
+		return err
+	}
+	path = fmt.Sprintf("%s/%s[.='%s']", path, schema.Name, editValueString(v))
+
+	list, _ := tree[schema.Name].([]interface{})
+	idx := -1
+	for i, lv := range list {
+		if editValueString(lv) == editValueString(v) {
+			idx = i
+			break
+		}
+	}
+
+	switch op {
+	case EditNone:
+		if idx == -1 {
+			return &NETCONFError{Tag: ErrorTagDataMissing, Path: path, Message: "data does not exist"}
+		}
+		return nil
+	case EditCreate:
+		if idx != -1 {
+			return &NETCONFError{Tag: ErrorTagDataExists, Path: path, Message: "data already exists"}
+		}
+	case EditDelete, EditRemove:
+		if idx == -1 {
+			if op == EditDelete {
+				return &NETCONFError{Tag: ErrorTagDataMissing, Path: path, Message: "data does not exist"}
+			}
+			return nil
+		}
+		list = append(list[:idx], list[idx+1:]...)
+		if len(list) == 0 {
+			delete(tree, schema.Name)
+			return nil
+		}
+		tree[schema.Name] = list
+		return nil
+	}
+	if idx == -1 {
+		tree[schema.Name] = append(list, v)
+	}
+	return nil
+}
+
+// applyLeaf applies the element el, which is the leaf described by schema, to
+// tree, which is the JSON object of the parent of the leaf.
+func (e *editor) applyLeaf(schema *yang.Entry, tree map[string]interface{}, el *xmlElement, op EditOperation, path string) error {
+	path = path + "/" + schema.Name
+	_, exists := tree[schema.Name]
+	switch op {
+	case EditNone:
+		return nil
+	case EditCreate:
+		if exists {
+			return &NETCONFError{Tag: ErrorTagDataExists, Path: path, Message: "data already exists"}
+		}
+	case EditDelete, EditRemove:
+		if !exists && op == EditDelete {
+			return &NETCONFError{Tag: ErrorTagDataMissing, Path: path, Message: "data does not exist"}
+		}
+		delete(tree, schema.Name)
+		return nil
+	}
+	v, err := e.leafValue(schema, el, path)
+	if err != nil {
+		return err
+	}
+	tree[schema.Name] = v
+	return nil
+}
+
+// leafValue returns the RFC7951 JSON value of the element el, which is the
+// leaf or leaf-list described by schema.
+func (e *editor) leafValue(schema *yang.Entry, el *xmlElement, path string) (interface{}, error) {
+	rs, err := util.ResolveIfLeafRef(schema)
+	if err != nil {
+		return nil, &NETCONFError{Tag: ErrorTagOperationFailed, Path: path, Message: err.Error()}
+	}
+	if rs.Type == nil {
+		return nil, &NETCONFError{Tag: ErrorTagOperationFailed, Path: path, Message: fmt.Sprintf("leaf schema type is nil for schema %s", schema.Name)}
+	}
+	v, ok := xmlLeafValue(rs.Type, el, e.modules)
+	if !ok {
+		return nil, &NETCONFError{Tag: ErrorTagInvalidValue, Path: path, Message: fmt.Sprintf("invalid %v value %q", rs.Type.Kind, el.text)}
+	}
+	if s, ok := v.(string); ok && rs.Type.Kind == yang.Yidentityref {
+		// Identities are stored without the name of the module that
+		// defines them, as in the tree that is edited.
+		return util.StripModulePrefix(s), nil
+	}
+	return v, nil
+}
+
+// editNode applies the operation op to the JSON object cur of a container or
+// list member at path, which exists if exists is true. It returns the object
+// that the children of the data node should be applied to, or nil if the
+// data node should be removed, along with the operation for its children.
+func editNode(cur map[string]interface{}, exists bool, op EditOperation, path string) (map[string]interface{}, EditOperation, error) {
+	switch op {
+	case EditNone:
+		if !exists {
+			return nil, "", &NETCONFError{Tag: ErrorTagDataMissing, Path: path, Message: "data does not exist"}
+		}
+		return cur, EditNone, nil
+	case EditCreate:
+		if exists {
+			return nil, "", &NETCONFError{Tag: ErrorTagDataExists, Path: path, Message: "data already exists"}
+		}
+		return map[string]interface{}{}, EditMerge, nil
+	case EditDelete:
+		if !exists {
+			return nil, "", &NETCONFError{Tag: ErrorTagDataMissing, Path: path, Message: "data does not exist"}
+		}
+		return nil, "", nil
+	case EditRemove:
+		return nil, "", nil
+	case EditReplace:
+		return map[string]interface{}{}, EditMerge, nil
+	}
+	if !exists || cur == nil {
+		cur = map[string]interface{}{}
+	}
+	return cur, EditMerge, nil
+}
+
+// xmlChild returns the first child element of el with the supplied name, or
+// nil if there is none.
+func xmlChild(el *xmlElement, name string) *xmlElement {
+	for _, c := range el.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// editValueString returns the string representation of the RFC7951 JSON
+// value v, such that values can be compared.
+func editValueString(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// editConfig wraps the supplied XML in a NETCONF config element, declaring
+// the nc prefix for the NETCONF namespace.
+func editConfig(x string) []byte {
+	return []byte(`<config xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">` + x + `</config>`)
+}
+
+func TestApplyEditConfig(t *testing.T) {
+	tests := []struct {
+		desc        string
+		inRoot      *xmlDevice
+		inConfig    []byte
+		inDefaultOp EditOperation
+		want        *xmlDevice
+		wantErrTag  string
+		wantErrPath string
+	}{{
+		desc:     "merge into empty root",
+		inRoot:   &xmlDevice{},
+		inConfig: editConfig(`<system xmlns="urn:dev"><config><hostname>rtr1</hostname><dns>10.0.0.1</dns></config></system>`),
+		want:     &xmlDevice{Hostname: ygot.String("rtr1"), DNS: []string{"10.0.0.1"}},
+	}, {
+		desc:     "merge retains existing data",
+		inRoot:   &xmlDevice{Hostname: ygot.String("rtr1"), DNS: []string{"10.0.0.1"}, Mtu: ygot.Uint16(1500)},
+		inConfig: editConfig(`<system xmlns="urn:dev"><config><dns>10.0.0.2</dns><dns>10.0.0.1</dns><mtu>9000</mtu></config></system>`),
+		want:     &xmlDevice{Hostname: ygot.String("rtr1"), DNS: []string{"10.0.0.1", "10.0.0.2"}, Mtu: ygot.Uint16(9000)},
+	}, {
+		desc:     "replace container",
+		inRoot:   &xmlDevice{Hostname: ygot.String("rtr1"), Mtu: ygot.Uint16(1500), Logging: &xmlLogging{}},
+		inConfig: editConfig(`<system xmlns="urn:dev"><config nc:operation="replace"><mtu>9000</mtu></config></system>`),
+		want:     &xmlDevice{Mtu: ygot.Uint16(9000), Logging: &xmlLogging{}},
+	}, {
+		desc:   "replace list member",
+		inRoot: &xmlDevice{Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500)}}},
+		inConfig: editConfig(`<interfaces xmlns="urn:dev">
+  <interface nc:operation="replace"><name>eth0</name><config><name>eth0</name></config></interface>
+</interfaces>`),
+		want: &xmlDevice{Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0")}}},
+	}, {
+		desc:     "create list member",
+		inRoot:   &xmlDevice{Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0")}}},
+		inConfig: editConfig(`<interfaces xmlns="urn:dev"><interface nc:operation="create"><name>eth1</name><config><mtu>9000</mtu></config></interface></interfaces>`),
+		want: &xmlDevice{Interface: map[string]*xmlInterface{
+			"eth0": {Name: ygot.String("eth0")},
+			"eth1": {Name: ygot.String("eth1"), Mtu: ygot.Uint16(9000)},
+		}},
+	}, {
+		desc:        "create existing list member",
+		inRoot:      &xmlDevice{Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0")}}},
+		inConfig:    editConfig(`<interfaces xmlns="urn:dev"><interface nc:operation="create"><name>eth0</name></interface></interfaces>`),
+		wantErrTag:  ErrorTagDataExists,
+		wantErrPath: "/interfaces/interface[name='eth0']",
+	}, {
+		desc:        "create existing leaf",
+		inRoot:      &xmlDevice{Hostname: ygot.String("rtr1")},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><hostname nc:operation="create">rtr2</hostname></config></system>`),
+		wantErrTag:  ErrorTagDataExists,
+		wantErrPath: "/system/config/hostname",
+	}, {
+		desc: "delete list member",
+		inRoot: &xmlDevice{Interface: map[string]*xmlInterface{
+			"eth0": {Name: ygot.String("eth0")},
+			"eth1": {Name: ygot.String("eth1")},
+		}},
+		inConfig: editConfig(`<interfaces xmlns="urn:dev"><interface nc:operation="delete"><name>eth1</name></interface></interfaces>`),
+		want:     &xmlDevice{Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0")}}},
+	}, {
+		desc:        "delete missing container",
+		inRoot:      &xmlDevice{Hostname: ygot.String("rtr1")},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><logging nc:operation="delete"/></system>`),
+		wantErrTag:  ErrorTagDataMissing,
+		wantErrPath: "/system/logging",
+	}, {
+		desc:     "remove missing container",
+		inRoot:   &xmlDevice{Hostname: ygot.String("rtr1")},
+		inConfig: editConfig(`<system xmlns="urn:dev"><logging nc:operation="remove"/></system>`),
+		want:     &xmlDevice{Hostname: ygot.String("rtr1")},
+	}, {
+		desc:     "delete leaf-list entry",
+		inRoot:   &xmlDevice{DNS: []string{"10.0.0.1", "10.0.0.2"}},
+		inConfig: editConfig(`<system xmlns="urn:dev"><config><dns nc:operation="delete">10.0.0.1</dns></config></system>`),
+		want:     &xmlDevice{DNS: []string{"10.0.0.2"}},
+	}, {
+		desc:        "delete missing leaf-list entry",
+		inRoot:      &xmlDevice{DNS: []string{"10.0.0.1"}},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><dns nc:operation="delete">10.0.0.2</dns></config></system>`),
+		wantErrTag:  ErrorTagDataMissing,
+		wantErrPath: "/system/config/dns[.='10.0.0.2']",
+	}, {
+		desc:        "default operation none",
+		inRoot:      &xmlDevice{Hostname: ygot.String("rtr1"), Mtu: ygot.Uint16(1500)},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><hostname>rtr2</hostname><mtu nc:operation="delete"/></config></system>`),
+		inDefaultOp: EditNone,
+		want:        &xmlDevice{Hostname: ygot.String("rtr1")},
+	}, {
+		desc:        "default operation none with missing parent",
+		inRoot:      &xmlDevice{},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><hostname nc:operation="merge">rtr2</hostname></config></system>`),
+		inDefaultOp: EditNone,
+		wantErrTag:  ErrorTagDataMissing,
+		wantErrPath: "/system",
+	}, {
+		desc:        "default operation replace",
+		inRoot:      &xmlDevice{Hostname: ygot.String("rtr1"), Interface: map[string]*xmlInterface{"eth0": {Name: ygot.String("eth0")}}},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><mtu>9000</mtu></config></system>`),
+		inDefaultOp: EditReplace,
+		want:        &xmlDevice{Mtu: ygot.Uint16(9000)},
+	}, {
+		desc:     "merge into keyless list",
+		inRoot:   &xmlDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}}},
+		inConfig: editConfig(`<servers xmlns="urn:dev"><server><address>192.0.2.2</address></server></servers>`),
+		want:     &xmlDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}, {Address: ygot.String("192.0.2.2")}}},
+	}, {
+		desc:        "delete member of keyless list",
+		inRoot:      &xmlDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}}},
+		inConfig:    editConfig(`<servers xmlns="urn:dev"><server nc:operation="delete"><address>192.0.2.1</address></server></servers>`),
+		wantErrTag:  ErrorTagOperationNotSupported,
+		wantErrPath: "/servers/server",
+	}, {
+		desc:        "invalid operation",
+		inRoot:      &xmlDevice{},
+		inConfig:    editConfig(`<system xmlns="urn:dev" nc:operation="copy"/>`),
+		wantErrTag:  ErrorTagBadAttribute,
+		wantErrPath: "/system",
+	}, {
+		desc:        "unknown element",
+		inRoot:      &xmlDevice{},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><clock/></system>`),
+		wantErrTag:  ErrorTagUnknownElement,
+		wantErrPath: "/system/clock",
+	}, {
+		desc:        "missing list key",
+		inRoot:      &xmlDevice{},
+		inConfig:    editConfig(`<interfaces xmlns="urn:dev"><interface><config><mtu>9000</mtu></config></interface></interfaces>`),
+		wantErrTag:  ErrorTagMissingElement,
+		wantErrPath: "/interfaces/interface",
+	}, {
+		desc:        "invalid value",
+		inRoot:      &xmlDevice{Hostname: ygot.String("rtr1")},
+		inConfig:    editConfig(`<system xmlns="urn:dev"><config><mtu>jumbo</mtu></config></system>`),
+		wantErrTag:  ErrorTagInvalidValue,
+		wantErrPath: "/system/config/mtu",
+	}, {
+		desc:        "invalid default operation",
+		inRoot:      &xmlDevice{},
+		inConfig:    editConfig(``),
+		inDefaultOp: EditDelete,
+		wantErrTag:  ErrorTagInvalidValue,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, err := ygot.DeepCopy(tt.inRoot)
+			if err != nil {
+				t.Fatalf("DeepCopy: got unexpected error: %v", err)
+			}
+
+			err = ApplyEditConfig(encodingtest.Schema(), tt.inRoot, tt.inConfig, tt.inDefaultOp)
+			if tt.wantErrTag != "" {
+				nerr, ok := err.(*NETCONFError)
+				if !ok {
+					t.Fatalf("ApplyEditConfig: got error %v, want *NETCONFError with tag %s", err, tt.wantErrTag)
+				}
+				if nerr.Tag != tt.wantErrTag || nerr.Path != tt.wantErrPath {
+					t.Errorf("ApplyEditConfig: got error tag %s at %s, want: %s at %s", nerr.Tag, nerr.Path, tt.wantErrTag, tt.wantErrPath)
+				}
+				if diff := cmp.Diff(orig, tt.inRoot); diff != "" {
+					t.Errorf("ApplyEditConfig: root was modified by failed edit, diff(-want, +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyEditConfig: got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, tt.inRoot); diff != "" {
+				t.Errorf("ApplyEditConfig: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
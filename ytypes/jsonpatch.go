@@ -0,0 +1,327 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc6902 for the semantics of JSON
+// Patch, and https://tools.ietf.org/html/rfc6901 for JSON Pointer.
+
+// jsonPatchOp is an operation of a JSON Patch document.
+type jsonPatchOp struct {
+	Op    string           `json:"op"`
+	Path  *string          `json:"path"`
+	From  *string          `json:"from"`
+	Value *json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies the JSON Patch document patch, as per RFC6902, to
+// the RFC7951 JSON of root, with module names appended, as returned by
+// ygot.ConstructIETFJSON. The root must be the GoStruct described by schema.
+// All of the operations of RFC6902 are supported, and are applied in order,
+// such that the JSON Pointer of each operation identifies a location within
+// the document once the preceding operations have been applied. The
+// resulting document is unmarshalled into root.
+//
+// The patch is applied atomically - if any of its operations fails, or the
+// resulting document is not valid for the schema, root is left unchanged.
+func ApplyJSONPatch(schema *yang.Entry, root ygot.GoStruct, patch []byte) error {
+	switch {
+	case schema == nil:
+		return fmt.Errorf("nil schema")
+	case util.IsValueNil(root):
+		return fmt.Errorf("nil root")
+	}
+
+	var ops []*jsonPatchOp
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("cannot decode JSON Patch: %v", err)
+	}
+
+	tree, err := editTree(root, &ygot.RFC7951JSONConfig{AppendModuleName: true})
+	if err != nil {
+		return err
+	}
+	var doc interface{} = tree
+	for i, op := range ops {
+		if doc, err = applyJSONPatchOp(doc, op); err != nil {
+			path := "<nil>"
+			if op.Path != nil {
+				path = *op.Path
+			}
+			return fmt.Errorf("JSON Patch operation %d (%s %s): %v", i, op.Op, path, err)
+		}
+	}
+
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("patched document is %T, not an object", doc)
+	}
+	n := reflect.New(reflect.TypeOf(root).Elem())
+	if err := Unmarshal(schema, n.Interface(), m); err != nil {
+		return fmt.Errorf("cannot unmarshal patched document: %v", err)
+	}
+	reflect.ValueOf(root).Elem().Set(n.Elem())
+	return nil
+}
+
+// applyJSONPatchOp applies the operation op to the JSON document doc, and
+// returns the resulting document.
+func applyJSONPatchOp(doc interface{}, op *jsonPatchOp) (interface{}, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("operation does not specify a path")
+	}
+	path, err := parseJSONPointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var val interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("operation does not specify a value")
+		}
+		if err := json.Unmarshal(*op.Value, &val); err != nil {
+			return nil, fmt.Errorf("cannot decode value: %v", err)
+		}
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("operation does not specify from")
+		}
+		from, err := parseJSONPointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+		if val, err = jsonPointerValue(doc, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return jsonPatchAdd(doc, path, copyJSONValue(val))
+		}
+		if strings.HasPrefix(*op.Path, *op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s to its descendant", *op.From)
+		}
+		if doc, err = jsonPatchRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return jsonPatchAdd(doc, path, val)
+	case "remove":
+	default:
+		return nil, fmt.Errorf("invalid operation %q", op.Op)
+	}
+
+	switch op.Op {
+	case "add":
+		return jsonPatchAdd(doc, path, val)
+	case "remove":
+		return jsonPatchRemove(doc, path)
+	case "replace":
+		if _, err := jsonPointerValue(doc, path); err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return val, nil
+		}
+		return jsonPatchAt(doc, path, func(c interface{}, tok string) (interface{}, error) {
+			switch c := c.(type) {
+			case map[string]interface{}:
+				c[tok] = val
+			case []interface{}:
+				i, _ := jsonArrayIndex(c, tok, false)
+				c[i] = val
+			}
+			return c, nil
+		})
+	}
+	// The operation is test.
+	cur, err := jsonPointerValue(doc, path)
+	if err != nil {
+		return nil, err
+	}
+	if !reflect.DeepEqual(cur, val) {
+		return nil, fmt.Errorf("value %v is not equal to %v", cur, val)
+	}
+	return doc, nil
+}
+
+// parseJSONPointer returns the reference tokens of the JSON Pointer p.
+func parseJSONPointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(p, "/") {
+		return nil, fmt.Errorf("invalid JSON Pointer %q, must start with /", p)
+	}
+	toks := strings.Split(p[1:], "/")
+	for i, t := range toks {
+		toks[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return toks, nil
+}
+
+// jsonArrayIndex returns the index of the JSON array l that is referenced by
+// the token tok. If add is true, the index may be equal to the length of l,
+// or the token may be "-", to reference the position following the last
+// element.
+func jsonArrayIndex(l []interface{}, tok string, add bool) (int, error) {
+	max := len(l) - 1
+	if add {
+		max = len(l)
+		if tok == "-" {
+			return len(l), nil
+		}
+	}
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || (len(tok) > 1 && tok[0] == '0') || tok[0] == '+' {
+		return 0, fmt.Errorf("invalid array index %q", tok)
+	}
+	if i > max {
+		return 0, fmt.Errorf("array index %d is out of range", i)
+	}
+	return i, nil
+}
+
+// jsonPointerValue returns the value within the JSON document doc that is
+// referenced by the tokens path.
+func jsonPointerValue(doc interface{}, path []string) (interface{}, error) {
+	v := doc
+	for _, tok := range path {
+		switch c := v.(type) {
+		case map[string]interface{}:
+			cv, ok := c[tok]
+			if !ok {
+				return nil, fmt.Errorf("member %q does not exist", tok)
+			}
+			v = cv
+		case []interface{}:
+			i, err := jsonArrayIndex(c, tok, false)
+			if err != nil {
+				return nil, err
+			}
+			v = c[i]
+		default:
+			return nil, fmt.Errorf("cannot reference %q within %T value", tok, v)
+		}
+	}
+	return v, nil
+}
+
+// jsonPatchAt calls fn with the object or array within the JSON document doc
+// that contains the location referenced by path, which must not be empty,
+// along with the last token of path. The value returned by fn replaces the
+// container within doc, and the resulting document is returned.
+func jsonPatchAt(doc interface{}, path []string, fn func(c interface{}, tok string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		switch doc.(type) {
+		case map[string]interface{}, []interface{}:
+			return fn(doc, path[0])
+		}
+		return nil, fmt.Errorf("cannot reference %q within %T value", path[0], doc)
+	}
+
+	child, err := jsonPointerValue(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	nc, err := jsonPatchAt(child, path[1:], fn)
+	if err != nil {
+		return nil, err
+	}
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		c[path[0]] = nc
+	case []interface{}:
+		i, _ := jsonArrayIndex(c, path[0], false)
+		c[i] = nc
+	}
+	return doc, nil
+}
+
+// jsonPatchAdd adds the value val to the JSON document doc at the location
+// referenced by path, and returns the resulting document.
+func jsonPatchAdd(doc interface{}, path []string, val interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return val, nil
+	}
+	return jsonPatchAt(doc, path, func(c interface{}, tok string) (interface{}, error) {
+		switch c := c.(type) {
+		case map[string]interface{}:
+			c[tok] = val
+			return c, nil
+		case []interface{}:
+			i, err := jsonArrayIndex(c, tok, true)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], append([]interface{}{val}, c[i:]...)...), nil
+		}
+		return c, nil
+	})
+}
+
+// jsonPatchRemove removes the value at the location referenced by path from
+// the JSON document doc, and returns the resulting document.
+func jsonPatchRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the document")
+	}
+	return jsonPatchAt(doc, path, func(c interface{}, tok string) (interface{}, error) {
+		switch c := c.(type) {
+		case map[string]interface{}:
+			if _, ok := c[tok]; !ok {
+				return nil, fmt.Errorf("member %q does not exist", tok)
+			}
+			delete(c, tok)
+			return c, nil
+		case []interface{}:
+			i, err := jsonArrayIndex(c, tok, false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return c, nil
+	})
+}
+
+// copyJSONValue returns a deep copy of the JSON value v.
+func copyJSONValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, cv := range v {
+			m[k] = copyJSONValue(cv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, cv := range v {
+			l[i] = copyJSONValue(cv)
+		}
+		return l
+	}
+	return v
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+func TestApplyJSONPatch(t *testing.T) {
+	twoIntfs := func() map[string]*patchInterface {
+		return map[string]*patchInterface{
+			"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500)},
+			"eth1": {Name: ygot.String("eth1")},
+		}
+	}
+
+	tests := []struct {
+		desc             string
+		inRoot           *patchDevice
+		inPatch          string
+		want             *patchDevice
+		wantErrSubstring string
+	}{{
+		desc:    "add and replace leaves",
+		inRoot:  &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch: `[{"op": "add", "path": "/system/config/dns", "value": ["10.0.0.1"]}, {"op": "replace", "path": "/system/config/hostname", "value": "rtr2"}]`,
+		want:    &patchDevice{Hostname: ygot.String("rtr2"), DNS: []string{"10.0.0.1"}},
+	}, {
+		desc:    "add container",
+		inRoot:  &patchDevice{},
+		inPatch: `[{"op": "add", "path": "/routes", "value": {"route": [{"prefix": "10.0.0.0/8", "vrf": 42, "next-hop": "192.0.2.1"}]}}]`,
+		want:    &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1")},
+	}, {
+		desc:    "remove list entry",
+		inRoot:  &patchDevice{Interface: twoIntfs()},
+		inPatch: `[{"op": "remove", "path": "/interfaces/interface/1"}]`,
+		want:    &patchDevice{Interface: map[string]*patchInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500)}}},
+	}, {
+		desc:    "add and move entries of ordered list",
+		inRoot:  &patchDevice{Rule: patchRules("a", "b", "c")},
+		inPatch: `[{"op": "add", "path": "/rules/rule/-", "value": {"key": "d"}}, {"op": "move", "from": "/rules/rule/2", "path": "/rules/rule/0"}, {"op": "add", "path": "/rules/rule/1", "value": {"key": "e"}}]`,
+		want:    &patchDevice{Rule: patchRules("c", "e", "a", "b", "d")},
+	}, {
+		desc:    "copy and test",
+		inRoot:  &patchDevice{Hostname: ygot.String("rtr1"), Interface: twoIntfs()},
+		inPatch: `[{"op": "test", "path": "/interfaces/interface/0/config/mtu", "value": 1500}, {"op": "copy", "from": "/interfaces/interface/0/config/name", "path": "/system/config/hostname"}]`,
+		want:    &patchDevice{Hostname: ygot.String("eth0"), Interface: twoIntfs()},
+	}, {
+		desc:             "test fails",
+		inRoot:           &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch:          `[{"op": "replace", "path": "/system/config/hostname", "value": "rtr2"}, {"op": "test", "path": "/system/config/hostname", "value": "rtr1"}]`,
+		wantErrSubstring: "JSON Patch operation 1 (test /system/config/hostname): value rtr2 is not equal to rtr1",
+	}, {
+		desc:             "remove missing member",
+		inRoot:           &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch:          `[{"op": "remove", "path": "/system/config/dns"}]`,
+		wantErrSubstring: `member "dns" does not exist`,
+	}, {
+		desc:             "replace missing member",
+		inRoot:           &patchDevice{},
+		inPatch:          `[{"op": "replace", "path": "/system", "value": {}}]`,
+		wantErrSubstring: `member "system" does not exist`,
+	}, {
+		desc:             "array index with leading zero",
+		inRoot:           &patchDevice{Rule: patchRules("a", "b")},
+		inPatch:          `[{"op": "remove", "path": "/rules/rule/01"}]`,
+		wantErrSubstring: `invalid array index "01"`,
+	}, {
+		desc:             "array index out of range",
+		inRoot:           &patchDevice{Rule: patchRules("a", "b")},
+		inPatch:          `[{"op": "add", "path": "/rules/rule/3", "value": {"key": "c"}}]`,
+		wantErrSubstring: "array index 3 is out of range",
+	}, {
+		desc:             "move to descendant",
+		inRoot:           &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch:          `[{"op": "move", "from": "/system", "path": "/system/config"}]`,
+		wantErrSubstring: "cannot move /system to its descendant",
+	}, {
+		desc:             "remove document",
+		inRoot:           &patchDevice{},
+		inPatch:          `[{"op": "remove", "path": ""}]`,
+		wantErrSubstring: "cannot remove the document",
+	}, {
+		desc:             "missing value",
+		inRoot:           &patchDevice{},
+		inPatch:          `[{"op": "add", "path": "/system"}]`,
+		wantErrSubstring: "operation does not specify a value",
+	}, {
+		desc:             "invalid operation",
+		inRoot:           &patchDevice{},
+		inPatch:          `[{"op": "merge", "path": "/system", "value": {}}]`,
+		wantErrSubstring: `invalid operation "merge"`,
+	}, {
+		desc:             "invalid pointer",
+		inRoot:           &patchDevice{},
+		inPatch:          `[{"op": "add", "path": "system", "value": {}}]`,
+		wantErrSubstring: `invalid JSON Pointer "system"`,
+	}, {
+		desc:             "invalid value",
+		inRoot:           &patchDevice{Interface: twoIntfs()},
+		inPatch:          `[{"op": "replace", "path": "/interfaces/interface/0/config/mtu", "value": "jumbo"}]`,
+		wantErrSubstring: "cannot unmarshal patched document",
+	}, {
+		desc:             "invalid document",
+		inRoot:           &patchDevice{},
+		inPatch:          `{"op": "add"}`,
+		wantErrSubstring: "cannot decode JSON Patch",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, err := ygot.DeepCopy(tt.inRoot)
+			if err != nil {
+				t.Fatalf("DeepCopy: got unexpected error: %v", err)
+			}
+
+			err = ApplyJSONPatch(encodingtest.Schema(), tt.inRoot, []byte(tt.inPatch))
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("ApplyJSONPatch: did not get expected error, %s", diff)
+			}
+			want := tt.want
+			if err != nil {
+				want = orig.(*patchDevice)
+			}
+			if diff := cmp.Diff(want, tt.inRoot, cmp.AllowUnexported(orderedListElems{})); diff != "" {
+				t.Errorf("ApplyJSONPatch: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseJSONPointer(t *testing.T) {
+	got, err := parseJSONPointer("/a~1b/c~0d/~01")
+	if err != nil {
+		t.Fatalf("parseJSONPointer: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a/b", "c~d", "~1"}, got); diff != "" {
+		t.Errorf("parseJSONPointer: did not get expected tokens, diff(-want, +got):\n%s", diff)
+	}
+}
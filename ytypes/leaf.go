@@ -17,6 +17,7 @@ package ytypes
 import (
 	"encoding/base64"
 	"fmt"
+	"math"
 	"math/big"
 	"reflect"
 	"strconv"
@@ -35,7 +36,8 @@ import (
 // This value is expected to be a Go basic type corresponding to the leaf
 // schema type.
 func validateLeaf(inSchema *yang.Entry, value interface{}) util.Errors {
-	// TODO(mostrowski): "mandatory" not implemented.
+	// Mandatory leaves are checked by validateMandatory, since a leaf that is
+	// not populated has no value to validate.
 	if util.IsValueNil(value) {
 		return nil
 	}
@@ -79,9 +81,7 @@ func validateLeaf(inSchema *yang.Entry, value interface{}) util.Errors {
 	case yang.Ybinary:
 		return util.NewErrs(validateBinary(schema, value))
 	case yang.Ybits:
-		return nil
-		// TODO(mostrowski): restore when representation is decided.
-		//return util.NewErrs(validateBitset(schema, rv))
+		return util.NewErrs(validateBits(schema, rv))
 	case yang.Ybool:
 		return util.NewErrs(validateBool(schema, rv))
 	case yang.Yempty:
@@ -362,11 +362,6 @@ func unmarshalLeaf(inSchema *yang.Entry, parent interface{}, value interface{},
 		return unmarshalUnion(schema, parent, fieldName, value, enc)
 	}
 
-	if ykind == yang.Ybits {
-		// TODO(mostrowski)
-		return nil
-	}
-
 	v, err := unmarshalScalar(parent, schema, fieldName, value, enc)
 	if err != nil {
 		return err
@@ -705,8 +700,7 @@ func sanitizeJSON(parent interface{}, schema *yang.Entry, fieldName string, valu
 		return true, nil
 
 	case yang.Ybits:
-		// TODO(mostrowski)
-		return nil, nil
+		return bitsStringToValue(parent, fieldName, value.(string))
 
 	case yang.Ybool:
 		return value.(bool), nil
@@ -715,12 +709,12 @@ func sanitizeJSON(parent interface{}, schema *yang.Entry, fieldName string, valu
 		return value.(string), nil
 
 	case yang.Ydecimal64:
-		floatV, err := strconv.ParseFloat(value.(string), 64)
+		decV, err := decimalStringToValue(parent, schema, fieldName, value.(string))
 		if err != nil {
 			return nil, fmt.Errorf("error parsing %v for schema %s: %v", value, schema.Name, err)
 		}
 
-		return floatV, nil
+		return decV, nil
 
 	case yang.Yenum, yang.Yidentityref:
 		return enumStringToValue(parent, fieldName, value.(string))
@@ -781,6 +775,8 @@ func sanitizeGNMI(parent interface{}, schema *yang.Entry, fieldName string, tv *
 		return tv.GetStringVal(), nil
 	case yang.Yenum, yang.Yidentityref:
 		return enumStringToValue(parent, fieldName, tv.GetStringVal())
+	case yang.Ybits:
+		return bitsStringToValue(parent, fieldName, tv.GetStringVal())
 	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64:
 		gt := reflect.TypeOf(yangBuiltinTypeToGoType(ykind))
 		vs := fmt.Sprintf("%v", tv.GetIntVal())
@@ -800,8 +796,23 @@ func sanitizeGNMI(parent interface{}, schema *yang.Entry, fieldName string, tv *
 	case yang.Ybinary:
 		return tv.GetBytesVal(), nil
 	case yang.Ydecimal64:
+		exact, err := isDecimal64Field(parent, fieldName)
+		if err != nil {
+			return nil, err
+		}
+		fd := uint8(schema.Type.FractionDigits)
 		switch v := tv.GetValue().(type) {
 		case *gpb.TypedValue_DecimalVal:
+			if exact {
+				// The precision of the received value may differ from the
+				// fraction digits of the schema, so the value is rescaled,
+				// which fails if the value cannot be represented exactly.
+				p := v.DecimalVal.Precision
+				if p > math.MaxUint8 {
+					return nil, fmt.Errorf("invalid precision %d for decimal64 value", p)
+				}
+				return ygot.ParseDecimal64(string(ygot.NewDecimal64(v.DecimalVal.Digits, uint8(p))), fd)
+			}
 			prec := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(v.DecimalVal.Precision)), nil)
 			// Second return value indicates whether returned float64 value exactly
 			// represents the division. We don't want to fail unmarshalling as float64
@@ -810,6 +821,9 @@ func sanitizeGNMI(parent interface{}, schema *yang.Entry, fieldName string, tv *
 			fv, _ := new(big.Rat).SetFrac(big.NewInt(v.DecimalVal.Digits), prec).Float64()
 			return fv, nil
 		case *gpb.TypedValue_FloatVal:
+			if exact {
+				return ygot.ParseDecimal64(strconv.FormatFloat(float64(v.FloatVal), 'f', -1, 32), fd)
+			}
 			return float64(v.FloatVal), nil
 		}
 	}
@@ -825,7 +839,7 @@ func gNMIToYANGTypeMatches(ykind yang.TypeKind, tv *gpb.TypedValue) bool {
 	switch ykind {
 	case yang.Ybool:
 		_, ok = tv.GetValue().(*gpb.TypedValue_BoolVal)
-	case yang.Ystring, yang.Yenum, yang.Yidentityref:
+	case yang.Ystring, yang.Yenum, yang.Yidentityref, yang.Ybits:
 		_, ok = tv.GetValue().(*gpb.TypedValue_StringVal)
 	case yang.Yint8, yang.Yint16, yang.Yint32, yang.Yint64:
 		_, ok = tv.GetValue().(*gpb.TypedValue_IntVal)
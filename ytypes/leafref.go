@@ -15,29 +15,26 @@
 package ytypes
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
-	"reflect"
 	"strings"
 
 	log "github.com/golang/glog"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
-	"github.com/openconfig/ygot/ygot"
 
 	gpb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 // ValidateLeafRefData traverses the entire tree with root value and the given
-// corresponding schema. For the referring node A, the leafref will point to a
-// value set B which may be empty. For each element in B:
-// - if the element is a leaf, it checks whether A == B
-// - if the element is a leaf list C, it check whether A is equal to
-//   any of the elements of C.
-// It returns nil if at least one equality check passes or an error otherwise.
-// It also returns an error if any leafref points to a value outside of the tree
-// rooted at value; therefore it should only be called on the root node of the
+// corresponding schema. For each referring node A, the leafref path of A is
+// evaluated as an XPath expression, with A as the context node, to give a
+// node-set B which may be empty. It returns an error if B is empty, or if the
+// value of A is not equal to the value of any node in B. Leafref paths may
+// contain any number of predicates, nested paths and functions including
+// current() and deref(). Leafrefs whose type has require-instance false are
+// not required to refer to an existing node, and hence are not checked.
+// Paths which lead outside of the tree rooted at value
+// select no nodes; therefore it should only be called on the root node of the
 // entire data tree. The supplied LeafrefOptions specify particular behaviours
 // of the leafref validation such as ignoring missing pointed to elements.
 func ValidateLeafRefData(schema *yang.Entry, value interface{}, opt *LeafrefOptions) util.Errors {
@@ -46,53 +43,115 @@ func ValidateLeafRefData(schema *yang.Entry, value interface{}, opt *LeafrefOpti
 	if opt != nil && opt.IgnoreMissingData {
 		return nil
 	}
+	if util.IsValueNil(value) {
+		return nil
+	}
 
-	// validateLeafRefDataIterFunc is called on every node in the tree through
-	// ForEachField below.
-	validateLeafRefDataIterFunc := func(ni *util.NodeInfo, in, out interface{}) util.Errors {
-		if util.IsValueNil(ni) || util.IsNilOrInvalidValue(ni.FieldValue) {
-			return nil
-		}
-		schema := ni.Schema
-		if schema == nil {
-			return util.NewErrs(fmt.Errorf("schema is nil for value %s, type %T", util.ValueStr(value), value))
-		}
-		if !util.IsLeafRef(schema) || schema.IsLeafList() {
-			return nil
-		}
+	start, err := util.NewXPathTree(schema, value)
+	if err != nil {
+		return util.NewErrs(err)
+	}
+	if start == nil {
+		return nil
+	}
 
-		pathQueryNode, ok := in.(*util.PathQueryNodeMemo)
-		if !ok {
-			return util.NewErrs(fmt.Errorf("expected input to validateLeafRefDataIterFunc to be type *util.PathQueryNodeMemo, but got %T", in))
-		}
-		gNMIPath, err := leafRefToGNMIPath(ni, schema.Type.Path, pathQueryNode)
-		if err != nil {
-			return util.NewErrs(err)
-		}
-		matchNodes, err := dataNodesAtPath(ni, gNMIPath, pathQueryNode)
-		if err != nil {
-			return util.NewErrs(err)
+	var errs util.Errors
+	parsed := map[string]util.XPathExpr{}
+	start.Walk(func(n *util.XPathNode) bool {
+		if n.Outside() || !util.IsLeafRef(n.Schema()) || n.Schema().Type.OptionalInstance {
+			return true
 		}
+		errs = util.AppendErrs(errs, validateLeafRefNode(n, parsed, opt))
+		return true
+	})
+	return errs
+}
 
-		pathStr := util.StripModulePrefixesStr(schema.Type.Path)
-		util.DbgPrint("Verifying leafref at %s, matching nodes are: %v", pathStr, util.ValueStrDebug(matchNodes))
+// validateLeafRefNode validates that the value of the leaf or leaf-list
+// element n, which is a leafref, is equal to one of the nodes that are
+// selected by its leafref path. Parsed leafref paths are cached in parsed.
+func validateLeafRefNode(n *util.XPathNode, parsed map[string]util.XPathExpr, opt *LeafrefOptions) util.Errors {
+	schema := n.Schema()
+	e, err := parseLeafRefPath(schema, parsed)
+	if err != nil {
+		return util.NewErrs(err)
+	}
+	targets, err := leafRefTargets(e, n)
+	if err != nil {
+		return util.NewErrs(err)
+	}
 
-		match, err := matchesNodes(ni, matchNodes)
-		if err != nil {
-			return leafrefErrOrLog(util.NewErrs(err), opt)
-		}
-		if !match {
-			e := fmt.Errorf("field name %s value %s schema path %s has leafref path %s not equal to any target nodes",
-				ni.StructField.Name, util.ValueStr(ni.FieldValue.Interface()), ni.Schema.Path(), pathStr)
-			util.DbgPrint("ERR: %s", e)
-			return leafrefErrOrLog(util.NewErrs(e), opt)
+	pathStr := util.StripModulePrefixesStr(schema.Type.Path)
+	util.DbgPrint("Verifying leafref at %s, matching nodes are: %v", pathStr, targets)
+
+	if len(targets) == 0 {
+		err = fmt.Errorf("pointed-to value with path %s from field %s value %s schema %s is empty set",
+			pathStr, n.FieldName(), util.ValueStr(n.Value()), schema.Path())
+		util.DbgPrint("ERR: %s", err)
+		return leafrefErrOrLog(util.NewErrs(err), opt)
+	}
+
+	val := n.StringValue()
+	for _, t := range targets {
+		if t.StringValue() == val {
+			return nil
 		}
+	}
+	err = fmt.Errorf("field name %s value %s schema path %s has leafref path %s not equal to any target nodes",
+		n.FieldName(), util.ValueStr(n.Value()), schema.Path(), pathStr)
+	util.DbgPrint("ERR: %s", err)
+	return leafrefErrOrLog(util.NewErrs(err), opt)
+}
 
-		return nil
+// parseLeafRefPath returns the parsed path of the leafref schema. Parsed
+// paths are cached in parsed.
+func parseLeafRefPath(schema *yang.Entry, parsed map[string]util.XPathExpr) (util.XPathExpr, error) {
+	if e, ok := parsed[schema.Type.Path]; ok {
+		return e, nil
+	}
+	e, err := util.ParseXPath(schema.Type.Path)
+	if err != nil {
+		return nil, fmt.Errorf("schema path %s: invalid leafref path: %v", schema.Path(), err)
 	}
+	parsed[schema.Type.Path] = e
+	return e, nil
+}
 
-	pathQueryRootNode := &util.PathQueryNodeMemo{Memo: util.PathQueryMemo{}}
-	return util.ForEachField(schema, value, pathQueryRootNode, nil, validateLeafRefDataIterFunc)
+// leafRefTargets returns the nodes that are selected by the parsed leafref
+// path e of the leafref node n.
+func leafRefTargets(e util.XPathExpr, n *util.XPathNode) ([]*util.XPathNode, error) {
+	v, err := util.EvalXPath(clampLeafRefPath(e, n), n)
+	if err != nil {
+		return nil, fmt.Errorf("schema path %s: cannot evaluate leafref path %s: %v", n.Schema().Path(), n.Schema().Type.Path, err)
+	}
+	targets, ok := v.([]*util.XPathNode)
+	if !ok {
+		return nil, fmt.Errorf("schema path %s: leafref path %s does not evaluate to a node-set", n.Schema().Path(), n.Schema().Type.Path)
+	}
+	return targets, nil
+}
+
+// clampLeafRefPath returns the relative leafref path e of the node n with
+// any leading ".." steps that lead above the root of the data tree removed,
+// such that they stop at the root. This retains the tolerance of earlier
+// implementations for paths that contain too many ".." steps.
+func clampLeafRefPath(e util.XPathExpr, n *util.XPathNode) util.XPathExpr {
+	lp, ok := e.(*util.XPathLocationPath)
+	if !ok || lp.Absolute {
+		return e
+	}
+	var up int
+	for up < len(lp.Steps) && lp.Steps[up].String() == ".." {
+		up++
+	}
+	var depth int
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		depth++
+	}
+	if up <= depth {
+		return e
+	}
+	return &util.XPathLocationPath{Steps: lp.Steps[up-depth:]}
 }
 
 // leafrefErrOrLog returns an error if the global ValidationOptions specifies
@@ -111,418 +170,234 @@ func leafrefErrOrLog(e util.Errors, opt *LeafrefOptions) util.Errors {
 	return nil
 }
 
-// leafRefToGNMIPath takes a leafref path string and transforms any leafref
-// path references of the form a[k1 = ../path/to/val and k2 = ...] to a GNMI
-// path where the key values are the values being referenced i.e.
-// ../path/to/val above is replaced with the actual value at that path.
-func leafRefToGNMIPath(root *util.NodeInfo, path string, pathQueryNode *util.PathQueryNodeMemo) (*gpb.Path, error) {
-	pv := util.SplitPath(path)
-	out := &gpb.Path{}
-
-	for _, p := range pv {
-		prefix, k, v, err := extractKeyValue(p)
-		if err != nil {
-			return nil, err
-		}
-		gp := &gpb.PathElem{Name: prefix}
-
-		switch {
-		case k == "":
-		// No kvs, path element is just the prefix.
-		case isInQuotes(v):
-			// Value should be treated as a literal, just strip off the quotes.
-			gp.Key = map[string]string{k: v[1 : len(v)-1]}
-		default:
-			// The value is a path, need to replace it with the actual val at
-			// the indicated path.
-			// current() can only mean the unique current node in the subtree
-			// branch of the node containing the leafref. It can be removed
-			// since it is implicit.
-			v = strings.TrimPrefix(v, "current()/")
-
-			gp.Key = make(map[string]string)
-			ns, err := dataNodesAtPath(root, pathNoKeysToGNMIPath(v), pathQueryNode)
-			var j []byte
-			switch len(ns) {
-			case 0:
-			case 1:
-				actualValue := ns[0]
-				if err != nil {
-					return nil, err
-				}
-				j, err = json.Marshal(actualValue)
-				if err != nil {
-					return nil, err
-				}
-			default:
-				return nil, fmt.Errorf("expect single node to match value at path %s, got %d", v, len(ns))
-			}
-
-			gp.Key = map[string]string{k: removeQuotes(string(j))}
+// PopulateLeafRefTargets is an unmarshal option that specifies that, once
+// the data tree has been unmarshalled, a keyed list member should be created
+// for each list key leafref that refers to a member that does not exist. For
+// example, if the key of an entry of the OpenConfig
+// /lldp/interfaces/interface list refers to an interface that is not in the
+// data tree, an entry in /interfaces/interface with the same name is
+// created. Only the key of each member that is created is populated.
+//
+// A leafref is considered if it is the key of a list, or if it is the target
+// of a key of a list which is itself a leafref. Leafrefs with
+// require-instance false, those whose path contains predicates, and those
+// that refer to nodes outside of the unmarshalled data tree are not
+// considered.
+//
+// When supplied to SetNode with InitMissingElements, the option specifies
+// that the targets of the leafref keys of each list member that is created,
+// such as the config/name leaf of an OpenConfig interface, are set to the
+// value of the key.
+type PopulateLeafRefTargets struct{}
+
+// IsUnmarshalOpt marks PopulateLeafRefTargets as a valid UnmarshalOpt.
+func (*PopulateLeafRefTargets) IsUnmarshalOpt() {}
+
+// IsSetNodeOpt marks PopulateLeafRefTargets as a valid SetNodeOpt.
+func (*PopulateLeafRefTargets) IsSetNodeOpt() {}
+
+// hasPopulateLeafRefTargets determines whether the supplied slice of
+// UnmarshalOpts contains the PopulateLeafRefTargets option.
+func hasPopulateLeafRefTargets(opts []UnmarshalOpt) bool {
+	for _, o := range opts {
+		if _, ok := o.(*PopulateLeafRefTargets); ok {
+			return true
 		}
-		out.Elem = append(out.Elem, gp)
 	}
-
-	return out, nil
+	return false
 }
 
-// dataNodesAtPath returns all nodes that match the given path from the given
-// node.
-func dataNodesAtPath(ni *util.NodeInfo, path *gpb.Path, pathQueryNode *util.PathQueryNodeMemo) ([]interface{}, error) {
-	util.DbgPrint("DataNodeAtPath got leafref with path %s from node path %s, field name %s", path, ni.Schema.Path(), ni.StructField.Name)
-	if path == nil || len(path.GetElem()) == 0 {
-		return []interface{}{ni}, nil
-	}
-	root := getDataTreeRoot(ni)
-	pathQueryRoot := pathQueryNode.GetRoot()
-	if path.GetElem()[0].GetName() == "" {
-		// absolute path
-		path.Elem = path.GetElem()[1:]
-	} else {
-		// relative path, go up the data tree
-		root = ni
-		pathQueryRoot = pathQueryNode
-		for len(path.GetElem()) != 0 && path.GetElem()[0].GetName() == ".." {
-			if root.Parent == nil {
-				return nil, fmt.Errorf("no parent for leafref path at %v, with remaining path %s", ni.Schema.Path(), path)
-			}
-			if !util.IsCompressedSchema(root.Schema) && root.Parent.Schema.IsList() && util.IsValueMap(root.Parent.FieldValue) {
-				// If we are in an uncompressed schema, then we have one more level of the data tree than
-				// the YANG expects, since our data tree layout is:
-				// struct (parent container)
-				//  --> map (the list)
-				//  --> struct (the list member)
-				//
-				// In YANG, .. from the list member struct gets us to the parent container, but for us
-				// we have only reached the map. This means that we end up over-consuming the ".."s. To
-				// avoid this issue, if we are in an uncompressed schema, and in a list, and we find
-				// that we're looking at the map, we consume another level of the data tree. This gets
-				// us to the parent container with ".." as would be expected.
-				//
-				// This is NOT required for the compressed schema, because in this case, we have removed
-				// a level of the data tree. So the parent container in the above example will have been
-				// removed. This is enforced by ygen. In this case, we do want to consume the extra level
-				// of ..s in the list case, such that we do not end up under-consuming them.
-				root = root.Parent
-				pathQueryRoot = pathQueryRoot.Parent
+// populateLeafRefTargets creates the list members that are referred to by
+// list key leafrefs within root, which has the supplied container schema,
+// but which do not exist. Since the members that are created may themselves
+// have keys that are leafrefs, this is repeated until no further members
+// are created.
+func populateLeafRefTargets(schema *yang.Entry, root interface{}) error {
+	created := map[string]bool{}
+	for {
+		paths, err := missingLeafRefTargets(schema, root)
+		if err != nil {
+			return err
+		}
+
+		var n int
+		for _, p := range paths {
+			ps := p.String()
+			if created[ps] {
 				continue
-			} else {
-				path.Elem = removeParentDirPrefix(path.GetElem(), root.PathFromParent)
-				util.DbgPrint("going up data tree from type %s to %s, schema path from parent is %v, remaining path %v",
-					root.FieldValue.Type(), root.Parent.FieldValue.Type(), root.PathFromParent, path)
-				root = root.Parent
-				pathQueryRoot = pathQueryRoot.Parent
 			}
+			if _, err := retrieveNode(schema, root, p, nil, retrieveNodeArgs{modifyRoot: true, populateKeyTargets: true}); err != nil {
+				return fmt.Errorf("cannot create leafref target %v: %v", p, err)
+			}
+			created[ps] = true
+			n++
+		}
+		if n == 0 {
+			return nil
 		}
 	}
+}
 
-	util.DbgPrint("root element type %s with remaining path %s", root.FieldValue.Type(), path)
-
-	// Check whether we have already done a lookup for the path specified by 'path' from this node before
-	// -- if so, return it from the cache rather than walking the tree again
-
-	// Get the query path for this node.
-	strPath, err := ygot.PathToString(path)
+// missingLeafRefTargets returns the paths, relative to root, of the list
+// members that are referred to by list key leafrefs within root, which has
+// the supplied schema, but do not exist.
+func missingLeafRefTargets(schema *yang.Entry, root interface{}) ([]*gpb.Path, error) {
+	start, err := util.NewXPathTree(schema, root)
 	if err != nil {
 		return nil, err
 	}
-
-	// Now, check for a previous identical query in the memo map.
-	qVal, ok := pathQueryRoot.Memo[strPath]
-	if ok {
-		return qVal.Nodes, qVal.Err
-	}
-	nodes, _, err := util.GetNodes(root.Schema, root.FieldValue.Interface(), path)
-	pathQueryRoot.Memo[strPath] = util.PathQueryResult{Nodes: nodes, Err: err}
-	return nodes, err
-}
-
-// removeParentDirPrefix removes the leading .. from path and returns the
-// remaining path from the parent node, restoring any compressed out path
-// elements along the way.
-func removeParentDirPrefix(path []*gpb.PathElem, pathFromParent []string) []*gpb.PathElem {
-	plen := len(pathFromParent)
-	out := path
-	for len(out) > 0 && out[0].GetName() == ".." && plen > 0 {
-		out = out[1:]
-		plen--
-	}
-	// If we are inside a compressed node, restore the compressed out part
-	// of the path when we go up to the parent.
-	for i := 0; i < plen; i++ {
-		out = append([]*gpb.PathElem{{Name: pathFromParent[i]}}, out...)
-	}
-	return out
-}
-
-// matchesNodes reports whether ni matches any of the elements in matchNodes.
-// matchNodes may contain one or more leaf-lists, in which case ni is compared
-// against each value in the leaf-list.
-func matchesNodes(ni *util.NodeInfo, matchNodes []interface{}) (bool, error) {
-	// Handle source or destination being empty.
-	pathStr := util.StripModulePrefixesStr(ni.Schema.Type.Path)
-	if util.IsNilOrInvalidValue(ni.FieldValue) || util.IsValueNilOrDefault(ni.FieldValue.Interface()) {
-		if len(matchNodes) == 0 {
-			util.DbgPrint("OK: source value is nil, dest is empty or list")
-			return true, nil
-		}
-		other := matchNodes[0]
-		if util.IsValueNilOrDefault(other) {
-			util.DbgPrint("OK: both values are nil for leafref")
-			return true, nil
-		}
-		return true, nil
-	}
-	// ni is known not to be empty at this point.
-	nii := ni.FieldValue.Interface()
-	if len(matchNodes) == 0 {
-		return false, util.NewErrs(util.DbgErr(fmt.Errorf("pointed-to value with path %s from field %s value %s schema %s is empty set",
-			pathStr, ni.StructField.Name, util.ValueStr(nii), ni.Schema.Path())))
+	if start == nil {
+		return nil, nil
 	}
 
-	// Check if any of the matching data nodes is equal to the referring
-	// node value. In the case that the referring node is a list, check that
-	// each node in the list is also in the target list.
-	sourceNodes := []interface{}{nii}
-	if ni.FieldValue.Type().Kind() == reflect.Slice {
-		sourceNodes = ni.FieldValue.Elem().Interface().([]interface{})
-	}
-
-	for _, sourceNode := range sourceNodes {
-		match := false
-		for _, other := range matchNodes {
-			if util.IsValueNilOrDefault(other) {
-				continue
-			}
-			ov := reflect.ValueOf(other)
-			switch {
-			case util.IsValueScalar(ov):
-				util.DbgPrint("comparing leafref values %s vs %s", util.ValueStrDebug(sourceNode), util.ValueStrDebug(other))
-				if util.DeepEqualDerefPtrs(sourceNode, other) {
-					util.DbgPrint("values are equal")
-					match = true
-					break
-				}
-			case util.IsValueSlice(ov):
-				sourceNode := ni.FieldValue.Interface()
-				util.DbgPrint("checking whether value %s is leafref leaf-list %v", util.ValueStrDebug(sourceNode), util.ValueStrDebug(other))
-				for i := 0; i < ov.Len(); i++ {
-					if util.DeepEqualDerefPtrs(sourceNode, ov.Index(i).Interface()) {
-						util.DbgPrint("value exists in list")
-						match = true
-						break
-					}
-				}
-			case util.IsValueStructPtr(ov):
-				// TODO(robjs): clean this up.
-				// This is an interface value, which is represented as a struct pointer.
-				ovv := ov.Elem().FieldByIndex([]int{0})
-				svv := ni.FieldValue.Elem().Elem().FieldByIndex([]int{0})
-				if reflect.DeepEqual(ovv.Interface(), svv.Interface()) {
-					match = true
-					break
-				}
-			}
+	var paths []*gpb.Path
+	var errs util.Errors
+	parsed := map[string]util.XPathExpr{}
+	start.Walk(func(n *util.XPathNode) bool {
+		s := n.Schema()
+		if n.Outside() || !util.IsLeafRef(s) || s.Type.OptionalInstance || !isKeyLeafRef(s) {
+			return true
 		}
-		if !match {
-			return false, nil
+		p, err := missingLeafRefTarget(start, n, parsed)
+		switch {
+		case err != nil:
+			errs = util.AppendErr(errs, err)
+		case p != nil:
+			paths = append(paths, p)
 		}
+		return true
+	})
+	if errs != nil {
+		return nil, errs
 	}
-
-	return true, nil
+	return paths, nil
 }
 
-// getDataTreeRoot returns the root NodeInfo element for the current node.
-func getDataTreeRoot(ni *util.NodeInfo) *util.NodeInfo {
-	if ni == nil {
-		return nil
+// isKeyLeafRef returns true if the leafref schema is the key of a list, or
+// the target of a key of the closest enclosing list.
+func isKeyLeafRef(schema *yang.Entry) bool {
+	l := schema.Parent
+	for l != nil && !l.IsList() {
+		l = l.Parent
 	}
-	cur := ni.Parent
-	for cur.Parent != nil {
-		cur = cur.Parent
+	if l == nil {
+		return false
+	}
+	for _, k := range strings.Fields(l.Key) {
+		ks := l.Dir[k]
+		if ks == schema {
+			return true
+		}
+		if util.IsLeafRef(ks) {
+			if ts, err := util.FindLeafRefSchema(ks, ks.Type.Path); err == nil && ts == schema {
+				return true
+			}
+		}
 	}
-	return cur
+	return false
 }
 
-// extractKeyValue parses a string containing a key-value of the form:
-// prefix[key1 = current()/path] or prefix[key1 = "literal value"]
-// It returns the prefix, key and value from the string, if they are present
-// or empty strings otherwise.
-func extractKeyValue(p string) (prefix string, k, v string, err error) {
-	if p == "" {
-		return "", "", "", nil
-	}
-	isKV, err := isKeyValue(p)
+// missingLeafRefTarget returns the path, relative to start, of the list
+// member that is referred to by the leafref node n if it does not exist. It
+// returns nil if the member exists, or it cannot be created because the
+// target of n is not the single key of a keyed list, or the member is not
+// within the tree rooted at start.
+func missingLeafRefTarget(start, n *util.XPathNode, parsed map[string]util.XPathExpr) (*gpb.Path, error) {
+	s := n.Schema()
+	e, err := parseLeafRefPath(s, parsed)
 	if err != nil {
-		return
-	}
-	if !isKV {
-		return util.StripModulePrefix(p), "", "", nil
-	}
-
-	p1 := splitUnescapedUnquoted(p, '[')
-	p2 := splitUnescapedUnquoted(p1[1], ']')
-	kv := splitUnescapedUnquoted(p2[0], '=')
-	if len(kv) != 2 {
-		return "", "", "", fmt.Errorf("bad kv string %s", kv)
-	}
-	k = strings.TrimSpace(kv[0])
-	v = strings.TrimSpace(kv[1])
-
-	if !strings.HasPrefix(v, "current()/") && !isInQuotes(v) {
-		return "", "", "", fmt.Errorf("bad kv string %s: value must be in quotes or begin with current()/", p2[0])
+		return nil, err
 	}
-
-	return util.StripModulePrefix(p1[0]), util.StripModulePrefix(k), util.StripModulePrefix(v), nil
-}
-
-// isKeyValue reports whether p contains a valid key-value leafref path element.
-func isKeyValue(p string) (bool, error) {
-	p1 := splitUnescapedUnquoted(p, '[')
-	l1 := len(p1)
-	pv := splitUnescapedUnquoted(p, ']')
-	l2 := len(pv)
-
-	switch {
-	case l1 == 0 || l2 == 0:
-		return false, fmt.Errorf("empty path element (%s)", p)
-	case l1 == 1 && l2 == 1:
-		return false, nil
-	case l1 > 2 || l2 > 2 || l1 == 1 && l2 > 1 || l2 == 1 && l1 > 1:
-		return false, fmt.Errorf("malformed path element %s ", p1)
-	case pv[1] != "":
-		return false, fmt.Errorf("trailing chars after [...]: %s", p)
+	targets, err := leafRefTargets(e, n)
+	if err != nil {
+		return nil, err
 	}
-
-	return true, nil
-}
-
-// splitUnescaped splits source across splitCh. If splitCh is immedaitely
-// preceded by \ it is skipped.
-func splitUnescaped(source string, splitCh rune) []string {
-	var prev rune
-	var out []string
-	var w bytes.Buffer
-	doCompare := true
-
-	for _, r := range source {
-		if doCompare && r == splitCh && prev != '\\' {
-			out = append(out, w.String())
-			w.Reset()
-		} else {
-			w.WriteRune(r)
+	val := n.StringValue()
+	for _, t := range targets {
+		if t.StringValue() == val {
+			return nil, nil
 		}
-		prev = r
 	}
 
-	if w.Len() != 0 {
-		out = append(out, w.String())
+	lp, ok := e.(*util.XPathLocationPath)
+	if !ok {
+		return nil, nil
 	}
-	// If split on last char, add trailing empty value.
-	if prev == splitCh {
-		out = append(out, "")
+	ts, err := util.FindLeafRefSchema(s, s.Type.Path)
+	if err != nil {
+		return nil, err
+	}
+	list := ts.Parent
+	if list == nil || !list.IsList() || list.Key != ts.Name {
+		return nil, nil
 	}
 
-	return out
-}
-
-// splitUnescapedUnquoted splits source across splitCh. If splitCh is
-// immediately preceded by \ or inside unescaped quotes, it is skipped.
-func splitUnescapedUnquoted(source string, splitCh rune) []string {
-	var prev rune
-	var out []string
-	var w bytes.Buffer
-	doCompare := true
-
-	for _, r := range source {
-		if r == '"' && prev != '\\' {
-			doCompare = !doCompare
+	// Follow the leading parent steps of the path within the data tree,
+	// all remaining steps must select a single child by name.
+	cur, steps := n, lp.Steps
+	if lp.Absolute {
+		for cur.Parent() != nil {
+			cur = cur.Parent()
 		}
-		if doCompare && r == splitCh && prev != '\\' {
-			out = append(out, w.String())
-			w.Reset()
-		} else {
-			w.WriteRune(r)
-		}
-		prev = r
 	}
-
-	if w.Len() != 0 {
-		out = append(out, w.String())
+	for ; len(steps) > 0 && steps[0].Axis != "child"; steps = steps[1:] {
+		switch {
+		case steps[0].NodeType != "node" || len(steps[0].Predicates) != 0:
+			return nil, nil
+		case steps[0].Axis == "parent":
+			if cur = cur.Parent(); cur == nil {
+				return nil, nil
+			}
+		case steps[0].Axis != "self":
+			return nil, nil
+		}
 	}
-	// If split on last char, add trailing empty value.
-	if prev == splitCh {
-		out = append(out, "")
+	if len(steps) < 2 {
+		return nil, nil
 	}
-
-	return out
-}
-
-// splitUnquoted splits source source across splitStr. Any instance of splitStr
-// inside quotes is ignored.
-func splitUnquoted(source, splitStr string) []string {
-	var prev rune
-	var out []string
-	var w bytes.Buffer
-	doCompare := true
-
-	for i, r := range source {
-		// don't compare anything inside unquoted ".
-		if r == '"' && prev != '\\' {
-			doCompare = !doCompare
+	for _, st := range steps {
+		if st.Axis != "child" || st.NodeType != "" || st.Name == "*" || len(st.Predicates) != 0 {
+			return nil, nil
 		}
-
-		if doCompare && prev != '\\' && strings.HasPrefix(source[i:], splitStr) {
-			// splitStr is included in all elements except the first, trim it.
-			out = append(out, strings.TrimPrefix(w.String(), splitStr))
-			w.Reset()
-		} else {
-			w.WriteRune(r)
+	}
+	// Lists other than the target list cannot be traversed without keys.
+	for p := list.Parent; p != nil && p != cur.Schema(); p = p.Parent {
+		if p.IsList() {
+			return nil, nil
 		}
-		prev = r
 	}
 
-	if w.Len() != 0 {
-		out = append(out, w.String())
+	path, ok := xpathNodePath(start, cur)
+	if !ok {
+		return nil, nil
 	}
-	if strings.HasSuffix(source, splitStr) {
-		out = append(out, "")
+	for _, st := range steps[:len(steps)-1] {
+		path.Elem = append(path.Elem, &gpb.PathElem{Name: st.Name})
 	}
-
-	return out
+	path.Elem[len(path.Elem)-1].Key = map[string]string{ts.Name: val}
+	return path, nil
 }
 
-// isInQuotes reports whether s starts and ends with the quote character.
-func isInQuotes(s string) bool {
-	return strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"")
-}
-
-// removeQuotes removes quotes around s if they are present.
-func removeQuotes(s string) string {
-	out := strings.TrimPrefix(s, "\"")
-	return strings.TrimSuffix(out, "\"")
-}
-
-// pathNoKeysToGNMIPath converts the supplied path, which may not contain any
-// keys, into a GNMI path.
-func pathNoKeysToGNMIPath(path string) *gpb.Path {
-	out := &gpb.Path{}
-	for _, p := range strings.Split(path, "/") {
-		out.Elem = append(out.Elem, &gpb.PathElem{Name: p})
-	}
-	return out
-}
-
-// pathMatchesPrefix reports whether prefix is a prefix of path.
-func pathMatchesPrefix(path []string, prefix []string) bool {
-	if len(path) < len(prefix) {
-		return false
-	}
-	for i := range prefix {
-		if prefix[i] != path[i] {
-			return false
+// xpathNodePath returns the gNMI path of the data node n relative to start.
+// It returns false if n is not start or one of its descendants.
+func xpathNodePath(start, n *util.XPathNode) (*gpb.Path, bool) {
+	var elems []*gpb.PathElem
+	for ; n != start; n = n.Parent() {
+		if n == nil || n.Outside() {
+			return nil, false
+		}
+		e := &gpb.PathElem{Name: n.Name()}
+		if s := n.Schema(); s.IsList() && s.Key != "" {
+			e.Key = map[string]string{}
+			for _, k := range strings.Fields(s.Key) {
+				for _, c := range n.Children() {
+					if c.Name() == k {
+						e.Key[k] = c.StringValue()
+					}
+				}
+			}
 		}
+		elems = append([]*gpb.PathElem{e}, elems...)
 	}
-
-	return true
+	return &gpb.Path{Elem: elems}, true
 }
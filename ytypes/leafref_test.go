@@ -15,12 +15,14 @@
 package ytypes
 
 import (
-	"reflect"
+	"encoding/json"
+	"sort"
 	"strings"
 	"testing"
 
-	"github.com/openconfig/gnmi/errdiff"
+	"github.com/google/go-cmp/cmp"
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
 	"github.com/openconfig/ygot/ygot"
 )
 
@@ -619,230 +621,275 @@ func TestValidateLeafRefDataCompressedSchemaListOnly(t *testing.T) {
 	}
 }
 
-func TestSplitUnescaped(t *testing.T) {
-	tests := []struct {
-		desc string
-		in   string
-		want []string
-	}{
-		{
-			desc: "simple",
-			in:   "a/b/c",
-			want: []string{"a", "b", "c"},
-		},
-		{
-			desc: "blank",
-			in:   "a//b",
-			want: []string{"a", "", "b"},
-		},
-		{
-			desc: "lead trail slash",
-			in:   "/a/b/c/",
-			want: []string{"", "a", "b", "c", ""},
-		},
-		{
-			desc: "escape slash",
-			in:   `a/\/b/c`,
-			want: []string{"a", `\/b`, "c"},
-		},
+type leafrefXPathSystem struct {
+	Ref      *string `path:"ref"`
+	MtuRef   *uint32 `path:"mtu-ref"`
+	UpMtu    *uint32 `path:"up-mtu"`
+	PeerName *string `path:"peer-name"`
+}
+
+func (*leafrefXPathSystem) IsYANGGoStruct() {}
+
+type leafrefXPathDevice struct {
+	Interface map[string]*xpathInterface `path:"interfaces/interface"`
+	System    *leafrefXPathSystem        `path:"system"`
+}
+
+func (*leafrefXPathDevice) IsYANGGoStruct() {}
+
+func TestValidateLeafRefDataXPath(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	system := schema.Dir["system"]
+	for name, path := range map[string]string{
+		// deref() of a sibling leafref.
+		"mtu-ref": "deref(../ref)/../config/mtu",
+		// Multiple predicates, one of which uses current().
+		"up-mtu": "/interfaces/interface[name = current()/../ref][config/enabled = 'true']/config/mtu",
+		// A predicate containing a path with a nested predicate.
+		"peer-name": "/interfaces/interface[config/mtu = /interfaces/interface[name = current()/../ref]/config/mtu]/config/name",
+	} {
+		system.Dir[name] = &yang.Entry{
+			Name:   name,
+			Kind:   yang.LeafEntry,
+			Type:   &yang.YangType{Kind: yang.Yleafref, Path: path},
+			Parent: system,
+		}
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.desc, func(t *testing.T) {
-			if got, want := splitUnescaped(tt.in, '/'), tt.want; !reflect.DeepEqual(got, want) {
-				t.Errorf("%s: got: %v, want: %v", tt.desc, got, want)
-			}
-		})
+	interfaces := func() map[string]*xpathInterface {
+		return map[string]*xpathInterface{
+			"eth0": {Name: String("eth0"), Mtu: Uint32(1500), Enabled: ygot.Bool(true)},
+			"eth1": {Name: String("eth1"), Mtu: Uint32(9000)},
+			"eth2": {Name: String("eth2"), Mtu: Uint32(1500)},
+		}
 	}
-}
 
-func TestSplitUnquoted(t *testing.T) {
 	tests := []struct {
-		desc     string
-		in       string
-		splitStr string
-		want     []string
-	}{
-		{
-			desc:     "simple",
-			in:       "a/b/c",
-			splitStr: "/",
-			want:     []string{"a", "b", "c"},
+		desc    string
+		in      *leafrefXPathDevice
+		wantErr string
+	}{{
+		desc: "deref target matches",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth1"), MtuRef: Uint32(9000)},
 		},
-		{
-			desc:     "blank",
-			in:       "a//b",
-			splitStr: "/",
-			want:     []string{"a", "", "b"},
+	}, {
+		desc: "deref target does not match",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth1"), MtuRef: Uint32(1500)},
 		},
-		{
-			desc:     "lead trail slash",
-			in:       "/a/b/c/",
-			splitStr: "/",
-			want:     []string{"", "a", "b", "c", ""},
+		wantErr: "field name MtuRef value 1500 (uint32 ptr) schema path /xpath-test/system/mtu-ref has leafref path deref(../ref)/../config/mtu not equal to any target nodes",
+	}, {
+		desc: "deref of missing reference",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth3"), MtuRef: Uint32(1500)},
 		},
-		{
-			desc:     "quoted",
-			in:       `a/"/"b"/"/c`,
-			splitStr: "/",
-			want:     []string{"a", `"/"b"/"`, "c"},
+		wantErr: "field name Ref value eth3 (string ptr) schema path /xpath-test/system/ref has leafref path /interfaces/interface/name not equal to any target nodes, " +
+			"pointed-to value with path deref(../ref)/../config/mtu from field MtuRef value 1500 (uint32 ptr) schema /xpath-test/system/mtu-ref is empty set",
+	}, {
+		desc: "multiple predicates match",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth0"), UpMtu: Uint32(1500)},
 		},
-	}
+	}, {
+		desc: "multiple predicates select no nodes",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth1"), UpMtu: Uint32(9000)},
+		},
+		wantErr: "pointed-to value with path /interfaces/interface[name = current()/../ref][config/enabled = 'true']/config/mtu from field UpMtu value 9000 (uint32 ptr) schema /xpath-test/system/up-mtu is empty set",
+	}, {
+		desc: "nested predicate matches any of several nodes",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth0"), PeerName: String("eth2")},
+		},
+	}, {
+		desc: "nested predicate does not match",
+		in: &leafrefXPathDevice{
+			Interface: interfaces(),
+			System:    &leafrefXPathSystem{Ref: String("eth0"), PeerName: String("eth1")},
+		},
+		wantErr: "field name PeerName value eth1 (string ptr) schema path /xpath-test/system/peer-name has leafref path /interfaces/interface[config/mtu = /interfaces/interface[name = current()/../ref]/config/mtu]/config/name not equal to any target nodes",
+	}}
 
 	for _, tt := range tests {
 		t.Run(tt.desc, func(t *testing.T) {
-			if got, want := splitUnquoted(tt.in, tt.splitStr), tt.want; !reflect.DeepEqual(got, want) {
-				t.Errorf("%s: got: %v, want: %v", tt.desc, got, want)
+			errs := ValidateLeafRefData(schema, tt.in, nil)
+			if got, want := errs.String(), tt.wantErr; got != want {
+				t.Errorf("ValidateLeafRefData: got error: %s, want error: %s", got, want)
 			}
 		})
 	}
 }
 
-func TestExtractKeyValue(t *testing.T) {
-	tests := []struct {
-		desc       string
-		in         string
-		wantErr    string
-		wantPrefix string
-		wantKey    string
-		wantValue  string
-	}{
-		{
-			desc:       "literal",
-			in:         `b[key = "value"]`,
-			wantPrefix: "b",
-			wantKey:    "key",
-			wantValue:  `"value"`,
-		},
-		{
-			desc:       "spacing",
-			in:         `b[key="value"]`,
-			wantPrefix: "b",
-			wantKey:    "key",
-			wantValue:  `"value"`,
-		},
-		{
-			desc:       "quotes",
-			in:         `b[key="[=value=]"]`,
-			wantPrefix: "b",
-			wantKey:    "key",
-			wantValue:  `"[=value=]"`,
-		},
-		{
-			desc:       "path",
-			in:         "b[key = current()/../a/b/c]",
-			wantPrefix: "b",
-			wantKey:    "key",
-			wantValue:  "current()/../a/b/c",
-		},
-		{
-			desc:       "path",
-			in:         "b[key = ../a/b/c]",
-			wantPrefix: "b",
-			wantErr:    `bad kv string key = ../a/b/c: value must be in quotes or begin with current()/`,
-		},
-		{
-			desc:       "escapes",
-			in:         `b\[[\[key\]\" = "[a]"]`,
-			wantPrefix: `b\[`,
-			wantKey:    `\[key\]\"`,
-			wantValue:  `"[a]"`,
+func TestValidateLeafRefDataRequireInstance(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	in := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth0": {Name: String("eth0")},
 		},
+		System: &xpathSystem{Ref: String("eth1")},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.desc, func(t *testing.T) {
-			prefix, k, v, err := extractKeyValue(tt.in)
-			if got, want := errToString(err), tt.wantErr; got != want {
-				t.Errorf("%s: got error: %s, want error: %s", tt.desc, got, want)
-			}
-			if err != nil {
-				return
-			}
-			if got, want := prefix, tt.wantPrefix; got != want {
-				t.Errorf("%s prefix: got: %s, want: %s", tt.desc, got, want)
-			}
-			if got, want := k, tt.wantKey; !reflect.DeepEqual(got, want) {
-				t.Errorf("%s key: got: %v, want: %v", tt.desc, got, want)
-			}
-			if got, want := v, tt.wantValue; !reflect.DeepEqual(got, want) {
-				t.Errorf("%s value: got: %v, want: %v", tt.desc, got, want)
-			}
-		})
+	want := "field name Ref value eth1 (string ptr) schema path /xpath-test/system/ref has leafref path /interfaces/interface/name not equal to any target nodes"
+	if got := ValidateLeafRefData(schema, in, nil).String(); got != want {
+		t.Errorf("ValidateLeafRefData with require-instance true: got error: %s, want error: %s", got, want)
+	}
+
+	schema.Dir["system"].Dir["ref"].Type.OptionalInstance = true
+	if errs := ValidateLeafRefData(schema, in, nil); errs != nil {
+		t.Errorf("ValidateLeafRefData with require-instance false: got unexpected error: %v", errs)
 	}
 }
 
-func TestIsKeyValue(t *testing.T) {
+func TestValidateLeafRefDataParentSteps(t *testing.T) {
+	in := &xpathDevice{
+		Interface: map[string]*xpathInterface{
+			"eth0": {Name: String("eth0")},
+		},
+		System: &xpathSystem{Ref: String("eth0")},
+	}
+
 	tests := []struct {
-		name             string
-		in               string
-		want             bool
-		wantErrSubstring string
+		desc    string
+		inPath  string
+		wantErr string
 	}{{
-		name: "no quotes",
-		in:   "foo[baz=bar]",
-		want: true,
-	}, {
-		name: "quotes",
-		in:   `foo[bar="baz"]`,
-		want: true,
-	}, {
-		name:             "no key",
-		in:               "",
-		wantErrSubstring: "empty path element",
+		desc:   "parent steps to the root",
+		inPath: "../../interfaces/interface/name",
 	}, {
-		name:             "malformed",
-		in:               "foo]",
-		wantErrSubstring: "malformed path element",
+		desc:   "parent steps above the root stop at the root",
+		inPath: "../../../../interfaces/interface/name",
 	}, {
-		name:             "trailing chars",
-		in:               "foo[bar=baz]trailing",
-		wantErrSubstring: "trailing chars after",
+		desc:    "parent steps within the tree are not adjusted",
+		inPath:  "../interfaces/interface/name",
+		wantErr: "pointed-to value with path ../interfaces/interface/name from field Ref value eth0 (string ptr) schema /xpath-test/system/ref is empty set",
 	}}
 
 	for _, tt := range tests {
-		got, err := isKeyValue(tt.in)
-		if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
-			t.Errorf("%s: isKeyValue(%v): did not get expected error, %s", tt.name, tt.in, diff)
-		}
+		t.Run(tt.desc, func(t *testing.T) {
+			schema := yangtest.XPathSchema(t)
+			schema.Dir["system"].Dir["ref"].Type.Path = tt.inPath
+			if got, want := ValidateLeafRefData(schema, in, nil).String(), tt.wantErr; got != want {
+				t.Errorf("ValidateLeafRefData: got error: %s, want error: %s", got, want)
+			}
+		})
+	}
+}
 
-		if err != nil {
-			continue
-		}
+type leafrefLLDPInterface struct {
+	Name *string `path:"config/name|name"`
+}
 
-		if got != tt.want {
-			t.Errorf("%s: isKeyValue(%v): did not get expected value, got: %v, want: %v", tt.name, tt.in, got, tt.want)
-		}
-	}
+func (*leafrefLLDPInterface) IsYANGGoStruct() {}
+
+type leafrefPopulateDevice struct {
+	Interface     map[string]*xpathInterface       `path:"interfaces/interface"`
+	LLDPInterface map[string]*leafrefLLDPInterface `path:"lldp/interface"`
+	System        *xpathSystem                     `path:"system"`
 }
 
-func TestPathMatchesPrefix(t *testing.T) {
+func (*leafrefPopulateDevice) IsYANGGoStruct() {}
+
+func TestUnmarshalPopulateLeafRefTargets(t *testing.T) {
 	tests := []struct {
-		name     string
-		inPath   []string
-		inPrefix []string
-		want     bool
+		desc         string
+		inJSON       string
+		inOpts       []UnmarshalOpt
+		inOptional   bool
+		wantIntfs    []string
+		wantValidErr bool
 	}{{
-		name:     "short path",
-		inPath:   []string{"one"},
-		inPrefix: []string{"two", "three"},
-		want:     false,
+		desc:      "missing interfaces are created",
+		inJSON:    `{"interfaces": {"interface": [{"name": "eth0", "config": {"name": "eth0", "mtu": 1500}}]}, "lldp": {"interface": [{"name": "eth0"}, {"name": "eth1"}]}, "system": {"ref": "eth2"}}`,
+		inOpts:    []UnmarshalOpt{&PopulateLeafRefTargets{}},
+		wantIntfs: []string{"eth0", "eth1"},
+		// The reference from /system/ref is not a list key, and hence
+		// its target is not created.
+		wantValidErr: true,
 	}, {
-		name:     "path does not match",
-		inPath:   []string{"one", "two", "three"},
-		inPrefix: []string{"one", "four"},
-		want:     false,
+		desc:      "all referenced interfaces exist",
+		inJSON:    `{"interfaces": {"interface": [{"name": "eth0"}, {"name": "eth1"}]}, "lldp": {"interface": [{"name": "eth1"}]}}`,
+		inOpts:    []UnmarshalOpt{&PopulateLeafRefTargets{}},
+		wantIntfs: []string{"eth0", "eth1"},
 	}, {
-		name:     "path matches prefix",
-		inPath:   []string{"one", "two"},
-		inPrefix: []string{"one"},
-		want:     true,
+		desc:         "targets not created without option",
+		inJSON:       `{"lldp": {"interface": [{"name": "eth0"}]}}`,
+		wantValidErr: true,
+	}, {
+		desc:       "targets not created with require-instance false",
+		inJSON:     `{"lldp": {"interface": [{"name": "eth0"}]}}`,
+		inOpts:     []UnmarshalOpt{&PopulateLeafRefTargets{}},
+		inOptional: true,
 	}}
 
 	for _, tt := range tests {
-		if got := pathMatchesPrefix(tt.inPath, tt.inPrefix); got != tt.want {
-			t.Errorf("%s: pathMatchesPrefix(%v, %v): did not get expected output, got: %v, want: %v", tt.name, tt.inPath, tt.inPrefix, got, tt.want)
-		}
+		t.Run(tt.desc, func(t *testing.T) {
+			// The lldp interface list is keyed by a reference to the
+			// interfaces list.
+			schema := yangtest.XPathSchema(t)
+			schema.Dir["lldp"] = &yang.Entry{
+				Name: "lldp",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"interface": {
+						Name:     "interface",
+						Kind:     yang.DirectoryEntry,
+						ListAttr: &yang.ListAttr{},
+						Key:      "name",
+						Dir: map[string]*yang.Entry{
+							"name": {
+								Name: "name",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Yleafref, Path: "../config/name"},
+							},
+							"config": {
+								Name: "config",
+								Kind: yang.DirectoryEntry,
+								Dir: map[string]*yang.Entry{
+									"name": {
+										Name: "name",
+										Kind: yang.LeafEntry,
+										Type: &yang.YangType{Kind: yang.Yleafref, Path: "/interfaces/interface/name", OptionalInstance: tt.inOptional},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+			addParents(schema)
+
+			var jsonTree interface{}
+			if err := json.Unmarshal([]byte(tt.inJSON), &jsonTree); err != nil {
+				t.Fatalf("cannot unmarshal JSON: %v", err)
+			}
+			got := &leafrefPopulateDevice{}
+			if err := Unmarshal(schema, got, jsonTree, tt.inOpts...); err != nil {
+				t.Fatalf("Unmarshal: got unexpected error: %v", err)
+			}
+
+			var gotIntfs []string
+			for name, intf := range got.Interface {
+				if intf.Name == nil || *intf.Name != name {
+					t.Errorf("interface %s: got name %v, want: %s", name, intf.Name, name)
+				}
+				gotIntfs = append(gotIntfs, name)
+			}
+			sort.Strings(gotIntfs)
+			if diff := cmp.Diff(tt.wantIntfs, gotIntfs); diff != "" {
+				t.Errorf("Unmarshal: did not get expected interfaces, diff(-want, +got):\n%s", diff)
+			}
+
+			if errs := ValidateLeafRefData(schema, got, nil); (errs != nil) != tt.wantValidErr {
+				t.Errorf("ValidateLeafRefData: got errors %v, want errors: %v", errs, tt.wantValidErr)
+			}
+		})
 	}
 }
 
@@ -17,11 +17,14 @@ package ytypes
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/kylelemons/godebug/pretty"
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 // Refer to: https://tools.ietf.org/html/rfc6020#section-7.8.
@@ -41,22 +44,33 @@ func validateList(schema *yang.Entry, value interface{}) util.Errors {
 
 	util.DbgPrint("validateList with value %v, type %T, schema name %s", value, value, schema.Name)
 
-	kind := reflect.TypeOf(value).Kind()
-	if kind == reflect.Slice || kind == reflect.Map {
+	rv := reflect.ValueOf(value)
+	if util.IsValueOrderedMap(rv) || rv.Kind() == reflect.Slice || rv.Kind() == reflect.Map {
 		// Check list attributes: size constraints etc.
 		// Skip this check if not a list type - in this case value may be a list
-		// element which shares the list schema (excluding ListAttr).
+		// element which shares the list schema (excluding ListAttr). The size
+		// of lists that are not populated is checked by validateMandatory.
 		errors = util.AppendErrs(errors, validateListAttr(schema, value))
 	}
 
-	switch kind {
-	case reflect.Slice:
+	switch {
+	case util.IsValueOrderedMap(rv):
+		// Keyed list that is ordered-by user is an ordered map in the data
+		// tree, whose members are checked in the same way as those of a map.
+		keys := util.OrderedMapKeys(rv)
+		for i, cv := range util.OrderedMapValues(rv) {
+			errors = util.AppendErrs(errors, checkKeys(schema, cv.Elem(), keys[i]))
+			errors = util.AppendErrs(errors, validateStructElems(schema, cv.Interface()))
+		}
+		errors = util.AppendErrs(errors, validateUnique(schema, value))
+	case rv.Kind() == reflect.Slice:
 		// List without key is a slice in the data tree.
 		sv := reflect.ValueOf(value)
 		for i := 0; i < sv.Len(); i++ {
 			errors = util.AppendErrs(errors, validateStructElems(schema, sv.Index(i).Interface()))
 		}
-	case reflect.Map:
+		errors = util.AppendErrs(errors, validateUnique(schema, value))
+	case rv.Kind() == reflect.Map:
 		// List with key is a map in the data tree, with the key being the value
 		// of the key field(s) in the elements.
 		for _, key := range reflect.ValueOf(value).MapKeys() {
@@ -68,7 +82,8 @@ func validateList(schema *yang.Entry, value interface{}) util.Errors {
 			// Verify each elements's fields.
 			errors = util.AppendErrs(errors, validateStructElems(schema, cv))
 		}
-	case reflect.Ptr:
+		errors = util.AppendErrs(errors, validateUnique(schema, value))
+	case rv.Kind() == reflect.Ptr:
 		// Validate was called on a list element rather than the whole list, or
 		// on a completely bogus struct. In either case, evaluate just the
 		// element against the list schema without considering list attributes.
@@ -81,6 +96,137 @@ func validateList(schema *yang.Entry, value interface{}) util.Errors {
 	return errors
 }
 
+// uniqueEntry is an entry of a list that is checked against the unique
+// statements of the list's schema.
+type uniqueEntry struct {
+	// name identifies the entry within error messages.
+	name string
+	// node is the data tree node of the entry.
+	node *util.XPathNode
+}
+
+// validateUnique checks that the entries of the list value, which must be a
+// map (keyed list) or a slice (keyless list), satisfy each of the unique
+// statements of the list schema. An entry that does not contain all of the
+// leaves that are referenced by a unique statement is not checked against
+// that statement. Refer to: https://tools.ietf.org/html/rfc7950#section-7.8.3.
+func validateUnique(schema *yang.Entry, value interface{}) util.Errors {
+	uniques := util.SchemaXPathStatements(schema, "unique")
+	if len(uniques) == 0 {
+		return nil
+	}
+
+	entries, err := uniqueEntries(schema, reflect.ValueOf(value))
+	if err != nil {
+		return util.NewErrs(err)
+	}
+	return checkUniqueEntries(schema, uniques, entries)
+}
+
+// checkUniqueEntries checks that the entries of the list described by schema
+// satisfy each of the unique statements uniques of the list.
+func checkUniqueEntries(schema *yang.Entry, uniques []*util.XPathStatement, entries []*uniqueEntry) util.Errors {
+	var errors []error
+	for _, u := range uniques {
+		var paths []util.XPathExpr
+		for _, p := range strings.Fields(u.Expr) {
+			e, err := util.ParseXPath(p)
+			if err != nil {
+				errors = util.AppendErr(errors, fmt.Errorf("schema path %s: invalid unique statement %q: %v", util.SchemaTreePathNoModule(schema), u.Expr, err))
+				paths = nil
+				break
+			}
+			paths = append(paths, e)
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		seen := map[string]string{}
+		for _, e := range entries {
+			vals, err := uniqueValues(paths, e.node)
+			switch {
+			case err != nil:
+				errors = util.AppendErr(errors, fmt.Errorf("schema path %s: cannot evaluate unique statement %q for list entry %s: %v", util.SchemaTreePathNoModule(schema), u.Expr, e.name, err))
+				continue
+			case vals == nil:
+				continue
+			}
+			k := fmt.Sprintf("%q", vals)
+			if other, ok := seen[k]; ok {
+				errors = util.AppendErr(errors, fmt.Errorf("schema path %s: list entry %s has the same values %v as list entry %s for unique statement %q", util.SchemaTreePathNoModule(schema), e.name, vals, other, u.Expr))
+				continue
+			}
+			seen[k] = e.name
+		}
+	}
+	return errors
+}
+
+// uniqueEntries returns the entries of the list v, which must be a map, an
+// ordered map or a slice of struct pointers. Map entries are sorted by key,
+// such that errors are reported deterministically, whereas the entries of an
+// ordered map are returned in order.
+func uniqueEntries(schema *yang.Entry, v reflect.Value) ([]*uniqueEntry, error) {
+	var entries []*uniqueEntry
+	add := func(name string, ev reflect.Value) error {
+		n, err := util.NewXPathTree(schema, ev.Interface())
+		if err != nil {
+			return err
+		}
+		if n != nil {
+			entries = append(entries, &uniqueEntry{name: name, node: n})
+		}
+		return nil
+	}
+
+	switch {
+	case util.IsValueOrderedMap(v):
+		keys := util.OrderedMapKeys(v)
+		for i, ev := range util.OrderedMapValues(v) {
+			if err := add(fmt.Sprintf("with key %v", keys[i].Interface()), ev); err != nil {
+				return nil, err
+			}
+		}
+	case v.Kind() == reflect.Map:
+		for _, k := range v.MapKeys() {
+			if err := add(fmt.Sprintf("with key %v", k.Interface()), v.MapIndex(k)); err != nil {
+				return nil, err
+			}
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	case v.Kind() == reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := add(fmt.Sprintf("at index %d", i), v.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return entries, nil
+}
+
+// uniqueValues returns the string values of the leaves selected by each of
+// the paths, relative to the list entry n. It returns nil if any of the
+// leaves does not exist.
+func uniqueValues(paths []util.XPathExpr, n *util.XPathNode) ([]string, error) {
+	var vals []string
+	for _, p := range paths {
+		r, err := util.EvalXPath(p, n)
+		if err != nil {
+			return nil, err
+		}
+		ns, ok := r.([]*util.XPathNode)
+		if !ok {
+			return nil, fmt.Errorf("%s does not select a node-set", p)
+		}
+		if len(ns) == 0 {
+			return nil, nil
+		}
+		vals = append(vals, ns[0].StringValue())
+	}
+	return vals, nil
+}
+
 // checkKeys checks that the map key value for the list equals the value of the
 // key field(s) in the elements for the map value.
 //   entry is the schema for the list.
@@ -195,7 +341,7 @@ func validateStructElems(schema *yang.Entry, value interface{}) util.Errors {
 		if cschema == nil {
 			errors = util.AppendErr(errors, fmt.Errorf("child schema not found for struct %s field %s", schema.Name, fieldName))
 		} else {
-			errors = util.AppendErrs(errors, Validate(cschema, fieldValue))
+			errors = util.AppendErrs(errors, validate(cschema, fieldValue))
 		}
 	}
 
@@ -272,7 +418,8 @@ func nameMatchesPath(fieldName string, path []string) (bool, error) {
 }
 
 // unmarshalList unmarshals a JSON array into a list parent, which must be a
-// map or slice ptr.
+// map, ordered map or slice ptr. The order of the members of the JSON array is
+// retained when unmarshalling into an ordered map.
 //   schema is the schema of the schema node corresponding to the struct being
 //     unmamshaled into
 //   jsonList is a JSON list
@@ -289,10 +436,10 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{},
 
 	util.DbgPrint("unmarshalList jsonList %v, type %T, into parent type %T, schema name %s", util.ValueStrDebug(jsonList), jsonList, parent, schema.Name)
 
-	// Parent must be a map, slice ptr, or struct ptr.
+	// Parent must be a map, ordered map, slice ptr, or struct ptr.
 	t := reflect.TypeOf(parent)
 
-	if util.IsTypeStructPtr(t) {
+	if util.IsTypeStructPtr(t) && !util.IsTypeOrderedMap(t) {
 		// May be trying to unmarshal a single list element rather than the
 		// whole list.
 		return unmarshalContainerWithListSchema(schema, parent, jsonList, opts...)
@@ -305,12 +452,15 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{},
 			schema.Name, util.ValueStr(jsonList), jsonList)
 	}
 
-	if !(util.IsTypeMap(t) || util.IsTypeSlicePtr(t)) {
+	if !(util.IsTypeMap(t) || util.IsTypeOrderedMap(t) || util.IsTypeSlicePtr(t)) {
 		return fmt.Errorf("unmarshalList for %s got parent type %s, expect map, slice ptr or struct ptr", schema.Name, t.Kind())
 	}
 
 	listElementType := t.Elem()
-	if util.IsTypeSlicePtr(t) {
+	switch {
+	case util.IsTypeOrderedMap(t):
+		listElementType = util.OrderedMapElemType(t)
+	case util.IsTypeSlicePtr(t):
 		listElementType = t.Elem().Elem()
 	}
 	if !util.IsTypeStructPtr(listElementType) {
@@ -341,6 +491,8 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{},
 				return err
 			}
 			err = util.InsertIntoMap(parent, newKey.Interface(), newVal.Interface())
+		case util.IsTypeOrderedMap(t):
+			err = insertIntoOrderedMap(schema, parent, newVal)
 		case util.IsTypeSlicePtr(t):
 			err = util.InsertIntoSlice(parent, newVal.Interface())
 		default:
@@ -364,17 +516,15 @@ func unmarshalList(schema *yang.Entry, parent interface{}, jsonList interface{},
 // supplied schema. Also, function uses the last schema tag if there is more
 // than one by assuming it is direct descendant.
 // - schema: schema of the map.
-// - parent: value of the map.
+// - parent: value of the map, which may also be an ordered map.
 // - keys: dictionary received as part of Key field of gNMI PathElem.
 func makeValForInsert(schema *yang.Entry, parent interface{}, keys map[string]string) (reflect.Value, error) {
-	rv, rt := reflect.ValueOf(parent), reflect.TypeOf(parent)
-	if !util.IsValueMap(rv) {
+	rv := reflect.ValueOf(parent)
+	if !util.IsValueMap(rv) && !util.IsValueOrderedMap(rv) {
 		return reflect.ValueOf(nil), fmt.Errorf("%T is not a reflect.Map kind", parent)
 	}
-	// key is a non-pointer type
-	keyT := rt.Key()
-	// element is pointer type
-	elmT := rt.Elem()
+	// key is a non-pointer type, element is pointer type
+	keyT, elmT := keyedListTypes(rv.Type())
 
 	if !util.IsTypeStructPtr(elmT) {
 		return reflect.ValueOf(nil), fmt.Errorf("%v is not a pointer to a struct", elmT)
@@ -428,11 +578,55 @@ func makeValForInsert(schema *yang.Entry, parent interface{}, keys map[string]st
 	return val, nil
 }
 
+// keyedListTypes returns the types of the keys and values of t, which must be
+// a map or an ordered map type representing a keyed list.
+func keyedListTypes(t reflect.Type) (reflect.Type, reflect.Type) {
+	if util.IsTypeOrderedMap(t) {
+		return util.OrderedMapKeyType(t), util.OrderedMapElemType(t)
+	}
+	return t.Key(), t.Elem()
+}
+
+// keyedListMember returns the member of the keyed list v, which must be a map
+// or an ordered map, with the supplied key.
+func keyedListMember(v, key reflect.Value) reflect.Value {
+	if util.IsValueOrderedMap(v) {
+		return util.OrderedMapGet(v, key)
+	}
+	return v.MapIndex(key)
+}
+
+// deleteKeyedListMember deletes the member of the keyed list v, which must be
+// a map or an ordered map, with the supplied key.
+func deleteKeyedListMember(v, key reflect.Value) {
+	if util.IsValueOrderedMap(v) {
+		util.OrderedMapDelete(v, key)
+		return
+	}
+	v.SetMapIndex(key, reflect.Value{})
+}
+
+// insertIntoOrderedMap inserts the struct newVal into the parent, which must be
+// an ordered map. If a member with the same key already exists, its value is
+// replaced by newVal whilst retaining its position in the list, otherwise
+// newVal is appended to the end of the list.
+func insertIntoOrderedMap(schema *yang.Entry, parentMap interface{}, newVal reflect.Value) error {
+	newKey, err := makeKeyForInsert(schema, parentMap, newVal)
+	if err != nil {
+		return err
+	}
+	if ev := util.OrderedMapGet(reflect.ValueOf(parentMap), newKey); !ev.IsNil() {
+		ev.Elem().Set(newVal.Elem())
+		return nil
+	}
+	return util.InsertIntoOrderedMap(parentMap, newVal.Interface())
+}
+
 // makeKeyForInsert returns a key for inserting a struct newVal into the parent,
-// which must be a map.
+// which must be a map or an ordered map.
 func makeKeyForInsert(schema *yang.Entry, parentMap interface{}, newVal reflect.Value) (reflect.Value, error) {
 	// Key is always a value type, never a ptr.
-	listKeyType := reflect.TypeOf(parentMap).Key()
+	listKeyType, _ := keyedListTypes(reflect.TypeOf(parentMap))
 	newKey := reflect.New(listKeyType).Elem()
 
 	if util.IsTypeStruct(listKeyType) {
@@ -484,16 +678,17 @@ func makeKeyForInsert(schema *yang.Entry, parentMap interface{}, newVal reflect.
 }
 
 // insertAndGetKey creates key and value from the supplied keys map. It inserts
-// key and value into the given root which must be a map with the supplied schema.
+// key and value into the given root which must be a map or an ordered map with
+// the supplied schema. The value is appended to the end of an ordered map.
 func insertAndGetKey(schema *yang.Entry, root interface{}, keys map[string]string) (interface{}, error) {
 	switch {
 	case schema.Key == "":
 		return nil, fmt.Errorf("unkeyed list can't be traversed, type %T, keys %v", root, keys)
+	case util.IsValueOrderedMap(reflect.ValueOf(root)):
 	case !util.IsValueMap(reflect.ValueOf(root)):
 		return nil, fmt.Errorf("root has type %T, want map", root)
 	}
 
-	// TODO(yusufsn): When the key is a leafref, its target should be filled out.
 	mapVal, err := makeValForInsert(schema, root, keys)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create map value for insert, root %T, keys %v: %v", root, keys, err)
@@ -502,7 +697,11 @@ func insertAndGetKey(schema *yang.Entry, root interface{}, keys map[string]strin
 	if err != nil {
 		return nil, fmt.Errorf("failed to create map key for insert, root %T, keys %v: %v", root, keys, err)
 	}
-	err = util.InsertIntoMap(root, mapKey.Interface(), mapVal.Interface())
+	if util.IsValueOrderedMap(reflect.ValueOf(root)) {
+		err = util.InsertIntoOrderedMap(root, mapVal.Interface())
+	} else {
+		err = util.InsertIntoMap(root, mapKey.Interface(), mapVal.Interface())
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to insert into map %T, keys %v: %v", root, keys, err)
 	}
@@ -510,6 +709,93 @@ func insertAndGetKey(schema *yang.Entry, root interface{}, keys map[string]strin
 	return mapKey.Interface(), nil
 }
 
+// setKeyLeafRefTargets sets the value of each node within the new list
+// member val, which has the supplied list schema, that is the target of a key
+// of the list which is a leafref. For example, the key of an OpenConfig list
+// is a leafref to the config/key leaf of the same member, which is set to
+// the value of the key. Targets outside of the list member are not set.
+func setKeyLeafRefTargets(schema *yang.Entry, val reflect.Value) error {
+	for _, k := range strings.Fields(schema.Key) {
+		ks := schema.Dir[k]
+		if !util.IsLeafRef(ks) {
+			continue
+		}
+		ts, err := util.FindLeafRefSchema(ks, ks.Type.Path)
+		if err != nil {
+			return err
+		}
+		if ts == ks {
+			continue
+		}
+
+		var elems []*gpb.PathElem
+		p := ts.Parent
+		for ; p != nil && p != schema; p = p.Parent {
+			if !util.IsChoiceOrCase(p) {
+				elems = append([]*gpb.PathElem{{Name: p.Name}}, elems...)
+			}
+		}
+		if p == nil {
+			continue
+		}
+
+		kfn, err := schemaNameToFieldName(val.Elem(), k)
+		if err != nil {
+			return err
+		}
+		// Where the schema is compressed, the key and its target may be
+		// represented by the same field.
+		kf, _ := val.Elem().Type().FieldByName(kfn)
+		paths, err := util.SchemaPaths(kf)
+		if err != nil {
+			return err
+		}
+		if hasSchemaPath(paths, elems, ts.Name) {
+			continue
+		}
+
+		parent, _, err := GetOrCreateNode(schema, val.Interface(), &gpb.Path{Elem: elems})
+		if err != nil {
+			return err
+		}
+		tfn, err := schemaNameToFieldName(reflect.ValueOf(parent).Elem(), ts.Name)
+		if err != nil {
+			return err
+		}
+		// The target is given a copy of the key value, such that the two
+		// fields do not share the same pointer.
+		kv := val.Elem().FieldByName(kfn)
+		if util.IsValuePtr(kv) {
+			kv = kv.Elem()
+		}
+		if err := util.InsertIntoStruct(parent, tfn, kv.Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasSchemaPath returns true if paths contains the path made up of the names
+// of elems followed by name.
+func hasSchemaPath(paths [][]string, elems []*gpb.PathElem, name string) bool {
+	for _, p := range paths {
+		if len(p) != len(elems)+1 || p[len(p)-1] != name {
+			continue
+		}
+		match := true
+		for i, e := range elems {
+			if p[i] != e.Name {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
 // unmarshalContainerWithListSchema unmarshals a container data tree element
 // using a list schema. This can happen because in OC schemas, list elements
 // share the list schema so if a user attempts to unmarshal a list element vs.
@@ -26,6 +26,8 @@ import (
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
 	"github.com/openconfig/ygot/ygot"
+
+	gpb "github.com/openconfig/gnmi/proto/gnmi"
 )
 
 var validListSchema = &yang.Entry{
@@ -395,6 +397,127 @@ func TestValidateListStructKey(t *testing.T) {
 	}
 }
 
+// uniqueListSchema returns the schema of a list, keyed by name if keyed is
+// true, with a unique statement on its address and port leaves.
+func uniqueListSchema(keyed bool) *yang.Entry {
+	leaf := func(name string, kind yang.TypeKind) *yang.Entry {
+		return &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: kind}}
+	}
+	list := &yang.Entry{
+		Name:     "server",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{},
+		Dir: map[string]*yang.Entry{
+			"config": {
+				Name: "config",
+				Kind: yang.DirectoryEntry,
+				Dir: map[string]*yang.Entry{
+					"name":    leaf("name", yang.Ystring),
+					"address": leaf("address", yang.Ystring),
+					"port":    leaf("port", yang.Yuint32),
+				},
+			},
+		},
+		Extra: map[string][]interface{}{
+			"unique": {&yang.Value{Name: "config/address config/port"}},
+		},
+	}
+	if keyed {
+		list.Key = "name"
+		list.Dir["name"] = &yang.Entry{
+			Name: "name",
+			Kind: yang.LeafEntry,
+			Type: &yang.YangType{Kind: yang.Yleafref, Path: "../config/name"},
+		}
+	}
+	root := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Dir:  map[string]*yang.Entry{"server": list},
+	}
+	addParents(root)
+	return list
+}
+
+type uniqueServer struct {
+	Name    *string `path:"config/name|name"`
+	Address *string `path:"config/address"`
+	Port    *uint32 `path:"config/port"`
+}
+
+func (*uniqueServer) IsYANGGoStruct() {}
+
+func TestValidateListUnique(t *testing.T) {
+	tests := []struct {
+		desc     string
+		schema   *yang.Entry
+		in       interface{}
+		wantErrs []string
+	}{{
+		desc:   "keyed list with unique values",
+		schema: uniqueListSchema(true),
+		in: map[string]*uniqueServer{
+			"s1": {Name: String("s1"), Address: String("192.0.2.1"), Port: Uint32(80)},
+			"s2": {Name: String("s2"), Address: String("192.0.2.1"), Port: Uint32(443)},
+		},
+	}, {
+		desc:   "keyed list with duplicate values",
+		schema: uniqueListSchema(true),
+		in: map[string]*uniqueServer{
+			"s1": {Name: String("s1"), Address: String("192.0.2.1"), Port: Uint32(80)},
+			"s2": {Name: String("s2"), Address: String("192.0.2.1"), Port: Uint32(80)},
+			"s3": {Name: String("s3"), Address: String("192.0.2.1"), Port: Uint32(80)},
+		},
+		wantErrs: []string{
+			`schema path /server: list entry with key s2 has the same values [192.0.2.1 80] as list entry with key s1 for unique statement "config/address config/port"`,
+			`schema path /server: list entry with key s3 has the same values [192.0.2.1 80] as list entry with key s1 for unique statement "config/address config/port"`,
+		},
+	}, {
+		desc:   "entries missing a unique leaf are not checked",
+		schema: uniqueListSchema(true),
+		in: map[string]*uniqueServer{
+			"s1": {Name: String("s1"), Address: String("192.0.2.1")},
+			"s2": {Name: String("s2"), Address: String("192.0.2.1")},
+		},
+	}, {
+		desc:   "keyless list with duplicate values",
+		schema: uniqueListSchema(false),
+		in: []*uniqueServer{
+			{Address: String("192.0.2.1"), Port: Uint32(80)},
+			{Address: String("192.0.2.2"), Port: Uint32(80)},
+			{Address: String("192.0.2.1"), Port: Uint32(80)},
+		},
+		wantErrs: []string{
+			`schema path /server: list entry at index 2 has the same values [192.0.2.1 80] as list entry at index 0 for unique statement "config/address config/port"`,
+		},
+	}, {
+		desc: "invalid unique statement",
+		schema: func() *yang.Entry {
+			s := uniqueListSchema(false)
+			s.Extra["unique"] = []interface{}{&yang.Value{Name: "config/address["}}
+			return s
+		}(),
+		in: []*uniqueServer{
+			{Address: String("192.0.2.1"), Port: Uint32(80)},
+		},
+		wantErrs: []string{
+			`schema path /server: invalid unique statement "config/address[": cannot parse XPath expression "config/address[": expected node test at position 15, got ""`,
+		},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got []string
+			for _, err := range validateList(tt.schema, tt.in) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("validateList: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestUnmarshalList(t *testing.T) {
 	// nil value
 	if got := unmarshalList(nil, nil, nil, JSONEncoding); got != nil {
@@ -1402,3 +1525,164 @@ func TestUnmarshalUnionKeyedList(t *testing.T) {
 		}
 	}
 }
+
+type orderedListElem struct {
+	Key       *string `path:"key"`
+	LeafField *int32  `path:"leaf-field"`
+}
+
+func (*orderedListElem) IsYANGGoStruct() {}
+
+func (e *orderedListElem) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"key": *e.Key}, nil
+}
+
+// orderedListElems is an ordered map of orderedListElem structs, of the form
+// that is generated for an ordered-by user list.
+type orderedListElems struct {
+	keys     []string
+	valueMap map[string]*orderedListElem
+}
+
+func (*orderedListElems) IsYANGOrderedList() {}
+
+func (o *orderedListElems) Keys() []string {
+	if o == nil {
+		return nil
+	}
+	return append([]string{}, o.keys...)
+}
+
+func (o *orderedListElems) Values() []*orderedListElem {
+	if o == nil {
+		return nil
+	}
+	var values []*orderedListElem
+	for _, k := range o.keys {
+		values = append(values, o.valueMap[k])
+	}
+	return values
+}
+
+func (o *orderedListElems) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.keys)
+}
+
+func (o *orderedListElems) Get(key string) *orderedListElem {
+	if o == nil {
+		return nil
+	}
+	return o.valueMap[key]
+}
+
+func (o *orderedListElems) Delete(key string) bool {
+	for i, k := range o.keys {
+		if k == key {
+			o.keys = append(o.keys[:i], o.keys[i+1:]...)
+			delete(o.valueMap, key)
+			return true
+		}
+	}
+	return false
+}
+
+func (o *orderedListElems) Append(v *orderedListElem) error {
+	if v == nil || v.Key == nil {
+		return fmt.Errorf("invalid member %v", v)
+	}
+	if _, ok := o.valueMap[*v.Key]; ok {
+		return fmt.Errorf("duplicate key %s", *v.Key)
+	}
+	if o.valueMap == nil {
+		o.valueMap = map[string]*orderedListElem{}
+	}
+	o.keys = append(o.keys, *v.Key)
+	o.valueMap[*v.Key] = v
+	return nil
+}
+
+type orderedListContainer struct {
+	KeyList *orderedListElems `path:"key-list"`
+}
+
+func (*orderedListContainer) IsYANGGoStruct() {}
+
+func TestUnmarshalOrderedList(t *testing.T) {
+	listSchema := &yang.Entry{
+		Name:     "key-list",
+		Kind:     yang.DirectoryEntry,
+		ListAttr: &yang.ListAttr{MaxElements: &yang.Value{Name: "4"}, OrderedBy: &yang.Value{Name: "user"}},
+		Key:      "key",
+		Config:   yang.TSTrue,
+		Dir: map[string]*yang.Entry{
+			"key": {
+				Kind: yang.LeafEntry,
+				Name: "key",
+				Type: &yang.YangType{Kind: yang.Ystring},
+			},
+			"leaf-field": {
+				Kind: yang.LeafEntry,
+				Name: "leaf-field",
+				Type: &yang.YangType{Kind: yang.Yint32},
+			},
+		},
+	}
+	containerSchema := &yang.Entry{
+		Name: "container",
+		Kind: yang.DirectoryEntry,
+		Dir:  map[string]*yang.Entry{"key-list": listSchema},
+	}
+	listSchema.Parent = containerSchema
+	for _, e := range listSchema.Dir {
+		e.Parent = listSchema
+	}
+
+	var parent orderedListContainer
+	unmarshal := func(j string) {
+		var jsonTree interface{}
+		if err := json.Unmarshal([]byte(j), &jsonTree); err != nil {
+			t.Fatalf("cannot unmarshal JSON %s: %v", j, err)
+		}
+		if err := Unmarshal(containerSchema, &parent, jsonTree); err != nil {
+			t.Fatalf("Unmarshal(%s): got unexpected error: %v", j, err)
+		}
+	}
+
+	unmarshal(`{ "key-list" : [ { "key" : "c", "leaf-field" : 3 }, { "key" : "a", "leaf-field" : 1 }, { "key" : "b" } ] }`)
+	if diff := cmp.Diff([]string{"c", "a", "b"}, parent.KeyList.Keys()); diff != "" {
+		t.Errorf("Unmarshal: did not get expected order, diff(-want,+got):\n%s", diff)
+	}
+
+	// Unmarshalling an existing member replaces it in its existing position.
+	unmarshal(`{ "key-list" : [ { "key" : "d" }, { "key" : "a", "leaf-field" : 10 } ] }`)
+	if diff := cmp.Diff([]string{"c", "a", "b", "d"}, parent.KeyList.Keys()); diff != "" {
+		t.Errorf("Unmarshal: did not get expected order, diff(-want,+got):\n%s", diff)
+	}
+	if got := parent.KeyList.Get("a").LeafField; got == nil || *got != 10 {
+		t.Errorf("Unmarshal: did not update member a, got leaf-field: %v, want: 10", got)
+	}
+
+	if errs := Validate(containerSchema, &parent); errs != nil {
+		t.Errorf("Validate: got unexpected errors: %v", errs)
+	}
+
+	if err := SetNode(containerSchema, &parent, mustPath("/key-list[key=e]/leaf-field"), &gpb.TypedValue{Value: &gpb.TypedValue_IntVal{IntVal: 5}}, &InitMissingElements{}); err != nil {
+		t.Fatalf("SetNode: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"c", "a", "b", "d", "e"}, parent.KeyList.Keys()); diff != "" {
+		t.Errorf("SetNode: did not get expected order, diff(-want,+got):\n%s", diff)
+	}
+	if errs := Validate(containerSchema, &parent); errs == nil {
+		t.Errorf("Validate: did not get expected error for list exceeding max-elements")
+	}
+
+	if err := DeleteNode(containerSchema, &parent, mustPath("/key-list[key=a]")); err != nil {
+		t.Fatalf("DeleteNode: got unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"c", "b", "d", "e"}, parent.KeyList.Keys()); diff != "" {
+		t.Errorf("DeleteNode: did not get expected order, diff(-want,+got):\n%s", diff)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc7950#section-3 for the definition
+// of a mandatory node.
+
+// EnforceMandatory is a ValidationOption that specifies that the YANG
+// mandatory, min-elements and max-elements statements should be enforced
+// during validation. Without it, only the size of the lists that are
+// populated is checked, as in previous releases, such that partial data trees
+// in which data nodes that are required by the schema are absent can be
+// validated.
+type EnforceMandatory struct{}
+
+// IsValidationOption ensures that EnforceMandatory implements the
+// ValidationOption interface.
+func (*EnforceMandatory) IsValidationOption() {}
+
+// hasEnforceMandatory determines whether the supplied slice of
+// ValidationOptions contains the EnforceMandatory option.
+func hasEnforceMandatory(opts []ygot.ValidationOption) bool {
+	for _, o := range opts {
+		if _, ok := o.(*EnforceMandatory); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMandatory checks that the data tree value, which is described by
+// schema, contains each of the mandatory leaves and choices within the
+// schema, and that each list and leaf-list satisfies its min-elements and
+// max-elements constraints. Constraints on data nodes within a case are
+// enforced only if the case is selected. The size of the lists that are
+// populated is checked by validateList, and hence only that of unpopulated
+// lists is checked here.
+func validateMandatory(schema *yang.Entry, value interface{}) util.Errors {
+	if util.IsValueNil(value) {
+		return nil
+	}
+	v := reflect.ValueOf(value)
+	switch {
+	case schema.IsLeafList():
+		return validateListAttrPath(schema, value)
+	case schema.IsList() && util.IsValueStructPtr(v) && !util.IsValueOrderedMap(v):
+		// A single list element is being validated.
+		return validateStructMandatory(schema, v)
+	case schema.IsList():
+		return validateListElemsMandatory(schema, v)
+	case (schema.IsContainer() || util.IsOperationContainer(schema)) && util.IsValueStructPtr(v):
+		return validateStructMandatory(schema, v)
+	}
+	return nil
+}
+
+// validateListAttrPath calls validateListAttr for the list or leaf-list
+// value described by schema, prefixing any errors with the schema path.
+func validateListAttrPath(schema *yang.Entry, value interface{}) util.Errors {
+	if schema.ListAttr == nil {
+		return nil
+	}
+	var errs util.Errors
+	for _, err := range validateListAttr(schema, value) {
+		errs = util.AppendErr(errs, fmt.Errorf("schema path %s: %v", util.SchemaTreePathNoModule(schema), err))
+	}
+	return errs
+}
+
+// validateListElemsMandatory calls validateStructMandatory for each of the
+// elements of the list v, which must be a map, ordered map or slice of struct
+// pointers.
+func validateListElemsMandatory(schema *yang.Entry, v reflect.Value) util.Errors {
+	var errs util.Errors
+	if util.IsValueOrderedMap(v) {
+		for _, e := range util.OrderedMapValues(v) {
+			errs = util.AppendErrs(errs, validateStructMandatory(schema, e))
+		}
+		return errs
+	}
+	switch v.Kind() {
+	case reflect.Map:
+		for _, k := range v.MapKeys() {
+			errs = util.AppendErrs(errs, validateStructMandatory(schema, v.MapIndex(k)))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			errs = util.AppendErrs(errs, validateStructMandatory(schema, v.Index(i)))
+		}
+	}
+	return errs
+}
+
+// mandatoryField is a field of a GoStruct that is examined when checking
+// mandatory nodes.
+type mandatoryField struct {
+	// schema is the schema of the data node stored in the field.
+	schema *yang.Entry
+	// value is the value of the field.
+	value reflect.Value
+	// set indicates whether the field is populated.
+	set bool
+}
+
+// validateStructMandatory checks the mandatory nodes and list size
+// constraints of the fields of the GoStruct v, which is described by
+// schema, and recursively of the GoStructs that it contains. Containers
+// that are not populated are checked as though they were empty, unless they
+// are presence containers.
+func validateStructMandatory(schema *yang.Entry, v reflect.Value) util.Errors {
+	if !util.IsValueStructPtr(v) || v.IsNil() {
+		return nil
+	}
+	sv := v.Elem()
+
+	var fields []*mandatoryField
+	// selected stores the case and choice schema nodes which contain a
+	// populated field.
+	selected := map[*yang.Entry]bool{}
+	// choices stores the choices that contain a field, in the order they
+	// are encountered.
+	var choices []*yang.Entry
+	seenChoice := map[*yang.Entry]bool{}
+	for i := 0; i < sv.NumField(); i++ {
+		ft := sv.Type().Field(i)
+		if util.IsYgotAnnotation(ft) {
+			continue
+		}
+		// Errors and unknown fields are reported by validateContainer.
+		cschema, err := util.ChildSchema(schema, ft)
+		if err != nil || cschema == nil {
+			continue
+		}
+		f := &mandatoryField{schema: cschema, value: sv.Field(i), set: isFieldPopulated(sv.Field(i))}
+		fields = append(fields, f)
+		for e := cschema.Parent; e != nil && e != schema; e = e.Parent {
+			if !util.IsChoiceOrCase(e) {
+				continue
+			}
+			if f.set {
+				selected[e] = true
+			}
+			if e.IsChoice() && !seenChoice[e] {
+				seenChoice[e] = true
+				choices = append(choices, e)
+			}
+		}
+	}
+
+	var errs util.Errors
+	for _, f := range fields {
+		if !isCaseSelected(f.schema, schema, selected) {
+			continue
+		}
+		switch {
+		case f.schema.IsLeaf():
+			if !f.set && f.schema.Mandatory == yang.TSTrue {
+				errs = util.AppendErr(errs, fmt.Errorf("schema path %s: mandatory leaf is not populated", util.SchemaTreePathNoModule(f.schema)))
+			}
+		case f.schema.IsLeafList():
+			errs = util.AppendErrs(errs, validateListAttrPath(f.schema, f.value.Interface()))
+		case f.schema.IsList():
+			if util.IsValueNil(f.value.Interface()) {
+				errs = util.AppendErrs(errs, validateListAttrPath(f.schema, nil))
+			}
+			errs = util.AppendErrs(errs, validateListElemsMandatory(f.schema, f.value))
+		case f.schema.IsContainer() && util.IsTypeStructPtr(f.value.Type()):
+			switch {
+			case f.set:
+				errs = util.AppendErrs(errs, validateStructMandatory(f.schema, f.value))
+			case !util.IsPresenceContainer(f.schema):
+				// A non-presence container is a mandatory node if any of its
+				// children are mandatory nodes, hence check an empty instance
+				// of the container.
+				errs = util.AppendErrs(errs, validateStructMandatory(f.schema, reflect.New(f.value.Type().Elem())))
+			}
+		}
+	}
+
+	for _, c := range choices {
+		if c.Mandatory == yang.TSTrue && !selected[c] && isCaseSelected(c, schema, selected) {
+			p := util.SchemaTreePathNoModule(schema)
+			if p == "" {
+				p = "/"
+			}
+			errs = util.AppendErr(errs, fmt.Errorf("schema path %s: no case is selected for mandatory choice %s", p, c.Name))
+		}
+	}
+	return errs
+}
+
+// isCaseSelected reports whether the case that most closely encloses the
+// schema node e, below the schema node parent, is selected. It returns true
+// if e is not within a case.
+func isCaseSelected(e, parent *yang.Entry, selected map[*yang.Entry]bool) bool {
+	for e = e.Parent; e != nil && e != parent; e = e.Parent {
+		if e.IsCase() {
+			return selected[e]
+		}
+	}
+	return true
+}
+
+// isFieldPopulated reports whether the GoStruct field v contains data. Maps
+// and slices that contain no elements are considered to be unpopulated.
+func isFieldPopulated(v reflect.Value) bool {
+	if (util.IsValueMap(v) || util.IsValueSlice(v)) && v.Len() == 0 {
+		return false
+	}
+	if om, ok := v.Interface().(ygot.GoOrderedMap); ok && om.Len() == 0 {
+		return false
+	}
+	return !util.IsValueNilOrDefault(v.Interface())
+}
@@ -0,0 +1,254 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
+)
+
+type mandatoryRoot struct {
+	A  *string                       `path:"a"`
+	LL []string                      `path:"ll"`
+	L  map[string]*mandatoryListElem `path:"l"`
+	X  *string                       `path:"x"`
+	Y  *string                       `path:"y"`
+	Z  *string                       `path:"z"`
+	NP *mandatoryContainer           `path:"np"`
+	P  *mandatoryContainer           `path:"p"`
+}
+
+func (*mandatoryRoot) IsYANGGoStruct() {}
+
+type mandatoryListElem struct {
+	K *string `path:"k"`
+	V *string `path:"v"`
+}
+
+func (*mandatoryListElem) IsYANGGoStruct() {}
+
+type mandatoryContainer struct {
+	M *string `path:"m"`
+}
+
+func (*mandatoryContainer) IsYANGGoStruct() {}
+
+func TestValidateMandatory(t *testing.T) {
+	// The choice ch is mandatory, and the p container is a presence
+	// container, whereas np is not.
+	leaf := func(name string, mandatory bool) *yang.Entry {
+		e := &yang.Entry{Name: name, Kind: yang.LeafEntry, Type: &yang.YangType{Kind: yang.Ystring}}
+		if mandatory {
+			e.Mandatory = yang.TSTrue
+		}
+		return e
+	}
+	container := func(name string, presence bool) *yang.Entry {
+		e := &yang.Entry{
+			Name: name,
+			Kind: yang.DirectoryEntry,
+			Dir:  map[string]*yang.Entry{"m": leaf("m", true)},
+		}
+		if presence {
+			e.Extra = map[string][]interface{}{"presence": {&yang.Value{Name: "enabled"}}}
+		}
+		return e
+	}
+
+	ll := leaf("ll", false)
+	ll.ListAttr = &yang.ListAttr{MinElements: &yang.Value{Name: "1"}}
+	schema := &yang.Entry{
+		Name: "root",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"a":  leaf("a", true),
+			"ll": ll,
+			"l": {
+				Name:     "l",
+				Kind:     yang.DirectoryEntry,
+				Key:      "k",
+				ListAttr: &yang.ListAttr{MaxElements: &yang.Value{Name: "2"}},
+				Dir: map[string]*yang.Entry{
+					"k": leaf("k", false),
+					"v": leaf("v", true),
+				},
+			},
+			"ch": {
+				Name:      "ch",
+				Kind:      yang.ChoiceEntry,
+				Mandatory: yang.TSTrue,
+				Dir: map[string]*yang.Entry{
+					"c1": {
+						Name: "c1",
+						Kind: yang.CaseEntry,
+						Dir: map[string]*yang.Entry{
+							"x": leaf("x", true),
+							"y": leaf("y", false),
+						},
+					},
+					"c2": {
+						Name: "c2",
+						Kind: yang.CaseEntry,
+						Dir: map[string]*yang.Entry{
+							"z": leaf("z", false),
+						},
+					},
+				},
+			},
+			"np": container("np", false),
+			"p":  container("p", true),
+		},
+	}
+	addParents(schema)
+
+	tests := []struct {
+		desc     string
+		schema   *yang.Entry
+		in       interface{}
+		opts     []ygot.ValidationOption
+		wantErrs []string
+	}{{
+		desc:   "all mandatory nodes populated",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in: &mandatoryRoot{
+			A:  String("a"),
+			LL: []string{"one"},
+			L: map[string]*mandatoryListElem{
+				"k1": {K: String("k1"), V: String("v1")},
+			},
+			Z:  String("z"),
+			NP: &mandatoryContainer{M: String("m")},
+		},
+	}, {
+		desc:   "empty struct",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in:     &mandatoryRoot{},
+		wantErrs: []string{
+			"schema path /a: mandatory leaf is not populated",
+			"schema path /ll: list ll contains fewer than min required elements: 0 < 1",
+			"schema path /np/m: mandatory leaf is not populated",
+			"schema path /: no case is selected for mandatory choice ch",
+		},
+	}, {
+		desc:   "mandatory nodes not checked without option",
+		schema: schema,
+		in:     &mandatoryRoot{},
+	}, {
+		desc:   "mandatory leaf in selected case",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in: &mandatoryRoot{
+			A:  String("a"),
+			LL: []string{"one"},
+			Y:  String("y"),
+			NP: &mandatoryContainer{M: String("m")},
+		},
+		wantErrs: []string{"schema path /x: mandatory leaf is not populated"},
+	}, {
+		desc:   "presence container with missing mandatory leaf",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in: &mandatoryRoot{
+			A:  String("a"),
+			LL: []string{"one"},
+			Z:  String("z"),
+			NP: &mandatoryContainer{M: String("m")},
+			P:  &mandatoryContainer{},
+		},
+		wantErrs: []string{"schema path /p/m: mandatory leaf is not populated"},
+	}, {
+		desc:   "list element with missing mandatory leaf",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in: &mandatoryRoot{
+			A:  String("a"),
+			LL: []string{"one"},
+			L: map[string]*mandatoryListElem{
+				"k1": {K: String("k1")},
+			},
+			Z:  String("z"),
+			NP: &mandatoryContainer{M: String("m")},
+		},
+		wantErrs: []string{"schema path /l/v: mandatory leaf is not populated"},
+	}, {
+		desc:   "list with too many elements",
+		schema: schema,
+		opts:   []ygot.ValidationOption{&EnforceMandatory{}},
+		in: &mandatoryRoot{
+			A:  String("a"),
+			LL: []string{"one"},
+			L: map[string]*mandatoryListElem{
+				"k1": {K: String("k1"), V: String("v1")},
+				"k2": {K: String("k2"), V: String("v2")},
+				"k3": {K: String("k3"), V: String("v3")},
+			},
+			Z:  String("z"),
+			NP: &mandatoryContainer{M: String("m")},
+		},
+		wantErrs: []string{"/root/l: list l contains more than max allowed elements: 3 > 2"},
+	}, {
+		desc:   "size of populated list checked without option",
+		schema: schema,
+		in: &mandatoryRoot{
+			L: map[string]*mandatoryListElem{
+				"k1": {K: String("k1"), V: String("v1")},
+				"k2": {K: String("k2"), V: String("v2")},
+				"k3": {K: String("k3"), V: String("v3")},
+			},
+		},
+		wantErrs: []string{"/root/l: list l contains more than max allowed elements: 3 > 2"},
+	}, {
+		desc:     "list element below the root",
+		schema:   schema.Dir["l"],
+		in:       &mandatoryListElem{K: String("k1")},
+		opts:     []ygot.ValidationOption{&EnforceMandatory{}},
+		wantErrs: []string{"schema path /l/v: mandatory leaf is not populated"},
+	}, {
+		desc:     "list below the root",
+		schema:   schema.Dir["l"],
+		in:       map[string]*mandatoryListElem{"k1": {K: String("k1")}},
+		opts:     []ygot.ValidationOption{&EnforceMandatory{}},
+		wantErrs: []string{"schema path /l/v: mandatory leaf is not populated"},
+	}, {
+		desc:     "leaf-list below the root",
+		schema:   schema.Dir["ll"],
+		in:       []string{},
+		opts:     []ygot.ValidationOption{&EnforceMandatory{}},
+		wantErrs: []string{"schema path /ll: list ll contains fewer than min required elements: 0 < 1"},
+	}, {
+		desc:     "container below the root",
+		schema:   schema.Dir["np"],
+		in:       &mandatoryContainer{},
+		opts:     []ygot.ValidationOption{&EnforceMandatory{}},
+		wantErrs: []string{"schema path /np/m: mandatory leaf is not populated"},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tt.schema, tt.in, tt.opts...) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Validate: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// EvaluateMustStatements is a ValidationOption that specifies that the YANG
+// must statements within the schema should be evaluated during validation.
+// Each must statement is evaluated for every instance of the data node that
+// it constrains, and an error is returned for each statement that does not
+// evaluate to true. Where the validated value is not the root of the data
+// tree, must statements that refer to data outside of the value are not
+// evaluated, since that data is not available.
+type EvaluateMustStatements struct{}
+
+// IsValidationOption ensures that EvaluateMustStatements implements the
+// ValidationOption interface.
+func (*EvaluateMustStatements) IsValidationOption() {}
+
+// hasEvaluateMustStatements determines whether the supplied slice of
+// ValidationOptions contains the EvaluateMustStatements option.
+func hasEvaluateMustStatements(opts []ygot.ValidationOption) bool {
+	for _, o := range opts {
+		if _, ok := o.(*EvaluateMustStatements); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMust evaluates the must statements within schema for each of the
+// data nodes of value, which is described by schema. An error is returned
+// for each must statement that is not satisfied. Where value is not the root
+// of the data tree, must statements that refer to data outside of value are
+// not checked, since that data is not available.
+func validateMust(schema *yang.Entry, value interface{}) util.Errors {
+	start, err := util.NewXPathTree(schema, value)
+	if err != nil {
+		return util.NewErrs(err)
+	}
+	if start == nil {
+		return nil
+	}
+
+	var errs util.Errors
+	parsed := map[string]util.XPathExpr{}
+	start.Walk(func(n *util.XPathNode) bool {
+		for _, m := range util.SchemaXPathStatements(n.Schema(), "must") {
+			e, ok := parsed[m.Expr]
+			if !ok {
+				if e, err = util.ParseXPath(m.Expr); err != nil {
+					errs = util.AppendErr(errs, fmt.Errorf("schema path %s: invalid must statement: %v", util.SchemaTreePathNoModule(n.Schema()), err))
+					continue
+				}
+				parsed[m.Expr] = e
+			}
+			ok, inTree, err := util.EvalXPathBoolInTree(e, n)
+			switch {
+			case err != nil:
+				errs = util.AppendErr(errs, fmt.Errorf("schema path %s: cannot evaluate must statement %q: %v", util.SchemaTreePathNoModule(n.Schema()), m.Expr, err))
+			case inTree && !ok:
+				errs = util.AppendErr(errs, newMustError(n.Schema(), m))
+			}
+		}
+		return true
+	})
+	return errs
+}
+
+// newMustError returns the error that is reported when the must statement m
+// of the data node described by schema is not satisfied.
+func newMustError(schema *yang.Entry, m *util.XPathStatement) error {
+	if m.ErrorMessage != "" {
+		return fmt.Errorf("schema path %s: must statement %q is not satisfied: %s", util.SchemaTreePathNoModule(schema), m.Expr, m.ErrorMessage)
+	}
+	return fmt.Errorf("schema path %s: must statement %q is not satisfied", util.SchemaTreePathNoModule(schema), m.Expr)
+}
@@ -0,0 +1,259 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+type xpathInterface struct {
+	Name    *string  `path:"config/name|name"`
+	Mtu     *uint32  `path:"config/mtu"`
+	Type    EnumType `path:"config/type"`
+	Enabled *bool    `path:"config/enabled"`
+}
+
+func (*xpathInterface) IsYANGGoStruct() {}
+
+func (i *xpathInterface) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+type xpathSystem struct {
+	Hostname *string  `path:"hostname"`
+	Server   []string `path:"server"`
+	Ref      *string  `path:"ref"`
+}
+
+func (*xpathSystem) IsYANGGoStruct() {}
+
+type xpathDevice struct {
+	Interface map[string]*xpathInterface `path:"interfaces/interface"`
+	System    *xpathSystem               `path:"system"`
+}
+
+func (*xpathDevice) IsYANGGoStruct() {}
+
+func TestValidateMust(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	schema.Dir["system"].Dir["hostname"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{
+			Name:         "re-match(., '[a-z]+')",
+			ErrorMessage: &yang.Value{Name: "hostname must be lower case"},
+		}},
+	}
+	schema.Dir["system"].Dir["server"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{Name: "string-length(.) > 1"}},
+	}
+	schema.Dir["system"].Dir["ref"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{
+			Name:         "deref(.)/../config/mtu >= 1500",
+			ErrorMessage: &yang.Value{Name: "referenced interface has a small MTU"},
+		}},
+	}
+	schema.Dir["interfaces"].Dir["interface"].Dir["config"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{Name: "not(mtu) or mtu <= 9216"}},
+	}
+	schema.Dir["interfaces"].Dir["interface"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{Name: "count(/interfaces/interface) <= 2"}},
+	}
+	schema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["enabled"].Extra = map[string][]interface{}{
+		"must": {&yang.Must{Name: "invalid["}},
+	}
+
+	tests := []struct {
+		desc     string
+		in       *xpathDevice
+		opts     []ygot.ValidationOption
+		wantErrs []string
+	}{{
+		desc: "all must statements satisfied",
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+			},
+			System: &xpathSystem{
+				Hostname: String("router"),
+				Server:   []string{"s1", "s2"},
+				Ref:      String("eth0"),
+			},
+		},
+		opts: []ygot.ValidationOption{&EvaluateMustStatements{}},
+	}, {
+		desc: "must statements not evaluated without option",
+		in: &xpathDevice{
+			System: &xpathSystem{Hostname: String("ROUTER")},
+		},
+	}, {
+		desc: "leaf must with error message",
+		in: &xpathDevice{
+			System: &xpathSystem{Hostname: String("ROUTER")},
+		},
+		opts:     []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{`schema path /system/hostname: must statement "re-match(., '[a-z]+')" is not satisfied: hostname must be lower case`},
+	}, {
+		desc: "leaf-list must evaluated for each element",
+		in: &xpathDevice{
+			System: &xpathSystem{Server: []string{"s1", "a", "b"}},
+		},
+		opts: []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{
+			`schema path /system/server: must statement "string-length(.) > 1" is not satisfied`,
+			`schema path /system/server: must statement "string-length(.) > 1" is not satisfied`,
+		},
+	}, {
+		desc: "must using deref",
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1400)},
+			},
+			System: &xpathSystem{Ref: String("eth0")},
+		},
+		opts:     []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{`schema path /system/ref: must statement "deref(.)/../config/mtu >= 1500" is not satisfied: referenced interface has a small MTU`},
+	}, {
+		desc: "must on compressed container and list",
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500)},
+				"eth1": {Name: String("eth1"), Mtu: Uint32(10000)},
+				"eth2": {Name: String("eth2")},
+			},
+		},
+		opts: []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{
+			`schema path /interfaces/interface: must statement "count(/interfaces/interface) <= 2" is not satisfied`,
+			`schema path /interfaces/interface: must statement "count(/interfaces/interface) <= 2" is not satisfied`,
+			`schema path /interfaces/interface/config: must statement "not(mtu) or mtu <= 9216" is not satisfied`,
+			`schema path /interfaces/interface: must statement "count(/interfaces/interface) <= 2" is not satisfied`,
+		},
+	}, {
+		desc: "invalid must statement",
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Enabled: ygot.Bool(true)},
+			},
+		},
+		opts:     []ygot.ValidationOption{&EvaluateMustStatements{}},
+		wantErrs: []string{`schema path /interfaces/interface/config/enabled: invalid must statement: cannot parse XPath expression "invalid[": expected node test at position 8, got ""`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(schema, tt.in, tt.opts...) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Validate: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidateMustSubtree(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	schema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["mtu"].Extra = map[string][]interface{}{
+		"must": {
+			&yang.Must{Name: ". >= 1280 and ../name = current()/../../name"},
+			// The system container is outside of the validated interface,
+			// hence the statement is not evaluated.
+			&yang.Must{Name: "/system/hostname = 'router'"},
+		},
+	}
+
+	intf := &xpathInterface{Name: String("eth0"), Mtu: Uint32(1000)}
+	errs := Validate(schema.Dir["interfaces"].Dir["interface"], intf, &EvaluateMustStatements{})
+	want := `schema path /interfaces/interface/config/mtu: must statement ". >= 1280 and ../name = current()/../../name" is not satisfied`
+	if got := errs.String(); got != want {
+		t.Errorf("Validate: got error %s, want: %s", got, want)
+	}
+
+	intf.Mtu = Uint32(1500)
+	if errs := Validate(schema.Dir["interfaces"].Dir["interface"], intf, &EvaluateMustStatements{}); errs != nil {
+		t.Errorf("Validate: got unexpected error: %v", errs)
+	}
+}
+
+type mustYANGDevice struct {
+	System *xpathSystem `path:"system"`
+}
+
+func (*mustYANGDevice) IsYANGGoStruct() {}
+
+func TestValidateMustFromYANG(t *testing.T) {
+	schema := yangtest.ParseModule(t, "must-test", `
+module must-test {
+  prefix "mt";
+  namespace "urn:mt";
+
+  container system {
+    leaf hostname {
+      type string;
+      must "re-match(., '[a-z]+')" {
+        error-message "hostname must be lower case";
+      }
+      when "../server";
+    }
+
+    leaf-list server {
+      type string;
+      must "string-length(.) > 1";
+    }
+
+    leaf ref {
+      type string;
+    }
+  }
+}`)
+
+	tests := []struct {
+		desc     string
+		in       *mustYANGDevice
+		wantErrs []string
+	}{{
+		desc: "all statements satisfied",
+		in:   &mustYANGDevice{System: &xpathSystem{Hostname: String("router"), Server: []string{"s1"}}},
+	}, {
+		desc:     "leaf must",
+		in:       &mustYANGDevice{System: &xpathSystem{Hostname: String("ROUTER"), Server: []string{"s1"}}},
+		wantErrs: []string{`schema path /system/hostname: must statement "re-match(., '[a-z]+')" is not satisfied: hostname must be lower case`},
+	}, {
+		desc:     "leaf-list must",
+		in:       &mustYANGDevice{System: &xpathSystem{Server: []string{"s1", "a"}}},
+		wantErrs: []string{`schema path /system/server: must statement "string-length(.) > 1" is not satisfied`},
+	}, {
+		desc:     "leaf when",
+		in:       &mustYANGDevice{System: &xpathSystem{Hostname: String("router")}},
+		wantErrs: []string{`schema path /system/hostname: data node /system/hostname is populated but when statement "../server" is false`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(schema, tt.in, &EvaluateMustStatements{}, &EvaluateWhenStatements{}) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Validate: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
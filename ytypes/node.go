@@ -48,6 +48,13 @@ type retrieveNodeArgs struct {
 	// If val is set to a non-nil value, leaf/leaflist node corresponding
 	// to the given path is updated with this value.
 	val interface{}
+	// If valJSON is set to true, val is a JSON value as decoded by
+	// encoding/json, rather than a gNMI TypedValue.
+	valJSON bool
+	// If populateKeyTargets is set to true, the nodes within each list
+	// member that is created by modifyRoot that are the targets of list
+	// key leafrefs are set to the value of the key.
+	populateKeyTargets bool
 }
 
 // retrieveNode is an internal function that retrieves the node specified by
@@ -82,7 +89,7 @@ func retrieveNode(schema *yang.Entry, root interface{}, path, traversedPath *gpb
 
 	switch {
 	// Check if the schema is a container, or the schema is a list and the parent provided is a member of that list.
-	case schema.IsContainer() || (schema.IsList() && util.IsTypeStructPtr(reflect.TypeOf(root))):
+	case schema.IsContainer() || util.IsOperationContainer(schema) || (schema.IsList() && util.IsTypeStructPtr(reflect.TypeOf(root)) && !util.IsTypeOrderedMap(reflect.TypeOf(root))):
 		return retrieveNodeContainer(schema, root, path, traversedPath, args)
 	case schema.IsList():
 		return retrieveNodeList(schema, root, path, traversedPath, args)
@@ -126,8 +133,9 @@ func retrieveNodeContainer(schema *yang.Entry, root interface{}, path *gpb.Path,
 			if !util.PathMatchesPrefix(path, p) {
 				continue
 			}
+			isKeyedList := util.IsTypeMap(ft.Type) || util.IsTypeOrderedMap(ft.Type)
 			to := len(p)
-			if util.IsTypeMap(ft.Type) {
+			if isKeyedList {
 				to--
 			}
 
@@ -139,8 +147,9 @@ func retrieveNodeContainer(schema *yang.Entry, root interface{}, path *gpb.Path,
 
 			// If delete is specified, and the path is exhausted, then we set the
 			// corresponding field to its zero value. The zero value is the unset value for
-			// any node type, whether leaf or non-leaf.
-			if args.delete && len(path.Elem) == to {
+			// any node type, whether leaf or non-leaf. A path that specifies a keyed
+			// list without any keys deletes all members of the list.
+			if args.delete && (len(path.Elem) == to || isKeyedList && len(path.Elem) == len(p) && len(path.Elem[to].GetKey()) == 0) {
 				fv.Set(reflect.Zero(ft.Type))
 				return nil, nil
 			}
@@ -159,7 +168,11 @@ func retrieveNodeContainer(schema *yang.Entry, root interface{}, path *gpb.Path,
 					// With GNMIEncoding, unmarshalGeneric can only unmarshal leaf or leaf list
 					// nodes. Schema provided must be the schema of the leaf or leaf list node.
 					// root must be the reference of container leaf/leaf list belongs to.
-					if err := unmarshalGeneric(cschema, root, args.val, GNMIEncoding); err != nil {
+					enc := Encoding(GNMIEncoding)
+					if args.valJSON {
+						enc = JSONEncoding
+					}
+					if err := unmarshalGeneric(cschema, root, args.val, enc); err != nil {
 						return nil, status.Errorf(codes.Unknown, "failed to update struct field %s in %T with value %v; %v", ft.Name, root, args.val, err)
 					}
 				}
@@ -179,8 +192,8 @@ func retrieveNodeContainer(schema *yang.Entry, root interface{}, path *gpb.Path,
 	return nil, status.Errorf(codes.InvalidArgument, "no match found in %T, for path %v", root, path)
 }
 
-// retrieveNodeList is an internal function and operates on a map. It returns the nodes matching
-// with keys corresponding to the key supplied in path.
+// retrieveNodeList is an internal function and operates on a map or an ordered map. It returns
+// the nodes matching with keys corresponding to the key supplied in path.
 // Function returns list of nodes, list of schemas and error.
 func retrieveNodeList(schema *yang.Entry, root interface{}, path, traversedPath *gpb.Path, args retrieveNodeArgs) ([]*TreeNode, error) {
 	rv := reflect.ValueOf(root)
@@ -189,16 +202,16 @@ func retrieveNodeList(schema *yang.Entry, root interface{}, path, traversedPath
 		return nil, status.Errorf(codes.InvalidArgument, "unkeyed list can't be traversed, type %T, path %v", root, path)
 	case len(path.GetElem()) == 0:
 		return nil, status.Errorf(codes.InvalidArgument, "path length is 0, schema %v, root %v", schema, root)
-	case !util.IsValueMap(rv):
+	case !util.IsValueMap(rv) && !util.IsValueOrderedMap(rv):
 		return nil, status.Errorf(codes.InvalidArgument, "root has type %T, expect map", root)
 	}
 
 	var matches []*TreeNode
 
-	listKeyT := rv.Type().Key()
-	listElemT := rv.Type().Elem()
-	for _, k := range rv.MapKeys() {
-		listElemV := rv.MapIndex(k)
+	listKeyT, listElemT := keyedListTypes(rv.Type())
+	listKeys, listElems := util.KeyedListMembers(rv)
+	for i, k := range listKeys {
+		listElemV := listElems[i]
 
 		// Handle lists with a single key.
 		if !util.IsValueStruct(k) {
@@ -237,7 +250,7 @@ func retrieveNodeList(schema *yang.Entry, root interface{}, path, traversedPath
 			if keyAsString == pathKey {
 				remainingPath := util.PopGNMIPath(path)
 				if args.delete && len(remainingPath.GetElem()) == 0 {
-					rv.SetMapIndex(k, reflect.Value{})
+					deleteKeyedListMember(rv, k)
 					return nil, nil
 				}
 				return retrieveNode(schema, listElemV.Interface(), remainingPath, appendElem(traversedPath, path.GetElem()[0]), args)
@@ -291,7 +304,7 @@ func retrieveNodeList(schema *yang.Entry, root interface{}, path, traversedPath
 			}
 			remainingPath := util.PopGNMIPath(path)
 			if args.delete && len(remainingPath.GetElem()) == 0 {
-				rv.SetMapIndex(k, reflect.Value{})
+				deleteKeyedListMember(rv, k)
 				return nil, nil
 			}
 			nodes, err := retrieveNode(schema, listElemV.Interface(), remainingPath, appendElem(traversedPath, &gpb.PathElem{Name: path.GetElem()[0].Name, Key: keys}), args)
@@ -310,7 +323,13 @@ func retrieveNodeList(schema *yang.Entry, root interface{}, path, traversedPath
 		if err != nil {
 			return nil, err
 		}
-		nodes, err := retrieveNode(schema, rv.MapIndex(reflect.ValueOf(key)).Interface(), util.PopGNMIPath(path), appendElem(traversedPath, path.GetElem()[0]), args)
+		member := keyedListMember(rv, reflect.ValueOf(key))
+		if args.populateKeyTargets {
+			if err := setKeyLeafRefTargets(schema, member); err != nil {
+				return nil, status.Errorf(codes.Unknown, "failed to set leafref targets of keys for %v: %v", traversedPath, err)
+			}
+		}
+		nodes, err := retrieveNode(schema, member.Interface(), util.PopGNMIPath(path), appendElem(traversedPath, path.GetElem()[0]), args)
 		if err != nil {
 			return nil, err
 		}
@@ -416,8 +435,9 @@ func appendElem(p *gpb.Path, e *gpb.PathElem) *gpb.Path {
 // behaviours, such as whether or not to ensure that the node's ancestors are initialized.
 func SetNode(schema *yang.Entry, root interface{}, path *gpb.Path, val interface{}, opts ...SetNodeOpt) error {
 	nodes, err := retrieveNode(schema, root, path, nil, retrieveNodeArgs{
-		modifyRoot: hasInitMissingElements(opts),
-		val:        val,
+		modifyRoot:         hasInitMissingElements(opts),
+		val:                val,
+		populateKeyTargets: hasSetNodePopulateLeafRefTargets(opts),
 	})
 
 	if err != nil {
@@ -455,10 +475,25 @@ func hasInitMissingElements(opts []SetNodeOpt) bool {
 	return false
 }
 
+// hasSetNodePopulateLeafRefTargets determines whether there is an instance of
+// PopulateLeafRefTargets within the supplied SetNodeOpt slice. It is used to
+// determine whether to set the targets of the leafref keys of list members
+// that are created.
+func hasSetNodePopulateLeafRefTargets(opts []SetNodeOpt) bool {
+	for _, o := range opts {
+		if _, ok := o.(*PopulateLeafRefTargets); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // DeleteNode zeroes the value of the node specified by the supplied path from
 // the specified root, whose schema must also be supplied. If the node
 // specified by that path is already its zero value, or an intermediate node
 // in the path is nil (implying the node is already deleted), then the call is a no-op.
+// A path to a keyed list that does not specify any keys deletes every member
+// of the list.
 func DeleteNode(schema *yang.Entry, root interface{}, path *gpb.Path) error {
 	_, err := retrieveNode(schema, root, path, nil, retrieveNodeArgs{
 		delete: true,
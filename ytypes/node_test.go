@@ -1501,6 +1501,86 @@ func TestSetNode(t *testing.T) {
 	}
 }
 
+type keyLeafRefListConfig struct {
+	Name *string `path:"name"`
+}
+
+type keyLeafRefListElem struct {
+	Name   *string               `path:"name"`
+	Config *keyLeafRefListConfig `path:"config"`
+}
+
+type keyLeafRefDevice struct {
+	Interface map[string]*keyLeafRefListElem `path:"interface"`
+}
+
+func TestSetNodePopulateKeyTargets(t *testing.T) {
+	// The interface list is uncompressed, and its key is a leafref to a
+	// leaf within the config container of the same member.
+	schema := &yang.Entry{
+		Name: "device",
+		Kind: yang.DirectoryEntry,
+		Dir: map[string]*yang.Entry{
+			"interface": {
+				Name:     "interface",
+				Kind:     yang.DirectoryEntry,
+				ListAttr: &yang.ListAttr{},
+				Key:      "name",
+				Dir: map[string]*yang.Entry{
+					"name": {
+						Name: "name",
+						Kind: yang.LeafEntry,
+						Type: &yang.YangType{Kind: yang.Yleafref, Path: "../config/name"},
+					},
+					"config": {
+						Name: "config",
+						Kind: yang.DirectoryEntry,
+						Dir: map[string]*yang.Entry{
+							"name": {
+								Name: "name",
+								Kind: yang.LeafEntry,
+								Type: &yang.YangType{Kind: yang.Ystring},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	addParents(schema)
+
+	tests := []struct {
+		desc   string
+		inOpts []SetNodeOpt
+		want   *keyLeafRefDevice
+	}{{
+		desc:   "targets not populated by default",
+		inOpts: []SetNodeOpt{&InitMissingElements{}},
+		want: &keyLeafRefDevice{Interface: map[string]*keyLeafRefListElem{
+			"eth0": {Name: ygot.String("eth0")},
+		}},
+	}, {
+		desc:   "targets populated with option",
+		inOpts: []SetNodeOpt{&InitMissingElements{}, &PopulateLeafRefTargets{}},
+		want: &keyLeafRefDevice{Interface: map[string]*keyLeafRefListElem{
+			"eth0": {Name: ygot.String("eth0"), Config: &keyLeafRefListConfig{Name: ygot.String("eth0")}},
+		}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := &keyLeafRefDevice{}
+			val := &gpb.TypedValue{Value: &gpb.TypedValue_StringVal{StringVal: "eth0"}}
+			if err := SetNode(schema, got, mustPath("/interface[name=eth0]/name"), val, tt.inOpts...); err != nil {
+				t.Fatalf("SetNode: got unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetNode: got: %v\nwant: %v", pretty.Sprint(got), pretty.Sprint(tt.want))
+			}
+		})
+	}
+}
+
 func TestDeleteNode(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -1605,6 +1685,21 @@ func TestDeleteNode(t *testing.T) {
 				},
 			},
 		},
+	}, {
+		name:     "deleting all entries of a list",
+		inSchema: containerWithStringKey,
+		inRoot: &ContainerStruct1{
+			StructKeyList: map[string]*ListElemStruct1{
+				"forty-one": {
+					Key1: ygot.String("forty-one"),
+				},
+				"forty-two": {
+					Key1: ygot.String("forty-two"),
+				},
+			},
+		},
+		inPath: mustPath("/config/simple-key-list"),
+		want:   &ContainerStruct1{},
 	}, {
 		name:     "deleting an inner node from a multi-keyed list",
 		inSchema: containerWithMultiKeyedList,
@@ -17,9 +17,11 @@ package ytypes
 import (
 	"errors"
 	"fmt"
+	"reflect"
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // UnmarshalOpt is an interface used for any option to be supplied
@@ -43,8 +45,51 @@ func (*IgnoreExtraFields) IsUnmarshalOpt() {}
 // parent, using the given schema. Any values already in the parent that are
 // not present in value are preserved. If provided schema is a leaf or leaf
 // list, parent must be referencing the parent GoStruct.
+//
+// Where the EvaluateWhenStatements or PopulateLeafRefTargets options are
+// supplied, and parent is a GoStruct, the data is unmarshalled into a copy of
+// parent, which is checked before being stored in parent. parent is hence
+// unchanged if an error is returned, and the fields of parent refer to new
+// values if it is not.
 func Unmarshal(schema *yang.Entry, parent interface{}, value interface{}, opts ...UnmarshalOpt) error {
-	return unmarshalGeneric(schema, parent, value, JSONEncoding, opts...)
+	gs, ok := parent.(ygot.GoStruct)
+	if !ok || !(hasPopulateLeafRefTargets(opts) || hasUnmarshalEvaluateWhenStatements(opts)) || util.IsValueNil(parent) {
+		return unmarshalTree(schema, parent, value, opts...)
+	}
+	c, err := ygot.DeepCopy(gs)
+	if err != nil {
+		return fmt.Errorf("cannot copy parent of type %T: %v", parent, err)
+	}
+	if err := unmarshalTree(schema, c, value, opts...); err != nil {
+		return err
+	}
+	reflect.ValueOf(parent).Elem().Set(reflect.ValueOf(c).Elem())
+	return nil
+}
+
+// unmarshalTree unmarshals the JSON data tree in value into parent, using
+// the supplied schema, and then populates leafref targets and evaluates when
+// statements within the resulting data tree as specified by opts.
+func unmarshalTree(schema *yang.Entry, parent interface{}, value interface{}, opts ...UnmarshalOpt) error {
+	if err := unmarshalGeneric(schema, parent, value, JSONEncoding, opts...); err != nil {
+		return err
+	}
+	// Leafref targets are populated before when statements are evaluated,
+	// since the members that are created may satisfy when statements.
+	if hasPopulateLeafRefTargets(opts) && (schema.IsContainer() || util.IsOperationContainer(schema)) {
+		if err := populateLeafRefTargets(schema, parent); err != nil {
+			return err
+		}
+	}
+	// When statements can refer to any part of the unmarshalled data tree,
+	// and hence are evaluated only once the entire tree has been
+	// unmarshalled.
+	if hasUnmarshalEvaluateWhenStatements(opts) && (schema.IsContainer() || schema.IsList() || util.IsOperationContainer(schema)) {
+		if errs := validateWhen(schema, parent); errs != nil {
+			return errs
+		}
+	}
+	return nil
 }
 
 // Encoding specifies how the value provided to UnmarshalGeneric function is encoded.
@@ -88,7 +133,7 @@ func unmarshalGeneric(schema *yang.Entry, parent interface{}, value interface{},
 		return unmarshalList(schema, parent, value, enc, opts...)
 	case schema.IsChoice():
 		return fmt.Errorf("cannot pass choice schema %s to Unmarshal", schema.Name)
-	case schema.IsContainer():
+	case schema.IsContainer(), util.IsOperationContainer(schema):
 		return unmarshalContainer(schema, parent, value, enc, opts...)
 	}
 	return fmt.Errorf("unknown schema type for type %T, value %v", value, value)
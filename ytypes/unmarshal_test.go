@@ -15,9 +15,11 @@
 package ytypes
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/ygot"
 )
 
 func TestUnmarshal(t *testing.T) {
@@ -79,3 +81,51 @@ func TestUnmarshal(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalOperation(t *testing.T) {
+	type InputStruct struct {
+		Delay *uint32 `path:"delay"`
+	}
+	inputSchema := &yang.Entry{
+		Name: "input",
+		Kind: yang.InputEntry,
+		Dir: map[string]*yang.Entry{
+			"delay": {
+				Name: "delay",
+				Kind: yang.LeafEntry,
+				Type: &yang.YangType{Kind: yang.Yuint32},
+			},
+		},
+	}
+
+	tests := []struct {
+		desc    string
+		value   interface{}
+		want    InputStruct
+		wantErr string
+	}{{
+		desc:  "valid input",
+		value: map[string]interface{}{"delay": float64(10)},
+		want:  InputStruct{Delay: ygot.Uint32(10)},
+	}, {
+		desc:    "unknown field",
+		value:   map[string]interface{}{"method": "cold"},
+		wantErr: `parent container input (type *ytypes.InputStruct): JSON contains unexpected field method`,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got InputStruct
+			err := Unmarshal(inputSchema, &got, tt.value)
+			if got, want := errToString(err), tt.wantErr; got != want {
+				t.Errorf("%s: got error: %v, want error: %v", tt.desc, got, want)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: did not get expected struct, got: %v, want: %v", tt.desc, got, tt.want)
+			}
+		})
+	}
+}
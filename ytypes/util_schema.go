@@ -21,6 +21,7 @@ import (
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
 )
 
 // validateLengthSchema validates whether the given schema has a valid length
@@ -89,6 +90,8 @@ func validateListAttr(schema *yang.Entry, value interface{}) util.Errors {
 	var size int
 	if value == nil {
 		size = 0
+	} else if om, ok := value.(ygot.GoOrderedMap); ok {
+		size = om.Len()
 	} else {
 		switch reflect.TypeOf(value).Kind() {
 		case reflect.Slice, reflect.Map:
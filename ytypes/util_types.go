@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 
 	"github.com/openconfig/goyang/pkg/yang"
 	"github.com/openconfig/ygot/util"
@@ -88,12 +89,103 @@ func castToEnumValue(ft reflect.Type, value string) (interface{}, error) {
 	return nil, nil
 }
 
+// bitsStringToValue returns the bits type value that the space-separated bit
+// names within value map to for the field fieldName in the parent, which must
+// be a struct ptr.
+func bitsStringToValue(parent interface{}, fieldName, value string) (interface{}, error) {
+	util.DbgPrint("bitsStringToValue with parent type %T, fieldName %s, value %s", parent, fieldName, value)
+	v := reflect.ValueOf(parent)
+	if !util.IsValueStructPtr(v) {
+		return nil, fmt.Errorf("bitsStringToValue: %T is not a struct ptr", parent)
+	}
+	field := v.Elem().FieldByName(fieldName)
+	if !field.IsValid() {
+		return nil, fmt.Errorf("%s is not a valid bits field name in %T", fieldName, parent)
+	}
+
+	return castToBitsValue(field.Type(), value)
+}
+
+// castToBitsValue returns value, which is a space-separated list of bit names,
+// as the given type ft, which must be a GoBits type, or a pointer to, or slice
+// of, a GoBits type. It returns an error if any of the bit names are not
+// defined for ft.
+func castToBitsValue(ft reflect.Type, value string) (interface{}, error) {
+	if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Ptr {
+		// leaf-list or leaf case
+		ft = ft.Elem()
+	}
+
+	b, ok := reflect.Zero(ft).Interface().(ygot.GoBits)
+	if !ok || ft.Kind() != reflect.Uint64 {
+		return nil, fmt.Errorf("%s is not a valid bits type", ft)
+	}
+
+	m, ok := b.ΛBits()[ft.Name()]
+	if !ok {
+		return nil, fmt.Errorf("%s is not a known bits type", ft.Name())
+	}
+	positions := make(map[string]uint32, len(m))
+	for p, n := range m {
+		positions[n] = p
+	}
+
+	var u uint64
+	for _, n := range strings.Fields(value) {
+		p, ok := positions[n]
+		if !ok {
+			return nil, fmt.Errorf("%s is not a valid bit name for bits type %s", n, ft.Name())
+		}
+		u |= 1 << p
+	}
+	return reflect.ValueOf(u).Convert(ft).Interface(), nil
+}
+
+// decimalStringToValue returns the value that the decimal string value maps
+// to for the field fieldName in the parent, which must be a struct ptr. If the
+// field is an exact decimal field, the value is returned as a ygot.Decimal64
+// with the fraction digits specified by schema, otherwise it is returned as a
+// float64.
+func decimalStringToValue(parent interface{}, schema *yang.Entry, fieldName, value string) (interface{}, error) {
+	util.DbgPrint("decimalStringToValue with parent type %T, fieldName %s, value %s", parent, fieldName, value)
+	exact, err := isDecimal64Field(parent, fieldName)
+	if err != nil {
+		return nil, err
+	}
+	if !exact {
+		return strconv.ParseFloat(value, 64)
+	}
+	return ygot.ParseDecimal64(value, uint8(schema.Type.FractionDigits))
+}
+
+// isDecimal64Field returns true if the field fieldName in the parent, which
+// must be a struct ptr, is a ygot.Decimal64, or a pointer to, or slice of,
+// ygot.Decimal64.
+func isDecimal64Field(parent interface{}, fieldName string) (bool, error) {
+	v := reflect.ValueOf(parent)
+	if !util.IsValueStructPtr(v) {
+		return false, fmt.Errorf("isDecimal64Field: %T is not a struct ptr", parent)
+	}
+	field := v.Elem().FieldByName(fieldName)
+	if !field.IsValid() {
+		return false, fmt.Errorf("%s is not a valid decimal field name in %T", fieldName, parent)
+	}
+
+	ft := field.Type()
+	if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Ptr {
+		// leaf-list or leaf case
+		ft = ft.Elem()
+	}
+	return ft == reflect.TypeOf(ygot.Decimal64("")), nil
+}
+
 // StringToType converts given string to given type which can be one of
 // the following;
 // - int, int8, int16, int32, int64
 // - uint, uint8, uint16, uint32, uint64
 // - string
 // - GoEnum type
+// - GoBits type
 // Function can be extended to support other types as well. If the given string
 // carries an incompatible or overflowing value for the given type, function
 // returns error.
@@ -108,6 +200,13 @@ func StringToType(t reflect.Type, s string) (reflect.Value, error) {
 		}
 		return reflect.ValueOf(i), nil
 	}
+	if t.Implements(reflect.TypeOf((*ygot.GoBits)(nil)).Elem()) {
+		i, err := castToBitsValue(t, s)
+		if err != nil {
+			return reflect.ValueOf(nil), err
+		}
+		return reflect.ValueOf(i), nil
+	}
 
 	switch t.Kind() {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
@@ -179,7 +278,7 @@ func yangToJSONType(t yang.TypeKind) reflect.Type {
 	case yang.Yint8, yang.Yint16, yang.Yint32,
 		yang.Yuint8, yang.Yuint16, yang.Yuint32:
 		return reflect.TypeOf(float64(0))
-	case yang.Ybinary, yang.Ydecimal64, yang.Yenum, yang.Yidentityref, yang.Yint64, yang.Yuint64, yang.Ystring:
+	case yang.Ybinary, yang.Ybits, yang.Ydecimal64, yang.Yenum, yang.Yidentityref, yang.Yint64, yang.Yuint64, yang.Ystring:
 		return reflect.TypeOf(string(""))
 	case yang.Ybool:
 		return reflect.TypeOf(bool(false))
@@ -188,7 +287,6 @@ func yangToJSONType(t yang.TypeKind) reflect.Type {
 	case yang.Yunion:
 		return reflect.TypeOf(nil)
 	default:
-		// TODO(mostrowski): handle bitset.
 		log.Errorf("unexpected type %v in yangToJSONType", t)
 	}
 	return reflect.TypeOf(nil)
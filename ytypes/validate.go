@@ -74,24 +74,53 @@ func Validate(schema *yang.Entry, value interface{}, opts ...ygot.ValidationOpti
 		errs = ValidateLeafRefData(schema, value, leafrefOpt)
 	}
 
+	// Options are only supplied to the top-level call to Validate, hence
+	// must and when statements, and mandatory nodes, are checked for the
+	// entire tree at this point.
+	if hasEvaluateMustStatements(opts) {
+		errs = util.AppendErrs(errs, validateMust(schema, value))
+	}
+	if hasEvaluateWhenStatements(opts) {
+		errs = util.AppendErrs(errs, validateWhen(schema, value))
+	}
+	if hasEnforceMandatory(opts) {
+		errs = util.AppendErrs(errs, validateMandatory(schema, value))
+	}
+
+	return util.AppendErrs(errs, validate(schema, value))
+}
+
+// validate recursively validates the value of the given data tree struct
+// against the given schema. It is used to validate the descendants of the
+// value supplied to Validate, such that checks that are made against the
+// entire data tree by Validate are made only once.
+func validate(schema *yang.Entry, value interface{}) util.Errors {
+	// Nil value means the field is unset.
+	if util.IsValueNil(value) {
+		return nil
+	}
+	if schema == nil {
+		return util.NewErrs(fmt.Errorf("nil schema for type %T, value %v", value, value))
+	}
+
 	util.DbgPrint("Validate with value %v, type %T, schema name %s", util.ValueStr(value), value, schema.Name)
 
 	switch {
 	case schema.IsLeaf():
-		return util.AppendErrs(errs, validateLeaf(schema, value))
-	case schema.IsContainer():
+		return validateLeaf(schema, value)
+	case schema.IsContainer(), util.IsOperationContainer(schema):
 		gsv, ok := value.(ygot.GoStruct)
 		if !ok {
-			return util.AppendErr(errs, fmt.Errorf("type %T is not a GoStruct for schema %s", value, schema.Name))
+			return util.NewErrs(fmt.Errorf("type %T is not a GoStruct for schema %s", value, schema.Name))
 		}
-		return util.AppendErrs(errs, validateContainer(schema, gsv))
+		return validateContainer(schema, gsv)
 	case schema.IsLeafList():
-		return util.AppendErrs(errs, validateLeafList(schema, value))
+		return validateLeafList(schema, value)
 	case schema.IsList():
-		return util.AppendErrs(errs, validateList(schema, value))
+		return validateList(schema, value)
 	case schema.IsChoice():
-		return util.AppendErrs(errs, util.NewErrs(fmt.Errorf("cannot pass choice schema %s to Validate", schema.Name)))
+		return util.NewErrs(fmt.Errorf("cannot pass choice schema %s to Validate", schema.Name))
 	}
 
-	return util.AppendErrs(errs, util.NewErrs(fmt.Errorf("unknown schema type for type %T, value %v", value, value)))
+	return util.NewErrs(fmt.Errorf("unknown schema type for type %T, value %v", value, value))
 }
@@ -153,6 +153,24 @@ func TestValidate(t *testing.T) {
 		},
 	}
 
+	inputSchema := &yang.Entry{
+		Name: "input",
+		Kind: yang.InputEntry,
+		Dir: map[string]*yang.Entry{
+			"case1-leaf1": {
+				Kind: yang.LeafEntry,
+				Name: "case1-leaf1",
+				Type: &yang.YangType{Kind: yang.Ystring, Pattern: []string{"^a.*"}},
+			},
+		},
+	}
+
+	notificationSchema := &yang.Entry{
+		Name: "notification",
+		Kind: yang.NotificationEntry,
+		Dir:  map[string]*yang.Entry{},
+	}
+
 	tests := []struct {
 		desc       string
 		val        interface{}
@@ -224,6 +242,22 @@ func TestValidate(t *testing.T) {
 			schema: containerWithChoiceSchema,
 			val:    &Case1Leaf1ChoiceStruct{Case1Leaf1: ygot.String("Case1Leaf1Value")},
 		},
+		{
+			desc:   "rpc input",
+			schema: inputSchema,
+			val:    &Case1Leaf1ChoiceStruct{Case1Leaf1: ygot.String("abc")},
+		},
+		{
+			desc:    "rpc input with invalid leaf",
+			schema:  inputSchema,
+			val:     &Case1Leaf1ChoiceStruct{Case1Leaf1: ygot.String("fish")},
+			wantErr: `/case1-leaf1: "fish" does not match regular expression pattern "^a.*$" for schema case1-leaf1`,
+		},
+		{
+			desc:   "notification",
+			schema: notificationSchema,
+			val:    &EmptyContainerStruct{},
+		},
 		{
 			desc:    "choice schema not allowed",
 			schema:  &yang.Entry{Kind: yang.ChoiceEntry, Name: "choice"},
@@ -0,0 +1,85 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// EvaluateWhenStatements is an option that specifies that the YANG when
+// statements within the schema should be evaluated. When supplied to
+// Validate, or to Unmarshal, an error is returned for each data node that is
+// populated whilst the condition specified by its when statement is false.
+// Where the validated value is not the root of the data tree, when statements
+// that refer to data outside of the value are not evaluated, since that data
+// is not available.
+type EvaluateWhenStatements struct{}
+
+// IsValidationOption ensures that EvaluateWhenStatements implements the
+// ValidationOption interface.
+func (*EvaluateWhenStatements) IsValidationOption() {}
+
+// IsUnmarshalOpt marks EvaluateWhenStatements as a valid UnmarshalOpt.
+func (*EvaluateWhenStatements) IsUnmarshalOpt() {}
+
+// hasEvaluateWhenStatements determines whether the supplied slice of
+// ValidationOptions contains the EvaluateWhenStatements option.
+func hasEvaluateWhenStatements(opts []ygot.ValidationOption) bool {
+	for _, o := range opts {
+		if _, ok := o.(*EvaluateWhenStatements); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hasUnmarshalEvaluateWhenStatements determines whether the supplied slice
+// of UnmarshalOpts contains the EvaluateWhenStatements option.
+func hasUnmarshalEvaluateWhenStatements(opts []UnmarshalOpt) bool {
+	for _, o := range opts {
+		if _, ok := o.(*EvaluateWhenStatements); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateWhen evaluates the when statements within schema for each of the
+// data nodes of value, which is described by schema. An error is returned
+// for each data node that is populated whilst its when statement is false.
+func validateWhen(schema *yang.Entry, value interface{}) util.Errors {
+	start, err := util.NewXPathTree(schema, value)
+	if err != nil {
+		return util.NewErrs(err)
+	}
+	if start == nil {
+		return nil
+	}
+
+	inactive, errs := util.InactiveWhenNodes(start)
+	for _, n := range inactive {
+		var exprs []string
+		for _, w := range util.SchemaXPathStatements(n.Schema(), "when") {
+			exprs = append(exprs, w.Expr)
+		}
+		errs = util.AppendErr(errs, fmt.Errorf("schema path %s: data node %s is populated but when statement %q is false", util.SchemaTreePathNoModule(n.Schema()), n, strings.Join(exprs, " and ")))
+	}
+	return errs
+}
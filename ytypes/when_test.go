@@ -0,0 +1,236 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/yangtest"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+func TestValidateWhen(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	schema.Dir["system"].Dir["hostname"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "../server"}},
+	}
+	schema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["mtu"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "../enabled = 'true'"}},
+	}
+	schema.Dir["system"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "/interfaces/interface"}},
+	}
+
+	tests := []struct {
+		desc     string
+		schema   *yang.Entry
+		in       interface{}
+		opts     []ygot.ValidationOption
+		wantErrs []string
+	}{{
+		desc:   "all when statements true",
+		schema: schema,
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500), Enabled: ygot.Bool(true)},
+			},
+			System: &xpathSystem{Hostname: String("router"), Server: []string{"s1"}},
+		},
+		opts: []ygot.ValidationOption{&EvaluateWhenStatements{}},
+	}, {
+		desc:   "when statements not evaluated without option",
+		schema: schema,
+		in: &xpathDevice{
+			System: &xpathSystem{Hostname: String("router")},
+		},
+	}, {
+		desc:   "leaf populated with false when statement",
+		schema: schema,
+		in: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Mtu: Uint32(1500), Enabled: ygot.Bool(false)},
+			},
+		},
+		opts:     []ygot.ValidationOption{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /interfaces/interface/config/mtu: data node /interfaces/interface/config/mtu is populated but when statement "../enabled = 'true'" is false`},
+	}, {
+		desc:   "only the inactive container is reported",
+		schema: schema,
+		in: &xpathDevice{
+			System: &xpathSystem{Hostname: String("router")},
+		},
+		opts:     []ygot.ValidationOption{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /system: data node /system is populated but when statement "/interfaces/interface" is false`},
+	}, {
+		// The when statement of the system container refers to the
+		// interfaces, which are outside of the validated container, hence
+		// it is not evaluated, whereas that of the hostname leaf is.
+		desc:     "container below the root",
+		schema:   schema.Dir["system"],
+		in:       &xpathSystem{Hostname: String("router")},
+		opts:     []ygot.ValidationOption{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /system/hostname: data node /system/hostname is populated but when statement "../server" is false`},
+	}, {
+		desc:   "container below the root with all when statements true",
+		schema: schema.Dir["system"],
+		in:     &xpathSystem{Hostname: String("router"), Server: []string{"s1"}},
+		opts:   []ygot.ValidationOption{&EvaluateWhenStatements{}},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			var got []string
+			for _, err := range Validate(tt.schema, tt.in, tt.opts...) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Validate: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnmarshalWhen(t *testing.T) {
+	schema := yangtest.XPathSchema(t)
+	schema.Dir["system"].Dir["hostname"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "../server"}},
+	}
+	schema.Dir["interfaces"].Dir["interface"].Dir["config"].Dir["mtu"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "../enabled = 'true'"}},
+	}
+	schema.Dir["system"].Extra = map[string][]interface{}{
+		"when": {&yang.Value{Name: "/interfaces/interface"}},
+	}
+
+	tests := []struct {
+		desc     string
+		schema   *yang.Entry
+		parent   interface{}
+		json     map[string]interface{}
+		opts     []UnmarshalOpt
+		wantErrs []string
+	}{{
+		desc:   "all when statements true",
+		schema: schema,
+		parent: &xpathDevice{},
+		json: map[string]interface{}{
+			"system": map[string]interface{}{
+				"hostname": "router",
+				"server":   []interface{}{"s1"},
+			},
+			"interfaces": map[string]interface{}{
+				"interface": []interface{}{
+					map[string]interface{}{
+						"name": "eth0",
+						"config": map[string]interface{}{
+							"name":    "eth0",
+							"mtu":     float64(1500),
+							"enabled": true,
+						},
+					},
+				},
+			},
+		},
+		opts: []UnmarshalOpt{&EvaluateWhenStatements{}},
+	}, {
+		desc:   "leaf populated with false when statement",
+		schema: schema,
+		parent: &xpathDevice{},
+		json: map[string]interface{}{
+			"interfaces": map[string]interface{}{
+				"interface": []interface{}{
+					map[string]interface{}{
+						"name": "eth0",
+						"config": map[string]interface{}{
+							"name": "eth0",
+							"mtu":  float64(1500),
+						},
+					},
+				},
+			},
+		},
+		opts:     []UnmarshalOpt{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /interfaces/interface/config/mtu: data node /interfaces/interface/config/mtu is populated but when statement "../enabled = 'true'" is false`},
+	}, {
+		desc:   "populated parent unchanged by false when statement",
+		schema: schema,
+		parent: &xpathDevice{
+			Interface: map[string]*xpathInterface{
+				"eth0": {Name: String("eth0"), Enabled: ygot.Bool(false)},
+			},
+		},
+		json: map[string]interface{}{
+			"system": map[string]interface{}{
+				"hostname": "router",
+			},
+		},
+		opts:     []UnmarshalOpt{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /system/hostname: data node /system/hostname is populated but when statement "../server" is false`},
+	}, {
+		desc:   "when statements not evaluated without option",
+		schema: schema,
+		parent: &xpathDevice{},
+		json: map[string]interface{}{
+			"system": map[string]interface{}{
+				"hostname": "router",
+			},
+		},
+	}, {
+		desc:   "list element",
+		schema: schema.Dir["interfaces"].Dir["interface"],
+		parent: &xpathInterface{},
+		json: map[string]interface{}{
+			"name": "eth0",
+			"config": map[string]interface{}{
+				"name": "eth0",
+				"mtu":  float64(1500),
+			},
+		},
+		opts:     []UnmarshalOpt{&EvaluateWhenStatements{}},
+		wantErrs: []string{`schema path /interfaces/interface/config/mtu: data node /interfaces/interface/config/mtu is populated but when statement "../enabled = 'true'" is false`},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, err := ygot.DeepCopy(tt.parent.(ygot.GoStruct))
+			if err != nil {
+				t.Fatalf("DeepCopy: got unexpected error: %v", err)
+			}
+
+			var got []string
+			err = Unmarshal(tt.schema, tt.parent, tt.json, tt.opts...)
+			switch errs := err.(type) {
+			case nil:
+			case util.Errors:
+				for _, err := range errs {
+					got = append(got, err.Error())
+				}
+			default:
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tt.wantErrs, got); diff != "" {
+				t.Errorf("Unmarshal: did not get expected errors, diff(-want, +got):\n%s", diff)
+			}
+			if err != nil {
+				if diff := cmp.Diff(orig, tt.parent); diff != "" {
+					t.Errorf("Unmarshal: parent modified despite error, diff(-want, +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
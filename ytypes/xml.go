@@ -0,0 +1,283 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+)
+
+// netconfBaseNamespace is the XML namespace of the NETCONF protocol
+// elements, as per RFC6241 Section 3.1.
+const netconfBaseNamespace = "urn:ietf:params:xml:ns:netconf:base:1.0"
+
+// UnmarshalXML unmarshals the RFC7950 XML document in data into the GoStruct
+// parent, which is described by schema. The document consists of an element
+// for each data node within parent, as output by ygot.EmitXML. A NETCONF
+// <config> or <data> element that contains these elements is also accepted.
+// The XML is mapped to the corresponding RFC7951 JSON, which is then
+// unmarshalled with the supplied options as per Unmarshal. The modules that
+// define the identities that are used as identityref values are determined
+// from the module namespaces that are stored within the schema by ygen. Any
+// values already in parent that are not present in data are preserved.
+func UnmarshalXML(schema *yang.Entry, parent interface{}, data []byte, opts ...UnmarshalOpt) error {
+	if schema == nil {
+		return fmt.Errorf("nil schema for parent type %T", parent)
+	}
+
+	elems, err := parseXMLData(data)
+	if err != nil {
+		return err
+	}
+
+	tree, err := xmlToJSON(schema, elems, namespaceModules(schema), opts)
+	if err != nil {
+		return err
+	}
+	return Unmarshal(schema, parent, tree, opts...)
+}
+
+// xmlElement is an element of an XML document that is unmarshalled by
+// UnmarshalXML.
+type xmlElement struct {
+	// name is the local name of the element.
+	name string
+	// ns is the XML namespace of the element.
+	ns string
+	// prefixes is a map, keyed by prefix, of the namespaces that are in
+	// scope for the element. The default namespace is keyed by the empty
+	// string.
+	prefixes map[string]string
+	// text is the character data of the element.
+	text string
+	// operation is the value of the NETCONF operation attribute of the
+	// element, as per RFC6241 Section 7.2.
+	operation string
+	// children is the set of child elements, in document order.
+	children []*xmlElement
+}
+
+// parseXML parses the XML document data, and returns an element whose
+// children are the top-level elements of the document.
+func parseXML(data []byte) (*xmlElement, error) {
+	root := &xmlElement{prefixes: map[string]string{}}
+	stack := []*xmlElement{root}
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse XML: %v", err)
+		}
+
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &xmlElement{name: t.Name.Local, ns: t.Name.Space, prefixes: map[string]string{}}
+			for p, ns := range top.prefixes {
+				e.prefixes[p] = ns
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					e.prefixes[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					e.prefixes[""] = a.Value
+				case a.Name.Space == netconfBaseNamespace && a.Name.Local == "operation":
+					e.operation = a.Value
+				}
+			}
+			top.children = append(top.children, e)
+			stack = append(stack, e)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			top.text += string(t)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("cannot parse XML: unexpected end of document")
+	}
+	return root, nil
+}
+
+// namespaceModules returns a map, keyed by XML namespace, of the names of the
+// modules that the schema tree that schema is within was generated from.
+func namespaceModules(schema *yang.Entry) map[string]string {
+	modules := map[string]string{}
+	for m, ns := range util.ModuleNamespaces(schema) {
+		modules[ns] = m
+	}
+	return modules
+}
+
+// parseXMLData parses the XML document data, and returns its top-level
+// elements. If the document consists of a NETCONF <config> or <data>
+// element, its children are returned.
+func parseXMLData(data []byte) ([]*xmlElement, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	elems := root.children
+	if len(elems) == 1 && elems[0].ns == netconfBaseNamespace && (elems[0].name == "config" || elems[0].name == "data") {
+		elems = elems[0].children
+	}
+	return elems, nil
+}
+
+// xmlToJSON returns the RFC7951 JSON object that corresponds to the XML
+// elements elems, which are the children of the data node described by
+// schema. The modules map is keyed by XML namespace. Elements that are not
+// described by the schema are an error, unless the IgnoreExtraFields option
+// is specified.
+func xmlToJSON(schema *yang.Entry, elems []*xmlElement, modules map[string]string, opts []UnmarshalOpt) (map[string]interface{}, error) {
+	out := map[string]interface{}{}
+	for _, e := range elems {
+		cs := util.FirstChild(schema, []string{e.name})
+		if cs == nil {
+			if hasIgnoreExtraFields(opts) {
+				continue
+			}
+			return nil, fmt.Errorf("element %s is not a valid child of %s", e.name, schema.Name)
+		}
+
+		switch {
+		case cs.IsList():
+			m, err := xmlToJSON(cs, e.children, modules, opts)
+			if err != nil {
+				return nil, err
+			}
+			l, _ := out[e.name].([]interface{})
+			out[e.name] = append(l, m)
+		case cs.IsContainer():
+			m, err := xmlToJSON(cs, e.children, modules, opts)
+			if err != nil {
+				return nil, err
+			}
+			if em, ok := out[e.name].(map[string]interface{}); ok {
+				for k, v := range m {
+					em[k] = v
+				}
+				continue
+			}
+			out[e.name] = m
+		case cs.IsLeaf(), cs.IsLeafList():
+			rs, err := util.ResolveIfLeafRef(cs)
+			if err != nil {
+				return nil, err
+			}
+			if rs.Type == nil {
+				return nil, fmt.Errorf("leaf schema type is nil for schema %s", cs.Name)
+			}
+			v, _ := xmlLeafValue(rs.Type, e, modules)
+			if cs.IsLeaf() {
+				out[e.name] = v
+				continue
+			}
+			l, _ := out[e.name].([]interface{})
+			out[e.name] = append(l, v)
+		default:
+			return nil, fmt.Errorf("unsupported schema node %s for XML element %s", cs.Name, e.name)
+		}
+	}
+	return out, nil
+}
+
+// xmlLeafValue returns the RFC7951 JSON value that corresponds to the text of
+// the XML element e, which is a leaf or leaf-list of type t. It returns false
+// if the text is not a valid lexical representation of t, in which case the
+// text is returned such that the error is reported when it is unmarshalled.
+// The value of a union is that of the first member type whose lexical
+// representation the text matches, as per RFC7950 Section 9.12.
+func xmlLeafValue(t *yang.YangType, e *xmlElement, modules map[string]string) (interface{}, bool) {
+	s := strings.TrimSpace(e.text)
+	switch t.Kind {
+	case yang.Yunion:
+		for _, mt := range t.Type {
+			if v, ok := xmlLeafValue(mt, e, modules); ok {
+				return v, true
+			}
+		}
+	case yang.Ystring:
+		// Whitespace is significant within string values.
+		return e.text, true
+	case yang.Yempty:
+		if s == "" {
+			return []interface{}{nil}, true
+		}
+	case yang.Ybool:
+		if b, err := strconv.ParseBool(s); err == nil && (s == "true" || s == "false") {
+			return b, true
+		}
+	case yang.Yint8, yang.Yint16, yang.Yint32:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return float64(i), true
+		}
+	case yang.Yuint8, yang.Yuint16, yang.Yuint32:
+		if i, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return float64(i), true
+		}
+	case yang.Yint64:
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return s, true
+		}
+	case yang.Yuint64:
+		if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+			return s, true
+		}
+	case yang.Ydecimal64:
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			return s, true
+		}
+	case yang.Ybinary:
+		// Base64 encoded values may be split across lines.
+		return strings.Join(strings.Fields(e.text), ""), true
+	case yang.Yidentityref:
+		return xmlIdentityName(s, e.prefixes, modules), true
+	case yang.Yenum, yang.Ybits:
+		return s, true
+	}
+	return s, false
+}
+
+// xmlIdentityName returns the RFC7951 name of the identity that is referred
+// to by the XML qualified name qn, which is qualified with the name of the
+// module that defines the identity. The namespace that the prefix of qn
+// refers to is resolved using the prefixes that are in scope, and mapped to a
+// module name using the modules map, which is keyed by XML namespace. Where
+// the namespace is not known, the prefix itself is used as the module name.
+func xmlIdentityName(qn string, prefixes map[string]string, modules map[string]string) string {
+	pfx, name := "", qn
+	if i := strings.Index(qn, ":"); i != -1 {
+		pfx, name = qn[:i], qn[i+1:]
+	}
+	if m, ok := modules[prefixes[pfx]]; ok && prefixes[pfx] != "" {
+		return fmt.Sprintf("%s:%s", m, name)
+	}
+	if pfx != "" {
+		return fmt.Sprintf("%s:%s", pfx, name)
+	}
+	return name
+}
@@ -0,0 +1,245 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/gnmi/errdiff"
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+type xmlDevice struct {
+	Hostname  *string                  `path:"system/config/hostname"`
+	DNS       []string                 `path:"system/config/dns"`
+	Mtu       *uint16                  `path:"system/config/mtu"`
+	Counter   *uint64                  `path:"system/config/counter"`
+	Enabled   *bool                    `path:"system/config/enabled"`
+	Flag      YANGEmpty                `path:"system/config/flag"`
+	KeyData   Binary                   `path:"system/config/key-data"`
+	Ident     EnumType                 `path:"system/config/ident"`
+	Logging   *xmlLogging              `path:"system/logging" yangPresence:"true"`
+	Interface map[string]*xmlInterface `path:"interfaces/interface"`
+	Server    []*xmlServer             `path:"servers/server"`
+}
+
+func (*xmlDevice) IsYANGGoStruct() {}
+
+type xmlLogging struct {
+	Level *string `path:"level"`
+}
+
+func (*xmlLogging) IsYANGGoStruct() {}
+
+type xmlInterface struct {
+	Mtu  *uint16 `path:"config/mtu"`
+	Name *string `path:"config/name|name"`
+}
+
+func (*xmlInterface) IsYANGGoStruct() {}
+
+type xmlServer struct {
+	Address *string `path:"address"`
+	Port    *uint16 `path:"port"`
+}
+
+func (*xmlServer) IsYANGGoStruct() {}
+
+func TestUnmarshalXML(t *testing.T) {
+	tests := []struct {
+		desc             string
+		in               string
+		inParent         *xmlDevice
+		inOpts           []UnmarshalOpt
+		want             *xmlDevice
+		wantErrSubstring string
+	}{{
+		desc: "leaves and leaf-lists",
+		in: `<system xmlns="urn:dev">
+  <config>
+    <hostname> rtr&lt;1&gt;</hostname>
+    <dns>10.0.0.1</dns>
+    <dns>10.0.0.2</dns>
+    <mtu> 1500 </mtu>
+    <counter>18446744073709551615</counter>
+    <enabled>true</enabled>
+    <flag/>
+    <key-data>
+      YWJj
+    </key-data>
+  </config>
+</system>`,
+		want: &xmlDevice{
+			Hostname: ygot.String(" rtr<1>"),
+			DNS:      []string{"10.0.0.1", "10.0.0.2"},
+			Mtu:      ygot.Uint16(1500),
+			Counter:  ygot.Uint64(18446744073709551615),
+			Enabled:  ygot.Bool(true),
+			Flag:     true,
+			KeyData:  Binary("abc"),
+		},
+	}, {
+		desc: "identityref with declared prefix",
+		in:   `<system xmlns="urn:dev" xmlns:f="urn:foo"><config><ident>f:E_VALUE_FORTY_TWO</ident></config></system>`,
+		want: &xmlDevice{Ident: 42},
+	}, {
+		desc: "keyed and keyless lists within NETCONF config element",
+		in: `<config xmlns="urn:ietf:params:xml:ns:netconf:base:1.0">
+  <interfaces xmlns="urn:dev">
+    <interface>
+      <name>eth0</name>
+      <config><name>eth0</name><mtu>9000</mtu></config>
+    </interface>
+    <interface>
+      <name>eth1</name>
+    </interface>
+  </interfaces>
+  <servers xmlns="urn:dev">
+    <server><address>192.0.2.2</address><port>53</port></server>
+    <server><address>192.0.2.1</address></server>
+  </servers>
+</config>`,
+		want: &xmlDevice{
+			Interface: map[string]*xmlInterface{
+				"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(9000)},
+				"eth1": {Name: ygot.String("eth1")},
+			},
+			Server: []*xmlServer{
+				{Address: ygot.String("192.0.2.2"), Port: ygot.Uint16(53)},
+				{Address: ygot.String("192.0.2.1")},
+			},
+		},
+	}, {
+		desc:     "existing values are retained",
+		in:       `<system><logging/></system>`,
+		inParent: &xmlDevice{Hostname: ygot.String("rtr1")},
+		want:     &xmlDevice{Hostname: ygot.String("rtr1"), Logging: &xmlLogging{}},
+	}, {
+		desc:             "unknown element",
+		in:               `<system><clock/></system>`,
+		wantErrSubstring: "element clock is not a valid child of system",
+	}, {
+		desc:   "unknown element ignored",
+		in:     `<system><clock/><logging><level>info</level></logging></system>`,
+		inOpts: []UnmarshalOpt{&IgnoreExtraFields{}},
+		want:   &xmlDevice{Logging: &xmlLogging{Level: ygot.String("info")}},
+	}, {
+		desc:             "invalid value",
+		in:               `<system><config><mtu>jumbo</mtu></config></system>`,
+		wantErrSubstring: "got string type for field mtu, expect float64",
+	}, {
+		desc:             "malformed XML",
+		in:               `<system><config>`,
+		wantErrSubstring: "cannot parse XML",
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			got := tt.inParent
+			if got == nil {
+				got = &xmlDevice{}
+			}
+			err := UnmarshalXML(encodingtest.Schema(), got, []byte(tt.in), tt.inOpts...)
+			if diff := errdiff.Substring(err, tt.wantErrSubstring); diff != "" {
+				t.Fatalf("UnmarshalXML: did not get expected error, %s", diff)
+			}
+			if err != nil {
+				return
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("UnmarshalXML: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestXMLLeafValue(t *testing.T) {
+	union := &yang.YangType{
+		Kind: yang.Yunion,
+		Type: []*yang.YangType{{Kind: yang.Yint8}, {Kind: yang.Yempty}, {Kind: yang.Ystring}},
+	}
+	tests := []struct {
+		desc   string
+		inType *yang.YangType
+		in     *xmlElement
+		want   interface{}
+		wantOK bool
+	}{{
+		desc:   "int32",
+		inType: &yang.YangType{Kind: yang.Yint32},
+		in:     &xmlElement{text: "-42"},
+		want:   float64(-42),
+		wantOK: true,
+	}, {
+		desc:   "invalid int32",
+		inType: &yang.YangType{Kind: yang.Yint32},
+		in:     &xmlElement{text: "forty-two"},
+		want:   "forty-two",
+	}, {
+		desc:   "decimal64",
+		inType: &yang.YangType{Kind: yang.Ydecimal64},
+		in:     &xmlElement{text: " 1.50 "},
+		want:   "1.50",
+		wantOK: true,
+	}, {
+		desc:   "invalid bool",
+		inType: &yang.YangType{Kind: yang.Ybool},
+		in:     &xmlElement{text: "1"},
+		want:   "1",
+	}, {
+		desc:   "identityref with default namespace",
+		inType: &yang.YangType{Kind: yang.Yidentityref},
+		in:     &xmlElement{text: "ID", prefixes: map[string]string{"": "urn:foo"}},
+		want:   "foo:ID",
+		wantOK: true,
+	}, {
+		desc:   "identityref with unknown prefix",
+		inType: &yang.YangType{Kind: yang.Yidentityref},
+		in:     &xmlElement{text: "bar:ID", prefixes: map[string]string{}},
+		want:   "bar:ID",
+		wantOK: true,
+	}, {
+		desc:   "union of int8",
+		inType: union,
+		in:     &xmlElement{text: "42"},
+		want:   float64(42),
+		wantOK: true,
+	}, {
+		desc:   "union of empty",
+		inType: union,
+		in:     &xmlElement{},
+		want:   []interface{}{nil},
+		wantOK: true,
+	}, {
+		desc:   "union of string",
+		inType: union,
+		in:     &xmlElement{text: "forty-two"},
+		want:   "forty-two",
+		wantOK: true,
+	}}
+
+	for _, tt := range tests {
+		got, ok := xmlLeafValue(tt.inType, tt.in, map[string]string{"urn:foo": "foo"})
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Errorf("%s: xmlLeafValue(%v, %v): did not get expected value, diff(-want, +got):\n%s", tt.desc, tt.inType, tt.in, diff)
+		}
+		if ok != tt.wantOK {
+			t.Errorf("%s: xmlLeafValue(%v, %v): got ok: %v, want: %v", tt.desc, tt.inType, tt.in, ok, tt.wantOK)
+		}
+	}
+}
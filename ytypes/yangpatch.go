@@ -0,0 +1,560 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/openconfig/goyang/pkg/yang"
+	"github.com/openconfig/ygot/util"
+	"github.com/openconfig/ygot/ygot"
+)
+
+// Refer to: https://tools.ietf.org/html/rfc8072 for the semantics of the
+// YANG Patch edit operations.
+
+// YANGPatchError is an error that results from applying a YANG Patch.
+type YANGPatchError struct {
+	// EditID is the edit-id of the edit that failed, or the empty string
+	// if the error does not relate to a single edit.
+	EditID string
+	// Tag is the error-tag that identifies the error condition, which is
+	// one of the ErrorTag constants.
+	Tag string
+	// Path is the target of the edit that failed.
+	Path string
+	// Message describes the error.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *YANGPatchError) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Tag, e.Message)
+	if e.Path != "" {
+		msg = fmt.Sprintf("%s at %s: %s", e.Tag, e.Path, e.Message)
+	}
+	if e.EditID == "" {
+		return msg
+	}
+	return fmt.Sprintf("edit %s: %s", e.EditID, msg)
+}
+
+// yangPatchDoc is a YANG Patch document, encoded as per RFC7951.
+type yangPatchDoc struct {
+	Patch *struct {
+		PatchID string `json:"patch-id"`
+		Edit    []*struct {
+			EditID    string                 `json:"edit-id"`
+			Operation string                 `json:"operation"`
+			Target    string                 `json:"target"`
+			Point     string                 `json:"point"`
+			Where     string                 `json:"where"`
+			Value     map[string]interface{} `json:"value"`
+		} `json:"edit"`
+	} `json:"ietf-yang-patch:yang-patch"`
+}
+
+// ApplyYANGPatch applies the YANG Patch document patch, as per RFC8072 and
+// encoded as per RFC7951, to root, which must be the GoStruct described by
+// schema. The edits of the patch are applied in order. The target of each
+// edit, and the point of insert and move edits, is a data resource
+// identifier, as per RFC8040, relative to root. Containers that are
+// ancestors of a target are created where required, but list entries that
+// are ancestors of a target must already exist.
+//
+// The create, delete, merge, replace and remove operations are supported
+// for all data nodes other than the entries of keyless lists. The insert
+// and move operations are supported for entries of ordered-by user lists
+// and leaf-lists.
+//
+// The patch is applied atomically - if any of its edits fails, or the
+// resulting data tree cannot be unmarshalled, root is left unchanged. The
+// error returned is a *YANGPatchError.
+func ApplyYANGPatch(schema *yang.Entry, root ygot.GoStruct, patch []byte) error {
+	switch {
+	case schema == nil:
+		return &YANGPatchError{Tag: ErrorTagOperationFailed, Message: "nil schema"}
+	case util.IsValueNil(root):
+		return &YANGPatchError{Tag: ErrorTagOperationFailed, Message: "nil root"}
+	}
+
+	var doc yangPatchDoc
+	if err := json.Unmarshal(patch, &doc); err != nil {
+		return &YANGPatchError{Tag: ErrorTagMalformedMessage, Message: fmt.Sprintf("cannot decode YANG Patch: %v", err)}
+	}
+	if doc.Patch == nil {
+		return &YANGPatchError{Tag: ErrorTagMalformedMessage, Message: "document does not contain ietf-yang-patch:yang-patch"}
+	}
+
+	tree, err := editTree(root, nil)
+	if err != nil {
+		return &YANGPatchError{Tag: ErrorTagOperationFailed, Message: err.Error()}
+	}
+	for _, e := range doc.Patch.Edit {
+		p := &yangPatcher{schema: schema, tree: tree, target: e.Target}
+		if err := p.apply(EditOperation(e.Operation), e.Value, e.Where, e.Point); err != nil {
+			err.EditID = e.EditID
+			return err
+		}
+	}
+
+	n := reflect.New(reflect.TypeOf(root).Elem())
+	if err := Unmarshal(schema, n.Interface(), tree); err != nil {
+		return &YANGPatchError{Tag: ErrorTagInvalidValue, Message: err.Error()}
+	}
+	reflect.ValueOf(root).Elem().Set(n.Elem())
+	return nil
+}
+
+// YANG Patch operations that are not also edit-config operations.
+const (
+	// yangPatchInsert inserts an entry into an ordered-by user list.
+	yangPatchInsert EditOperation = "insert"
+	// yangPatchMove moves an entry of an ordered-by user list.
+	yangPatchMove EditOperation = "move"
+)
+
+// yangPatcher applies an edit of a YANG Patch to the RFC7951 JSON tree of a
+// data tree, whose member names are not qualified with module names.
+type yangPatcher struct {
+	// schema is the schema of the root of tree.
+	schema *yang.Entry
+	// tree is the JSON tree that is edited.
+	tree map[string]interface{}
+	// target is the target of the edit.
+	target string
+}
+
+// yangPatchNode is a data node that is the target of an edit.
+type yangPatchNode struct {
+	// parent is the JSON object of the parent of the data node, or nil if
+	// the parent does not exist.
+	parent map[string]interface{}
+	// schema is the schema of the data node.
+	schema *yang.Entry
+	// entry is true if the data node is an entry of a list or leaf-list.
+	entry bool
+	// keys is the values of the keys of a list entry, keyed by name, or
+	// the value of a leaf-list entry keyed by the name of the leaf-list.
+	keys map[string]string
+	// index is the index of the entry within its list, or -1 if it does
+	// not exist.
+	index int
+	// exists indicates that the data node exists.
+	exists bool
+}
+
+// errorf returns a *YANGPatchError for the target of p.
+func (p *yangPatcher) errorf(tag, format string, args ...interface{}) *YANGPatchError {
+	return &YANGPatchError{Tag: tag, Path: p.target, Message: fmt.Sprintf(format, args...)}
+}
+
+// apply applies the edit with operation op and value val to the tree of p.
+// The where and point arguments specify the position of insert and move
+// edits.
+func (p *yangPatcher) apply(op EditOperation, val map[string]interface{}, where, point string) *YANGPatchError {
+	create := op == EditCreate || op == EditMerge || op == EditReplace || op == yangPatchInsert
+	n, err := p.resolve(p.target, create)
+	if err != nil {
+		return err
+	}
+
+	var v interface{}
+	switch op {
+	case EditCreate, EditMerge, EditReplace, yangPatchInsert:
+		if v, err = p.value(n, val); err != nil {
+			return err
+		}
+	case EditDelete, EditRemove, yangPatchMove:
+	default:
+		return p.errorf(ErrorTagInvalidValue, "invalid operation %q", op)
+	}
+
+	switch op {
+	case EditCreate:
+		if n.exists {
+			return p.errorf(ErrorTagDataExists, "data already exists")
+		}
+		p.set(n, v)
+	case EditDelete:
+		if !n.exists {
+			return p.errorf(ErrorTagDataMissing, "data does not exist")
+		}
+		p.remove(n)
+	case EditRemove:
+		if n.exists {
+			p.remove(n)
+		}
+	case EditReplace:
+		p.set(n, v)
+	case EditMerge:
+		if !n.exists {
+			p.set(n, v)
+			return nil
+		}
+		cur := n.parent[n.schema.Name]
+		if n.entry {
+			cur = cur.([]interface{})[n.index]
+		}
+		merged, err := p.merge(n.schema, cur, v)
+		if err != nil {
+			return err
+		}
+		p.set(n, merged)
+	case yangPatchInsert, yangPatchMove:
+		if !n.entry || !util.IsOrderedByUser(n.schema) {
+			return p.errorf(ErrorTagOperationNotSupported, "operation %s is only supported for entries of ordered-by user lists", op)
+		}
+		if op == yangPatchInsert && n.exists {
+			return p.errorf(ErrorTagDataExists, "data already exists")
+		}
+		if op == yangPatchMove {
+			if !n.exists {
+				return p.errorf(ErrorTagDataMissing, "data does not exist")
+			}
+			l := n.parent[n.schema.Name].([]interface{})
+			v = l[n.index]
+			p.remove(n)
+		}
+		return p.insert(n, v, where, point)
+	}
+	return nil
+}
+
+// resolve returns the data node identified by the data resource identifier
+// id. If create is true, the containers that are ancestors of the data node
+// are created if they do not exist. The parent of the returned data node is
+// nil if an ancestor of it does not exist.
+func (p *yangPatcher) resolve(id string, create bool) (*yangPatchNode, *YANGPatchError) {
+	segs, err := util.ParseResourceID(id)
+	switch {
+	case err != nil:
+		return nil, p.errorf(ErrorTagInvalidValue, "invalid target: %v", err)
+	case len(segs) == 0:
+		return nil, p.errorf(ErrorTagInvalidValue, "invalid target %q, must identify a data node", id)
+	}
+	cur, schema := p.tree, p.schema
+	for i, seg := range segs {
+		name, hasKeys := seg.Name, seg.Keys != nil
+		cs := util.FirstChild(schema, []string{util.StripModulePrefix(name)})
+		if cs == nil {
+			return nil, p.errorf(ErrorTagUnknownElement, "unknown data node %s", name)
+		}
+		if j := strings.IndexByte(name, ':'); j >= 0 {
+			mod, err := util.EntryModule(cs)
+			switch {
+			case err != nil:
+				return nil, p.errorf(ErrorTagOperationFailed, "%v", err)
+			case mod != name[:j]:
+				return nil, p.errorf(ErrorTagUnknownNamespace, "data node %s is not defined in module %s", cs.Name, name[:j])
+			}
+		}
+		last := i == len(segs)-1
+		n := &yangPatchNode{parent: cur, schema: cs, index: -1}
+
+		switch {
+		case cs.IsLeaf():
+			if hasKeys || !last {
+				return nil, p.errorf(ErrorTagUnknownElement, "leaf %s cannot be identified by a value, or have children", name)
+			}
+			_, n.exists = cur[cs.Name]
+			return n, nil
+		case cs.IsLeafList():
+			if !last {
+				return nil, p.errorf(ErrorTagUnknownElement, "leaf-list %s cannot have children", name)
+			}
+			_, n.exists = cur[cs.Name]
+			switch {
+			case !hasKeys:
+				return n, nil
+			case len(seg.Keys) != 1:
+				return nil, p.errorf(ErrorTagInvalidValue, "leaf-list %s must be identified by a single value, got %d values", name, len(seg.Keys))
+			}
+			n.entry, n.keys = true, map[string]string{cs.Name: seg.Keys[0]}
+			n.index = p.entryIndex(n, cur[cs.Name])
+			n.exists = n.index != -1
+			return n, nil
+		case cs.IsList():
+			keys := strings.Fields(cs.Key)
+			if len(keys) == 0 {
+				return nil, p.errorf(ErrorTagOperationNotSupported, "entries of keyless list %s cannot be identified", name)
+			}
+			if !hasKeys {
+				return nil, p.errorf(ErrorTagInvalidValue, "entries of list %s must be identified by their keys", name)
+			}
+			if len(seg.Keys) != len(keys) {
+				return nil, p.errorf(ErrorTagInvalidValue, "list %s has keys %v, got %d key values", name, keys, len(seg.Keys))
+			}
+			n.entry, n.keys = true, map[string]string{}
+			for j, k := range keys {
+				n.keys[k] = seg.Keys[j]
+			}
+			n.index = p.entryIndex(n, cur[cs.Name])
+			n.exists = n.index != -1
+			if last {
+				return n, nil
+			}
+			var next map[string]interface{}
+			if n.exists {
+				next = cur[cs.Name].([]interface{})[n.index].(map[string]interface{})
+			}
+			cur, schema = next, cs
+		default:
+			if hasKeys {
+				return nil, p.errorf(ErrorTagInvalidValue, "container %s cannot be identified by a value", name)
+			}
+			_, n.exists = cur[cs.Name]
+			if last {
+				return n, nil
+			}
+			next, ok := cur[cs.Name].(map[string]interface{})
+			if !ok && create && cur != nil {
+				next = map[string]interface{}{}
+				cur[cs.Name] = next
+			}
+			cur, schema = next, cs
+		}
+	}
+	return nil, p.errorf(ErrorTagInvalidValue, "invalid target %q", id)
+}
+
+// entryIndex returns the index of the entry of the list or leaf-list n
+// within the JSON array l, or -1 if there is no such entry.
+func (p *yangPatcher) entryIndex(n *yangPatchNode, l interface{}) int {
+	entries, _ := l.([]interface{})
+	for i, e := range entries {
+		if n.matches(e) {
+			return i
+		}
+	}
+	return -1
+}
+
+// matches returns true if the JSON value e of a list or leaf-list entry has
+// the keys of the entry n.
+func (n *yangPatchNode) matches(e interface{}) bool {
+	equal := func(v interface{}, s string) bool {
+		vs := editValueString(v)
+		return vs == s || util.StripModulePrefix(vs) == util.StripModulePrefix(s)
+	}
+	if n.schema.IsLeafList() {
+		return equal(e, n.keys[n.schema.Name])
+	}
+	m, ok := e.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for k, v := range n.keys {
+		if mv, ok := m[k]; !ok || !equal(mv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// value returns the JSON value of the data node n within the value val of an
+// edit, which must contain only the data node.
+func (p *yangPatcher) value(n *yangPatchNode, val map[string]interface{}) (interface{}, *YANGPatchError) {
+	if n.parent == nil {
+		return nil, p.errorf(ErrorTagDataMissing, "parent of data does not exist")
+	}
+	if len(val) != 1 {
+		return nil, p.errorf(ErrorTagInvalidValue, "value must contain the data node %s only", n.schema.Name)
+	}
+	var v interface{}
+	for k, mv := range val {
+		if util.StripModulePrefix(k) != n.schema.Name {
+			return nil, p.errorf(ErrorTagInvalidValue, "value contains %s, not %s", k, n.schema.Name)
+		}
+		v = unqualifyJSON(mv)
+	}
+	if !n.entry {
+		return v, nil
+	}
+
+	l, ok := v.([]interface{})
+	if !ok || len(l) != 1 {
+		return nil, p.errorf(ErrorTagInvalidValue, "value of entry of %s must be an array containing the entry", n.schema.Name)
+	}
+	if !n.matches(l[0]) {
+		return nil, p.errorf(ErrorTagInvalidValue, "value %v does not match the target", l[0])
+	}
+	return l[0], nil
+}
+
+// set sets the data node n to the JSON value v, which is the value of the
+// entry for list and leaf-list entries. Entries that do not exist are
+// appended to their list.
+func (p *yangPatcher) set(n *yangPatchNode, v interface{}) {
+	if !n.entry {
+		n.parent[n.schema.Name] = v
+		return
+	}
+	l, _ := n.parent[n.schema.Name].([]interface{})
+	if n.exists {
+		l[n.index] = v
+		return
+	}
+	n.parent[n.schema.Name] = append(l, v)
+}
+
+// remove removes the data node n, which exists.
+func (p *yangPatcher) remove(n *yangPatchNode) {
+	if !n.entry {
+		delete(n.parent, n.schema.Name)
+		return
+	}
+	l := n.parent[n.schema.Name].([]interface{})
+	l = append(l[:n.index], l[n.index+1:]...)
+	if len(l) == 0 {
+		delete(n.parent, n.schema.Name)
+		return
+	}
+	n.parent[n.schema.Name] = l
+}
+
+// insert inserts the JSON value v of the entry n, which does not exist, into
+// its list at the position specified by where and point.
+func (p *yangPatcher) insert(n *yangPatchNode, v interface{}, where, point string) *YANGPatchError {
+	l, _ := n.parent[n.schema.Name].([]interface{})
+	var i int
+	switch where {
+	case "first":
+	case "", "last":
+		i = len(l)
+	case "before", "after":
+		if point == "" {
+			return p.errorf(ErrorTagMissingElement, "point must be specified for %s", where)
+		}
+		pn, err := p.resolve(point, false)
+		if err != nil {
+			return err
+		}
+		if !pn.exists || pn.schema != n.schema || !pn.entry || reflect.ValueOf(pn.parent).Pointer() != reflect.ValueOf(n.parent).Pointer() {
+			return p.errorf(ErrorTagInvalidValue, "point %s is not an entry of the same list as the target", point)
+		}
+		i = pn.index
+		if where == "after" {
+			i++
+		}
+	default:
+		return p.errorf(ErrorTagInvalidValue, "invalid where %q", where)
+	}
+	n.parent[n.schema.Name] = append(l[:i], append([]interface{}{v}, l[i:]...)...)
+	return nil
+}
+
+// merge returns the result of merging the JSON value src into the JSON value
+// dst of the data node described by schema.
+func (p *yangPatcher) merge(schema *yang.Entry, dst, src interface{}) (interface{}, *YANGPatchError) {
+	switch {
+	case schema.IsLeaf():
+		return src, nil
+	case schema.IsLeafList():
+		dl, _ := dst.([]interface{})
+		sl, _ := src.([]interface{})
+		for _, sv := range sl {
+			found := false
+			for _, dv := range dl {
+				if editValueString(dv) == editValueString(sv) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				dl = append(dl, sv)
+			}
+		}
+		return dl, nil
+	case schema.IsList() && !isListEntry(dst):
+		dl, _ := dst.([]interface{})
+		sl, _ := src.([]interface{})
+		keys := strings.Fields(schema.Key)
+		for _, sv := range sl {
+			idx := -1
+			if len(keys) != 0 {
+				sm, _ := sv.(map[string]interface{})
+				n := &yangPatchNode{schema: schema, keys: map[string]string{}}
+				for _, k := range keys {
+					n.keys[k] = editValueString(sm[k])
+				}
+				idx = p.entryIndex(n, dl)
+			}
+			if idx == -1 {
+				dl = append(dl, sv)
+				continue
+			}
+			mv, err := p.merge(schema, dl[idx], sv)
+			if err != nil {
+				return nil, err
+			}
+			dl[idx] = mv
+		}
+		return dl, nil
+	}
+
+	dm, ok := dst.(map[string]interface{})
+	sm, ok2 := src.(map[string]interface{})
+	if !ok || !ok2 {
+		return src, nil
+	}
+	for k, sv := range sm {
+		cs := util.FirstChild(schema, []string{k})
+		if cs == nil {
+			return nil, p.errorf(ErrorTagUnknownElement, "unknown data node %s within %s", k, schema.Name)
+		}
+		dv, ok := dm[k]
+		if !ok {
+			dm[k] = sv
+			continue
+		}
+		mv, err := p.merge(cs, dv, sv)
+		if err != nil {
+			return nil, err
+		}
+		dm[k] = mv
+	}
+	return dm, nil
+}
+
+// isListEntry returns true if the JSON value v is a list entry, rather than
+// the array of entries of a list.
+func isListEntry(v interface{}) bool {
+	_, ok := v.(map[string]interface{})
+	return ok
+}
+
+// unqualifyJSON returns a copy of the JSON value v in which the names of the
+// members of objects are not qualified with module names.
+func unqualifyJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, cv := range v {
+			m[util.StripModulePrefix(k)] = unqualifyJSON(cv)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, cv := range v {
+			l[i] = unqualifyJSON(cv)
+		}
+		return l
+	}
+	return v
+}
@@ -0,0 +1,340 @@
+// Copyright 2018 Google Inc.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ytypes
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/openconfig/ygot/internal/encodingtest"
+	"github.com/openconfig/ygot/ygot"
+)
+
+type patchDevice struct {
+	Hostname  *string                       `path:"system/config/hostname"`
+	DNS       []string                      `path:"system/config/dns"`
+	Interface map[string]*patchInterface    `path:"interfaces/interface"`
+	Route     map[patchRouteKey]*patchRoute `path:"routes/route"`
+	Rule      *orderedListElems             `path:"rules/rule"`
+	Server    []*xmlServer                  `path:"servers/server"`
+}
+
+func (*patchDevice) IsYANGGoStruct() {}
+
+type patchInterface struct {
+	Mtu  *uint16 `path:"config/mtu"`
+	Name *string `path:"config/name|name"`
+}
+
+func (*patchInterface) IsYANGGoStruct() {}
+
+func (i *patchInterface) ΛListKeyMap() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": *i.Name}, nil
+}
+
+type patchRouteKey struct {
+	Prefix string `path:"prefix"`
+	Vrf    uint32 `path:"vrf"`
+}
+
+type patchRoute struct {
+	Prefix  *string `path:"prefix"`
+	Vrf     *uint32 `path:"vrf"`
+	NextHop *string `path:"next-hop"`
+}
+
+func (*patchRoute) IsYANGGoStruct() {}
+
+// patchRoutes returns a route list containing routes with the supplied
+// prefix, VRF and next-hop triples.
+func patchRoutes(routes ...interface{}) map[patchRouteKey]*patchRoute {
+	m := map[patchRouteKey]*patchRoute{}
+	for i := 0; i+2 < len(routes); i += 3 {
+		prefix, vrf, nh := routes[i].(string), uint32(routes[i+1].(int)), routes[i+2].(string)
+		m[patchRouteKey{prefix, vrf}] = &patchRoute{Prefix: ygot.String(prefix), Vrf: ygot.Uint32(vrf), NextHop: ygot.String(nh)}
+	}
+	return m
+}
+
+// patchRules returns an ordered rule list containing rules with the supplied
+// keys.
+func patchRules(keys ...string) *orderedListElems {
+	o := &orderedListElems{}
+	for _, k := range keys {
+		o.Append(&orderedListElem{Key: ygot.String(k)})
+	}
+	return o
+}
+
+// yangPatch returns a YANG Patch document containing the supplied edits.
+func yangPatch(edits string) []byte {
+	return []byte(`{"ietf-yang-patch:yang-patch": {"patch-id": "p1", "edit": [` + edits + `]}}`)
+}
+
+func TestApplyYANGPatch(t *testing.T) {
+	tests := []struct {
+		desc        string
+		inRoot      *patchDevice
+		inPatch     []byte
+		want        *patchDevice
+		wantErrTag  string
+		wantErrPath string
+		wantEditID  string
+	}{{
+		desc:    "create leaf with missing ancestors",
+		inRoot:  &patchDevice{},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "create", "target": "/dev:system/config/hostname", "value": {"dev:hostname": "rtr1"}}`),
+		want:    &patchDevice{Hostname: ygot.String("rtr1")},
+	}, {
+		desc:        "create existing leaf",
+		inRoot:      &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "create", "target": "/system/config/hostname", "value": {"hostname": "rtr2"}}`),
+		wantErrTag:  ErrorTagDataExists,
+		wantErrPath: "/system/config/hostname",
+		wantEditID:  "e1",
+	}, {
+		desc:   "merge list entry",
+		inRoot: &patchDevice{Interface: map[string]*patchInterface{"eth0/1": {Name: ygot.String("eth0/1")}}},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "merge", "target": "/interfaces/interface=eth0%2F1",
+			"value": {"interface": [{"name": "eth0/1", "config": {"mtu": 9000}}]}}`),
+		want: &patchDevice{Interface: map[string]*patchInterface{"eth0/1": {Name: ygot.String("eth0/1"), Mtu: ygot.Uint16(9000)}}},
+	}, {
+		desc:   "replace entry of multi-keyed list",
+		inRoot: &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1", "10.0.0.0/8", 1, "192.0.2.1")},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "replace", "target": "/routes/route=10.0.0.0%2F8,42",
+			"value": {"route": [{"prefix": "10.0.0.0/8", "vrf": 42, "next-hop": "192.0.2.2"}]}}`),
+		want: &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.2", "10.0.0.0/8", 1, "192.0.2.1")},
+	}, {
+		desc:   "delete entry of multi-keyed list",
+		inRoot: &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1", "10.0.0.0/8", 1, "192.0.2.1")},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "delete", "target": "/routes/route=10.0.0.0%2F8,1"},
+			{"edit-id": "e2", "operation": "remove", "target": "/routes/route=10.0.0.0%2F8,1"}`),
+		want: &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1")},
+	}, {
+		desc:        "delete missing list entry",
+		inRoot:      &patchDevice{Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1")},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "delete", "target": "/routes/route=10.0.0.0%2F8,1"}`),
+		wantErrTag:  ErrorTagDataMissing,
+		wantErrPath: "/routes/route=10.0.0.0%2F8,1",
+		wantEditID:  "e1",
+	}, {
+		desc:        "wrong number of keys",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "delete", "target": "/routes/route=10.0.0.0%2F8"}`),
+		wantErrTag:  ErrorTagInvalidValue,
+		wantErrPath: "/routes/route=10.0.0.0%2F8",
+		wantEditID:  "e1",
+	}, {
+		desc:        "child of missing list entry",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "merge", "target": "/interfaces/interface=eth0/config/mtu", "value": {"mtu": 1500}}`),
+		wantErrTag:  ErrorTagDataMissing,
+		wantErrPath: "/interfaces/interface=eth0/config/mtu",
+		wantEditID:  "e1",
+	}, {
+		desc:        "value does not match target",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "create", "target": "/interfaces/interface=eth0", "value": {"interface": [{"name": "eth1"}]}}`),
+		wantErrTag:  ErrorTagInvalidValue,
+		wantErrPath: "/interfaces/interface=eth0",
+		wantEditID:  "e1",
+	}, {
+		desc:   "leaf-list entries",
+		inRoot: &patchDevice{DNS: []string{"10.0.0.1", "10.0.0.2"}},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "delete", "target": "/system/config/dns=10.0.0.1"},
+			{"edit-id": "e2", "operation": "insert", "target": "/system/config/dns=10.0.0.3", "where": "first", "value": {"dns": ["10.0.0.3"]}}`),
+		want: &patchDevice{DNS: []string{"10.0.0.3", "10.0.0.2"}},
+	}, {
+		desc:   "insert and move entries of ordered list",
+		inRoot: &patchDevice{Rule: patchRules("a", "b", "c")},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "insert", "target": "/rules/rule=d", "where": "after", "point": "/rules/rule=a", "value": {"rule": [{"key": "d"}]}},
+			{"edit-id": "e2", "operation": "move", "target": "/rules/rule=c", "where": "before", "point": "/rules/rule=a"},
+			{"edit-id": "e3", "operation": "move", "target": "/rules/rule=a", "where": "last"}`),
+		want: &patchDevice{Rule: patchRules("c", "d", "b", "a")},
+	}, {
+		desc:        "insert with point in another list",
+		inRoot:      &patchDevice{Rule: patchRules("a"), Interface: map[string]*patchInterface{"eth0": {Name: ygot.String("eth0")}}},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "insert", "target": "/rules/rule=d", "where": "after", "point": "/interfaces/interface=eth0", "value": {"rule": [{"key": "d"}]}}`),
+		wantErrTag:  ErrorTagInvalidValue,
+		wantErrPath: "/rules/rule=d",
+		wantEditID:  "e1",
+	}, {
+		desc:        "insert into unordered list",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "insert", "target": "/interfaces/interface=eth0", "where": "first", "value": {"interface": [{"name": "eth0"}]}}`),
+		wantErrTag:  ErrorTagOperationNotSupported,
+		wantErrPath: "/interfaces/interface=eth0",
+		wantEditID:  "e1",
+	}, {
+		desc:        "entry of keyless list",
+		inRoot:      &patchDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}}},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "delete", "target": "/servers/server=192.0.2.1"}`),
+		wantErrTag:  ErrorTagOperationNotSupported,
+		wantErrPath: "/servers/server=192.0.2.1",
+		wantEditID:  "e1",
+	}, {
+		desc:    "replace container of keyless list",
+		inRoot:  &patchDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}}},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "replace", "target": "/servers", "value": {"servers": {"server": [{"address": "192.0.2.2"}]}}}`),
+		want:    &patchDevice{Server: []*xmlServer{{Address: ygot.String("192.0.2.2")}}},
+	}, {
+		desc:        "unknown data node",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "remove", "target": "/system/clock"}`),
+		wantErrTag:  ErrorTagUnknownElement,
+		wantErrPath: "/system/clock",
+		wantEditID:  "e1",
+	}, {
+		desc:    "qualified target",
+		inRoot:  &patchDevice{},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "create", "target": "/dev:system/config/hostname", "value": {"dev:hostname": "rtr1"}}`),
+		want:    &patchDevice{Hostname: ygot.String("rtr1")},
+	}, {
+		desc:        "target qualified by another module",
+		inRoot:      &patchDevice{},
+		inPatch:     yangPatch(`{"edit-id": "e1", "operation": "create", "target": "/ext:system/config/hostname", "value": {"hostname": "rtr1"}}`),
+		wantErrTag:  ErrorTagUnknownNamespace,
+		wantErrPath: "/ext:system/config/hostname",
+		wantEditID:  "e1",
+	}, {
+		desc:   "later edit fails",
+		inRoot: &patchDevice{Hostname: ygot.String("rtr1")},
+		inPatch: yangPatch(`{"edit-id": "e1", "operation": "replace", "target": "/system/config/hostname", "value": {"hostname": "rtr2"}},
+			{"edit-id": "e2", "operation": "copy", "target": "/system/config/hostname"}`),
+		wantErrTag:  ErrorTagInvalidValue,
+		wantErrPath: "/system/config/hostname",
+		wantEditID:  "e2",
+	}, {
+		desc:       "invalid value",
+		inRoot:     &patchDevice{},
+		inPatch:    yangPatch(`{"edit-id": "e1", "operation": "merge", "target": "/interfaces/interface=eth0", "value": {"interface": [{"name": "eth0", "config": {"mtu": "jumbo"}}]}}`),
+		wantErrTag: ErrorTagInvalidValue,
+	}, {
+		desc:       "malformed document",
+		inRoot:     &patchDevice{},
+		inPatch:    []byte(`{"yang-patch": {}}`),
+		wantErrTag: ErrorTagMalformedMessage,
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			orig, err := ygot.DeepCopy(tt.inRoot)
+			if err != nil {
+				t.Fatalf("DeepCopy: got unexpected error: %v", err)
+			}
+
+			err = ApplyYANGPatch(encodingtest.Schema(), tt.inRoot, tt.inPatch)
+			if tt.wantErrTag != "" {
+				perr, ok := err.(*YANGPatchError)
+				if !ok {
+					t.Fatalf("ApplyYANGPatch: got error %v, want *YANGPatchError with tag %s", err, tt.wantErrTag)
+				}
+				if perr.Tag != tt.wantErrTag || perr.Path != tt.wantErrPath || perr.EditID != tt.wantEditID {
+					t.Errorf("ApplyYANGPatch: got error tag %s at %s in edit %q, want: %s at %s in edit %q", perr.Tag, perr.Path, perr.EditID, tt.wantErrTag, tt.wantErrPath, tt.wantEditID)
+				}
+				if diff := cmp.Diff(orig, tt.inRoot, cmp.AllowUnexported(orderedListElems{})); diff != "" {
+					t.Errorf("ApplyYANGPatch: root was modified by failed patch, diff(-want, +got):\n%s", diff)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ApplyYANGPatch: got unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, tt.inRoot, cmp.AllowUnexported(orderedListElems{})); diff != "" {
+				t.Errorf("ApplyYANGPatch: did not get expected struct, diff(-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+// TestPatchRoundTrip checks that the patches rendered by ygot transform the
+// original struct into the modified struct when they are applied.
+func TestPatchRoundTrip(t *testing.T) {
+	tests := []struct {
+		desc          string
+		inOrig, inMod *patchDevice
+	}{{
+		desc:   "empty to populated",
+		inOrig: &patchDevice{},
+		inMod: &patchDevice{
+			Hostname:  ygot.String("rtr1"),
+			DNS:       []string{"10.0.0.1"},
+			Interface: map[string]*patchInterface{"eth0": {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500)}},
+			Route:     patchRoutes("10.0.0.0/8", 42, "192.0.2.1"),
+			Rule:      patchRules("a", "b"),
+			Server:    []*xmlServer{{Address: ygot.String("192.0.2.1")}},
+		},
+	}, {
+		desc: "changes to all lists",
+		inOrig: &patchDevice{
+			Hostname: ygot.String("rtr1"),
+			DNS:      []string{"10.0.0.1", "10.0.0.2"},
+			Interface: map[string]*patchInterface{
+				"eth0":   {Name: ygot.String("eth0"), Mtu: ygot.Uint16(1500)},
+				"eth1/0": {Name: ygot.String("eth1/0")},
+			},
+			Route:  patchRoutes("10.0.0.0/8", 42, "192.0.2.1", "10.0.0.0/8", 1, "192.0.2.1", "0.0.0.0/0", 1, "192.0.2.9"),
+			Rule:   patchRules("a", "b", "c", "d"),
+			Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}},
+		},
+		inMod: &patchDevice{
+			DNS: []string{"10.0.0.2", "10.0.0.3"},
+			Interface: map[string]*patchInterface{
+				"eth0":   {Name: ygot.String("eth0"), Mtu: ygot.Uint16(9000)},
+				"eth2,3": {Name: ygot.String("eth2,3")},
+			},
+			Route:  patchRoutes("10.0.0.0/8", 42, "192.0.2.2", "0.0.0.0/0", 1, "192.0.2.9", "0.0.0.0/0", 2, "192.0.2.9"),
+			Rule:   patchRules("d", "e", "b", "a"),
+			Server: []*xmlServer{{Address: ygot.String("192.0.2.1")}, {Address: ygot.String("192.0.2.2")}},
+		},
+	}, {
+		desc:   "populated to empty",
+		inOrig: &patchDevice{Hostname: ygot.String("rtr1"), Rule: patchRules("a"), Route: patchRoutes("10.0.0.0/8", 42, "192.0.2.1")},
+		inMod:  &patchDevice{},
+	}}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			for name, apply := range map[string]func(root *patchDevice) error{
+				"YANG Patch": func(root *patchDevice) error {
+					p, err := ygot.DiffToYANGPatch(tt.inOrig, tt.inMod, "p1")
+					if err != nil {
+						return err
+					}
+					return ApplyYANGPatch(encodingtest.Schema(), root, p)
+				},
+				"JSON Patch": func(root *patchDevice) error {
+					p, err := ygot.DiffToJSONPatch(tt.inOrig, tt.inMod)
+					if err != nil {
+						return err
+					}
+					return ApplyJSONPatch(encodingtest.Schema(), root, p)
+				},
+			} {
+				c, err := ygot.DeepCopy(tt.inOrig)
+				if err != nil {
+					t.Fatalf("DeepCopy: got unexpected error: %v", err)
+				}
+				root := c.(*patchDevice)
+				if err := apply(root); err != nil {
+					t.Fatalf("%s: got unexpected error: %v", name, err)
+				}
+				if diff := cmp.Diff(tt.inMod, root, cmp.AllowUnexported(orderedListElems{})); diff != "" {
+					t.Errorf("%s: did not get modified struct, diff(-want, +got):\n%s", name, diff)
+				}
+			}
+		})
+	}
+}